@@ -0,0 +1,131 @@
+// Command csvbacktest backtests 5m candles from a CSV file using only the public packages
+//
+// The file holds one candle per row as open_time,open,high,low,close,volume, the open time
+// in unix milliseconds or RFC3339; a header row is skipped:
+//
+//	go run ./examples/csvbacktest -file BTCUSDT-5m.csv -symbol BTCUSDT
+package main
+
+import (
+	"CryptoTradeBot/pkg/backtest"
+	"CryptoTradeBot/pkg/strategy"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+func main() {
+	file := flag.String("file", "", "CSV file of 5m candles")
+	symbol := flag.String("symbol", "BTCUSDT", "Symbol the candles belong to")
+	config := flag.String("strategy-config", "", "Strategy config file, the built-in parameters when empty")
+	timing := flag.String("execution-timing", string(backtest.TimingNextOpen), "'next_open' or 'close'")
+	flag.Parse()
+
+	if *file == "" {
+		log.Fatal("Usage: csvbacktest -file candles.csv [-symbol BTCUSDT] [-strategy-config file]")
+	}
+
+	prices, err := readCandles(*file, *symbol)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	strategies, err := strategy.LoadStrategyManager(*config, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	settings := backtest.DefaultConfig()
+	if settings.Timing, err = backtest.ParseExecutionTiming(*timing); err != nil {
+		log.Fatal(err)
+	}
+
+	// The first candles only warm the strategies up
+	interval := 5 * time.Minute
+	warmUp := time.Duration(strategies.WindowCandles(backtest.BaseTimeFrame)) * interval
+	start := prices[0].OpenTime.Add(warmUp)
+	end := prices[len(prices)-1].OpenTime
+	if !start.Before(end) {
+		log.Fatalf("Need more than %s of candles to warm up", warmUp)
+	}
+
+	engine := backtest.NewEngine(backtest.NewSliceSource(prices), strategies, settings)
+	results, err := engine.RunBacktest(start, end, []string{*symbol})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Total Trades: %d\n", results.TotalTrades)
+	fmt.Printf("Win Rate: %.2f%%\n", results.WinRate*100)
+	fmt.Printf("Profit Factor: %.2f\n", backtest.ProfitFactor(results.Trades))
+	fmt.Printf("Max Drawdown: %.2f%%\n", results.MaxDrawdown*100)
+	fmt.Printf("Final Balance: %.2f\n", results.FinalBalance)
+}
+
+// readCandles parses the CSV at path into 5m candles of symbol
+func readCandles(path, symbol string) ([]backtest.Price, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = 6
+
+	var prices []backtest.Price
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		openTime, err := parseTime(record[0])
+		if err != nil {
+			if line == 1 {
+				continue // Header
+			}
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		var values [5]float64
+		for i := range values {
+			if values[i], err = strconv.ParseFloat(record[i+1], 64); err != nil {
+				return nil, fmt.Errorf("line %d: %v", line, err)
+			}
+		}
+
+		prices = append(prices, backtest.Price{
+			Symbol:    symbol,
+			TimeFrame: backtest.BaseTimeFrame,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    values[4],
+		})
+	}
+
+	if len(prices) == 0 {
+		return nil, fmt.Errorf("no candles in %s", path)
+	}
+	return prices, nil
+}
+
+// parseTime reads unix milliseconds or RFC3339
+func parseTime(value string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -2,8 +2,12 @@ package backtesting
 
 import (
 	"CryptoTradeBot/internal/models"
-	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/provenance"
 	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
 	"log"
 	"math"
 	"sort"
@@ -28,6 +32,47 @@ type Trade struct {
 	TakeProfit float64
 	PnL        float64
 	Reason     string
+
+	// PnL in units of the loss the initial stop stood for, nil without a usable initial stop
+	RMultiple *float64
+
+	InitialStopDistance float64
+	LiquidationPrice    float64
+	Confidence          float64
+	Confluence          analysis.Confluence // Per-timeframe breakdown of the entry signal
+	RiskMultiplier      float64             // Factor FixedSize was scaled by for the streak at entry
+
+	// Factor FixedSize was scaled by for the signal's confidence, 1 unless analysis.Config.ConfidenceSizing is set
+	ConfidenceMultiplier float64
+
+	// Margin put up in USDT, FixedSize scaled by RiskMultiplier and ConfidenceMultiplier and bounded by
+	// the sizing guards; 0 for trades built from live positions, see margin
+	Margin        float64
+	RequestedSize float64 // Size before the sizing guards
+	SizeGuard     string  // Guard that changed the size, see trading.SizingConfig
+
+	// Leveraged notional the entry wanted after the sizing guards and the part its candles' volume let fill,
+	// and the candles it filled over; all 0 unless Config.Liquidity is set, see LiquidityConfig
+	WantedNotional float64
+	FilledNotional float64
+	FillCandles    int
+
+	fillsLeft int // Later candles the rest may still fill over, see fillRest
+
+	// Furthest price went against (MAE) and in favor of (MFE) the trade while open,
+	// as price distances from entry and in initial stop distances
+	MAE  float64
+	MFE  float64
+	MAER float64
+	MFER float64
+
+	StopChanges int // Times the strategy moved the stop or target while open, see StrategyManager.ManagePosition
+
+	FromReversal bool    // Opened by reversing the previous position
+	HeldPnL      float64 // For reversal closes, the PnL had the position been held, see ReversalStats
+
+	// Analysis snapshot at entry, the same one live positions store
+	EntryContext *analysis.EntryContext
 }
 
 type EquityPoint struct {
@@ -41,193 +86,473 @@ type BacktestResults struct {
 	LosingTrades  int
 	WinRate       float64
 	AveragePnL    float64
-	MaxDrawdown   float64
+	MaxDrawdown   float64 // Deepest fall of every equity point below its running peak, as a fraction of it
 	FinalBalance  float64
 	SharpeRatio   float64
 	Trades        []Trade
 	EquityCurve   []EquityPoint
+
+	// Sortino and Calmar next to SharpeRatio, all three from the equity resampled to daily closes, see Ratios
+	// Calmar so divides by the drawdown of the daily closes, which misses intraday lows MaxDrawdown counts
+	SortinoRatio       float64
+	CalmarRatio        float64
+	RatioDays          int  // Daily returns the ratios come from
+	RatiosInsufficient bool // Fewer than RatioConfig.MinDays daily returns, the ratios are left at 0
+
+	// Entry order statistics, signals equal fills for market entries at the close
+	Signals  int
+	Fills    int
+	FillRate float64
+	GapSkips int // Signals skipped because the next open gapped beyond Config.GapTolerance
+
+	Liquidations int // Trades force-closed at their liquidation price, included in LosingTrades
+
+	// Stop and target moves made for the strategy while trades were open, and those failing validation
+	StopChanges         int
+	RejectedStopChanges int
+
+	// Candles that reached both the take profit and the stop loss, resolved by Config.SameBar
+	AmbiguousBars int
+	ProbedBars    int // Ambiguous bars the 1m candles resolved, the rest fell back to worst case
+
+	// Symbol performance suspensions, zero unless Config.Performance is set
+	Suspensions      int
+	SuspendedSignals int
+
+	// Account equity stop-outs and the signals skipped while stopped, zero unless Config.EquityStop is set
+	EquityStopOuts    int
+	EquityStopSignals int
+
+	// Signals skipped because the day's trade caps were reached, zero unless Config.Frequency is set
+	ThrottledSignals int
+
+	// Signals not traded because their entry, stop and target failed strategy.ValidateResult
+	InvalidSignals int
+
+	// Fills not taken because the sizing guards rejected their size, zero unless Config.Sizing is set;
+	// trades whose size a guard changed carry it in SizeGuard
+	SizeRejections int
+
+	// Fill shortfall of the entries capped by candle volume, and the fills not taken because their
+	// candle traded nothing; zero unless Config.Liquidity is set
+	Liquidity      LiquidityStats
+	LiquiditySkips int
+
+	Seed int64 // Config.Seed of the run, to reproduce it
+
+	Excursions ExcursionStats
+
+	// Trade outcomes in R, trades without a usable initial stop counted as excluded
+	R trading.RStats
+
+	// Performance by the confidence trades were entered at, see trading.ConfidenceBucketEdges
+	Confidence []trading.ConfidenceBucket
+
+	Reversals ReversalStats
+
+	// Symbols left out because their history does not reach back over the period and its warm-up
+	Skipped []SkippedSymbol
+
+	// Build and settings that produced the run, nil for runs saved before it was recorded
+	Provenance *provenance.Snapshot `json:",omitempty"`
+}
+
+// Config holds the backtest execution settings
+type Config struct {
+	Entry    trading.EntryConfig
+	Reversal trading.ReversalConfig
+
+	// Performance suspends entries on losing symbols like live trading does, nil to disable
+	Performance *risk.PerformanceConfig
+
+	// Scaling sizes trades by the win or loss streak like live trading does, nil for fixed size
+	Scaling *risk.ScalingConfig
+
+	// EquityStop flattens and stops entering once equity falls through its floor like live trading does, nil to disable
+	EquityStop *risk.EquityStopConfig
+
+	// Frequency caps the positions opened per UTC day like live trading does, zero caps are off
+	Frequency risk.FrequencyConfig
+
+	// Sizing bounds each position's size like live trading does, nil for the unbounded fixed size
+	Sizing *trading.SizingConfig
+
+	// BreakevenAtR moves the stop to entry like live trading does, see trading.TradeConfig.BreakevenAtR
+	BreakevenAtR float64
+
+	// Liquidity caps each fill at a share of its candle's volume, nil to fill entries in full
+	Liquidity *LiquidityConfig
+
+	// Universe limits entries to the symbols selected for trading at the time, nil to trade every symbol
+	Universe *Universe
+
+	// Direction limits entries to one side, models.PositionSideLong or models.PositionSideShort,
+	// or allows both with strategy.DirectionBoth. Reversals only run when both sides trade
+	Direction string
+
+	// Timing is when market entries fill; with TimingNextOpen a signal whose next open moved more
+	// than GapTolerance from the signal close is skipped
+	Timing       ExecutionTiming
+	GapTolerance float64
+
+	SameBar        SameBarPolicy // Exit taken when a candle reaches both take profit and stop loss
+	ExitSlippage   float64       // Fraction of the stop price a stop loss fills worse by
+	SlippageJitter float64       // Up to this fraction more slippage, drawn at random per stop loss
+
+	// Seed drives every random choice, runs with the same seed and settings are identical
+	Seed int64
+
+	// Ratios tunes the Sharpe, Sortino and Calmar ratios of the results
+	Ratios RatioConfig
+}
+
+// DefaultConfig returns the default backtest settings
+func DefaultConfig() Config {
+	return Config{
+		Entry:    trading.DefaultEntryConfig(),
+		Reversal: trading.DefaultReversalConfig(),
+
+		Direction: strategy.DirectionBoth,
+
+		Timing:       TimingNextOpen,
+		GapTolerance: DefaultGapTolerance,
+
+		SameBar:      SameBarWorstCase,
+		ExitSlippage: DefaultExitSlippage,
+
+		Seed: DefaultSeed,
+
+		Ratios: DefaultRatioConfig(),
+
+		BreakevenAtR: trading.DefaultBreakevenAtR,
+	}
 }
 
 type Backtest struct {
-	priceRepo      *repositories.PriceRepository
-	analysis       *analysis.Analysis
+	source         PriceSource
+	strategies     *strategy.StrategyManager
+	config         Config
 	currentBalance float64
-	maxBalance     float64
 	trades         []Trade
 	equityCurve    []EquityPoint
+	phaseOrder     []Phase
+	hooks          map[Phase][]PhaseHook
+	signals        int
+	fills          int
+	gapSkips       int
+	warmUp         map[string]int // Candles each timeframe needs before analysis
+	window         int            // Base candles passed to the strategy, derived from warmUp
+	reversalCount  map[string]int // Reversals per symbol and UTC day
+	liquidations   int
+	ambiguousBars  int
+	probedBars     int
+	random         *Random
+	held           map[string][]*heldLeg // Reversed positions followed as if held, per symbol
+	unresolvedHeld int
+
+	suspendedUntil   map[string]time.Time // End of each symbol's latest suspension
+	suspensions      int
+	suspendedSignals int
+
+	accountStop       *risk.EquityStop // Account-wide, see equityStop
+	equityStopOuts    int
+	equityStopSignals int
+
+	opened           map[time.Time]int // Positions opened per UTC day, see throttled
+	openedBySymbol   map[string]int    // Likewise per symbol and day, see tradeDayKey
+	throttledSignals int
+	invalidSignals   int
+	sizeRejections   int
+	liquiditySkips   int
+
+	skipped []SkippedSymbol // Symbols whose history does not cover the run, see checkHistory
+
+	stopChanges         int // See manageStops
+	rejectedStopChanges int
+
+	progress func(Progress) // Set by OnProgress, nil to not report
+
+	runs       []*symbolRun    // Symbols being stepped through, see replay
+	ctx        context.Context // Of the run, bounds the source queries made mid-replay such as probeFirstHit
+	stepClosed bool            // Whether a position closed at the current step
 }
 
-func NewBacktest(priceRepo *repositories.PriceRepository, analysis *analysis.Analysis) *Backtest {
+func NewBacktest(source PriceSource, strategies *strategy.StrategyManager) *Backtest {
+	return NewBacktestWithConfig(source, strategies, DefaultConfig())
+}
+
+// NewBacktestWithConfig creates a Backtest replaying the candles of source with the given settings
+func NewBacktestWithConfig(source PriceSource, strategies *strategy.StrategyManager, config Config) *Backtest {
+	warmUp := strategies.WarmUp()
+
 	return &Backtest{
-		priceRepo:      priceRepo,
-		analysis:       analysis,
+		source:         source,
+		strategies:     strategies,
+		config:         config,
+		warmUp:         warmUp,
+		window:         strategy.WindowCandles(warmUp, BaseTimeFrame),
 		currentBalance: InitialBalance,
-		maxBalance:     InitialBalance,
 		trades:         make([]Trade, 0),
 		equityCurve:    make([]EquityPoint, 0),
+		phaseOrder:     DefaultPhaseOrder,
+		hooks:          make(map[Phase][]PhaseHook),
+		reversalCount:  make(map[string]int),
+		opened:         make(map[time.Time]int),
+		openedBySymbol: make(map[string]int),
+		suspendedUntil: make(map[string]time.Time),
+		random:         NewRandom(config.Seed),
+		held:           make(map[string][]*heldLeg),
 	}
 }
 
 func (b *Backtest) RunBacktest(startTime, endTime time.Time, symbols []string) (*BacktestResults, error) {
+	return b.RunBacktestContext(context.Background(), startTime, endTime, symbols)
+}
+
+// RunBacktestContext is RunBacktest stopping with ctx's error once ctx is cancelled
+func (b *Backtest) RunBacktestContext(ctx context.Context, startTime, endTime time.Time, symbols []string) (*BacktestResults, error) {
 	log.Printf("Running backtest from %s to %s",
 		startTime.Format("2006-01-02 15:04:05"),
 		endTime.Format("2006-01-02 15:04:05"))
+	b.ctx = ctx
 
+	tracker := newProgressTracker(b.progress, startTime, endTime, len(symbols))
+	runs := make([]*symbolRun, 0, len(symbols))
 	for _, symbol := range symbols {
-		log.Printf("Processing %s...", symbol)
-		if err := b.runSymbol(symbol, startTime, endTime); err != nil {
+		skipped, err := b.checkHistory(symbol, startTime)
+		if err != nil {
 			return nil, err
 		}
-	}
-
-	results := b.calculateResults()
-	log.Printf("Processed %d days of data", int(endTime.Sub(startTime).Hours()/24))
-
-	return results, nil
-}
-
-func (b *Backtest) runSymbol(symbol string, startTime, endTime time.Time) error {
-	// Get all prices for the period
-	prices, err := b.priceRepo.GetPricesByTimeFrame(symbol, models.PriceTimeFrame5m, startTime, endTime)
-	if err != nil {
-		return err
-	}
-
-	if len(prices) < 200 {
-		log.Printf("Not enough data for %s, skipping", symbol)
-		return nil
-	}
-
-	// Sort prices by time to ensure chronological order
-	sort.Slice(prices, func(i, j int) bool {
-		return prices[i].OpenTime.Before(prices[j].OpenTime)
-	})
-
-	var activePosition *Trade
-
-	// Process each candle for the entire period
-	for i := 200; i < len(prices); i++ {
-		currentPrice := prices[i]
-
-		// Skip if outside our date range
-		if currentPrice.OpenTime.Before(startTime) || currentPrice.OpenTime.After(endTime) {
+		if skipped != nil {
+			log.Printf("Skipping %s: %s", symbol, skipped.Reason)
+			b.skipped = append(b.skipped, *skipped)
+			tracker.symbolDone(symbol)
 			continue
 		}
 
-		if activePosition != nil {
-			if b.shouldExitPosition(activePosition, currentPrice) {
-				reason := b.getExitReason(activePosition, currentPrice)
-				b.closePosition(activePosition, currentPrice, reason)
-				activePosition = nil
-			}
-			continue
+		daily, err := b.loadDaily(ctx, symbol, startTime, endTime)
+		if err != nil {
+			return nil, err
 		}
+		// Stream the period plus the warm-up history before it, keeping only the analysis window
+		runs = append(runs, &symbolRun{
+			symbol: symbol,
+			stream: newCandleStream(b.source, symbol, b.warmUpStart(startTime), endTime),
+			window: newCandleWindow(b.window),
+			daily:  daily,
+			first:  -1,
+		})
+	}
 
-		// Analysis window
-		analysisWindow := prices[i-200 : i+1]
-		result := b.analysis.Analyze(analysisWindow)
-
-		if result.IsValid {
-			activePosition = b.openPosition(result, currentPrice)
+	log.Printf("Processing %d symbols...", len(runs))
+	if err := b.replay(ctx, runs, startTime, tracker); err != nil {
+		return nil, err
+	}
+	for _, run := range runs {
+		b.finishSymbol(run)
+		if !run.done {
+			tracker.symbolDone(run.symbol)
 		}
 	}
 
-	return nil
-}
+	results := b.calculateResults(startTime, endTime)
+	log.Printf("Processed %d days of data", int(endTime.Sub(startTime).Hours()/24))
 
-func (b *Backtest) shouldExitPosition(trade *Trade, price models.Price) bool {
-	if trade.Side == "long" {
-		return price.High >= trade.TakeProfit || price.Low <= trade.StopLoss
-	}
-	return price.Low <= trade.TakeProfit || price.High >= trade.StopLoss
+	return results, nil
 }
 
-func (b *Backtest) getExitReason(trade *Trade, price models.Price) string {
-	if trade.Side == "long" {
-		if price.High >= trade.TakeProfit {
-			return "take_profit"
-		}
-		if price.Low <= trade.StopLoss {
-			return "stop_loss"
-		}
-	} else {
-		if price.Low <= trade.TakeProfit {
-			return "take_profit"
-		}
-		if price.High >= trade.StopLoss {
-			return "stop_loss"
-		}
+// openPosition opens result's position at entryPrice, nil when the sizing guards reject it
+func (b *Backtest) openPosition(result *analysis.AnalysisResult, entryPrice float64, candle models.Price) *Trade {
+	entryTime := candle.OpenTime
+	multiplier, confidence := b.riskMultiplier(entryTime), result.SizeFactor()
+	wanted, decision, ok := b.guardSize(result, entryPrice, entryTime, FixedSize*multiplier*confidence)
+	if !ok {
+		return nil
+	}
+	margin, rest := b.capLiquidity(wanted, candle)
+	if margin <= 0 {
+		log.Printf("%s %s at %s not opened: its candle traded no volume",
+			result.Symbol, result.Direction, entryTime.Format("2006-01-02 15:04"))
+		b.liquiditySkips++
+		return nil
+	}
+	size := margin / entryPrice // Convert the margin to asset quantity
+	b.fills++
+	b.countOpened(result.Symbol, entryTime)
+
+	liquidationPrice := trading.PositionLiquidationPrice(result.Symbol, result.Direction, entryPrice, size*Leverage, Leverage)
+	if trading.StopBeyondLiquidation(result.Direction, result.StopLoss, liquidationPrice) {
+		log.Printf("Warning: %s %s at %s has its stop %.8f beyond liquidation %.8f",
+			result.Symbol, result.Direction, entryTime.Format("2006-01-02 15:04"), result.StopLoss, liquidationPrice)
 	}
-	return "unknown"
-}
-
-func (b *Backtest) openPosition(result *analysis.AnalysisResult, price models.Price) *Trade {
-	size := FixedSize / price.Close // Convert $10 to asset quantity
 
-	return &Trade{
+	trade := &Trade{
 		Symbol:     result.Symbol,
-		EntryTime:  price.OpenTime,
+		EntryTime:  entryTime,
 		Side:       result.Direction,
-		EntryPrice: price.Close,
+		EntryPrice: entryPrice,
 		Size:       size,
 		StopLoss:   result.StopLoss,
 		TakeProfit: result.TakeProfit,
+
+		InitialStopDistance: trading.InitialStopDistance(entryPrice, result.StopLoss),
+		LiquidationPrice:    liquidationPrice,
+		Confidence:          result.Confidence,
+		Confluence:          result.Confluence,
+		RiskMultiplier:      multiplier,
+		Margin:              margin,
+		RequestedSize:       decision.Requested / Leverage,
+		SizeGuard:           decision.Reason,
+		EntryContext:        result.EntryContext(),
+
+		ConfidenceMultiplier: confidence,
 	}
+	if b.config.Liquidity != nil {
+		trade.WantedNotional = wanted * Leverage
+		trade.FilledNotional = margin * Leverage
+		trade.FillCandles = 1
+		if rest > 0 {
+			trade.fillsLeft = b.config.Liquidity.MaxCandles
+		}
+	}
+	return trade
+}
+
+// applyBreakeven moves the stop to entry once the candle's best price reaches Config.BreakevenAtR
+// It runs after the exit check so the new stop only applies from the next candle
+func (b *Backtest) applyBreakeven(trade *Trade, price models.Price) {
+	if b.config.BreakevenAtR == 0 {
+		return
+	}
+	bestPrice := price.High
+	if trade.Side == "short" {
+		bestPrice = price.Low
+	}
+
+	trade.StopLoss = trading.BreakevenStop(trade.Side, trade.EntryPrice, trade.StopLoss,
+		trade.InitialStopDistance, bestPrice, b.config.BreakevenAtR)
 }
 
-func (b *Backtest) closePosition(trade *Trade, price models.Price, reason string) {
+func (b *Backtest) closePosition(trade *Trade, price models.Price, exitPrice float64, reason string) {
 	trade.ExitTime = price.OpenTime
-	trade.ExitPrice = price.Close
+	trade.ExitPrice = exitPrice
 	trade.Reason = reason
+	trade.trackExcursion(price.Low, price.High, trade.StopLoss, trade.TakeProfit, exitPrice)
 
-	// Calculate PnL
+	trade.PnL = tradePnL(trade, exitPrice)
+	trade.recordRMultiple()
+
+	b.updateBalance(trade.PnL)
+	b.trades = append(b.trades, *trade)
+}
+
+// tradePnL returns the PnL in USDT of trade exiting at exitPrice: its margin times leverage times the move
+func tradePnL(trade *Trade, exitPrice float64) float64 {
 	var pnlPercentage float64
 	if trade.Side == "long" {
-		pnlPercentage = (trade.ExitPrice - trade.EntryPrice) / trade.EntryPrice
+		pnlPercentage = (exitPrice - trade.EntryPrice) / trade.EntryPrice
 	} else {
-		pnlPercentage = (trade.EntryPrice - trade.ExitPrice) / trade.EntryPrice
+		pnlPercentage = (trade.EntryPrice - exitPrice) / trade.EntryPrice
 	}
 
-	// Calculate PnL in USDT (Fixed $10 position * leverage * percentage gain/loss)
-	trade.PnL = FixedSize * pnlPercentage * float64(Leverage)
+	// Fixed $10 position * leverage * percentage gain/loss
+	return trade.margin() * pnlPercentage * float64(Leverage)
+}
 
+// liquidate force-closes a trade at its liquidation price, losing the full margin
+func (b *Backtest) liquidate(trade *Trade, price models.Price) {
+	trade.ExitTime = price.OpenTime
+	trade.ExitPrice = trade.LiquidationPrice
+	trade.Reason = trading.CloseReasonLiquidation
+	trade.trackExcursion(price.Low, price.High, trade.LiquidationPrice, trade.TakeProfit)
+	trade.PnL = -trade.margin()
+	trade.recordRMultiple()
+
+	b.liquidations++
 	b.updateBalance(trade.PnL)
 	b.trades = append(b.trades, *trade)
-	b.equityCurve = append(b.equityCurve, EquityPoint{
-		Timestamp: price.OpenTime,
-		Balance:   b.currentBalance,
+}
+
+// riskMultiplier returns the size factor for an entry at entryTime from the streak of trades closed by then
+// The streak spans every symbol, as symbols share one clock and one balance
+func (b *Backtest) riskMultiplier(entryTime time.Time) float64 {
+	if b.config.Scaling == nil {
+		return 1
+	}
+
+	// Newest first, trades closing at the same step keep the one processed last first
+	closed := make([]Trade, 0, len(b.trades))
+	for i := len(b.trades) - 1; i >= 0; i-- {
+		if !b.trades[i].ExitTime.After(entryTime) {
+			closed = append(closed, b.trades[i])
+		}
+	}
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closed[i].ExitTime.After(closed[j].ExitTime)
 	})
+	if n := b.config.Scaling.Lookback(); len(closed) > n {
+		closed = closed[:n]
+	}
+
+	pnls := make([]float64, len(closed))
+	for i, trade := range closed {
+		pnls[i] = trade.PnL
+	}
+	return b.config.Scaling.Multiplier(risk.Streak(pnls))
 }
 
 func (b *Backtest) updateBalance(pnl float64) {
-	b.currentBalance += pnl
-	if b.currentBalance > b.maxBalance {
-		b.maxBalance = b.currentBalance
-	}
+	b.currentBalance = models.RoundAmount(b.currentBalance + models.RoundAmount(pnl))
 	if b.currentBalance < 0 {
 		b.currentBalance = 0
 	}
 }
 
-func (b *Backtest) calculateResults() *BacktestResults {
+// calculateResults totals the run over startTime to endTime
+func (b *Backtest) calculateResults(startTime, endTime time.Time) *BacktestResults {
 	results := &BacktestResults{
 		TotalTrades:  len(b.trades),
 		FinalBalance: b.currentBalance,
 		Trades:       b.trades,
 		EquityCurve:  b.equityCurve,
+		Skipped:      b.skipped,
+		Provenance:   b.provenance(),
 	}
 
 	var totalPnL float64
-	returns := make([]float64, len(b.trades))
-
-	for i, trade := range b.trades {
+	for _, trade := range b.trades {
 		if trade.PnL > 0 {
 			results.WinningTrades++
 		} else {
 			results.LosingTrades++
 		}
 		totalPnL += trade.PnL
-		returns[i] = trade.PnL / InitialBalance
+	}
+
+	results.Signals = b.signals
+	results.Fills = b.fills
+	results.GapSkips = b.gapSkips
+	results.Liquidations = b.liquidations
+	results.StopChanges = b.stopChanges
+	results.RejectedStopChanges = b.rejectedStopChanges
+	results.AmbiguousBars = b.ambiguousBars
+	results.ProbedBars = b.probedBars
+	results.Suspensions = b.suspensions
+	results.SuspendedSignals = b.suspendedSignals
+	results.EquityStopOuts = b.equityStopOuts
+	results.EquityStopSignals = b.equityStopSignals
+	results.ThrottledSignals = b.throttledSignals
+	results.InvalidSignals = b.invalidSignals
+	results.SizeRejections = b.sizeRejections
+	results.Liquidity = LiquidityOf(b.trades)
+	results.LiquiditySkips = b.liquiditySkips
+	results.Seed = b.random.Seed()
+	results.Excursions = Excursions(b.trades)
+	results.R = RStatsOf(b.trades)
+	results.Confidence = ConfidenceBucketsOf(b.trades)
+	results.Reversals = Reversals(b.trades, b.unresolvedHeld)
+	if b.signals > 0 {
+		results.FillRate = float64(b.fills) / float64(b.signals)
 	}
 
 	if results.TotalTrades > 0 {
@@ -235,43 +560,25 @@ func (b *Backtest) calculateResults() *BacktestResults {
 		results.AveragePnL = totalPnL / float64(results.TotalTrades)
 	}
 
-	results.MaxDrawdown = b.calculateMaxDrawdown()
-	if len(returns) > 1 {
-		results.SharpeRatio = b.calculateSharpeRatio(returns)
-	}
+	results.MaxDrawdown = calculateMaxDrawdown(b.equityCurve, InitialBalance)
+	results.measureRatios(InitialBalance, startTime, endTime, b.config.Ratios)
 
 	return results
 }
 
-func (b *Backtest) calculateMaxDrawdown() float64 {
-	if b.maxBalance == 0 {
-		return 0
-	}
-
-	maxDrawdown := 0.0
-	for _, point := range b.equityCurve {
-		drawdown := (b.maxBalance - point.Balance) / b.maxBalance
-		maxDrawdown = math.Max(maxDrawdown, drawdown)
+// calculateMaxDrawdown returns the deepest fall of the equity curve below its running peak, as a fraction
+// of that peak, the peak starting at initial
+func calculateMaxDrawdown(curve []EquityPoint, initial float64) float64 {
+	peak, maxDrawdown := initial, 0.0
+	for _, point := range curve {
+		peak = math.Max(peak, point.Balance)
+		if peak > 0 {
+			maxDrawdown = math.Max(maxDrawdown, (peak-point.Balance)/peak)
+		}
 	}
-
 	return maxDrawdown
 }
 
-func (b *Backtest) calculateSharpeRatio(returns []float64) float64 {
-	if len(returns) < 2 {
-		return 0
-	}
-
-	avgReturn := average(returns)
-	stdDev := standardDeviation(returns, avgReturn)
-
-	if stdDev == 0 {
-		return 0
-	}
-
-	return (avgReturn * math.Sqrt(252)) / stdDev
-}
-
 func average(values []float64) float64 {
 	if len(values) == 0 {
 		return 0
@@ -0,0 +1,117 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBreakevenExitsAtEntryAfterOneR(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	trade := openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 103)
+	state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+	// +1R, then back through entry down to the original stop
+	trades := stepExits(b, state,
+		candle("BTCUSDT", 1, 100, 101, 99.5, 100.8),
+		candle("BTCUSDT", 2, 100.8, 100.9, 99, 99.2),
+	)
+
+	if len(trades) != 1 {
+		t.Fatalf("got %d closed trades, want 1", len(trades))
+	}
+	if trades[0].Reason != "stop_loss" || trades[0].ExitPrice != 100 {
+		t.Errorf("exit %s at %v, want stop_loss at entry 100", trades[0].Reason, trades[0].ExitPrice)
+	}
+	if math.Abs(trades[0].PnL) > 1e-9 {
+		t.Errorf("PnL = %v, want 0 at breakeven", trades[0].PnL)
+	}
+}
+
+func TestOriginalStopHoldsBelowOneR(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	trade := openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 103)
+	state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+	trades := stepExits(b, state,
+		candle("BTCUSDT", 1, 100, 100.9, 99.5, 100.5),
+		candle("BTCUSDT", 2, 100.5, 100.6, 99, 99.2),
+	)
+
+	if len(trades) != 1 || trades[0].ExitPrice != 99 {
+		t.Fatalf("trades = %+v, want a stop loss at the original stop 99", trades)
+	}
+}
+
+func TestBreakevenDisabledKeepsTheOriginalStop(t *testing.T) {
+	config := exactConfig()
+	config.BreakevenAtR = 0
+	b := newTestBacktest(t, config)
+	trade := openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 103)
+	state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+	// The same +1R and fall back as TestBreakevenExitsAtEntryAfterOneR
+	trades := stepExits(b, state,
+		candle("BTCUSDT", 1, 100, 101, 99.5, 100.8),
+		candle("BTCUSDT", 2, 100.8, 100.9, 99, 99.2),
+	)
+
+	if len(trades) != 1 || trades[0].Reason != "stop_loss" || trades[0].ExitPrice != 99 {
+		t.Fatalf("trades = %+v, want a stop loss at the original stop 99", trades)
+	}
+}
+
+func TestMaxDrawdownFromTheRunningPeak(t *testing.T) {
+	curve := func(balances ...float64) []EquityPoint {
+		points := make([]EquityPoint, len(balances))
+		for i, balance := range balances {
+			points[i] = EquityPoint{Timestamp: testStart.Add(time.Duration(i) * time.Hour), Balance: balance}
+		}
+		return points
+	}
+	tests := []struct {
+		name  string
+		curve []EquityPoint
+		want  float64
+	}{
+		{"dip before a new high", curve(1200, 900, 1500, 1400), 0.25}, // 1200 down to 900, not 1500 down to 900
+		{"dip below the initial balance", curve(800, 1100), 0.2},
+		{"only new highs", curve(1100, 1200), 0},
+		{"no points", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := calculateMaxDrawdown(tt.curve, 1000); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("calculateMaxDrawdown() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEquityPointsCarryCandleTimes(t *testing.T) {
+	b := NewBacktestWithConfig(fixtureSource(), testStrategies(t), DefaultConfig())
+	start := warmUpStartTime(b)
+	end := start.Add(2 * 24 * time.Hour)
+
+	results, err := b.RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	if len(results.Trades) == 0 || len(results.EquityCurve) == 0 {
+		t.Fatalf("%d trades and %d equity points, the test needs both", len(results.Trades), len(results.EquityCurve))
+	}
+
+	// Stamped with the candle they were marked at, not when the backtest ran
+	step := models.TimeFrameDurations[BaseTimeFrame]
+	var previous time.Time
+	for i, point := range results.EquityCurve {
+		if point.Timestamp.Before(start) || point.Timestamp.After(end) || !point.Timestamp.Truncate(step).Equal(point.Timestamp) {
+			t.Fatalf("equity point %d at %s, want a 5m candle time from %s to %s", i, point.Timestamp, start, end)
+		}
+		if point.Timestamp.Before(previous) {
+			t.Fatalf("equity point %d at %s, after one at %s", i, point.Timestamp, previous)
+		}
+		previous = point.Timestamp
+	}
+}
@@ -0,0 +1,46 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/books"
+	"fmt"
+	"time"
+)
+
+// BookResults is one book's part of a portfolio run
+type BookResults struct {
+	Book    string
+	Results *BacktestResults
+}
+
+// PortfolioResults are the runs of every book over the same period
+type PortfolioResults struct {
+	Books        []BookResults
+	TotalTrades  int
+	FinalBalance float64 // Summed over the books, each starting from InitialBalance
+}
+
+// RunBooks backtests each book over its own symbols on a balance of its own, like live trading runs
+// them side by side. Only source is shared: every book gets its own engine from config with the book's
+// sizing and trade caps, so nothing one book does reaches another's positions, balance or throttles
+func RunBooks(source PriceSource, portfolio []books.Book, config Config, start, end time.Time) (*PortfolioResults, error) {
+	results := &PortfolioResults{}
+	for _, book := range portfolio {
+		bookConfig := config
+		if book.Sizing != nil {
+			sizing := *book.Sizing
+			bookConfig.Sizing = &sizing
+		}
+		if book.Frequency != nil {
+			bookConfig.Frequency = *book.Frequency
+		}
+
+		run, err := NewBacktestWithConfig(source, book.Strategies, bookConfig).RunBacktest(start, end, book.Symbols)
+		if err != nil {
+			return nil, fmt.Errorf("book %s: %v", book.Name, err)
+		}
+		results.Books = append(results.Books, BookResults{Book: book.Name, Results: run})
+		results.TotalTrades += run.TotalTrades
+		results.FinalBalance += run.FinalBalance
+	}
+	return results, nil
+}
@@ -0,0 +1,83 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/books"
+	"CryptoTradeBot/internal/testdb"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// twoBooks is a scalping book on both fixture symbols, capped at 3 trades a day, and a swing
+// book on ETHUSDT alone with its own sizing and a stricter strategy
+const twoBooks = `{
+  "books": [
+    {"name": "scalp", "symbols": ["BTCUSDT", "ETHUSDT"], "max_trades_per_day": 3},
+    {"name": "swing", "symbols": ["ETHUSDT"], "strategy": {"default": {"min_confidence": 0.75}},
+     "sizing": {"min_notional": 5, "max_margin_fraction": 0.25}}
+  ]
+}`
+
+// loadBooks parses twoBooks afresh, so every run gets strategies of its own
+func loadBooks(t *testing.T) []books.Book {
+	t.Helper()
+	portfolio, err := books.Parse([]byte(twoBooks))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return portfolio
+}
+
+// runBooks runs portfolio over the fixture's period after its warm-up
+func runBooks(t *testing.T, portfolio []books.Book) *PortfolioResults {
+	t.Helper()
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+	results, err := RunBooks(fixtureSource(), portfolio, DefaultConfig(), start, end)
+	if err != nil {
+		t.Fatalf("RunBooks() error = %v", err)
+	}
+	return results
+}
+
+func TestRunBooksKeepsBooksIsolated(t *testing.T) {
+	together := runBooks(t, loadBooks(t))
+	if len(together.Books) != 2 {
+		t.Fatalf("got %d books, want 2", len(together.Books))
+	}
+
+	scalp, swing := together.Books[0].Results, together.Books[1].Results
+	if scalp.TotalTrades == 0 || swing.TotalTrades == 0 {
+		t.Fatalf("scalp traded %d times and swing %d, want both to trade", scalp.TotalTrades, swing.TotalTrades)
+	}
+	for _, trade := range swing.Trades {
+		if trade.Symbol != "ETHUSDT" {
+			t.Errorf("swing book traded %s outside its symbols", trade.Symbol)
+		}
+	}
+	// The scalping book's daily cap throttles its own entries only
+	if scalp.ThrottledSignals == 0 || swing.ThrottledSignals != 0 {
+		t.Errorf("throttled signals scalp %d, swing %d, want only scalp's", scalp.ThrottledSignals, swing.ThrottledSignals)
+	}
+
+	// Each book runs exactly as it does alone, whichever book runs first
+	portfolio := loadBooks(t)
+	for i, book := range portfolio {
+		alone := runBooks(t, []books.Book{book}).Books[0].Results
+		got := together.Books[i].Results
+		if !reflect.DeepEqual(got.Trades, alone.Trades) || got.FinalBalance != alone.FinalBalance {
+			t.Errorf("book %s: %d trades ending at %.4f beside the other book, %d ending at %.4f alone",
+				book.Name, got.TotalTrades, got.FinalBalance, alone.TotalTrades, alone.FinalBalance)
+		}
+	}
+	reversed := loadBooks(t)
+	reversed[0], reversed[1] = reversed[1], reversed[0]
+	swapped := runBooks(t, reversed)
+	if !reflect.DeepEqual(swapped.Books[1].Results.Trades, scalp.Trades) || !reflect.DeepEqual(swapped.Books[0].Results.Trades, swing.Trades) {
+		t.Error("reordering the books changed their trades")
+	}
+
+	if want := scalp.FinalBalance + swing.FinalBalance; together.FinalBalance != want {
+		t.Errorf("FinalBalance = %v, want the books' sum %v", together.FinalBalance, want)
+	}
+}
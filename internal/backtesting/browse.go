@@ -0,0 +1,193 @@
+package backtesting
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// browsePage is how many trades the interactive summary shows at once
+const browsePage = 20
+
+const browseHelp = `Commands:
+  sort <entry|symbol|pnl|reason|duration> [asc|desc]   order the trades
+  symbol|side|reason <value>                           keep matching trades, "*" to drop the filter
+  pnl <min> [max]                                      keep trades within the PnL range, "*" for an open end
+  clear                                                drop every filter and sort by entry
+  next, prev, top                                      page through the trades
+  export <file.csv>                                    write the trades in view to CSV
+  help, quit`
+
+// Browse runs the interactive summary of results: commands read from in filter and sort the trade table
+// written to out, see browseHelp. It works on results alone, so runs loaded from JSON browse the same way,
+// and returns once in is exhausted or on quit
+func Browse(results *BacktestResults, in io.Reader, out io.Writer) error {
+	var view TradeView
+	trades := results.Trades
+	page := 0
+
+	show := func() {
+		writeBrowseSummary(out, view, trades, results.Trades)
+		writeBrowseTable(out, trades, page)
+	}
+	show()
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		command, args := strings.ToLower(fields[0]), fields[1:]
+		switch command {
+		case "quit", "q", "exit":
+			return nil
+		case "help", "?":
+			fmt.Fprintln(out, browseHelp)
+			continue
+		case "next", "n":
+			if (page+1)*browsePage < len(trades) {
+				page++
+			}
+			writeBrowseTable(out, trades, page)
+			continue
+		case "prev", "p":
+			page = max(page-1, 0)
+			writeBrowseTable(out, trades, page)
+			continue
+		case "top":
+			page = 0
+			writeBrowseTable(out, trades, page)
+			continue
+		case "export":
+			if len(args) != 1 {
+				fmt.Fprintln(out, "usage: export <file.csv>")
+				continue
+			}
+			if err := ExportTradesCSV(args[0], trades); err != nil {
+				fmt.Fprintf(out, "export failed: %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "%d trades written to %s\n", len(trades), args[0])
+			continue
+		}
+
+		next, err := applyBrowseCommand(view, command, args)
+		if err != nil {
+			fmt.Fprintf(out, "%v, type help for the commands\n", err)
+			continue
+		}
+		view = next
+		trades = view.Apply(results.Trades)
+		page = 0
+		show()
+	}
+}
+
+// applyBrowseCommand returns view changed by a filter or sort command
+func applyBrowseCommand(view TradeView, command string, args []string) (TradeView, error) {
+	value := func() (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("%s takes one value", command)
+		}
+		if args[0] == "*" {
+			return "", nil
+		}
+		return args[0], nil
+	}
+
+	var err error
+	switch command {
+	case "sort":
+		if len(args) == 0 || len(args) > 2 || !ValidSortKey(strings.ToLower(args[0])) {
+			return view, fmt.Errorf("sort by one of %s", strings.Join(SortKeys, ", "))
+		}
+		view.Sort = strings.ToLower(args[0])
+		view.Descending = len(args) == 2 && strings.EqualFold(args[1], "desc")
+	case "symbol":
+		view.Symbol, err = value()
+	case "side":
+		view.Side, err = value()
+	case "reason":
+		view.Reason, err = value()
+	case "pnl":
+		if len(args) == 0 || len(args) > 2 {
+			return view, fmt.Errorf("pnl takes a minimum and an optional maximum")
+		}
+		if view.MinPnL, err = parseBound(args[0]); err != nil {
+			return view, err
+		}
+		view.MaxPnL = nil
+		if len(args) == 2 {
+			view.MaxPnL, err = parseBound(args[1])
+		}
+	case "clear":
+		view = TradeView{}
+	default:
+		return view, fmt.Errorf("unknown command %q", command)
+	}
+	return view, err
+}
+
+// parseBound parses a PnL bound, nil for "*"
+func parseBound(value string) (*float64, error) {
+	if value == "*" {
+		return nil, nil
+	}
+	bound, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pnl bound %q", value)
+	}
+	return &bound, nil
+}
+
+// writeBrowseSummary writes the totals of the trades in view next to those of the whole run
+func writeBrowseSummary(out io.Writer, view TradeView, trades, all []Trade) {
+	shown, total := SummarizeTrades(trades), SummarizeTrades(all)
+
+	fmt.Fprintf(out, "\nView: %s\n", view)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tIn view\tAll trades")
+	fmt.Fprintf(w, "Trades\t%d\t%d\n", shown.Trades, total.Trades)
+	fmt.Fprintf(w, "Win Rate\t%.2f%%\t%.2f%%\n", shown.WinRate*100, total.WinRate*100)
+	fmt.Fprintf(w, "Total PnL\t%.2f\t%.2f\n", shown.TotalPnL, total.TotalPnL)
+	fmt.Fprintf(w, "Average PnL\t%.4f\t%.4f\n", shown.AveragePnL, total.AveragePnL)
+	fmt.Fprintf(w, "Profit Factor\t%.2f\t%.2f\n", shown.ProfitFactor, total.ProfitFactor)
+	fmt.Fprintf(w, "Average Hold\t%s\t%s\n", shown.AverageHold.Round(time.Minute), total.AverageHold.Round(time.Minute))
+	w.Flush()
+}
+
+// writeBrowseTable writes one page of trades
+func writeBrowseTable(out io.Writer, trades []Trade, page int) {
+	if len(trades) == 0 {
+		fmt.Fprintln(out, "\nNo trades in view")
+		return
+	}
+	from := min(page*browsePage, len(trades))
+	to := min(from+browsePage, len(trades))
+
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "#\tEntry\tSymbol\tSide\tEntry Price\tExit Price\tPnL\tR\tHeld\tReason\t")
+	for i, trade := range trades[from:to] {
+		r := ""
+		if trade.RMultiple != nil {
+			r = fmt.Sprintf("%.2f", *trade.RMultiple)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.8f\t%.8f\t%.2f\t%s\t%s\t%s\t\n",
+			from+i+1, trade.EntryTime.UTC().Format("2006-01-02 15:04"), trade.Symbol, trade.Side,
+			trade.EntryPrice, trade.ExitPrice, trade.PnL, r, trade.Duration().Round(time.Minute), trade.Reason)
+	}
+	w.Flush()
+	fmt.Fprintf(out, "Trades %d-%d of %d\n", from+1, to, len(trades))
+}
@@ -0,0 +1,12 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+)
+
+// cadenceCloses reports whether the state's candle closes a candle of the symbol's analysis cadence,
+// the only candles entries and reversals are analyzed on, like live trading; exits still run on every candle
+func (b *Backtest) cadenceCloses(state *CandleState) bool {
+	interval := models.TimeFrameDurations[b.strategies.Cadence(state.Symbol)]
+	return candleClose(state.Price).Truncate(interval).Equal(candleClose(state.Price))
+}
@@ -0,0 +1,68 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"sync"
+	"testing"
+	"time"
+)
+
+// cadenceProbe is a strategy that never enters, recording the close of each window it analyzes and the cadence it was told
+type cadenceProbe struct {
+	mu       sync.Mutex
+	closes   []time.Time
+	cadences map[string]int
+}
+
+func (p *cadenceProbe) Analyze(prices []models.Price) *analysis.AnalysisResult {
+	return p.AnalyzeOnCadence(prices, "")
+}
+
+func (p *cadenceProbe) AnalyzeOnCadence(prices []models.Price, cadence string) *analysis.AnalysisResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closes = append(p.closes, candleClose(prices[len(prices)-1]))
+	p.cadences[cadence]++
+	return &analysis.AnalysisResult{Symbol: prices[len(prices)-1].Symbol, Reason: "probe"}
+}
+
+func TestBacktestAnalyzesOnTheCadence(t *testing.T) {
+	probe := &cadenceProbe{cadences: make(map[string]int)}
+	strategy.Register("cadence-probe", func(analysis.Config) strategy.Strategy { return probe })
+
+	var prices []models.Price
+	for _, price := range testdb.FixturePrices("BTCUSDT", testdb.FixtureDays) {
+		if price.TimeFrame == models.PriceTimeFrame5m {
+			prices = append(prices, price)
+		}
+	}
+	params := strategy.DefaultParams()
+	params.Strategy = "cadence-probe"
+	params.Cadence = models.PriceTimeFrame15m
+	strategies, err := strategy.NewStrategyManager(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := start.Add(6 * time.Hour)
+	if _, err := NewBacktestWithConfig(NewSliceSource(prices), strategies, DefaultConfig()).RunBacktest(start, end, []string{"BTCUSDT"}); err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+
+	// Six hours of 5m candles, analyzed only on the 24 closing a 15m candle
+	if len(probe.closes) != 24 {
+		t.Errorf("analyzed %d windows, want the 24 15m closes", len(probe.closes))
+	}
+	for _, close := range probe.closes {
+		if !close.Equal(close.Truncate(15 * time.Minute)) {
+			t.Errorf("analyzed the window closing at %s, between 15m closes", close.Format("15:04"))
+		}
+	}
+	if probe.cadences[models.PriceTimeFrame15m] != len(probe.closes) {
+		t.Errorf("strategy told cadences %v, want 15m every time", probe.cadences)
+	}
+}
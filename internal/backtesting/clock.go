@@ -0,0 +1,241 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"log"
+	"time"
+)
+
+// streamChunk is how many base candles of a symbol are read per query while stepping through the period
+const streamChunk = 2000
+
+// candleStream reads one symbol's base candles a chunk of time at a time
+// Symbols are stepped through together, so each needs its own cursor; short chunked reads keep memory bounded by
+// the chunk and hold no database connection between steps
+type candleStream struct {
+	source PriceSource
+	symbol string
+	next   time.Time // Start of the next chunk to read
+	end    time.Time
+	buffer []models.Price
+}
+
+func newCandleStream(source PriceSource, symbol string, start, end time.Time) *candleStream {
+	return &candleStream{source: source, symbol: symbol, next: start, end: end}
+}
+
+// peek returns the next candle without consuming it, false once the stream is exhausted
+func (s *candleStream) peek() (models.Price, bool, error) {
+	for len(s.buffer) == 0 && !s.next.After(s.end) {
+		chunkEnd := s.next.Add(streamChunk * models.TimeFrameDurations[BaseTimeFrame])
+		last := !chunkEnd.Before(s.end)
+		if last {
+			chunkEnd = s.end
+		}
+
+		// Sources include both ends; candles at chunkEnd belong to the next chunk unless this is the last
+		err := s.source.StreamPricesByTimeFrame(s.symbol, BaseTimeFrame, s.next, chunkEnd, func(price models.Price) error {
+			if last || price.OpenTime.Before(chunkEnd) {
+				s.buffer = append(s.buffer, price)
+			}
+			return nil
+		})
+		if err != nil {
+			return models.Price{}, false, err
+		}
+
+		s.next = chunkEnd
+		if last {
+			s.next = s.end.Add(time.Nanosecond)
+		}
+	}
+	if len(s.buffer) == 0 {
+		return models.Price{}, false, nil
+	}
+	return s.buffer[0], true, nil
+}
+
+// pop consumes the candle returned by peek
+func (s *candleStream) pop() {
+	s.buffer = s.buffer[1:]
+}
+
+// symbolRun is the state a symbol carries from one step to the next
+type symbolRun struct {
+	symbol string
+	stream *candleStream
+	window *candleWindow
+
+	position, hedge *Trade
+	pending         *PendingEntry
+	queued          *QueuedEntry
+
+	daily      []models.Price
+	closedDays int
+
+	dataStart time.Time
+	last      models.Price
+	count     int
+	first     int // Index of the first candle in the period, -1 until reached
+	done      bool
+}
+
+// legs returns the run's open legs
+func (r *symbolRun) legs() []*Trade {
+	legs := make([]*Trade, 0, 2)
+	for _, leg := range []*Trade{r.position, r.hedge} {
+		if leg != nil {
+			legs = append(legs, leg)
+		}
+	}
+	return legs
+}
+
+// replay steps every symbol through one shared clock: each step processes the candles of every symbol opening at
+// the step's time, in the order the symbols were given, then marks the account's equity once
+// Symbols without a candle at a step are left where they are
+func (b *Backtest) replay(ctx context.Context, runs []*symbolRun, startTime time.Time, tracker *progressTracker) error {
+	b.runs = runs
+	defer func() { b.runs = nil }()
+
+	steps := 0
+	for {
+		at, ok, err := nextStep(runs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		b.stepClosed = false
+		for _, run := range runs {
+			if err := b.stepSymbol(run, at, startTime); err != nil {
+				return err
+			}
+		}
+		if at.Before(startTime) {
+			continue
+		}
+
+		b.markEquity(at)
+		if steps%progressEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			tracker.step(at)
+		}
+		steps++
+
+		for _, run := range runs {
+			if run.done {
+				continue
+			}
+			if _, more, err := run.stream.peek(); err != nil {
+				return err
+			} else if !more {
+				run.done = true
+				tracker.symbolDone(run.symbol)
+			}
+		}
+	}
+}
+
+// nextStep returns the earliest open time among the symbols' next candles, false once every stream is exhausted
+func nextStep(runs []*symbolRun) (time.Time, bool, error) {
+	var at time.Time
+	found := false
+	for _, run := range runs {
+		price, ok, err := run.stream.peek()
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		if ok && (!found || price.OpenTime.Before(at)) {
+			at, found = price.OpenTime, true
+		}
+	}
+	return at, found, nil
+}
+
+// stepSymbol processes run's candles opening at at, if it has any
+// Warm-up candles before startTime only fill the window
+func (b *Backtest) stepSymbol(run *symbolRun, at, startTime time.Time) error {
+	for {
+		price, ok, err := run.stream.peek()
+		if err != nil {
+			return err
+		}
+		if !ok || !price.OpenTime.Equal(at) {
+			return nil
+		}
+		run.stream.pop()
+
+		index := run.count
+		run.count++
+		if index == 0 {
+			run.dataStart = price.OpenTime
+		}
+		run.window.Push(price)
+		run.last = price
+
+		if price.OpenTime.Before(startTime) {
+			continue
+		}
+		if run.first < 0 {
+			run.first = index
+			if err := b.checkWarmUp(run.symbol, run.dataStart, run.first); err != nil {
+				return err
+			}
+		}
+
+		run.closedDays = closedDaily(run.daily, run.closedDays, candleClose(price))
+		state := &CandleState{
+			Symbol:   run.symbol,
+			Index:    index,
+			Window:   run.window.Candles(),
+			Price:    price,
+			Position: run.position,
+			Hedge:    run.hedge,
+			Pending:  run.pending,
+			Queued:   run.queued,
+			Daily:    run.daily[:run.closedDays],
+		}
+		b.processCandle(state)
+		run.position = state.Position
+		run.hedge = state.Hedge
+		run.pending = state.Pending
+		run.queued = state.Queued
+	}
+}
+
+// markEquity records the account's equity once every symbol's candles at at are processed: the balance plus each
+// open leg marked at its symbol's latest close, so positions open at the same time weigh on the curve together
+// Steps where nothing is open and nothing closed leave the curve as it was
+func (b *Backtest) markEquity(at time.Time) {
+	equity, open := b.currentBalance, false
+	for _, run := range b.runs {
+		for _, leg := range run.legs() {
+			equity += tradePnL(leg, run.last.Close)
+			open = true
+		}
+	}
+	if !open && !b.stepClosed {
+		return
+	}
+
+	b.equityCurve = append(b.equityCurve, EquityPoint{
+		Timestamp: at,
+		Balance:   equity,
+	})
+}
+
+// finishSymbol settles what is left of run once the clock has passed its last candle
+func (b *Backtest) finishSymbol(run *symbolRun) {
+	if run.first < 0 {
+		log.Printf("No data for %s in the backtest period, skipping", run.symbol)
+		return
+	}
+	b.closeHeldLegs(run.symbol, run.last)
+	log.Printf("Streamed %d prices for %s", run.count, run.symbol)
+}
@@ -0,0 +1,59 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// flat returns the 5m candle of symbol i candles after testStart trading at a single price
+func flat(symbol string, i int, price float64) models.Price {
+	return candle(symbol, i, price, price, price, price)
+}
+
+func TestOverlappingTradesMarkOneCombinedEquityPoint(t *testing.T) {
+	// BTC has a candle at 4 where ETH has none; both dip at 1
+	prices := []models.Price{
+		flat("BTCUSDT", 0, 100), flat("BTCUSDT", 1, 99), flat("BTCUSDT", 2, 99.5), flat("BTCUSDT", 3, 100), flat("BTCUSDT", 4, 100.5),
+		flat("ETHUSDT", 0, 100), flat("ETHUSDT", 1, 98.5), flat("ETHUSDT", 2, 99), flat("ETHUSDT", 3, 100),
+	}
+	b := newTestBacktest(t, exactConfig(), prices...)
+	b.ctx = context.Background()
+	end := testStart.Add(time.Hour)
+
+	runs := make([]*symbolRun, 0, 2)
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		runs = append(runs, &symbolRun{
+			symbol:   symbol,
+			stream:   newCandleStream(b.source, symbol, testStart, end),
+			window:   newCandleWindow(b.window),
+			first:    0, // Warm-up is not what is under test
+			position: openTrade(symbol, models.PositionSideLong, 100, 90, 110),
+		})
+	}
+	if err := b.replay(b.ctx, runs, testStart, newProgressTracker(nil, testStart, end, len(runs))); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(b.equityCurve) != 5 {
+		t.Fatalf("%d equity points recorded over 5 steps, want one per step: %+v", len(b.equityCurve), b.equityCurve)
+	}
+	for i, point := range b.equityCurve {
+		if want := testStart.Add(time.Duration(i) * 5 * time.Minute); !point.Timestamp.Equal(want) {
+			t.Errorf("point %d at %s, want %s", i, point.Timestamp, want)
+		}
+	}
+
+	// 1 USDT margin at 50x: BTC's 1% and ETH's 1.5% dip lose 0.5 and 0.75 together
+	want := InitialBalance - 0.5 - 0.75
+	if got := b.equityCurve[1].Balance; math.Abs(got-want) > 1e-9 {
+		t.Errorf("equity at the shared dip = %v, want %v", got, want)
+	}
+	for i, point := range b.equityCurve {
+		if point.Balance < b.equityCurve[1].Balance {
+			t.Errorf("point %d at %v is below the combined dip", i, point.Balance)
+		}
+	}
+}
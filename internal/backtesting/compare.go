@@ -0,0 +1,251 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/provenance"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Save writes the results as JSON so runs can be compared later
+func (r *BacktestResults) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode results: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write results: %v", err)
+	}
+	return nil
+}
+
+// LoadResults reads results written by Save
+func LoadResults(path string) (*BacktestResults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read results: %v", err)
+	}
+	var results BacktestResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse results %s: %v", path, err)
+	}
+	return &results, nil
+}
+
+// MetricDelta is one headline metric from both runs
+type MetricDelta struct {
+	Name  string
+	A     float64
+	B     float64
+	Delta float64 // B - A
+}
+
+// SymbolDelta compares the trades of one symbol across both runs
+type SymbolDelta struct {
+	Symbol   string
+	TradesA  int
+	TradesB  int
+	PnLA     float64
+	PnLB     float64
+	WinRateA float64
+	WinRateB float64
+
+	// Excursion distributions of the symbol's trades, in R
+	ExcursionsA ExcursionStats
+	ExcursionsB ExcursionStats
+
+	// Average R of the symbol's trades that have one
+	AverageRA float64
+	AverageRB float64
+}
+
+// RunDiff is the difference between two backtest runs, B relative to A
+type RunDiff struct {
+	// Config hashes of both runs, empty for runs saved without provenance,
+	// and the settings that changed between them when both are known and differ
+	ConfigA       string
+	ConfigB       string
+	ConfigChanges []string
+
+	Metrics []MetricDelta
+	Symbols []SymbolDelta
+	Matched int
+	OnlyInA []Trade
+	OnlyInB []Trade
+}
+
+// Compare diffs two runs, pairing trades with the same symbol whose entries are within tolerance
+func Compare(a, b *BacktestResults, tolerance time.Duration) *RunDiff {
+	diff := &RunDiff{
+		Metrics: []MetricDelta{
+			newMetricDelta("Total Trades", float64(a.TotalTrades), float64(b.TotalTrades)),
+			newMetricDelta("Win Rate", a.WinRate, b.WinRate),
+			newMetricDelta("Total PnL", totalPnL(a.Trades), totalPnL(b.Trades)),
+			newMetricDelta("Average PnL", a.AveragePnL, b.AveragePnL),
+			newMetricDelta("Liquidations", float64(a.Liquidations), float64(b.Liquidations)),
+			newMetricDelta("Max Drawdown", a.MaxDrawdown, b.MaxDrawdown),
+			newMetricDelta("Sharpe Ratio", a.SharpeRatio, b.SharpeRatio),
+			newMetricDelta("Sortino Ratio", a.SortinoRatio, b.SortinoRatio),
+			newMetricDelta("Calmar Ratio", a.CalmarRatio, b.CalmarRatio),
+			newMetricDelta("Profit Factor", ProfitFactor(a.Trades), ProfitFactor(b.Trades)),
+			newMetricDelta("Median MAE (R)", a.Excursions.MAE.P50, b.Excursions.MAE.P50),
+			newMetricDelta("Median MFE (R)", a.Excursions.MFE.P50, b.Excursions.MFE.P50),
+			newMetricDelta("Average R", a.R.Average, b.R.Average),
+			newMetricDelta("Trades >= 2R", a.R.AtLeast2R, b.R.AtLeast2R),
+			newMetricDelta("Final Balance", a.FinalBalance, b.FinalBalance),
+		},
+	}
+
+	diff.ConfigA, diff.ConfigB, diff.ConfigChanges = compareProvenance(a.Provenance, b.Provenance)
+	diff.Matched, diff.OnlyInA, diff.OnlyInB = matchTrades(a.Trades, b.Trades, tolerance)
+	diff.Symbols = symbolDeltas(a.Trades, b.Trades)
+
+	return diff
+}
+
+// compareProvenance returns the short config hashes of both runs and, when they differ, what changed
+func compareProvenance(a, b *provenance.Snapshot) (string, string, []string) {
+	var hashA, hashB string
+	if a != nil {
+		hashA = a.Short()
+	}
+	if b != nil {
+		hashB = b.Short()
+	}
+	if a == nil || b == nil || a.Hash == b.Hash {
+		return hashA, hashB, nil
+	}
+	changes, err := provenance.Diff(a, b)
+	if err != nil {
+		return hashA, hashB, []string{err.Error()}
+	}
+	return hashA, hashB, changes
+}
+
+// ProfitFactor returns gross profit over gross loss, +Inf when nothing was lost
+func ProfitFactor(trades []Trade) float64 {
+	var profit, loss float64
+	for _, t := range trades {
+		if t.PnL > 0 {
+			profit += t.PnL
+		} else {
+			loss -= t.PnL
+		}
+	}
+	if loss == 0 {
+		if profit == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return profit / loss
+}
+
+func newMetricDelta(name string, a, b float64) MetricDelta {
+	return MetricDelta{Name: name, A: a, B: b, Delta: b - a}
+}
+
+func totalPnL(trades []Trade) float64 {
+	var total float64
+	for _, t := range trades {
+		total += t.PnL
+	}
+	return total
+}
+
+// matchTrades pairs each trade in a with the closest unused trade in b on the same symbol
+func matchTrades(a, b []Trade, tolerance time.Duration) (int, []Trade, []Trade) {
+	used := make([]bool, len(b))
+	var onlyInA []Trade
+	matched := 0
+
+	for _, ta := range a {
+		best := -1
+		var bestGap time.Duration
+		for j, tb := range b {
+			if used[j] || tb.Symbol != ta.Symbol {
+				continue
+			}
+			gap := tb.EntryTime.Sub(ta.EntryTime)
+			if gap < 0 {
+				gap = -gap
+			}
+			if gap <= tolerance && (best == -1 || gap < bestGap) {
+				best, bestGap = j, gap
+			}
+		}
+
+		if best == -1 {
+			onlyInA = append(onlyInA, ta)
+			continue
+		}
+		used[best] = true
+		matched++
+	}
+
+	var onlyInB []Trade
+	for j, tb := range b {
+		if !used[j] {
+			onlyInB = append(onlyInB, tb)
+		}
+	}
+
+	return matched, onlyInA, onlyInB
+}
+
+func symbolDeltas(a, b []Trade) []SymbolDelta {
+	bySymbol := make(map[string]*SymbolDelta)
+	winsA := make(map[string]int)
+	winsB := make(map[string]int)
+	tradesA := make(map[string][]Trade)
+	tradesB := make(map[string][]Trade)
+
+	get := func(symbol string) *SymbolDelta {
+		if d, ok := bySymbol[symbol]; ok {
+			return d
+		}
+		d := &SymbolDelta{Symbol: symbol}
+		bySymbol[symbol] = d
+		return d
+	}
+
+	for _, t := range a {
+		d := get(t.Symbol)
+		d.TradesA++
+		d.PnLA += t.PnL
+		if t.PnL > 0 {
+			winsA[t.Symbol]++
+		}
+		tradesA[t.Symbol] = append(tradesA[t.Symbol], t)
+	}
+	for _, t := range b {
+		d := get(t.Symbol)
+		d.TradesB++
+		d.PnLB += t.PnL
+		if t.PnL > 0 {
+			winsB[t.Symbol]++
+		}
+		tradesB[t.Symbol] = append(tradesB[t.Symbol], t)
+	}
+
+	deltas := make([]SymbolDelta, 0, len(bySymbol))
+	for symbol, d := range bySymbol {
+		if d.TradesA > 0 {
+			d.WinRateA = float64(winsA[symbol]) / float64(d.TradesA)
+		}
+		if d.TradesB > 0 {
+			d.WinRateB = float64(winsB[symbol]) / float64(d.TradesB)
+		}
+		d.ExcursionsA = Excursions(tradesA[symbol])
+		d.ExcursionsB = Excursions(tradesB[symbol])
+		d.AverageRA = RStatsOf(tradesA[symbol]).Average
+		d.AverageRB = RStatsOf(tradesB[symbol]).Average
+		deltas = append(deltas, *d)
+	}
+	sort.Slice(deltas, func(i, j int) bool { return deltas[i].Symbol < deltas[j].Symbol })
+
+	return deltas
+}
@@ -0,0 +1,164 @@
+package backtesting
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closedTrade returns a closed trade of symbol entered i 5m candles after testStart
+func closedTrade(symbol string, i int, pnl float64) Trade {
+	entry := testStart.Add(time.Duration(i) * 5 * time.Minute)
+	return Trade{Symbol: symbol, Side: "long", EntryTime: entry, ExitTime: entry.Add(time.Hour), PnL: pnl}
+}
+
+// savedRun writes a run of trades to a results file and loads it back, the way -mode compare reads runs
+func savedRun(t *testing.T, name string, trades ...Trade) *BacktestResults {
+	t.Helper()
+	results := &BacktestResults{Trades: trades, TotalTrades: len(trades), FinalBalance: 1000 + totalPnL(trades)}
+	wins := 0
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			wins++
+		}
+	}
+	results.WinRate = float64(wins) / float64(len(trades))
+
+	path := filepath.Join(t.TempDir(), name+".json")
+	if err := results.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadResults(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loaded
+}
+
+func metric(diff *RunDiff, name string) MetricDelta {
+	for _, m := range diff.Metrics {
+		if m.Name == name {
+			return m
+		}
+	}
+	return MetricDelta{Name: name, A: math.NaN()}
+}
+
+func TestCompareDeltas(t *testing.T) {
+	// B enters BTC one candle later, drops the ETH loser and adds a SOL winner
+	a := savedRun(t, "a",
+		closedTrade("BTCUSDT", 10, 6),
+		closedTrade("BTCUSDT", 40, -2),
+		closedTrade("ETHUSDT", 20, -4),
+	)
+	b := savedRun(t, "b",
+		closedTrade("BTCUSDT", 11, 8),
+		closedTrade("BTCUSDT", 40, -2),
+		closedTrade("SOLUSDT", 30, 3),
+	)
+
+	diff := Compare(a, b, 5*time.Minute)
+
+	near := func(x, y float64) bool { return math.Abs(x-y) < 1e-9 }
+	for _, want := range []MetricDelta{
+		{Name: "Total Trades", A: 3, B: 3, Delta: 0},
+		{Name: "Win Rate", A: 1.0 / 3, B: 2.0 / 3, Delta: 1.0 / 3},
+		{Name: "Total PnL", A: 0, B: 9, Delta: 9},
+		{Name: "Profit Factor", A: 1, B: 5.5, Delta: 4.5},
+		{Name: "Final Balance", A: 1000, B: 1009, Delta: 9},
+	} {
+		got := metric(diff, want.Name)
+		if !near(got.A, want.A) || !near(got.B, want.B) || !near(got.Delta, want.Delta) {
+			t.Errorf("%s = %+v, want %+v", want.Name, got, want)
+		}
+	}
+
+	if diff.Matched != 2 {
+		t.Errorf("Matched = %d, want 2", diff.Matched)
+	}
+	if len(diff.OnlyInA) != 1 || diff.OnlyInA[0].Symbol != "ETHUSDT" {
+		t.Errorf("OnlyInA = %+v, want the ETH trade", diff.OnlyInA)
+	}
+	if len(diff.OnlyInB) != 1 || diff.OnlyInB[0].Symbol != "SOLUSDT" {
+		t.Errorf("OnlyInB = %+v, want the SOL trade", diff.OnlyInB)
+	}
+
+	wantSymbols := []SymbolDelta{
+		{Symbol: "BTCUSDT", TradesA: 2, TradesB: 2, PnLA: 4, PnLB: 6, WinRateA: 0.5, WinRateB: 0.5},
+		{Symbol: "ETHUSDT", TradesA: 1, PnLA: -4},
+		{Symbol: "SOLUSDT", TradesB: 1, PnLB: 3, WinRateB: 1},
+	}
+	if len(diff.Symbols) != len(wantSymbols) {
+		t.Fatalf("Symbols = %+v, want %d", diff.Symbols, len(wantSymbols))
+	}
+	for i, want := range wantSymbols {
+		got := diff.Symbols[i]
+		if got.Symbol != want.Symbol || got.TradesA != want.TradesA || got.TradesB != want.TradesB ||
+			!near(got.PnLA, want.PnLA) || !near(got.PnLB, want.PnLB) ||
+			!near(got.WinRateA, want.WinRateA) || !near(got.WinRateB, want.WinRateB) {
+			t.Errorf("Symbols[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestCompareMatchTolerance(t *testing.T) {
+	a := savedRun(t, "a", closedTrade("BTCUSDT", 10, 1))
+	b := savedRun(t, "b", closedTrade("BTCUSDT", 11, 1))
+
+	tests := []struct {
+		tolerance time.Duration
+		matched   int
+	}{
+		{0, 0},
+		{4 * time.Minute, 0},
+		{5 * time.Minute, 1},
+	}
+	for _, tt := range tests {
+		diff := Compare(a, b, tt.tolerance)
+		if diff.Matched != tt.matched || len(diff.OnlyInA) != 1-tt.matched || len(diff.OnlyInB) != 1-tt.matched {
+			t.Errorf("tolerance %s: matched %d, only in A %d, only in B %d, want %d matched",
+				tt.tolerance, diff.Matched, len(diff.OnlyInA), len(diff.OnlyInB), tt.matched)
+		}
+	}
+}
+
+func TestCompareMatchesClosestEntry(t *testing.T) {
+	// Within tolerance of both, the A trade pairs with the nearer B entry and leaves the other
+	a := savedRun(t, "a", closedTrade("BTCUSDT", 10, 1))
+	b := savedRun(t, "b", closedTrade("BTCUSDT", 8, 1), closedTrade("BTCUSDT", 11, 1))
+
+	diff := Compare(a, b, 15*time.Minute)
+	if diff.Matched != 1 || len(diff.OnlyInB) != 1 || !diff.OnlyInB[0].EntryTime.Equal(testStart.Add(40*time.Minute)) {
+		t.Errorf("matched %d, only in B %+v, want the entry at candle 8 left over", diff.Matched, diff.OnlyInB)
+	}
+}
+
+func TestCompareShowsChangedSettings(t *testing.T) {
+	strategies := testStrategies(t)
+	a := savedRun(t, "a", closedTrade("BTCUSDT", 10, 6))
+	b := savedRun(t, "b", closedTrade("BTCUSDT", 10, 6))
+	a.Provenance = (&Backtest{strategies: strategies, config: DefaultConfig()}).provenance()
+	same := (&Backtest{strategies: strategies, config: DefaultConfig()}).provenance()
+
+	wider := DefaultConfig()
+	wider.GapTolerance *= 2
+	b.Provenance = (&Backtest{strategies: strategies, config: wider}).provenance()
+
+	if a.Provenance == nil || same.Hash != a.Provenance.Hash {
+		t.Fatalf("the same settings hashed %v and %v", a.Provenance, same)
+	}
+	diff := Compare(a, b, 5*time.Minute)
+	if diff.ConfigA != a.Provenance.Short() || diff.ConfigB != b.Provenance.Short() || diff.ConfigA == diff.ConfigB {
+		t.Errorf("config hashes %q and %q, want both runs' differing short hashes", diff.ConfigA, diff.ConfigB)
+	}
+	if len(diff.ConfigChanges) != 1 || !strings.HasPrefix(diff.ConfigChanges[0], "backtest.GapTolerance: ") {
+		t.Errorf("ConfigChanges = %v, want the gap tolerance", diff.ConfigChanges)
+	}
+
+	b.Provenance = same
+	if diff := Compare(a, b, 5*time.Minute); diff.ConfigChanges != nil {
+		t.Errorf("ConfigChanges = %v between identical settings", diff.ConfigChanges)
+	}
+}
@@ -0,0 +1,17 @@
+package backtesting
+
+import "CryptoTradeBot/internal/services/trading"
+
+// ConfidenceBucketsOf returns the performance of trades by the confidence they were entered at
+func ConfidenceBucketsOf(trades []Trade) []trading.ConfidenceBucket {
+	outcomes := make([]trading.ConfidenceOutcome, len(trades))
+	for i, t := range trades {
+		outcomes[i] = trading.ConfidenceOutcome{
+			Confidence:     t.Confidence,
+			SizeMultiplier: t.confidenceFactor(),
+			PnL:            t.PnL,
+			R:              t.RMultiple,
+		}
+	}
+	return trading.ConfidenceBuckets(outcomes)
+}
@@ -0,0 +1,50 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"testing"
+)
+
+func TestConfidenceScalesTheEntrySize(t *testing.T) {
+	tests := []struct {
+		name       string
+		multiplier float64
+		margin     float64
+	}{
+		{"unscaled by default", 0, FixedSize},
+		{"high confidence", 1.5, 1.5 * FixedSize},
+		{"low confidence", 0.5, 0.5 * FixedSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			result := &analysis.AnalysisResult{
+				Symbol: "BTCUSDT", Direction: models.PositionSideLong, Confidence: 0.8,
+				StopLoss: 98, TakeProfit: 104, SizeMultiplier: tt.multiplier,
+			}
+			trade := b.openPosition(result, 100, candle("BTCUSDT", 0, 100, 100, 100, 100))
+			if trade == nil {
+				t.Fatal("openPosition() opened nothing")
+			}
+			if trade.Margin != tt.margin || trade.Size != tt.margin/100 || trade.confidenceFactor() != result.SizeFactor() {
+				t.Errorf("opened %v margin, size %v at x%v, want %v margin at x%v",
+					trade.Margin, trade.Size, trade.ConfidenceMultiplier, tt.margin, result.SizeFactor())
+			}
+		})
+	}
+}
+
+func TestConfidenceBucketsOfTrades(t *testing.T) {
+	low, high := closedTrade("BTCUSDT", 0, -1), closedTrade("BTCUSDT", 1, 2)
+	low.Confidence, low.ConfidenceMultiplier = 0.72, 0.6
+	high.Confidence, high.ConfidenceMultiplier = 0.93, 1.4
+
+	buckets := ConfidenceBucketsOf([]Trade{low, high})
+	if len(buckets) != 2 || buckets[0].Label != "0.70 to 0.80" || buckets[1].Label != "0.90 and up" {
+		t.Fatalf("ConfidenceBucketsOf() = %+v, want one trade in each of two buckets", buckets)
+	}
+	if buckets[0].PnL != -1 || buckets[0].SizeMultiplier != 0.6 || buckets[1].PnL != 2 || buckets[1].SizeMultiplier != 1.4 {
+		t.Errorf("ConfidenceBucketsOf() = %+v, want each trade's PnL and multiplier in its bucket", buckets)
+	}
+}
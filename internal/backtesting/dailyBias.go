@@ -0,0 +1,44 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"fmt"
+	"time"
+)
+
+// loadDaily returns the symbol's 1d candles from the daily bias warm-up before start up to end
+// A source without daily candles leaves every signal without a bias
+func (b *Backtest) loadDaily(ctx context.Context, symbol string, start, end time.Time) ([]models.Price, error) {
+	day := models.TimeFrameDurations[models.PriceTimeFrame1d]
+	from := start.Truncate(day).Add(-time.Duration(analysis.DailyBiasCandles+1) * day)
+
+	daily, err := b.source.GetPricesByTimeFrame(ctx, symbol, models.PriceTimeFrame1d, from, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load daily candles for %s: %v", symbol, err)
+	}
+	return daily, nil
+}
+
+// closedDaily advances closed, the count of daily candles already closed, to those closed by at
+func closedDaily(daily []models.Price, closed int, at time.Time) int {
+	day := models.TimeFrameDurations[models.PriceTimeFrame1d]
+	for closed < len(daily) && !daily[closed].OpenTime.Add(day).After(at) {
+		closed++
+	}
+	return closed
+}
+
+// candleClose returns the time a base candle closes, when its signal is acted on
+func candleClose(price models.Price) time.Time {
+	return price.OpenTime.Add(models.TimeFrameDurations[BaseTimeFrame])
+}
+
+// decide is the backtest's analysis at at: the strategies run on window, the candles closed by then,
+// and the result biased by daily, the 1d candles closed by then. Entries, reversals and Parity decide through it
+func (b *Backtest) decide(symbol string, window, daily []models.Price, at time.Time) *analysis.AnalysisResult {
+	result := b.strategies.AnalyzeDirection(symbol, b.config.Direction, window)
+	b.strategies.ApplyDailyBias(symbol, result, daily, at)
+	return result
+}
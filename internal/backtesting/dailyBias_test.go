@@ -0,0 +1,107 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"testing"
+	"time"
+)
+
+// bullishDays returns symbol's 1d candles climbing in a zigzag from well before the fixture to its end,
+// a bullish daily bias throughout the fixture
+func bullishDays(symbol string) []models.Price {
+	day := 24 * time.Hour
+	start := testdb.FixtureStart.Add(-time.Duration(analysis.DailyBiasCandles+20) * day)
+	var prices []models.Price
+	close := 1000.0
+	for at, i := start, 0; at.Before(testdb.FixtureStart.Add(testdb.FixtureDays * day)); at, i = at.Add(day), i+1 {
+		open := close
+		if i%2 == 0 {
+			close += 20
+		} else {
+			close -= 10
+		}
+		prices = append(prices, models.Price{
+			Symbol:    symbol,
+			TimeFrame: models.PriceTimeFrame1d,
+			OpenTime:  at,
+			CloseTime: at.Add(day - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 1,
+			Low:       min(open, close) - 1,
+			Close:     close,
+			Volume:    1000,
+		})
+	}
+	return prices
+}
+
+// dailyBiasTrades runs the fixture backtest of BTCUSDT under a bullish daily, counting its trades per side
+func dailyBiasTrades(t *testing.T, filter bool) map[string]int {
+	t.Helper()
+	var prices []models.Price
+	for _, price := range testdb.FixturePrices("BTCUSDT", testdb.FixtureDays) {
+		if price.TimeFrame != models.PriceTimeFrame1d {
+			prices = append(prices, price)
+		}
+	}
+	prices = append(prices, bullishDays("BTCUSDT")...)
+
+	params := strategy.DefaultParams()
+	params.DailyBiasFilter = filter
+	strategies, err := strategy.NewStrategyManager(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig()
+	config.Reversal.Enabled = false
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+	results, err := NewBacktestWithConfig(NewSliceSource(prices), strategies, config).RunBacktest(start, end, []string{"BTCUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	counts := make(map[string]int)
+	for _, trade := range results.Trades {
+		counts[trade.Side]++
+	}
+	return counts
+}
+
+func TestBullishDailyBlocksIntradayShorts(t *testing.T) {
+	unfiltered := dailyBiasTrades(t, false)
+	if unfiltered[models.PositionSideShort] == 0 {
+		t.Fatalf("the fixture traded %v without the filter, want intraday shorts disagreeing with the daily", unfiltered)
+	}
+	filtered := dailyBiasTrades(t, true)
+	if filtered[models.PositionSideShort] != 0 {
+		t.Errorf("%d shorts taken against the bullish daily, want none", filtered[models.PositionSideShort])
+	}
+	if filtered[models.PositionSideLong] == 0 {
+		t.Errorf("no longs taken with the bullish daily, want them let through")
+	}
+}
+
+func TestClosedDailyHasNoLookahead(t *testing.T) {
+	daily := bullishDays("BTCUSDT")[:3]
+	first := daily[0].OpenTime
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"during the first day", first.Add(12 * time.Hour), 0},
+		{"a millisecond before it closes", first.Add(24*time.Hour - time.Millisecond), 0},
+		{"as it closes", first.Add(24 * time.Hour), 1},
+		{"after every day", first.Add(72 * time.Hour), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closedDaily(daily, 0, tt.at); got != tt.want {
+				t.Errorf("closedDaily() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
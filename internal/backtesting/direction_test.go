@@ -0,0 +1,61 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"testing"
+	"time"
+)
+
+// sides runs the fixture backtest trading direction and counts its trades per side and its reversals
+func sides(t *testing.T, direction string) (map[string]int, int) {
+	t.Helper()
+	config := DefaultConfig()
+	config.Direction = direction
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	results, err := NewBacktestWithConfig(fixtureSource(), testStrategies(t), config).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	counts := make(map[string]int)
+	reversals := 0
+	for _, trade := range results.Trades {
+		counts[trade.Side]++
+		if trade.FromReversal {
+			reversals++
+		}
+	}
+	return counts, reversals
+}
+
+func TestSingleSideBacktestsTradeOnlyThatSide(t *testing.T) {
+	both, _ := sides(t, strategy.DirectionBoth)
+	if both[models.PositionSideLong] == 0 || both[models.PositionSideShort] == 0 {
+		t.Fatalf("the fixture traded %v, want valid setups on both sides", both)
+	}
+
+	tests := []struct {
+		direction string
+		excluded  string
+	}{
+		{models.PositionSideLong, models.PositionSideShort},
+		{models.PositionSideShort, models.PositionSideLong},
+	}
+	for _, tt := range tests {
+		t.Run(tt.direction, func(t *testing.T) {
+			counts, reversals := sides(t, tt.direction)
+			if counts[tt.direction] == 0 {
+				t.Errorf("%s-only made no %s trades", tt.direction, tt.direction)
+			}
+			if counts[tt.excluded] != 0 {
+				t.Errorf("%s-only made %d %s trades, want none", tt.direction, counts[tt.excluded], tt.excluded)
+			}
+			if reversals != 0 {
+				t.Errorf("%s-only reversed %d times, want reversals disabled", tt.direction, reversals)
+			}
+		})
+	}
+}
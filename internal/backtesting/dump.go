@@ -0,0 +1,178 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+// DumpColumns is the header row of a Dump, one column per value in this order:
+//
+//	open_time, symbol, open, high, low, close, volume   the base candle, open time in RFC3339 UTC
+//	ema_fast, ema_slow, rsi, macd, macd_signal,
+//	macd_histogram                                       analysis timeframe indicators at the close
+//	volume_ratio                                         latest volume over the recent average
+//	momentum                                             net close change over the last few candles
+//	raw_direction, raw_confidence                        from the indicators alone, see analysis.Inspection
+//	signal_<tf>, confidence_<tf>                         confluence vote per analysis.ConfluenceTimeFrames
+//	valid, direction, confidence, reason                 the strategy's decision, daily bias applied
+//	long_valid, short_valid                              whether it passed an entry on each side
+//
+// Values that do not exist yet, such as indicators still warming up or timeframes with too few
+// candles, are empty cells rather than zero
+var DumpColumns = dumpColumns()
+
+func dumpColumns() []string {
+	columns := []string{"open_time", "symbol", "open", "high", "low", "close", "volume",
+		"ema_fast", "ema_slow", "rsi", "macd", "macd_signal", "macd_histogram",
+		"volume_ratio", "momentum", "raw_direction", "raw_confidence"}
+	for _, tf := range analysis.ConfluenceTimeFrames {
+		columns = append(columns, "signal_"+tf, "confidence_"+tf)
+	}
+	return append(columns, "valid", "direction", "confidence", "reason", "long_valid", "short_valid")
+}
+
+// Dump writes what the strategies see on every base candle of symbols from start to end as CSV to w,
+// headed by DumpColumns. Each row is analyzed on the same lookahead-safe window a backtest would use
+// at the candle's close, on every candle rather than only at the cadence, and no positions are
+// simulated. Rows are streamed, so memory stays bounded by the analysis window whatever the period
+func (b *Backtest) Dump(ctx context.Context, w io.Writer, startTime, endTime time.Time, symbols []string) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(DumpColumns); err != nil {
+		return fmt.Errorf("failed to write dump: %v", err)
+	}
+
+	for _, symbol := range symbols {
+		skipped, err := b.checkHistory(symbol, startTime)
+		if err != nil {
+			return err
+		}
+		if skipped != nil {
+			log.Printf("Skipping %s: %s", symbol, skipped.Reason)
+			continue
+		}
+		if err := b.dumpSymbol(ctx, out, symbol, startTime, endTime); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	if err := out.Error(); err != nil {
+		return fmt.Errorf("failed to write dump: %v", err)
+	}
+	return nil
+}
+
+func (b *Backtest) dumpSymbol(ctx context.Context, out *csv.Writer, symbol string, startTime, endTime time.Time) error {
+	window := newCandleWindow(b.window)
+	daily, err := b.loadDaily(ctx, symbol, startTime, endTime)
+	if err != nil {
+		return err
+	}
+	closedDays, rows := 0, 0
+
+	err = b.source.StreamPricesByTimeFrame(symbol, BaseTimeFrame, b.warmUpStart(startTime), endTime, func(price models.Price) error {
+		window.Push(price)
+		if price.OpenTime.Before(startTime) {
+			return nil
+		}
+		if rows%progressEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		rows++
+
+		candles := window.Candles()
+		inspection := b.strategies.Inspect(symbol, candles)
+		result := b.strategies.Analyze(symbol, candles)
+		closedDays = closedDaily(daily, closedDays, candleClose(price))
+		b.strategies.ApplyDailyBias(symbol, result, daily[:closedDays], candleClose(price))
+
+		if err := out.Write(dumpRow(price, inspection, result)); err != nil {
+			return fmt.Errorf("failed to write dump: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Dumped %d candles of %s", rows, symbol)
+	return nil
+}
+
+// dumpRow formats one candle's row in DumpColumns order
+func dumpRow(price models.Price, inspection *analysis.Inspection, result *analysis.AnalysisResult) []string {
+	row := []string{
+		price.OpenTime.UTC().Format(time.RFC3339),
+		price.Symbol,
+		dumpFloat(price.Open),
+		dumpFloat(price.High),
+		dumpFloat(price.Low),
+		dumpFloat(price.Close),
+		dumpFloat(price.Volume),
+	}
+
+	if inspection == nil {
+		inspection = &analysis.Inspection{}
+	}
+	if ind := inspection.Indicators; ind != nil {
+		row = append(row, dumpFloat(ind.EMA8), dumpFloat(ind.EMA21), dumpFloat(ind.RSI),
+			dumpFloat(ind.MACD), dumpFloat(ind.Signal), dumpFloat(ind.Histogram))
+	} else {
+		row = append(row, "", "", "", "", "", "")
+	}
+	row = append(row, dumpPositive(inspection.VolumeRatio))
+	if inspection.Confluence != nil {
+		row = append(row, dumpFloat(inspection.Momentum))
+	} else {
+		row = append(row, "")
+	}
+	if inspection.Indicators != nil {
+		row = append(row, inspection.Direction, dumpFloat(inspection.Confidence))
+	} else {
+		row = append(row, "", "")
+	}
+
+	for _, tf := range analysis.ConfluenceTimeFrames {
+		vote, ok := inspection.Confluence[tf]
+		if !ok || vote.Insufficient {
+			row = append(row, "", "")
+			continue
+		}
+		row = append(row, strconv.Itoa(vote.Signal), dumpFloat(vote.Confidence))
+	}
+
+	if result.IsValid {
+		row = append(row, "true", result.Direction, dumpFloat(result.Confidence), "")
+	} else {
+		row = append(row, "false", "", "", result.Reason)
+	}
+	return append(row,
+		strconv.FormatBool(result.IsValid && result.Direction == models.PositionSideLong),
+		strconv.FormatBool(result.IsValid && result.Direction == models.PositionSideShort))
+}
+
+// dumpFloat formats v with as many digits as it takes to read it back exactly, empty for NaN or infinity
+func dumpFloat(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return ""
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// dumpPositive is dumpFloat for values where 0 means unavailable
+func dumpPositive(v float64) string {
+	if v <= 0 {
+		return ""
+	}
+	return dumpFloat(v)
+}
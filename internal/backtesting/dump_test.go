@@ -0,0 +1,100 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/testdb"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dumpGolden holds the dump of two hours of the fixture
+const dumpGolden = "testdata/fixture_dump.golden.csv"
+
+func TestDumpMatchesGolden(t *testing.T) {
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := start.Add(2*time.Hour - time.Minute)
+	var got bytes.Buffer
+	b := NewBacktestWithConfig(fixtureSource(), testStrategies(t), DefaultConfig())
+	if err := b.Dump(context.Background(), &got, start, end, []string{"BTCUSDT", "ETHUSDT"}); err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile(dumpGolden, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(dumpGolden)
+	if err != nil {
+		t.Fatalf("%v, run with -update to create it", err)
+	}
+
+	gotRows, err := csv.NewReader(bytes.NewReader(got.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows, err := csv.NewReader(bytes.NewReader(want)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(gotRows[0], ",") != strings.Join(DumpColumns, ",") {
+		t.Fatalf("header = %v, want DumpColumns", gotRows[0])
+	}
+	// A row per 5m candle of each symbol
+	if len(gotRows) != 1+2*24 || len(gotRows) != len(wantRows) {
+		t.Fatalf("got %d rows, golden has %d, want the header and 48 candles", len(gotRows), len(wantRows))
+	}
+	for i := range wantRows {
+		if len(gotRows[i]) != len(DumpColumns) {
+			t.Fatalf("row %d has %d cells, want %d", i, len(gotRows[i]), len(DumpColumns))
+		}
+		for j := range wantRows[i] {
+			if !sameCell(gotRows[i][j], wantRows[i][j]) {
+				t.Errorf("row %d %s = %q, want %q", i, DumpColumns[j], gotRows[i][j], wantRows[i][j])
+			}
+		}
+	}
+}
+
+// sameCell compares dump cells, numbers within the golden test's tolerance for fused multiply-adds
+func sameCell(got, want string) bool {
+	if got == want {
+		return true
+	}
+	g, errG := parseCell(got)
+	w, errW := parseCell(want)
+	return errG == nil && errW == nil && math.Abs(g-w) <= 1e-9*math.Max(1, math.Abs(w))
+}
+
+func parseCell(s string) (float64, error) {
+	var v float64
+	_, err := fmt.Sscan(s, &v)
+	return v, err
+}
+
+func TestDumpFloatLeavesMissingValuesEmpty(t *testing.T) {
+	for _, tt := range []struct {
+		v    float64
+		want string
+	}{
+		{math.NaN(), ""},
+		{math.Inf(1), ""},
+		{0, "0"},
+		{-0.25, "-0.25"},
+		{1.0 / 3, "0.3333333333333333"},
+	} {
+		if got := dumpFloat(tt.v); got != tt.want {
+			t.Errorf("dumpFloat(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+	if got := dumpPositive(0); got != "" {
+		t.Errorf("dumpPositive(0) = %q, want empty", got)
+	}
+}
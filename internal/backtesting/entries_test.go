@@ -0,0 +1,89 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"testing"
+)
+
+// limitConfig rests entries 0.05% below a long signal for 3 candles
+func limitConfig() Config {
+	config := exactConfig()
+	config.Entry.Mode = trading.EntryModeLimit
+	config.Entry.LimitOffset = 0.0005
+	config.Entry.ExpiryCandles = 3
+	return config
+}
+
+// pendingLong is a long signalled at 100 resting at 99.95, stop 98 and target 103
+func pendingLong() *PendingEntry {
+	return &PendingEntry{
+		Signal: &analysis.AnalysisResult{
+			Symbol:     "BTCUSDT",
+			IsValid:    true,
+			Direction:  models.PositionSideLong,
+			StopLoss:   98,
+			TakeProfit: 103,
+			Confidence: 0.8,
+		},
+		LimitPrice: trading.LimitPrice(models.PositionSideLong, 100, 0.0005),
+	}
+}
+
+func TestPendingLimitEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.Price
+		filled  bool
+		pending bool // Still resting after the candles
+	}{
+		{"fills once a low trades through", []models.Price{
+			candle("BTCUSDT", 1, 100, 100.5, 100.1, 100.3),
+			candle("BTCUSDT", 2, 100.3, 100.4, 99.9, 100),
+		}, true, false},
+		{"rests inside its window", []models.Price{
+			candle("BTCUSDT", 1, 100, 100.5, 100.1, 100.3),
+			candle("BTCUSDT", 2, 100.3, 100.6, 100.2, 100.4),
+		}, false, true},
+		{"expires after its window", []models.Price{
+			candle("BTCUSDT", 1, 100, 100.5, 100.1, 100.3),
+			candle("BTCUSDT", 2, 100.3, 100.6, 100.2, 100.4),
+			candle("BTCUSDT", 3, 100.4, 100.7, 100.3, 100.5),
+			candle("BTCUSDT", 4, 100.5, 100.6, 99.5, 99.8), // Would have filled
+		}, false, false},
+		{"is dropped once price runs to the target", []models.Price{
+			candle("BTCUSDT", 1, 100, 103.2, 100.1, 103),
+			candle("BTCUSDT", 2, 103, 103.1, 99.5, 99.8), // Would have filled
+		}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, limitConfig())
+			state := &CandleState{Symbol: "BTCUSDT", Pending: pendingLong()}
+			for i, price := range tt.candles {
+				state.Price, state.Index = price, i+1
+				if state.Pending != nil {
+					b.checkPendingEntry(state)
+				}
+			}
+
+			if got := state.Position != nil; got != tt.filled {
+				t.Fatalf("filled = %v, want %v", got, tt.filled)
+			}
+			if got := state.Pending != nil; got != tt.pending {
+				t.Errorf("pending = %v, want %v", got, tt.pending)
+			}
+			if tt.filled && (state.Position.EntryPrice < 99.949 || state.Position.EntryPrice > 99.951) {
+				t.Errorf("EntryPrice = %v, want the 99.95 limit", state.Position.EntryPrice)
+			}
+			want := 0
+			if tt.filled {
+				want = 1
+			}
+			if b.fills != want {
+				t.Errorf("fills = %d, want %d", b.fills, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"log"
+)
+
+// equityStop applies the live account equity stop at the candle's close, flattening every symbol's legs when it trips
+// Symbols share one clock, so the unrealized PnL counts this symbol's legs at the candle's close and the other
+// symbols' legs at their latest close, as the live account would see them
+func (b *Backtest) equityStop(state *CandleState) {
+	cfg := b.config.EquityStop
+	if cfg == nil {
+		return
+	}
+	if b.accountStop == nil {
+		b.accountStop = risk.NewEquityStop(*cfg, nil)
+	}
+
+	equity := b.currentBalance
+	for _, leg := range state.legs() {
+		if *leg != nil {
+			equity += tradePnL(*leg, state.Price.Close)
+		}
+	}
+	for _, run := range b.otherRuns(state.Symbol) {
+		for _, leg := range run.legs() {
+			equity += tradePnL(leg, run.last.Close)
+		}
+	}
+	tripped, _ := b.accountStop.Check(equity, state.Price.OpenTime)
+	if !tripped {
+		return
+	}
+
+	b.equityStopOuts++
+	log.Printf("Equity stop on %s at %s: equity %.2f below %.2f",
+		state.Symbol, state.Price.OpenTime.Format("2006-01-02 15:04"), equity, b.accountStop.Floor())
+	for _, leg := range state.legs() {
+		if *leg != nil {
+			b.closePosition(*leg, state.Price, state.Price.Close, trading.CloseReasonEquityStop)
+			*leg = nil
+			state.closedThisCandle = true
+		}
+	}
+	state.Pending, state.Queued = nil, nil
+
+	for _, run := range b.otherRuns(state.Symbol) {
+		for _, leg := range run.legs() {
+			b.closePosition(leg, run.last, run.last.Close, trading.CloseReasonEquityStop)
+			b.stepClosed = true
+		}
+		run.position, run.hedge, run.pending, run.queued = nil, nil, nil, nil
+	}
+}
+
+// otherRuns returns the runs of the symbols other than symbol
+func (b *Backtest) otherRuns(symbol string) []*symbolRun {
+	runs := make([]*symbolRun, 0, len(b.runs))
+	for _, run := range b.runs {
+		if run.symbol != symbol {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// equityBlocked reports whether the account's equity stop tripped this session
+func (b *Backtest) equityBlocked(state *CandleState) bool {
+	if b.accountStop == nil {
+		return false
+	}
+	blocked, _ := b.accountStop.Blocked(state.Price.OpenTime)
+	return blocked
+}
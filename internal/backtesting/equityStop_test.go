@@ -0,0 +1,59 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEquityStopFlattensEverySymbol(t *testing.T) {
+	config := exactConfig()
+	config.EquityStop = &risk.EquityStopConfig{Floor: 0.9}
+	b := newTestBacktest(t, config)
+	b.ctx = context.Background()
+
+	// Three positions of 1 USDT margin at 50x, each losing 0.5 USDT on a 1% adverse move
+	eth := &symbolRun{symbol: "ETHUSDT", position: openTrade("ETHUSDT", models.PositionSideLong, 100, 90, 110)}
+	sol := &symbolRun{symbol: "SOLUSDT", position: openTrade("SOLUSDT", models.PositionSideShort, 100, 110, 90)}
+	eth.last = candle("ETHUSDT", 0, 100, 100, 100, 100)
+	sol.last = candle("SOLUSDT", 0, 100, 100, 100, 100)
+	b.runs = []*symbolRun{eth, sol}
+	state := &CandleState{Symbol: "BTCUSDT", Position: openTrade("BTCUSDT", models.PositionSideLong, 100, 90, 110)}
+
+	// The session starts at the 10 USDT balance with nothing lost yet
+	state.Price = candle("BTCUSDT", 0, 100, 100, 100, 100)
+	b.equityStop(state)
+	if len(b.trades) != 0 || b.equityBlocked(state) {
+		t.Fatalf("stopped at the session's starting equity, %d trades closed", len(b.trades))
+	}
+
+	// 1.5 USDT lost leaves 8.5, below the 9 floor
+	eth.last = candle("ETHUSDT", 1, 99, 99, 99, 99)
+	sol.last = candle("SOLUSDT", 1, 101, 101, 101, 101)
+	state.Price = candle("BTCUSDT", 1, 99, 99, 99, 99)
+	b.equityStop(state)
+
+	if len(b.trades) != 3 {
+		t.Fatalf("%d trades closed, want all 3", len(b.trades))
+	}
+	for _, trade := range b.trades {
+		if trade.Reason != trading.CloseReasonEquityStop {
+			t.Errorf("%s closed by %s, want %s", trade.Symbol, trade.Reason, trading.CloseReasonEquityStop)
+		}
+	}
+	if state.Position != nil || eth.position != nil || sol.position != nil {
+		t.Error("a leg is still open after the stop")
+	}
+	if !b.equityBlocked(state) || b.equityStopOuts != 1 {
+		t.Errorf("blocked %v after %d stop-outs, want entries blocked after 1", b.equityBlocked(state), b.equityStopOuts)
+	}
+
+	// Entries resume with the next UTC day's session
+	state.Price.OpenTime = state.Price.OpenTime.Add(24 * time.Hour)
+	if b.equityBlocked(state) {
+		t.Error("entries still blocked on the next UTC day")
+	}
+}
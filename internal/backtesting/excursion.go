@@ -0,0 +1,62 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/trading"
+	"math"
+	"sort"
+)
+
+// Percentiles summarizes a distribution
+type Percentiles struct {
+	P25 float64
+	P50 float64
+	P75 float64
+	P90 float64
+}
+
+// ExcursionStats are the MAE and MFE distributions of a set of trades, in R
+type ExcursionStats struct {
+	MAE Percentiles
+	MFE Percentiles
+}
+
+// trackExcursion widens the trade's MAE and MFE to the prices from low to high, clamped to bounds when given
+func (t *Trade) trackExcursion(low, high float64, bounds ...float64) {
+	if len(bounds) > 0 {
+		low, high = trading.ClampRange(low, high, bounds...)
+	}
+	adverse, favorable := trading.Excursion(t.Side, t.EntryPrice, low, high)
+	t.MAE = math.Max(t.MAE, adverse)
+	t.MFE = math.Max(t.MFE, favorable)
+	t.MAER = trading.InR(t.MAE, t.InitialStopDistance)
+	t.MFER = trading.InR(t.MFE, t.InitialStopDistance)
+}
+
+// Excursions returns the MAE and MFE distributions of trades
+func Excursions(trades []Trade) ExcursionStats {
+	mae := make([]float64, len(trades))
+	mfe := make([]float64, len(trades))
+	for i, t := range trades {
+		mae[i], mfe[i] = t.MAER, t.MFER
+	}
+	return ExcursionStats{MAE: percentiles(mae), MFE: percentiles(mfe)}
+}
+
+// percentiles interpolates between the closest ranks, all zero for no values
+func percentiles(values []float64) Percentiles {
+	if len(values) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	at := func(p float64) float64 {
+		rank := p * float64(len(sorted)-1)
+		i := int(rank)
+		if i+1 >= len(sorted) {
+			return sorted[i]
+		}
+		return sorted[i] + (rank-float64(i))*(sorted[i+1]-sorted[i])
+	}
+	return Percentiles{P25: at(0.25), P50: at(0.5), P75: at(0.75), P90: at(0.9)}
+}
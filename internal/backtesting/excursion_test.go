@@ -0,0 +1,102 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"math"
+	"testing"
+)
+
+// excursions is a trade's MAE and MFE in price and in R
+type excursions struct {
+	mae, mfe, maeR, mfeR float64
+}
+
+func (e excursions) matches(t Trade) bool {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	return near(t.MAE, e.mae) && near(t.MFE, e.mfe) && near(t.MAER, e.maeR) && near(t.MFER, e.mfeR)
+}
+
+func TestExcursionsFollowAScriptedPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		trade   *Trade
+		candles []models.Price
+		want    excursions
+	}{
+		// 0.5 against, then 1 against and 2.5 in favor, then through the target at 104,
+		// whose fill caps the favorable excursion
+		{"long to its target", openTrade("BTCUSDT", models.PositionSideLong, 100, 98, 104), []models.Price{
+			candle("BTCUSDT", 1, 100, 101, 99.5, 100.5),
+			candle("BTCUSDT", 2, 100.5, 102.5, 99, 102),
+			candle("BTCUSDT", 3, 102, 103, 100.2, 102.8),
+			candle("BTCUSDT", 4, 102.8, 105, 101, 104.5),
+		}, excursions{mae: 1, mfe: 4, maeR: 0.5, mfeR: 2}},
+		{"short to its target", openTrade("BTCUSDT", models.PositionSideShort, 100, 102, 96), []models.Price{
+			candle("BTCUSDT", 1, 100, 100.5, 99, 99.5),
+			candle("BTCUSDT", 2, 99.5, 101, 97.5, 98),
+			candle("BTCUSDT", 3, 98, 99.8, 97, 97.2),
+			candle("BTCUSDT", 4, 97.2, 99, 95, 95.5),
+		}, excursions{mae: 1, mfe: 4, maeR: 0.5, mfeR: 2}},
+		// The stop fill bounds the adverse excursion however far the candle went through it
+		{"long to its stop", openTrade("BTCUSDT", models.PositionSideLong, 100, 98, 104), []models.Price{
+			candle("BTCUSDT", 1, 100, 100.8, 99.2, 100.4),
+			candle("BTCUSDT", 2, 100.4, 100.6, 96, 96.5),
+		}, excursions{mae: 2, mfe: 0.8, maeR: 1, mfeR: 0.4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			trades := stepExits(b, &CandleState{Symbol: "BTCUSDT", Position: tt.trade}, tt.candles...)
+			if len(trades) != 1 {
+				t.Fatalf("got %d closed trades, want 1", len(trades))
+			}
+			if !tt.want.matches(trades[0]) {
+				t.Errorf("MAE %v MFE %v (%vR, %vR), want %+v", trades[0].MAE, trades[0].MFE, trades[0].MAER, trades[0].MFER, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcursionsOfATradeClosedOnItsEntryCandle(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	signal := &analysis.AnalysisResult{Symbol: "BTCUSDT", IsValid: true, Direction: models.PositionSideLong,
+		EntryPrice: 100, StopLoss: 99, TakeProfit: 102, Confidence: 0.8}
+	state := &CandleState{Symbol: "BTCUSDT", Queued: &QueuedEntry{Signal: signal}}
+
+	// Fills at the open of 100, trades up to 101.5, then down through the stop
+	state.Price = candle("BTCUSDT", 1, 100, 101.5, 97, 97.5)
+	b.fillQueued(state)
+	if state.Position == nil {
+		t.Fatal("the queued entry did not fill")
+	}
+	b.protectiveExits(state)
+	if len(b.trades) != 1 {
+		t.Fatalf("got %d closed trades, want the entry stopped out on its candle", len(b.trades))
+	}
+	if want := (excursions{mae: 1, mfe: 1.5, maeR: 1, mfeR: 1.5}); !want.matches(b.trades[0]) {
+		trade := b.trades[0]
+		t.Errorf("MAE %v MFE %v (%vR, %vR), want %+v", trade.MAE, trade.MFE, trade.MAER, trade.MFER, want)
+	}
+}
+
+func TestExcursionPercentiles(t *testing.T) {
+	var trades []Trade
+	for i := 1; i <= 5; i++ {
+		trades = append(trades, Trade{MAER: float64(i) / 10, MFER: float64(i)})
+	}
+	stats := Excursions(trades)
+	want := ExcursionStats{
+		MAE: Percentiles{P25: 0.2, P50: 0.3, P75: 0.4, P90: 0.46},
+		MFE: Percentiles{P25: 2, P50: 3, P75: 4, P90: 4.6},
+	}
+	near := func(a, b Percentiles) bool {
+		return math.Abs(a.P25-b.P25) < 1e-9 && math.Abs(a.P50-b.P50) < 1e-9 && math.Abs(a.P75-b.P75) < 1e-9 && math.Abs(a.P90-b.P90) < 1e-9
+	}
+	if !near(stats.MAE, want.MAE) || !near(stats.MFE, want.MFE) {
+		t.Errorf("Excursions() = %+v, want %+v", stats, want)
+	}
+	if got := Excursions(nil); got != (ExcursionStats{}) {
+		t.Errorf("Excursions(nil) = %+v, want zeros", got)
+	}
+}
@@ -0,0 +1,139 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// SameBarPolicy decides which exit fills when one candle reaches both the take profit and the stop loss
+type SameBarPolicy string
+
+const (
+	SameBarWorstCase SameBarPolicy = "worst-case"            // The stop loss was hit first
+	SameBarBestCase  SameBarPolicy = "best-case"             // The take profit was hit first
+	SameBarProbe     SameBarPolicy = "probe-lower-timeframe" // Replay the 1m candles, worst case when they cannot tell
+
+	// Draw the first hit at random, the level nearer the open being the likelier
+	SameBarProbabilistic SameBarPolicy = "probabilistic"
+)
+
+// ProbeTimeFrame is the timeframe SameBarProbe replays an ambiguous candle on
+const ProbeTimeFrame = models.PriceTimeFrame1m
+
+// DefaultExitSlippage is how much worse than its price a stop loss fills, as a fraction
+const DefaultExitSlippage = 0.0005
+
+// ParseSameBarPolicy validates a policy name
+func ParseSameBarPolicy(value string) (SameBarPolicy, error) {
+	switch policy := SameBarPolicy(value); policy {
+	case SameBarWorstCase, SameBarBestCase, SameBarProbe, SameBarProbabilistic:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid same-bar policy %q, want %s, %s, %s or %s", value, SameBarWorstCase, SameBarBestCase, SameBarProbe, SameBarProbabilistic)
+}
+
+// resolveExit reports whether the candle closes trade at its take profit or stop loss, and at what price
+func (b *Backtest) resolveExit(trade *Trade, price models.Price) (string, float64, bool) {
+	hitTP, hitSL := touches(trade, price)
+
+	var reason string
+	switch {
+	case hitTP && hitSL:
+		reason = b.sameBarExit(trade, price)
+	case hitTP:
+		reason = "take_profit"
+	case hitSL:
+		reason = "stop_loss"
+	default:
+		return "", 0, false
+	}
+	return reason, b.exitFill(trade, price, reason), true
+}
+
+// touches reports whether the candle's range reaches the take profit and the stop loss of trade
+func touches(trade *Trade, price models.Price) (bool, bool) {
+	if trade.Side == models.PositionSideLong {
+		return trading.PriceGTE(price.High, trade.TakeProfit), trading.PriceLTE(price.Low, trade.StopLoss)
+	}
+	return trading.PriceLTE(price.Low, trade.TakeProfit), trading.PriceGTE(price.High, trade.StopLoss)
+}
+
+// sameBarExit picks the exit of a candle that reached both levels
+// A candle opening beyond a level hit it first; otherwise the order is unknown and the policy decides
+func (b *Backtest) sameBarExit(trade *Trade, price models.Price) string {
+	gapTP, gapSL := touches(trade, models.Price{Open: price.Open, High: price.Open, Low: price.Open})
+	switch {
+	case gapSL:
+		return "stop_loss"
+	case gapTP:
+		return "take_profit"
+	}
+
+	b.ambiguousBars++
+	switch b.config.SameBar {
+	case SameBarBestCase:
+		return "take_profit"
+	case SameBarProbe:
+		if reason, ok := b.probeFirstHit(trade, price); ok {
+			b.probedBars++
+			return reason
+		}
+	case SameBarProbabilistic:
+		toTP := math.Abs(price.Open - trade.TakeProfit)
+		toSL := math.Abs(price.Open - trade.StopLoss)
+		if b.random.Stream("same-bar").Float64()*(toTP+toSL) < toSL {
+			return "take_profit"
+		}
+	}
+	return "stop_loss"
+}
+
+// probeFirstHit replays the 1m candles inside price to find which level was reached first
+// It fails when they are missing or a single one of them reaches both levels
+func (b *Backtest) probeFirstHit(trade *Trade, price models.Price) (string, bool) {
+	end := price.OpenTime.Add(models.TimeFrameDurations[BaseTimeFrame] - time.Nanosecond)
+	candles, err := b.source.GetPricesByTimeFrame(b.ctx, trade.Symbol, ProbeTimeFrame, price.OpenTime, end)
+	if err != nil {
+		log.Printf("Error getting %s candles for %s at %s: %v", ProbeTimeFrame, trade.Symbol, price.OpenTime.Format("2006-01-02 15:04"), err)
+		return "", false
+	}
+
+	for _, candle := range candles {
+		hitTP, hitSL := touches(trade, candle)
+		switch {
+		case hitTP && hitSL:
+			return "", false
+		case hitSL:
+			return "stop_loss", true
+		case hitTP:
+			return "take_profit", true
+		}
+	}
+	return "", false
+}
+
+// exitFill returns the fill price of an exit: a take profit fills at its price, a stop loss
+// slips by ExitSlippage plus jitter, and either fills at the open when the candle gapped through it
+func (b *Backtest) exitFill(trade *Trade, price models.Price, reason string) float64 {
+	long := trade.Side == models.PositionSideLong
+
+	if reason == "take_profit" {
+		if (long && price.Open > trade.TakeProfit) || (!long && price.Open < trade.TakeProfit) {
+			return price.Open
+		}
+		return trade.TakeProfit
+	}
+
+	slippage := b.config.ExitSlippage
+	if b.config.SlippageJitter > 0 {
+		slippage += b.random.Stream("slippage").Float64() * b.config.SlippageJitter
+	}
+	if long {
+		return min(price.Open, trade.StopLoss) * (1 - slippage)
+	}
+	return max(price.Open, trade.StopLoss) * (1 + slippage)
+}
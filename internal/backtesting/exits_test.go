@@ -0,0 +1,130 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// oneMinute returns the 1m candle i minutes into the 5m candle 1, the one the exit tests step through
+func oneMinute(i int, high, low float64) models.Price {
+	price := candle("BTCUSDT", 1, 100, high, low, 100)
+	price.TimeFrame = models.PriceTimeFrame1m
+	price.OpenTime = price.OpenTime.Add(time.Duration(i) * time.Minute)
+	price.CloseTime = price.OpenTime.Add(time.Minute - time.Millisecond)
+	return price
+}
+
+func TestSameBarPolicies(t *testing.T) {
+	if policy := DefaultConfig().SameBar; policy != SameBarWorstCase {
+		t.Errorf("default same-bar policy = %q, want %q", policy, SameBarWorstCase)
+	}
+
+	// A long at 100 exiting at 99 or 103, and a candle reaching both from an open in between
+	spanning := candle("BTCUSDT", 1, 100, 103.5, 98.5, 101)
+	targetFirst := []models.Price{oneMinute(0, 101, 99.8), oneMinute(1, 103.5, 100), oneMinute(2, 101, 98.5)}
+	stopFirst := []models.Price{oneMinute(0, 100.5, 98.5), oneMinute(1, 103.5, 99.5)}
+	bothInOne := []models.Price{oneMinute(0, 103.5, 98.5)}
+
+	tests := []struct {
+		name     string
+		policy   SameBarPolicy
+		slippage float64
+		minutes  []models.Price
+		reason   string
+		fill     float64
+		probed   int
+	}{
+		{"worst case", SameBarWorstCase, 0, nil, "stop_loss", 99, 0},
+		{"worst case slips the stop", SameBarWorstCase, 0.001, nil, "stop_loss", 99 * 0.999, 0},
+		{"best case", SameBarBestCase, 0, nil, "take_profit", 103, 0},
+		{"probe finds the target first", SameBarProbe, 0, targetFirst, "take_profit", 103, 1},
+		{"probe finds the stop first", SameBarProbe, 0, stopFirst, "stop_loss", 99, 1},
+		{"probe without 1m candles", SameBarProbe, 0, nil, "stop_loss", 99, 0},
+		{"probe with one 1m candle reaching both", SameBarProbe, 0, bothInOne, "stop_loss", 99, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := exactConfig()
+			config.SameBar = tt.policy
+			config.ExitSlippage = tt.slippage
+			b := newTestBacktest(t, config, tt.minutes...)
+			b.ctx = context.Background()
+			state := &CandleState{Symbol: "BTCUSDT", Position: openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 103)}
+
+			trades := stepExits(b, state, spanning)
+			if len(trades) != 1 {
+				t.Fatalf("%d trades closed, want 1", len(trades))
+			}
+			if got := trades[0]; got.Reason != tt.reason || math.Abs(got.ExitPrice-tt.fill) > 1e-9 {
+				t.Errorf("closed by %s at %v, want %s at %v", got.Reason, got.ExitPrice, tt.reason, tt.fill)
+			}
+			results := b.calculateResults(testStart, testStart.Add(time.Hour))
+			if results.AmbiguousBars != 1 || results.ProbedBars != tt.probed {
+				t.Errorf("%d ambiguous and %d probed bars reported, want 1 and %d", results.AmbiguousBars, results.ProbedBars, tt.probed)
+			}
+		})
+	}
+}
+
+func TestGapThroughALevelIsNotAmbiguous(t *testing.T) {
+	tests := []struct {
+		name   string
+		price  models.Price
+		reason string
+		fill   float64
+	}{
+		{"opens below the stop", candle("BTCUSDT", 1, 98.7, 103.5, 98.5, 101), "stop_loss", 98.7},
+		{"opens above the target", candle("BTCUSDT", 1, 103.2, 103.5, 98.5, 101), "take_profit", 103.2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The open shows which level went first whatever the policy
+			config := exactConfig()
+			config.SameBar = SameBarBestCase
+			b := newTestBacktest(t, config)
+			state := &CandleState{Symbol: "BTCUSDT", Position: openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 103)}
+
+			trades := stepExits(b, state, tt.price)
+			if len(trades) != 1 || trades[0].Reason != tt.reason || trades[0].ExitPrice != tt.fill {
+				t.Fatalf("closed %+v, want %s at the open %v", trades, tt.reason, tt.fill)
+			}
+			if b.ambiguousBars != 0 {
+				t.Errorf("a gap counted as %d ambiguous bars", b.ambiguousBars)
+			}
+		})
+	}
+}
+
+func TestExitsTriggerOnDriftedLevels(t *testing.T) {
+	// Levels read back from the database a hair off: the candle's 0.07233999999 is the stored 0.072340 target
+	tests := []struct {
+		name      string
+		side      string
+		high, low float64
+		reason    string
+	}{
+		{"long reaches its target", models.PositionSideLong, 0.07233999999, 0.0722, "take_profit"},
+		{"long reaches its stop", models.PositionSideLong, 0.0723, 0.07200000001, "stop_loss"},
+		{"short reaches its target", models.PositionSideShort, 0.0723, 0.07214000001, "take_profit"},
+		{"short reaches its stop", models.PositionSideShort, 0.07239999999, 0.0722, "stop_loss"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			b.ctx = context.Background()
+			trade := openTrade("BTCUSDT", tt.side, 0.0722, 0.072, 0.07234)
+			if tt.side == models.PositionSideShort {
+				trade = openTrade("BTCUSDT", tt.side, 0.0722, 0.0724, 0.07214)
+			}
+			state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+			trades := stepExits(b, state, candle("BTCUSDT", 1, 0.0722, tt.high, tt.low, 0.0722))
+			if len(trades) != 1 || trades[0].Reason != tt.reason {
+				t.Fatalf("closed %+v, want one trade closed by %s", trades, tt.reason)
+			}
+		})
+	}
+}
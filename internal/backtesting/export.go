@@ -0,0 +1,141 @@
+package backtesting
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export writes the results in the format named by the path's extension: .json, .csv or .html
+// JSON keeps everything and can be loaded back, CSV holds one row per trade and HTML a readable summary
+func (r *BacktestResults) Export(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return r.Save(path)
+	case ".csv":
+		return r.writeCSV(path)
+	case ".html", ".htm":
+		return r.writeHTML(path)
+	default:
+		return fmt.Errorf("unknown export format %q, use .json, .csv or .html", filepath.Ext(path))
+	}
+}
+
+func (r *BacktestResults) writeCSV(path string) error {
+	return ExportTradesCSV(path, r.Trades)
+}
+
+// ExportTradesCSV writes trades to path in the CSV format of Export, one row per trade
+func ExportTradesCSV(path string, trades []Trade) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create csv: %v", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"symbol", "side", "entry_time", "exit_time", "entry_price", "exit_price", "size",
+		"stop_loss", "take_profit", "pnl", "reason", "confidence", "mae_r", "mfe_r", "r_multiple", "size_multiplier"})
+	for _, t := range trades {
+		w.Write([]string{
+			t.Symbol,
+			t.Side,
+			t.EntryTime.UTC().Format("2006-01-02T15:04:05Z"),
+			t.ExitTime.UTC().Format("2006-01-02T15:04:05Z"),
+			exportFloat(t.EntryPrice),
+			exportFloat(t.ExitPrice),
+			exportFloat(t.Size),
+			exportFloat(t.StopLoss),
+			exportFloat(t.TakeProfit),
+			exportFloat(t.PnL),
+			t.Reason,
+			exportFloat(t.Confidence),
+			exportFloat(t.MAER),
+			exportFloat(t.MFER),
+			exportR(t.RMultiple),
+			exportFloat(t.confidenceFactor()),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write csv: %v", err)
+	}
+	return nil
+}
+
+func exportFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 8, 64)
+}
+
+// exportR formats an R multiple, empty for trades without one
+func exportR(r *float64) string {
+	if r == nil {
+		return ""
+	}
+	return exportFloat(*r)
+}
+
+var resultsTemplate = template.Must(template.New("results").Funcs(template.FuncMap{
+	"pct":   func(v float64) string { return fmt.Sprintf("%.2f%%", v*100) },
+	"usdt":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"stamp": func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;font-size:14px">
+<h2>Results</h2>
+<table cellpadding="4">
+<tr><td>Total Trades</td><td>{{.TotalTrades}}</td></tr>
+<tr><td>Winning Trades</td><td>{{.WinningTrades}}</td></tr>
+<tr><td>Losing Trades</td><td>{{.LosingTrades}}</td></tr>
+<tr><td>Win Rate</td><td>{{pct .WinRate}}</td></tr>
+<tr><td>Average PnL</td><td>{{usdt .AveragePnL}} USDT</td></tr>
+<tr><td>Liquidations</td><td>{{.Liquidations}}</td></tr>
+<tr><td>Max Drawdown</td><td>{{pct .MaxDrawdown}}</td></tr>
+{{if .RatiosInsufficient}}<tr><td>Sharpe, Sortino, Calmar</td><td>insufficient data ({{.RatioDays}} daily returns)</td></tr>
+{{else}}<tr><td>Sharpe Ratio</td><td>{{usdt .SharpeRatio}}</td></tr>
+<tr><td>Sortino Ratio</td><td>{{usdt .SortinoRatio}}</td></tr>
+<tr><td>Calmar Ratio</td><td>{{usdt .CalmarRatio}}</td></tr>
+{{end}}<tr><td>Final Balance</td><td>{{usdt .FinalBalance}} USDT</td></tr>
+<tr><td>Total R</td><td>{{usdt .R.Total}}R over {{.R.Trades}} trades ({{.R.Excluded}} without an initial stop)</td></tr>
+<tr><td>Average R</td><td>{{usdt .R.Average}}R</td></tr>
+<tr><td>Trades &ge; 2R</td><td>{{pct .R.AtLeast2R}}</td></tr>
+</table>
+{{if .Skipped}}<h3>Skipped Symbols</h3>
+<table cellpadding="4" border="1" style="border-collapse:collapse">
+<tr><th>Symbol</th><th>Reason</th></tr>
+{{range .Skipped}}<tr><td>{{.Symbol}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+{{end}}<h3>R Distribution</h3>
+<table cellpadding="4" border="1" style="border-collapse:collapse">
+<tr><th>R</th><th>Trades</th></tr>
+{{range .R.Buckets}}<tr><td>{{.Label}}</td><td>{{.Count}}</td></tr>
+{{end}}</table>
+{{if .Confidence}}<h3>By Confidence</h3>
+<table cellpadding="4" border="1" style="border-collapse:collapse">
+<tr><th>Confidence</th><th>Trades</th><th>Win Rate</th><th>PnL</th><th>Average R</th><th>Size</th></tr>
+{{range .Confidence}}<tr><td>{{.Label}}</td><td>{{.Trades}}</td><td>{{pct .WinRate}}</td><td>{{usdt .PnL}}</td><td>{{usdt .AverageR}}R</td><td>x{{usdt .SizeMultiplier}}</td></tr>
+{{end}}</table>
+{{end}}<h3>Trades</h3>
+<table cellpadding="4" border="1" style="border-collapse:collapse">
+<tr><th>Entry</th><th>Symbol</th><th>Side</th><th>Entry Price</th><th>Exit Price</th><th>PnL</th><th>Reason</th></tr>
+{{range .Trades}}<tr><td>{{stamp .EntryTime}}</td><td>{{.Symbol}}</td><td>{{.Side}}</td><td>{{.EntryPrice}}</td><td>{{.ExitPrice}}</td><td>{{usdt .PnL}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+func (r *BacktestResults) writeHTML(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create html: %v", err)
+	}
+	defer file.Close()
+
+	if err := resultsTemplate.Execute(file, r); err != nil {
+		return fmt.Errorf("failed to render html: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"math"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testStrategies returns a manager running the default strategy for every symbol
+func testStrategies(t testing.TB) *strategy.StrategyManager {
+	t.Helper()
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strategies
+}
+
+// newTestBacktest returns a backtest with the default strategy over prices
+func newTestBacktest(t *testing.T, config Config, prices ...models.Price) *Backtest {
+	t.Helper()
+	return NewBacktestWithConfig(NewSliceSource(prices), testStrategies(t), config)
+}
+
+// exactConfig returns the default settings without exit slippage, so fills land on their levels
+func exactConfig() Config {
+	config := DefaultConfig()
+	config.ExitSlippage = 0
+	return config
+}
+
+// candle returns the 5m candle of symbol i candles after testStart
+func candle(symbol string, i int, open, high, low, close float64) models.Price {
+	openTime := testStart.Add(time.Duration(i) * 5 * time.Minute)
+	return models.Price{
+		Symbol:    symbol,
+		TimeFrame: BaseTimeFrame,
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     close,
+		Volume:    100,
+	}
+}
+
+// openTrade returns a position of the fixed size on side entered at entry on the candle at testStart
+func openTrade(symbol, side string, entry, stop, target float64) *Trade {
+	margin := float64(FixedSize)
+	return &Trade{
+		Symbol:              symbol,
+		EntryTime:           testStart,
+		Side:                side,
+		EntryPrice:          entry,
+		Size:                margin / entry,
+		StopLoss:            stop,
+		TakeProfit:          target,
+		InitialStopDistance: math.Abs(entry - stop),
+		LiquidationPrice:    0,
+		RiskMultiplier:      1,
+		Margin:              margin,
+	}
+}
+
+// stepExits runs the protective exits of each candle against state, returning the closed trades
+func stepExits(b *Backtest, state *CandleState, candles ...models.Price) []Trade {
+	for i, price := range candles {
+		state.Price = price
+		state.Index = i + 1
+		state.closedThisCandle = false
+		b.protectiveExits(state)
+	}
+	return b.trades
+}
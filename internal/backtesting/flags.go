@@ -0,0 +1,113 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Flags are the command line flags of the settings only backtests have, see Config
+// The settings shared with live trading come from trading.TradeFlags
+type Flags struct {
+	timing         string
+	gapTolerance   float64
+	sameBar        string
+	exitSlippage   float64
+	slippageJitter float64
+	seed           int64
+	direction      string
+
+	maxParticipation float64
+	liquidityMode    string
+	liquidityCandles int
+
+	riskFreeRate float64
+	minRatioDays int
+
+	sweepReversalMargin string
+}
+
+// RegisterFlags defines the flags on fs, defaulting to DefaultConfig
+func (f *Flags) RegisterFlags(fs *flag.FlagSet) {
+	defaults := DefaultConfig()
+	fs.StringVar(&f.timing, "execution-timing", string(defaults.Timing), "When backtested market entries fill: 'next_open' of the candle after the signal or the signal candle's 'close'")
+	fs.Float64Var(&f.gapTolerance, "gap-tolerance", defaults.GapTolerance, "Fraction the next open may move from the signal close before a next_open backtest entry is skipped")
+	fs.StringVar(&f.sameBar, "same-bar", string(defaults.SameBar), "Backtest exit when a candle reaches both take profit and stop loss: 'worst-case', 'best-case', 'probe-lower-timeframe' or 'probabilistic'")
+	fs.Float64Var(&f.exitSlippage, "exit-slippage", defaults.ExitSlippage, "Fraction of the stop price backtested stop losses fill worse by")
+	fs.Float64Var(&f.slippageJitter, "slippage-jitter", 0, "Up to this fraction of extra backtest stop slippage, drawn at random")
+	fs.Int64Var(&f.seed, "seed", defaults.Seed, "Seed of the backtest's random choices, the same seed reproduces a run")
+	fs.StringVar(&f.direction, "direction", defaults.Direction, "Backtest entries on one side only: 'long', 'short' or 'both'; single sides disable reversals")
+
+	fs.Float64Var(&f.maxParticipation, "max-participation", 0, "Share of a candle's quote volume one backtest entry may fill, e.g. 0.05; 0 fills entries in full")
+	fs.StringVar(&f.liquidityMode, "liquidity-mode", LiquidityClip, "What becomes of the part of a backtest entry beyond -max-participation: 'clip' drops it, 'spread' fills it at the close of the next candles")
+	fs.IntVar(&f.liquidityCandles, "liquidity-candles", DefaultLiquidityCandles, "Candles after its entry a spread backtest entry may keep filling over")
+
+	fs.Float64Var(&f.riskFreeRate, "risk-free-rate", defaults.Ratios.RiskFreeRate, "Annual risk-free rate the Sharpe and Sortino ratios measure against, as a fraction")
+	fs.IntVar(&f.minRatioDays, "min-ratio-days", defaults.Ratios.MinDays, "Daily returns needed before the Sharpe, Sortino and Calmar ratios are reported")
+
+	fs.StringVar(&f.sweepReversalMargin, "sweep-reversal-margin", "", "Comma separated reversal margins to backtest one after another and compare, e.g. 0,0.05,0.1")
+}
+
+// Config validates the parsed flags into backtest settings over trade, the settings shared with live trading
+func (f *Flags) Config(trade trading.TradeConfig) (Config, error) {
+	config := DefaultConfig()
+	config.Entry = trade.Entry
+	config.Reversal = trade.Reversal
+	config.Performance = trade.Performance
+	config.Scaling = trade.Scaling
+	config.EquityStop = trade.EquityStop
+	config.Frequency = trade.Frequency
+	config.Sizing = &trade.Sizing
+	config.BreakevenAtR = trade.BreakevenAtR
+	config.Ratios = f.Ratios()
+	config.GapTolerance = f.gapTolerance
+	config.ExitSlippage = f.exitSlippage
+	config.SlippageJitter = f.slippageJitter
+	config.Seed = f.seed
+
+	var err error
+	if config.Timing, err = ParseExecutionTiming(f.timing); err != nil {
+		return Config{}, err
+	}
+	if config.SameBar, err = ParseSameBarPolicy(f.sameBar); err != nil {
+		return Config{}, err
+	}
+	if f.direction != strategy.DirectionBoth && f.direction != models.PositionSideLong && f.direction != models.PositionSideShort {
+		return Config{}, fmt.Errorf("invalid direction %q, use 'long', 'short' or 'both'", f.direction)
+	}
+	config.Direction = f.direction
+
+	if f.maxParticipation > 0 {
+		liquidity := LiquidityConfig{MaxParticipation: f.maxParticipation, Mode: f.liquidityMode, MaxCandles: f.liquidityCandles}
+		if err := liquidity.Validate(); err != nil {
+			return Config{}, err
+		}
+		config.Liquidity = &liquidity
+	}
+	return config, nil
+}
+
+// Ratios returns the ratio settings, which live results are measured with too
+func (f *Flags) Ratios() RatioConfig {
+	return RatioConfig{RiskFreeRate: f.riskFreeRate, MinDays: f.minRatioDays}
+}
+
+// SweepMargins returns the reversal margins to sweep, none when no sweep was asked for
+func (f *Flags) SweepMargins() ([]float64, error) {
+	var margins []float64
+	for _, field := range strings.Split(f.sweepReversalMargin, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		margin, err := strconv.ParseFloat(field, 64)
+		if err != nil || margin < 0 {
+			return nil, fmt.Errorf("invalid reversal margin %q", field)
+		}
+		margins = append(margins, margin)
+	}
+	return margins, nil
+}
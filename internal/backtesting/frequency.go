@@ -0,0 +1,43 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"time"
+)
+
+// throttled applies the live daily trade caps to an entry signalled by the state's candle
+// Symbols share one clock, so the account-wide cap fills up in the order entries happen, as it does live
+func (b *Backtest) throttled(state *CandleState) bool {
+	return b.throttledAt(state.Symbol, b.signalEntryTime(state))
+}
+
+// throttledAt reports whether a position on symbol opened at entryTime would be over a daily cap
+func (b *Backtest) throttledAt(symbol string, entryTime time.Time) bool {
+	if !b.config.Frequency.Enabled() {
+		return false
+	}
+	day := risk.TradeDay(entryTime)
+	return b.config.Frequency.Exceeded(symbol, b.openedBySymbol[tradeDayKey(symbol, day)], b.opened[day]) != ""
+}
+
+// countOpened records a position opened at entryTime against the daily trade caps
+func (b *Backtest) countOpened(symbol string, entryTime time.Time) {
+	day := risk.TradeDay(entryTime)
+	b.openedBySymbol[tradeDayKey(symbol, day)]++
+	b.opened[day]++
+}
+
+// signalEntryTime returns when an entry signalled by the state's candle would be counted as opened:
+// at the candle's open time for market entries filling at its close, like openPosition records them,
+// otherwise once it has closed
+func (b *Backtest) signalEntryTime(state *CandleState) time.Time {
+	if b.config.Timing == TimingClose && b.config.Entry.Mode != trading.EntryModeLimit {
+		return state.Price.OpenTime
+	}
+	return candleClose(state.Price)
+}
+
+func tradeDayKey(symbol string, day time.Time) string {
+	return symbol + "|" + day.Format("2006-01-02")
+}
@@ -0,0 +1,53 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/testdb"
+	"testing"
+	"time"
+)
+
+func TestDailyTradeCapsThrottleTheBacktest(t *testing.T) {
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+	run := func(frequency risk.FrequencyConfig) (*BacktestResults, map[string]int, map[string]int) {
+		config := DefaultConfig()
+		config.Frequency = frequency
+		results, err := NewBacktestWithConfig(fixtureSource(), testStrategies(t), config).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+		if err != nil {
+			t.Fatalf("RunBacktest() error = %v", err)
+		}
+		bySymbol, byDay := make(map[string]int), make(map[string]int)
+		for _, trade := range results.Trades {
+			day := risk.TradeDay(trade.EntryTime).Format("2006-01-02")
+			bySymbol[trade.Symbol+" "+day]++
+			byDay[day]++
+		}
+		return results, bySymbol, byDay
+	}
+
+	free, freeBySymbol, _ := run(risk.FrequencyConfig{})
+	busiest := 0
+	for _, n := range freeBySymbol {
+		busiest = max(busiest, n)
+	}
+	if busiest < 2 || free.ThrottledSignals != 0 {
+		t.Fatalf("uncapped run made at most %d trades a symbol-day, %d throttled; the test needs a busier fixture", busiest, free.ThrottledSignals)
+	}
+
+	capped, bySymbol, byDay := run(risk.FrequencyConfig{MaxTradesPerSymbolPerDay: 1, MaxTradesPerDay: 1})
+	for day, n := range byDay {
+		if n > 1 {
+			t.Errorf("%d trades on %s, want at most 1", n, day)
+		}
+	}
+	for key, n := range bySymbol {
+		if n > 1 {
+			t.Errorf("%d trades on %s, want at most 1", n, key)
+		}
+	}
+	if capped.ThrottledSignals == 0 || len(capped.Trades) >= len(free.Trades) {
+		t.Errorf("capped run made %d trades of %d, %d throttled, want fewer trades and throttled signals",
+			len(capped.Trades), len(free.Trades), capped.ThrottledSignals)
+	}
+}
@@ -0,0 +1,141 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"encoding/json"
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fixtureGolden holds the trades and final balance of the fixture backtest
+const fixtureGolden = "testdata/fixture_backtest.golden.json"
+
+// goldenRun is the part of a backtest's results its golden file pins
+type goldenRun struct {
+	Trades       []goldenTrade
+	FinalBalance float64
+}
+
+type goldenTrade struct {
+	Symbol     string
+	Side       string
+	EntryTime  time.Time
+	ExitTime   time.Time
+	EntryPrice float64
+	ExitPrice  float64
+	PnL        float64
+	Reason     string
+}
+
+// runFixture backtests the fixture's symbols from source over its last 7 days, the first 3 being warm-up
+func runFixture(t *testing.T, source PriceSource) goldenRun {
+	t.Helper()
+	return runFixtureWith(t, source, testStrategies(t))
+}
+
+// runFixtureWith runs the fixture backtest with strategies
+func runFixtureWith(t *testing.T, source PriceSource, strategies *strategy.StrategyManager) goldenRun {
+	t.Helper()
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	results, err := NewBacktestWithConfig(source, strategies, DefaultConfig()).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	run := goldenRun{FinalBalance: results.FinalBalance}
+	for _, trade := range results.Trades {
+		run.Trades = append(run.Trades, goldenTrade{
+			Symbol:     trade.Symbol,
+			Side:       trade.Side,
+			EntryTime:  trade.EntryTime.UTC(),
+			ExitTime:   trade.ExitTime.UTC(),
+			EntryPrice: trade.EntryPrice,
+			ExitPrice:  trade.ExitPrice,
+			PnL:        trade.PnL,
+			Reason:     trade.Reason,
+		})
+	}
+	return run
+}
+
+// checkGolden compares run with the golden file, rewriting it instead with -update
+func checkGolden(t *testing.T, run goldenRun) {
+	t.Helper()
+	if *update {
+		data, err := json.MarshalIndent(run, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(fixtureGolden), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fixtureGolden, append(data, '\n'), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(fixtureGolden)
+	if err != nil {
+		t.Fatalf("%v, run with -update to create it", err)
+	}
+	var want goldenRun
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+
+	// Prices match to the last bit on amd64, the tolerance covers platforms fusing multiply-adds
+	near := func(a, b float64) bool { return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b)) }
+	if len(run.Trades) != len(want.Trades) {
+		t.Fatalf("got %d trades, want %d", len(run.Trades), len(want.Trades))
+	}
+	for i, got := range run.Trades {
+		w := want.Trades[i]
+		if got.Symbol != w.Symbol || got.Side != w.Side || got.Reason != w.Reason ||
+			!got.EntryTime.Equal(w.EntryTime) || !got.ExitTime.Equal(w.ExitTime) ||
+			!near(got.EntryPrice, w.EntryPrice) || !near(got.ExitPrice, w.ExitPrice) || !near(got.PnL, w.PnL) {
+			t.Errorf("trade %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if !near(run.FinalBalance, want.FinalBalance) {
+		t.Errorf("FinalBalance = %v, want %v", run.FinalBalance, want.FinalBalance)
+	}
+}
+
+// fixtureSource holds the whole price fixture in memory
+func fixtureSource() *SliceSource {
+	var prices []models.Price
+	for symbol := range testdb.FixtureSymbols {
+		prices = append(prices, testdb.FixturePrices(symbol, testdb.FixtureDays)...)
+	}
+	return NewSliceSource(prices)
+}
+
+func TestFixtureBacktestMatchesGolden(t *testing.T) {
+	checkGolden(t, runFixture(t, fixtureSource()))
+}
+
+func TestFixtureBacktestIsDeterministic(t *testing.T) {
+	first, second := runFixture(t, fixtureSource()), runFixture(t, fixtureSource())
+	if len(first.Trades) == 0 {
+		t.Fatal("the fixture backtest made no trades")
+	}
+	if len(first.Trades) != len(second.Trades) || first.FinalBalance != second.FinalBalance {
+		t.Fatalf("runs differ: %d trades ending at %v, then %d ending at %v",
+			len(first.Trades), first.FinalBalance, len(second.Trades), second.FinalBalance)
+	}
+	for i := range first.Trades {
+		if first.Trades[i] != second.Trades[i] {
+			t.Errorf("trade %d differs between runs: %+v and %+v", i, first.Trades[i], second.Trades[i])
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package backtesting
+
+import (
+	"math"
+	"testing"
+)
+
+// hedgedLegs returns a long and a short on BTCUSDT both entered at 100
+// The long exits at 99 or 103, the short at 101 or 97
+func hedgedLegs() (*Trade, *Trade) {
+	long := openTrade("BTCUSDT", "long", 100, 99, 103)
+	short := openTrade("BTCUSDT", "short", 100, 101, 97)
+	short.InitialStopDistance = 1
+	return long, short
+}
+
+func TestHedgedLegsResolveIndependently(t *testing.T) {
+	config := exactConfig()
+	config.Entry.HedgeMode = true
+	b := newTestBacktest(t, config)
+	long, short := hedgedLegs()
+	state := &CandleState{Symbol: "BTCUSDT", Position: long, Hedge: short}
+
+	// The rally takes the long's target and the short's stop on the same candle
+	trades := stepExits(b, state, candle("BTCUSDT", 1, 100, 103.5, 99.8, 103.2))
+	if len(trades) != 2 {
+		t.Fatalf("%d trades closed, want both legs", len(trades))
+	}
+	bySide := map[string]Trade{trades[0].Side: trades[0], trades[1].Side: trades[1]}
+	if got := bySide["long"]; got.Reason != "take_profit" || got.ExitPrice != 103 || !(got.PnL > 0) {
+		t.Errorf("long closed %s at %v with PnL %v, want take_profit at 103 in profit", got.Reason, got.ExitPrice, got.PnL)
+	}
+	if got := bySide["short"]; got.Reason != "stop_loss" || got.ExitPrice != 101 || !(got.PnL < 0) {
+		t.Errorf("short closed %s at %v with PnL %v, want stop_loss at 101 at a loss", got.Reason, got.ExitPrice, got.PnL)
+	}
+	if state.Position != nil || state.Hedge != nil {
+		t.Errorf("legs left open: %+v, %+v", state.Position, state.Hedge)
+	}
+
+	// Each leg's PnL reaches the balance on its own
+	want := InitialBalance + bySide["long"].PnL + bySide["short"].PnL
+	if math.Abs(b.currentBalance-want) > 1e-9 {
+		t.Errorf("balance = %v, want %v", b.currentBalance, want)
+	}
+}
+
+func TestHedgedLegSurvivesTheOtherClosing(t *testing.T) {
+	config := exactConfig()
+	config.Entry.HedgeMode = true
+	b := newTestBacktest(t, config)
+	long, short := hedgedLegs()
+	state := &CandleState{Symbol: "BTCUSDT", Position: long, Hedge: short}
+
+	// The dip stops the long out and leaves the short short of its target
+	trades := stepExits(b, state, candle("BTCUSDT", 1, 100, 100.2, 98.5, 98.8))
+	if len(trades) != 1 || trades[0].Side != "long" || trades[0].Reason != "stop_loss" {
+		t.Fatalf("closed %+v, want the long stopped out alone", trades)
+	}
+	if state.Position != short || state.Hedge != nil {
+		t.Fatalf("open legs %+v / %+v, want the short moved to the primary slot", state.Position, state.Hedge)
+	}
+
+	// The surviving leg still runs to its own target
+	trades = stepExits(b, state, candle("BTCUSDT", 2, 98.8, 99, 96.5, 96.8))
+	if len(trades) != 2 || trades[1].Side != "short" || trades[1].Reason != "take_profit" || trades[1].ExitPrice != 97 {
+		t.Errorf("closed %+v, want the short at its 97 target", trades[len(trades)-1])
+	}
+}
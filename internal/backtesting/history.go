@@ -0,0 +1,48 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"time"
+)
+
+// SkippedSymbol is a symbol a run left out, with why
+type SkippedSymbol struct {
+	Symbol   string
+	Reason   string
+	Earliest time.Time // First stored candle, zero when there is none
+}
+
+// warmUpStart returns when the warm-up before a period starting at startTime begins
+func (b *Backtest) warmUpStart(startTime time.Time) time.Time {
+	return startTime.Add(-time.Duration(b.window) * models.TimeFrameDurations[BaseTimeFrame])
+}
+
+// checkHistory returns why symbol is skipped when its base series starts after the warm-up before
+// startTime, so a recent listing is not analyzed on a handful of higher timeframe candles.
+// It returns nil when the history covers the warm-up or the source cannot tell
+func (b *Backtest) checkHistory(symbol string, startTime time.Time) (*SkippedSymbol, error) {
+	source, ok := b.source.(historySource)
+	if !ok {
+		return nil, nil
+	}
+	earliest, err := source.GetEarliestPriceByTimeFrame(symbol, BaseTimeFrame)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the first %s candle: %v", symbol, err)
+	}
+	if earliest == nil {
+		return &SkippedSymbol{Symbol: symbol, Reason: "insufficient history, no candles"}, nil
+	}
+
+	// The first candle of a covered warm-up opens within one candle of its start
+	warmUpStart := b.warmUpStart(startTime)
+	if !earliest.OpenTime.After(warmUpStart.Add(models.TimeFrameDurations[BaseTimeFrame])) {
+		return nil, nil
+	}
+	return &SkippedSymbol{
+		Symbol: symbol,
+		Reason: fmt.Sprintf("insufficient history, candles start %s but the warm-up starts %s",
+			earliest.OpenTime.UTC().Format("2006-01-02 15:04"), warmUpStart.UTC().Format("2006-01-02 15:04")),
+		Earliest: earliest.OpenTime,
+	}, nil
+}
@@ -0,0 +1,75 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/testdb"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSymbolsListedAfterTheWarmUpAreSkipped(t *testing.T) {
+	// ETHUSDT listed two and a half days into the fixture, BTCUSDT covering all of it
+	listed := testdb.FixtureStart.Add(60 * time.Hour)
+	prices := testdb.FixturePrices("BTCUSDT", testdb.FixtureDays)
+	for _, p := range testdb.FixturePrices("ETHUSDT", testdb.FixtureDays) {
+		if !p.OpenTime.Before(listed) {
+			prices = append(prices, p)
+		}
+	}
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	tests := []struct {
+		name    string
+		start   time.Time
+		skipped bool
+	}{
+		// The warm-up, a day of candles, starts before the listing
+		{"warm-up before the listing", testdb.FixtureStart.Add(3 * 24 * time.Hour), true},
+		// By day 5 the listing is behind the warm-up
+		{"listed before the warm-up", testdb.FixtureStart.Add(5 * 24 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := NewBacktestWithConfig(NewSliceSource(prices), testStrategies(t), DefaultConfig()).
+				RunBacktest(tt.start, end, []string{"BTCUSDT", "ETHUSDT"})
+			if err != nil {
+				t.Fatalf("RunBacktest() error = %v", err)
+			}
+
+			traded := make(map[string]bool)
+			for _, trade := range results.Trades {
+				traded[trade.Symbol] = true
+			}
+			if !traded["BTCUSDT"] {
+				t.Error("BTCUSDT made no trades")
+			}
+			if !tt.skipped {
+				if len(results.Skipped) != 0 {
+					t.Errorf("Skipped = %+v, want none", results.Skipped)
+				}
+				return
+			}
+			if len(results.Skipped) != 1 {
+				t.Fatalf("Skipped = %+v, want ETHUSDT", results.Skipped)
+			}
+			skipped := results.Skipped[0]
+			if skipped.Symbol != "ETHUSDT" || !skipped.Earliest.Equal(listed) || !strings.HasPrefix(skipped.Reason, "insufficient history") {
+				t.Errorf("Skipped = %+v, want ETHUSDT from %v", skipped, listed)
+			}
+			if traded["ETHUSDT"] {
+				t.Error("the skipped ETHUSDT traded")
+			}
+		})
+	}
+}
+
+func TestSymbolWithoutCandlesIsSkipped(t *testing.T) {
+	b := newTestBacktest(t, DefaultConfig(), candle("BTCUSDT", 0, 100, 101, 99, 100))
+	skipped, err := b.checkHistory("ETHUSDT", testStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped == nil || skipped.Symbol != "ETHUSDT" || !skipped.Earliest.IsZero() {
+		t.Errorf("checkHistory() = %+v, want ETHUSDT skipped without candles", skipped)
+	}
+}
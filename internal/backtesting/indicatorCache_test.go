@@ -0,0 +1,71 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"testing"
+	"time"
+)
+
+// cachedStrategies returns the default strategies with cached indicator state and resamples
+func cachedStrategies(t testing.TB) *strategy.StrategyManager {
+	t.Helper()
+	strategies := testStrategies(t)
+	strategies.EnableIncremental()
+	return strategies
+}
+
+func TestIndicatorCacheTradesMatchRecomputation(t *testing.T) {
+	want := runFixture(t, fixtureSource())
+	got := runFixtureWith(t, fixtureSource(), cachedStrategies(t))
+
+	if len(want.Trades) == 0 {
+		t.Fatal("the fixture backtest made no trades")
+	}
+	if len(got.Trades) != len(want.Trades) {
+		t.Fatalf("cached run made %d trades, recomputed %d", len(got.Trades), len(want.Trades))
+	}
+	for i := range want.Trades {
+		if got.Trades[i] != want.Trades[i] {
+			t.Errorf("trade %d = %+v cached, %+v recomputed", i, got.Trades[i], want.Trades[i])
+		}
+	}
+	if got.FinalBalance != want.FinalBalance {
+		t.Errorf("FinalBalance = %v cached, %v recomputed", got.FinalBalance, want.FinalBalance)
+	}
+}
+
+// BenchmarkIndicatorCache backtests 30 days of every fixture symbol with indicators recomputed
+// over each step's window and then folded into cached state
+func BenchmarkIndicatorCache(b *testing.B) {
+	const days = 30
+	var source []models.Price
+	for symbol := range testdb.FixtureSymbols {
+		source = append(source, testdb.FixturePrices(symbol, days)...)
+	}
+	symbols := make([]string, 0, len(testdb.FixtureSymbols))
+	for symbol := range testdb.FixtureSymbols {
+		symbols = append(symbols, symbol)
+	}
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(days*24*time.Hour - time.Minute)
+
+	for _, cached := range []bool{false, true} {
+		name := "recomputed"
+		if cached {
+			name = "cached"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				strategies := testStrategies(b)
+				if cached {
+					strategies.EnableIncremental()
+				}
+				if _, err := NewBacktestWithConfig(NewSliceSource(source), strategies, DefaultConfig()).RunBacktest(start, end, symbols); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
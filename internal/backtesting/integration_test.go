@@ -0,0 +1,14 @@
+//go:build integration
+
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"testing"
+)
+
+func TestFixtureBacktestFromDatabaseMatchesGolden(t *testing.T) {
+	db := testdb.Seeded(t)
+	checkGolden(t, runFixture(t, repositories.NewPriceRepository(db)))
+}
@@ -0,0 +1,47 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/trading"
+	"math"
+	"testing"
+)
+
+func TestLiquidationYieldsToStopInsideIt(t *testing.T) {
+	// At 50x a long BTCUSDT entry at 100 liquidates near 98.4
+	liquidation := trading.PositionLiquidationPrice("BTCUSDT", "long", 100, FixedSize*Leverage, Leverage)
+	if math.Abs(liquidation-98.4) > 1e-9 {
+		t.Fatalf("liquidation price = %v, want 98.4 at %dx", liquidation, Leverage)
+	}
+
+	tests := []struct {
+		name         string
+		stop         float64
+		reason       string
+		exitPrice    float64
+		liquidations int
+	}{
+		{"stop inside liquidation", 99, "stop_loss", 99, 0},
+		{"stop beyond liquidation", 97, trading.CloseReasonLiquidation, liquidation, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			trade := openTrade("BTCUSDT", "long", 100, tt.stop, 103)
+			trade.LiquidationPrice = liquidation
+			state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+			// One candle drops through the stop and the liquidation price alike
+			trades := stepExits(b, state, candle("BTCUSDT", 1, 100, 100.2, 98, 98.5))
+			if len(trades) != 1 {
+				t.Fatalf("%d trades closed, want 1", len(trades))
+			}
+			got := trades[0]
+			if got.Reason != tt.reason || math.Abs(got.ExitPrice-tt.exitPrice) > 1e-9 {
+				t.Errorf("closed %s at %v, want %s at %v", got.Reason, got.ExitPrice, tt.reason, tt.exitPrice)
+			}
+			if b.liquidations != tt.liquidations {
+				t.Errorf("liquidations = %d, want %d", b.liquidations, tt.liquidations)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"fmt"
+)
+
+// Liquidity modes, what becomes of the part of an entry beyond what its candle's volume allows
+const (
+	LiquidityClip   = "clip"   // Dropped, the position keeps the size that filled
+	LiquiditySpread = "spread" // Filled at the close of the following candles, each taking up to its own cap
+)
+
+// Liquidity defaults, see LiquidityConfig
+const (
+	DefaultMaxParticipation = 0.05
+	DefaultLiquidityCandles = 6
+)
+
+// LiquidityConfig caps each fill at a share of the quote volume its candle traded, so entries
+// no larger than the market could have absorbed are simulated on thin symbols
+type LiquidityConfig struct {
+	MaxParticipation float64 // Share of a candle's quote volume one entry may take, above 0 and at most 1
+	Mode             string  // LiquidityClip or LiquiditySpread
+	MaxCandles       int     // With LiquiditySpread, candles after the entry's the rest may fill over before it is dropped
+}
+
+// DefaultLiquidityConfig returns a 5% participation cap clipping what is left over
+func DefaultLiquidityConfig() LiquidityConfig {
+	return LiquidityConfig{
+		MaxParticipation: DefaultMaxParticipation,
+		Mode:             LiquidityClip,
+		MaxCandles:       DefaultLiquidityCandles,
+	}
+}
+
+// Validate checks the settings are usable
+func (c LiquidityConfig) Validate() error {
+	if c.MaxParticipation <= 0 || c.MaxParticipation > 1 {
+		return fmt.Errorf("max participation must be above 0 and at most 1, got %v", c.MaxParticipation)
+	}
+	switch c.Mode {
+	case LiquidityClip:
+	case LiquiditySpread:
+		if c.MaxCandles < 1 {
+			return fmt.Errorf("spread fills need at least 1 candle, got %d", c.MaxCandles)
+		}
+	default:
+		return fmt.Errorf("invalid liquidity mode %q, want %s or %s", c.Mode, LiquidityClip, LiquiditySpread)
+	}
+	return nil
+}
+
+// Cap returns the leveraged notional candle lets one entry fill: its quote volume, approximated
+// at the close, times MaxParticipation
+func (c LiquidityConfig) Cap(candle models.Price) float64 {
+	return c.MaxParticipation * candle.Volume * candle.Close
+}
+
+// capLiquidity returns the part of margin candle's volume lets fill, and the margin left for later candles
+// under LiquiditySpread. Without Config.Liquidity the whole margin fills
+func (b *Backtest) capLiquidity(margin float64, candle models.Price) (float64, float64) {
+	if b.config.Liquidity == nil {
+		return margin, 0
+	}
+	filled := min(margin, b.config.Liquidity.Cap(candle)/Leverage)
+	if b.config.Liquidity.Mode != LiquiditySpread {
+		return filled, 0
+	}
+	return filled, margin - filled
+}
+
+// fillRest adds to each leg still filling what this candle's volume allows of its rest, at the close.
+// A leg stops filling once it has its size, after Config.Liquidity.MaxCandles, or once the candle traded
+// through its take profit, the move it was entered for gone like for a resting limit entry
+func (b *Backtest) fillRest(state *CandleState) {
+	if b.config.Liquidity == nil {
+		return
+	}
+	for _, leg := range state.legs() {
+		trade := *leg
+		// A leg opened at this candle's open already took its cap
+		if trade == nil || trade.fillsLeft == 0 || !state.Price.OpenTime.After(trade.EntryTime) {
+			continue
+		}
+		trade.fillsLeft--
+		if trading.LimitInvalidated(trade.Side, trade.TakeProfit, state.Price.Low, state.Price.High) {
+			trade.fillsLeft = 0
+			continue
+		}
+
+		rest, limit := trade.WantedNotional-trade.FilledNotional, b.config.Liquidity.Cap(state.Price)
+		switch {
+		case limit >= rest:
+			trade.addFill(rest, state.Price.Close)
+			trade.FilledNotional = trade.WantedNotional // Without the float residue of the sum
+			trade.fillsLeft = 0
+		case limit > 0:
+			trade.addFill(limit, state.Price.Close)
+		}
+	}
+}
+
+// addFill adds notional filled at price to the trade, its entry price becoming the average by quantity.
+// The initial stop stays where it was, its distance measured again from the new entry
+func (t *Trade) addFill(notional, price float64) {
+	initialStop := t.EntryPrice - t.InitialStopDistance
+	if t.Side == "short" {
+		initialStop = t.EntryPrice + t.InitialStopDistance
+	}
+	hasStop := t.InitialStopDistance > 0
+
+	quantity := t.FilledNotional/t.EntryPrice + notional/price
+	t.FilledNotional += notional
+	t.FillCandles++
+	t.EntryPrice = t.FilledNotional / quantity
+	t.Margin = t.FilledNotional / Leverage
+	t.Size = t.Margin / t.EntryPrice
+	if hasStop {
+		t.InitialStopDistance = trading.InitialStopDistance(t.EntryPrice, initialStop)
+	}
+	t.LiquidationPrice = trading.PositionLiquidationPrice(t.Symbol, t.Side, t.EntryPrice, t.Size*Leverage, Leverage)
+}
+
+// LiquidityStats is how much of what entries wanted the candles' volume let them fill
+type LiquidityStats struct {
+	Capped    int     // Trades filled short of what they wanted
+	Spread    int     // Trades filled over more than one candle
+	Wanted    float64 // Leveraged notional the entries wanted
+	Filled    float64 // and the part that filled
+	Shortfall float64 // Share of Wanted that did not fill, 0 to 1
+}
+
+// LiquidityOf returns the fill shortfall of trades, zero for runs without Config.Liquidity
+func LiquidityOf(trades []Trade) LiquidityStats {
+	var stats LiquidityStats
+	for _, trade := range trades {
+		if trade.WantedNotional == 0 {
+			continue
+		}
+		stats.Wanted += trade.WantedNotional
+		stats.Filled += trade.FilledNotional
+		if trade.FilledNotional < trade.WantedNotional {
+			stats.Capped++
+		}
+		if trade.FillCandles > 1 {
+			stats.Spread++
+		}
+	}
+	if stats.Wanted > 0 {
+		stats.Shortfall = 1 - stats.Filled/stats.Wanted
+	}
+	return stats
+}
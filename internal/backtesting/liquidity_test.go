@@ -0,0 +1,163 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/testdb"
+	"math"
+	"testing"
+	"time"
+)
+
+// thin returns the flat candle of symbol i candles after testStart at price, trading volume units
+func thin(symbol string, i int, price, volume float64) models.Price {
+	c := flat(symbol, i, price)
+	c.Volume = volume
+	return c
+}
+
+// liquidityConfig returns exactConfig capping fills at 5% of candle volume in mode
+func liquidityConfig(mode string, candles int) Config {
+	config := exactConfig()
+	config.Liquidity = &LiquidityConfig{MaxParticipation: 0.05, Mode: mode, MaxCandles: candles}
+	return config
+}
+
+// longSignal returns a long setup on symbol with its stop and target well clear of 100
+func longSignal(symbol string) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{
+		Symbol: symbol, Direction: models.PositionSideLong, Confidence: 0.8, StopLoss: 90, TakeProfit: 120,
+	}
+}
+
+func TestLiquidityClipsTheEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		volume float64 // Of the entry candle closing at 100, whose 5% caps the fill
+		filled float64 // Leveraged notional, of the 50 wanted
+	}{
+		{"thin candle", 4, 20},
+		{"just enough volume", 10, 50},
+		{"deep candle", 1000, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, liquidityConfig(LiquidityClip, 0))
+			trade := b.openPosition(longSignal("ALTUSDT"), 100, thin("ALTUSDT", 0, 100, tt.volume))
+			if trade == nil {
+				t.Fatal("openPosition() opened nothing")
+			}
+			if trade.WantedNotional != FixedSize*Leverage || math.Abs(trade.FilledNotional-tt.filled) > 1e-9 {
+				t.Errorf("wanted %v filled %v, want %v of %v", trade.WantedNotional, trade.FilledNotional, tt.filled, FixedSize*Leverage)
+			}
+			if margin := tt.filled / Leverage; math.Abs(trade.Margin-margin) > 1e-9 || math.Abs(trade.Size-margin/100) > 1e-12 {
+				t.Errorf("margin %v size %v, want %v and %v", trade.Margin, trade.Size, margin, margin/100)
+			}
+			if trade.FillCandles != 1 || trade.fillsLeft != 0 {
+				t.Errorf("filled over %d candles with %d left, want one and none", trade.FillCandles, trade.fillsLeft)
+			}
+		})
+	}
+
+	// A candle that traded nothing opens nothing
+	b := newTestBacktest(t, liquidityConfig(LiquidityClip, 0))
+	if trade := b.openPosition(longSignal("ALTUSDT"), 100, thin("ALTUSDT", 0, 100, 0)); trade != nil || b.liquiditySkips != 1 {
+		t.Errorf("openPosition() on a candle without volume = %+v with %d skips, want nothing and 1", trade, b.liquiditySkips)
+	}
+}
+
+func TestLiquiditySpreadsTheRestOverLaterCandles(t *testing.T) {
+	b := newTestBacktest(t, liquidityConfig(LiquiditySpread, 6))
+	trade := b.openPosition(longSignal("ALTUSDT"), 100, thin("ALTUSDT", 0, 100, 4))
+	if trade == nil || trade.FilledNotional != 20 || trade.fillsLeft != 6 {
+		t.Fatalf("openPosition() = %+v, want 20 of 50 filled and 6 candles to fill the rest", trade)
+	}
+
+	// 20.4 more at 102, then the 9.6 left at 101 out of a deeper candle; the next adds nothing
+	state := &CandleState{Symbol: "ALTUSDT", Position: trade}
+	stepExits(b, state, thin("ALTUSDT", 1, 102, 4), thin("ALTUSDT", 2, 101, 100), thin("ALTUSDT", 3, 101, 100))
+
+	quantity := 20.0/100 + 20.4/102 + 9.6/101
+	if trade.FilledNotional != 50 || trade.FillCandles != 3 || trade.fillsLeft != 0 {
+		t.Errorf("filled %v over %d candles with %d left, want 50 over 3", trade.FilledNotional, trade.FillCandles, trade.fillsLeft)
+	}
+	if want := 50 / quantity; math.Abs(trade.EntryPrice-want) > 1e-9 {
+		t.Errorf("entry price %v, want the quantity weighted %v", trade.EntryPrice, want)
+	}
+	if trade.Margin != FixedSize || math.Abs(trade.Size-FixedSize/trade.EntryPrice) > 1e-12 {
+		t.Errorf("margin %v size %v, want the full margin at the average entry", trade.Margin, trade.Size)
+	}
+	// The initial stop stays at 90, measured from the new entry
+	if math.Abs(trade.InitialStopDistance-(trade.EntryPrice-90)) > 1e-9 {
+		t.Errorf("initial stop distance %v, want %v", trade.InitialStopDistance, trade.EntryPrice-90)
+	}
+}
+
+func TestLiquiditySpreadGivesUpAfterMaxCandles(t *testing.T) {
+	b := newTestBacktest(t, liquidityConfig(LiquiditySpread, 2))
+	trade := b.openPosition(longSignal("ALTUSDT"), 100, thin("ALTUSDT", 0, 100, 2))
+	state := &CandleState{Symbol: "ALTUSDT", Position: trade}
+	stepExits(b, state, thin("ALTUSDT", 1, 100, 2), thin("ALTUSDT", 2, 100, 2), thin("ALTUSDT", 3, 100, 100))
+
+	// 10 at the entry and 10 on each of the 2 candles allowed, the deep third comes too late
+	if math.Abs(trade.FilledNotional-30) > 1e-9 || trade.FillCandles != 3 || trade.fillsLeft != 0 {
+		t.Errorf("filled %v over %d candles with %d left, want 30 over 3", trade.FilledNotional, trade.FillCandles, trade.fillsLeft)
+	}
+}
+
+func TestLiquidityOf(t *testing.T) {
+	clipped, spread, full, unlimited := Trade{}, Trade{}, Trade{}, Trade{}
+	clipped.WantedNotional, clipped.FilledNotional, clipped.FillCandles = 50, 20, 1
+	spread.WantedNotional, spread.FilledNotional, spread.FillCandles = 50, 50, 3
+	full.WantedNotional, full.FilledNotional, full.FillCandles = 100, 100, 1
+
+	stats := LiquidityOf([]Trade{clipped, spread, full, unlimited})
+	want := LiquidityStats{Capped: 1, Spread: 1, Wanted: 200, Filled: 170, Shortfall: 0.15}
+	if stats.Capped != want.Capped || stats.Spread != want.Spread || stats.Wanted != want.Wanted ||
+		stats.Filled != want.Filled || math.Abs(stats.Shortfall-want.Shortfall) > 1e-12 {
+		t.Errorf("LiquidityOf() = %+v, want %+v", stats, want)
+	}
+	if stats := LiquidityOf([]Trade{unlimited}); stats != (LiquidityStats{}) {
+		t.Errorf("LiquidityOf() without a liquidity cap = %+v, want zero", stats)
+	}
+}
+
+func TestLowVolumeFixtureFillsShort(t *testing.T) {
+	// The fixture with a hundred thousandth of its volume: a 5m BTC candle trades about 40 USDT, so an
+	// entry's 50 USDT notional cannot fill from one candle. Volume ratios, and so the signals, are unchanged
+	var prices []models.Price
+	for symbol := range testdb.FixtureSymbols {
+		for _, price := range testdb.FixturePrices(symbol, testdb.FixtureDays) {
+			price.Volume *= 1e-5
+			prices = append(prices, price)
+		}
+	}
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	run := func(mode string) *BacktestResults {
+		t.Helper()
+		config := DefaultConfig()
+		config.Liquidity = &LiquidityConfig{MaxParticipation: 0.05, Mode: mode, MaxCandles: DefaultLiquidityCandles}
+		results, err := NewBacktestWithConfig(NewSliceSource(prices), testStrategies(t), config).
+			RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Trades) == 0 {
+			t.Fatalf("the %s run made no trades", mode)
+		}
+		return results
+	}
+
+	clipped := run(LiquidityClip)
+	if stats := clipped.Liquidity; stats.Capped != len(clipped.Trades) || stats.Spread != 0 || stats.Shortfall < 0.5 {
+		t.Errorf("clipped run of %d trades: %+v, want every trade capped to under half", len(clipped.Trades), stats)
+	}
+
+	spread := run(LiquiditySpread)
+	if stats := spread.Liquidity; stats.Spread == 0 || stats.Shortfall >= clipped.Liquidity.Shortfall {
+		t.Errorf("spread run: %+v, want fills over several candles and less shortfall than clipping's %v",
+			stats, clipped.Liquidity.Shortfall)
+	}
+}
@@ -0,0 +1,200 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"sort"
+	"time"
+)
+
+// FromLive converts closed live positions and the account's transactions into backtest results,
+// so live trading over a period can be exported and compared like a backtest of it
+// The equity curve follows the balance after each trade and funding transaction, starting from
+// initialBalance when there are no transactions; the ratios are measured over start to end like a backtest's
+func FromLive(positions []models.Position, transactions []models.Transaction, initialBalance float64,
+	start, end time.Time, ratios RatioConfig) *BacktestResults {
+	trades := make([]Trade, 0, len(positions))
+	for _, p := range positions {
+		if p.Status != models.PositionStatusClosed {
+			continue
+		}
+		trades = append(trades, tradeFromPosition(p))
+	}
+	sort.SliceStable(trades, func(i, j int) bool { return trades[i].ExitTime.Before(trades[j].ExitTime) })
+
+	startBalance := initialBalance
+	curve := make([]EquityPoint, 0, len(transactions)+1)
+	for _, tx := range transactions {
+		if tx.Type != models.TransactionTypeTrade && tx.Type != models.TransactionTypeFunding {
+			continue
+		}
+		if len(curve) == 0 {
+			startBalance = tx.BalanceBefore
+			curve = append(curve, EquityPoint{Timestamp: tx.CreatedAt, Balance: tx.BalanceBefore})
+		}
+		curve = append(curve, EquityPoint{Timestamp: tx.CreatedAt, Balance: tx.BalanceAfter})
+	}
+
+	results := &BacktestResults{
+		TotalTrades:  len(trades),
+		FinalBalance: startBalance,
+		Trades:       trades,
+		EquityCurve:  curve,
+		Signals:      len(trades),
+		Fills:        len(trades),
+		Excursions:   Excursions(trades),
+		R:            RStatsOf(trades),
+		Confidence:   ConfidenceBucketsOf(trades),
+	}
+	if len(trades) > 0 {
+		results.FillRate = 1
+	}
+
+	if len(curve) > 0 {
+		results.FinalBalance = curve[len(curve)-1].Balance
+	}
+
+	var totalPnL float64
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			results.WinningTrades++
+		} else {
+			results.LosingTrades++
+		}
+		if trade.Reason == trading.CloseReasonLiquidation {
+			results.Liquidations++
+		}
+		totalPnL += trade.PnL
+	}
+
+	if results.TotalTrades > 0 {
+		results.WinRate = float64(results.WinningTrades) / float64(results.TotalTrades)
+		results.AveragePnL = totalPnL / float64(results.TotalTrades)
+	}
+
+	results.MaxDrawdown = calculateMaxDrawdown(curve, startBalance)
+	results.measureRatios(startBalance, start, end, ratios)
+
+	return results
+}
+
+// tradeFromPosition converts a closed position, recovering the exit price from its PnL
+func tradeFromPosition(p models.Position) Trade {
+	exitPrice := p.EntryPrice
+	if p.Size > 0 {
+		if p.Side == models.PositionSideLong {
+			exitPrice = p.EntryPrice + p.PnL/p.Size
+		} else {
+			exitPrice = p.EntryPrice - p.PnL/p.Size
+		}
+	}
+
+	riskMultiplier := p.RiskMultiplier
+	if riskMultiplier == 0 {
+		riskMultiplier = 1
+	}
+	confidenceMultiplier := p.ConfidenceMultiplier
+	if confidenceMultiplier == 0 {
+		confidenceMultiplier = 1
+	}
+	var rMultiple *float64
+	if r, ok := trading.PositionR(&p); ok {
+		rMultiple = &r
+	}
+	confluence, _ := analysis.ParseConfluence(p.Confluence)
+	entryContext, _ := analysis.ParseEntryContext(p.EntryContext)
+
+	return Trade{
+		Symbol:     p.Symbol,
+		EntryTime:  p.OpenTime,
+		ExitTime:   p.CloseTime,
+		Side:       p.Side,
+		EntryPrice: p.EntryPrice,
+		ExitPrice:  exitPrice,
+		Size:       p.Size,
+		StopLoss:   p.StopLossPrice,
+		TakeProfit: p.TakeProfitPrice,
+		PnL:        p.PnL,
+		Reason:     p.CloseReason,
+		RMultiple:  rMultiple,
+
+		InitialStopDistance: p.InitialStopDistance,
+		LiquidationPrice:    p.LiquidationPrice,
+		Confidence:          p.Confidence,
+		Confluence:          confluence,
+		RiskMultiplier:      riskMultiplier,
+		Margin:              guardedMargin(p),
+		RequestedSize:       p.RequestedSize,
+		SizeGuard:           p.SizeGuard,
+		EntryContext:        entryContext,
+
+		ConfidenceMultiplier: confidenceMultiplier,
+
+		MAE:  p.MAE,
+		MFE:  p.MFE,
+		MAER: p.MAER,
+		MFER: p.MFER,
+	}
+}
+
+// SymbolShortfall is how live trading of one symbol diverged from its backtest
+type SymbolShortfall struct {
+	Symbol         string
+	BacktestTrades int
+	LiveTrades     int
+	BacktestPnL    float64
+	LivePnL        float64
+}
+
+// TradeDelta returns the live trade count minus the backtest's
+func (s SymbolShortfall) TradeDelta() int {
+	return s.LiveTrades - s.BacktestTrades
+}
+
+// PnLDelta returns the live PnL minus the backtest's, the implementation shortfall when negative
+func (s SymbolShortfall) PnLDelta() float64 {
+	return s.LivePnL - s.BacktestPnL
+}
+
+// Shortfall compares live trading with a backtest of the same period and symbols
+type Shortfall struct {
+	Symbols      []SymbolShortfall
+	Matched      int
+	BacktestOnly []Trade // Signals the backtest traded that live trading did not
+	LiveOnly     []Trade // Signals live trading traded that the backtest did not
+}
+
+// CompareLive measures live results against a backtest, pairing trades whose entries are within tolerance
+// Backtest trades are limited to entries from start up to but not including end, so a backtest
+// over a longer period does not count as missed live trades
+func CompareLive(live, backtest *BacktestResults, start, end time.Time, tolerance time.Duration) *Shortfall {
+	var expected []Trade
+	for _, t := range backtest.Trades {
+		if !t.EntryTime.Before(start) && t.EntryTime.Before(end) {
+			expected = append(expected, t)
+		}
+	}
+
+	shortfall := &Shortfall{}
+	shortfall.Matched, shortfall.BacktestOnly, shortfall.LiveOnly = matchTrades(expected, live.Trades, tolerance)
+	for _, d := range symbolDeltas(expected, live.Trades) {
+		shortfall.Symbols = append(shortfall.Symbols, SymbolShortfall{
+			Symbol:         d.Symbol,
+			BacktestTrades: d.TradesA,
+			LiveTrades:     d.TradesB,
+			BacktestPnL:    d.PnLA,
+			LivePnL:        d.PnLB,
+		})
+	}
+
+	return shortfall
+}
+
+// guardedMargin returns the margin of a position whose size a sizing guard changed, 0 for the fixed size
+func guardedMargin(p models.Position) float64 {
+	if p.SizeGuard == "" || p.Leverage <= 0 {
+		return 0
+	}
+	return p.Size * p.EntryPrice / float64(p.Leverage)
+}
@@ -0,0 +1,163 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// closedPosition returns a closed live position entered hours after testStart and held for an hour
+func closedPosition(symbol, side string, hours int, entry, size, pnl float64) models.Position {
+	at := testStart.Add(time.Duration(hours) * time.Hour)
+	return models.Position{
+		Symbol:          symbol,
+		Side:            side,
+		Size:            size,
+		Leverage:        Leverage,
+		EntryPrice:      entry,
+		StopLossPrice:   entry * 0.99,
+		TakeProfitPrice: entry * 1.02,
+		OpenTime:        at,
+		CloseTime:       at.Add(time.Hour),
+		Status:          models.PositionStatusClosed,
+		PnL:             pnl,
+		CloseReason:     "take_profit",
+	}
+}
+
+// booked returns the transaction moving the balance from before to after at hours after testStart
+func booked(txType string, hours int, before, after float64) models.Transaction {
+	return models.Transaction{Type: txType, Amount: after - before, BalanceBefore: before, BalanceAfter: after,
+		CreatedAt: testStart.Add(time.Duration(hours) * time.Hour)}
+}
+
+func TestFromLiveSummarizesClosedPositions(t *testing.T) {
+	open := closedPosition("ETHUSDT", models.PositionSideLong, 5, 2000, 0.1, 0)
+	open.Status = models.PositionStatusOpen
+	positions := []models.Position{
+		closedPosition("ETHUSDT", models.PositionSideShort, 2, 2000, 0.5, -105),
+		closedPosition("BTCUSDT", models.PositionSideLong, 0, 100, 5, 50),
+		closedPosition("BTCUSDT", models.PositionSideLong, 3, 100, 5, 35),
+		open,
+	}
+	transactions := []models.Transaction{
+		booked(models.TransactionTypeDeposit, 0, 0, 1000), // Not trading, left out of the curve
+		booked(models.TransactionTypeTrade, 1, 1000, 1050),
+		booked(models.TransactionTypeTrade, 3, 1050, 945),
+		booked(models.TransactionTypeFunding, 3, 945, 945-0.5),
+		booked(models.TransactionTypeTrade, 4, 944.5, 979.5),
+	}
+
+	results := FromLive(positions, transactions, 500, testStart, testStart.Add(24*time.Hour), DefaultRatioConfig())
+	if results.TotalTrades != 3 || results.WinningTrades != 2 || results.LosingTrades != 1 {
+		t.Fatalf("trades %d, %d won, %d lost, want 3, 2 and 1 with the open position left out",
+			results.TotalTrades, results.WinningTrades, results.LosingTrades)
+	}
+	if math.Abs(results.WinRate-2.0/3) > 1e-9 || math.Abs(results.AveragePnL-(-20.0/3)) > 1e-9 {
+		t.Errorf("win rate %v average PnL %v, want 2/3 and -20/3", results.WinRate, results.AveragePnL)
+	}
+	if results.FinalBalance != 979.5 {
+		t.Errorf("final balance %v, want the last trade's 979.5", results.FinalBalance)
+	}
+	// From the 1050 peak down to 944.5 after funding
+	if want := (1050 - 944.5) / 1050; math.Abs(results.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("max drawdown %v, want %v", results.MaxDrawdown, want)
+	}
+	if len(results.EquityCurve) != 5 || results.EquityCurve[0].Balance != 1000 {
+		t.Errorf("equity curve %+v, want 1000 then each trade and funding booking", results.EquityCurve)
+	}
+	if !results.RatiosInsufficient {
+		t.Error("a single day reported ratios, want them marked insufficient")
+	}
+
+	// Trades come out in exit order, with the exit price recovered from the PnL
+	wantExits := []struct {
+		symbol string
+		exit   float64
+	}{{"BTCUSDT", 110}, {"ETHUSDT", 2210}, {"BTCUSDT", 107}}
+	for i, want := range wantExits {
+		trade := results.Trades[i]
+		if trade.Symbol != want.symbol || math.Abs(trade.ExitPrice-want.exit) > 1e-9 {
+			t.Errorf("trade %d = %s exiting at %v, want %s at %v", i, trade.Symbol, trade.ExitPrice, want.symbol, want.exit)
+		}
+	}
+
+	// The live results export like a backtest's
+	for _, name := range []string{"live.json", "live.csv", "live.html"} {
+		path := filepath.Join(t.TempDir(), name)
+		if err := results.Export(path); err != nil {
+			t.Errorf("Export(%s) error = %v", name, err)
+		} else if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Errorf("Export(%s) wrote nothing", name)
+		}
+	}
+}
+
+func TestFromLiveWithoutTransactions(t *testing.T) {
+	results := FromLive(nil, nil, 1000, testStart, testStart.Add(24*time.Hour), DefaultRatioConfig())
+	if results.TotalTrades != 0 || results.FinalBalance != 1000 || results.MaxDrawdown != 0 || results.FillRate != 0 {
+		t.Errorf("FromLive() of nothing = %+v, want the initial balance and no trades", results)
+	}
+}
+
+func TestCompareLiveReportsDivergence(t *testing.T) {
+	trade := func(symbol string, minutes int, pnl float64) Trade {
+		return Trade{Symbol: symbol, Side: models.PositionSideLong, EntryTime: testStart.Add(time.Duration(minutes) * time.Minute), PnL: pnl}
+	}
+	backtest := &BacktestResults{Trades: []Trade{
+		trade("BTCUSDT", 60, 20),
+		trade("BTCUSDT", 300, 15),
+		trade("ETHUSDT", 120, -10),
+		trade("ETHUSDT", 24*60+30, 50), // After the live period, not a missed trade
+	}}
+	live := &BacktestResults{Trades: []Trade{
+		trade("BTCUSDT", 65, 12), // Filled five minutes later than the backtest
+		trade("ETHUSDT", 120, -12),
+		trade("ETHUSDT", 600, 8), // Live only
+	}}
+
+	shortfall := CompareLive(live, backtest, testStart, testStart.Add(24*time.Hour), 10*time.Minute)
+	if shortfall.Matched != 2 || len(shortfall.BacktestOnly) != 1 || len(shortfall.LiveOnly) != 1 {
+		t.Fatalf("matched %d, backtest only %d, live only %d, want 2, 1 and 1",
+			shortfall.Matched, len(shortfall.BacktestOnly), len(shortfall.LiveOnly))
+	}
+	if missed := shortfall.BacktestOnly[0]; missed.Symbol != "BTCUSDT" || !missed.EntryTime.Equal(testStart.Add(5*time.Hour)) {
+		t.Errorf("backtest only %+v, want the BTCUSDT entry at 05:00", missed)
+	}
+	if extra := shortfall.LiveOnly[0]; extra.Symbol != "ETHUSDT" || !extra.EntryTime.Equal(testStart.Add(10*time.Hour)) {
+		t.Errorf("live only %+v, want the ETHUSDT entry at 10:00", extra)
+	}
+
+	want := map[string]struct {
+		trades int
+		pnl    float64
+	}{
+		"BTCUSDT": {-1, 12 - 35},
+		"ETHUSDT": {1, -4 - (-10)},
+	}
+	if len(shortfall.Symbols) != len(want) {
+		t.Fatalf("got %d symbols, want %d", len(shortfall.Symbols), len(want))
+	}
+	for _, s := range shortfall.Symbols {
+		w := want[s.Symbol]
+		if s.TradeDelta() != w.trades || math.Abs(s.PnLDelta()-w.pnl) > 1e-9 {
+			t.Errorf("%s: trade delta %d PnL delta %v, want %d and %v", s.Symbol, s.TradeDelta(), s.PnLDelta(), w.trades, w.pnl)
+		}
+	}
+}
+
+func TestFromLiveMaxDrawdownBeforeANewHigh(t *testing.T) {
+	transactions := []models.Transaction{
+		booked(models.TransactionTypeTrade, 1, 1000, 1200),
+		booked(models.TransactionTypeTrade, 2, 1200, 900),
+		booked(models.TransactionTypeTrade, 3, 900, 1500),
+	}
+	results := FromLive(nil, transactions, 1000, testStart, testStart.Add(24*time.Hour), DefaultRatioConfig())
+	// From the 1200 peak at the time, the later 1500 high does not deepen it
+	if want := (1200 - 900) / 1200.0; math.Abs(results.MaxDrawdown-want) > 1e-9 {
+		t.Errorf("max drawdown %v, want %v", results.MaxDrawdown, want)
+	}
+}
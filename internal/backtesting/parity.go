@@ -0,0 +1,145 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// parityConfidenceTolerance absorbs float noise when comparing confidences
+const parityConfidenceTolerance = 1e-9
+
+// LiveAnalyzer is the live decision path Parity compares the backtest against, an AnalysisHandler on a fake clock
+type LiveAnalyzer interface {
+	// Window returns how many 5m candles the live analysis reads
+	Window() int
+	// AnalyzeAt runs the live analysis at at on the candles it would read then: the latest Window closed
+	// 5m candles and the latest 1d candles, the forming day included
+	AnalyzeAt(symbol string, prices, daily []models.Price, at time.Time) *analysis.AnalysisResult
+}
+
+// ParityDecision is one analysis outcome, what Parity compares
+type ParityDecision struct {
+	Time       time.Time // Close of the candle analyzed
+	Symbol     string
+	Direction  string
+	Confidence float64
+	Valid      bool
+}
+
+// decisionOf reduces result at at to the fields Parity compares
+func decisionOf(symbol string, at time.Time, result *analysis.AnalysisResult) ParityDecision {
+	return ParityDecision{Time: at, Symbol: symbol, Direction: result.Direction, Confidence: result.Confidence, Valid: result.IsValid}
+}
+
+// matches reports whether two decisions agree, directions and confidences only mattering on valid ones
+func (d ParityDecision) matches(other ParityDecision) bool {
+	if d.Valid != other.Valid {
+		return false
+	}
+	if !d.Valid {
+		return true
+	}
+	return d.Direction == other.Direction && math.Abs(d.Confidence-other.Confidence) <= parityConfidenceTolerance
+}
+
+func (d ParityDecision) String() string {
+	if !d.Valid {
+		return "no entry"
+	}
+	return fmt.Sprintf("%s %.4f", d.Direction, d.Confidence)
+}
+
+// ParityDivergence is a candle the two paths decided differently
+type ParityDivergence struct {
+	Backtest ParityDecision
+	Live     ParityDecision
+}
+
+// ParityReport is what Parity found
+type ParityReport struct {
+	Candles     int // Analyzed by both paths
+	Signals     int // Valid on the backtest path
+	Divergences []ParityDivergence
+}
+
+// Parity replays symbols' base candles from start to end through the backtest's analysis and live's,
+// each fed the candles it would read at every candle close, and lists the candles they decided differently.
+//
+// Only the analysis is compared. What intentionally differs around it is left out on both sides: cadence
+// gating, vetoes, throttles and position state decide whether a signal is acted on, not what it is. Live's
+// daily candles include the forming day while the backtest's stop at the last closed one, which the daily bias
+// must absorb. A divergence therefore means the two paths read different windows or analyze them differently
+func (b *Backtest) Parity(ctx context.Context, live LiveAnalyzer, startTime, endTime time.Time, symbols []string) (*ParityReport, error) {
+	report := &ParityReport{}
+	for _, symbol := range symbols {
+		skipped, err := b.checkHistory(symbol, startTime)
+		if err != nil {
+			return nil, err
+		}
+		if skipped != nil {
+			log.Printf("Skipping %s: %s", symbol, skipped.Reason)
+			continue
+		}
+		if err := b.paritySymbol(ctx, live, report, symbol, startTime, endTime); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func (b *Backtest) paritySymbol(ctx context.Context, live LiveAnalyzer, report *ParityReport, symbol string, startTime, endTime time.Time) error {
+	daily, err := b.loadDaily(ctx, symbol, startTime, endTime)
+	if err != nil {
+		return err
+	}
+
+	warmUp := b.warmUpStart(startTime)
+	if liveStart := startTime.Add(-time.Duration(live.Window()) * models.TimeFrameDurations[BaseTimeFrame]); liveStart.Before(warmUp) {
+		warmUp = liveStart
+	}
+	window, liveWindow := newCandleWindow(b.window), newCandleWindow(live.Window())
+	closedDays, openedDays, candles := 0, 0, 0
+
+	err = b.source.StreamPricesByTimeFrame(symbol, BaseTimeFrame, warmUp, endTime, func(price models.Price) error {
+		window.Push(price)
+		liveWindow.Push(price)
+		if price.OpenTime.Before(startTime) {
+			return nil
+		}
+		if candles%progressEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		candles++
+
+		at := candleClose(price)
+		closedDays = closedDaily(daily, closedDays, at)
+		for openedDays < len(daily) && !daily[openedDays].OpenTime.After(at) {
+			openedDays++
+		}
+		liveDaily := daily[max(0, openedDays-(analysis.DailyBiasCandles+1)):openedDays]
+
+		backtest := decisionOf(symbol, at, b.decide(symbol, window.Candles(), daily[:closedDays], at))
+		decision := decisionOf(symbol, at, live.AnalyzeAt(symbol, liveWindow.Candles(), liveDaily, at))
+		if backtest.Valid {
+			report.Signals++
+		}
+		if !backtest.matches(decision) {
+			report.Divergences = append(report.Divergences, ParityDivergence{Backtest: backtest, Live: decision})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	report.Candles += candles
+	log.Printf("Compared %d candles of %s", candles, symbol)
+	return nil
+}
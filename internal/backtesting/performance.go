@@ -0,0 +1,46 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"log"
+	"time"
+)
+
+// suspended applies the live symbol performance rule to the simulated trade history
+// It returns true while symbol is serving a suspension, starting one when the evidence calls for it
+func (b *Backtest) suspended(symbol string, now time.Time) bool {
+	cfg := b.config.Performance
+	if cfg == nil {
+		return false
+	}
+
+	since := b.suspendedUntil[symbol]
+	if now.Before(since) {
+		return true
+	}
+	if cfg.MaxAge > 0 {
+		if cutoff := now.Add(-cfg.MaxAge); cutoff.After(since) {
+			since = cutoff
+		}
+	}
+
+	// Newest first, matching the live tracker
+	pnls := make([]float64, 0, cfg.Window)
+	for i := len(b.trades) - 1; i >= 0 && len(pnls) < cfg.Window; i-- {
+		trade := b.trades[i]
+		if trade.Symbol == symbol && trade.ExitTime.After(since) {
+			pnls = append(pnls, trade.PnL)
+		}
+	}
+
+	stats, suspend := risk.EvaluatePerformance(pnls, *cfg)
+	if !suspend {
+		return false
+	}
+
+	b.suspendedUntil[symbol] = now.Add(cfg.Probation)
+	b.suspensions++
+	log.Printf("Suspending %s at %s: expectancy %.4f USDT over %d trades",
+		symbol, now.Format("2006-01-02 15:04"), stats.Expectancy, stats.Trades)
+	return true
+}
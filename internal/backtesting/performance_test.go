@@ -0,0 +1,51 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"testing"
+	"time"
+)
+
+func TestSuspendedAppliesTheLiveRule(t *testing.T) {
+	config := exactConfig()
+	config.Performance = &risk.PerformanceConfig{Window: 10, MinTrades: 3, MinExpectancy: 0, Probation: 24 * time.Hour}
+	b := newTestBacktest(t, config)
+	b.trades = []Trade{
+		closedTrade("ONDOUSDT", 0, -1),
+		closedTrade("BTCUSDT", 1, -5),
+		closedTrade("ONDOUSDT", 2, 0.5),
+	}
+	now := testStart.Add(6 * time.Hour)
+
+	// Two trades are not enough evidence
+	if b.suspended("ONDOUSDT", now) {
+		t.Fatal("suspended on 2 trades, MinTrades is 3")
+	}
+
+	b.trades = append(b.trades, closedTrade("ONDOUSDT", 3, -1))
+	if !b.suspended("ONDOUSDT", now) {
+		t.Fatal("not suspended at expectancy -0.5 over 3 trades")
+	}
+	if b.suspended("BTCUSDT", now) {
+		t.Error("another symbol's losses suspended BTCUSDT on one trade")
+	}
+	if !b.suspended("ONDOUSDT", now.Add(23*time.Hour)) {
+		t.Error("suspension lifted during probation")
+	}
+
+	// Probation over, the losers before it no longer count
+	if b.suspended("ONDOUSDT", now.Add(24*time.Hour)) {
+		t.Error("still suspended after probation on trades from before it")
+	}
+	if b.suspensions != 1 {
+		t.Errorf("suspensions = %d, want 1", b.suspensions)
+	}
+}
+
+func TestSuspendedOffWithoutConfig(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	b.trades = []Trade{closedTrade("ONDOUSDT", 0, -1), closedTrade("ONDOUSDT", 1, -1), closedTrade("ONDOUSDT", 2, -1)}
+	if b.suspended("ONDOUSDT", testStart.Add(time.Hour)) {
+		t.Error("suspended without Config.Performance")
+	}
+}
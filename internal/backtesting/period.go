@@ -0,0 +1,39 @@
+package backtesting
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParsePeriod returns the period between from and to, each YYYY-MM-DD in UTC or RFC3339
+// A missing to is now and a missing from is days before to
+func ParsePeriod(from, to string, days int) (time.Time, time.Time, error) {
+	end := time.Now()
+	if to != "" {
+		parsed, err := parseTimeOrDay(to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -days)
+	if from != "" {
+		parsed, err := parseTimeOrDay(from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+// parseTimeOrDay reads an RFC3339 time or a YYYY-MM-DD day, the latter at UTC midnight
+func parseTimeOrDay(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
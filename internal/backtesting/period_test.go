@@ -0,0 +1,48 @@
+package backtesting
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePeriod(t *testing.T) {
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		from, to   string
+		days       int
+		start, end time.Time
+		wantErr    bool
+	}{
+		{"days", "2024-01-01", "2024-02-01", 5, jan, feb, false},
+		{"days before to", "", "2024-02-01", 31, jan, feb, false},
+		{"RFC3339", "2024-01-01T00:00:00Z", "2024-01-01T12:30:00+02:00", 30, jan, jan.Add(10*time.Hour + 30*time.Minute), false},
+		{"from after to", "2024-02-01", "2024-01-01", 30, time.Time{}, time.Time{}, true},
+		{"empty period", "2024-01-01", "2024-01-01", 30, time.Time{}, time.Time{}, true},
+		{"malformed from", "01/01/2024", "2024-02-01", 30, time.Time{}, time.Time{}, true},
+		{"malformed to", "", "2024-13-01", 30, time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, err := ParsePeriod(tt.from, tt.to, tt.days)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePeriod() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !start.Equal(tt.start) || !end.Equal(tt.end) {
+				t.Errorf("ParsePeriod() = %s to %s, want %s to %s", start, end, tt.start, tt.end)
+			}
+		})
+	}
+}
+
+func TestParsePeriodEndsNowByDefault(t *testing.T) {
+	before := time.Now()
+	start, end, err := ParsePeriod("", "", 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if end.Before(before) || time.Since(end) > time.Minute || !start.Equal(end.AddDate(0, 0, -30)) {
+		t.Errorf("ParsePeriod() = %s to %s, want the 30 days up to now", start, end)
+	}
+}
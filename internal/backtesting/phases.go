@@ -0,0 +1,314 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+)
+
+// Phase is one step of the per-candle processing order.
+//
+// Every candle is processed in the same fixed order so results never depend on
+// incidental code layout:
+//
+//  1. PhaseProtectiveExits - take profit / stop loss on the open position, then stop adjustments,
+//     then the account equity stop, then the rest of entries filling over several candles
+//  2. PhaseTimeExits       - time or regime based exits
+//  3. PhaseReversals       - evaluation of reversing the open position
+//  4. PhaseEntries         - new entries when flat and nothing closed on this candle
+//  5. PhaseEquityMark      - record the equity curve
+//
+// This mirrors the live pipeline, where the position monitor resolves exits
+// before the analysis loop considers a new entry for the symbol.
+//
+// With TimingNextOpen, market entries and reversals signalled by a candle are queued
+// and fill at the next candle's open, before any of its phases run.
+type Phase int
+
+const (
+	PhaseProtectiveExits Phase = iota
+	PhaseTimeExits
+	PhaseReversals
+	PhaseEntries
+	PhaseEquityMark
+)
+
+// DefaultPhaseOrder is the order every candle is processed in
+var DefaultPhaseOrder = []Phase{
+	PhaseProtectiveExits,
+	PhaseTimeExits,
+	PhaseReversals,
+	PhaseEntries,
+	PhaseEquityMark,
+}
+
+func (p Phase) String() string {
+	switch p {
+	case PhaseProtectiveExits:
+		return "protective_exits"
+	case PhaseTimeExits:
+		return "time_exits"
+	case PhaseReversals:
+		return "reversals"
+	case PhaseEntries:
+		return "entries"
+	case PhaseEquityMark:
+		return "equity_mark"
+	}
+	return "unknown"
+}
+
+// CandleState is the mutable state shared by the phases of one candle
+type CandleState struct {
+	Symbol   string
+	Index    int            // Position of Price in the symbol's candle stream
+	Window   []models.Price // Trailing analysis window ending at Price, valid for this candle only
+	Price    models.Price
+	Position *Trade        // Open position, nil when flat
+	Hedge    *Trade        // Opposite leg of Position in hedge mode, nil otherwise
+	Pending  *PendingEntry // Resting limit entry, nil when none
+	Queued   *QueuedEntry  // Market entry filling at the next open, nil when none
+
+	// 1d candles closed by the end of Price, for the daily bias
+	Daily []models.Price
+
+	closedThisCandle bool
+}
+
+// PendingEntry is a simulated limit entry waiting for price to reach it
+type PendingEntry struct {
+	Signal     *analysis.AnalysisResult
+	LimitPrice float64
+	PlacedAt   int // Candle index the order was placed on
+}
+
+// PhaseHook runs after the built-in work of the phase it is attached to
+type PhaseHook func(state *CandleState)
+
+// OnPhase attaches a hook to a phase
+func (b *Backtest) OnPhase(phase Phase, hook PhaseHook) {
+	b.hooks[phase] = append(b.hooks[phase], hook)
+}
+
+// processCandle runs every phase for one candle in the configured order
+func (b *Backtest) processCandle(state *CandleState) {
+	b.fillQueued(state)
+	b.trackHeldLegs(state.Symbol, state.Price)
+	for _, phase := range b.phaseOrder {
+		b.runPhase(phase, state)
+		for _, hook := range b.hooks[phase] {
+			hook(state)
+		}
+	}
+}
+
+func (b *Backtest) runPhase(phase Phase, state *CandleState) {
+	switch phase {
+	case PhaseProtectiveExits:
+		b.protectiveExits(state)
+	case PhaseTimeExits:
+		// No built-in behavior yet; hooks may act here
+	case PhaseReversals:
+		b.reversals(state)
+	case PhaseEntries:
+		b.entries(state)
+	case PhaseEquityMark:
+		b.equityMark(state)
+	}
+}
+
+// legs returns the slots holding the symbol's positions, the hedge leg last
+func (s *CandleState) legs() []**Trade {
+	return []**Trade{&s.Position, &s.Hedge}
+}
+
+// canOpen reports whether a position on side may be added, one per side in hedge mode
+func (s *CandleState) canOpen(side string, hedgeMode bool) bool {
+	if !hedgeMode {
+		return s.Position == nil
+	}
+	for _, leg := range s.legs() {
+		if *leg != nil && (*leg).Side == side {
+			return false
+		}
+	}
+	return true
+}
+
+// place puts a new position in the first free slot
+func (s *CandleState) place(trade *Trade) {
+	if s.Position == nil {
+		s.Position = trade
+		return
+	}
+	s.Hedge = trade
+}
+
+// protectiveExits checks each leg's take profit and stop loss independently
+func (b *Backtest) protectiveExits(state *CandleState) {
+	for _, leg := range state.legs() {
+		if *leg == nil {
+			continue
+		}
+
+		// Liquidation takes precedence over a stop beyond it, whose exit price would lose more than the margin
+		if trading.LiquidatedBeforeStop((*leg).Side, (*leg).StopLoss, (*leg).LiquidationPrice, state.Price.Low, state.Price.High) {
+			b.liquidate(*leg, state.Price)
+			*leg = nil
+			state.closedThisCandle = true
+			continue
+		}
+
+		if reason, exitPrice, ok := b.resolveExit(*leg, state.Price); ok {
+			b.closePosition(*leg, state.Price, exitPrice, reason)
+			*leg = nil
+			state.closedThisCandle = true
+			continue
+		}
+
+		(*leg).trackExcursion(state.Price.Low, state.Price.High)
+		b.applyBreakeven(*leg, state.Price)
+		b.manageStops(*leg, state)
+	}
+	b.equityStop(state)
+
+	// Keep the remaining leg in the primary slot
+	if state.Position == nil {
+		state.Position, state.Hedge = state.Hedge, nil
+	}
+	b.fillRest(state)
+}
+
+// reversals flips the open position when an opposite signal clears the same guards as live trading
+func (b *Backtest) reversals(state *CandleState) {
+	if state.Position == nil || state.Queued != nil || state.closedThisCandle || !b.config.Reversal.Enabled || b.config.Entry.HedgeMode {
+		return
+	}
+	// A reversal would open the side a single-side run excludes
+	if b.config.Direction != strategy.DirectionBoth {
+		return
+	}
+	if b.config.Universe != nil && !b.config.Universe.Contains(state.Symbol, state.Price.OpenTime) {
+		return
+	}
+	if !b.cadenceCloses(state) {
+		return
+	}
+
+	result := b.decide(state.Symbol, state.Window, state.Daily, candleClose(state.Price))
+
+	key := reversalKey(state.Symbol, state.Price)
+	ok, _ := trading.ShouldReverse(b.config.Reversal, state.Position.Side, state.Position.Confidence,
+		state.Position.EntryTime, result, state.Price.OpenTime, b.reversalCount[key])
+	if !ok || b.invalidSignal(state, result) {
+		return
+	}
+	if b.suspended(state.Symbol, state.Price.OpenTime) {
+		b.suspendedSignals++
+		return
+	}
+	if b.equityBlocked(state) {
+		b.equityStopSignals++
+		return
+	}
+	if b.throttled(state) {
+		b.throttledSignals++
+		return
+	}
+
+	b.signals++
+	if b.config.Timing == TimingNextOpen {
+		state.Queued = &QueuedEntry{Signal: result, Reversal: true}
+		return
+	}
+
+	b.reverse(state, state.Price, state.Price.Close)
+	state.Position = b.openPosition(result, state.Price.Close, state.Price)
+	if state.Position != nil {
+		state.Position.FromReversal = true
+	}
+	b.reversalCount[key]++
+}
+
+func (b *Backtest) entries(state *CandleState) {
+	if state.closedThisCandle || state.Queued != nil || (state.Position != nil && (!b.config.Entry.HedgeMode || state.Hedge != nil)) {
+		return
+	}
+
+	if state.Pending != nil {
+		b.checkPendingEntry(state)
+		return
+	}
+	// Like live trading, a symbol out of the universe keeps its positions but takes no new ones
+	if b.config.Universe != nil && !b.config.Universe.Contains(state.Symbol, state.Price.OpenTime) {
+		return
+	}
+	if !b.cadenceCloses(state) {
+		return
+	}
+
+	result := b.decide(state.Symbol, state.Window, state.Daily, candleClose(state.Price))
+
+	if !result.IsValid {
+		return
+	}
+	if !state.canOpen(result.Direction, b.config.Entry.HedgeMode) {
+		return
+	}
+	if b.invalidSignal(state, result) {
+		return
+	}
+	if b.suspended(state.Symbol, state.Price.OpenTime) {
+		b.suspendedSignals++
+		return
+	}
+	if b.equityBlocked(state) {
+		b.equityStopSignals++
+		return
+	}
+	if b.throttled(state) {
+		b.throttledSignals++
+		return
+	}
+	b.signals++
+
+	if b.config.Entry.Mode == trading.EntryModeLimit {
+		state.Pending = &PendingEntry{
+			Signal:     result,
+			LimitPrice: trading.LimitPrice(result.Direction, state.Price.Close, b.config.Entry.LimitOffset),
+			PlacedAt:   state.Index,
+		}
+		return
+	}
+	if b.config.Timing == TimingNextOpen {
+		state.Queued = &QueuedEntry{Signal: result}
+		return
+	}
+
+	state.place(b.openPosition(result, state.Price.Close, state.Price))
+}
+
+// checkPendingEntry fills, invalidates or expires a resting limit entry against this candle
+func (b *Backtest) checkPendingEntry(state *CandleState) {
+	pending := state.Pending
+	side := pending.Signal.Direction
+
+	switch {
+	case trading.LimitFilled(side, pending.LimitPrice, state.Price.Low, state.Price.High):
+		state.place(b.openPosition(pending.Signal, pending.LimitPrice, state.Price))
+		state.Pending = nil
+	case trading.LimitInvalidated(side, pending.Signal.TakeProfit, state.Price.Low, state.Price.High):
+		state.Pending = nil
+	case state.Index-pending.PlacedAt >= b.config.Entry.ExpiryCandles:
+		state.Pending = nil
+	}
+}
+
+// equityMark notes a close on the candle; the point itself is recorded once per step by markEquity,
+// after every symbol's candle at the step's time is processed
+func (b *Backtest) equityMark(state *CandleState) {
+	if state.closedThisCandle {
+		b.stepClosed = true
+	}
+}
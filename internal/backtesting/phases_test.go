@@ -0,0 +1,72 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+)
+
+// entryHook opens a long at 100, stop 99 and target 103, on the first candle the symbol has no position
+func entryHook(opened *bool) PhaseHook {
+	return func(state *CandleState) {
+		if state.Position == nil && !*opened {
+			state.place(openTrade(state.Symbol, "long", 100, 99, 103))
+			*opened = true
+		}
+	}
+}
+
+func TestPhasesRunInDefaultOrder(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	var ran []Phase
+	for _, phase := range DefaultPhaseOrder {
+		b.OnPhase(phase, func(state *CandleState) { ran = append(ran, phase) })
+	}
+	price := candle("BTCUSDT", 1, 100, 101, 99.5, 100)
+	b.processCandle(&CandleState{Symbol: "BTCUSDT", Price: price, Window: []models.Price{price}})
+
+	// The live pipeline's order: the monitor settles exits before the analysis loop reverses or enters
+	want := []Phase{PhaseProtectiveExits, PhaseTimeExits, PhaseReversals, PhaseEntries, PhaseEquityMark}
+	if len(ran) != len(want) {
+		t.Fatalf("phases ran %v, want %v", ran, want)
+	}
+	for i := range want {
+		if ran[i] != want[i] {
+			t.Fatalf("phases ran %v, want %v", ran, want)
+		}
+	}
+}
+
+func TestPhaseOrderIsLoadBearing(t *testing.T) {
+	// The candle an entry fills at the close of dips through its stop before that close
+	price := candle("BTCUSDT", 1, 100, 100.5, 98.5, 100)
+
+	tests := []struct {
+		name   string
+		order  []Phase
+		trades int
+	}{
+		{"default, the entry waits for the next candle's range", DefaultPhaseOrder, 0},
+		{"entries first, the entry is stopped by the range it opened after", []Phase{PhaseEntries, PhaseReversals, PhaseProtectiveExits, PhaseTimeExits, PhaseEquityMark}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			b.phaseOrder = tt.order
+			var opened bool
+			b.OnPhase(PhaseEntries, entryHook(&opened))
+
+			// A window too short for the strategy to signal, the hook's the only entry
+			state := &CandleState{Symbol: "BTCUSDT", Price: price, Window: []models.Price{price}}
+			b.processCandle(state)
+			if len(b.trades) != tt.trades {
+				t.Fatalf("got %d trades, want %d", len(b.trades), tt.trades)
+			}
+			if tt.trades == 0 && state.Position == nil {
+				t.Error("the entry was not kept open")
+			}
+			if tt.trades == 1 && b.trades[0].Reason != "stop_loss" {
+				t.Errorf("Reason = %q, want stop_loss", b.trades[0].Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package backtesting
+
+import (
+	"sync"
+	"time"
+)
+
+// progressEvery is how many candles pass between cancellation checks and progress reports
+const progressEvery = 500
+
+// Progress is how far a run has come
+type Progress struct {
+	Symbol   string    `json:"symbol"`   // Symbol that just finished, empty while symbols are stepped through
+	Time     time.Time `json:"time"`     // Step reached
+	Done     int       `json:"done"`     // Symbols finished
+	Symbols  int       `json:"symbols"`  // Symbols in the run
+	Fraction float64   `json:"fraction"` // Share of the whole run done, 0 to 1
+}
+
+// OnProgress reports the run's progress to fn every few hundred steps and after each symbol
+// fn is called from the goroutine running the backtest
+func (b *Backtest) OnProgress(fn func(Progress)) {
+	b.progress = fn
+}
+
+// progressTracker turns step times into Progress for a run over symbols
+// Symbols share one clock, so the fraction is the share of the period stepped through, or of the symbols
+// finished when that is further along
+type progressTracker struct {
+	mu         sync.Mutex
+	report     func(Progress)
+	start, end time.Time
+	symbols    int
+	done       int
+	at         time.Time
+}
+
+func newProgressTracker(report func(Progress), start, end time.Time, symbols int) *progressTracker {
+	return &progressTracker{report: report, start: start, end: end, symbols: symbols, at: start}
+}
+
+// step reports the run having reached the step at openTime
+func (t *progressTracker) step(openTime time.Time) {
+	if t == nil || t.report == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.at = openTime
+	t.send("")
+}
+
+// symbolDone reports symbol having been replayed to the end
+func (t *progressTracker) symbolDone(symbol string) {
+	if t == nil || t.report == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.done++
+	t.send(symbol)
+}
+
+func (t *progressTracker) send(symbol string) {
+	fraction := 1.0
+	if t.symbols > 0 {
+		fraction = float64(t.done) / float64(t.symbols)
+		if span := t.end.Sub(t.start); span > 0 && t.done < t.symbols {
+			fraction = max(fraction, min(max(float64(t.at.Sub(t.start))/float64(span), 0), 1))
+		}
+	}
+	t.report(Progress{Symbol: symbol, Time: t.at, Done: t.done, Symbols: t.symbols, Fraction: fraction})
+}
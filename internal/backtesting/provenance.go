@@ -0,0 +1,23 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/provenance"
+	"CryptoTradeBot/internal/services/strategy"
+	"log"
+)
+
+// RunSettings is everything a run's outcome depends on besides its candles, as recorded in its provenance
+type RunSettings struct {
+	Strategy strategy.Settings `json:"strategy"`
+	Backtest Config            `json:"backtest"`
+}
+
+// provenance snapshots the build and settings of the run, nil when they cannot be encoded
+func (b *Backtest) provenance() *provenance.Snapshot {
+	snapshot, err := provenance.New(RunSettings{Strategy: b.strategies.Settings(), Backtest: b.config})
+	if err != nil {
+		log.Printf("Failed to record the run's settings: %v", err)
+		return nil
+	}
+	return snapshot
+}
@@ -0,0 +1,41 @@
+package backtesting
+
+import (
+	"hash/fnv"
+	"math/rand"
+)
+
+// DefaultSeed seeds the randomness of a backtest unless Config.Seed says otherwise
+const DefaultSeed int64 = 1
+
+// Random is the only source of randomness in a backtest, so a run is reproduced from its seed
+// Each component draws from its own stream, so enabling one does not shift the draws of another
+type Random struct {
+	seed    int64
+	streams map[string]*rand.Rand
+}
+
+// NewRandom creates a new instance of Random
+func NewRandom(seed int64) *Random {
+	return &Random{
+		seed:    seed,
+		streams: make(map[string]*rand.Rand),
+	}
+}
+
+// Seed returns the seed every stream derives from
+func (r *Random) Seed() int64 {
+	return r.seed
+}
+
+// Stream returns the generator of the named component, created on first use
+func (r *Random) Stream(name string) *rand.Rand {
+	if stream, ok := r.streams[name]; ok {
+		return stream
+	}
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	stream := rand.New(rand.NewSource(r.seed ^ int64(h.Sum64())))
+	r.streams[name] = stream
+	return stream
+}
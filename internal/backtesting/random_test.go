@@ -0,0 +1,98 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/testdb"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRandomStreamsAreIndependent(t *testing.T) {
+	draw := func(r *Random, name string) []float64 {
+		values := make([]float64, 5)
+		for i := range values {
+			values[i] = r.Stream(name).Float64()
+		}
+		return values
+	}
+
+	alone := draw(NewRandom(7), "slippage")
+	mixed := NewRandom(7)
+	draw(mixed, "same-bar")
+	if got := draw(mixed, "slippage"); !equalFloats(got, alone) {
+		t.Errorf("slippage draws shifted by another stream: %v, want %v", got, alone)
+	}
+	if got := draw(NewRandom(8), "slippage"); equalFloats(got, alone) {
+		t.Errorf("seeds 7 and 8 drew the same slippage %v", got)
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stochasticRun runs the fixture with every random component enabled and returns the saved results
+func stochasticRun(t *testing.T, seed int64) []byte {
+	t.Helper()
+	config := DefaultConfig()
+	config.SameBar = SameBarProbabilistic
+	config.SlippageJitter = 0.002
+	config.Seed = seed
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	results, err := NewBacktestWithConfig(fixtureSource(), testStrategies(t), config).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	if len(results.Trades) == 0 {
+		t.Fatal("the fixture backtest made no trades")
+	}
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := results.Export(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// firstDiff returns the first differing line of a and b with its number, for a readable failure
+func firstDiff(a, b []byte) (int, string, string) {
+	aLines, bLines := strings.Split(string(a), "\n"), strings.Split(string(b), "\n")
+	for i := range min(len(aLines), len(bLines)) {
+		if aLines[i] != bLines[i] {
+			return i + 1, aLines[i], bLines[i]
+		}
+	}
+	return min(len(aLines), len(bLines)) + 1, "", ""
+}
+
+func TestSeededRunsAreByteIdentical(t *testing.T) {
+	first, second := stochasticRun(t, DefaultSeed), stochasticRun(t, DefaultSeed)
+	if !bytes.Equal(first, second) {
+		line, a, b := firstDiff(first, second)
+		t.Fatalf("two runs with seed %d differ from line %d:\n  %s\n  %s", DefaultSeed, line, a, b)
+	}
+	if !bytes.Contains(first, []byte(`"Seed": 1`)) {
+		t.Error("exported results do not record the seed")
+	}
+
+	// Another seed draws other slippage, so the stochastic fills move
+	if other := stochasticRun(t, 42); bytes.Equal(first, other) {
+		t.Error("seeds 1 and 42 produced identical results, the random components drew nothing")
+	}
+}
@@ -0,0 +1,126 @@
+package backtesting
+
+import (
+	"math"
+	"time"
+)
+
+// DaysPerYear annualizes daily figures; crypto trades every day of the year
+const DaysPerYear = 365
+
+// RatioConfig tunes the risk-adjusted ratios of a run
+type RatioConfig struct {
+	RiskFreeRate float64 // Annual rate returns are measured against, as a fraction
+	MinDays      int     // Daily returns needed before the ratios are reported
+}
+
+// DefaultRatioConfig measures against a zero risk-free rate and needs a month of daily returns
+func DefaultRatioConfig() RatioConfig {
+	return RatioConfig{MinDays: 30}
+}
+
+// Ratios are a run's risk-adjusted performance, computed from its equity resampled to daily closes
+// Sharpe and Sortino annualize by the square root of DaysPerYear; a ratio whose denominator is zero is 0
+type Ratios struct {
+	Days       int  // Daily returns the ratios come from
+	Sufficient bool // At least RatioConfig.MinDays daily returns; the ratios are left at 0 otherwise
+
+	Sharpe       float64 // Mean excess daily return over its standard deviation
+	Sortino      float64 // Mean excess daily return over its downside deviation
+	Calmar       float64 // AnnualReturn over MaxDrawdown
+	AnnualReturn float64 // Compounded from the daily returns
+	MaxDrawdown  float64 // Deepest fall of the daily closes below their running peak, as a fraction of it
+}
+
+// DailyCloses resamples an equity curve sorted by time to the equity at the end of each UTC day from
+// start's day to end's, carrying the latest equity over days without points and initial before the first
+func DailyCloses(curve []EquityPoint, initial float64, start, end time.Time) []float64 {
+	day := 24 * time.Hour
+	first := start.UTC().Truncate(day)
+	last := end.UTC().Add(-time.Nanosecond).Truncate(day)
+
+	var closes []float64
+	equity, next := initial, 0
+	for at := first; !at.After(last); at = at.Add(day) {
+		dayEnd := at.Add(day)
+		for next < len(curve) && curve[next].Timestamp.Before(dayEnd) {
+			equity = curve[next].Balance
+			next++
+		}
+		closes = append(closes, equity)
+	}
+	return closes
+}
+
+// DailyReturns returns the change of each daily close from the one before, the first from initial
+// A day starting from no equity has a zero return
+func DailyReturns(initial float64, closes []float64) []float64 {
+	returns := make([]float64, len(closes))
+	previous := initial
+	for i, close := range closes {
+		if previous > 0 {
+			returns[i] = close/previous - 1
+		}
+		previous = close
+	}
+	return returns
+}
+
+// MeasureRatios computes the Ratios of daily closes starting from initial
+func MeasureRatios(initial float64, closes []float64, config RatioConfig) Ratios {
+	returns := DailyReturns(initial, closes)
+	ratios := Ratios{Days: len(returns), Sufficient: len(returns) >= max(config.MinDays, 2)}
+	if !ratios.Sufficient {
+		return ratios
+	}
+
+	riskFree := config.RiskFreeRate / DaysPerYear
+	excess := make([]float64, len(returns))
+	var downside float64
+	for i, r := range returns {
+		excess[i] = r - riskFree
+		if excess[i] < 0 {
+			downside += excess[i] * excess[i]
+		}
+	}
+	mean := average(excess)
+	annualize := math.Sqrt(DaysPerYear)
+
+	if stdDev := standardDeviation(excess, mean); stdDev > 0 {
+		ratios.Sharpe = mean / stdDev * annualize
+	}
+	if downside = math.Sqrt(downside / float64(len(excess))); downside > 0 {
+		ratios.Sortino = mean / downside * annualize
+	}
+
+	growth := 1.0
+	for _, r := range returns {
+		growth *= 1 + r
+	}
+	ratios.AnnualReturn = -1
+	if growth > 0 {
+		ratios.AnnualReturn = math.Pow(growth, DaysPerYear/float64(len(returns))) - 1
+	}
+
+	peak := initial
+	for _, close := range closes {
+		peak = math.Max(peak, close)
+		if peak > 0 {
+			ratios.MaxDrawdown = math.Max(ratios.MaxDrawdown, (peak-close)/peak)
+		}
+	}
+	if ratios.MaxDrawdown > 0 {
+		ratios.Calmar = ratios.AnnualReturn / ratios.MaxDrawdown
+	}
+	return ratios
+}
+
+// measureRatios sets the ratios of results from its equity curve over start to end, starting from initial
+func (r *BacktestResults) measureRatios(initial float64, start, end time.Time, config RatioConfig) {
+	ratios := MeasureRatios(initial, DailyCloses(r.EquityCurve, initial, start, end), config)
+	r.SharpeRatio = ratios.Sharpe
+	r.SortinoRatio = ratios.Sortino
+	r.CalmarRatio = ratios.Calmar
+	r.RatioDays = ratios.Days
+	r.RatiosInsufficient = !ratios.Sufficient
+}
@@ -0,0 +1,88 @@
+package backtesting
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDailyClosesCarryEquityOverQuietDays(t *testing.T) {
+	day := 24 * time.Hour
+	curve := []EquityPoint{
+		{Timestamp: testStart.Add(2 * time.Hour), Balance: 105},
+		{Timestamp: testStart.Add(20 * time.Hour), Balance: 110},
+		// Nothing on the second day
+		{Timestamp: testStart.Add(2*day + time.Hour), Balance: 99},
+		{Timestamp: testStart.Add(3*day - time.Nanosecond), Balance: 108.9},
+	}
+	got := DailyCloses(curve, 100, testStart, testStart.Add(4*day))
+	if want := []float64{110, 110, 108.9, 108.9}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DailyCloses() = %v, want %v", got, want)
+	}
+	if got := DailyCloses(nil, 100, testStart, testStart.Add(2*day)); !reflect.DeepEqual(got, []float64{100, 100}) {
+		t.Errorf("DailyCloses() of an empty curve = %v, want the initial equity each day", got)
+	}
+}
+
+func TestMeasureRatiosMatchesHandComputedValues(t *testing.T) {
+	// Daily returns of +10%, -10%, +10% and 0 from 100
+	closes := []float64{110, 99, 108.9, 108.9}
+	annualize := math.Sqrt(365)
+
+	tests := []struct {
+		name    string
+		rate    float64
+		sharpe  float64
+		sortino float64
+	}{
+		// Mean 0.025; sample deviation sqrt(0.0275/3); downside deviation sqrt(0.01/4) = 0.05
+		{"zero risk-free rate", 0, 0.025 / math.Sqrt(0.0275/3) * annualize, 0.025 / 0.05 * annualize},
+		// 3.65% a year is 0.0001 a day: the mean drops to 0.0249, the flat day falls below the rate
+		{"3.65% risk-free rate", 0.0365, 0.0249 / math.Sqrt(0.0275/3) * annualize,
+			0.0249 / math.Sqrt((0.1001*0.1001+0.0001*0.0001)/4) * annualize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ratios := MeasureRatios(100, closes, RatioConfig{RiskFreeRate: tt.rate, MinDays: 4})
+			if !ratios.Sufficient || ratios.Days != 4 {
+				t.Fatalf("MeasureRatios() = %+v, want 4 sufficient days", ratios)
+			}
+			annual := math.Pow(1.1*0.9*1.1, 365.0/4) - 1
+			for _, check := range []struct {
+				name      string
+				got, want float64
+			}{
+				{"Sharpe", ratios.Sharpe, tt.sharpe},
+				{"Sortino", ratios.Sortino, tt.sortino},
+				{"annual return", ratios.AnnualReturn, annual},
+				{"max drawdown", ratios.MaxDrawdown, 0.1}, // 110 down to 99
+				{"Calmar", ratios.Calmar, annual / 0.1},
+			} {
+				if math.Abs(check.got-check.want) > 1e-6*math.Max(1, math.Abs(check.want)) {
+					t.Errorf("%s = %v, want %v", check.name, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRatiosNeedEnoughDays(t *testing.T) {
+	ratios := MeasureRatios(100, []float64{110, 99, 108.9}, DefaultRatioConfig())
+	if ratios.Sufficient || ratios.Days != 3 || ratios.Sharpe != 0 || ratios.Sortino != 0 || ratios.Calmar != 0 {
+		t.Errorf("MeasureRatios() = %+v over 3 days, want insufficient data and no ratios", ratios)
+	}
+
+	results := &BacktestResults{}
+	results.measureRatios(100, testStart, testStart.Add(3*24*time.Hour), DefaultRatioConfig())
+	if !results.RatiosInsufficient || results.RatioDays != 3 {
+		t.Errorf("results marked insufficient %v over %d days, want true over 3", results.RatiosInsufficient, results.RatioDays)
+	}
+}
+
+func TestFlatEquityHasNoRatios(t *testing.T) {
+	ratios := MeasureRatios(100, []float64{100, 100, 100}, RatioConfig{MinDays: 2})
+	if !ratios.Sufficient || ratios.Sharpe != 0 || ratios.Sortino != 0 || ratios.Calmar != 0 || ratios.MaxDrawdown != 0 {
+		t.Errorf("MeasureRatios() of flat equity = %+v, want zero ratios", ratios)
+	}
+}
@@ -0,0 +1,101 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+)
+
+// ReversalStats measures whether reversing positions paid off
+//
+// Every reversed position is also followed as if it had been held: from the next candle
+// on it exits at the take profit or stop loss it had when reversed, the stop filling with
+// ExitSlippage but no jitter and a candle reaching both counting as the stop, the same as
+// the worst-case policy. Legs still open when the data ends are marked at the last close.
+type ReversalStats struct {
+	Reversals int
+
+	ClosedLegPnL   float64 // PnL the reversed positions closed with
+	HeldPnL        float64 // PnL they would have closed with had they been held instead
+	ReversedLegPnL float64 // PnL of the positions the reversals opened
+
+	// NetBenefit is what reversing gained over holding: the closed and new legs' PnL minus the held PnL
+	NetBenefit float64
+
+	Unresolved int // Held legs still open at the end of the data
+}
+
+// heldLeg follows a reversed position as if it had been held
+type heldLeg struct {
+	trade Trade // Copy of the position as it was reversed
+	index int   // Index of its reversal close in Backtest.trades
+}
+
+// reverse closes the position at exitPrice on price for a reversal and starts following it as if held
+func (b *Backtest) reverse(state *CandleState, price models.Price, exitPrice float64) {
+	held := *state.Position
+	b.closePosition(state.Position, price, exitPrice, "reversal")
+	b.held[state.Symbol] = append(b.held[state.Symbol], &heldLeg{trade: held, index: len(b.trades) - 1})
+}
+
+// trackHeldLegs moves the symbol's held legs through the candle, recording those that exit on it
+func (b *Backtest) trackHeldLegs(symbol string, price models.Price) {
+	legs := b.held[symbol][:0]
+	for _, leg := range b.held[symbol] {
+		if exitPrice, ok := b.heldExit(&leg.trade, price); ok {
+			b.trades[leg.index].HeldPnL = tradePnL(&leg.trade, exitPrice)
+			continue
+		}
+		legs = append(legs, leg)
+	}
+	b.held[symbol] = legs
+}
+
+// heldExit returns the price a held leg exits at on price, without touching the run's random draws
+func (b *Backtest) heldExit(trade *Trade, price models.Price) (float64, bool) {
+	if trading.LiquidatedBeforeStop(trade.Side, trade.StopLoss, trade.LiquidationPrice, price.Low, price.High) {
+		return trade.LiquidationPrice, true
+	}
+
+	long := trade.Side == models.PositionSideLong
+	hitTP, hitSL := touches(trade, price)
+	gapTP, _ := touches(trade, models.Price{Open: price.Open, High: price.Open, Low: price.Open})
+	switch {
+	case hitTP && (!hitSL || gapTP):
+		if (long && price.Open > trade.TakeProfit) || (!long && price.Open < trade.TakeProfit) {
+			return price.Open, true
+		}
+		return trade.TakeProfit, true
+	case hitSL:
+		if long {
+			return min(price.Open, trade.StopLoss) * (1 - b.config.ExitSlippage), true
+		}
+		return max(price.Open, trade.StopLoss) * (1 + b.config.ExitSlippage), true
+	}
+	return 0, false
+}
+
+// closeHeldLegs marks the symbol's unresolved held legs at the last close once its data ends
+func (b *Backtest) closeHeldLegs(symbol string, last models.Price) {
+	for _, leg := range b.held[symbol] {
+		b.trades[leg.index].HeldPnL = tradePnL(&leg.trade, last.Close)
+		b.unresolvedHeld++
+	}
+	delete(b.held, symbol)
+}
+
+// Reversals summarizes the reversals among trades
+func Reversals(trades []Trade, unresolved int) ReversalStats {
+	stats := ReversalStats{Unresolved: unresolved}
+	for _, t := range trades {
+		if t.Reason == "reversal" {
+			stats.Reversals++
+			stats.ClosedLegPnL += t.PnL
+			stats.HeldPnL += t.HeldPnL
+		}
+		if t.FromReversal {
+			stats.ReversedLegPnL += t.PnL
+		}
+	}
+	stats.NetBenefit = stats.ClosedLegPnL + stats.ReversedLegPnL - stats.HeldPnL
+	return stats
+}
@@ -0,0 +1,130 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/testdb"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReversalCounterfactualByHand(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	long := openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 102)
+	state := &CandleState{Symbol: "BTCUSDT", Position: long}
+
+	// Reversed at 100.5 the long books 1 * 0.5% * 50 = 0.25
+	reversal := candle("BTCUSDT", 1, 100.2, 100.6, 100.1, 100.5)
+	state.Price = reversal
+	b.reverse(state, reversal, 100.5)
+	short := openTrade("BTCUSDT", models.PositionSideShort, 100.5, 101.5, 99.5)
+	short.FromReversal = true
+	short.EntryTime = reversal.OpenTime
+	state.Position = short
+
+	// The next candle rallies through both: held, the long would have taken profit at 102 for
+	// 1 * 2% * 50 = 1, while the short stops out at 101.5 for -1 * (1 / 100.5) * 50
+	rally := candle("BTCUSDT", 2, 100.5, 102.2, 100.3, 102.1)
+	b.trackHeldLegs("BTCUSDT", rally)
+	trades := stepExits(b, state, rally)
+	if len(trades) != 2 || trades[0].Reason != "reversal" || trades[1].Reason != "stop_loss" {
+		t.Fatalf("trades = %+v, want the reversal close and the stopped short", trades)
+	}
+
+	stopped := -50 / 100.5
+	stats := Reversals(trades, b.unresolvedHeld)
+	want := ReversalStats{
+		Reversals:      1,
+		ClosedLegPnL:   0.25,
+		HeldPnL:        1,
+		ReversedLegPnL: stopped,
+		NetBenefit:     0.25 + stopped - 1,
+	}
+	if !equalReversals(stats, want) {
+		t.Errorf("Reversals() = %+v, want %+v", stats, want)
+	}
+	if len(b.held["BTCUSDT"]) != 0 {
+		t.Errorf("%d held legs still followed after the target, want none", len(b.held["BTCUSDT"]))
+	}
+}
+
+func TestReversalHeldLegs(t *testing.T) {
+	tests := []struct {
+		name       string
+		next       models.Price
+		held       float64
+		unresolved int
+	}{
+		// A short reversed into a long, stopped at 101 had it been held: -1 * 1% * 50
+		{"held short stopped", candle("BTCUSDT", 2, 100.2, 101.3, 100.1, 101.2), -0.5, 0},
+		// The stop wins a candle reaching both, like the worst-case policy
+		{"both levels count as the stop", candle("BTCUSDT", 2, 100, 101.5, 97.5, 99), -0.5, 0},
+		// Gapping past the target takes the better open
+		{"held short gaps past the target", candle("BTCUSDT", 2, 97, 97.5, 96.5, 97), 1.5, 0},
+		// Neither level reached before the data ends, marked at the last close: 1 * 0.4% * 50
+		{"unresolved held short marked at the close", candle("BTCUSDT", 2, 100, 100.5, 99.5, 99.6), 0.2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			position := openTrade("BTCUSDT", models.PositionSideShort, 100, 101, 98)
+			state := &CandleState{Symbol: "BTCUSDT", Position: position}
+			b.reverse(state, candle("BTCUSDT", 1, 100, 100.2, 99.8, 100), 100)
+
+			b.trackHeldLegs("BTCUSDT", tt.next)
+			b.closeHeldLegs("BTCUSDT", tt.next)
+			if got := b.trades[0].HeldPnL; math.Abs(got-tt.held) > 1e-9 {
+				t.Errorf("HeldPnL = %v, want %v", got, tt.held)
+			}
+			if b.unresolvedHeld != tt.unresolved {
+				t.Errorf("%d unresolved held legs, want %d", b.unresolvedHeld, tt.unresolved)
+			}
+		})
+	}
+}
+
+func TestBacktestReportsReversals(t *testing.T) {
+	config := DefaultConfig()
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+	results, err := NewBacktestWithConfig(fixtureSource(), testStrategies(t), config).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+
+	closes, opens := 0, 0
+	for _, trade := range results.Trades {
+		if trade.Reason == "reversal" {
+			closes++
+		}
+		if trade.FromReversal {
+			opens++
+		}
+	}
+	stats := results.Reversals
+	if closes == 0 || stats.Reversals != closes || opens > closes {
+		t.Errorf("%d reversals reported, %d reversal closes and %d positions opened by them, want the fixture to reverse and the counts to match",
+			stats.Reversals, closes, opens)
+	}
+	if math.Abs(stats.NetBenefit-(stats.ClosedLegPnL+stats.ReversedLegPnL-stats.HeldPnL)) > 1e-9 {
+		t.Errorf("NetBenefit = %v, want closed %v plus reversed %v minus held %v", stats.NetBenefit, stats.ClosedLegPnL, stats.ReversedLegPnL, stats.HeldPnL)
+	}
+
+	// Without reversals nothing is reversed or followed
+	config.Reversal.Enabled = false
+	results, err = NewBacktestWithConfig(fixtureSource(), testStrategies(t), config).RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	if results.Reversals != (ReversalStats{}) {
+		t.Errorf("Reversals with reversals disabled = %+v, want none", results.Reversals)
+	}
+}
+
+// equalReversals compares reversal stats to within float rounding
+func equalReversals(got, want ReversalStats) bool {
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	return got.Reversals == want.Reversals && got.Unresolved == want.Unresolved &&
+		near(got.ClosedLegPnL, want.ClosedLegPnL) && near(got.HeldPnL, want.HeldPnL) &&
+		near(got.ReversedLegPnL, want.ReversedLegPnL) && near(got.NetBenefit, want.NetBenefit)
+}
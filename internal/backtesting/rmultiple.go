@@ -0,0 +1,36 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/trading"
+)
+
+// initialRisk returns what the trade stood to lose at its initial stop: its leveraged notional
+// times the stop's distance as a fraction of entry
+func (t *Trade) initialRisk() float64 {
+	if t.EntryPrice <= 0 {
+		return 0
+	}
+	return t.margin() * float64(Leverage) * t.InitialStopDistance / t.EntryPrice
+}
+
+// recordRMultiple sets the R multiple of a trade whose PnL was just set
+func (t *Trade) recordRMultiple() {
+	t.RMultiple = nil
+	if r, ok := trading.RMultiple(t.PnL, t.initialRisk()); ok {
+		t.RMultiple = &r
+	}
+}
+
+// RStatsOf returns the R distribution of trades, counting those without an R as excluded
+func RStatsOf(trades []Trade) trading.RStats {
+	rs := make([]float64, 0, len(trades))
+	excluded := 0
+	for _, t := range trades {
+		if t.RMultiple == nil {
+			excluded++
+			continue
+		}
+		rs = append(rs, *t.RMultiple)
+	}
+	return trading.RDistribution(rs, excluded)
+}
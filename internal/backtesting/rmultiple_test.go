@@ -0,0 +1,80 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"encoding/csv"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTradesCloseWithTheirRMultiple(t *testing.T) {
+	// A long at 100 risking 1 to its stop at 99, aiming for 102
+	tests := []struct {
+		name  string
+		price models.Price
+		want  float64
+	}{
+		{"win at the target", candle("BTCUSDT", 1, 100, 102.5, 99.5, 102), 2},
+		{"loss at the stop", candle("BTCUSDT", 1, 100, 100.5, 98.5, 99), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			state := &CandleState{Symbol: "BTCUSDT", Position: openTrade("BTCUSDT", models.PositionSideLong, 100, 99, 102)}
+
+			trades := stepExits(b, state, tt.price)
+			if len(trades) != 1 || trades[0].RMultiple == nil {
+				t.Fatalf("closed %+v, want one trade with an R", trades)
+			}
+			// The risk is the leveraged notional 1*50 times the stop's 1% distance
+			got := *trades[0].RMultiple
+			if math.Abs(got-trades[0].PnL/0.5) > 1e-9 || math.Abs(got-tt.want) > 0.1 {
+				t.Errorf("R = %v from PnL %v, want about %v", got, trades[0].PnL, tt.want)
+			}
+		})
+	}
+}
+
+func TestRStatsExcludeTradesWithoutAStop(t *testing.T) {
+	win, loss := 2.0, -1.0
+	trades := []Trade{
+		{Symbol: "BTCUSDT", RMultiple: &win},
+		{Symbol: "ETHUSDT", RMultiple: &loss},
+		{Symbol: "SOLUSDT"}, // Its stop sat at entry
+	}
+	stats := RStatsOf(trades)
+	if stats.Trades != 2 || stats.Excluded != 1 || stats.Total != 1 || stats.Average != 0.5 || stats.AtLeast2R != 0.5 {
+		t.Errorf("RStatsOf() = %+v, want 2 trades totalling 1R and 1 excluded", stats)
+	}
+
+	// The export leaves the excluded trade's R empty
+	path := filepath.Join(t.TempDir(), "trades.csv")
+	if err := ExportTradesCSV(path, trades); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := -1
+	for i, name := range rows[0] {
+		if name == "r_multiple" {
+			column = i
+		}
+	}
+	if column < 0 {
+		t.Fatalf("header %v has no r_multiple", rows[0])
+	}
+	for i, want := range []string{"2.00000000", "-1.00000000", ""} {
+		if got := rows[i+1][column]; got != want {
+			t.Errorf("%s r_multiple = %q, want %q", trades[i].Symbol, got, want)
+		}
+	}
+}
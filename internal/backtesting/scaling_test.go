@@ -0,0 +1,45 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRiskMultiplierFollowsTheStreakAcrossSymbols(t *testing.T) {
+	config := exactConfig()
+	scaling := risk.DefaultScalingConfig().WithWinStreak(2, 0.03)
+	config.Scaling = &scaling
+	b := newTestBacktest(t, config)
+
+	results := []struct {
+		symbol string
+		pnl    float64
+		want   float64
+	}{
+		{"BTCUSDT", -10, 1},
+		{"ETHUSDT", -10, 0.5},
+		{"BTCUSDT", 20, 1},
+		{"ETHUSDT", 20, 1.5},
+	}
+	for i, result := range results {
+		exit := testStart.Add(time.Duration(i+1) * time.Hour)
+		b.trades = append(b.trades, Trade{Symbol: result.symbol, ExitTime: exit, PnL: result.pnl})
+
+		if got := b.riskMultiplier(exit); math.Abs(got-result.want) > 1e-9 {
+			t.Errorf("multiplier after trade %d (%s %+.0f) = %v, want %v", i+1, result.symbol, result.pnl, got, result.want)
+		}
+		// An entry before the trade closed does not see it yet
+		if i > 0 {
+			if got, want := b.riskMultiplier(exit.Add(-time.Minute)), results[i-1].want; math.Abs(got-want) > 1e-9 {
+				t.Errorf("multiplier before trade %d closed = %v, want %v", i+1, got, want)
+			}
+		}
+	}
+
+	config.Scaling = nil
+	if got := newTestBacktest(t, config).riskMultiplier(testStart); got != 1 {
+		t.Errorf("multiplier without scaling = %v, want 1", got)
+	}
+}
@@ -0,0 +1,55 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"log"
+	"time"
+)
+
+// guardSize bounds the margin of an entry on result at entryPrice by Config.Sizing against the balance
+// like live trading does, returning the margin to use and the guard's decision on the leveraged size.
+// It returns false, counting and logging the rejection, when the guards reject the entry
+func (b *Backtest) guardSize(result *analysis.AnalysisResult, entryPrice float64, entryTime time.Time, margin float64) (float64, trading.SizeDecision, bool) {
+	size := margin * Leverage / entryPrice
+	if b.config.Sizing == nil {
+		return margin, trading.SizeDecision{Requested: size, Granted: size}, true
+	}
+
+	decision, err := b.config.Sizing.Guard(result.Symbol, size, entryPrice, b.currentBalance, Leverage)
+	if err != nil {
+		log.Printf("%s %s at %s not opened: %v",
+			result.Symbol, result.Direction, entryTime.Format("2006-01-02 15:04"), err)
+		b.sizeRejections++
+		return 0, decision, false
+	}
+	return decision.Granted * entryPrice / Leverage, decision, true
+}
+
+// margin returns what the trade put up in USDT; trades built from live positions carry none and use the
+// fixed size scaled for the streak and the confidence
+func (t *Trade) margin() float64 {
+	if t.Margin > 0 {
+		return t.Margin
+	}
+	return FixedSize * t.RiskMultiplier * t.confidenceFactor()
+}
+
+// confidenceFactor returns ConfidenceMultiplier, 1 for trades saved before it was recorded
+func (t *Trade) confidenceFactor() float64 {
+	if t.ConfidenceMultiplier <= 0 {
+		return 1
+	}
+	return t.ConfidenceMultiplier
+}
+
+// SizeGuards counts the trades whose size each sizing guard changed
+func SizeGuards(trades []Trade) map[string]int {
+	counts := make(map[string]int)
+	for _, trade := range trades {
+		if trade.SizeGuard != "" {
+			counts[trade.SizeGuard]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,52 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"math"
+	"testing"
+)
+
+func TestGuardSizeBoundsMarginLikeLiveTrading(t *testing.T) {
+	tests := []struct {
+		name     string
+		balance  float64
+		margin   float64
+		want     float64
+		reason   string
+		rejected bool
+	}{
+		{"within bounds", InitialBalance, 1, 1, "", false},
+		{"huge risk cut to half the balance", InitialBalance, 20, 5, trading.SizeGuardMarginCap, false},
+		{"tiny margin raised to the minimum notional", InitialBalance, 0.01, 0.1, trading.SizeGuardMinNotional, false},
+		{"tiny balance below the minimum's margin", 0.05, 0.001, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := exactConfig()
+			sizing := trading.DefaultSizingConfig()
+			config.Sizing = &sizing
+			b := newTestBacktest(t, config)
+			b.currentBalance = tt.balance
+			result := &analysis.AnalysisResult{Symbol: "BTCUSDT", Direction: models.PositionSideLong}
+
+			margin, decision, ok := b.guardSize(result, 100, testStart, tt.margin)
+			if ok == tt.rejected {
+				t.Fatalf("guardSize() opened %v, want rejected %v", ok, tt.rejected)
+			}
+			if tt.rejected {
+				if b.sizeRejections != 1 {
+					t.Errorf("%d rejections counted, want 1", b.sizeRejections)
+				}
+				return
+			}
+			if math.Abs(margin-tt.want) > 1e-9 || decision.Reason != tt.reason {
+				t.Errorf("guardSize() = %v margin for %q, want %v for %q", margin, decision.Reason, tt.want, tt.reason)
+			}
+			if requested := tt.margin * Leverage / 100; math.Abs(decision.Requested-requested) > 1e-9 {
+				t.Errorf("requested size %v recorded, want %v", decision.Requested, requested)
+			}
+		})
+	}
+}
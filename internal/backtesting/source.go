@@ -0,0 +1,99 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// PriceSource supplies the candles a backtest replays, repositories.PriceRepository among others
+// Both methods cover start to end inclusive, in open time order; GetPricesByTimeFrame is bounded by ctx
+type PriceSource interface {
+	StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error
+	GetPricesByTimeFrame(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.Price, error)
+}
+
+// historySource is a PriceSource that knows where each series starts, letting a run skip symbols
+// listed too late for the period; the repository and SliceSource are
+type historySource interface {
+	GetEarliestPriceByTimeFrame(symbol, timeFrame string) (*models.Price, error)
+}
+
+// SliceSource is a PriceSource holding its candles in memory, e.g. loaded from a file
+type SliceSource struct {
+	series map[seriesKey][]models.Price
+}
+
+// seriesKey identifies a symbol and timeframe in a SliceSource
+type seriesKey struct {
+	Symbol    string
+	TimeFrame string
+}
+
+// NewSliceSource creates a SliceSource from candles of any symbols and timeframes, in any order
+func NewSliceSource(prices []models.Price) *SliceSource {
+	s := &SliceSource{series: make(map[seriesKey][]models.Price)}
+	s.Add(prices...)
+	return s
+}
+
+// Add stores more candles, keeping each series in open time order
+func (s *SliceSource) Add(prices ...models.Price) {
+	touched := make(map[seriesKey]bool)
+	for _, price := range prices {
+		key := seriesKey{Symbol: price.Symbol, TimeFrame: price.TimeFrame}
+		s.series[key] = append(s.series[key], price)
+		touched[key] = true
+	}
+	for key := range touched {
+		series := s.series[key]
+		sort.SliceStable(series, func(i, j int) bool { return series[i].OpenTime.Before(series[j].OpenTime) })
+	}
+}
+
+// StreamPricesByTimeFrame calls fn for each candle of the series between start and end
+func (s *SliceSource) StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error {
+	if symbol == "" || timeFrame == "" {
+		return errors.New("invalid symbol or timeframe")
+	}
+	for _, price := range s.between(symbol, timeFrame, start, end) {
+		if err := fn(price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPricesByTimeFrame returns the candles of the series between start and end
+func (s *SliceSource) GetPricesByTimeFrame(ctx context.Context, symbol, timeFrame string, start, end time.Time) ([]models.Price, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	between := s.between(symbol, timeFrame, start, end)
+	return append([]models.Price(nil), between...), nil
+}
+
+// GetEarliestPriceByTimeFrame returns the first candle of the series, nil when it has none
+func (s *SliceSource) GetEarliestPriceByTimeFrame(symbol, timeFrame string) (*models.Price, error) {
+	series := s.series[seriesKey{Symbol: symbol, TimeFrame: timeFrame}]
+	if len(series) == 0 {
+		return nil, nil
+	}
+	first := series[0]
+	return &first, nil
+}
+
+func (s *SliceSource) between(symbol, timeFrame string, start, end time.Time) []models.Price {
+	series := s.series[seriesKey{Symbol: symbol, TimeFrame: timeFrame}]
+	from := sort.Search(len(series), func(i int) bool { return !series[i].OpenTime.Before(start) })
+	to := sort.Search(len(series), func(i int) bool { return series[i].OpenTime.After(end) })
+	if from >= to {
+		return nil
+	}
+	return series[from:to]
+}
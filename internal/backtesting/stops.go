@@ -0,0 +1,39 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+)
+
+// manageStops moves the levels of trade where its strategy wants them after the candle, validated
+// the same way live stop updates are. Like breakeven, the new levels apply from the next candle
+func (b *Backtest) manageStops(trade *Trade, state *CandleState) {
+	position := &models.Position{
+		Symbol:              trade.Symbol,
+		Side:                trade.Side,
+		Size:                trade.Size,
+		EntryPrice:          trade.EntryPrice,
+		StopLossPrice:       trade.StopLoss,
+		TakeProfitPrice:     trade.TakeProfit,
+		InitialStopDistance: trade.InitialStopDistance,
+		OpenTime:            trade.EntryTime,
+		Status:              models.PositionStatusOpen,
+	}
+	adjustment := b.strategies.ManagePosition(position, state.Window)
+	if adjustment == nil {
+		return
+	}
+
+	update := trading.StopUpdate{StopLoss: adjustment.StopLoss, TakeProfit: adjustment.TakeProfit, Reason: adjustment.Reason}
+	change, err := trading.ApplyStopUpdate(position, update, state.Price.Close)
+	if err != nil {
+		b.rejectedStopChanges++
+		return
+	}
+	if change == nil {
+		return
+	}
+	trade.StopLoss, trade.TakeProfit = position.StopLossPrice, position.TakeProfitPrice
+	trade.StopChanges++
+	b.stopChanges++
+}
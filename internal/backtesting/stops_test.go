@@ -0,0 +1,67 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"testing"
+)
+
+// stopMover is a strategy that never enters and asks for an open position's stop at a fixed level
+type stopMover struct {
+	stop float64
+}
+
+func (s *stopMover) Analyze(prices []models.Price) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{Symbol: prices[len(prices)-1].Symbol, Reason: "stop mover"}
+}
+
+func (s *stopMover) ManagePosition(position *models.Position, prices []models.Price) *analysis.StopAdjustment {
+	return &analysis.StopAdjustment{StopLoss: s.stop, Reason: "test"}
+}
+
+func TestManagedStopExitsAtTheNewLevel(t *testing.T) {
+	tests := []struct {
+		name        string
+		stop        float64
+		wantExit    bool // On the adverse candle, at stop
+		wantChanges int
+		wantRejects int
+	}{
+		{"raised stop", 101, true, 1, 0},
+		// Loosening fails validation, the 98 stop holds through the dip to 99.5
+		{"loosened stop", 97, false, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mover := &stopMover{stop: tt.stop}
+			strategy.Register("stop-mover", func(analysis.Config) strategy.Strategy { return mover })
+			params := strategy.DefaultParams()
+			params.Strategy = "stop-mover"
+			strategies, err := strategy.NewStrategyManager(params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := NewBacktestWithConfig(NewSliceSource(nil), strategies, exactConfig())
+			trade := openTrade("BTCUSDT", models.PositionSideLong, 100, 98, 110)
+			state := &CandleState{Symbol: "BTCUSDT", Position: trade}
+
+			// A rise short of breakeven, then a dip through 101
+			trades := stepExits(b, state,
+				candle("BTCUSDT", 1, 100, 101.8, 100, 101.5),
+				candle("BTCUSDT", 2, 101.5, 101.6, 99.5, 99.8),
+			)
+			if !tt.wantExit {
+				if len(trades) != 0 {
+					t.Fatalf("closed %+v, want the trade still open", trades)
+				}
+			} else if len(trades) != 1 || trades[0].ExitPrice != tt.stop || trades[0].Reason != "stop_loss" {
+				t.Fatalf("closed %+v, want a stop loss at %v", trades, tt.stop)
+			}
+			if b.stopChanges != tt.wantChanges || b.rejectedStopChanges != tt.wantRejects || trade.StopChanges != tt.wantChanges {
+				t.Errorf("%d stop changes (%d on the trade), %d rejected, want %d and %d rejected",
+					b.stopChanges, trade.StopChanges, b.rejectedStopChanges, tt.wantChanges, tt.wantRejects)
+			}
+		})
+	}
+}
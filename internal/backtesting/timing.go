@@ -0,0 +1,81 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"math"
+)
+
+// ExecutionTiming decides when a market entry fills relative to the candle that signalled it
+type ExecutionTiming string
+
+const (
+	TimingClose    ExecutionTiming = "close"     // At the signal candle's close, a price that is already gone
+	TimingNextOpen ExecutionTiming = "next_open" // At the next candle's open, like live trading acting once the candle closed
+)
+
+// DefaultGapTolerance is how far the next open may move from the signal close before the entry is skipped, as a fraction
+const DefaultGapTolerance = 0.005
+
+// ParseExecutionTiming validates a timing name
+func ParseExecutionTiming(value string) (ExecutionTiming, error) {
+	switch timing := ExecutionTiming(value); timing {
+	case TimingClose, TimingNextOpen:
+		return timing, nil
+	}
+	return "", fmt.Errorf("invalid execution timing %q, want %s or %s", value, TimingClose, TimingNextOpen)
+}
+
+// QueuedEntry is a market signal waiting to fill at the next candle's open
+type QueuedEntry struct {
+	Signal   *analysis.AnalysisResult
+	Reversal bool // Reverses the open position instead of opening beside it
+}
+
+// fillQueued fills the entry queued on the previous candle at this candle's open,
+// skipping it when the open gapped further from the signal price than GapTolerance
+func (b *Backtest) fillQueued(state *CandleState) {
+	queued := state.Queued
+	if queued == nil {
+		return
+	}
+	state.Queued = nil
+
+	open := state.Price.Open
+	if math.Abs(open-queued.Signal.EntryPrice)/queued.Signal.EntryPrice > b.config.GapTolerance {
+		b.gapSkips++
+		return
+	}
+	// Entries signalled on the same candle by several symbols all passed the caps, those filling first
+	// take what is left of them, as live entries open one after another
+	if b.throttledAt(state.Symbol, state.Price.OpenTime) {
+		b.throttledSignals++
+		return
+	}
+	signal := queued.Signal.AtFill(open)
+
+	if !queued.Reversal {
+		if state.canOpen(signal.Direction, b.config.Entry.HedgeMode) {
+			state.place(b.openPosition(signal, open, state.Price))
+		}
+		return
+	}
+	if state.Position == nil {
+		return
+	}
+
+	// The reversal leaves at the open, the rest of the candle belongs to the new position
+	atOpen := models.Price{Symbol: state.Price.Symbol, OpenTime: state.Price.OpenTime, Open: open, High: open, Low: open, Close: open}
+	b.reverse(state, atOpen, open)
+	state.Position = b.openPosition(signal, open, state.Price)
+	if state.Position != nil {
+		state.Position.FromReversal = true
+	}
+	b.reversalCount[reversalKey(state.Symbol, state.Price)]++
+}
+
+// reversalKey identifies a symbol's UTC day for the daily reversal limit
+func reversalKey(symbol string, price models.Price) string {
+	return symbol + price.OpenTime.UTC().Format("2006-01-02")
+}
@@ -0,0 +1,128 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"math"
+	"testing"
+)
+
+// gapSignal is a long signalled at the close of 100 with a 2% target and 1% stop
+func gapSignal(atLevel bool) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{Symbol: "BTCUSDT", IsValid: true, Direction: models.PositionSideLong,
+		EntryPrice: 100, StopLoss: 99, TakeProfit: 102, Confidence: 0.8,
+		TargetMode: analysis.TargetModePercent, TargetAtLevel: atLevel, StopAtLevel: atLevel}
+}
+
+// tradeTiming enters signal with timing after the signal candle and runs the exits over next, returning the closed trades
+func tradeTiming(t *testing.T, timing ExecutionTiming, signal *analysis.AnalysisResult, next models.Price) []Trade {
+	t.Helper()
+	config := exactConfig()
+	config.Timing = timing
+	b := newTestBacktest(t, config)
+	signalled := candle("BTCUSDT", 0, 99.8, 100.1, 99.7, 100)
+	state := &CandleState{Symbol: "BTCUSDT", Price: signalled}
+
+	switch timing {
+	case TimingClose:
+		state.place(b.openPosition(signal, signalled.Close, signalled))
+	case TimingNextOpen:
+		state.Queued = &QueuedEntry{Signal: signal}
+		state.Price = next
+		b.fillQueued(state)
+	}
+	return stepExits(b, state, next)
+}
+
+func TestNextOpenEntryAfterAGap(t *testing.T) {
+	// The next candle opens 0.3% above the signal close and rallies to 102.5
+	next := candle("BTCUSDT", 1, 100.3, 102.5, 100.2, 102.4)
+
+	tests := []struct {
+		name     string
+		timing   ExecutionTiming
+		atLevel  bool
+		entry    float64
+		target   float64
+		reason   string
+		pnlShare float64 // Move captured, as a fraction of the entry
+	}{
+		{"close fills at the gone close", TimingClose, false, 100, 102, "take_profit", 0.02},
+		// Percent exits are recomputed off the fill, keeping the 2% but needing 102.306
+		{"next open moves percent exits with the fill", TimingNextOpen, false, 100.3, 102.306, "take_profit", 0.02},
+		// Exits at a level stay put, so the gap comes out of the trade
+		{"next open keeps level exits", TimingNextOpen, true, 100.3, 102, "take_profit", (102 - 100.3) / 100.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades := tradeTiming(t, tt.timing, gapSignal(tt.atLevel), next)
+			if len(trades) != 1 {
+				t.Fatalf("got %d trades, want 1", len(trades))
+			}
+			trade := trades[0]
+			if math.Abs(trade.EntryPrice-tt.entry) > 1e-9 || math.Abs(trade.TakeProfit-tt.target) > 1e-9 || trade.Reason != tt.reason {
+				t.Errorf("entry %v, target %v, %s, want %v, %v, %s", trade.EntryPrice, trade.TakeProfit, trade.Reason, tt.entry, tt.target, tt.reason)
+			}
+			if want := trade.margin() * tt.pnlShare * Leverage; math.Abs(trade.PnL-want) > 1e-9 {
+				t.Errorf("PnL = %v, want %v", trade.PnL, want)
+			}
+			if tt.timing == TimingNextOpen && !trade.EntryTime.Equal(next.OpenTime) {
+				t.Errorf("EntryTime = %v, want the fill candle's open %v", trade.EntryTime, next.OpenTime)
+			}
+		})
+	}
+
+	// Against the same level target the gap costs next open 0.3 of the 2 points the close timing books
+	atClose := tradeTiming(t, TimingClose, gapSignal(true), next)
+	atOpen := tradeTiming(t, TimingNextOpen, gapSignal(true), next)
+	closeMove := atClose[0].PnL / atClose[0].margin() / Leverage * atClose[0].EntryPrice
+	openMove := atOpen[0].PnL / atOpen[0].margin() / Leverage * atOpen[0].EntryPrice
+	if math.Abs(closeMove-2) > 1e-9 || math.Abs(openMove-1.7) > 1e-9 {
+		t.Errorf("captured %v at the close and %v at the next open, want 2 and 1.7", closeMove, openMove)
+	}
+}
+
+func TestNextOpenSkipsGapsBeyondTolerance(t *testing.T) {
+	tests := []struct {
+		name   string
+		open   float64
+		filled bool
+	}{
+		{"0.3% up fills", 100.3, true},
+		{"at the tolerance fills", 100.5, true},
+		{"0.6% up is skipped", 100.6, false},
+		{"0.6% down is skipped", 99.4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBacktest(t, exactConfig())
+			state := &CandleState{Symbol: "BTCUSDT", Queued: &QueuedEntry{Signal: gapSignal(false)}}
+			state.Price = candle("BTCUSDT", 1, tt.open, tt.open+0.1, tt.open-0.1, tt.open)
+			b.fillQueued(state)
+
+			if (state.Position != nil) != tt.filled {
+				t.Errorf("position %+v, want filled %v", state.Position, tt.filled)
+			}
+			if want := map[bool]int{true: 0, false: 1}[tt.filled]; b.gapSkips != want {
+				t.Errorf("%d gap skips, want %d", b.gapSkips, want)
+			}
+			if state.Queued != nil {
+				t.Error("the queued entry is still waiting after its candle")
+			}
+		})
+	}
+}
+
+func TestDefaultTimingIsNextOpen(t *testing.T) {
+	if config := DefaultConfig(); config.Timing != TimingNextOpen || config.GapTolerance != DefaultGapTolerance {
+		t.Errorf("default timing %q with tolerance %v, want %q with %v", config.Timing, config.GapTolerance, TimingNextOpen, DefaultGapTolerance)
+	}
+	for _, value := range []string{"close", "next_open"} {
+		if _, err := ParseExecutionTiming(value); err != nil {
+			t.Errorf("ParseExecutionTiming(%q) error = %v", value, err)
+		}
+	}
+	if _, err := ParseExecutionTiming("next_close"); err == nil {
+		t.Error("ParseExecutionTiming(next_close) succeeded")
+	}
+}
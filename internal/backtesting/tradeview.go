@@ -0,0 +1,170 @@
+package backtesting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Keys the trades of a TradeView can be sorted by
+const (
+	SortEntry    = "entry"
+	SortSymbol   = "symbol"
+	SortPnL      = "pnl"
+	SortReason   = "reason"
+	SortDuration = "duration"
+)
+
+// SortKeys lists the keys a TradeView sorts by
+var SortKeys = []string{SortEntry, SortSymbol, SortPnL, SortReason, SortDuration}
+
+// TradeView picks and orders the trades shown by the interactive summary
+// Empty filters match every trade; text filters ignore case
+type TradeView struct {
+	Symbol string
+	Side   string
+	Reason string
+	MinPnL *float64
+	MaxPnL *float64
+
+	Sort       string // One of SortKeys, SortEntry when empty
+	Descending bool
+}
+
+// Matches reports whether trade passes every filter of the view
+func (v TradeView) Matches(trade Trade) bool {
+	switch {
+	case v.Symbol != "" && !strings.EqualFold(trade.Symbol, v.Symbol):
+		return false
+	case v.Side != "" && !strings.EqualFold(trade.Side, v.Side):
+		return false
+	case v.Reason != "" && !strings.EqualFold(trade.Reason, v.Reason):
+		return false
+	case v.MinPnL != nil && trade.PnL < *v.MinPnL:
+		return false
+	case v.MaxPnL != nil && trade.PnL > *v.MaxPnL:
+		return false
+	}
+	return true
+}
+
+// Apply returns the trades passing the filters in the view's order, leaving trades as they are
+// Trades equal on the sort key keep their entry order
+func (v TradeView) Apply(trades []Trade) []Trade {
+	picked := make([]Trade, 0, len(trades))
+	for _, trade := range trades {
+		if v.Matches(trade) {
+			picked = append(picked, trade)
+		}
+	}
+
+	less := tradeLess(v.Sort)
+	sort.SliceStable(picked, func(i, j int) bool {
+		if v.Descending {
+			return less(picked[j], picked[i])
+		}
+		return less(picked[i], picked[j])
+	})
+	return picked
+}
+
+// tradeLess orders trades by key, falling back to entry time
+func tradeLess(key string) func(a, b Trade) bool {
+	byEntry := func(a, b Trade) bool { return a.EntryTime.Before(b.EntryTime) }
+	switch key {
+	case SortSymbol:
+		return func(a, b Trade) bool {
+			if a.Symbol != b.Symbol {
+				return a.Symbol < b.Symbol
+			}
+			return byEntry(a, b)
+		}
+	case SortPnL:
+		return func(a, b Trade) bool { return a.PnL < b.PnL }
+	case SortReason:
+		return func(a, b Trade) bool {
+			if a.Reason != b.Reason {
+				return a.Reason < b.Reason
+			}
+			return byEntry(a, b)
+		}
+	case SortDuration:
+		return func(a, b Trade) bool { return a.Duration() < b.Duration() }
+	default:
+		return byEntry
+	}
+}
+
+// ValidSortKey reports whether key is one of SortKeys
+func ValidSortKey(key string) bool {
+	for _, k := range SortKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the view's filters and order, such as "symbol BTCUSDT, pnl >= 0, by pnl desc"
+func (v TradeView) String() string {
+	var parts []string
+	for _, filter := range []struct{ name, value string }{{"symbol", v.Symbol}, {"side", v.Side}, {"reason", v.Reason}} {
+		if filter.value != "" {
+			parts = append(parts, filter.name+" "+filter.value)
+		}
+	}
+	if v.MinPnL != nil {
+		parts = append(parts, fmt.Sprintf("pnl >= %g", *v.MinPnL))
+	}
+	if v.MaxPnL != nil {
+		parts = append(parts, fmt.Sprintf("pnl <= %g", *v.MaxPnL))
+	}
+
+	order := v.Sort
+	if order == "" {
+		order = SortEntry
+	}
+	if v.Descending {
+		order += " desc"
+	}
+	return strings.Join(append(parts, "by "+order), ", ")
+}
+
+// Duration returns how long the trade was open
+func (t Trade) Duration() time.Duration {
+	return t.ExitTime.Sub(t.EntryTime)
+}
+
+// TradeSummary totals a set of trades for the interactive summary
+type TradeSummary struct {
+	Trades       int
+	Wins         int
+	WinRate      float64
+	TotalPnL     float64
+	AveragePnL   float64
+	ProfitFactor float64
+	AverageHold  time.Duration
+}
+
+// SummarizeTrades totals trades, counting those that made money as wins
+func SummarizeTrades(trades []Trade) TradeSummary {
+	summary := TradeSummary{Trades: len(trades)}
+	if len(trades) == 0 {
+		return summary
+	}
+
+	var held time.Duration
+	for _, trade := range trades {
+		if trade.PnL > 0 {
+			summary.Wins++
+		}
+		summary.TotalPnL += trade.PnL
+		held += trade.Duration()
+	}
+	summary.WinRate = float64(summary.Wins) / float64(len(trades))
+	summary.AveragePnL = summary.TotalPnL / float64(len(trades))
+	summary.ProfitFactor = ProfitFactor(trades)
+	summary.AverageHold = held / time.Duration(len(trades))
+	return summary
+}
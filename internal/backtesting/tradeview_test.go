@@ -0,0 +1,146 @@
+package backtesting
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// viewTrades returns four closed trades over two symbols with mixed outcomes, reasons and holds
+func viewTrades() []Trade {
+	trades := []Trade{
+		closedTrade("BTCUSDT", 0, 2),
+		closedTrade("ETHUSDT", 1, -1),
+		closedTrade("BTCUSDT", 2, -0.5),
+		closedTrade("ETHUSDT", 3, 3),
+	}
+	trades[0].Reason, trades[1].Reason, trades[2].Reason, trades[3].Reason = "take_profit", "stop_loss", "stop_loss", "take_profit"
+	trades[1].ExitTime = trades[1].EntryTime.Add(3 * time.Hour)
+	trades[3].Side = "short"
+	return trades
+}
+
+// pnls returns the PnL of each trade in order
+func pnls(trades []Trade) []float64 {
+	out := make([]float64, len(trades))
+	for i, trade := range trades {
+		out[i] = trade.PnL
+	}
+	return out
+}
+
+func TestTradeViewApply(t *testing.T) {
+	zero, two := 0.0, 2.0
+	tests := []struct {
+		name string
+		view TradeView
+		want []float64
+	}{
+		{"everything by entry", TradeView{}, []float64{2, -1, -0.5, 3}},
+		{"symbol ignores case", TradeView{Symbol: "btcusdt"}, []float64{2, -0.5}},
+		{"side", TradeView{Side: "SHORT"}, []float64{3}},
+		{"reason", TradeView{Reason: "stop_loss"}, []float64{-1, -0.5}},
+		{"winners", TradeView{MinPnL: &zero}, []float64{2, 3}},
+		{"pnl range", TradeView{MinPnL: &zero, MaxPnL: &two}, []float64{2}},
+		{"by pnl", TradeView{Sort: SortPnL}, []float64{-1, -0.5, 2, 3}},
+		{"by pnl descending", TradeView{Sort: SortPnL, Descending: true}, []float64{3, 2, -0.5, -1}},
+		{"by symbol keeps entry order", TradeView{Sort: SortSymbol}, []float64{2, -0.5, -1, 3}},
+		{"by reason keeps entry order", TradeView{Sort: SortReason}, []float64{-1, -0.5, 2, 3}},
+		{"longest hold first", TradeView{Sort: SortDuration, Descending: true}, []float64{-1, 2, -0.5, 3}},
+		{"filtered and sorted", TradeView{Symbol: "ETHUSDT", Sort: SortPnL, Descending: true}, []float64{3, -1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trades := viewTrades()
+			if got := pnls(tt.view.Apply(trades)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Apply() PnLs = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(pnls(trades), pnls(viewTrades())) {
+				t.Errorf("Apply() reordered its input to %v", pnls(trades))
+			}
+		})
+	}
+}
+
+func TestApplyBrowseCommand(t *testing.T) {
+	one := 1.0
+	tests := []struct {
+		name    string
+		view    TradeView
+		command string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"sort descending", TradeView{}, "sort", []string{"PnL", "desc"}, "by pnl desc", false},
+		{"sort by an unknown key", TradeView{}, "sort", []string{"size"}, "", true},
+		{"symbol filter", TradeView{}, "symbol", []string{"BTCUSDT"}, "symbol BTCUSDT, by entry", false},
+		{"star drops a filter", TradeView{Symbol: "BTCUSDT"}, "symbol", []string{"*"}, "by entry", false},
+		{"pnl with an open end", TradeView{}, "pnl", []string{"*", "1.5"}, "pnl <= 1.5, by entry", false},
+		{"pnl replaces both bounds", TradeView{MaxPnL: &one}, "pnl", []string{"0"}, "pnl >= 0, by entry", false},
+		{"invalid pnl bound", TradeView{}, "pnl", []string{"lots"}, "", true},
+		{"clear", TradeView{Reason: "stop_loss", Sort: SortPnL}, "clear", nil, "by entry", false},
+		{"unknown command", TradeView{}, "plot", nil, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view, err := applyBrowseCommand(tt.view, tt.command, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyBrowseCommand() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && view.String() != tt.want {
+				t.Errorf("view = %q, want %q", view, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowseExportsTheFilteredView(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "view.csv")
+	in := strings.NewReader("symbol btcusdt\nsort pnl desc\nexport " + path + "\nquit\n")
+	var out strings.Builder
+	if err := Browse(&BacktestResults{Trades: viewTrades()}, in, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "2 trades written to "+path) {
+		t.Errorf("export not reported:\n%s", out.String())
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("%d CSV rows, want a header and the 2 BTCUSDT trades", len(rows))
+	}
+	for _, row := range rows[1:] {
+		if !strings.Contains(strings.Join(row, ","), "BTCUSDT") {
+			t.Errorf("row %v is outside the view", row)
+		}
+	}
+}
+
+func TestSummarizeTrades(t *testing.T) {
+	summary := SummarizeTrades(viewTrades())
+	if summary.Trades != 4 || summary.Wins != 2 || summary.WinRate != 0.5 || summary.TotalPnL != 3.5 {
+		t.Errorf("SummarizeTrades() = %+v, want 4 trades, 2 wins and 3.5 total", summary)
+	}
+	if summary.ProfitFactor != 5.0/1.5 {
+		t.Errorf("profit factor = %v, want %v", summary.ProfitFactor, 5.0/1.5)
+	}
+	if want := (time.Hour + 3*time.Hour + time.Hour + time.Hour) / 4; summary.AverageHold != want {
+		t.Errorf("average hold = %s, want %s", summary.AverageHold, want)
+	}
+	if empty := SummarizeTrades(nil); empty != (TradeSummary{}) {
+		t.Errorf("SummarizeTrades(nil) = %+v, want zeroes", empty)
+	}
+}
@@ -0,0 +1,50 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"sort"
+	"time"
+)
+
+// Universe replays the daily symbol selections live trading made, so a backtest
+// only enters the symbols that were being traded at the time
+type Universe struct {
+	days []time.Time // Oldest first
+	sets []map[string]bool
+}
+
+// NewUniverse builds a Universe from snapshots sorted oldest first
+func NewUniverse(snapshots []models.UniverseSnapshot) *Universe {
+	u := &Universe{}
+	for _, snapshot := range snapshots {
+		set := make(map[string]bool)
+		for _, symbol := range snapshot.SymbolList() {
+			set[symbol] = true
+		}
+		u.days = append(u.days, snapshot.Day)
+		u.sets = append(u.sets, set)
+	}
+	return u
+}
+
+// Contains reports whether symbol was in the universe in force at at
+func (u *Universe) Contains(symbol string, at time.Time) bool {
+	i := sort.Search(len(u.days), func(i int) bool { return u.days[i].After(at) }) - 1
+	return i >= 0 && u.sets[i][symbol]
+}
+
+// Symbols returns every symbol that was in the universe at some point, sorted
+func (u *Universe) Symbols() []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, set := range u.sets {
+		for symbol := range set {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
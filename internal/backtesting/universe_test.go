@@ -0,0 +1,37 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUniverseReplaysEachDaysSelection(t *testing.T) {
+	day := func(n int) time.Time { return testStart.AddDate(0, 0, n) }
+	u := NewUniverse([]models.UniverseSnapshot{
+		{Day: day(0), Symbols: "BTCUSDT,ETHUSDT"},
+		{Day: day(2), Symbols: "BTCUSDT,SOLUSDT"},
+	})
+
+	tests := []struct {
+		symbol string
+		at     time.Time
+		want   bool
+	}{
+		{"BTCUSDT", day(0).Add(-time.Minute), false}, // Before any selection
+		{"BTCUSDT", day(0), true},
+		{"ETHUSDT", day(1).Add(12 * time.Hour), true}, // A day without a selection keeps the last one
+		{"SOLUSDT", day(1).Add(12 * time.Hour), false},
+		{"ETHUSDT", day(2), false}, // Left the universe
+		{"SOLUSDT", day(5), true},
+	}
+	for _, tt := range tests {
+		if got := u.Contains(tt.symbol, tt.at); got != tt.want {
+			t.Errorf("Contains(%s, %s) = %v, want %v", tt.symbol, tt.at.Format(time.DateTime), got, tt.want)
+		}
+	}
+	if got, want := u.Symbols(), []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Symbols() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,20 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"log"
+)
+
+// invalidSignal reports whether result fails strategy.ValidateResult like live trading checks before
+// opening, logging the result; such signals are counted instead of traded
+func (b *Backtest) invalidSignal(state *CandleState, result *analysis.AnalysisResult) bool {
+	err := strategy.ValidateResult(result)
+	if err == nil {
+		return false
+	}
+	log.Printf("Invalid %s signal at %s not traded: %v: %+v",
+		state.Symbol, state.Price.OpenTime.Format("2006-01-02 15:04"), err, *result)
+	b.invalidSignals++
+	return true
+}
@@ -0,0 +1,26 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"testing"
+)
+
+func TestInvalidSignalsAreCountedNotTraded(t *testing.T) {
+	b := newTestBacktest(t, exactConfig())
+	state := &CandleState{Symbol: "BTCUSDT", Price: candle("BTCUSDT", 1, 100, 100.5, 99.5, 100)}
+	valid := &analysis.AnalysisResult{Symbol: "BTCUSDT", Direction: models.PositionSideLong, IsValid: true,
+		Confidence: 0.8, EntryPrice: 100, StopLoss: 99, TakeProfit: 102}
+	inverted := *valid
+	inverted.StopLoss, inverted.TakeProfit = 102, 99
+
+	if b.invalidSignal(state, valid) {
+		t.Error("a valid long was refused")
+	}
+	if !b.invalidSignal(state, &inverted) {
+		t.Error("a long with its levels inverted was accepted")
+	}
+	if b.invalidSignals != 1 {
+		t.Errorf("invalidSignals = %d, want 1", b.invalidSignals)
+	}
+}
@@ -0,0 +1,48 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BaseTimeFrame is the timeframe the backtest steps through; higher ones are resampled from it
+const BaseTimeFrame = models.PriceTimeFrame5m
+
+// checkWarmUp fails when there are fewer than window-1 base candles before index first
+// dataStart is the open time of the symbol's first streamed candle
+func (b *Backtest) checkWarmUp(symbol string, dataStart time.Time, first int) error {
+	if first >= b.window-1 {
+		return nil
+	}
+
+	available := time.Duration(first) * models.TimeFrameDurations[BaseTimeFrame]
+
+	timeFrames := make([]string, 0, len(b.warmUp))
+	for tf := range b.warmUp {
+		timeFrames = append(timeFrames, tf)
+	}
+	sort.Slice(timeFrames, func(i, j int) bool {
+		return models.TimeFrameDurations[timeFrames[i]] < models.TimeFrameDurations[timeFrames[j]]
+	})
+
+	var missing []string
+	for _, tf := range timeFrames {
+		have := int(available / models.TimeFrameDurations[tf])
+		if have < b.warmUp[tf] {
+			missing = append(missing, fmt.Sprintf("%s needs %d candles, has %d", tf, b.warmUp[tf], have))
+		}
+	}
+	if len(missing) == 0 {
+		missing = append(missing, fmt.Sprintf("%s needs %d candles, has %d", BaseTimeFrame, b.window-1, first))
+	}
+
+	from := "start of data"
+	if !dataStart.IsZero() {
+		from = dataStart.Format("2006-01-02 15:04")
+	}
+	return fmt.Errorf("not enough history before the backtest start for %s (data from %s): %s",
+		symbol, from, strings.Join(missing, "; "))
+}
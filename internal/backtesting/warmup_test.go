@@ -0,0 +1,103 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/testdb"
+	"strings"
+	"testing"
+	"time"
+)
+
+// streamOnly hides where a source's series start, so a run finds short history while streaming
+type streamOnly struct {
+	PriceSource
+}
+
+// warmUpStartTime returns the earliest start the fixture's history fully warms up for b
+func warmUpStartTime(b *Backtest) time.Time {
+	return testdb.FixtureStart.Add(time.Duration(b.window-1) * models.TimeFrameDurations[BaseTimeFrame])
+}
+
+func TestNoTradeBeforeWarmUp(t *testing.T) {
+	b := NewBacktestWithConfig(fixtureSource(), testStrategies(t), DefaultConfig())
+	start := warmUpStartTime(b)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour - time.Minute)
+
+	// Every candle reaching the entries phase has every timeframe's warm-up behind it
+	analyzed := 0
+	b.OnPhase(PhaseEntries, func(state *CandleState) {
+		analyzed++
+		if state.Price.OpenTime.Before(start) {
+			t.Errorf("%s analyzed at %s, before the start %s", state.Symbol, state.Price.OpenTime, start)
+		}
+		span := time.Duration(len(state.Window)) * models.TimeFrameDurations[BaseTimeFrame]
+		for tf, need := range b.warmUp {
+			if have := int(span / models.TimeFrameDurations[tf]); have < need {
+				t.Fatalf("%s analyzed at %s with %d %s candles, warm-up needs %d", state.Symbol, state.Price.OpenTime, have, tf, need)
+			}
+		}
+	})
+
+	results, err := b.RunBacktest(start, end, []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	if analyzed == 0 || len(results.Trades) == 0 {
+		t.Fatalf("analyzed %d candles and made %d trades, the test needs both", analyzed, len(results.Trades))
+	}
+	for _, trade := range results.Trades {
+		if trade.EntryTime.Before(start) {
+			t.Errorf("%s %s entered at %s, before the warm-up ended at %s", trade.Symbol, trade.Side, trade.EntryTime, start)
+		}
+	}
+}
+
+func TestShortWarmUpFailsInsteadOfTrading(t *testing.T) {
+	b := NewBacktestWithConfig(streamOnly{fixtureSource()}, testStrategies(t), DefaultConfig())
+	start := warmUpStartTime(b).Add(-time.Hour)
+
+	results, err := b.RunBacktest(start, start.Add(24*time.Hour), []string{"BTCUSDT"})
+	if err == nil {
+		t.Fatalf("RunBacktest() made %d trades on a short warm-up, want an error", len(results.Trades))
+	}
+	for _, want := range []string{"not enough history", "BTCUSDT", "needs"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestShortHistorySkipsSymbol(t *testing.T) {
+	b := NewBacktestWithConfig(fixtureSource(), testStrategies(t), DefaultConfig())
+	start := warmUpStartTime(b).Add(-time.Hour)
+
+	results, err := b.RunBacktest(start, start.Add(24*time.Hour), []string{"BTCUSDT"})
+	if err != nil {
+		t.Fatalf("RunBacktest() error = %v", err)
+	}
+	if len(results.Skipped) != 1 || results.Skipped[0].Symbol != "BTCUSDT" {
+		t.Fatalf("Skipped = %+v, want BTCUSDT", results.Skipped)
+	}
+	if len(results.Trades) != 0 {
+		t.Errorf("a skipped symbol made %d trades", len(results.Trades))
+	}
+}
+
+func TestCheckWarmUpListsEveryShortTimeFrame(t *testing.T) {
+	b := newTestBacktest(t, DefaultConfig())
+	b.warmUp = map[string]int{models.PriceTimeFrame5m: 50, models.PriceTimeFrame15m: 30, models.PriceTimeFrame1h: 30}
+	b.window = 30 * 12
+
+	err := b.checkWarmUp("BTCUSDT", testStart, 120)
+	if err == nil {
+		t.Fatal("checkWarmUp() passed 10 hours of history for 30 hourly candles")
+	}
+	// 10 hours are 120 5m and 40 15m candles, enough for both
+	want := "not enough history before the backtest start for BTCUSDT (data from 2024-03-01 00:00): 1h needs 30 candles, has 10"
+	if err.Error() != want {
+		t.Errorf("checkWarmUp() = %q, want %q", err, want)
+	}
+	if err := b.checkWarmUp("BTCUSDT", testStart, b.window-1); err != nil {
+		t.Errorf("checkWarmUp() at the full window = %v", err)
+	}
+}
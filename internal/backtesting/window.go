@@ -0,0 +1,43 @@
+package backtesting
+
+import "CryptoTradeBot/internal/models"
+
+// candleWindow holds the trailing size candles of a stream
+//
+// Candles are appended to a buffer twice the window size; when it fills, the
+// last size-1 candles are moved to the front. Candles stays one contiguous
+// slice the analysis can read directly, at an amortized copy per candle, and
+// memory is bounded by the window rather than the length of the backtest.
+type candleWindow struct {
+	size   int
+	buffer []models.Price
+}
+
+func newCandleWindow(size int) *candleWindow {
+	if size < 1 {
+		size = 1
+	}
+	return &candleWindow{
+		size:   size,
+		buffer: make([]models.Price, 0, 2*size),
+	}
+}
+
+// Push appends the next candle, dropping the oldest once the window is full
+func (w *candleWindow) Push(price models.Price) {
+	if len(w.buffer) == cap(w.buffer) {
+		keep := w.size - 1
+		copy(w.buffer, w.buffer[len(w.buffer)-keep:])
+		w.buffer = w.buffer[:keep]
+	}
+	w.buffer = append(w.buffer, price)
+}
+
+// Candles returns up to the last size candles, oldest first
+// The slice is only valid until the next Push
+func (w *candleWindow) Candles() []models.Price {
+	if len(w.buffer) > w.size {
+		return w.buffer[len(w.buffer)-w.size:]
+	}
+	return w.buffer
+}
@@ -0,0 +1,105 @@
+package backtesting
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCandleWindowKeepsTrailingCandles(t *testing.T) {
+	const size = 4
+	for _, pushed := range []int{0, 1, size, size + 1, 2 * size, 2*size + 1, 25} {
+		w := newCandleWindow(size)
+		for i := 0; i < pushed; i++ {
+			w.Push(candle("BTCUSDT", i, 100, 100, 100, float64(i)))
+		}
+
+		got := w.Candles()
+		want := min(pushed, size)
+		if len(got) != want {
+			t.Fatalf("after %d pushes: %d candles, want %d", pushed, len(got), want)
+		}
+		for i, price := range got {
+			if wantClose := float64(pushed - want + i); price.Close != wantClose {
+				t.Errorf("after %d pushes: candle %d closes at %v, want %v", pushed, i, price.Close, wantClose)
+			}
+		}
+		if cap(w.buffer) != 2*size {
+			t.Errorf("after %d pushes: buffer grew to %d, want it bounded at %d", pushed, cap(w.buffer), 2*size)
+		}
+	}
+}
+
+// heapSampler records the largest live heap a run held above where it started
+// Each sample collects garbage first, so it measures what the run keeps rather than what it churns
+type heapSampler struct {
+	base, peak uint64
+}
+
+func newHeapSampler() *heapSampler {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return &heapSampler{base: stats.HeapAlloc}
+}
+
+func (s *heapSampler) sample() {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > s.base {
+		s.peak = max(s.peak, stats.HeapAlloc-s.base)
+	}
+}
+
+func (s *heapSampler) report(b *testing.B) {
+	b.ReportMetric(float64(s.peak)/(1<<20), "peak-heap-MB")
+}
+
+// BenchmarkBacktest90Days replays 90 days of BTCUSDT, reporting wall time and the heap the run held
+// on top of the source. "streamed" is the engine as it runs; "full-slices" first loads the period's
+// base candles into one slice the way the engine did before streaming, and holds it through the run
+func BenchmarkBacktest90Days(b *testing.B) {
+	const days = 90
+	source := NewSliceSource(testdb.FixturePrices("BTCUSDT", days))
+	start := testdb.FixtureStart.Add(3 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(days*24*time.Hour - time.Minute)
+
+	for _, full := range []bool{false, true} {
+		name := "streamed"
+		if full {
+			name = "full-slices"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				heap := newHeapSampler()
+				var loaded []models.Price
+				if full {
+					series, err := source.GetPricesByTimeFrame(context.Background(), "BTCUSDT", BaseTimeFrame, testdb.FixtureStart, end)
+					if err != nil {
+						b.Fatal(err)
+					}
+					// Copied, as rows scanned from the database would be
+					loaded = append(loaded, series...)
+				}
+
+				backtest := NewBacktestWithConfig(source, testStrategies(b), DefaultConfig())
+				candles := 0
+				backtest.OnPhase(PhaseEntries, func(*CandleState) {
+					if candles++; candles%2000 == 0 {
+						heap.sample()
+					}
+				})
+				if _, err := backtest.RunBacktest(start, end, []string{"BTCUSDT"}); err != nil {
+					b.Fatal(err)
+				}
+				heap.sample()
+				runtime.KeepAlive(loaded)
+				heap.report(b)
+			}
+		})
+	}
+}
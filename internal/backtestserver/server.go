@@ -0,0 +1,457 @@
+// Package backtestserver runs backtests on request over HTTP
+//
+// Runs are queued in memory and execute at most Concurrency at a time; their results and
+// exported artifacts live until the process exits. Every endpoint requires the bearer token.
+package backtestserver
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/pkg/backtest"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultConcurrency is how many backtests run at once, the rest wait in the queue
+const DefaultConcurrency = 2
+
+// maxRequestSize bounds a run request body in bytes
+const maxRequestSize = 1 << 20
+
+// Run statuses
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusDone      = "done"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// artifactFormats are the formats each finished run is exported to
+var artifactFormats = []string{"json", "csv", "html"}
+
+// Config sets up a Server
+type Config struct {
+	Token        string          // Bearer token every request must carry
+	Concurrency  int             // Runs executed at once
+	ArtifactsDir string          // Directory the exported results are written to
+	Symbols      []string        // Symbols run when a request names none
+	Days         int             // Days run when a request gives no from
+	Base         backtest.Config // Engine settings requests start from
+}
+
+// Request is the body of POST /backtests; everything is optional
+type Request struct {
+	Symbols  []string        `json:"symbols"`
+	From     string          `json:"from"`               // YYYY-MM-DD in UTC or RFC3339, Days before To by default
+	To       string          `json:"to"`                 // Exclusive, now by default
+	Strategy json.RawMessage `json:"strategy,omitempty"` // Strategy parameters in the STRATEGY_CONFIG file layout
+
+	Direction       string   `json:"direction,omitempty"`
+	Seed            *int64   `json:"seed,omitempty"`
+	ExecutionTiming string   `json:"execution_timing,omitempty"`
+	SameBar         string   `json:"same_bar,omitempty"`
+	GapTolerance    *float64 `json:"gap_tolerance,omitempty"`
+	ExitSlippage    *float64 `json:"exit_slippage,omitempty"`
+}
+
+// Run is one requested backtest
+type Run struct {
+	ID        string            `json:"id"`
+	Status    string            `json:"status"`
+	Symbols   []string          `json:"symbols"`
+	From      time.Time         `json:"from"`
+	To        time.Time         `json:"to"`
+	Progress  backtest.Progress `json:"progress"`
+	Error     string            `json:"error,omitempty"`
+	Created   time.Time         `json:"created"`
+	Started   *time.Time        `json:"started,omitempty"`
+	Finished  *time.Time        `json:"finished,omitempty"`
+	Artifacts map[string]string `json:"artifacts,omitempty"` // Download links by format
+	Results   *backtest.Results `json:"results,omitempty"`   // Only on GET /backtests/{id}
+
+	cancel     context.CancelFunc
+	strategies *strategy.StrategyManager
+	config     backtest.Config
+}
+
+// Server queues, runs and serves backtests
+type Server struct {
+	prices     backtest.PriceSource
+	strategies *strategy.StrategyManager // Used when a request carries no strategy parameters
+	config     Config
+	slots      chan struct{} // One token per run allowed to execute
+
+	mu   sync.Mutex
+	runs map[string]*Run
+	wg   sync.WaitGroup
+}
+
+// NewServer creates a new instance of Server
+func NewServer(prices backtest.PriceSource, strategies *strategy.StrategyManager, config Config) (*Server, error) {
+	if config.Token == "" {
+		return nil, fmt.Errorf("a bearer token is required")
+	}
+	if config.Concurrency < 1 {
+		return nil, fmt.Errorf("concurrency must be at least 1")
+	}
+	if err := os.MkdirAll(config.ArtifactsDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %v", err)
+	}
+	return &Server{
+		prices:     prices,
+		strategies: strategies,
+		config:     config,
+		slots:      make(chan struct{}, config.Concurrency),
+		runs:       make(map[string]*Run),
+	}, nil
+}
+
+// Handler returns the backtest endpoints, all behind the bearer token
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /backtests", s.handleCreate)
+	mux.HandleFunc("GET /backtests", s.handleList)
+	mux.HandleFunc("GET /backtests/{id}", s.handleGet)
+	mux.HandleFunc("DELETE /backtests/{id}", s.handleCancel)
+	mux.HandleFunc("GET /backtests/{id}/artifacts/{format}", s.handleArtifact)
+	return s.authorized(mux)
+}
+
+// Serve exposes the backtest endpoints at addr until ctx is cancelled, then cancels the runs left
+func (s *Server) Serve(ctx context.Context, addr string) {
+	server := &http.Server{Addr: addr, Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving backtests on %s", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Backtest server error: %v", err)
+	}
+
+	s.mu.Lock()
+	for _, run := range s.runs {
+		run.cancel()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// authorized rejects requests without the bearer token
+func (s *Server) authorized(next http.Handler) http.Handler {
+	want := []byte("Bearer " + s.config.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handleCreate validates a run request and queues it
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	run, err := s.newRun(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run.cancel = cancel
+	s.mu.Lock()
+	s.runs[run.ID] = run
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.execute(ctx, run)
+
+	w.Header().Set("Location", "/backtests/"+run.ID)
+	writeStatus(w, http.StatusAccepted, s.snapshot(run, false))
+}
+
+// newRun builds a queued run from req on top of the server's defaults
+func (s *Server) newRun(req Request) (*Run, error) {
+	symbols := req.Symbols
+	if len(symbols) == 0 {
+		symbols = s.config.Symbols
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols to backtest")
+	}
+	start, end, err := backtest.ParsePeriod(req.From, req.To, s.config.Days)
+	if err != nil {
+		return nil, err
+	}
+
+	strategies := s.strategies
+	if len(req.Strategy) > 0 {
+		if strategies, err = strategy.ParseStrategyManager(req.Strategy, symbols); err != nil {
+			return nil, err
+		}
+	}
+
+	config := s.config.Base
+	if req.Direction != "" {
+		if req.Direction != strategy.DirectionBoth && req.Direction != models.PositionSideLong && req.Direction != models.PositionSideShort {
+			return nil, fmt.Errorf("invalid direction %q, want long, short or both", req.Direction)
+		}
+		config.Direction = req.Direction
+	}
+	if req.Seed != nil {
+		config.Seed = *req.Seed
+	}
+	if req.ExecutionTiming != "" {
+		if config.Timing, err = backtest.ParseExecutionTiming(req.ExecutionTiming); err != nil {
+			return nil, err
+		}
+	}
+	if req.SameBar != "" {
+		if config.SameBar, err = backtest.ParseSameBarPolicy(req.SameBar); err != nil {
+			return nil, err
+		}
+	}
+	if req.GapTolerance != nil {
+		config.GapTolerance = *req.GapTolerance
+	}
+	if req.ExitSlippage != nil {
+		config.ExitSlippage = *req.ExitSlippage
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	return &Run{
+		ID:         id,
+		Status:     StatusQueued,
+		Symbols:    symbols,
+		From:       start,
+		To:         end,
+		Progress:   backtest.Progress{Symbols: len(symbols)},
+		Created:    time.Now(),
+		strategies: strategies,
+		config:     config,
+	}, nil
+}
+
+// execute waits for a free slot, runs the backtest and exports its results
+func (s *Server) execute(ctx context.Context, run *Run) {
+	defer s.wg.Done()
+	defer run.cancel()
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		s.finish(run, nil, ctx.Err())
+		return
+	}
+
+	s.update(run, func() {
+		now := time.Now()
+		run.Status = StatusRunning
+		run.Started = &now
+	})
+
+	results, err := s.backtest(ctx, run)
+	s.finish(run, results, err)
+}
+
+// backtest checks the stored candles cover the run and replays it
+func (s *Server) backtest(ctx context.Context, run *Run) (results *backtest.Results, err error) {
+	// A panicking strategy fails its run, not the server
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("backtest panicked: %v", r)
+		}
+	}()
+
+	warmUp := time.Duration(run.strategies.WindowCandles(backtest.BaseTimeFrame)) * models.TimeFrameDurations[backtest.BaseTimeFrame]
+	if err := s.checkCoverage(run.Symbols, run.From.Add(-warmUp), run.To); err != nil {
+		return nil, err
+	}
+
+	engine := backtest.NewEngine(s.prices, run.strategies, run.config)
+	engine.OnProgress(func(progress backtest.Progress) {
+		s.update(run, func() { run.Progress = progress })
+	})
+	return engine.RunBacktestContext(ctx, run.From, run.To, run.Symbols)
+}
+
+// checkCoverage fails naming the first missing stretch of candles between start and end
+func (s *Server) checkCoverage(symbols []string, start, end time.Time) error {
+	for _, symbol := range symbols {
+		gaps, err := priceOperations.MissingRanges(s.prices, symbol, backtest.BaseTimeFrame, start, end)
+		if err != nil {
+			return err
+		}
+		if len(gaps) > 0 {
+			return fmt.Errorf("stored candles do not cover the run and its warm-up, %s %s is missing %s to %s (%d gaps), download them first",
+				symbol, backtest.BaseTimeFrame, gaps[0].From.UTC().Format(time.RFC3339), gaps[0].To.UTC().Format(time.RFC3339), len(gaps))
+		}
+	}
+	return nil
+}
+
+// finish records how run ended, exporting its results when it succeeded
+func (s *Server) finish(run *Run, results *backtest.Results, err error) {
+	artifacts := make(map[string]string, len(artifactFormats))
+	if err == nil {
+		for _, format := range artifactFormats {
+			if exportErr := results.Export(s.artifactPath(run.ID, format)); exportErr != nil {
+				err = exportErr
+				break
+			}
+			artifacts[format] = fmt.Sprintf("/backtests/%s/artifacts/%s", run.ID, format)
+		}
+	}
+
+	s.update(run, func() {
+		now := time.Now()
+		run.Finished = &now
+		switch {
+		case errors.Is(err, context.Canceled):
+			run.Status = StatusCancelled
+		case err != nil:
+			run.Status = StatusFailed
+			run.Error = err.Error()
+		default:
+			run.Status = StatusDone
+			run.Results = results
+			run.Artifacts = artifacts
+		}
+	})
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("Backtest %s failed: %v", run.ID, err)
+	}
+}
+
+func (s *Server) artifactPath(id, format string) string {
+	return filepath.Join(s.config.ArtifactsDir, id+"."+format)
+}
+
+// update changes run under the server's lock
+func (s *Server) update(run *Run, change func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change()
+}
+
+// snapshot copies run under the lock, with its results only when asked
+func (s *Server) snapshot(run *Run, withResults bool) Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *run
+	if !withResults {
+		copied.Results = nil
+	}
+	return copied
+}
+
+// lookup returns the run named by the request's {id}
+func (s *Server) lookup(r *http.Request) (*Run, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[r.PathValue("id")]
+	return run, ok
+}
+
+// handleList serves every run, newest first, without their results
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	runs := make([]*Run, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Created.After(runs[j].Created) })
+	list := make([]Run, len(runs))
+	for i, run := range runs {
+		list[i] = s.snapshot(run, false)
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.lookup(r)
+	if !ok {
+		http.Error(w, "backtest not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, s.snapshot(run, true))
+}
+
+// handleCancel cancels a queued or running run, finished runs are left as they are
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.lookup(r)
+	if !ok {
+		http.Error(w, "backtest not found", http.StatusNotFound)
+		return
+	}
+	run.cancel()
+	writeStatus(w, http.StatusAccepted, s.snapshot(run, false))
+}
+
+// handleArtifact serves a finished run's exported results
+func (s *Server) handleArtifact(w http.ResponseWriter, r *http.Request) {
+	run, ok := s.lookup(r)
+	if !ok {
+		http.Error(w, "backtest not found", http.StatusNotFound)
+		return
+	}
+	format := r.PathValue("format")
+	if _, ok := s.snapshot(run, false).Artifacts[format]; !ok {
+		http.Error(w, "artifact not found", http.StatusNotFound)
+		return
+	}
+	http.ServeFile(w, r, s.artifactPath(run.ID, format))
+}
+
+// newID returns a random run identifier
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate id: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	writeStatus(w, http.StatusOK, v)
+}
+
+func writeStatus(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
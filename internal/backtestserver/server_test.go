@@ -0,0 +1,207 @@
+package backtestserver
+
+import (
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/pkg/backtest"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "secret"
+
+var (
+	testFrom = time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	testTo   = testFrom.Add(24 * time.Hour)
+)
+
+// fixtureSource holds symbol's 5m candles oscillating around 100 from the warm-up before testFrom to testTo
+func fixtureSource(t *testing.T, strategies *strategy.StrategyManager, symbol string) *backtest.SliceSource {
+	t.Helper()
+	interval := 5 * time.Minute
+	start := testFrom.Add(-time.Duration(strategies.WindowCandles(backtest.BaseTimeFrame)) * interval)
+	var prices []backtest.Price
+	for at, i := start, 0; at.Before(testTo); at, i = at.Add(interval), i+1 {
+		open := 100 + 5*math.Sin(float64(i)/12)
+		close := 100 + 5*math.Sin(float64(i+1)/12)
+		prices = append(prices, backtest.Price{
+			Symbol:    symbol,
+			TimeFrame: backtest.BaseTimeFrame,
+			OpenTime:  at,
+			CloseTime: at.Add(interval - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 0.2,
+			Low:       min(open, close) - 0.2,
+			Close:     close,
+			Volume:    100,
+		})
+	}
+	return backtest.NewSliceSource(prices)
+}
+
+// newTestServer returns a server running at most concurrency backtests over the fixture
+func newTestServer(t *testing.T, concurrency int) (*Server, *httptest.Server) {
+	t.Helper()
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewServer(fixtureSource(t, strategies, "BTCUSDT"), strategies, Config{
+		Token:        testToken,
+		Concurrency:  concurrency,
+		ArtifactsDir: t.TempDir(),
+		Symbols:      []string{"BTCUSDT"},
+		Days:         1,
+		Base:         backtest.DefaultConfig(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// call sends a request with the bearer token and decodes the response into out, returning the status
+func call(t *testing.T, ts *httptest.Server, method, path, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// waitFor polls the run until it leaves the queue and finishes
+func waitFor(t *testing.T, ts *httptest.Server, id string) Run {
+	t.Helper()
+	deadline := time.Now().Add(30 * time.Second)
+	for {
+		var run Run
+		if status := call(t, ts, http.MethodGet, "/backtests/"+id, "", &run); status != http.StatusOK {
+			t.Fatalf("GET /backtests/%s = %d", id, status)
+		}
+		if run.Finished != nil {
+			return run
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("run %s still %s", id, run.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestBacktestRunsFromEnqueueToResults(t *testing.T) {
+	_, ts := newTestServer(t, 1)
+
+	var queued Run
+	body := `{"from": "2024-03-02", "to": "2024-03-03", "seed": 7}`
+	if status := call(t, ts, http.MethodPost, "/backtests", body, &queued); status != http.StatusAccepted {
+		t.Fatalf("POST /backtests = %d, want %d", status, http.StatusAccepted)
+	}
+	if queued.ID == "" || queued.Progress.Symbols != 1 || !queued.From.Equal(testFrom) || !queued.To.Equal(testTo) {
+		t.Fatalf("queued run = %+v, want BTCUSDT over the requested day", queued)
+	}
+
+	run := waitFor(t, ts, queued.ID)
+	if run.Status != StatusDone || run.Error != "" {
+		t.Fatalf("run = %s %q, want done", run.Status, run.Error)
+	}
+	if run.Progress.Done != 1 || run.Progress.Fraction != 1 || run.Started == nil {
+		t.Errorf("progress = %+v, want the one symbol finished", run.Progress)
+	}
+	if run.Results == nil {
+		t.Fatal("finished run carries no results")
+	}
+
+	var list []Run
+	if call(t, ts, http.MethodGet, "/backtests", "", &list); len(list) != 1 || list[0].ID != run.ID || list[0].Results != nil {
+		t.Errorf("GET /backtests = %+v, want the run listed without its results", list)
+	}
+	for _, format := range artifactFormats {
+		link, ok := run.Artifacts[format]
+		if !ok {
+			t.Errorf("no %s artifact in %v", format, run.Artifacts)
+			continue
+		}
+		if status := call(t, ts, http.MethodGet, link, "", nil); status != http.StatusOK {
+			t.Errorf("GET %s = %d, want %d", link, status, http.StatusOK)
+		}
+	}
+}
+
+func TestQueuedBacktestCancels(t *testing.T) {
+	s, ts := newTestServer(t, 1)
+	s.slots <- struct{}{} // Another run holds the only slot
+	defer func() { <-s.slots }()
+
+	var queued Run
+	if status := call(t, ts, http.MethodPost, "/backtests", `{}`, &queued); status != http.StatusAccepted || queued.Status != StatusQueued {
+		t.Fatalf("POST /backtests = %d %s, want accepted and queued", status, queued.Status)
+	}
+	if status := call(t, ts, http.MethodDelete, "/backtests/"+queued.ID, "", nil); status != http.StatusAccepted {
+		t.Fatalf("DELETE = %d, want %d", status, http.StatusAccepted)
+	}
+	if run := waitFor(t, ts, queued.ID); run.Status != StatusCancelled || run.Started != nil {
+		t.Errorf("run = %s, started %v, want cancelled before it ran", run.Status, run.Started)
+	}
+}
+
+func TestBacktestRequests(t *testing.T) {
+	_, ts := newTestServer(t, 1)
+	tests := []struct {
+		name  string
+		token string
+		body  string
+		want  int
+	}{
+		{"missing token", "", `{}`, http.StatusUnauthorized},
+		{"wrong token", "guess", `{}`, http.StatusUnauthorized},
+		{"unknown field", testToken, `{"symbol": "BTCUSDT"}`, http.StatusBadRequest},
+		{"bad direction", testToken, `{"direction": "up"}`, http.StatusBadRequest},
+		{"inverted period", testToken, `{"from": "2024-03-03", "to": "2024-03-02"}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/backtests", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("POST /backtests = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestBacktestFailsOnUncoveredCandles(t *testing.T) {
+	_, ts := newTestServer(t, 1)
+	var queued Run
+	call(t, ts, http.MethodPost, "/backtests", `{"from": "2024-03-03", "to": "2024-03-04"}`, &queued)
+	if run := waitFor(t, ts, queued.ID); run.Status != StatusFailed || !strings.Contains(run.Error, "BTCUSDT 5m is missing 2024-03-03T00:00:00Z") {
+		t.Errorf("run = %s %q, want failed naming the missing candles", run.Status, run.Error)
+	}
+}
@@ -0,0 +1,156 @@
+// Package books groups a trading account with what trades it into independent portfolios
+//
+// Each book has its own account, symbols, strategies, position sizing and trade caps. Books
+// running in one process share only the price feed; positions, balances, summaries, metrics
+// and status are kept per book under its account name.
+package books
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Book is one portfolio: an account, the symbols it trades and how it trades them
+type Book struct {
+	Name       string // Also the name of the book's account
+	Symbols    []string
+	Strategies *strategy.StrategyManager
+	Sizing     *trading.SizingConfig // nil for the process-wide sizing
+	Frequency  *risk.FrequencyConfig // nil for the process-wide trade caps
+}
+
+// Config is one book as written in the books file. Strategy is in the strategy config
+// layout, overrides limited to the book's symbols:
+//
+//	{
+//	  "books": [
+//	    {"name": "scalp", "symbols": ["BTCUSDT", "ETHUSDT"], "strategy": {"default": {"min_confidence": 0.7}},
+//	     "sizing": {"min_notional": 5, "max_margin_fraction": 0.25}, "max_trades_per_day": 20},
+//	    {"name": "swing", "symbols": ["SOLUSDT"], "strategy": {"default": {"cadence": "1h"}}}
+//	  ]
+//	}
+type Config struct {
+	Name     string                `json:"name"`
+	Symbols  []string              `json:"symbols"`
+	Strategy json.RawMessage       `json:"strategy"` // Empty for the built-in defaults
+	Sizing   *trading.SizingConfig `json:"sizing"`
+
+	// Trade caps, both unset for the process-wide ones
+	MaxTradesPerDay          *int `json:"max_trades_per_day"`
+	MaxTradesPerSymbolPerDay *int `json:"max_trades_per_symbol_per_day"`
+}
+
+// FileConfig is the layout of the books file
+type FileConfig struct {
+	Books []Config `json:"books"`
+}
+
+// Load builds the books of the JSON file at path
+func Load(path string) ([]Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read books config: %v", err)
+	}
+	return Parse(data)
+}
+
+// Parse builds books from JSON in the FileConfig layout, rejecting the whole file on any bad book
+func Parse(data []byte) ([]Book, error) {
+	var file FileConfig
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse books config: %v", err)
+	}
+	if len(file.Books) == 0 {
+		return nil, fmt.Errorf("no books in books config")
+	}
+
+	books := make([]Book, 0, len(file.Books))
+	seen := make(map[string]bool)
+	for _, config := range file.Books {
+		if seen[config.Name] {
+			return nil, fmt.Errorf("duplicate book %q", config.Name)
+		}
+		seen[config.Name] = true
+
+		book, err := config.build()
+		if err != nil {
+			return nil, fmt.Errorf("book %q: %v", config.Name, err)
+		}
+		books = append(books, book)
+	}
+	return books, nil
+}
+
+// build validates the book's settings and loads its strategies
+func (c Config) build() (Book, error) {
+	if c.Name == "" || strings.ContainsAny(c.Name, ",= ") {
+		return Book{}, fmt.Errorf("name must be set without commas, equals signs or spaces")
+	}
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, symbol := range c.Symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" || seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return Book{}, fmt.Errorf("no symbols")
+	}
+
+	book := Book{Name: c.Name, Symbols: symbols, Sizing: c.Sizing}
+	var err error
+	if len(c.Strategy) == 0 {
+		book.Strategies, err = strategy.NewStrategyManager(strategy.DefaultParams())
+	} else {
+		book.Strategies, err = strategy.ParseStrategyManager(c.Strategy, symbols)
+	}
+	if err != nil {
+		return Book{}, err
+	}
+
+	if c.Sizing != nil {
+		if err := c.Sizing.Validate(); err != nil {
+			return Book{}, err
+		}
+	}
+	if c.MaxTradesPerDay != nil || c.MaxTradesPerSymbolPerDay != nil {
+		frequency := risk.FrequencyConfig{}
+		if c.MaxTradesPerDay != nil {
+			frequency.MaxTradesPerDay = *c.MaxTradesPerDay
+		}
+		if c.MaxTradesPerSymbolPerDay != nil {
+			frequency.MaxTradesPerSymbolPerDay = *c.MaxTradesPerSymbolPerDay
+		}
+		if err := frequency.Validate(); err != nil {
+			return Book{}, err
+		}
+		book.Frequency = &frequency
+	}
+	return book, nil
+}
+
+// Symbols returns every symbol traded by any of books, sorted: the prices the books share
+func Symbols(books []Book) []string {
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, book := range books {
+		for _, symbol := range book.Symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
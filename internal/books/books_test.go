@@ -0,0 +1,65 @@
+package books
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	books, err := Parse([]byte(`{"books": [
+		{"name": "scalp", "symbols": ["btcusdt", "ETHUSDT", "BTCUSDT"], "max_trades_per_day": 20,
+		 "sizing": {"min_notional": 5, "max_margin_fraction": 0.25}},
+		{"name": "swing", "symbols": ["SOLUSDT"], "strategy": {"symbols": {"SOLUSDT": {"stop_loss": 0.01}}}}
+	]}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("got %d books, want 2", len(books))
+	}
+
+	scalp, swing := books[0], books[1]
+	if !reflect.DeepEqual(scalp.Symbols, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("scalp symbols = %v, want upper-cased without the duplicate", scalp.Symbols)
+	}
+	if scalp.Frequency == nil || scalp.Frequency.MaxTradesPerDay != 20 || scalp.Frequency.MaxTradesPerSymbolPerDay != 0 {
+		t.Errorf("scalp frequency = %+v, want 20 a day", scalp.Frequency)
+	}
+	if scalp.Sizing == nil || scalp.Sizing.MaxMarginFraction != 0.25 {
+		t.Errorf("scalp sizing = %+v", scalp.Sizing)
+	}
+	if swing.Sizing != nil || swing.Frequency != nil {
+		t.Errorf("swing sizing %+v and frequency %+v, want the process-wide ones", swing.Sizing, swing.Frequency)
+	}
+	if got := swing.Strategies.ParamsFor("SOLUSDT").StopLoss; got != 0.01 {
+		t.Errorf("swing SOLUSDT stop loss = %v, want 0.01", got)
+	}
+	if got := Symbols(books); !reflect.DeepEqual(got, []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"}) {
+		t.Errorf("Symbols() = %v", got)
+	}
+}
+
+func TestParseRejectsBadBooks(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"no books", `{"books": []}`, "no books"},
+		{"duplicate name", `{"books": [{"name": "a", "symbols": ["BTCUSDT"]}, {"name": "a", "symbols": ["ETHUSDT"]}]}`, `duplicate book "a"`},
+		{"unnamed", `{"books": [{"symbols": ["BTCUSDT"]}]}`, "name must be set"},
+		{"no symbols", `{"books": [{"name": "a", "symbols": [" "]}]}`, "no symbols"},
+		{"override outside the book", `{"books": [{"name": "a", "symbols": ["BTCUSDT"], "strategy": {"symbols": {"ETHUSDT": {}}}}]}`, "unknown symbol ETHUSDT"},
+		{"bad sizing", `{"books": [{"name": "a", "symbols": ["BTCUSDT"], "sizing": {"min_notional": -1}}]}`, "cannot be negative"},
+		{"negative cap", `{"books": [{"name": "a", "symbols": ["BTCUSDT"], "max_trades_per_day": -1}]}`, `book "a"`},
+		{"not json", `books:`, "failed to parse books config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.config)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for everything that stamps, waits or polls
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	Sleep(d time.Duration)
+}
+
+// Ticker delivers ticks on C until stopped, like time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTicker(d time.Duration) Ticker { return realTicker{time.NewTicker(d)} }
+
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time { return t.ticker.C }
+
+func (t realTicker) Stop() { t.ticker.Stop() }
+
+// Fake is a Clock that only moves when told to
+// Tickers fire and sleepers wake as Advance or Set passes their time
+type Fake struct {
+	mu       sync.Mutex
+	now      time.Time
+	tickers  []*fakeTicker
+	sleepers []*sleeper
+}
+
+type sleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// NewFake creates a Fake clock reading start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTicker{
+		clock:  f,
+		period: d,
+		next:   f.now.Add(d),
+		c:      make(chan time.Time, 1), // Like time.Ticker, slow readers drop ticks
+	}
+	f.tickers = append(f.tickers, t)
+	return t
+}
+
+// Sleep blocks until the clock is moved past now+d
+func (f *Fake) Sleep(d time.Duration) {
+	f.mu.Lock()
+	if d <= 0 {
+		f.mu.Unlock()
+		return
+	}
+	s := &sleeper{until: f.now.Add(d), done: make(chan struct{})}
+	f.sleepers = append(f.sleepers, s)
+	f.mu.Unlock()
+	<-s.done
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to t, firing every tick and waking every sleeper due on the way
+// Ticks are delivered in time order; moving backwards only changes Now
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for {
+		next := f.nextTicker(t)
+		if next == nil {
+			break
+		}
+		f.now = next.next
+		select {
+		case next.c <- next.next:
+		default:
+		}
+		next.next = next.next.Add(next.period)
+	}
+	f.now = t
+
+	remaining := f.sleepers[:0]
+	for _, s := range f.sleepers {
+		if s.until.After(f.now) {
+			remaining = append(remaining, s)
+			continue
+		}
+		close(s.done)
+	}
+	f.sleepers = remaining
+}
+
+// nextTicker returns the earliest ticker due at or before t, nil when none is
+func (f *Fake) nextTicker(t time.Time) *fakeTicker {
+	var next *fakeTicker
+	for _, ticker := range f.tickers {
+		if ticker.next.After(t) {
+			continue
+		}
+		if next == nil || ticker.next.Before(next.next) {
+			next = ticker
+		}
+	}
+	return next
+}
+
+// Waiters reports how many tickers and sleepers are waiting on the clock
+// Tests use it to know a goroutine has reached its wait before advancing
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.tickers) + len(f.sleepers)
+}
+
+type fakeTicker struct {
+	clock  *Fake
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	for i, ticker := range t.clock.tickers {
+		if ticker == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// received returns the ticks waiting on c without blocking
+func received(c <-chan time.Time) []time.Time {
+	var ticks []time.Time
+	for {
+		select {
+		case tick := <-c:
+			ticks = append(ticks, tick)
+		default:
+			return ticks
+		}
+	}
+}
+
+func TestFakeTickerFiresAsTimePasses(t *testing.T) {
+	clk := NewFake(start)
+	ticker := clk.NewTicker(15 * time.Second)
+
+	clk.Advance(14 * time.Second)
+	if ticks := received(ticker.C()); len(ticks) != 0 {
+		t.Fatalf("ticked %v before its period", ticks)
+	}
+	clk.Advance(time.Second)
+	if ticks := received(ticker.C()); len(ticks) != 1 || !ticks[0].Equal(start.Add(15*time.Second)) {
+		t.Fatalf("ticks = %v, want one at 15s", ticks)
+	}
+
+	// A reader that falls behind gets one tick, like time.Ticker, and the schedule keeps its phase
+	clk.Advance(time.Minute)
+	if ticks := received(ticker.C()); len(ticks) != 1 || !ticks[0].Equal(start.Add(30*time.Second)) {
+		t.Fatalf("ticks after a minute unread = %v, want the first one due", ticks)
+	}
+	clk.Advance(15 * time.Second)
+	if ticks := received(ticker.C()); len(ticks) != 1 || !ticks[0].Equal(start.Add(90*time.Second)) {
+		t.Errorf("next tick = %v, want 90s", ticks)
+	}
+	if !clk.Now().Equal(start.Add(90 * time.Second)) {
+		t.Errorf("Now() = %s after advancing 90s", clk.Now())
+	}
+
+	ticker.Stop()
+	clk.Advance(time.Hour)
+	if ticks := received(ticker.C()); len(ticks) != 0 || clk.Waiters() != 0 {
+		t.Errorf("a stopped ticker got %v, %d waiters left", ticks, clk.Waiters())
+	}
+}
+
+func TestFakeTickersKeepTheirOwnSchedules(t *testing.T) {
+	clk := NewFake(start)
+	fast, slow := clk.NewTicker(10*time.Second), clk.NewTicker(25*time.Second)
+
+	tests := []struct {
+		advance    time.Duration
+		fast, slow []time.Duration
+	}{
+		{10 * time.Second, []time.Duration{10 * time.Second}, nil},
+		{10 * time.Second, []time.Duration{20 * time.Second}, nil},
+		{5 * time.Second, nil, []time.Duration{25 * time.Second}},
+		{5 * time.Second, []time.Duration{30 * time.Second}, nil},
+		{20 * time.Second, []time.Duration{40 * time.Second}, []time.Duration{50 * time.Second}},
+	}
+	for i, tt := range tests {
+		clk.Advance(tt.advance)
+		for name, c := range map[string]struct {
+			ticker Ticker
+			want   []time.Duration
+		}{"fast": {fast, tt.fast}, "slow": {slow, tt.slow}} {
+			ticks := received(c.ticker.C())
+			if len(ticks) != len(c.want) {
+				t.Fatalf("step %d: %s ticks = %v, want %v", i, name, ticks, c.want)
+			}
+			for j := range ticks {
+				if !ticks[j].Equal(start.Add(c.want[j])) {
+					t.Errorf("step %d: %s ticked at %s, want %s", i, name, ticks[j].Sub(start), c.want[j])
+				}
+			}
+		}
+	}
+}
+
+func TestFakeSleepWakesWhenAdvancedPast(t *testing.T) {
+	clk := NewFake(start)
+	woke := make(chan time.Time)
+	go func() {
+		clk.Sleep(time.Minute)
+		woke <- clk.Now()
+	}()
+	for clk.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	clk.Advance(59 * time.Second)
+	select {
+	case <-woke:
+		t.Fatal("Sleep() returned before its duration passed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+	select {
+	case at := <-woke:
+		if !at.Equal(start.Add(time.Minute)) {
+			t.Errorf("woke at %s, want 1m", at)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Sleep() did not return once its duration passed")
+	}
+	if clk.Waiters() != 0 {
+		t.Errorf("Waiters() = %d after the sleeper woke", clk.Waiters())
+	}
+
+	clk.Sleep(0) // Returns at once
+}
+
+func TestFakeSetBackwardsOnlyMovesNow(t *testing.T) {
+	clk := NewFake(start)
+	ticker := clk.NewTicker(time.Second)
+	clk.Set(start.Add(-time.Hour))
+	if ticks := received(ticker.C()); len(ticks) != 0 || !clk.Now().Equal(start.Add(-time.Hour)) {
+		t.Errorf("moving back: ticks %v, now %s", ticks, clk.Now())
+	}
+}
@@ -0,0 +1,112 @@
+package dashboard
+
+import (
+	"CryptoTradeBot/internal/operations/handlers"
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"sort"
+)
+
+// Controller is the manual control of one running account
+type Controller interface {
+	Pause(reason string) error
+	Resume() error
+	FlattenAndPause(ctx context.Context, reason string) (*handlers.FlattenReport, error)
+	PauseState() handlers.PauseState
+}
+
+// ControlStatus is an account's pause state as served by /api/control
+type ControlStatus struct {
+	Account string `json:"account"`
+	handlers.PauseState
+}
+
+// FlattenResult is the outcome of POST /control/flatten
+type FlattenResult struct {
+	Closed      int             `json:"closed"`
+	Remaining   map[uint]string `json:"remaining,omitempty"` // Positions still open, keyed by ID
+	RealizedPnL float64         `json:"realized_pnl"`
+}
+
+// EnableControl accepts pause, resume and flatten requests for controls, keyed by account,
+// from requests bearing token; the pause states are served on /api/control either way
+func (s *Server) EnableControl(token string, controls map[string]Controller) {
+	s.controlToken = token
+	s.controls = controls
+}
+
+// control guards a control endpoint with its bearer token and resolves the ?account= it acts on
+func (s *Server) control(next func(http.ResponseWriter, *http.Request, Controller)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.controlToken == "" {
+			http.Error(w, "control is disabled", http.StatusForbidden)
+			return
+		}
+		want := "Bearer " + s.controlToken
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		controller, ok := s.controls[account(r)]
+		if !ok {
+			http.Error(w, "unknown account", http.StatusNotFound)
+			return
+		}
+		next(w, r, controller)
+	})
+}
+
+// reason returns the ?reason= of a control request, "manual" when unset
+func reason(r *http.Request) string {
+	if reason := r.URL.Query().Get("reason"); reason != "" {
+		return reason
+	}
+	return "manual"
+}
+
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request, controller Controller) {
+	if err := controller.Pause(reason(r)); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, ControlStatus{Account: account(r), PauseState: controller.PauseState()})
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request, controller Controller) {
+	if err := controller.Resume(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, ControlStatus{Account: account(r), PauseState: controller.PauseState()})
+}
+
+// handleFlatten pauses the account and closes every open position at the latest price
+func (s *Server) handleFlatten(w http.ResponseWriter, r *http.Request, controller Controller) {
+	// A client hanging up must not stop the flatten halfway, only the query timeout bounds it
+	report, err := controller.FlattenAndPause(context.WithoutCancel(r.Context()), reason(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	result := FlattenResult{Closed: len(report.Closed), RealizedPnL: report.RealizedPnL}
+	if len(report.Remaining) > 0 {
+		result.Remaining = make(map[uint]string, len(report.Remaining))
+		for id, err := range report.Remaining {
+			result.Remaining[id] = err.Error()
+		}
+	}
+	writeJSON(w, result)
+}
+
+// handleControl serves every controlled account's pause state
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	statuses := make([]ControlStatus, 0, len(s.controls))
+	for name, controller := range s.controls {
+		statuses = append(statuses, ControlStatus{Account: name, PauseState: controller.PauseState()})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Account < statuses[j].Account })
+	writeJSON(w, statuses)
+}
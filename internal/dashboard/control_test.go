@@ -0,0 +1,121 @@
+package dashboard
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/handlers"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeController records the controls asked of it
+type fakeController struct {
+	state     handlers.PauseState
+	flattened int
+	ctxErr    error // Error of the context the flatten ran under once it returned
+}
+
+func (c *fakeController) Pause(reason string) error {
+	if !c.state.Paused {
+		c.state = handlers.PauseState{Paused: true, Since: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Reason: reason}
+	}
+	return nil
+}
+
+func (c *fakeController) Resume() error {
+	c.state = handlers.PauseState{}
+	return nil
+}
+
+func (c *fakeController) FlattenAndPause(ctx context.Context, reason string) (*handlers.FlattenReport, error) {
+	c.flattened++
+	c.Pause(reason)
+	c.ctxErr = ctx.Err()
+	return &handlers.FlattenReport{
+		Closed:      []models.Position{{ID: 1}, {ID: 2}},
+		Remaining:   map[uint]error{3: errors.New("injected close failure")},
+		RealizedPnL: -4.5,
+	}, nil
+}
+
+func (c *fakeController) PauseState() handlers.PauseState {
+	return c.state
+}
+
+func TestControlEndpoints(t *testing.T) {
+	controller := &fakeController{}
+	s := NewServer(nil, nil, nil, nil, nil, func() []string { return nil })
+	s.EnableControl("secret", map[string]Controller{models.DefaultAccount: controller})
+	handler := s.Handler()
+
+	request := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, tt := range []struct {
+		name, target, token string
+		status              int
+	}{
+		{"no token", "/control/pause", "", http.StatusUnauthorized},
+		{"wrong token", "/control/pause", "guess", http.StatusUnauthorized},
+		{"unknown account", "/control/pause?account=other", "secret", http.StatusNotFound},
+	} {
+		if rec := request(http.MethodPost, tt.target, tt.token); rec.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.status)
+		}
+	}
+	// Controls only answer POST, a GET falls through to the read-only routes
+	request(http.MethodGet, "/control/pause", "secret")
+	if controller.state.Paused {
+		t.Fatal("a refused request paused the account")
+	}
+
+	var status ControlStatus
+	rec := request(http.MethodPost, "/control/pause?reason=news", "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil || !status.Paused || status.Reason != "news" {
+		t.Errorf("pause = %d %s, want paused for news", rec.Code, rec.Body)
+	}
+
+	var statuses []ControlStatus
+	rec = request(http.MethodGet, "/api/control", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil || len(statuses) != 1 || !statuses[0].Paused {
+		t.Errorf("/api/control = %s, want the account paused", rec.Body)
+	}
+
+	rec = request(http.MethodPost, "/control/resume", "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil || status.Paused {
+		t.Errorf("resume = %d %s, want running", rec.Code, rec.Body)
+	}
+
+	var result FlattenResult
+	rec = request(http.MethodPost, "/control/flatten", "secret")
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Closed != 2 || result.RealizedPnL != -4.5 ||
+		result.Remaining[3] != "injected close failure" {
+		t.Errorf("flatten = %d %s, want 2 closed and position 3 remaining", rec.Code, rec.Body)
+	}
+	if controller.flattened != 1 || !controller.state.Paused || controller.state.Reason != "manual" || controller.ctxErr != nil {
+		t.Errorf("flattened %d times, state %+v, context %v, want once and paused manually", controller.flattened, controller.state, controller.ctxErr)
+	}
+}
+
+func TestControlDisabledWithoutToken(t *testing.T) {
+	controller := &fakeController{}
+	s := NewServer(nil, nil, nil, nil, nil, func() []string { return nil })
+	s.EnableControl("", map[string]Controller{models.DefaultAccount: controller})
+
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/control/flatten", nil))
+	if rec.Code != http.StatusForbidden || controller.flattened != 0 {
+		t.Errorf("flatten without a token = %d, flattened %d times, want forbidden", rec.Code, controller.flattened)
+	}
+}
@@ -0,0 +1,530 @@
+package dashboard
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/handlers"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultCandles = 300
+	maxCandles     = 2000
+
+	defaultHistory = 7 * 24 * time.Hour // Trades and balance shown when no range is given
+)
+
+//go:embed static
+var static embed.FS
+
+// SignalSource reports the latest analysis outcome and the analysis health per symbol
+// of one running account, and whether that account is a shadow
+type SignalSource interface {
+	LatestSignals() []handlers.SignalStatus
+	SymbolHealth() []handlers.SymbolHealth
+	Shadow() bool
+}
+
+// Server serves a read-only dashboard of the bot's state
+type Server struct {
+	priceRepo       *repositories.PriceRepository
+	positionRepo    *repositories.PositionRepository
+	accounts        *trading.AccountService
+	transactionRepo *repositories.TransactionRepository
+	signals         map[string]SignalSource // Keyed by account
+	symbols         func() []string         // Symbols currently traded
+	journalToken    string                  // Bearer token of the journal endpoints, empty leaves them disabled
+
+	// Served on /api/exchange, nil reports a healthy exchange
+	exchange *priceOperations.ExchangeHealth
+
+	// Manual controls by account, see EnableControl
+	controlToken string
+	controls     map[string]Controller
+}
+
+// NewServer creates a new instance of Server
+// Data endpoints take ?account= and default to the default account
+func NewServer(
+	priceRepo *repositories.PriceRepository,
+	positionRepo *repositories.PositionRepository,
+	accounts *trading.AccountService,
+	transactionRepo *repositories.TransactionRepository,
+	signals map[string]SignalSource,
+	symbols func() []string,
+) *Server {
+	return &Server{
+		priceRepo:       priceRepo,
+		positionRepo:    positionRepo,
+		accounts:        accounts,
+		transactionRepo: transactionRepo,
+		signals:         signals,
+		symbols:         symbols,
+	}
+}
+
+// EnableJournal accepts tag and notes updates on closed positions from requests bearing token
+func (s *Server) EnableJournal(token string) {
+	s.journalToken = token
+}
+
+// ShowExchangeHealth serves health's state on /api/exchange
+func (s *Server) ShowExchangeHealth(health *priceOperations.ExchangeHealth) {
+	s.exchange = health
+}
+
+// Handler returns the routes of the dashboard page and its data endpoints
+// Everything is read-only except the journal and control endpoints, which EnableJournal
+// and EnableControl turn on
+func (s *Server) Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	mux.HandleFunc("/api/symbols", s.handleSymbols)
+	mux.HandleFunc("/api/accounts", s.handleAccounts)
+	mux.HandleFunc("/api/candles", s.handleCandles)
+	mux.HandleFunc("/api/trades", s.handleTrades)
+	mux.HandleFunc("/api/trades/context", s.handleTradeContexts)
+	mux.HandleFunc("/api/positions", s.handlePositions)
+	mux.HandleFunc("/api/balance", s.handleBalance)
+	mux.HandleFunc("/api/signals", s.handleSignals)
+	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/exchange", s.handleExchange)
+	mux.HandleFunc("/api/equity", s.handleEquity)
+	mux.HandleFunc("/api/control", s.handleControl)
+
+	root := http.NewServeMux()
+	root.Handle("/", readOnly(mux))
+	root.Handle("PATCH /api/positions/{id}/tags", s.journal(s.handleTags))
+	root.Handle("PATCH /api/positions/{id}/notes", s.journal(s.handleNotes))
+	root.Handle("POST /control/pause", s.control(s.handlePause))
+	root.Handle("POST /control/resume", s.control(s.handleResume))
+	root.Handle("POST /control/flatten", s.control(s.handleFlatten))
+	return root
+}
+
+// Serve exposes the dashboard at addr until ctx is cancelled
+func (s *Server) Serve(ctx context.Context, addr string) {
+	server := &http.Server{Addr: addr, Handler: s.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving dashboard on %s", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Dashboard server error: %v", err)
+	}
+}
+
+// readOnly rejects every method that could change state
+func readOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "dashboard is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleSymbols(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.symbols())
+}
+
+// handleAccounts serves every account holding a balance
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := s.accounts.Accounts()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, accounts)
+}
+
+// account returns the ?account= of the request, the default account when unset
+func account(r *http.Request) string {
+	if account := r.URL.Query().Get("account"); account != "" {
+		return account
+	}
+	return models.DefaultAccount
+}
+
+// Candle is one OHLCV bar, times in unix seconds
+type Candle struct {
+	Time   int64   `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume float64 `json:"volume"`
+}
+
+// handleCandles serves ?symbol=&timeframe=&limit= for the latest candles,
+// or ?from=&to= (RFC3339) for a range
+func (s *Server) handleCandles(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	symbol := q.Get("symbol")
+	timeFrame := q.Get("timeframe")
+	if timeFrame == "" {
+		timeFrame = models.PriceTimeFrame5m
+	}
+	if _, ok := models.TimeFrameDurations[timeFrame]; !ok {
+		http.Error(w, "unknown timeframe", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultCandles
+	if raw := q.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxCandles)
+	}
+
+	var prices []models.Price
+	var err error
+	if q.Get("from") != "" {
+		start, end, rangeErr := parseRange(q.Get("from"), q.Get("to"))
+		if rangeErr != nil {
+			http.Error(w, rangeErr.Error(), http.StatusBadRequest)
+			return
+		}
+		prices, err = s.priceRepo.GetPricesByTimeFrameWithLimit(symbol, timeFrame, start, end, limit)
+	} else {
+		prices, err = s.priceRepo.GetLastNPrices(symbol, timeFrame, limit)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	candles := make([]Candle, len(prices))
+	for i, p := range prices {
+		candles[i] = Candle{
+			Time:   p.OpenTime.Unix(),
+			Open:   p.Open,
+			High:   p.High,
+			Low:    p.Low,
+			Close:  p.Close,
+			Volume: p.Volume,
+		}
+	}
+	writeJSON(w, candles)
+}
+
+// Trade is a position as drawn on the chart, times in unix seconds
+type Trade struct {
+	ID         uint    `json:"id"`
+	Symbol     string  `json:"symbol"`
+	Side       string  `json:"side"`
+	Status     string  `json:"status"`
+	EntryTime  int64   `json:"entryTime"`
+	EntryPrice float64 `json:"entryPrice"`
+	ExitTime   int64   `json:"exitTime,omitempty"`
+	StopLoss   float64 `json:"stopLoss"`
+	TakeProfit float64 `json:"takeProfit"`
+	PnL        float64 `json:"pnl"`
+}
+
+func newTrade(p models.Position) Trade {
+	trade := Trade{
+		ID:         p.ID,
+		Symbol:     p.Symbol,
+		Side:       p.Side,
+		Status:     p.Status,
+		EntryTime:  p.OpenTime.Unix(),
+		EntryPrice: p.EntryPrice,
+		StopLoss:   p.StopLossPrice,
+		TakeProfit: p.TakeProfitPrice,
+		PnL:        p.PnL,
+	}
+	if p.Status == models.PositionStatusClosed {
+		trade.ExitTime = p.CloseTime.Unix()
+	}
+	return trade
+}
+
+// handleTrades serves positions on ?symbol= opened within ?from=&to=, the last week by default
+func (s *Server) handleTrades(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, end, err := parseRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	positions, err := s.positionRepo.ForAccount(account(r)).GetPositionsByTimeRange(start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	symbol := q.Get("symbol")
+	trades := make([]Trade, 0, len(positions))
+	for _, p := range positions {
+		if symbol == "" || p.Symbol == symbol {
+			trades = append(trades, newTrade(p))
+		}
+	}
+	writeJSON(w, trades)
+}
+
+// ContextTrade is a closed position with the analysis snapshot taken when it was opened
+type ContextTrade struct {
+	Trade
+	Reason  string                 `json:"reason"`
+	Context *analysis.EntryContext `json:"context"`
+}
+
+// handleTradeContexts serves closed positions by a numeric entry context ?field= within ?min=&max=,
+// e.g. ?field=volume_ratio&max=1&outcome=loss for losing trades entered on below average volume
+func (s *Server) handleTradeContexts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repositories.ContextFilter{Field: q.Get("field"), Outcome: q.Get("outcome")}
+	for name, bound := range map[string]**float64{"min": &filter.Min, "max": &filter.Max} {
+		if q.Get(name) == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(q.Get(name), 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s", name), http.StatusBadRequest)
+			return
+		}
+		*bound = &value
+	}
+	if filter.Field == "" && (filter.Min != nil || filter.Max != nil) {
+		http.Error(w, "min and max need a field", http.StatusBadRequest)
+		return
+	}
+	if filter.Outcome != "" && filter.Outcome != repositories.ContextOutcomeWin && filter.Outcome != repositories.ContextOutcomeLoss {
+		http.Error(w, "outcome must be win or loss", http.StatusBadRequest)
+		return
+	}
+
+	positions, err := s.positionRepo.ForAccount(account(r)).FindClosedByContext(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	trades := make([]ContextTrade, 0, len(positions))
+	for _, p := range positions {
+		entryContext, err := analysis.ParseEntryContext(p.EntryContext)
+		if err != nil {
+			log.Printf("Position %d: %v", p.ID, err)
+			continue
+		}
+		trades = append(trades, ContextTrade{Trade: newTrade(p), Reason: p.CloseReason, Context: entryContext})
+	}
+	writeJSON(w, trades)
+}
+
+func (s *Server) handlePositions(w http.ResponseWriter, r *http.Request) {
+	positions, err := s.positionRepo.ForAccount(account(r)).FindOpenPositions()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	trades := make([]Trade, len(positions))
+	for i, p := range positions {
+		trades[i] = newTrade(p)
+	}
+	writeJSON(w, trades)
+}
+
+// BalancePoint is the balance after one ledger entry, time in unix seconds
+type BalancePoint struct {
+	Time    int64   `json:"time"`
+	Balance float64 `json:"balance"`
+}
+
+// BalanceReport is the current balance and its history
+type BalanceReport struct {
+	Balance float64        `json:"balance"`
+	History []BalancePoint `json:"history"`
+}
+
+// handleBalance serves the current balance and the ledger history within ?from=&to=
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, end, err := parseRange(q.Get("from"), q.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	balance, err := s.accounts.ForAccount(account(r)).Balance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	transactions, err := s.transactionRepo.ForAccount(account(r)).GetTransactionsByTimeRange(start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	report := BalanceReport{History: make([]BalancePoint, 0, len(transactions))}
+	if balance != nil {
+		report.Balance = balance.Balance
+	}
+	for _, t := range transactions {
+		if t.Symbol == s.accounts.QuoteAsset() {
+			report.History = append(report.History, BalancePoint{Time: t.CreatedAt.Unix(), Balance: t.BalanceAfter})
+		}
+	}
+	writeJSON(w, report)
+}
+
+func (s *Server) handleSignals(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.signals[account(r)]
+	if !ok {
+		writeJSON(w, []handlers.SignalStatus{})
+		return
+	}
+	writeJSON(w, source.LatestSignals())
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	source, ok := s.signals[account(r)]
+	if !ok {
+		writeJSON(w, []handlers.SymbolHealth{})
+		return
+	}
+	writeJSON(w, source.SymbolHealth())
+}
+
+// handleExchange serves whether Binance, or any symbol on it, is degraded
+func (s *Server) handleExchange(w http.ResponseWriter, r *http.Request) {
+	if s.exchange == nil {
+		writeJSON(w, priceOperations.HealthStatus{})
+		return
+	}
+	writeJSON(w, s.exchange.Status())
+}
+
+// AccountEquity is a running account's balances and open positions marked to the latest close
+type AccountEquity struct {
+	Account       string  `json:"account"`
+	Shadow        bool    `json:"shadow"`
+	Balance       float64 `json:"balance"` // Every balance valued in the quote asset
+	Equity        float64 `json:"equity"`  // Balance plus unrealized PnL
+	OpenPositions int     `json:"open_positions"`
+}
+
+// handleEquity serves the equity of every running account side by side, trading accounts before shadows
+func (s *Server) handleEquity(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(s.signals))
+	for name := range s.signals {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if s.signals[names[i]].Shadow() != s.signals[names[j]].Shadow() {
+			return !s.signals[names[i]].Shadow()
+		}
+		return names[i] < names[j]
+	})
+
+	equities := make([]AccountEquity, 0, len(names))
+	for _, name := range names {
+		equity, err := s.accountEquity(r.Context(), name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		equity.Shadow = s.signals[name].Shadow()
+		equities = append(equities, equity)
+	}
+	writeJSON(w, equities)
+}
+
+// accountEquity values the account's balances and marks its open positions to the latest close
+func (s *Server) accountEquity(ctx context.Context, name string) (AccountEquity, error) {
+	account := s.accounts.ForAccount(name)
+	positions, err := s.positionRepo.ForAccount(name).FindOpenPositions()
+	if err != nil {
+		return AccountEquity{}, err
+	}
+
+	marks := make(map[string]float64)
+	for _, position := range positions {
+		if _, ok := marks[position.Symbol]; ok {
+			continue
+		}
+		latest, err := s.priceRepo.GetLatestPrice(ctx, position.Symbol)
+		if err != nil {
+			return AccountEquity{}, err
+		}
+		if latest != nil {
+			marks[position.Symbol] = latest.Close
+		}
+	}
+
+	balance, err := account.TotalBalance(ctx)
+	if err != nil {
+		return AccountEquity{}, err
+	}
+	equity, err := account.Equity(ctx, positions, marks)
+	if err != nil {
+		return AccountEquity{}, err
+	}
+	return AccountEquity{Account: name, Balance: balance, Equity: equity, OpenPositions: len(positions)}, nil
+}
+
+// parseRange reads RFC3339 bounds, defaulting to the last defaultHistory up to now
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	end := time.Now()
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultHistory)
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return start, end, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing dashboard response: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package dashboard
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// maxJournalBody bounds the size of a journal request
+const maxJournalBody = 64 << 10
+
+// TagUpdate is the body of PATCH /api/positions/{id}/tags
+type TagUpdate struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// NotesUpdate is the body of PATCH /api/positions/{id}/notes
+type NotesUpdate struct {
+	Notes string `json:"notes"`
+}
+
+// Journal is a position's tags and notes after an update
+type Journal struct {
+	ID    uint     `json:"id"`
+	Tags  []string `json:"tags"`
+	Notes string   `json:"notes"`
+}
+
+// journal guards a journal endpoint with the bearer token, refusing everything when none is set
+func (s *Server) journal(next func(http.ResponseWriter, *http.Request, uint)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.journalToken == "" {
+			http.Error(w, "journal is disabled", http.StatusForbidden)
+			return
+		}
+		want := "Bearer " + s.journalToken
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+		if err != nil || id == 0 {
+			http.Error(w, "invalid position id", http.StatusBadRequest)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxJournalBody)
+		next(w, r, uint(id))
+	})
+}
+
+// handleTags adds and removes tags on a closed position of ?account=
+func (s *Server) handleTags(w http.ResponseWriter, r *http.Request, id uint) {
+	var update TagUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	positions := s.positionRepo.ForAccount(account(r))
+	for _, tag := range update.Add {
+		if err := positions.AddTag(id, tag); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	for _, tag := range update.Remove {
+		if err := positions.RemoveTag(id, tag); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	s.writeJournal(w, r, id)
+}
+
+// handleNotes replaces the notes of a closed position of ?account=
+func (s *Server) handleNotes(w http.ResponseWriter, r *http.Request, id uint) {
+	var update NotesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "invalid body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.positionRepo.ForAccount(account(r)).SetNotes(id, update.Notes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.writeJournal(w, r, id)
+}
+
+// writeJournal responds with the position's tags and notes as stored
+func (s *Server) writeJournal(w http.ResponseWriter, r *http.Request, id uint) {
+	positions := s.positionRepo.ForAccount(account(r))
+	position, err := positions.FindByID(id)
+	if err != nil || position == nil {
+		http.Error(w, "position not found", http.StatusNotFound)
+		return
+	}
+	tags, err := positions.Tags([]uint{id})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	journal := Journal{ID: id, Tags: tags[id], Notes: position.Notes}
+	if journal.Tags == nil {
+		journal.Tags = []string{}
+	}
+	writeJSON(w, journal)
+}
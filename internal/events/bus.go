@@ -0,0 +1,106 @@
+package events
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"log"
+	"sync"
+)
+
+// CandleClosed is published once a candle has been saved
+type CandleClosed struct {
+	Symbol    string
+	TimeFrame string
+	Price     models.Price
+}
+
+// Bus fans CandleClosed events out to subscribers without ever blocking the publisher
+// A subscriber whose buffer is full misses the event, which is logged and counted
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []*Subscription
+	closed bool
+}
+
+// Subscription receives the events published after it was made
+type Subscription struct {
+	name string
+	c    chan CandleClosed
+}
+
+// NewBus creates a new instance of Bus
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe returns a subscription buffering up to buffer events, name identifies it in logs and metrics
+// Subscribing to a closed bus returns a subscription whose channel is already closed
+func (b *Bus) Subscribe(name string, buffer int) *Subscription {
+	sub := &Subscription{name: name, c: make(chan CandleClosed, buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(sub.c)
+		return sub
+	}
+	b.subs = append(b.subs, sub)
+	return sub
+}
+
+// Unsubscribe stops delivering to sub and closes its channel
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			close(sub.c)
+			return
+		}
+	}
+}
+
+// Publish delivers event to every subscriber with room for it, it never waits
+func (b *Bus) Publish(event CandleClosed) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+
+	for _, sub := range b.subs {
+		select {
+		case sub.c <- event:
+		default:
+			log.Printf("Event bus: %s is falling behind, dropped %s %s candle", sub.name, event.Symbol, event.TimeFrame)
+			metrics.EventsDropped.WithLabelValues(sub.name).Inc()
+		}
+	}
+}
+
+// Run closes the bus when ctx is cancelled
+func (b *Bus) Run(ctx context.Context) {
+	<-ctx.Done()
+	b.Close()
+}
+
+// Close closes every subscription channel; later publishes are ignored
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		close(sub.c)
+	}
+	b.subs = nil
+}
+
+// C returns the channel events are delivered on, closed when the bus closes or sub unsubscribes
+func (s *Subscription) C() <-chan CandleClosed {
+	return s.c
+}
@@ -0,0 +1,174 @@
+package events
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+var testStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// closed returns the event for the i-th 5m candle of symbol
+func closed(symbol string, i int) CandleClosed {
+	return CandleClosed{
+		Symbol:    symbol,
+		TimeFrame: models.PriceTimeFrame5m,
+		Price:     models.Price{Symbol: symbol, TimeFrame: models.PriceTimeFrame5m, OpenTime: testStart.Add(time.Duration(i) * 5 * time.Minute)},
+	}
+}
+
+// drain returns every event left on sub until its channel closes
+func drain(t *testing.T, sub *Subscription) []CandleClosed {
+	t.Helper()
+	var got []CandleClosed
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event, ok := <-sub.C():
+			if !ok {
+				return got
+			}
+			got = append(got, event)
+		case <-timeout:
+			t.Fatal("subscription channel not closed")
+		}
+	}
+}
+
+func TestPublishDeliversEachEventOnceToEverySubscriber(t *testing.T) {
+	bus := NewBus()
+	subs := []*Subscription{bus.Subscribe("analysis", 10), bus.Subscribe("strategy", 10)}
+
+	for i := range 5 {
+		bus.Publish(closed("BTCUSDT", i))
+	}
+	bus.Close()
+
+	for _, sub := range subs {
+		got := drain(t, sub)
+		if len(got) != 5 {
+			t.Fatalf("%s got %d events, want 5", sub.name, len(got))
+		}
+		for i, event := range got {
+			if want := closed("BTCUSDT", i).Price.OpenTime; !event.Price.OpenTime.Equal(want) {
+				t.Errorf("%s event %d opens at %v, want %v", sub.name, i, event.Price.OpenTime, want)
+			}
+		}
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe("slow", 2)
+	fast := bus.Subscribe("fast", 1)
+
+	// The fast subscriber keeps up while the slow one never reads
+	var received []CandleClosed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range fast.C() {
+			received = append(received, event)
+		}
+	}()
+
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := range 100 {
+			bus.Publish(closed("BTCUSDT", i))
+			// Give the fast reader its turn, so only the slow subscriber overflows
+			for len(fast.c) > 0 {
+				time.Sleep(time.Microsecond)
+			}
+		}
+	}()
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish blocked on a slow subscriber")
+	}
+	bus.Close()
+	<-done
+
+	if len(received) != 100 {
+		t.Errorf("fast subscriber got %d events, want all 100", len(received))
+	}
+	// The slow subscriber keeps what fit in its buffer, the oldest, and missed the rest
+	got := drain(t, slow)
+	if len(got) != 2 || !got[0].Price.OpenTime.Equal(testStart) {
+		t.Errorf("slow subscriber got %d events, want the first 2", len(got))
+	}
+}
+
+func TestRunClosesTheBusWithTheContext(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("analysis", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		bus.Run(ctx)
+		close(stopped)
+	}()
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after the context was cancelled")
+	}
+	if got := drain(t, sub); len(got) != 0 {
+		t.Errorf("got %d events, want none", len(got))
+	}
+
+	// Publishing and closing again after shutdown are no-ops, and late subscribers see it closed
+	bus.Publish(closed("BTCUSDT", 0))
+	bus.Close()
+	if got := drain(t, bus.Subscribe("late", 1)); len(got) != 0 {
+		t.Errorf("late subscriber got %d events, want none", len(got))
+	}
+}
+
+func TestUnsubscribeStopsDelivery(t *testing.T) {
+	bus := NewBus()
+	gone := bus.Subscribe("gone", 5)
+	stays := bus.Subscribe("stays", 5)
+
+	bus.Publish(closed("BTCUSDT", 0))
+	bus.Unsubscribe(gone)
+	bus.Publish(closed("BTCUSDT", 1))
+	bus.Close()
+
+	if got := drain(t, gone); len(got) != 1 {
+		t.Errorf("unsubscribed got %d events, want the 1 before it left", len(got))
+	}
+	if got := drain(t, stays); len(got) != 2 {
+		t.Errorf("remaining subscriber got %d events, want 2", len(got))
+	}
+}
+
+func TestConcurrentPublishAndClose(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("analysis", 8)
+
+	var wg sync.WaitGroup
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				bus.Publish(closed(symbol, i))
+			}
+		}()
+	}
+	go func() {
+		time.Sleep(time.Millisecond)
+		bus.Close()
+	}()
+
+	// Neither a send on a closed channel nor a hang, whatever the interleaving
+	drain(t, sub)
+	wg.Wait()
+}
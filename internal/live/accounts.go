@@ -0,0 +1,139 @@
+package live
+
+import (
+	"CryptoTradeBot/internal/books"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Account is one trading pipeline: an account and the strategies that trade it
+type Account struct {
+	Name       string
+	Strategies *strategy.StrategyManager
+	Shadow     bool // Runs beside the primary account, its decisions only recorded
+
+	// Set for books, see books.Book
+	Symbols   []string              // Symbols the account trades, empty for every recorded symbol
+	Sizing    *trading.SizingConfig // nil for -min-notional and the other sizing flags
+	Frequency *risk.FrequencyConfig // nil for -max-trades-per-day and -max-trades-per-symbol
+}
+
+// tradable returns the account's symbols among symbols
+func (a Account) tradable(symbols []string) []string {
+	if len(a.Symbols) == 0 {
+		return symbols
+	}
+	var tradable []string
+	for _, symbol := range symbols {
+		if slices.Contains(a.Symbols, symbol) {
+			tradable = append(tradable, symbol)
+		}
+	}
+	return tradable
+}
+
+// AccountSymbols returns every symbol the accounts trade, the prices they share
+func AccountSymbols(accounts []Account) []string {
+	var symbols []string
+	for _, account := range accounts {
+		for _, symbol := range account.Symbols {
+			if !slices.Contains(symbols, symbol) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	return symbols
+}
+
+// LoadAccounts parses name=strategy-config pairs, each account loading its own parameters
+// An empty spec runs the default account on the already loaded strategies; a primary makes every
+// other account a shadow of it, so promoting a parameter set is naming its account primary.
+// A books file instead makes an account of each book, trading the book's symbols only
+func LoadAccounts(spec, booksPath, primary string, strategies *strategy.StrategyManager, symbols []string) ([]Account, error) {
+	if booksPath != "" {
+		if spec != "" {
+			return nil, fmt.Errorf("-books and -accounts cannot be combined")
+		}
+		return loadBooks(booksPath, primary)
+	}
+	if spec == "" {
+		if primary != "" && primary != models.DefaultAccount {
+			return nil, fmt.Errorf("primary account %q is not among the accounts", primary)
+		}
+		return []Account{{Name: models.DefaultAccount, Strategies: strategies}}, nil
+	}
+
+	var accounts []Account
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		name, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid account %q, want name=strategy-config", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate account %q", name)
+		}
+		seen[name] = true
+
+		manager, err := strategy.LoadStrategyManager(path, symbols)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", name, err)
+		}
+		accounts = append(accounts, Account{Name: name, Strategies: manager, Shadow: primary != "" && name != primary})
+	}
+	if primary != "" && !seen[primary] {
+		return nil, fmt.Errorf("primary account %q is not among the accounts", primary)
+	}
+	return accounts, nil
+}
+
+// loadBooks makes an account of each book in the books file at path
+func loadBooks(path, primary string) ([]Account, error) {
+	portfolio, err := books.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]Account, len(portfolio))
+	found := primary == ""
+	for i, book := range portfolio {
+		accounts[i] = Account{
+			Name:       book.Name,
+			Strategies: book.Strategies,
+			Shadow:     primary != "" && book.Name != primary,
+			Symbols:    book.Symbols,
+			Sizing:     book.Sizing,
+			Frequency:  book.Frequency,
+		}
+		found = found || book.Name == primary
+	}
+	if !found {
+		return nil, fmt.Errorf("primary account %q is not among the books", primary)
+	}
+	return accounts, nil
+}
+
+// WarmUpAge returns how far back the accounts' strategies read candles, which pruning must keep
+func WarmUpAge(accounts []Account) time.Duration {
+	var age time.Duration
+	for _, account := range accounts {
+		window := account.Strategies.WindowCandles(models.PriceTimeFrame5m)
+		age = max(age, time.Duration(window)*models.TimeFrameDurations[models.PriceTimeFrame5m])
+	}
+	return age
+}
+
+// checkAccountSymbols fails when an account holds no balance to book the PnL of one of symbols in
+func checkAccountSymbols(accountService *trading.AccountService, accounts []Account, symbols []string) error {
+	for _, account := range accounts {
+		if err := accountService.ForAccount(account.Name).CheckSymbols(account.tradable(symbols)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package live
+
+import (
+	"CryptoTradeBot/internal/operations/handlers"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/operations/reports"
+	"CryptoTradeBot/internal/services/trading"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Config is every setting live trading runs with besides its accounts and symbols
+type Config struct {
+	Trade    trading.TradeConfig
+	Recorder priceOperations.RecorderConfig
+	Dedup    handlers.DedupConfig
+
+	StaleCandles        float64 // 5m intervals a symbol's latest candle may lag before its entries are blocked, 0 to never block
+	AnalysisConcurrency int     // Analysis passes run at once across all accounts
+	EventBus            bool    // Analyze each symbol as its 5m candle is recorded instead of polling
+
+	SymbolsFile string   // Reloaded on SIGHUP, empty when the symbols are fixed
+	ExcludeTags []string // Journal tags whose trades reports leave out
+
+	ReportAt *time.Duration // UTC time of day of the daily summary, nil to disable
+	PruneAt  *time.Duration // UTC time of day candles past their retention are pruned, nil to disable
+
+	SkipHealthGate bool
+	HealthGrace    time.Duration // How long failing health checks are retried before giving up
+}
+
+// Flags are the command line flags of the settings only live trading has, see Config
+type Flags struct {
+	skipHealthGate      bool
+	healthGrace         time.Duration
+	analysisConcurrency int
+	staleCandles        float64
+	dedupWindow         time.Duration
+	dedupDelta          float64
+	eventBus            bool
+	reportTime          string
+	pruneTime           string
+}
+
+// RegisterFlags defines the flags on fs
+func (f *Flags) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&f.skipHealthGate, "skip-health-gate", false, "Start live trading without waiting for health checks (development only)")
+	fs.DurationVar(&f.healthGrace, "health-grace", 2*time.Minute, "How long to retry failing health checks before giving up")
+	fs.IntVar(&f.analysisConcurrency, "analysis-concurrency", handlers.DefaultAnalysisConcurrency, "Analysis passes run at once across all accounts")
+	fs.Float64Var(&f.staleCandles, "stale-candles", handlers.DefaultStaleCandles, "5m intervals past its close a symbol's latest candle may be before its entries are blocked, 0 to never block")
+	fs.DurationVar(&f.dedupWindow, "signal-dedup-window", handlers.DefaultDedupWindow, "How long a valid setup repeated by later analysis passes is logged and notified only once, 0 to announce every pass")
+	fs.Float64Var(&f.dedupDelta, "signal-dedup-delta", handlers.DefaultDedupConfidenceDelta, "Confidence change that announces a repeated setup again")
+	fs.BoolVar(&f.eventBus, "event-bus", true, "Analyze each symbol as its 5m candle is recorded; false polls every 15 seconds instead")
+	fs.StringVar(&f.reportTime, "report-time", "00:05", "UTC time of the daily summary in live mode, empty to disable")
+	fs.StringVar(&f.pruneTime, "prune-time", "03:30", "UTC time candles past their retention are pruned in live mode, empty to disable")
+}
+
+// Config validates the parsed flags into live settings over the trade and recorder settings
+func (f *Flags) Config(trade trading.TradeConfig, recorder priceOperations.RecorderConfig, symbolsFile string, excludeTags []string) (Config, error) {
+	config := Config{
+		Trade:               trade,
+		Recorder:            recorder,
+		Dedup:               handlers.DefaultDedupConfig(),
+		StaleCandles:        f.staleCandles,
+		AnalysisConcurrency: f.analysisConcurrency,
+		EventBus:            f.eventBus,
+		SymbolsFile:         symbolsFile,
+		ExcludeTags:         excludeTags,
+		SkipHealthGate:      f.skipHealthGate,
+		HealthGrace:         f.healthGrace,
+	}
+	config.Dedup.Window, config.Dedup.ConfidenceDelta = f.dedupWindow, f.dedupDelta
+	if err := config.Dedup.Validate(); err != nil {
+		return Config{}, err
+	}
+	if err := recorder.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	var err error
+	if config.ReportAt, err = timeOfDay(f.reportTime); err != nil {
+		return Config{}, fmt.Errorf("report time: %v", err)
+	}
+	if config.PruneAt, err = timeOfDay(f.pruneTime); err != nil {
+		return Config{}, fmt.Errorf("prune time: %v", err)
+	}
+	return config, nil
+}
+
+// timeOfDay parses an HH:MM time of day, nil when value is empty
+func timeOfDay(value string) (*time.Duration, error) {
+	if value == "" {
+		return nil, nil
+	}
+	at, err := reports.ParseTimeOfDay(value)
+	if err != nil {
+		return nil, err
+	}
+	return &at, nil
+}
@@ -0,0 +1,380 @@
+// Package live runs the trading accounts on the recorded price feed until the process is signalled
+package live
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/dashboard"
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/operations/handlers"
+	"CryptoTradeBot/internal/operations/health"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/operations/reports"
+	"CryptoTradeBot/internal/provenance"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Deps are the repositories and services live trading runs on, built by the caller
+type Deps struct {
+	DB              *gorm.DB
+	PriceRepo       *repositories.PriceRepository
+	PositionRepo    *repositories.PositionRepository
+	AccountService  *trading.AccountService
+	OrderRepo       *repositories.PendingOrderRepository
+	TransactionRepo *repositories.TransactionRepository
+	SuspensionRepo  *repositories.SymbolSuspensionRepository
+	SignalRepo      *repositories.SignalRepository
+	Notifier        *notifications.Notifier
+
+	Accounts []Account
+	Symbols  []string // Symbols recorded and traded until the universe or a reload replaces them
+
+	Limiter   *priceOperations.WeightLimiter
+	Validator *priceOperations.SymbolValidator
+	Universe  *priceOperations.UniverseService // nil to trade the fixed symbols
+	Retention *priceOperations.RetentionService
+}
+
+// Run trades every account until the process is interrupted or terminated
+func Run(deps Deps, config Config) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	symbols := deps.Symbols
+
+	// Requests back off while Binance or a symbol fails, shared by every client
+	exchangeHealth := priceOperations.NewExchangeHealth(deps.Notifier)
+
+	// Initialize handlers
+	priceHandler := handlers.NewPriceHandler(deps.PriceRepo, deps.Limiter)
+	priceHandler.SpillTo(config.Recorder.SpillPath)
+	priceHandler.UseHealth(exchangeHealth)
+
+	// Recorded candles wake the analysis instead of it polling the database
+	var bus *events.Bus
+	if config.EventBus {
+		bus = events.NewBus()
+		go bus.Run(ctx)
+		priceHandler.PublishTo(bus)
+	}
+
+	// Market fills use the live mark price, shared by every account
+	ticker := priceOperations.NewTickerService(
+		priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), deps.Limiter),
+		deps.Limiter, priceOperations.DefaultMarkPriceTTL)
+	ticker.UseHealth(exchangeHealth)
+
+	// Entries of strategies filtering on order flow are confirmed against the recorded symbols' trades
+	var orderFlow *priceOperations.AggTradeCollector
+	for _, account := range deps.Accounts {
+		if account.Strategies.FiltersOrderFlow() {
+			orderFlow = priceOperations.NewAggTradeCollector(config.Recorder.OrderFlowWindow)
+			priceHandler.CollectOrderFlow(orderFlow)
+			break
+		}
+	}
+
+	// Every account runs its own pipeline on the shared price feed, its passes capped by a shared pool
+	stateRepo := repositories.NewBotStateRepository(deps.DB)
+	configRepo := repositories.NewConfigSnapshotRepository(deps.DB)
+	pool := handlers.NewAnalysisPool(config.AnalysisConcurrency)
+	analysisHandlers := make([]*handlers.AnalysisHandler, len(deps.Accounts))
+	signals := make(map[string]dashboard.SignalSource, len(deps.Accounts))
+	for i, account := range deps.Accounts {
+		analysisHandlers[i] = newAccountPipeline(account, deps, config.Trade)
+		analysisHandlers[i].TallyRejections(deps.SignalRepo.ForAccount(account.Name))
+		analysisHandlers[i].PersistState(stateRepo.ForAccount(account.Name))
+		if config.Trade.EquityStop != nil {
+			analysisHandlers[i].UseEquityStop(risk.NewEquityStop(*config.Trade.EquityStop, stateRepo.ForAccount(account.Name)))
+		}
+		frequency, sizing := config.Trade.Frequency, config.Trade.Sizing
+		if account.Frequency != nil {
+			frequency = *account.Frequency
+		}
+		if account.Sizing != nil {
+			sizing = *account.Sizing
+		}
+		if frequency.Enabled() {
+			analysisHandlers[i].RegisterVeto(risk.NewFrequencyLimiter(frequency, deps.PositionRepo.ForAccount(account.Name)))
+		}
+		analysisHandlers[i].UseSizing(sizing)
+		analysisHandlers[i].UseBreakeven(config.Trade.BreakevenAtR)
+		if len(account.Symbols) > 0 {
+			analysisHandlers[i].LimitSymbols(account.Symbols)
+		}
+		hash, err := recordConfig(configRepo, settings{
+			Strategy:     account.Strategies.Settings(),
+			Entry:        config.Trade.Entry,
+			Reversal:     config.Trade.Reversal,
+			Performance:  config.Trade.Performance,
+			Scaling:      config.Trade.Scaling,
+			EquityStop:   config.Trade.EquityStop,
+			Frequency:    frequency,
+			Sizing:       sizing,
+			BreakevenAtR: config.Trade.BreakevenAtR,
+			StaleCandles: config.StaleCandles,
+		})
+		if err != nil {
+			log.Fatalf("Failed to record the configuration of %s: %v", account.Name, err)
+		}
+		log.Printf("[%s] Config %s (build %s)", account.Name, hash[:provenance.ShortHash], provenance.Version)
+		analysisHandlers[i].StampConfig(hash)
+		analysisHandlers[i].UseTicker(ticker)
+		analysisHandlers[i].UseExchangeHealth(exchangeHealth)
+		analysisHandlers[i].UseBackfill(priceHandler)
+		analysisHandlers[i].BlockStaleData(config.StaleCandles)
+		analysisHandlers[i].DedupSignals(config.Dedup)
+		if orderFlow != nil {
+			analysisHandlers[i].UseOrderFlow(orderFlow)
+		}
+		analysisHandlers[i].UsePool(pool)
+		if account.Shadow {
+			analysisHandlers[i].RunAsShadow()
+		}
+		if bus != nil {
+			analysisHandlers[i].SubscribeTo(bus)
+		}
+		signals[account.Name] = analysisHandlers[i]
+	}
+
+	// Trade today's most liquid symbols instead of the fixed list
+	if deps.Universe != nil {
+		selected, err := deps.Universe.Select(ctx)
+		if err != nil {
+			log.Fatal("Failed to select universe:", err)
+		}
+		symbols = selected
+	}
+	if err := checkAccountSymbols(deps.AccountService, deps.Accounts, symbols); err != nil {
+		log.Fatal(err)
+	}
+	rotation := handlers.NewSymbolRotation(priceHandler, analysisHandlers, symbols)
+
+	log.Println("Starting live trading...")
+	go deps.Notifier.Run(ctx)
+
+	// Expose Prometheus metrics when a port is configured
+	if port := os.Getenv("METRICS_PORT"); port != "" {
+		go metrics.Serve(ctx, ":"+port)
+	}
+
+	// Send each trading account's daily summary, and weekly on Mondays; shadows are compared in the primary's
+	if config.ReportAt != nil {
+		reporters := make([]*reports.ReportService, len(deps.Accounts))
+		var shadows []*reports.ReportService
+		for i, account := range deps.Accounts {
+			reporters[i] = reports.NewReportService(deps.PriceRepo,
+				deps.PositionRepo.ForAccount(account.Name),
+				deps.TransactionRepo.ForAccount(account.Name),
+				deps.SignalRepo.ForAccount(account.Name),
+				deps.Notifier, handlers.InitialBalance, deps.AccountService.QuoteAsset())
+			if err := reporters[i].ExcludeTags(config.ExcludeTags); err != nil {
+				log.Fatal(err)
+			}
+			reporters[i].UseAccount(deps.AccountService.ForAccount(account.Name))
+			reporters[i].UseConfigSnapshots(configRepo)
+			if account.Shadow {
+				shadows = append(shadows, reporters[i])
+			}
+		}
+		for i, account := range deps.Accounts {
+			if account.Shadow {
+				continue
+			}
+			reporters[i].CompareShadows(shadows...)
+			go reporters[i].Run(ctx, *config.ReportAt)
+		}
+	}
+
+	// Prune candles past their retention every night
+	if config.PruneAt != nil {
+		go deps.Retention.Run(ctx, *config.PruneAt)
+	}
+
+	// Serve the read-only dashboard when a port is configured
+	if port := os.Getenv("DASHBOARD_PORT"); port != "" {
+		server := dashboard.NewServer(deps.PriceRepo, deps.PositionRepo, deps.AccountService, deps.TransactionRepo, signals, rotation.Symbols)
+		if token := os.Getenv("DASHBOARD_JOURNAL_TOKEN"); token != "" {
+			server.EnableJournal(token)
+		}
+		server.ShowExchangeHealth(exchangeHealth)
+		controls := make(map[string]dashboard.Controller, len(deps.Accounts))
+		for i, account := range deps.Accounts {
+			controls[account.Name] = analysisHandlers[i]
+		}
+		server.EnableControl(os.Getenv("DASHBOARD_CONTROL_TOKEN"), controls)
+		go server.Serve(ctx, ":"+port)
+	}
+
+	// Start price handler
+	if err := priceHandler.Start(ctx, symbols); err != nil {
+		log.Fatal("Failed to start price handler:", err)
+	}
+	rotation.AwaitHistory()
+
+	time.Sleep(time.Second * 10)
+
+	// Refuse to trade until the environment is healthy
+	if config.SkipHealthGate {
+		log.Println("Warning: health gate skipped, trading without startup checks")
+	} else {
+		client := priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), deps.Limiter)
+		clk := clock.Real
+		gate := health.NewHealthGate(config.HealthGrace, health.LiveTradingChecks(deps.DB, client, deps.PriceRepo, symbols, clk)...)
+		gate.SetClock(clk)
+		gate.SetNotifier(deps.Notifier)
+		if err := gate.Wait(ctx); err != nil {
+			deps.Notifier.Notify(notifications.Event{
+				Severity: notifications.SeverityCritical,
+				Title:    "Trading blocked by health gate",
+				Message:  err.Error(),
+			})
+			log.Fatal("Refusing to start trading: ", err)
+		}
+	}
+
+	for _, analysisHandler := range analysisHandlers {
+		go analysisHandler.Start(ctx, symbols)
+	}
+	go rotation.Run(ctx)
+	if deps.Universe != nil {
+		go deps.Universe.Run(ctx, rotation.Apply)
+	}
+
+	// Reload the symbols file on SIGHUP, toggle the pause on SIGUSR1, shut down on anything else
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR1)
+	for sig := range c {
+		if sig == syscall.SIGUSR1 {
+			togglePause(analysisHandlers)
+			continue
+		}
+		if sig != syscall.SIGHUP {
+			break
+		}
+		reloadSymbols(ctx, rotation, config.SymbolsFile, deps.Validator, deps.AccountService, deps.Accounts)
+	}
+
+	log.Println("Shutting down...")
+	cancel()
+
+	// Recorded candles still queued are saved or spilled before exiting
+	select {
+	case <-priceHandler.Done():
+	case <-time.After(30 * time.Second):
+		log.Println("Timed out flushing recorded candles")
+	}
+	time.Sleep(time.Second * 2)
+	log.Println("Shutdown complete")
+}
+
+// settings is everything a live account's decisions depend on besides the market,
+// recorded as its config snapshot
+type settings struct {
+	Strategy     strategy.Settings       `json:"strategy"`
+	Entry        trading.EntryConfig     `json:"entry"`
+	Reversal     trading.ReversalConfig  `json:"reversal"`
+	Performance  *risk.PerformanceConfig `json:"performance"`
+	Scaling      *risk.ScalingConfig     `json:"scaling"`
+	EquityStop   *risk.EquityStopConfig  `json:"equity_stop"`
+	Frequency    risk.FrequencyConfig    `json:"frequency"`
+	Sizing       trading.SizingConfig    `json:"sizing"`
+	BreakevenAtR float64                 `json:"breakeven_at_r"`
+	StaleCandles float64                 `json:"stale_candles"`
+}
+
+// recordConfig stores the snapshot of settings as run by this build, returning its hash
+func recordConfig(configRepo *repositories.ConfigSnapshotRepository, settings settings) (string, error) {
+	snapshot, err := provenance.New(settings)
+	if err != nil {
+		return "", err
+	}
+	err = configRepo.Save(&models.ConfigSnapshot{
+		Hash:    snapshot.Hash,
+		Version: snapshot.Version,
+		Config:  string(snapshot.Config),
+	})
+	return snapshot.Hash, err
+}
+
+// newAccountPipeline builds the analysis handler for one account from repositories scoped to it
+func newAccountPipeline(account Account, deps Deps, trade trading.TradeConfig) *handlers.AnalysisHandler {
+	positionRepo := deps.PositionRepo.ForAccount(account.Name)
+	accountService := deps.AccountService.ForAccount(account.Name)
+
+	// Consecutive live windows overlap, so only fold in new candles
+	account.Strategies.EnableIncremental()
+
+	analysisHandler := handlers.NewAnalysisHandler(
+		account.Strategies,
+		deps.PriceRepo,
+		positionRepo,
+		accountService,
+		deps.OrderRepo.ForAccount(account.Name),
+		deps.Notifier,
+		trade.Entry,
+		trade.Reversal,
+	)
+
+	// Block entries while BTC is making an outsized hourly move
+	analysisHandler.RegisterVeto(risk.NewBTCMoveVeto(deps.PriceRepo, 0.03))
+
+	// Stop entering symbols that keep losing until their probation ends
+	if trade.Performance != nil {
+		tracker := risk.NewSymbolPerformanceTracker(positionRepo, deps.SuspensionRepo.ForAccount(account.Name), deps.Notifier, *trade.Performance)
+		analysisHandler.RegisterVeto(tracker)
+
+		suspensions, err := tracker.Active(time.Now())
+		if err != nil {
+			log.Fatalf("Failed to load symbol suspensions for %s: %v", account.Name, err)
+		}
+		for _, suspension := range suspensions {
+			log.Printf("[%s] %s suspended until %s: %s", account.Name, suspension.Symbol,
+				suspension.ResumeAt.UTC().Format(time.RFC3339), suspension.Reason)
+		}
+	}
+
+	// Size positions down after losing streaks, from the account's closed positions
+	if trade.Scaling != nil {
+		analysisHandler.ScaleRisk(risk.NewRiskScaler(positionRepo, *trade.Scaling))
+	}
+
+	// Initialize balance
+	if err := accountService.Init(handlers.InitialBalance); err != nil {
+		log.Fatalf("Failed to initialize balance for %s: %v", account.Name, err)
+	}
+
+	log.Printf("Account %s ready", account.Name)
+	return analysisHandler
+}
+
+// togglePause pauses every account unless all are paused already, in which case it resumes them
+func togglePause(analysisHandlers []*handlers.AnalysisHandler) {
+	paused := true
+	for _, h := range analysisHandlers {
+		paused = paused && h.PauseState().Paused
+	}
+	for _, h := range analysisHandlers {
+		var err error
+		if paused {
+			err = h.Resume()
+		} else {
+			err = h.Pause("SIGUSR1")
+		}
+		if err != nil {
+			log.Printf("Error toggling pause: %v", err)
+		}
+	}
+}
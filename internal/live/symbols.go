@@ -0,0 +1,72 @@
+package live
+
+import (
+	"CryptoTradeBot/internal/operations/handlers"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// LoadSymbols reads a symbols file: symbols separated by commas or whitespace, # starts a comment
+func LoadSymbols(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols file: %v", err)
+	}
+
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		for _, field := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			symbol := strings.ToUpper(field)
+			if !seen[symbol] {
+				seen[symbol] = true
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbols in %s", path)
+	}
+	return symbols, nil
+}
+
+// reloadSymbols applies the symbols file to the running rotation, keeping the current symbols if
+// one of them is not a trading perpetual or an account holds no balance in its quote asset
+func reloadSymbols(ctx context.Context, rotation *handlers.SymbolRotation, path string, validator *priceOperations.SymbolValidator,
+	accountService *trading.AccountService, accounts []Account) {
+	if path == "" {
+		log.Println("SIGHUP ignored, no -symbols-file to reload")
+		return
+	}
+
+	symbols, err := LoadSymbols(path)
+	if err != nil {
+		log.Printf("Keeping current symbols: %v", err)
+		return
+	}
+	if symbols, err = validator.Validate(ctx, symbols); err != nil {
+		log.Printf("Keeping current symbols: %v", err)
+		return
+	}
+	if err := trading.CheckSymbols(symbols, accountService.QuoteAsset()); err != nil {
+		log.Printf("Keeping current symbols: %v", err)
+		return
+	}
+	if err := checkAccountSymbols(accountService, accounts, symbols); err != nil {
+		log.Printf("Keeping current symbols: %v", err)
+		return
+	}
+
+	log.Printf("Reloading symbols: %s", strings.Join(symbols, ", "))
+	if err := rotation.Apply(ctx, symbols); err != nil {
+		log.Printf("Symbol reload incomplete: %v", err)
+	}
+	log.Printf("Trading %s", strings.Join(rotation.Symbols(), ", "))
+}
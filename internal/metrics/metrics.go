@@ -0,0 +1,181 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Labels are limited to symbol, timeframe and fixed reason strings to keep cardinality bounded
+var (
+	CandlesRecorded = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_candles_recorded_total",
+		Help: "Candles saved by the price recorder",
+	}, []string{"symbol", "timeframe"})
+
+	AnalysisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tradebot_analysis_duration_seconds",
+		Help:    "Time spent on one analysis pass for a symbol",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"symbol"})
+
+	SignalsGenerated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_signals_generated_total",
+		Help: "Valid signals produced by the analysis",
+	}, []string{"symbol"})
+
+	SignalsRefreshed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_signals_refreshed_total",
+		Help: "Valid signals repeating a setup already announced, suppressed by deduplication",
+	}, []string{"symbol"})
+
+	OrderFlowSkipped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_order_flow_skipped_total",
+		Help: "Entry checks let through unconfirmed because the symbol's trade stream was unavailable",
+	}, []string{"symbol"})
+
+	SignalsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_signals_rejected_total",
+		Help: "Analysis passes that did not produce a signal, by reason",
+	}, []string{"symbol", "reason"})
+
+	OpenPositions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_open_positions",
+		Help: "Number of open positions",
+	}, []string{"account"})
+
+	Balance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_balance_usdt",
+		Help: "Current account balance",
+	}, []string{"account"})
+
+	APIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_api_errors_total",
+		Help: "Failed exchange API calls",
+	}, []string{"symbol", "timeframe"})
+
+	APIWeightUsed = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tradebot_api_weight_used",
+		Help: "Binance request weight used in the current minute",
+	})
+
+	APIRateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_api_rate_limited_total",
+		Help: "Binance rate limit responses, by HTTP status",
+	}, []string{"status"})
+
+	SymbolSuspended = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_symbol_suspended",
+		Help: "1 while entries on a symbol are suspended for poor performance",
+	}, []string{"account", "symbol"})
+
+	NetExposure = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_net_exposure_usdt",
+		Help: "Signed notional of open positions per symbol, longs positive; hedged legs offset",
+	}, []string{"account", "symbol"})
+
+	EventsDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_events_dropped_total",
+		Help: "Candle events a subscriber missed because its buffer was full",
+	}, []string{"subscriber"})
+
+	PriceWriteQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tradebot_price_write_queue_depth",
+		Help: "Recorded candles waiting in memory to be saved",
+	})
+
+	PriceWriteSpilled = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tradebot_price_write_spilled",
+		Help: "Recorded candles waiting in the spill file to be saved",
+	})
+
+	PriceWriteRetries = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tradebot_price_write_retries_total",
+		Help: "Failed attempts to save a recorded candle",
+	})
+
+	PriceWritesDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_price_writes_dropped_total",
+		Help: "Recorded candles lost because the write queue was full and spilling failed",
+	}, []string{"symbol", "timeframe"})
+
+	PriceCorrections = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_price_corrections_total",
+		Help: "Stored candles repaired from the exchange's final values, by kind: changed or missing",
+	}, []string{"symbol", "timeframe", "kind"})
+
+	ExchangeDegraded = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_exchange_degraded",
+		Help: "1 while Binance requests are held back, by scope: exchange or a symbol",
+	}, []string{"scope"})
+
+	CandleAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_candle_age_seconds",
+		Help: "Time since the latest stored 5m candle of a symbol closed, as of its latest analysis pass",
+	}, []string{"symbol"})
+
+	DataStale = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tradebot_data_stale",
+		Help: "1 while an account blocks entries on a symbol because its candles are stale",
+	}, []string{"account", "symbol"})
+
+	InvalidSignals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tradebot_invalid_signals_total",
+		Help: "Signals not traded because their entry, stop and target failed validation",
+	}, []string{"account", "symbol"})
+)
+
+// Registry holds every bot metric
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		CandlesRecorded,
+		AnalysisDuration,
+		SignalsGenerated,
+		SignalsRefreshed,
+		OrderFlowSkipped,
+		SignalsRejected,
+		OpenPositions,
+		Balance,
+		APIErrors,
+		APIWeightUsed,
+		APIRateLimited,
+		SymbolSuspended,
+		NetExposure,
+		EventsDropped,
+		PriceWriteQueueDepth,
+		PriceWriteSpilled,
+		PriceWriteRetries,
+		PriceWritesDropped,
+		PriceCorrections,
+		ExchangeDegraded,
+		CandleAge,
+		DataStale,
+		InvalidSignals,
+	)
+}
+
+// Serve exposes the registry on /metrics at addr until ctx is cancelled
+func Serve(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("Serving metrics on %s/metrics", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Metrics server error: %v", err)
+	}
+}
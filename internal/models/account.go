@@ -0,0 +1,4 @@
+package models
+
+// DefaultAccount owns every row written before accounts existed and everything in single-account setups
+const DefaultAccount = "default"
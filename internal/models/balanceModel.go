@@ -6,6 +6,7 @@ import (
 
 type Balance struct {
 	ID      uint    `gorm:"primaryKey"`
+	Account string  `gorm:"index;not null;default:default"`
 	Symbol  string  `gorm:"index;not null"`
 	Balance float64 `gorm:"type:decimal(20,8);not null"`
 
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// BotState is one piece of in-memory bot state kept across restarts, stored as JSON under a key
+// Version is the layout of Value; readers discard rows whose version they do not understand
+type BotState struct {
+	ID      uint   `gorm:"primaryKey"`
+	Account string `gorm:"not null;default:default;uniqueIndex:idx_bot_state"`
+	Key     string `gorm:"not null;uniqueIndex:idx_bot_state"`
+	Version int    `gorm:"not null"`
+	Value   string `gorm:"type:text;not null"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ConfigSnapshot is the build and settings a live account ran with, stored once per hash
+// Positions refer to it through their ConfigHash
+type ConfigSnapshot struct {
+	Hash    string `gorm:"primaryKey"`
+	Version string `gorm:"not null"` // Git revision of the build
+	Config  string `gorm:"type:text;not null"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type PendingOrder struct {
+	ID              uint    `gorm:"primaryKey"`
+	Account         string  `gorm:"index;not null;default:default"`
+	Symbol          string  `gorm:"index;not null"`
+	Side            string  `gorm:"not null"`
+	LimitPrice      float64 `gorm:"type:decimal(20,8);not null"`
+	SignalPrice     float64 `gorm:"type:decimal(20,8);not null"`
+	StopLossPrice   float64 `gorm:"type:decimal(20,8);not null"`
+	TakeProfitPrice float64 `gorm:"type:decimal(20,8);not null"`
+	Confidence      float64 `gorm:"type:decimal(10,4)"`
+	SizeMultiplier  float64 `gorm:"type:decimal(10,4);not null;default:1"` // Factor the fill's size is scaled by for Confidence
+	Confluence      string  `gorm:"type:text"`                             // Per-timeframe breakdown of the signal as JSON
+	EntryContext    string  `gorm:"type:text"`                             // Analysis snapshot of the signal as JSON, passed on to the position
+
+	ExpiresAt  time.Time `gorm:"index;not null"`
+	Status     string    `gorm:"index;not null"`
+	PositionID uint      `gorm:"index"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+const (
+	PendingOrderStatusPending     = "pending"
+	PendingOrderStatusFilled      = "filled"
+	PendingOrderStatusExpired     = "expired"
+	PendingOrderStatusInvalidated = "invalidated"
+	PendingOrderStatusCancelled   = "cancelled" // Symbol removed from rotation or the equity stop tripped
+)
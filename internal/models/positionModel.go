@@ -4,6 +4,7 @@ import "time"
 
 type Position struct {
 	ID         uint    `gorm:"primaryKey"`
+	Account    string  `gorm:"index;not null;default:default"`
 	Symbol     string  `gorm:"index;not null"`
 	Side       string  `gorm:"not null"`
 	Size       float64 `gorm:"type:decimal(20,8);not null"`
@@ -13,11 +14,65 @@ type Position struct {
 	StopLossPrice   float64 `gorm:"type:decimal(20,8);not null"`
 	TakeProfitPrice float64 `gorm:"type:decimal(20,8);not null"`
 
+	// Distance between entry and the stop at open time, kept so stop moves can be measured in R
+	InitialStopDistance float64 `gorm:"type:decimal(20,8)"`
+
+	// Isolated margin liquidation price at open, 0 for positions opened before it was recorded
+	LiquidationPrice float64 `gorm:"type:decimal(20,8)"`
+
+	// Where the entry and close prices came from: the live mark price, the latest candle
+	// close when the mark price was unavailable, or the limit price of a pending order
+	EntryPriceSource string
+	ClosePriceSource string
+
 	PnL float64 `gorm:"type:decimal(20,8)"`
 
-	OpenTime  time.Time `gorm:"index;not null"`
-	CloseTime time.Time `gorm:"index"`
-	Status    string    `gorm:"not null"`
+	// PnL in units of the loss the initial stop stood for, nil while open or without a usable initial stop
+	RMultiple *float64 `gorm:"column:r_multiple;type:decimal(10,4)"`
+
+	// Furthest price went against (MAE) and in favor of (MFE) the position while open,
+	// as price distances from entry and in initial stop distances
+	MAE  float64 `gorm:"type:decimal(20,8)"`
+	MFE  float64 `gorm:"type:decimal(20,8)"`
+	MAER float64 `gorm:"column:mae_r;type:decimal(10,4)"`
+	MFER float64 `gorm:"column:mfe_r;type:decimal(10,4)"`
+
+	// Latest time the monitor checked the open position, where a replay of the candles it missed while
+	// the bot was down starts; zero until the first check
+	CheckedAt time.Time
+
+	// Signal confidence at entry, compared against reversal signals
+	Confidence float64 `gorm:"type:decimal(10,4)"`
+
+	// Per-timeframe breakdown of the entry signal as JSON
+	Confluence string `gorm:"type:text"`
+
+	// Versioned snapshot of the analysis at entry as JSON, see analysis.EntryContext
+	EntryContext string `gorm:"type:text"`
+
+	// Hash of the build and settings the position was opened with, see ConfigSnapshot
+	ConfigHash string `gorm:"index"`
+
+	// Factor the base position size was scaled by for the account's streak at entry
+	RiskMultiplier float64 `gorm:"type:decimal(10,4);not null;default:1"`
+
+	// Factor the base position size was scaled by for the signal's confidence, see analysis.Config.SizeMultiplier
+	ConfidenceMultiplier float64 `gorm:"type:decimal(10,4);not null;default:1"`
+
+	// Size the sizing formula asked for and the guard that changed it into Size, see trading.SizingConfig
+	RequestedSize float64 `gorm:"type:decimal(20,8)"`
+	SizeGuard     string
+
+	// Free-form journal notes written when reviewing the trade, tags are kept in PositionTag
+	Notes string `gorm:"type:text"`
+
+	// Position this one replaced through a reversal, 0 if none
+	ReversedFromID uint `gorm:"index"`
+
+	OpenTime    time.Time `gorm:"index;not null"`
+	CloseTime   time.Time `gorm:"index"`
+	CloseReason string    // take_profit, stop_loss, liquidation, reversal or flatten
+	Status      string    `gorm:"not null"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
@@ -30,4 +85,8 @@ const (
 
 	PositionSideLong  = "long"
 	PositionSideShort = "short"
+
+	PriceSourceMark   = "mark"
+	PriceSourceCandle = "candle"
+	PriceSourceLimit  = "limit"
 )
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PositionTag is one journal tag on a position, each tag at most once per position
+type PositionTag struct {
+	ID         uint   `gorm:"primaryKey"`
+	PositionID uint   `gorm:"not null;uniqueIndex:idx_position_tag"`
+	Tag        string `gorm:"not null;index;uniqueIndex:idx_position_tag"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+// MaxTagLength is the longest journal tag accepted
+const MaxTagLength = 32
@@ -0,0 +1,13 @@
+package models
+
+import "math"
+
+// AmountDecimals is the scale of the decimal(20,8) columns prices, sizes and balances are stored in
+const AmountDecimals = 8
+
+// RoundAmount rounds v to AmountDecimals places, the value the database keeps
+// Rounding before amounts are added up stops float error compounding across trades
+func RoundAmount(v float64) float64 {
+	scale := math.Pow10(AmountDecimals)
+	return math.Round(v*scale) / scale
+}
@@ -0,0 +1,32 @@
+package models
+
+import "testing"
+
+func TestRoundAmountStopsCompounding(t *testing.T) {
+	// Ten fees of 0.1 add up to 0.9999999999999999 in plain floats
+	var raw, rounded float64
+	for range 10 {
+		raw += 0.1
+		rounded = RoundAmount(rounded + RoundAmount(0.1))
+	}
+	if raw == 1 {
+		t.Fatal("plain float addition came out exact, the case below proves nothing")
+	}
+	if rounded != 1 {
+		t.Errorf("rounded balance = %v, want exactly 1", rounded)
+	}
+
+	tests := []struct {
+		in, want float64
+	}{
+		{0.072339999999, 0.07234},
+		{0.123456784, 0.12345678},
+		{0.123456785, 0.12345679},
+		{-2.000000004, -2},
+	}
+	for _, tt := range tests {
+		if got := RoundAmount(tt.in); got != tt.want {
+			t.Errorf("RoundAmount(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
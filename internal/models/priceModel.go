@@ -8,9 +8,10 @@ import (
 
 type Price struct {
 	ID        uint           `gorm:"primaryKey"`
-	Symbol    string         `gorm:"index;not null"`
-	TimeFrame string         `gorm:"not null"`
-	OpenTime  time.Time      `gorm:"index;not null"`
+	Exchange  string         `gorm:"index;uniqueIndex:idx_prices_series,priority:4;not null;default:binance"` // One of Exchanges, the candle was fetched from
+	Symbol    string         `gorm:"index;uniqueIndex:idx_prices_series,priority:1;not null"`
+	TimeFrame string         `gorm:"uniqueIndex:idx_prices_series,priority:2;not null"`
+	OpenTime  time.Time      `gorm:"index;uniqueIndex:idx_prices_series,priority:3;not null"`
 	CloseTime time.Time      `gorm:"index"`
 	Open      float64        `gorm:"type:decimal(20,8)"`
 	Close     float64        `gorm:"type:decimal(20,8)"`
@@ -20,9 +21,56 @@ type Price struct {
 	CreatedAt time.Time      `gorm:"autoCreateTime"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime"`
 	DeletedAt gorm.DeletedAt `gorm:"index"`
+
+	// Trades executed during the candle, 0 for candles stored before it was recorded
+	TradeCount int64
+
+	// IsGapFill marks synthetic candles inserted in memory to bridge missing data
+	IsGapFill bool `gorm:"-"`
+}
+
+// PriceOHLCV is the part of a Price the analysis and backtests read, loaded without the
+// bookkeeping columns so series queries decode less per row
+type PriceOHLCV struct {
+	Symbol     string
+	TimeFrame  string
+	OpenTime   time.Time
+	CloseTime  time.Time
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     float64
+	TradeCount int64
+}
+
+// Price returns the candle as a Price without an ID, so it cannot be updated in place
+func (p PriceOHLCV) Price() Price {
+	return Price{
+		Symbol:     p.Symbol,
+		TimeFrame:  p.TimeFrame,
+		OpenTime:   p.OpenTime,
+		CloseTime:  p.CloseTime,
+		Open:       p.Open,
+		High:       p.High,
+		Low:        p.Low,
+		Close:      p.Close,
+		Volume:     p.Volume,
+		TradeCount: p.TradeCount,
+	}
 }
 
+// Exchanges candles are fetched from; trading runs on Binance's alone
 const (
+	ExchangeBinance = "binance"
+	ExchangeBybit   = "bybit"
+)
+
+// Exchanges lists the exchanges candles can be fetched from
+var Exchanges = []string{ExchangeBinance, ExchangeBybit}
+
+const (
+	PriceTimeFrame1m  = "1m"
 	PriceTimeFrame5m  = "5m"
 	PriceTimeFrame15m = "15m"
 	PriceTimeFrame1h  = "1h"
@@ -30,6 +78,16 @@ const (
 	PriceTimeFrame1d  = "1d"
 )
 
+// TimeFrameDurations maps each timeframe to the length of one candle
+var TimeFrameDurations = map[string]time.Duration{
+	PriceTimeFrame1m:  time.Minute,
+	PriceTimeFrame5m:  5 * time.Minute,
+	PriceTimeFrame15m: 15 * time.Minute,
+	PriceTimeFrame1h:  time.Hour,
+	PriceTimeFrame4h:  4 * time.Hour,
+	PriceTimeFrame1d:  24 * time.Hour,
+}
+
 // TableName sets the table name for Price model
 func (Price) TableName() string {
 	return "prices"
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SignalTally counts how often analysis rejected a symbol for one reason on one UTC day
+type SignalTally struct {
+	ID      uint      `gorm:"primaryKey"`
+	Account string    `gorm:"not null;default:default;uniqueIndex:idx_signal_tally"`
+	Day     time.Time `gorm:"not null;uniqueIndex:idx_signal_tally"` // UTC midnight
+	Symbol  string    `gorm:"not null;uniqueIndex:idx_signal_tally"`
+	Reason  string    `gorm:"not null;uniqueIndex:idx_signal_tally"`
+	Count   int       `gorm:"not null"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
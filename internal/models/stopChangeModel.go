@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// StopChange is one move of an open position's stop loss or take profit, the audit trail of its levels
+type StopChange struct {
+	ID         uint `gorm:"primaryKey"`
+	PositionID uint `gorm:"not null;index"`
+
+	OldStopLoss   float64 `gorm:"type:decimal(20,8)"`
+	NewStopLoss   float64 `gorm:"type:decimal(20,8)"`
+	OldTakeProfit float64 `gorm:"type:decimal(20,8)"`
+	NewTakeProfit float64 `gorm:"type:decimal(20,8)"`
+
+	Price    float64 `gorm:"type:decimal(20,8)"` // Price when the levels moved
+	Reason   string  `gorm:"not null"`           // Who moved them, e.g. the strategy or "manual"
+	Override bool    // The stop was loosened on purpose
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+type SymbolSuspension struct {
+	ID      uint   `gorm:"primaryKey"`
+	Account string `gorm:"index;not null;default:default"`
+	Symbol  string `gorm:"index;not null"`
+	Reason  string `gorm:"not null"`
+
+	// Evidence at the time of suspension
+	Trades     int
+	WinRate    float64 `gorm:"type:decimal(10,4)"`
+	Expectancy float64 `gorm:"type:decimal(20,8)"`
+
+	SuspendedAt time.Time `gorm:"not null"`
+	ResumeAt    time.Time `gorm:"index;not null"`
+	Resumed     bool      `gorm:"not null;default:false"` // Set once the resumption has been announced
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
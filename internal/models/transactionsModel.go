@@ -6,20 +6,31 @@ import (
 
 type Transaction struct {
 	ID         uint    `gorm:"primaryKey"`
-	PositionID uint    `gorm:"index;not null"`
+	PositionID *uint   `gorm:"index"` // Nil for mutations not tied to a position
+	Account    string  `gorm:"index;not null;default:default"`
+	Symbol     string  `gorm:"index;not null"`
 	Type       string  `gorm:"not null"`
 	Amount     float64 `gorm:"type:decimal(20,8);not null"`
 
+	// Balance around the mutation
+	BalanceBefore float64 `gorm:"type:decimal(20,8);not null"`
+	BalanceAfter  float64 `gorm:"type:decimal(20,8);not null"`
+
+	// Set on mutations that must apply once however often they are retried, like a position close
+	IdempotencyKey *string `gorm:"uniqueIndex"`
+
 	// Time
-	CreatedAt time.Time `gorm:"autoCreateTime"`
+	CreatedAt time.Time `gorm:"index;autoCreateTime"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime"`
 
 	// Relationships
-	Position Position `gorm:"foreignKey:PositionID"`
+	Position *Position `gorm:"foreignKey:PositionID"`
 }
 
 const (
-	TransactionTypeDeposit  = "deposit"
-	TransactionTypeWithdraw = "withdraw"
-	TransactionTypeTrade    = "trade"
+	TransactionTypeDeposit    = "deposit"
+	TransactionTypeWithdraw   = "withdraw"
+	TransactionTypeTrade      = "trade"
+	TransactionTypeFunding    = "funding"
+	TransactionTypeAdjustment = "adjustment"
 )
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// UniverseSnapshot is the set of symbols selected for trading on one UTC day, best ranked first
+type UniverseSnapshot struct {
+	ID      uint      `gorm:"primaryKey"`
+	Day     time.Time `gorm:"uniqueIndex;not null"` // UTC midnight
+	Symbols string    `gorm:"type:text;not null"`   // Comma separated
+
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime"`
+}
+
+// SymbolList returns the snapshot's symbols in rank order
+func (s UniverseSnapshot) SymbolList() []string {
+	if s.Symbols == "" {
+		return nil
+	}
+	return strings.Split(s.Symbols, ",")
+}
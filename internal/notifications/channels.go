@@ -0,0 +1,199 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LogChannel writes notifications to the standard logger
+type LogChannel struct{}
+
+func (LogChannel) Name() string { return "log" }
+
+func (LogChannel) Send(ctx context.Context, subject, body string) error {
+	log.Printf("NOTIFY %s: %s", subject, body)
+	return nil
+}
+
+// TelegramChannel posts notifications through the Telegram Bot API
+type TelegramChannel struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+// NewTelegramChannel creates a new instance of TelegramChannel
+func NewTelegramChannel(token, chatID string) *TelegramChannel {
+	return &TelegramChannel{
+		token:  token,
+		chatID: chatID,
+		client: http.DefaultClient,
+	}
+}
+
+func (t *TelegramChannel) Name() string { return "telegram" }
+
+func (t *TelegramChannel) Send(ctx context.Context, subject, body string) error {
+	form := url.Values{
+		"chat_id": {t.chatID},
+		"text":    {subject + "\n" + body},
+	}
+
+	endpoint := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", t.token)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram returned %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailChannel sends notifications as email through an SMTP server
+type EmailChannel struct {
+	addr string // host:port
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+// NewEmailChannel creates a new instance of EmailChannel, auth is skipped when user is empty
+func NewEmailChannel(host, port, user, password, from string, to []string) *EmailChannel {
+	var auth smtp.Auth
+	if user != "" {
+		auth = smtp.PlainAuth("", user, password, host)
+	}
+	return &EmailChannel{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+		to:   to,
+	}
+}
+
+func (e *EmailChannel) Name() string { return "email" }
+
+func (e *EmailChannel) Send(ctx context.Context, subject, body string) error {
+	return e.send(ctx, subject, "text/plain", body)
+}
+
+func (e *EmailChannel) SendHTML(ctx context.Context, subject, html string) error {
+	return e.send(ctx, subject, "text/html", html)
+}
+
+func (e *EmailChannel) send(ctx context.Context, subject, contentType, body string) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n\r\n", contentType)
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	// net/smtp has no context support, so give up waiting once ctx ends
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(e.addr, e.auth, e.from, e.to, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// NewNotifierFromEnv builds a Notifier from environment settings:
+// NOTIFY_QUIET_HOURS ("23-7", UTC), LOG_MIN_SEVERITY,
+// TELEGRAM_BOT_TOKEN / TELEGRAM_CHAT_ID / TELEGRAM_MIN_SEVERITY, and
+// SMTP_HOST / SMTP_PORT / SMTP_USER / SMTP_PASSWORD / SMTP_FROM / SMTP_TO / SMTP_MIN_SEVERITY
+func NewNotifierFromEnv() (*Notifier, error) {
+	quiet, err := parseQuietHours(os.Getenv("NOTIFY_QUIET_HOURS"))
+	if err != nil {
+		return nil, err
+	}
+
+	notifier := NewNotifier(quiet)
+
+	logSeverity, err := envSeverity("LOG_MIN_SEVERITY", SeverityInfo)
+	if err != nil {
+		return nil, err
+	}
+	notifier.AddChannel(LogChannel{}, logSeverity)
+
+	if token := os.Getenv("TELEGRAM_BOT_TOKEN"); token != "" {
+		telegramSeverity, err := envSeverity("TELEGRAM_MIN_SEVERITY", SeverityTrade)
+		if err != nil {
+			return nil, err
+		}
+		notifier.AddChannel(NewTelegramChannel(token, os.Getenv("TELEGRAM_CHAT_ID")), telegramSeverity)
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		emailSeverity, err := envSeverity("SMTP_MIN_SEVERITY", SeverityCritical)
+		if err != nil {
+			return nil, err
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		to := strings.Split(os.Getenv("SMTP_TO"), ",")
+		for i := range to {
+			to[i] = strings.TrimSpace(to[i])
+		}
+		notifier.AddChannel(NewEmailChannel(host, port, os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"),
+			os.Getenv("SMTP_FROM"), to), emailSeverity)
+	}
+
+	return notifier, nil
+}
+
+func envSeverity(key string, fallback Severity) (Severity, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	severity, err := ParseSeverity(value)
+	if err != nil {
+		return fallback, fmt.Errorf("%s: %v", key, err)
+	}
+	return severity, nil
+}
+
+func parseQuietHours(value string) (QuietHours, error) {
+	if value == "" {
+		return QuietHours{}, nil
+	}
+
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return QuietHours{}, fmt.Errorf("NOTIFY_QUIET_HOURS must look like 23-7, got %q", value)
+	}
+
+	start, err1 := strconv.Atoi(strings.TrimSpace(parts[0]))
+	end, err2 := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil || start < 0 || start > 23 || end < 0 || end > 23 {
+		return QuietHours{}, fmt.Errorf("invalid NOTIFY_QUIET_HOURS %q", value)
+	}
+
+	return QuietHours{Enabled: true, StartHour: start, EndHour: end}, nil
+}
@@ -0,0 +1,254 @@
+package notifications
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityTrade
+	SeverityWarning
+	SeverityCritical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityTrade:
+		return "trade"
+	case SeverityWarning:
+		return "warning"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+// ParseSeverity converts a severity name into a Severity
+func ParseSeverity(name string) (Severity, error) {
+	for s := SeverityInfo; s <= SeverityCritical; s++ {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q", name)
+}
+
+type Event struct {
+	Severity Severity
+	Title    string
+	Message  string
+	Symbol   string
+	Account  string  // Account the event belongs to, shown unless it is the default
+	PnL      float64 // Realized PnL for trade close events
+	Time     time.Time
+}
+
+// title returns the event title, naming the account when it is not the default one
+func (e Event) title() string {
+	if e.Account == "" || e.Account == models.DefaultAccount {
+		return e.Title
+	}
+	return fmt.Sprintf("%s (%s)", e.Title, e.Account)
+}
+
+// Channel delivers rendered notifications somewhere
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, subject, body string) error
+}
+
+// HTMLChannel is a Channel that can also deliver an HTML body, such as email
+type HTMLChannel interface {
+	Channel
+	SendHTML(ctx context.Context, subject, html string) error
+}
+
+// QuietHours is a daily UTC window during which info and trade events are batched
+// A window may wrap midnight, e.g. 23 to 7
+type QuietHours struct {
+	Enabled   bool
+	StartHour int
+	EndHour   int
+}
+
+// Contains reports whether t falls inside the quiet window
+func (q QuietHours) Contains(t time.Time) bool {
+	if !q.Enabled || q.StartHour == q.EndHour {
+		return false
+	}
+	hour := t.UTC().Hour()
+	if q.StartHour < q.EndHour {
+		return hour >= q.StartHour && hour < q.EndHour
+	}
+	return hour >= q.StartHour || hour < q.EndHour
+}
+
+type subscription struct {
+	channel     Channel
+	minSeverity Severity
+}
+
+type Notifier struct {
+	mu            sync.Mutex
+	subscriptions []subscription
+	quietHours    QuietHours
+	pending       []Event
+	clock         clock.Clock
+}
+
+// NewNotifier creates a new instance of Notifier
+func NewNotifier(quietHours QuietHours) *Notifier {
+	return &Notifier{
+		quietHours: quietHours,
+		clock:      clock.Real,
+	}
+}
+
+// SetClock replaces the wall clock events are stamped with and the digest is flushed on
+func (n *Notifier) SetClock(clk clock.Clock) {
+	n.clock = clk
+}
+
+// AddChannel subscribes a channel to events at or above minSeverity
+func (n *Notifier) AddChannel(channel Channel, minSeverity Severity) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.subscriptions = append(n.subscriptions, subscription{channel: channel, minSeverity: minSeverity})
+}
+
+// Notify delivers an event, or holds it for the digest during quiet hours
+// Warnings and criticals are always delivered immediately
+func (n *Notifier) Notify(event Event) {
+	if n == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = n.clock.Now()
+	}
+
+	if event.Severity < SeverityWarning && n.quietHours.Contains(event.Time) {
+		n.mu.Lock()
+		n.pending = append(n.pending, event)
+		n.mu.Unlock()
+		return
+	}
+
+	n.deliver(event.Severity, fmt.Sprintf("[%s] %s", event.Severity, event.title()), event.Message)
+}
+
+// Run flushes the quiet-hours digest once the window ends, until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context) {
+	ticker := n.clock.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			n.Flush()
+			return
+		case <-ticker.C():
+			if !n.quietHours.Contains(n.clock.Now()) {
+				n.Flush()
+			}
+		}
+	}
+}
+
+// Flush delivers any batched events as a single digest
+func (n *Notifier) Flush() {
+	n.mu.Lock()
+	pending := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	subject, body, severity := renderDigest(pending)
+	n.deliver(severity, subject, body)
+}
+
+// SendReport delivers a scheduled report to every channel right away
+// Reports are asked for explicitly, so they bypass quiet hours and severity filters;
+// channels that take HTML get html when it is set, the rest get text
+func (n *Notifier) SendReport(subject, text, html string) {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	subscriptions := make([]subscription, len(n.subscriptions))
+	copy(subscriptions, n.subscriptions)
+	n.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		var err error
+		if htmlChannel, ok := sub.channel.(HTMLChannel); ok && html != "" {
+			err = htmlChannel.SendHTML(ctx, subject, html)
+		} else {
+			err = sub.channel.Send(ctx, subject, text)
+		}
+		if err != nil {
+			log.Printf("Error sending report via %s: %v", sub.channel.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// deliver sends to every channel whose minimum severity the event meets
+func (n *Notifier) deliver(severity Severity, subject, body string) {
+	n.mu.Lock()
+	subscriptions := make([]subscription, len(n.subscriptions))
+	copy(subscriptions, n.subscriptions)
+	n.mu.Unlock()
+
+	for _, sub := range subscriptions {
+		if severity < sub.minSeverity {
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := sub.channel.Send(ctx, subject, body); err != nil {
+			log.Printf("Error sending notification via %s: %v", sub.channel.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// renderDigest summarizes batched events, including trade count and PnL
+func renderDigest(events []Event) (string, string, Severity) {
+	var b strings.Builder
+	trades := 0
+	pnl := 0.0
+	severity := SeverityInfo
+
+	for _, e := range events {
+		if e.Severity > severity {
+			severity = e.Severity
+		}
+		if e.Severity == SeverityTrade {
+			trades++
+			pnl += e.PnL
+		}
+		fmt.Fprintf(&b, "%s %s: %s\n", e.Time.UTC().Format("15:04"), e.title(), e.Message)
+	}
+
+	summary := fmt.Sprintf("%d events, %d trades, PnL %.2f USDT\n\n", len(events), trades, pnl)
+	subject := fmt.Sprintf("Quiet hours digest (%s - %s UTC)",
+		events[0].Time.UTC().Format("15:04"), events[len(events)-1].Time.UTC().Format("15:04"))
+
+	return subject, summary + b.String(), severity
+}
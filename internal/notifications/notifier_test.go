@@ -0,0 +1,137 @@
+package notifications
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingChannel keeps the subjects and bodies sent to it
+type recordingChannel struct {
+	mu       sync.Mutex
+	subjects []string
+	bodies   []string
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(ctx context.Context, subject, body string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, subject)
+	c.bodies = append(c.bodies, body)
+	return nil
+}
+
+func (c *recordingChannel) sent() ([]string, []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.subjects...), append([]string(nil), c.bodies...)
+}
+
+// awaitSent blocks until channel got n messages, failing the test after a second
+func awaitSent(t *testing.T, channel *recordingChannel, n int) ([]string, []string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		subjects, bodies := channel.sent()
+		if len(subjects) >= n {
+			return subjects, bodies
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d messages %v, want %d", len(subjects), subjects, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestQuietHoursBatchTradesUntilTheWindowEnds(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(day.Add(22*time.Hour + 58*time.Minute))
+	notifier := NewNotifier(QuietHours{Enabled: true, StartHour: 23, EndHour: 7})
+	notifier.SetClock(clk)
+	all, urgent := &recordingChannel{}, &recordingChannel{}
+	notifier.AddChannel(all, SeverityInfo)
+	notifier.AddChannel(urgent, SeverityWarning)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go notifier.Run(ctx)
+	for clk.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Before the window a trade goes out on its own
+	clk.Set(day.Add(22*time.Hour + 59*time.Minute))
+	notifier.Notify(Event{Severity: SeverityTrade, Title: "Closed BTCUSDT", PnL: 3})
+	awaitSent(t, all, 1)
+
+	// Inside it trades and info wait for the digest, a critical does not
+	clk.Set(day.Add(23*time.Hour + 30*time.Minute))
+	notifier.Notify(Event{Severity: SeverityTrade, Title: "Closed ETHUSDT", Message: "take profit", PnL: 1.5})
+	clk.Set(day.Add(27 * time.Hour))
+	notifier.Notify(Event{Severity: SeverityCritical, Title: "Kill switch", Message: "daily loss limit"})
+	subjects, _ := awaitSent(t, all, 2)
+	if subjects[1] != "[critical] Kill switch" {
+		t.Errorf("second message = %q, want the critical", subjects[1])
+	}
+	if got, _ := awaitSent(t, urgent, 1); got[0] != "[critical] Kill switch" {
+		t.Errorf("warning channel got %q, want the critical", got[0])
+	}
+	clk.Set(day.Add(30*time.Hour + 50*time.Minute))
+	notifier.Notify(Event{Severity: SeverityTrade, Title: "Closed SOLUSDT", Message: "stop loss", PnL: -0.5})
+	notifier.Notify(Event{Severity: SeverityInfo, Title: "Universe refreshed"})
+
+	clk.Set(day.Add(30*time.Hour + 59*time.Minute))
+	if subjects, _ := all.sent(); len(subjects) != 2 {
+		t.Fatalf("got %v before the window ended, want the digest held", subjects)
+	}
+
+	// The first tick past 07:00 delivers one digest of the window
+	clk.Set(day.Add(31*time.Hour + time.Minute))
+	subjects, bodies := awaitSent(t, all, 3)
+	if len(subjects) != 3 {
+		t.Fatalf("got %v, want one digest", subjects)
+	}
+	if want := "Quiet hours digest (23:30 - 06:50 UTC)"; subjects[2] != want {
+		t.Errorf("digest subject = %q, want %q", subjects[2], want)
+	}
+	if !strings.HasPrefix(bodies[2], "3 events, 2 trades, PnL 1.00 USDT") {
+		t.Errorf("digest body = %q", bodies[2])
+	}
+	for _, title := range []string{"Closed ETHUSDT", "Closed SOLUSDT", "Universe refreshed"} {
+		if !strings.Contains(bodies[2], title) {
+			t.Errorf("digest misses %q", title)
+		}
+	}
+	if got, _ := urgent.sent(); len(got) != 1 {
+		t.Errorf("warning channel got %v, want only the critical", got)
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 3, 1, hour, 30, 0, 0, time.UTC) }
+	tests := []struct {
+		name  string
+		quiet QuietHours
+		hour  int
+		want  bool
+	}{
+		{"wrapping, before midnight", QuietHours{true, 23, 7}, 23, true},
+		{"wrapping, after midnight", QuietHours{true, 23, 7}, 6, true},
+		{"wrapping, at the end hour", QuietHours{true, 23, 7}, 7, false},
+		{"same day", QuietHours{true, 1, 5}, 3, true},
+		{"same day, outside", QuietHours{true, 1, 5}, 5, false},
+		{"disabled", QuietHours{false, 23, 7}, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.quiet.Contains(at(tt.hour)); got != tt.want {
+				t.Errorf("Contains(%02d:30) = %v, want %v", tt.hour, got, tt.want)
+			}
+		})
+	}
+}
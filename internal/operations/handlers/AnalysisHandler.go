@@ -1,10 +1,19 @@
 package handlers
 
 import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/metrics"
 	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/operations/priceOperations"
 	"CryptoTradeBot/internal/repositories"
 	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
@@ -12,216 +21,694 @@ import (
 )
 
 const (
-	InitialBalance = 1000.0 // USDT
+	InitialBalance = 1000.0 // Quote asset
 	Leverage       = 50     // Fixed leverage
 	RiskPerTrade   = 0.02   // 2% per trade
 )
 
 type AnalysisHandler struct {
-	analysis     *analysis.Analysis
+	strategies   *strategy.StrategyManager
 	priceRepo    *repositories.PriceRepository
 	positionRepo *repositories.PositionRepository
-	balanceRepo  *repositories.BalanceRepository
+	account      *trading.AccountService
+	orderRepo    *repositories.PendingOrderRepository
+	riskManager  *risk.RiskManager
+	notifier     *notifications.Notifier
+	entryConfig  trading.EntryConfig
+	reversals    trading.ReversalConfig
+	window       int // 5m candles passed to the strategies
+	signals      *signalBoard
+	dedup        *signalDedup // Folds valid signals repeated by later passes into their first occurrence
+	health       *healthBoard
+	signalRepo   *repositories.SignalRepository   // Rejection tallies for reports, nil to skip
+	stateRepo    *repositories.BotStateRepository // Analysis state kept across restarts, nil to skip
+	scaler       *risk.RiskScaler                 // Scales position size by the account's streak, nil for fixed size
+	bus          *events.Bus                      // Wakes analysis on recorded candles, nil to poll
+	closes       *trading.CloseRetries            // Closes that failed, retried by the monitor
+	ticker       MarkPricer                       // Live prices for market fills, nil for the candle close
+	pool         *AnalysisPool                    // Caps concurrent analysis passes, nil for no cap
+	equityStop   *risk.EquityStop                 // Flattens the account on an equity stop-out, nil to disable
+	exchange     *priceOperations.ExchangeHealth  // Blocks entries while Binance is degraded, nil to ignore
+	orderFlow    OrderFlowSource                  // Confirms entries of symbols filtering on order flow, nil for unavailable
+	stale        map[string]bool                  // Symbols warned about a stale price, used by the monitor only
+	managed      map[uint]time.Time               // Latest 5m candle each open position was managed on, see manageStops
+	staleCandles float64                          // Candles old the latest may be before entries are blocked, see BlockStaleData
+	shadow       bool                             // Decisions are only recorded, see RunAsShadow
+	configHash   string                           // Stamped on opened positions, see StampConfig
+	sizing       *trading.SizingConfig            // Bounds opened positions' size, nil for the fixed size
+	breakevenAtR float64                          // Initial stop distances in favor before the stop moves to entry, 0 to never move it
+	backfiller   Backfiller                       // Fills the window of symbols warming up, nil to wait for the recorder
+	warmUp       *warmUp                          // Symbols whose candles do not fill the window yet
+	clock        clock.Clock
+
+	// Entries paused by hand, see Pause
+	pauseMu sync.Mutex
+	pause   PauseState
+
+	// Symbols taking new entries, each with the cancel of its analysis loop
+	symbolsMu sync.Mutex
+	ctx       context.Context // Set by Start, parent of the analysis loops
+	entries   map[string]context.CancelFunc
+	limit     map[string]bool           // Symbols the account may trade, nil for every symbol, see LimitSymbols
+	triggers  map[string]chan time.Time // Wakes each symbol's analysis when subscribed to a bus
+	wg        sync.WaitGroup
+
+	// Symbols listed too recently for the window, each with when its analysis starts, see AwaitHistory
+	historyMu sync.Mutex
+	awaiting  map[string]time.Time
 }
 
 func NewAnalysisHandler(
-	analysis *analysis.Analysis,
+	strategies *strategy.StrategyManager,
 	priceRepo *repositories.PriceRepository,
 	positionRepo *repositories.PositionRepository,
-	balanceRepo *repositories.BalanceRepository,
+	account *trading.AccountService,
+	orderRepo *repositories.PendingOrderRepository,
+	notifier *notifications.Notifier,
+	entryConfig trading.EntryConfig,
+	reversals trading.ReversalConfig,
 ) *AnalysisHandler {
 	return &AnalysisHandler{
-		analysis:     analysis,
+		strategies:   strategies,
 		priceRepo:    priceRepo,
 		positionRepo: positionRepo,
-		balanceRepo:  balanceRepo,
+		account:      account,
+		orderRepo:    orderRepo,
+		riskManager:  risk.NewRiskManager(risk.DefaultVetoTimeout),
+		notifier:     notifier,
+		entryConfig:  entryConfig,
+		reversals:    reversals,
+		window:       strategies.WindowCandles(models.PriceTimeFrame5m),
+		signals:      newSignalBoard(),
+		dedup:        newSignalDedup(DefaultDedupConfig()),
+		health:       newHealthBoard(),
+		warmUp:       newWarmUp(),
+		closes:       trading.NewCloseRetries(),
+		stale:        make(map[string]bool),
+		managed:      make(map[uint]time.Time),
+		staleCandles: DefaultStaleCandles,
+		breakevenAtR: trading.DefaultBreakevenAtR,
+		clock:        clock.Real,
+		entries:      make(map[string]context.CancelFunc),
+		triggers:     make(map[string]chan time.Time),
+		awaiting:     make(map[string]time.Time),
 	}
 }
 
+// SetClock replaces the wall clock, letting tests drive the polling loops
+func (h *AnalysisHandler) SetClock(c clock.Clock) {
+	h.clock = c
+}
+
+// RegisterVeto adds a hook that can block entries before they are opened
+func (h *AnalysisHandler) RegisterVeto(veto risk.TradeVeto) {
+	h.riskManager.RegisterVeto(veto)
+}
+
+// ScaleRisk sizes every new position by the account's recent win or loss streak
+func (h *AnalysisHandler) ScaleRisk(scaler *risk.RiskScaler) {
+	h.scaler = scaler
+}
+
+// riskMultiplier returns the size factor for the next position, 1 without a scaler or when it fails
+// closing holds the PnL of positions closing in the same step, see RiskScaler.Multiplier
+func (h *AnalysisHandler) riskMultiplier(closing ...float64) float64 {
+	if h.scaler == nil {
+		return 1
+	}
+	multiplier, streak, err := h.scaler.Multiplier(closing...)
+	if err != nil {
+		log.Printf("Error scaling risk, using base size: %v", err)
+		return 1
+	}
+	if multiplier != 1 {
+		log.Printf("Position size scaled by %.2f for a streak of %d", multiplier, streak)
+	}
+	return multiplier
+}
+
+// Start monitors every open position and analyzes symbols, plus any added before or
+// after it, for entries until ctx is cancelled
 func (h *AnalysisHandler) Start(ctx context.Context, symbols []string) {
+	// Settle what crossed its levels while the bot was down before monitoring resumes
+	h.recoverOffline(ctx)
+
 	// Start position monitor
 	go h.monitorPositions(ctx)
 
+	if h.bus != nil {
+		go h.dispatchCandles(ctx, h.bus.Subscribe("analysis/"+h.positionRepo.Account(), candleEventBuffer))
+	}
+
 	// Start analysis for each symbol
-	var wg sync.WaitGroup
+	h.symbolsMu.Lock()
+	h.ctx = ctx
 	for _, symbol := range symbols {
-		wg.Add(1)
-		go h.analyzeSymbol(ctx, symbol, &wg)
+		if !h.allowed(symbol) {
+			continue
+		}
+		if _, ok := h.entries[symbol]; !ok {
+			h.entries[symbol] = nil
+		}
 	}
-	wg.Wait()
+	for symbol, cancel := range h.entries {
+		if cancel == nil {
+			h.startSymbol(symbol)
+		}
+	}
+	h.symbolsMu.Unlock()
+
+	<-ctx.Done()
+	h.wg.Wait()
 }
 
 func (h *AnalysisHandler) analyzeSymbol(ctx context.Context, symbol string, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	ticker := time.NewTicker(time.Second * 15)
-	defer ticker.Stop()
+	wake, stop := h.wakeups(symbol)
+	defer stop()
+
+	state := h.loadSymbolState(symbol)
+	saved := state
 
 	for {
 		select {
 		case <-ctx.Done():
+			h.saveSymbolState(symbol, state, &saved)
 			return
-		case <-ticker.C:
-			// Check for existing position
-			positions, err := h.positionRepo.FindOpenPositionsBySymbol(symbol)
-			if err != nil {
-				log.Printf("Error checking positions for %s: %v", symbol, err)
-				continue
-			}
+		case <-wake:
+			// Persist what the previous pass changed, at most once per candle
+			h.saveSymbolState(symbol, state, &saved)
+			h.runPass(ctx, symbol, &state)
+		}
+	}
+}
 
-			// Skip if position exists
-			if len(positions) > 0 {
-				continue
-			}
+// analyzeOnce checks symbol's open position for a reversal, or looks for an entry when flat
+// It fails only when the pass could not run; problems acting on a signal are logged
+func (h *AnalysisHandler) analyzeOnce(ctx context.Context, symbol string, state *symbolState) error {
+	// Nothing opens, reversals included, on candles the recorder stopped updating
+	current, err := h.checkFreshness(symbol)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.stale {
+		h.rejectStale(symbol, current, state)
+		return nil
+	}
 
-			// Get latest prices
-			prices, err := h.priceRepo.GetPricesByTimeFrame(
-				symbol,
-				models.PriceTimeFrame5m,
-				time.Now().AddDate(0, 0, -1),
-				time.Now(),
-			)
-			if err != nil {
-				log.Printf("Error getting prices for %s: %v", symbol, err)
-				continue
-			}
+	// Longer cadences skip the wakes between their candle closes
+	boundary, due, err := h.cadenceDue(symbol, state)
+	if err != nil || !due {
+		return err
+	}
+	if err := h.analyzeDue(ctx, symbol, state); err != nil {
+		return err
+	}
+
+	// The cadence candle only counts as analyzed once its pass succeeded
+	if !boundary.IsZero() {
+		state.LastCadenceClose = boundary
+	}
+	return nil
+}
+
+// analyzeDue is the pass of analyzeOnce once the symbol's cadence is due
+func (h *AnalysisHandler) analyzeDue(ctx context.Context, symbol string, state *symbolState) error {
+	// Check for existing position
+	positions, err := h.positionRepo.FindOpenPositionsBySymbol(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check positions: %v", err)
+	}
+
+	// In one-way mode an open position can only be reversed, not added to;
+	// in hedge mode the opposite leg may still be opened
+	if len(positions) > 0 && !h.entryConfig.HedgeMode {
+		if err := h.checkReversal(ctx, &positions[0], &state.LastReversalCheck); err != nil {
+			log.Printf("Error checking reversal for %s: %v", symbol, err)
+		}
+		return nil
+	}
+	if len(positions) >= 2 {
+		return nil
+	}
+
+	// Skip if a limit entry is already waiting
+	orders, err := h.orderRepo.FindPendingBySymbol(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check pending orders: %v", err)
+	}
+	if len(orders) > 0 {
+		return nil
+	}
+
+	// Get latest prices
+	prices, err := h.recentPrices(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get prices: %v", err)
+	}
+
+	if h.warmingUp(ctx, symbol, prices) {
+		return nil
+	}
 
-			if len(prices) < 10 {
-				continue
+	// Run analysis
+	started := time.Now() // Wall time, this measures the analysis itself
+	result := h.analyze(symbol, prices)
+	metrics.AnalysisDuration.WithLabelValues(symbol).Observe(time.Since(started).Seconds())
+
+	if !result.IsValid {
+		metrics.SignalsRejected.WithLabelValues(symbol, result.Reason).Inc()
+		h.signals.record(h.clock.Now(), result, "")
+		h.tallyRejection(symbol, result.Reason, prices[len(prices)-1].OpenTime, &state.LastTallied)
+	}
+
+	// Execute trade if valid
+	if result.IsValid {
+		repeat := h.observeSignal(result, prices[len(prices)-1].OpenTime)
+
+		if !trading.CanOpen(positions, result.Direction, h.entryConfig.HedgeMode) {
+			h.signals.record(h.clock.Now(), result, result.Direction+" leg already open")
+			return nil
+		}
+
+		if blocked, reason := h.checkVetoes(ctx, result); blocked {
+			if !repeat {
+				log.Printf("Entry for %s vetoed: %s [%s]", symbol, reason, result.Confluence)
 			}
+			metrics.SignalsRejected.WithLabelValues(symbol, "veto").Inc()
+			h.signals.record(h.clock.Now(), result, "vetoed: "+reason)
+			h.tallyRejection(symbol, "veto", prices[len(prices)-1].OpenTime, &state.LastTallied)
+			return nil
+		}
+		h.signals.record(h.clock.Now(), result, "")
 
-			// Run analysis
-			result := h.analysis.Analyze(prices)
-
-			// Execute trade if valid
-			if result.IsValid {
-				if err := h.openPosition(result); err != nil {
-					log.Printf("Error opening position for %s: %v", symbol, err)
-					continue
-				}
-				log.Printf("Opened position for %s: %s at price %.8f",
-					symbol, result.Direction, result.EntryPrice)
+		if h.entryConfig.Mode == trading.EntryModeLimit {
+			if err := h.placeLimitOrder(result); err != nil {
+				log.Printf("Error placing limit order for %s: %v", symbol, err)
 			}
+			return nil
+		}
+
+		fill, source := h.fillAtMarket(result)
+		if _, err := h.openPosition(ctx, fill, source); err != nil {
+			log.Printf("Error opening position for %s: %v", symbol, err)
 		}
 	}
+	return nil
+}
+
+// recentPrices returns the latest 5m candles, as many as the strategies look back over
+func (h *AnalysisHandler) recentPrices(symbol string) ([]models.Price, error) {
+	return h.priceRepo.GetLastNPrices(symbol, models.PriceTimeFrame5m, h.window)
+}
+
+// analyze runs the symbol's strategy over prices and applies its daily bias
+// Without daily candles the result carries no bias and is not filtered by it
+func (h *AnalysisHandler) analyze(symbol string, prices []models.Price) *analysis.AnalysisResult {
+	return h.analyzeWith(symbol, prices, func() ([]models.Price, error) {
+		// One extra candle, the latest stored may still be forming
+		return h.priceRepo.GetLastNPrices(symbol, models.PriceTimeFrame1d, analysis.DailyBiasCandles+1)
+	})
+}
+
+// analyzeWith is analyze with the daily candles from loadDaily, only called for valid results
+func (h *AnalysisHandler) analyzeWith(symbol string, prices []models.Price, loadDaily func() ([]models.Price, error)) *analysis.AnalysisResult {
+	result := h.strategies.Analyze(symbol, prices)
+	if !result.IsValid {
+		return result
+	}
+
+	daily, err := loadDaily()
+	if err != nil {
+		log.Printf("Error getting daily candles for %s: %v", symbol, err)
+		return result
+	}
+	h.strategies.ApplyDailyBias(symbol, result, daily, h.clock.Now())
+	return result
+}
+
+// checkVetoes gives the registered veto hooks a final say on an entry
+func (h *AnalysisHandler) checkVetoes(ctx context.Context, result *analysis.AnalysisResult) (bool, string) {
+	if pause := h.PauseState(); pause.Paused {
+		return true, "paused: " + pause.Reason
+	}
+	if blocked, reason := h.confirmOrderFlow(result); blocked {
+		return true, reason
+	}
+
+	account := risk.Snapshot{Timestamp: h.clock.Now()}
+
+	// Breakers see every balance valued in the main quote asset
+	total, err := h.account.TotalBalance(ctx)
+	if err != nil {
+		return true, fmt.Sprintf("failed to get balance: %v", err)
+	}
+	account.Balance = total
+
+	account.OpenPositions, err = h.positionRepo.FindOpenPositions()
+	if err != nil {
+		return true, fmt.Sprintf("failed to get open positions: %v", err)
+	}
+
+	return h.riskManager.CheckEntry(ctx, result, account)
 }
 
-func (h *AnalysisHandler) openPosition(result *analysis.AnalysisResult) error {
-	// Get current balance
-	balance, err := h.balanceRepo.FindBySymbol("USDT")
+// openPosition opens result's position at its entry price, source recording where that price came from
+func (h *AnalysisHandler) openPosition(ctx context.Context, result *analysis.AnalysisResult, source string) (*models.Position, error) {
+	if err := h.validateResult(result); err != nil {
+		return nil, err
+	}
+
+	// Get the balance the position's PnL is booked in, refusing symbols the account holds none for
+	balance, err := h.account.BalanceFor(result.Symbol)
 	if err != nil {
-		return fmt.Errorf("failed to get balance: %v", err)
+		return nil, fmt.Errorf("failed to get balance: %v", err)
 	}
 
 	// Use the balance variable to log the current balance
-	log.Printf("Current balance: %.2f USDT", balance.Balance)
+	log.Printf("Current balance: %.2f %s", balance.Balance, balance.Symbol)
+	h.recordBalance(ctx)
+
+	position := newPosition(result, h.clock.Now(), h.riskMultiplier())
+	position.EntryPriceSource = source
+	position.ConfigHash = h.configHash
+	if err := h.guardSize(position, balance.Balance); err != nil {
+		return nil, err
+	}
+	h.warnStopBeyondLiquidation(position)
 
+	if err := h.positionRepo.Create(ctx, position); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Opened position for %s: %s at price %.8f (%s) [%s]",
+		result.Symbol, result.Direction, result.EntryPrice, source, result.Confluence)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityTrade,
+		Title:    "Position opened",
+		Message: fmt.Sprintf("%s %s at %.8f (confidence %.2f)\nTimeframes: %s",
+			result.Symbol, result.Direction, result.EntryPrice, result.Confidence, result.Confluence),
+		Symbol:  result.Symbol,
+		Account: h.positionRepo.Account(),
+	})
+
+	return position, nil
+}
+
+// newPosition builds an unsaved position from a signal, opened at now with its size scaled by multiplier
+// and by the signal's confidence
+func newPosition(result *analysis.AnalysisResult, now time.Time, multiplier float64) *models.Position {
 	// Calculate position size using fixed size
 	const FixedSize = 1.0 // $1 per trade
-	positionSize := (FixedSize / result.EntryPrice) * float64(Leverage)
-
-	position := &models.Position{
-		Symbol:          result.Symbol,
-		Side:            result.Direction,
-		Size:            positionSize,
-		Leverage:        Leverage,
-		EntryPrice:      result.EntryPrice,
-		StopLossPrice:   result.StopLoss,
-		TakeProfitPrice: result.TakeProfit,
-		OpenTime:        time.Now(),
-		Status:          models.PositionStatusOpen,
-		PnL:             0,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
+	confidence := result.SizeFactor()
+	positionSize := (FixedSize * multiplier * confidence / result.EntryPrice) * float64(Leverage)
+	liquidationPrice := trading.PositionLiquidationPrice(result.Symbol, result.Direction, result.EntryPrice, positionSize, Leverage)
+
+	return &models.Position{
+		Symbol:               result.Symbol,
+		Side:                 result.Direction,
+		Size:                 positionSize,
+		Leverage:             Leverage,
+		EntryPrice:           result.EntryPrice,
+		StopLossPrice:        result.StopLoss,
+		TakeProfitPrice:      result.TakeProfit,
+		InitialStopDistance:  trading.InitialStopDistance(result.EntryPrice, result.StopLoss),
+		LiquidationPrice:     liquidationPrice,
+		OpenTime:             now,
+		Status:               models.PositionStatusOpen,
+		PnL:                  0,
+		Confidence:           result.Confidence,
+		Confluence:           result.Confluence.JSON(),
+		EntryContext:         result.EntryContext().JSON(),
+		RiskMultiplier:       multiplier,
+		ConfidenceMultiplier: confidence,
+		CreatedAt:            now,
+		UpdatedAt:            now,
 	}
-
-	return h.positionRepo.Create(position)
 }
 
 func (h *AnalysisHandler) monitorPositions(ctx context.Context) {
-	ticker := time.NewTicker(time.Second * 15)
+	ticker := h.clock.NewTicker(time.Second * 15)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if err := h.checkOpenPositions(); err != nil {
+		case <-ticker.C():
+			if err := h.checkPendingOrders(ctx); err != nil {
+				log.Printf("Error checking pending orders: %v", err)
+			}
+			h.retryCloses(ctx)
+			if err := h.checkOpenPositions(ctx); err != nil {
 				log.Printf("Error checking positions: %v", err)
 			}
 		}
 	}
 }
 
-func (h *AnalysisHandler) checkOpenPositions() error {
+func (h *AnalysisHandler) checkOpenPositions(ctx context.Context) error {
 	positions, err := h.positionRepo.FindOpenPositions()
 	if err != nil {
 		return fmt.Errorf("failed to get open positions: %v", err)
 	}
+	metrics.OpenPositions.WithLabelValues(h.positionRepo.Account()).Set(float64(len(positions)))
+
+	// Legs are checked independently, exposure is reported net per symbol
+	metrics.NetExposure.Reset()
+	for symbol, exposure := range trading.NetExposure(positions) {
+		metrics.NetExposure.WithLabelValues(h.positionRepo.Account(), symbol).Set(exposure)
+	}
 
+	if h.checkEquity(ctx, positions) {
+		return nil
+	}
+
+	checked := make([]uint, 0, len(positions))
 	for i := range positions {
-		if err := h.checkPosition(&positions[i]); err != nil {
+		// An exit that already triggered is retried as decided, not re-evaluated
+		if h.closes.Pending(positions[i].ID) {
+			continue
+		}
+		if err := h.checkPosition(ctx, &positions[i]); err != nil {
 			log.Printf("Error checking position %d: %v", positions[i].ID, err)
+			continue
+		}
+		if positions[i].Status == models.PositionStatusOpen {
+			checked = append(checked, positions[i].ID)
 		}
 	}
+	if err := h.positionRepo.Checkpoint(checked, h.clock.Now()); err != nil {
+		log.Printf("Error recording position checks of %s: %v", h.positionRepo.Account(), err)
+	}
+	h.forgetManaged(positions)
 
 	return nil
 }
 
-func (h *AnalysisHandler) checkPosition(position *models.Position) error {
-	latest, err := h.priceRepo.GetLatestPrice(position.Symbol)
+// retryCloses attempts again the closes that failed on an earlier pass and are due
+func (h *AnalysisHandler) retryCloses(ctx context.Context) {
+	for id, pending := range h.closes.Due(h.clock.Now()) {
+		position, err := h.positionRepo.FindByID(id)
+		if err != nil {
+			h.closes.Failed(id, pending, h.clock.Now())
+			log.Printf("Error loading position %d to retry its close: %v", id, err)
+			continue
+		}
+		// The earlier attempt may have landed with only its response lost
+		if position == nil || position.Status != models.PositionStatusOpen {
+			h.closes.Done(id)
+			continue
+		}
+
+		log.Printf("Retrying %s close of position %d (%s), attempt %d", pending.Reason, id, position.Symbol, pending.Attempts+1)
+		position.CloseReason = pending.Reason
+		position.CloseTime = pending.At
+		if err := h.closePosition(ctx, position, pending.Price, pending.PnL); err != nil {
+			log.Printf("Error closing position %d: %v", id, err)
+		}
+	}
+}
+
+func (h *AnalysisHandler) checkPosition(ctx context.Context, position *models.Position) error {
+	latest, err := h.priceRepo.GetLatestPrice(ctx, position.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get price: %v", err)
 	}
 
-	currentPrice := latest.Close
-	shouldClose := false
-	pnl := 0.0
+	h.warnStale(position.Symbol, latest)
+	currentPrice, source := h.markPrice(position.Symbol, latest.Close)
+
+	// Liquidation is checked against the candle's range, but only for candles the position has lived through
+	low, high := currentPrice, currentPrice
+	if !latest.OpenTime.Before(position.OpenTime) {
+		low, high = min(latest.Low, currentPrice), max(latest.High, currentPrice)
+	}
+	if trading.Liquidated(position.Side, position.LiquidationPrice, low, high) {
+		if trading.StopBeyondLiquidation(position.Side, position.StopLossPrice, position.LiquidationPrice) {
+			trading.TrackExcursion(position, low, high, position.LiquidationPrice, position.TakeProfitPrice)
+			position.CloseReason = trading.CloseReasonLiquidation
+			return h.closePosition(ctx, position, position.LiquidationPrice, -positionMargin(position))
+		}
+		// The stop sits inside the liquidation price, the move filled it on the way there
+		trading.TrackExcursion(position, low, high, position.StopLossPrice, position.TakeProfitPrice)
+		position.CloseReason = "stop_loss"
+		position.ClosePriceSource = source
+		return h.closePosition(ctx, position, position.StopLossPrice, calculatePnL(position, position.StopLossPrice))
+	}
 
 	if position.Side == models.PositionSideLong {
-		if currentPrice >= position.TakeProfitPrice || currentPrice <= position.StopLossPrice {
-			pnl = (currentPrice - position.EntryPrice) * position.Size
-			shouldClose = true
+		if trading.PriceGTE(currentPrice, position.TakeProfitPrice) {
+			position.CloseReason = "take_profit"
+		} else if trading.PriceLTE(currentPrice, position.StopLossPrice) {
+			position.CloseReason = "stop_loss"
 		}
 	} else {
-		if currentPrice <= position.TakeProfitPrice || currentPrice >= position.StopLossPrice {
-			pnl = (position.EntryPrice - currentPrice) * position.Size
-			shouldClose = true
+		if trading.PriceLTE(currentPrice, position.TakeProfitPrice) {
+			position.CloseReason = "take_profit"
+		} else if trading.PriceGTE(currentPrice, position.StopLossPrice) {
+			position.CloseReason = "stop_loss"
 		}
 	}
 
-	if shouldClose {
-		return h.closePosition(position, currentPrice, pnl)
+	if position.CloseReason != "" {
+		position.ClosePriceSource = source
+		trading.TrackExcursion(position, low, high, position.StopLossPrice, position.TakeProfitPrice, currentPrice)
+		return h.closePosition(ctx, position, currentPrice, calculatePnL(position, currentPrice))
 	}
 
-	return nil
+	tracked := trading.TrackExcursion(position, low, high)
+	moved := h.applyBreakeven(position, currentPrice)
+	h.manageStops(position, currentPrice)
+	if !moved && !tracked {
+		return nil
+	}
+	position.UpdatedAt = h.clock.Now()
+	return h.positionRepo.Update(position)
+}
+
+// UseBreakeven moves stops to entry after atR initial stop distances in favor, 0 disables
+// It applies to the monitor and to positions recovered at startup
+func (h *AnalysisHandler) UseBreakeven(atR float64) {
+	h.breakevenAtR = atR
 }
 
-func (h *AnalysisHandler) closePosition(position *models.Position, closePrice, pnl float64) error {
-	position.CloseTime = time.Now()
+// applyBreakeven moves the stop to entry once the trade has gone breakevenAtR in its favor
+// It reports whether the stop moved, leaving the position for the caller to save
+func (h *AnalysisHandler) applyBreakeven(position *models.Position, currentPrice float64) bool {
+	if h.breakevenAtR == 0 {
+		return false
+	}
+	if position.InitialStopDistance == 0 {
+		position.InitialStopDistance = trading.InitialStopDistance(position.EntryPrice, position.StopLossPrice)
+	}
+
+	newStop := trading.BreakevenStop(position.Side, position.EntryPrice, position.StopLossPrice,
+		position.InitialStopDistance, currentPrice, h.breakevenAtR)
+	if newStop == position.StopLossPrice {
+		return false
+	}
+
+	log.Printf("Moving %s %s stop to breakeven: %.8f -> %.8f",
+		position.Symbol, position.Side, position.StopLossPrice, newStop)
+
+	position.StopLossPrice = newStop
+	return true
+}
+
+// closePosition marks the position closed and books its PnL in one database transaction
+// A failed close is retried by the monitor with backoff, at the same price and close time
+func (h *AnalysisHandler) closePosition(ctx context.Context, position *models.Position, closePrice, pnl float64) error {
+	if position.CloseTime.IsZero() {
+		position.CloseTime = h.clock.Now()
+	}
 	position.Status = models.PositionStatusClosed
 	position.PnL = pnl
-	position.UpdatedAt = time.Now()
+	trading.RecordRMultiple(position)
+	position.UpdatedAt = h.clock.Now()
+
+	// PnL is booked in the symbol's quote asset
+	quote, err := h.account.QuoteFor(position.Symbol)
+	if err == nil {
+		_, err = h.positionRepo.Close(position, quote)
+	}
+	if errors.Is(err, repositories.ErrPositionNotOpen) {
+		h.closes.Done(position.ID)
+		return err
+	}
+	if err != nil {
+		position.Status = models.PositionStatusOpen
+		retry := h.closes.Failed(position.ID, trading.PendingClose{
+			Reason: position.CloseReason,
+			Price:  closePrice,
+			PnL:    pnl,
+			At:     position.CloseTime,
+		}, h.clock.Now())
+		return fmt.Errorf("failed to close position, retrying at %s: %v", retry.RetryAt.Format("15:04:05"), err)
+	}
+	h.closes.Done(position.ID)
+	h.recordBalance(ctx)
+
+	log.Printf("Position closed (%s): %s %s | Entry: %.8f Exit: %.8f | PnL: %.2f %s",
+		position.CloseReason, position.Symbol, position.Side, position.EntryPrice, closePrice, pnl, quote)
 
-	if err := h.positionRepo.Update(position); err != nil {
-		return fmt.Errorf("failed to update position: %v", err)
+	title := "Position closed"
+	severity := notifications.SeverityTrade
+	if position.CloseReason == trading.CloseReasonLiquidation {
+		title = "Position liquidated"
+		severity = notifications.SeverityWarning
 	}
+	h.notify(notifications.Event{
+		Severity: severity,
+		Title:    title,
+		Message: fmt.Sprintf("%s %s | Entry: %.8f Exit: %.8f | PnL: %.2f %s",
+			position.Symbol, position.Side, position.EntryPrice, closePrice, pnl, quote),
+		Symbol:  position.Symbol,
+		PnL:     pnl,
+		Account: h.positionRepo.Account(),
+	})
 
-	balance, err := h.balanceRepo.FindBySymbol("USDT")
+	return nil
+}
+
+// recordBalance reports the account's balances valued in its main quote asset
+func (h *AnalysisHandler) recordBalance(ctx context.Context) {
+	total, err := h.account.TotalBalance(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get balance: %v", err)
+		log.Printf("Error valuing balances of %s: %v", h.account.Account(), err)
+		return
 	}
+	metrics.Balance.WithLabelValues(h.account.Account()).Set(total)
+}
 
-	balance.Balance += pnl
-	balance.LastUpdated = time.Now()
+// positionMargin returns the isolated margin behind a position, all of which is lost on liquidation
+func positionMargin(position *models.Position) float64 {
+	if position.Leverage <= 0 {
+		return position.EntryPrice * position.Size
+	}
+	return position.EntryPrice * position.Size / float64(position.Leverage)
+}
 
-	if err := h.balanceRepo.Update(balance); err != nil {
-		return fmt.Errorf("failed to update balance: %v", err)
+// warnStopBeyondLiquidation reports a position whose stop loss can never trigger
+func (h *AnalysisHandler) warnStopBeyondLiquidation(position *models.Position) {
+	if !trading.StopBeyondLiquidation(position.Side, position.StopLossPrice, position.LiquidationPrice) {
+		return
 	}
 
-	log.Printf("Position closed: %s %s | Entry: %.8f Exit: %.8f | PnL: %.2f USDT",
-		position.Symbol, position.Side, position.EntryPrice, closePrice, pnl)
+	message := fmt.Sprintf("%s %s stop %.8f is beyond liquidation %.8f, the position will be liquidated first",
+		position.Symbol, position.Side, position.StopLossPrice, position.LiquidationPrice)
+	log.Printf("Warning: %s", message)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityWarning,
+		Title:    "Stop beyond liquidation",
+		Message:  message,
+		Symbol:   position.Symbol,
+		Account:  h.positionRepo.Account(),
+	})
+}
 
-	return nil
+// calculatePnL returns the PnL of a position if it were closed at price
+func calculatePnL(position *models.Position, price float64) float64 {
+	return trading.PositionPnL(position, price)
 }
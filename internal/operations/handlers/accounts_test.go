@@ -0,0 +1,105 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// risingWindow returns n 5m candles of symbol zigzagging up from 100, ending before dbTestStart
+// The default strategy reads a long entry from them
+func risingWindow(symbol string, n int) []models.Price {
+	start := dbTestStart.Add(-time.Duration(n) * 5 * time.Minute)
+	prices := make([]models.Price, n)
+	close := 100.0
+	for i := range prices {
+		open := close
+		if i%2 == 0 {
+			close += 0.2
+		} else {
+			close -= 0.17
+		}
+		prices[i] = models.Price{
+			Symbol:    symbol,
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  start.Add(time.Duration(i) * 5 * time.Minute),
+			CloseTime: start.Add(time.Duration(i+1)*5*time.Minute - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 0.05,
+			Low:       min(open, close) - 0.05,
+			Close:     close,
+			Volume:    10,
+		}
+	}
+	prices[n-1].Volume = 20
+	return prices
+}
+
+func TestAccountsTradeTheSameSignalsIndependently(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	// Two variants of the default strategy on one database, differing only in their target
+	quick, patient := strategy.DefaultParams(), strategy.DefaultParams()
+	quick.TargetProfit, patient.TargetProfit = 0.01, 0.03
+	handlers := map[string]*AnalysisHandler{
+		"quick":   newAccountHandler(t, db, "quick", quick, 1000),
+		"patient": newAccountHandler(t, db, "patient", patient, 500),
+	}
+
+	window := risingWindow("BTCUSDT", 250)
+	entries := make(map[string]*models.Position)
+	for name, h := range handlers {
+		h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+		result := h.analyze("BTCUSDT", window)
+		if !result.IsValid {
+			t.Fatalf("%s read no entry from the window: %s", name, result.Reason)
+		}
+		position, err := h.openPosition(ctx, result, "test")
+		if err != nil {
+			t.Fatalf("%s openPosition() error = %v", name, err)
+		}
+		entries[name] = position
+	}
+	if entries["quick"].EntryPrice != entries["patient"].EntryPrice || entries["quick"].TakeProfitPrice >= entries["patient"].TakeProfitPrice {
+		t.Fatalf("entries %+v and %+v, want one entry price and the quick target nearer", entries["quick"], entries["patient"])
+	}
+
+	// Both handlers read the same candle, 2% up: past the quick target, short of the patient one
+	rally := entries["quick"].EntryPrice * 1.02
+	storeCandle(t, handlers["quick"], "BTCUSDT", dbTestStart.Add(5*time.Minute), rally)
+	for name, h := range handlers {
+		if err := h.checkOpenPositions(ctx); err != nil {
+			t.Fatalf("%s checkOpenPositions() error = %v", name, err)
+		}
+	}
+
+	for _, want := range []struct {
+		account string
+		status  string
+		balance float64
+	}{
+		{"quick", models.PositionStatusClosed, 1000 + calculatePnL(entries["quick"], rally)},
+		{"patient", models.PositionStatusOpen, 500},
+	} {
+		h := handlers[want.account]
+		positions, err := h.positionRepo.FindAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(positions) != 1 || positions[0].ID != entries[want.account].ID || positions[0].Status != want.status {
+			t.Errorf("%s positions = %+v, want only its own, %s", want.account, positions, want.status)
+		}
+		// Balances are stored to 8 decimals
+		if got := usdtBalance(t, h); math.Abs(got-want.balance) > 1e-6 {
+			t.Errorf("%s balance = %v, want %v", want.account, got, want.balance)
+		}
+	}
+}
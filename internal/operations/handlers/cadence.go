@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"time"
+)
+
+// cadenceDue reports whether symbol's analysis cadence has closed a candle not analyzed yet, and that candle's close
+// A 5m cadence analyzes on every wake and returns a zero close; the close is left for the caller to commit
+// to state once the pass over it succeeds, so a failed pass is retried on the next wake
+func (h *AnalysisHandler) cadenceDue(symbol string, state *symbolState) (time.Time, bool, error) {
+	cadence := h.strategies.Cadence(symbol)
+	if cadence == models.PriceTimeFrame5m {
+		return time.Time{}, true, nil
+	}
+	if !cadenceBoundary(cadence, h.clock.Now()).After(state.LastCadenceClose) {
+		return time.Time{}, false, nil
+	}
+
+	latest, err := h.priceRepo.GetLatestPriceByTimeFrame(symbol, models.PriceTimeFrame5m)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get latest price: %v", err)
+	}
+	boundary, due := cadenceClosed(cadence, h.clock.Now(), latest, state.LastCadenceClose)
+	return boundary, due, nil
+}
+
+// cadenceBoundary returns the close of the last cadence candle at now
+func cadenceBoundary(cadence string, now time.Time) time.Time {
+	return now.Truncate(models.TimeFrameDurations[cadence])
+}
+
+// cadenceClosed reports whether a cadence candle closed after last by now, at :00, :15, :30 and :45 for 15m,
+// as soon as latest, the 5m candle completing it, is recorded; it returns that close
+func cadenceClosed(cadence string, now time.Time, latest *models.Price, last time.Time) (time.Time, bool) {
+	boundary := cadenceBoundary(cadence, now)
+	if !boundary.After(last) || latest == nil {
+		return time.Time{}, false
+	}
+	if latest.OpenTime.Add(models.TimeFrameDurations[models.PriceTimeFrame5m]).Before(boundary) {
+		return time.Time{}, false
+	}
+	return boundary, true
+}
@@ -0,0 +1,46 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestFailedCadencePassIsRetried(t *testing.T) {
+	db := testdb.Open(t)
+	params := strategy.DefaultParams()
+	params.Cadence = models.PriceTimeFrame15m
+	h := newAccountHandler(t, db, models.DefaultAccount, params, 1000)
+	boundary := dbTestStart.Add(time.Hour)
+	h.SetClock(clock.NewFake(boundary.Add(10 * time.Second)))
+	storeCandle(t, h, "BTCUSDT", boundary.Add(-5*time.Minute), 100)
+	state := &symbolState{LastCadenceClose: boundary.Add(-15 * time.Minute)}
+	ctx := context.Background()
+
+	// The pass cannot read positions, so the 15m close stays due
+	if err := db.Migrator().RenameTable(&models.Position{}, "positions_away"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.analyzeOnce(ctx, "BTCUSDT", state); err == nil {
+		t.Fatal("analyzeOnce() succeeded without a positions table")
+	}
+	if !state.LastCadenceClose.Equal(boundary.Add(-15 * time.Minute)) {
+		t.Fatalf("LastCadenceClose = %s after a failed pass, want the previous close", state.LastCadenceClose)
+	}
+
+	if err := db.Migrator().RenameTable("positions_away", &models.Position{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.analyzeOnce(ctx, "BTCUSDT", state); err != nil {
+		t.Fatalf("analyzeOnce() retry error = %v", err)
+	}
+	if !state.LastCadenceClose.Equal(boundary) {
+		t.Errorf("LastCadenceClose = %s after the retry, want %s", state.LastCadenceClose, boundary)
+	}
+}
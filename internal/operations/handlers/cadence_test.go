@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+func TestFifteenMinuteCadenceFiresOnQuarterHours(t *testing.T) {
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	last := start.Add(-15 * time.Minute) // The 10:00 close is the first due
+
+	// Wake every minute for an hour; each 5m candle is recorded 10 seconds after it closes
+	var analyzed []string
+	for minute := 0; minute < 60; minute++ {
+		for _, offset := range []time.Duration{0, 10 * time.Second, 30 * time.Second} {
+			now := start.Add(time.Duration(minute)*time.Minute + offset)
+			recorded := now.Add(-10 * time.Second).Truncate(5 * time.Minute).Add(-5 * time.Minute)
+			latest := &models.Price{TimeFrame: models.PriceTimeFrame5m, OpenTime: recorded}
+
+			boundary, due := cadenceClosed(models.PriceTimeFrame15m, now, latest, last)
+			if !due {
+				continue
+			}
+			analyzed = append(analyzed, now.Format("15:04:05"))
+			if !boundary.Equal(now.Truncate(15 * time.Minute)) {
+				t.Errorf("at %s the analyzed close is %s", now.Format("15:04:05"), boundary.Format("15:04"))
+			}
+			last = boundary
+		}
+	}
+
+	want := []string{"10:00:10", "10:15:10", "10:30:10", "10:45:10"}
+	if len(analyzed) != len(want) {
+		t.Fatalf("analyzed at %v, want exactly %v", analyzed, want)
+	}
+	for i := range want {
+		if analyzed[i] != want[i] {
+			t.Errorf("analyzed at %v, want exactly %v", analyzed, want)
+			break
+		}
+	}
+}
+
+func TestCadenceCloseIsRetriedUntilCommitted(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 15, 20, 0, time.UTC)
+	latest := &models.Price{TimeFrame: models.PriceTimeFrame5m, OpenTime: time.Date(2024, 3, 1, 10, 10, 0, 0, time.UTC)}
+	last := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	// A failed pass leaves last as it was, so the next wake is still due for the same close
+	for _, wake := range []time.Duration{0, time.Minute} {
+		boundary, due := cadenceClosed(models.PriceTimeFrame15m, now.Add(wake), latest, last)
+		if !due || !boundary.Equal(last.Add(15*time.Minute)) {
+			t.Errorf("wake at %s = %s, %v, want 10:15 due", now.Add(wake).Format("15:04:05"), boundary.Format("15:04"), due)
+		}
+	}
+	if _, due := cadenceClosed(models.PriceTimeFrame15m, now.Add(time.Minute), latest, last.Add(15*time.Minute)); due {
+		t.Error("a committed close was due again")
+	}
+	if _, due := cadenceClosed(models.PriceTimeFrame15m, now, nil, last); due {
+		t.Error("due without any candle recorded")
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"time"
+)
+
+// candleEventBuffer is how many candle events the analysis dispatcher may fall behind by
+const candleEventBuffer = 64
+
+// SubscribeTo analyzes each symbol when its 5m candle is recorded instead of polling every 15 seconds
+// Call it before Start; without it the polling loop is used
+func (h *AnalysisHandler) SubscribeTo(bus *events.Bus) {
+	h.bus = bus
+}
+
+// wakeups returns the channel that wakes the analysis of symbol and a func releasing it
+// With a bus it delivers the open time of each new candle, otherwise it ticks every 15 seconds
+func (h *AnalysisHandler) wakeups(symbol string) (<-chan time.Time, func()) {
+	if h.bus == nil {
+		ticker := h.clock.NewTicker(time.Second * 15)
+		return ticker.C(), ticker.Stop
+	}
+
+	// One pending wake is enough, the pass reads the latest candles from the database
+	c := make(chan time.Time, 1)
+	h.symbolsMu.Lock()
+	h.triggers[symbol] = c
+	h.symbolsMu.Unlock()
+
+	return c, func() {
+		h.symbolsMu.Lock()
+		defer h.symbolsMu.Unlock()
+		if h.triggers[symbol] == c {
+			delete(h.triggers, symbol)
+		}
+	}
+}
+
+// dispatchCandles wakes the analysis of a symbol once for each new 5m candle, until ctx is cancelled or the bus closes
+func (h *AnalysisHandler) dispatchCandles(ctx context.Context, sub *events.Subscription) {
+	defer h.bus.Unsubscribe(sub)
+
+	// Open time of the last candle dispatched per symbol, so a candle is analyzed once
+	dispatched := make(map[string]time.Time)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if event.TimeFrame != models.PriceTimeFrame5m || !event.Price.OpenTime.After(dispatched[event.Symbol]) {
+				continue
+			}
+
+			h.symbolsMu.Lock()
+			c := h.triggers[event.Symbol]
+			h.symbolsMu.Unlock()
+			if c == nil {
+				continue
+			}
+			dispatched[event.Symbol] = event.Price.OpenTime
+
+			select {
+			case c <- event.Price.OpenTime:
+			default: // A pass is already pending and will see this candle
+			}
+		}
+	}
+}
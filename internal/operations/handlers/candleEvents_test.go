@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"testing"
+	"time"
+)
+
+var eventsStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// candleEvent returns the event for the i-th candle of symbol on timeFrame
+func candleEvent(symbol, timeFrame string, i int) events.CandleClosed {
+	openTime := eventsStart.Add(time.Duration(i) * 5 * time.Minute)
+	return events.CandleClosed{Symbol: symbol, TimeFrame: timeFrame,
+		Price: models.Price{Symbol: symbol, TimeFrame: timeFrame, OpenTime: openTime}}
+}
+
+// wakeCount reads c until it stays quiet and returns the open times it delivered
+func wakeCount(c <-chan time.Time) []time.Time {
+	var got []time.Time
+	for {
+		select {
+		case openTime := <-c:
+			got = append(got, openTime)
+		case <-time.After(50 * time.Millisecond):
+			return got
+		}
+	}
+}
+
+func TestCandleEventsWakeEachSymbolOncePerCandle(t *testing.T) {
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewAnalysisHandler(strategies, nil, nil, nil, nil, nil, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	bus := events.NewBus()
+	h.SubscribeTo(bus)
+
+	btc, releaseBTC := h.wakeups("BTCUSDT")
+	defer releaseBTC()
+	eth, releaseETH := h.wakeups("ETHUSDT")
+	defer releaseETH()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := bus.Subscribe("analysis", candleEventBuffer)
+	dispatched := make(chan struct{})
+	go func() {
+		h.dispatchCandles(ctx, sub)
+		close(dispatched)
+	}()
+
+	// Each candle is announced twice, as after a recorder retry, and a 1h candle wakes nothing
+	for i := 1; i <= 3; i++ {
+		bus.Publish(candleEvent("BTCUSDT", models.PriceTimeFrame5m, i))
+		bus.Publish(candleEvent("BTCUSDT", models.PriceTimeFrame5m, i))
+		bus.Publish(candleEvent("BTCUSDT", models.PriceTimeFrame1h, i))
+		if got := wakeCount(btc); len(got) != 1 || !got[0].Equal(candleEvent("BTCUSDT", "", i).Price.OpenTime) {
+			t.Errorf("candle %d woke BTCUSDT %d times (%v), want once at its open time", i, len(got), got)
+		}
+	}
+	// An older candle arriving late is not analyzed again
+	bus.Publish(candleEvent("BTCUSDT", models.PriceTimeFrame5m, 2))
+	if got := wakeCount(btc); len(got) != 0 {
+		t.Errorf("a late older candle woke BTCUSDT %d times, want none", len(got))
+	}
+	if got := wakeCount(eth); len(got) != 0 {
+		t.Errorf("BTCUSDT candles woke ETHUSDT %d times, want none", len(got))
+	}
+
+	// A symbol busy with a pass keeps one pending wake, the dispatcher never waits for it
+	for i := 1; i <= 10; i++ {
+		bus.Publish(candleEvent("ETHUSDT", models.PriceTimeFrame5m, i))
+	}
+	bus.Publish(candleEvent("BTCUSDT", models.PriceTimeFrame5m, 4))
+	if got := wakeCount(btc); len(got) != 1 {
+		t.Errorf("BTCUSDT woke %d times behind a busy ETHUSDT, want once", len(got))
+	}
+	if got := wakeCount(eth); len(got) != 1 {
+		t.Errorf("a busy ETHUSDT has %d pending wakes, want one", len(got))
+	}
+
+	bus.Close()
+	select {
+	case <-dispatched:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop when the bus closed")
+	}
+}
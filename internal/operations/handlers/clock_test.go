@@ -0,0 +1,75 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+// eventually fails t unless cond holds within a few seconds of real time
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestFakeClockDrivesPositionMonitor(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(10 * time.Minute))
+	h.SetClock(clk)
+
+	// Exits at 90 and 110, the first candle leaves it open
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 105)
+	stored := func() *models.Position {
+		p, err := h.positionRepo.FindByID(position.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.monitorPositions(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	eventually(t, "the monitor's ticker", func() bool { return clk.Waiters() == 1 })
+
+	// Short of the 15s period nothing is checked
+	clk.Advance(14 * time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if p := stored(); !p.CheckedAt.IsZero() {
+		t.Fatalf("checked at %s before the first tick", p.CheckedAt)
+	}
+
+	// The first tick checks the position at the fake time
+	clk.Advance(time.Second)
+	first := dbTestStart.Add(10*time.Minute + 15*time.Second)
+	eventually(t, "the first check", func() bool { return stored().CheckedAt.Equal(first) })
+	if p := stored(); p.Status != models.PositionStatusOpen {
+		t.Fatalf("closed by %s at 105", p.CloseReason)
+	}
+
+	// The next candle takes the target, the next tick closes it and stamps the close with the fake time
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 111)
+	clk.Advance(15 * time.Second)
+	eventually(t, "the close", func() bool { return stored().Status == models.PositionStatusClosed })
+	if p := stored(); p.CloseReason != "take_profit" || !p.CloseTime.Equal(first.Add(15*time.Second)) {
+		t.Errorf("closed by %s at %s, want take_profit at %s", p.CloseReason, p.CloseTime, first.Add(15*time.Second))
+	}
+}
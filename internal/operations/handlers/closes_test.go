@@ -0,0 +1,153 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"errors"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// failWrites makes the next failures writes of kind ("create" or "update") to table fail
+func failWrites(t *testing.T, db *gorm.DB, kind, table string, failures int32) {
+	t.Helper()
+	remaining := atomic.Int32{}
+	remaining.Store(failures)
+	inject := func(tx *gorm.DB) {
+		if tx.Statement.Table == table && remaining.Add(-1) >= 0 {
+			tx.AddError(errors.New("injected failure"))
+		}
+	}
+
+	name := "test:fail_" + kind + "_" + table
+	var err error
+	switch kind {
+	case "create":
+		err = db.Callback().Create().Before("gorm:create").Register(name, inject)
+		t.Cleanup(func() { db.Callback().Create().Remove(name) })
+	case "update":
+		err = db.Callback().Update().Before("gorm:update").Register(name, inject)
+		t.Cleanup(func() { db.Callback().Update().Remove(name) })
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// closeTransactions counts the trade transactions booked for position
+func closeTransactions(t *testing.T, db *gorm.DB, position *models.Position) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Model(&models.Transaction{}).Where("position_id = ?", position.ID).Count(&n).Error; err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestFailedClosesAreRetriedWithoutDoubleCounting(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     string
+		table    string
+		failures int32
+	}{
+		{"position write fails", "update", "positions", 1},
+		{"balance write fails", "update", "balances", 1},
+		{"transaction record fails", "create", "transactions", 1},
+		{"fails twice", "update", "balances", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, db := newDBHandler(t, 1000)
+			clk := clock.NewFake(dbTestStart.Add(time.Hour))
+			h.SetClock(clk)
+			ctx := context.Background()
+			position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+			position.CloseReason = "take_profit"
+
+			failWrites(t, db, tt.kind, tt.table, tt.failures)
+			if err := h.closePosition(ctx, position, 110, 10); err == nil {
+				t.Fatal("closePosition() succeeded through an injected failure")
+			}
+			// The whole close rolled back
+			if got := usdtBalance(t, h); got != 1000 {
+				t.Fatalf("balance after the failed close = %v, want 1000", got)
+			}
+			if stored, err := h.positionRepo.FindByID(position.ID); err != nil || stored.Status != models.PositionStatusOpen {
+				t.Fatalf("position after the failed close = %+v, %v, want still open", stored, err)
+			}
+
+			// The monitor retries on later ticks once the backoff has passed
+			for attempt := 0; attempt < 5 && h.closes.Pending(position.ID); attempt++ {
+				clk.Advance(trading.CloseRetryMax)
+				h.retryCloses(ctx)
+			}
+			if h.closes.Pending(position.ID) {
+				t.Fatal("the close is still pending after the failures cleared")
+			}
+
+			if got := usdtBalance(t, h); math.Abs(got-1010) > 1e-6 {
+				t.Errorf("balance after the retries = %v, want 1010", got)
+			}
+			if n := closeTransactions(t, db, position); n != 1 {
+				t.Errorf("%d transactions booked for the close, want 1", n)
+			}
+			stored, err := h.positionRepo.FindByID(position.ID)
+			if err != nil || stored.Status != models.PositionStatusClosed || !stored.CloseTime.Equal(dbTestStart.Add(time.Hour)) {
+				t.Errorf("position after the retries = %+v, %v, want closed at the time first decided", stored, err)
+			}
+		})
+	}
+}
+
+func TestRetryingALandedCloseBooksNothing(t *testing.T) {
+	h, db := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(time.Hour))
+	h.SetClock(clk)
+	ctx := context.Background()
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	position.CloseReason = "take_profit"
+
+	if err := h.closePosition(ctx, position, 110, 10); err != nil {
+		t.Fatal(err)
+	}
+
+	// The close committed but its response was lost, so the monitor believes it failed
+	h.closes.Failed(position.ID, trading.PendingClose{Reason: "take_profit", Price: 110, PnL: 10, At: clk.Now()}, clk.Now())
+	clk.Advance(trading.CloseRetryMax)
+	h.retryCloses(ctx)
+	if h.closes.Pending(position.ID) {
+		t.Error("the landed close is still pending")
+	}
+
+	// Even a close that reaches the booking again finds its key applied
+	if err := db.Model(&models.Position{}).Where("id = ?", position.ID).Update("status", models.PositionStatusOpen).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.positionRepo.Close(position, "USDT"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := usdtBalance(t, h); math.Abs(got-1010) > 1e-6 {
+		t.Errorf("balance = %v, want 1010 booked once", got)
+	}
+	if n := closeTransactions(t, db, position); n != 1 {
+		t.Errorf("%d transactions booked for the close, want 1", n)
+	}
+	var key string
+	if err := db.Model(&models.Transaction{}).Where("position_id = ?", position.ID).Pluck("idempotency_key", &key).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := repositories.CloseKey(position.ID, "take_profit"); key != want {
+		t.Errorf("transaction key = %q, want %q", key, want)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	pauseStateKey     = "control/pause"
+	pauseStateVersion = 1
+)
+
+// PauseState is whether an account's entries are paused by hand, kept across restarts
+type PauseState struct {
+	Paused bool      `json:"paused"`
+	Since  time.Time `json:"since,omitempty"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Pause stops the account taking new entries and cancels its resting limit entries
+// Open positions keep being monitored and closed; pausing a paused account does nothing
+func (h *AnalysisHandler) Pause(reason string) error {
+	h.pauseMu.Lock()
+	defer h.pauseMu.Unlock()
+
+	if h.pause.Paused {
+		return nil
+	}
+	state := PauseState{Paused: true, Since: h.clock.Now(), Reason: reason}
+	if err := h.savePause(state); err != nil {
+		return err
+	}
+	h.pause = state
+	h.cancelPendingOrders("pause")
+
+	h.announcePause("Trading paused", fmt.Sprintf("New entries blocked (%s), open positions are still monitored", reason))
+	return nil
+}
+
+// Resume lets a paused account take new entries again
+func (h *AnalysisHandler) Resume() error {
+	h.pauseMu.Lock()
+	defer h.pauseMu.Unlock()
+
+	if !h.pause.Paused {
+		return nil
+	}
+	if err := h.savePause(PauseState{}); err != nil {
+		return err
+	}
+	since := h.pause.Since
+	h.pause = PauseState{}
+
+	h.announcePause("Trading resumed", fmt.Sprintf("New entries allowed again after %s paused",
+		h.clock.Now().Sub(since).Round(time.Second)))
+	return nil
+}
+
+// TogglePause pauses a running account for reason or resumes a paused one
+func (h *AnalysisHandler) TogglePause(reason string) error {
+	if h.PauseState().Paused {
+		return h.Resume()
+	}
+	return h.Pause(reason)
+}
+
+// PauseState returns whether the account is paused, and since when and why
+func (h *AnalysisHandler) PauseState() PauseState {
+	h.pauseMu.Lock()
+	defer h.pauseMu.Unlock()
+	return h.pause
+}
+
+// FlattenAndPause pauses the account, so nothing new opens meanwhile, then closes every open position
+func (h *AnalysisHandler) FlattenAndPause(ctx context.Context, reason string) (*FlattenReport, error) {
+	if err := h.Pause(reason); err != nil {
+		return nil, err
+	}
+	return h.Flatten(ctx)
+}
+
+// restorePause loads the pause saved before a restart
+func (h *AnalysisHandler) restorePause() {
+	var state PauseState
+	found, err := h.stateRepo.Load(pauseStateKey, pauseStateVersion, &state)
+	if err != nil {
+		log.Printf("Error loading pause state of %s: %v", h.positionRepo.Account(), err)
+		return
+	}
+	if !found || !state.Paused {
+		return
+	}
+
+	h.pauseMu.Lock()
+	h.pause = state
+	h.pauseMu.Unlock()
+	log.Printf("%s restored paused since %s: %s", h.positionRepo.Account(), state.Since.UTC().Format(time.RFC3339), state.Reason)
+}
+
+// savePause persists state, without a state repository it is kept in memory only
+func (h *AnalysisHandler) savePause(state PauseState) error {
+	if h.stateRepo == nil {
+		return nil
+	}
+	if !state.Paused {
+		return h.stateRepo.Delete(pauseStateKey)
+	}
+	if err := h.stateRepo.Save(pauseStateKey, pauseStateVersion, state); err != nil {
+		return fmt.Errorf("failed to save pause state: %v", err)
+	}
+	return nil
+}
+
+// announcePause logs and notifies a pause change
+func (h *AnalysisHandler) announcePause(title, message string) {
+	log.Printf("%s (%s): %s", title, h.positionRepo.Account(), message)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityWarning,
+		Title:    title,
+		Message:  message,
+		Account:  h.positionRepo.Account(),
+	})
+}
@@ -0,0 +1,109 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPausedAccountRejectsEntriesAndKeepsClosing(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	ctx := context.Background()
+
+	result := h.analyze("BTCUSDT", risingWindow("BTCUSDT", 250))
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	position := storePosition(t, h, "ETHUSDT", models.PositionSideLong, 100, 1)
+
+	if err := h.Pause("manual"); err != nil {
+		t.Fatal(err)
+	}
+	if blocked, reason := h.checkVetoes(ctx, result); !blocked || reason != "paused: manual" {
+		t.Errorf("checkVetoes() while paused = %v, %q, want the entry rejected", blocked, reason)
+	}
+
+	// Monitoring carries on: the open position still closes at its target
+	storeCandle(t, h, "ETHUSDT", dbTestStart.Add(5*time.Minute), position.TakeProfitPrice+1)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	if closed, err := h.positionRepo.FindByID(position.ID); err != nil || closed.Status != models.PositionStatusClosed {
+		t.Errorf("position = %+v, %v, want closed while paused", closed, err)
+	}
+
+	if err := h.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if blocked, reason := h.checkVetoes(ctx, result); blocked && strings.HasPrefix(reason, "paused") {
+		t.Errorf("checkVetoes() after resuming = %q, want the pause lifted", reason)
+	}
+}
+
+func TestFlattenClosesEachPositionOnceUnderConcurrentMonitoring(t *testing.T) {
+	h, db := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	ctx := context.Background()
+
+	// Every position is past its target, so the monitor and the flatten both try to close each one
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT", "BNBUSDT", "ADAUSDT"}
+	var positions []*models.Position
+	for _, symbol := range symbols {
+		position := storePosition(t, h, symbol, models.PositionSideLong, 100, 1)
+		storeCandle(t, h, symbol, dbTestStart.Add(5*time.Minute), position.TakeProfitPrice+1)
+		positions = append(positions, position)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				if err := h.checkOpenPositions(ctx); err != nil {
+					t.Errorf("checkOpenPositions() error = %v", err)
+				}
+			}
+		}()
+	}
+	report, err := h.FlattenAndPause(ctx, "emergency")
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("FlattenAndPause() error = %v", err)
+	}
+	if len(report.Remaining) > 0 {
+		t.Errorf("flatten left %v open", report.Remaining)
+	}
+	if pause := h.PauseState(); !pause.Paused || pause.Reason != "emergency" {
+		t.Errorf("PauseState() = %+v, want paused for the emergency", pause)
+	}
+
+	// Each position closed and booked exactly once, whichever got to it first
+	total := 0.0
+	for _, position := range positions {
+		closed, err := h.positionRepo.FindByID(position.ID)
+		if err != nil || closed.Status != models.PositionStatusClosed {
+			t.Fatalf("position %d = %+v, %v, want closed", position.ID, closed, err)
+		}
+		var transactions []models.Transaction
+		if err := db.Where("position_id = ?", position.ID).Find(&transactions).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(transactions) != 1 {
+			t.Errorf("position %d booked %d times, want once", position.ID, len(transactions))
+		}
+		total += closed.PnL
+	}
+	// Balances are stored to 8 decimals
+	if got := usdtBalance(t, h); math.Abs(got-(1000+total)) > 1e-6 {
+		t.Errorf("balance = %v, want 1000 plus the %v the positions closed for", got, total)
+	}
+}
@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/services/analysis"
+	"container/list"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Signal deduplication defaults, see DedupConfig
+const (
+	DefaultDedupWindow          = 30 * time.Minute
+	DefaultDedupConfidenceDelta = 0.05
+	DefaultDedupZone            = 0.002 // 0.2% of the price
+	DefaultDedupSize            = 256
+)
+
+// DedupConfig sets how a valid signal repeated by later analysis passes is folded into its first occurrence
+type DedupConfig struct {
+	Window          time.Duration // How long after it was last seen a setup suppresses its repeats, 0 to disable
+	ConfidenceDelta float64       // Confidence change since the last announcement that makes a repeat a material change
+	Zone            float64       // Width of the entry zones prices are rounded to, a fraction of the price
+	Size            int           // Setups remembered, the least recently seen are forgotten beyond it
+}
+
+// DefaultDedupConfig returns the deduplication the live analysis runs with
+func DefaultDedupConfig() DedupConfig {
+	return DedupConfig{
+		Window:          DefaultDedupWindow,
+		ConfidenceDelta: DefaultDedupConfidenceDelta,
+		Zone:            DefaultDedupZone,
+		Size:            DefaultDedupSize,
+	}
+}
+
+// Validate rejects negative settings
+func (c DedupConfig) Validate() error {
+	if c.Window < 0 || c.ConfidenceDelta < 0 || c.Zone < 0 || c.Size < 0 {
+		return fmt.Errorf("signal dedup settings cannot be negative")
+	}
+	return nil
+}
+
+// signalFingerprint identifies a setup: its symbol, direction, entry zone and the candle it triggered on
+type signalFingerprint struct {
+	Symbol    string
+	Direction string
+	Zone      int64
+	Candle    time.Time
+}
+
+// setupKey is a fingerprint without its candle, what a setup staying valid over later candles keeps
+type setupKey struct {
+	Symbol    string
+	Direction string
+	Zone      int64
+}
+
+// signalOccurrence tells how a valid signal relates to the setups seen before it
+type signalOccurrence int
+
+const (
+	signalFirst     signalOccurrence = iota // Not seen within the window
+	signalChanged                           // A repeat whose direction flipped or confidence moved beyond the delta
+	signalRefreshed                         // A repeat of a setup already announced
+)
+
+// seenSignal is a remembered setup
+type seenSignal struct {
+	Fingerprint signalFingerprint // Candle is where the setup first triggered
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Confidence  float64 // As last announced
+	Refreshes   int     // Repeats suppressed since the first occurrence
+}
+
+// signalDedup is a small LRU of the setups seen lately, keyed by fingerprint less its candle
+type signalDedup struct {
+	config DedupConfig
+
+	mu        sync.Mutex
+	order     *list.List // Of *dedupEntry, most recently seen first
+	seen      map[setupKey]*list.Element
+	direction map[string]string // Direction of each symbol's latest signal
+}
+
+// dedupEntry is the value held by the LRU list
+type dedupEntry struct {
+	key    setupKey
+	signal seenSignal
+}
+
+func newSignalDedup(config DedupConfig) *signalDedup {
+	return &signalDedup{
+		config:    config,
+		order:     list.New(),
+		seen:      make(map[setupKey]*list.Element),
+		direction: make(map[string]string),
+	}
+}
+
+// zone rounds price to the index of its entry zone, zones growing with the price so the width stays relative
+func (d *signalDedup) zone(price float64) int64 {
+	if price <= 0 || d.config.Zone <= 0 {
+		return 0
+	}
+	return int64(math.Floor(math.Log(price) / math.Log1p(d.config.Zone)))
+}
+
+// fingerprint returns the fingerprint of result triggered on the candle opening at candle
+func (d *signalDedup) fingerprint(result *analysis.AnalysisResult, candle time.Time) signalFingerprint {
+	return signalFingerprint{
+		Symbol:    result.Symbol,
+		Direction: result.Direction,
+		Zone:      d.zone(result.EntryPrice),
+		Candle:    candle,
+	}
+}
+
+// observe records the valid result seen at now on candle, returning how it relates to earlier signals
+// and the setup as remembered after it
+func (d *signalDedup) observe(result *analysis.AnalysisResult, candle, now time.Time) (signalOccurrence, seenSignal) {
+	fingerprint := d.fingerprint(result, candle)
+	if d.config.Window <= 0 {
+		return signalFirst, seenSignal{Fingerprint: fingerprint, FirstSeen: now, LastSeen: now, Confidence: result.Confidence}
+	}
+	key := setupKey{Symbol: fingerprint.Symbol, Direction: fingerprint.Direction, Zone: fingerprint.Zone}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	flipped := d.direction[result.Symbol] != "" && d.direction[result.Symbol] != result.Direction
+	d.direction[result.Symbol] = result.Direction
+
+	element, ok := d.seen[key]
+	if ok {
+		entry := element.Value.(*dedupEntry)
+		if now.Sub(entry.signal.LastSeen) > d.config.Window {
+			d.order.Remove(element)
+			delete(d.seen, key)
+			ok = false
+		}
+	}
+	if !ok {
+		signal := seenSignal{Fingerprint: fingerprint, FirstSeen: now, LastSeen: now, Confidence: result.Confidence}
+		d.seen[key] = d.order.PushFront(&dedupEntry{key: key, signal: signal})
+		d.evict()
+		return signalFirst, signal
+	}
+
+	entry := element.Value.(*dedupEntry)
+	d.order.MoveToFront(element)
+	entry.signal.LastSeen = now
+	if flipped || math.Abs(result.Confidence-entry.signal.Confidence) > d.config.ConfidenceDelta {
+		entry.signal.Confidence = result.Confidence
+		return signalChanged, entry.signal
+	}
+	entry.signal.Refreshes++
+	return signalRefreshed, entry.signal
+}
+
+// evict forgets the least recently seen setups beyond the configured size, the caller holds mu
+func (d *signalDedup) evict() {
+	size := d.config.Size
+	if size <= 0 {
+		size = DefaultDedupSize
+	}
+	for d.order.Len() > size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.seen, oldest.Value.(*dedupEntry).key)
+	}
+}
+
+// forget drops every setup of symbol, e.g. once it leaves the traded symbols
+func (d *signalDedup) forget(symbol string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, element := range d.seen {
+		if key.Symbol == symbol {
+			d.order.Remove(element)
+			delete(d.seen, key)
+		}
+	}
+	delete(d.direction, symbol)
+}
+
+// DedupSignals replaces how repeated valid signals are suppressed, a zero Window announcing every one
+func (h *AnalysisHandler) DedupSignals(config DedupConfig) {
+	h.dedup = newSignalDedup(config)
+}
+
+// observeSignal folds a valid result into the setups seen lately, announcing it on its first occurrence
+// and on material changes and counting it as refreshed otherwise. It reports whether it was suppressed
+func (h *AnalysisHandler) observeSignal(result *analysis.AnalysisResult, candle time.Time) bool {
+	occurrence, seen := h.dedup.observe(result, candle, h.clock.Now())
+	h.signals.refreshed(result.Symbol, seen)
+	if occurrence == signalRefreshed {
+		metrics.SignalsRefreshed.WithLabelValues(result.Symbol).Inc()
+		return true
+	}
+
+	metrics.SignalsGenerated.WithLabelValues(result.Symbol).Inc()
+	title := fmt.Sprintf("%s %s setup", result.Symbol, result.Direction)
+	message := fmt.Sprintf("Entry %.8f, stop %.8f, target %.8f, confidence %.2f [%s]",
+		result.EntryPrice, result.StopLoss, result.TakeProfit, result.Confidence, result.Confluence)
+	if occurrence == signalChanged {
+		title += " changed"
+		message = fmt.Sprintf("%s, valid since %s", message, seen.FirstSeen.UTC().Format("15:04"))
+	}
+	log.Printf("%s: %s", title, message)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityInfo,
+		Title:    title,
+		Message:  message,
+		Symbol:   result.Symbol,
+		Account:  h.positionRepo.Account(),
+	})
+	return false
+}
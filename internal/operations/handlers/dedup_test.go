@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+var dedupStart = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// setup returns a valid result of symbol in direction entering at entry with confidence
+func setup(symbol, direction string, entry, confidence float64) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{Symbol: symbol, Direction: direction, EntryPrice: entry, Confidence: confidence, IsValid: true}
+}
+
+func TestSignalDedupObserve(t *testing.T) {
+	d := newSignalDedup(DefaultDedupConfig())
+	candle := dedupStart.Add(-5 * time.Minute)
+
+	steps := []struct {
+		name   string
+		result *analysis.AnalysisResult
+		after  time.Duration // Since dedupStart
+		want   signalOccurrence
+	}{
+		{"first occurrence", setup("BTCUSDT", models.PositionSideLong, 100, 0.8), 0, signalFirst},
+		{"same pass again", setup("BTCUSDT", models.PositionSideLong, 100, 0.8), 15 * time.Second, signalRefreshed},
+		{"within the entry zone", setup("BTCUSDT", models.PositionSideLong, 99.95, 0.82), time.Minute, signalRefreshed},
+		{"confidence beyond the delta", setup("BTCUSDT", models.PositionSideLong, 100, 0.9), 2 * time.Minute, signalChanged},
+		{"other symbol", setup("ETHUSDT", models.PositionSideLong, 100, 0.8), 2 * time.Minute, signalFirst},
+		{"direction flipped", setup("BTCUSDT", models.PositionSideShort, 100, 0.9), 3 * time.Minute, signalFirst},
+		{"flipped back", setup("BTCUSDT", models.PositionSideLong, 100, 0.9), 4 * time.Minute, signalChanged},
+		{"entry moved out of its zone", setup("BTCUSDT", models.PositionSideLong, 101, 0.9), 5 * time.Minute, signalFirst},
+		{"unseen for longer than the window", setup("ETHUSDT", models.PositionSideLong, 100, 0.8), 40 * time.Minute, signalFirst},
+	}
+	for _, step := range steps {
+		if got, _ := d.observe(step.result, candle, dedupStart.Add(step.after)); got != step.want {
+			t.Errorf("%s: observe() = %d, want %d", step.name, got, step.want)
+		}
+	}
+
+	// Refreshes count the repeats since the first occurrence, kept across a material change
+	_, seen := d.observe(setup("BTCUSDT", models.PositionSideLong, 101, 0.9), candle, dedupStart.Add(6*time.Minute))
+	if seen.Refreshes != 1 || !seen.FirstSeen.Equal(dedupStart.Add(5*time.Minute)) || !seen.Fingerprint.Candle.Equal(candle) {
+		t.Errorf("observe() = %+v, want one refresh of the setup first seen at %v", seen, dedupStart.Add(5*time.Minute))
+	}
+}
+
+func TestSignalDedupEvictsTheLeastRecentlySeen(t *testing.T) {
+	config := DefaultDedupConfig()
+	config.Size = 2
+	d := newSignalDedup(config)
+	for i, symbol := range []string{"BTCUSDT", "ETHUSDT", "BTCUSDT", "SOLUSDT"} {
+		d.observe(setup(symbol, models.PositionSideLong, 100, 0.8), dedupStart, dedupStart.Add(time.Duration(i)*time.Second))
+	}
+	if got, _ := d.observe(setup("BTCUSDT", models.PositionSideLong, 100, 0.8), dedupStart, dedupStart.Add(time.Minute)); got != signalRefreshed {
+		t.Errorf("BTCUSDT, seen recently, observed as %d, want refreshed", got)
+	}
+	if got, _ := d.observe(setup("ETHUSDT", models.PositionSideLong, 100, 0.8), dedupStart, dedupStart.Add(time.Minute)); got != signalFirst {
+		t.Errorf("ETHUSDT, evicted, observed as %d, want first", got)
+	}
+}
+
+func TestSignalDedupDisabled(t *testing.T) {
+	d := newSignalDedup(DedupConfig{})
+	for i := 0; i < 3; i++ {
+		if got, _ := d.observe(setup("BTCUSDT", models.PositionSideLong, 100, 0.8), dedupStart, dedupStart); got != signalFirst {
+			t.Fatalf("pass %d observed as %d with a zero window, want every signal announced", i, got)
+		}
+	}
+}
+
+// titlesChannel collects the titles of the notifications sent to it
+type titlesChannel struct {
+	mu     sync.Mutex
+	titles []string
+}
+
+func (c *titlesChannel) Name() string { return "titles" }
+
+func (c *titlesChannel) Send(ctx context.Context, subject, body string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.titles = append(c.titles, subject)
+	return nil
+}
+
+func TestRepeatedSetupNotifiesOnce(t *testing.T) {
+	clk := clock.NewFake(dedupStart)
+	channel := &titlesChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+	h := &AnalysisHandler{
+		positionRepo: &repositories.PositionRepository{},
+		notifier:     notifier,
+		clock:        clk,
+		signals:      newSignalBoard(),
+		dedup:        newSignalDedup(DefaultDedupConfig()),
+	}
+	refreshed := testutil.ToFloat64(metrics.SignalsRefreshed.WithLabelValues("DEDUPUSDT"))
+
+	// The setup stays valid for 20 minutes of 15 second passes
+	candle := dedupStart.Add(-5 * time.Minute)
+	for pass := 0; pass < 80; pass++ {
+		result := setup("DEDUPUSDT", models.PositionSideLong, 100, 0.8)
+		h.observeSignal(result, candle)
+		h.signals.record(clk.Now(), result, "")
+		clk.Advance(15 * time.Second)
+	}
+
+	if want := []string{"[info] DEDUPUSDT long setup"}; !reflect.DeepEqual(channel.titles, want) {
+		t.Errorf("notified %v, want %v", channel.titles, want)
+	}
+	signals := h.LatestSignals()
+	if len(signals) != 1 || signals[0].Refreshes != 79 || !signals[0].Since.Equal(dedupStart) {
+		t.Errorf("LatestSignals() = %+v, want one row of the setup since %v refreshed 79 times", signals, dedupStart)
+	}
+	if got := testutil.ToFloat64(metrics.SignalsRefreshed.WithLabelValues("DEDUPUSDT")) - refreshed; got != 79 {
+		t.Errorf("%v refreshes counted, want 79", got)
+	}
+
+	// A confidence jump is announced again
+	h.observeSignal(setup("DEDUPUSDT", models.PositionSideLong, 100, 0.95), candle)
+	if len(channel.titles) != 2 || channel.titles[1] != "[info] DEDUPUSDT long setup changed" {
+		t.Errorf("notified %v, want the change announced", channel.titles)
+	}
+}
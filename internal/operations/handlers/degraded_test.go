@@ -0,0 +1,61 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+func TestDegradedExchangeBlocksEntriesAndKeepsMonitoring(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(time.Hour))
+	h.SetClock(clk)
+	health := priceOperations.NewExchangeHealth(nil)
+	h.UseExchangeHealth(health)
+	h.UseTicker(&fakeTicker{err: priceOperations.ErrDegraded})
+	ctx := context.Background()
+
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	storeCandle(t, h, "BTCUSDT", dbTestStart, position.TakeProfitPrice+1)
+
+	signal := &analysis.AnalysisResult{Symbol: "ETHUSDT", Direction: models.PositionSideLong, EntryPrice: 2500, IsValid: true}
+	if blocked, reason := h.checkVetoes(ctx, signal); blocked && strings.Contains(reason, "binance degraded") {
+		t.Fatalf("entry blocked before any failure: %s", reason)
+	}
+
+	// Binance goes into maintenance
+	health.Observe("", &common.APIError{Code: -1001, Message: "Internal error; unable to process your request"})
+	if blocked, reason := h.checkVetoes(ctx, signal); !blocked || !strings.Contains(reason, "binance degraded") {
+		t.Errorf("checkVetoes() = %v, %q, want entries blocked while degraded", blocked, reason)
+	}
+
+	// The open position is still checked, against the last recorded candle now an hour old
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	if !h.stale["BTCUSDT"] {
+		t.Error("the hour-old price was not flagged stale")
+	}
+	closed, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.Status != models.PositionStatusClosed || closed.CloseReason != "take_profit" || closed.ClosePriceSource != models.PriceSourceCandle {
+		t.Errorf("position = %s %s from %q, want closed at its target on the last known candle", closed.Status, closed.CloseReason, closed.ClosePriceSource)
+	}
+
+	// Entries resume once a request succeeds
+	health.Observe("BTCUSDT", nil)
+	if blocked, reason := h.checkVetoes(ctx, signal); blocked && strings.Contains(reason, "binance degraded") {
+		t.Errorf("entry still blocked after the recovery: %s", reason)
+	}
+}
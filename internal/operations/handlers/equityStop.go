@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"log"
+)
+
+// UseEquityStop flattens the account and blocks its entries once its equity falls through stop's floor
+func (h *AnalysisHandler) UseEquityStop(stop *risk.EquityStop) {
+	h.equityStop = stop
+	h.RegisterVeto(stop)
+}
+
+// checkEquity marks the account to market and, when that trips the equity stop, flattens it
+// It reports whether the account was flattened, leaving nothing for the position checks to do
+func (h *AnalysisHandler) checkEquity(ctx context.Context, positions []models.Position) bool {
+	if h.equityStop == nil {
+		return false
+	}
+
+	marks := make(map[string]float64)
+	for i := range positions {
+		symbol := positions[i].Symbol
+		if _, ok := marks[symbol]; ok {
+			continue
+		}
+		latest, err := h.priceRepo.GetLatestPrice(ctx, symbol)
+		if err != nil || latest == nil {
+			log.Printf("Equity of %s counts %s at entry, no price: %v", h.account.Account(), symbol, err)
+			continue
+		}
+		marks[symbol], _ = h.markPrice(symbol, latest.Close)
+	}
+
+	// Every balance and position is valued in the main quote asset
+	equity, err := h.account.Equity(ctx, positions, marks)
+	if err != nil {
+		log.Printf("Error checking equity stop: %v", err)
+		return false
+	}
+	tripped, err := h.equityStop.Check(equity, h.clock.Now())
+	if err != nil {
+		log.Printf("Error checking equity stop: %v", err)
+		return false
+	}
+	if !tripped {
+		return false
+	}
+
+	message := fmt.Sprintf("Equity %.2f %s fell below %.2f (session start %.2f), flattening %d positions and blocking entries until the next UTC day",
+		equity, h.account.QuoteAsset(), h.equityStop.Floor(), h.equityStop.StartEquity(), len(positions))
+	log.Printf("Equity stop: %s", message)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityCritical,
+		Title:    "Equity stop tripped",
+		Message:  message,
+		Account:  h.positionRepo.Account(),
+	})
+
+	h.cancelPendingOrders("equity stop")
+	if _, err := h.flatten(ctx, trading.CloseReasonEquityStop); err != nil {
+		log.Printf("Error flattening after equity stop: %v", err)
+	}
+	return true
+}
+
+// cancelPendingOrders cancels every limit entry still waiting, so none fills after a stop
+func (h *AnalysisHandler) cancelPendingOrders(why string) {
+	orders, err := h.orderRepo.FindPending()
+	if err != nil {
+		log.Printf("Error getting pending orders to cancel: %v", err)
+		return
+	}
+	for i := range orders {
+		orders[i].Status = models.PendingOrderStatusCancelled
+		if err := h.orderRepo.Update(&orders[i]); err != nil {
+			log.Printf("Error cancelling pending order %d: %v", orders[i].ID, err)
+			continue
+		}
+		log.Printf("Limit order %d for %s cancelled, %s", orders[i].ID, orders[i].Symbol, why)
+	}
+}
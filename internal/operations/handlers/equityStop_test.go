@@ -0,0 +1,78 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEquityStopFlattensAndBlocksEntries(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(10 * time.Minute))
+	h.SetClock(clk)
+	h.UseEquityStop(risk.NewEquityStop(risk.EquityStopConfig{Floor: 0.9}, nil))
+	ctx := context.Background()
+
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 40000)
+	storeCandle(t, h, "ETHUSDT", dbTestStart, 2500)
+	storeCandle(t, h, "SOLUSDT", dbTestStart, 100)
+	storePosition(t, h, "BTCUSDT", models.PositionSideLong, 40000, 0.01)
+	storePosition(t, h, "ETHUSDT", models.PositionSideLong, 2500, 0.1)
+	storePosition(t, h, "SOLUSDT", models.PositionSideShort, 100, 1)
+
+	// The session starts at 1000 USDT with the positions flat
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	if open, _ := h.positionRepo.FindOpenPositions(); len(open) != 3 {
+		t.Fatalf("%d positions open at the session start, want 3", len(open))
+	}
+
+	// Every position moves against the account: -20, -50 and -40 leave equity at 890, below the 900 floor
+	clk.Advance(5 * time.Minute)
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 38000)
+	storeCandle(t, h, "ETHUSDT", dbTestStart.Add(5*time.Minute), 2000)
+	storeCandle(t, h, "SOLUSDT", dbTestStart.Add(5*time.Minute), 140)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+
+	open, err := h.positionRepo.FindOpenPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 0 {
+		t.Fatalf("%d positions still open after the equity stop, want all closed", len(open))
+	}
+	closed, err := h.positionRepo.FindAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, position := range closed {
+		if position.CloseReason != trading.CloseReasonEquityStop {
+			t.Errorf("%s closed by %s, want %s", position.Symbol, position.CloseReason, trading.CloseReasonEquityStop)
+		}
+	}
+	if got := usdtBalance(t, h); math.Abs(got-890) > 1e-6 {
+		t.Errorf("balance = %v, want 890 after realizing the three losses", got)
+	}
+
+	// Further entries are blocked for the rest of the UTC day
+	signal := &analysis.AnalysisResult{Symbol: "BTCUSDT", Direction: models.PositionSideLong, EntryPrice: 38000, IsValid: true}
+	if blocked, reason := h.checkVetoes(ctx, signal); !blocked || !strings.Contains(reason, "equity stop") {
+		t.Errorf("checkVetoes() = %v, %q, want the entry blocked by the equity stop", blocked, reason)
+	}
+	clk.Set(dbTestStart.Truncate(24 * time.Hour).Add(24 * time.Hour))
+	if blocked, reason := h.checkVetoes(ctx, signal); blocked && strings.Contains(reason, "equity stop") {
+		t.Errorf("checkVetoes() = %v, %q on the next UTC day, want entries resumed", blocked, reason)
+	}
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"log"
+	"time"
+)
+
+// stalePriceAge is how long after its close a symbol's latest candle counts as stale
+const stalePriceAge = 10 * time.Minute
+
+// UseExchangeHealth blocks new entries while Binance is degraded exchange-wide
+// Open positions keep being checked against the last known price, with a warning once it goes stale
+func (h *AnalysisHandler) UseExchangeHealth(health *priceOperations.ExchangeHealth) {
+	h.exchange = health
+	h.RegisterVeto(risk.TradeVetoFunc(func(signal *analysis.AnalysisResult, account risk.Snapshot) (bool, string) {
+		if health.Degraded() {
+			return true, "binance degraded, entries resume once it recovers"
+		}
+		return false, ""
+	}))
+}
+
+// warnStale logs once when symbol's latest price goes stale and once when it is fresh again
+func (h *AnalysisHandler) warnStale(symbol string, latest *models.Price) {
+	if latest == nil {
+		return
+	}
+
+	closed := latest.OpenTime.Add(models.TimeFrameDurations[latest.TimeFrame])
+	age := h.clock.Now().Sub(closed)
+	stale := age > stalePriceAge
+	if stale == h.stale[symbol] {
+		return
+	}
+	h.stale[symbol] = stale
+
+	if !stale {
+		log.Printf("Price of %s is current again", symbol)
+		return
+	}
+	cause := "recording is behind"
+	if h.exchange != nil && h.exchange.Degraded() {
+		cause = "binance is degraded"
+	}
+	log.Printf("Warning: positions in %s are checked against a price %s old, %s", symbol, age.Round(time.Second), cause)
+}
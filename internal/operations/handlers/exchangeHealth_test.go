@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+func TestWarnStaleOnlyOnTransitions(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	h := &AnalysisHandler{stale: make(map[string]bool), clock: clk}
+	latest := &models.Price{Symbol: "BTCUSDT", TimeFrame: models.PriceTimeFrame5m, OpenTime: start.Add(-5 * time.Minute)}
+
+	steps := []struct {
+		name  string
+		at    time.Duration // After start
+		open  time.Duration // Latest candle's open after start
+		stale bool
+	}{
+		{"just closed", 0, -5 * time.Minute, false},
+		{"within the allowed age", stalePriceAge, -5 * time.Minute, false},
+		{"past it", stalePriceAge + time.Second, -5 * time.Minute, true},
+		{"still stale", time.Hour, -5 * time.Minute, true},
+		{"a new candle recorded", time.Hour, time.Hour - 5*time.Minute, false},
+	}
+	for _, step := range steps {
+		clk.Set(start.Add(step.at))
+		latest.OpenTime = start.Add(step.open)
+		h.warnStale("BTCUSDT", latest)
+		if h.stale["BTCUSDT"] != step.stale {
+			t.Errorf("%s: stale = %v, want %v", step.name, h.stale["BTCUSDT"], step.stale)
+		}
+	}
+
+	h.warnStale("ETHUSDT", nil)
+	if _, ok := h.stale["ETHUSDT"]; ok {
+		t.Error("a symbol without a price was marked")
+	}
+}
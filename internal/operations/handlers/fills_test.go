@@ -0,0 +1,78 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMarketFillsUseTheLivePrice(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	ctx := context.Background()
+
+	window := risingWindow("BTCUSDT", 250)
+	result := h.analyze("BTCUSDT", window)
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	close := window[len(window)-1].Close
+	ticker := &fakeTicker{price: close * 1.002}
+	h.UseTicker(ticker)
+
+	fill, source := h.fillAtMarket(result)
+	position, err := h.openPosition(ctx, fill, source)
+	if err != nil {
+		t.Fatalf("openPosition() error = %v", err)
+	}
+	stored, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Prices are stored to 8 decimals
+	if math.Abs(stored.EntryPrice-ticker.price) > 1e-6 || stored.EntryPriceSource != models.PriceSourceMark {
+		t.Fatalf("entry = %v from %q, want the live %v rather than the %v close", stored.EntryPrice, stored.EntryPriceSource, ticker.price, close)
+	}
+
+	// The stored candle has not reached the target yet, the live price has
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), stored.EntryPrice)
+	ticker.price = stored.TakeProfitPrice * 1.001
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	closed, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.Status != models.PositionStatusClosed || closed.CloseReason != "take_profit" || closed.ClosePriceSource != models.PriceSourceMark {
+		t.Fatalf("position = %s %s from %q, want closed at its target on the live price", closed.Status, closed.CloseReason, closed.ClosePriceSource)
+	}
+	if want := calculatePnL(stored, ticker.price); math.Abs(closed.PnL-want) > 1e-6 {
+		t.Errorf("PnL = %v, want the %v of closing at the live %v", closed.PnL, want, ticker.price)
+	}
+}
+
+func TestMarketFillsFallBackToTheCandleClose(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	h.UseTicker(&fakeTicker{err: context.DeadlineExceeded})
+
+	window := risingWindow("BTCUSDT", 250)
+	result := h.analyze("BTCUSDT", window)
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	fill, source := h.fillAtMarket(result)
+	position, err := h.openPosition(context.Background(), fill, source)
+	if err != nil {
+		t.Fatalf("openPosition() error = %v", err)
+	}
+	if position.EntryPrice != result.EntryPrice || position.EntryPriceSource != models.PriceSourceCandle {
+		t.Errorf("entry = %v from %q, want the %v candle close", position.EntryPrice, position.EntryPriceSource, result.EntryPrice)
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// FlattenReport summarizes the outcome of closing every open position
+type FlattenReport struct {
+	Closed      []models.Position
+	Remaining   map[uint]error // Positions that could not be closed, keyed by ID
+	RealizedPnL float64
+}
+
+// Flatten closes all open positions at the latest price, largest unrealized loss first
+// A failure on one position does not stop the rest; failures are listed in the report
+func (h *AnalysisHandler) Flatten(ctx context.Context) (*FlattenReport, error) {
+	return h.flatten(ctx, "flatten")
+}
+
+// flatten closes all open positions like Flatten, recording reason as their close reason
+func (h *AnalysisHandler) flatten(ctx context.Context, reason string) (*FlattenReport, error) {
+	positions, err := h.positionRepo.FindOpenPositions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open positions: %v", err)
+	}
+
+	report := &FlattenReport{Remaining: make(map[uint]error)}
+	if len(positions) == 0 {
+		return report, nil
+	}
+
+	// Mark every position so the riskiest can be closed first
+	marks := make(map[uint]float64, len(positions))
+	sources := make(map[uint]string, len(positions))
+	unrealized := make(map[uint]float64, len(positions))
+	for i := range positions {
+		latest, err := h.priceRepo.GetLatestPrice(ctx, positions[i].Symbol)
+		if err != nil || latest == nil {
+			report.Remaining[positions[i].ID] = fmt.Errorf("no price available for %s: %v", positions[i].Symbol, err)
+			continue
+		}
+		marks[positions[i].ID], sources[positions[i].ID] = h.markPrice(positions[i].Symbol, latest.Close)
+		unrealized[positions[i].ID] = calculatePnL(&positions[i], marks[positions[i].ID])
+	}
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		return unrealized[positions[i].ID] < unrealized[positions[j].ID]
+	})
+
+	for i := range positions {
+		position := &positions[i]
+		closePrice, marked := marks[position.ID]
+		if !marked {
+			continue
+		}
+
+		pnl := calculatePnL(position, closePrice)
+		position.CloseReason = reason
+		position.ClosePriceSource = sources[position.ID]
+		err := h.closePosition(ctx, position, closePrice, pnl)
+		if errors.Is(err, repositories.ErrPositionNotOpen) {
+			// The monitor or a reversal closed it first
+			log.Printf("Flatten skipped position %d (%s), already closed", position.ID, position.Symbol)
+			continue
+		}
+		if err != nil {
+			report.Remaining[position.ID] = err
+			log.Printf("Flatten failed for position %d (%s): %v", position.ID, position.Symbol, err)
+			continue
+		}
+
+		// Confirm the close actually landed before counting it
+		stored, err := h.positionRepo.FindByID(position.ID)
+		if err != nil || stored == nil || stored.Status != models.PositionStatusClosed {
+			report.Remaining[position.ID] = fmt.Errorf("position %d not confirmed closed: %v", position.ID, err)
+			continue
+		}
+
+		report.Closed = append(report.Closed, *stored)
+		converted, err := h.account.ConvertPnL(ctx, position.Symbol, pnl)
+		if err != nil {
+			log.Printf("Flatten counts the PnL of position %d unconverted: %v", position.ID, err)
+			converted = pnl
+		}
+		report.RealizedPnL += converted
+	}
+
+	log.Printf("Flatten complete: %d closed, %d remaining, realized PnL %.2f %s",
+		len(report.Closed), len(report.Remaining), report.RealizedPnL, h.account.QuoteAsset())
+
+	severity := notifications.SeverityWarning
+	if len(report.Remaining) > 0 {
+		severity = notifications.SeverityCritical
+	}
+	h.notify(notifications.Event{
+		Severity: severity,
+		Title:    "Flatten complete",
+		Message: fmt.Sprintf("%d closed, %d remaining, realized PnL %.2f %s",
+			len(report.Closed), len(report.Remaining), report.RealizedPnL, h.account.QuoteAsset()),
+		Account: h.positionRepo.Account(),
+	})
+
+	return report, nil
+}
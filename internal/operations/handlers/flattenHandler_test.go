@@ -0,0 +1,91 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// failCloses makes every save of the positions in fail error out, returning the IDs of the positions
+// saved so far in the order they were
+func failCloses(t *testing.T, db *gorm.DB, fail ...uint) func() []uint {
+	t.Helper()
+	var mu sync.Mutex
+	var saved []uint
+	err := db.Callback().Update().Before("gorm:update").Register("test:fail_closes", func(tx *gorm.DB) {
+		position, ok := tx.Statement.Model.(*models.Position)
+		if !ok {
+			return
+		}
+		mu.Lock()
+		saved = append(saved, position.ID)
+		mu.Unlock()
+		for _, id := range fail {
+			if position.ID == id {
+				tx.AddError(errors.New("injected close failure"))
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func() []uint {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]uint(nil), saved...)
+	}
+}
+
+func TestFlattenClosesRiskiestFirstAndReportsFailures(t *testing.T) {
+	h, db := newDBHandler(t, 1000)
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 40000)
+	storeCandle(t, h, "ETHUSDT", dbTestStart, 2500)
+	storeCandle(t, h, "SOLUSDT", dbTestStart, 100)
+
+	small := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 40500, 0.01) // -5
+	winner := storePosition(t, h, "ETHUSDT", models.PositionSideLong, 2400, 0.1)  // +10
+	largest := storePosition(t, h, "SOLUSDT", models.PositionSideShort, 90, 2)    // -20, fails to close
+	saved := failCloses(t, db, largest.ID)
+
+	report, err := h.Flatten(context.Background())
+	if err != nil {
+		t.Fatalf("Flatten() error = %v", err)
+	}
+
+	// Largest unrealized loss first, the failure not stopping the rest
+	want := []uint{largest.ID, small.ID, winner.ID}
+	if got := saved(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("closed in order %v, want %v", got, want)
+	}
+	if len(report.Closed) != 2 || report.Closed[0].ID != small.ID || report.Closed[1].ID != winner.ID {
+		t.Errorf("Closed = %+v, want positions %d and %d", report.Closed, small.ID, winner.ID)
+	}
+	if _, ok := report.Remaining[largest.ID]; !ok || len(report.Remaining) != 1 {
+		t.Errorf("Remaining = %v, want only position %d", report.Remaining, largest.ID)
+	}
+	if report.RealizedPnL != 5 {
+		t.Errorf("RealizedPnL = %v, want 5", report.RealizedPnL)
+	}
+
+	if got := usdtBalance(t, h); got != 1005 {
+		t.Errorf("balance = %v, want 1005", got)
+	}
+	open, err := h.positionRepo.FindOpenPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].ID != largest.ID {
+		t.Errorf("open positions = %+v, want only %d", open, largest.ID)
+	}
+	for _, closed := range report.Closed {
+		if closed.Status != models.PositionStatusClosed || closed.CloseReason != "flatten" {
+			t.Errorf("position %d stored as %s for %q, want closed for flatten", closed.ID, closed.Status, closed.CloseReason)
+		}
+	}
+}
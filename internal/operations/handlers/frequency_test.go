@@ -0,0 +1,76 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/services/risk"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDailyTradeCapsThrottleABurstOfSignals(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	ctx := context.Background()
+	result := h.analyze("BTCUSDT", risingWindow("BTCUSDT", 250))
+	if !result.IsValid {
+		t.Fatalf("read no entry from the window: %s", result.Reason)
+	}
+	h.RegisterVeto(risk.NewFrequencyLimiter(risk.FrequencyConfig{MaxTradesPerSymbolPerDay: 2, MaxTradesPerDay: 3}, h.positionRepo))
+
+	// A burst of valid signals in the last minutes of a UTC day, each entry churned out a minute later,
+	// then more just after midnight
+	midnight := dbTestStart.Add(24 * time.Hour)
+	clk := clock.NewFake(midnight.Add(-10 * time.Minute))
+	h.SetClock(clk)
+	burst := []struct {
+		symbol string
+		reason string // Empty when the entry opens
+	}{
+		{"BTCUSDT", ""},
+		{"BTCUSDT", ""},
+		{"BTCUSDT", "daily trade limit for BTCUSDT reached (2/2)"},
+		{"ETHUSDT", ""},
+		{"SOLUSDT", "daily trade limit reached (3/3)"},
+		{"BTCUSDT", "daily trade limit for BTCUSDT reached (2/2)"},
+		// The counts start over at midnight, the positions opened minutes before count for the day before
+		{"BTCUSDT", ""},
+		{"SOLUSDT", ""},
+	}
+	for i, entry := range burst {
+		if i == 6 {
+			clk.Set(midnight)
+		}
+		signal := *result
+		signal.Symbol = entry.symbol
+		want := ""
+		if entry.reason != "" {
+			want = "veto 0: " + entry.reason
+		}
+		blocked, reason := h.checkVetoes(ctx, &signal)
+		if blocked != (want != "") || reason != want {
+			t.Fatalf("signal %d on %s: checkVetoes() = %v, %q, want %q", i, entry.symbol, blocked, reason, want)
+		}
+		if blocked {
+			continue
+		}
+		position, err := h.openPosition(ctx, &signal, "test")
+		if err != nil {
+			t.Fatalf("signal %d: openPosition() error = %v", i, err)
+		}
+		clk.Advance(time.Minute)
+		position.CloseReason = "take_profit"
+		if err := h.closePosition(ctx, position, position.EntryPrice, 0); err != nil {
+			t.Fatalf("signal %d: closePosition() error = %v", i, err)
+		}
+	}
+
+	positions, err := h.positionRepo.FindAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 5 {
+		t.Errorf("%d positions opened, want 3 before midnight and 2 after", len(positions))
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// DefaultStaleCandles is how many 5m candles past its close the latest stored one may be before
+	// entries on the symbol are blocked
+	DefaultStaleCandles = 2.0
+
+	// staleDataReason is the rejection reason of entries blocked by stale candles
+	staleDataReason = "stale data"
+)
+
+// freshness is how current a symbol's stored 5m candles were at its latest analysis pass
+type freshness struct {
+	candle      time.Time // Open time of the latest stored candle
+	latestClose time.Time
+	age         time.Duration
+	stale       bool
+}
+
+// BlockStaleData blocks entries on a symbol while its latest stored 5m candle closed more than
+// candles 5m intervals ago, such as while the price recorder is failing; 0 disables the guard
+func (h *AnalysisHandler) BlockStaleData(candles float64) {
+	h.staleCandles = candles
+}
+
+// checkFreshness reports whether symbol's candles are current enough to enter on, recording their
+// age for SymbolHealth. Going stale and recovering are logged and notified once each
+func (h *AnalysisHandler) checkFreshness(symbol string) (*freshness, error) {
+	if h.staleCandles <= 0 {
+		return nil, nil
+	}
+	latest, err := h.priceRepo.GetLatestPriceByTimeFrame(symbol, models.PriceTimeFrame5m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest price: %v", err)
+	}
+	// Symbols without candles yet have nothing to analyze, the window check skips them
+	if latest == nil {
+		return nil, nil
+	}
+
+	interval := models.TimeFrameDurations[models.PriceTimeFrame5m]
+	latestClose := latest.OpenTime.Add(interval)
+	age := max(h.clock.Now().Sub(latestClose), 0)
+	current := &freshness{
+		candle:      latest.OpenTime,
+		latestClose: latestClose,
+		age:         age,
+		stale:       age > time.Duration(h.staleCandles*float64(interval)),
+	}
+
+	metrics.CandleAge.WithLabelValues(symbol).Set(age.Seconds())
+	if previous := h.health.observe(symbol, *current); previous == current.stale {
+		return current, nil
+	}
+
+	if !current.stale {
+		metrics.DataStale.WithLabelValues(h.positionRepo.Account(), symbol).Set(0)
+		log.Printf("Candles of %s are current again, entries allowed (%s)", symbol, h.positionRepo.Account())
+		return current, nil
+	}
+
+	metrics.DataStale.WithLabelValues(h.positionRepo.Account(), symbol).Set(1)
+	message := fmt.Sprintf("Latest %s candle closed %s ago, entries blocked until the recorder catches up",
+		symbol, age.Round(time.Second))
+	log.Printf("Warning: %s (%s)", message, h.positionRepo.Account())
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityWarning,
+		Title:    symbol + " data stale",
+		Message:  message,
+		Account:  h.positionRepo.Account(),
+	})
+	return current, nil
+}
+
+// rejectStale records an entry pass blocked by stale candles like any rejected signal
+func (h *AnalysisHandler) rejectStale(symbol string, current *freshness, state *symbolState) {
+	result := &analysis.AnalysisResult{Symbol: symbol, Reason: staleDataReason}
+	metrics.SignalsRejected.WithLabelValues(symbol, staleDataReason).Inc()
+	h.signals.record(h.clock.Now(), result, "")
+	h.tallyRejection(symbol, staleDataReason, current.candle, &state.LastTallied)
+}
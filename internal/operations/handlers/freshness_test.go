@@ -0,0 +1,65 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorderOutageBlocksEntries(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.BlockStaleData(DefaultStaleCandles)
+	channel := &subjectsChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+	h.notifier = notifier
+	ctx := context.Background()
+
+	// A window the strategy enters on, its last candle closing at dbTestStart, then nothing recorded for an hour
+	for _, price := range risingWindow("BTCUSDT", 250) {
+		if err := h.priceRepo.Create(ctx, &price); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clk := clock.NewFake(dbTestStart.Add(time.Hour))
+	h.SetClock(clk)
+
+	state := &symbolState{}
+	for pass := 0; pass < 2; pass++ {
+		if err := h.analyzeOnce(ctx, "BTCUSDT", state); err != nil {
+			t.Fatalf("analyzeOnce() error = %v", err)
+		}
+		clk.Advance(5 * time.Minute)
+	}
+	h.health.succeeded("BTCUSDT", clk.Now())
+
+	if open, _ := h.positionRepo.FindOpenPositions(); len(open) != 0 {
+		t.Fatalf("opened %+v on hour-old candles", open)
+	}
+	if signals := h.LatestSignals(); len(signals) != 1 || signals[0].Reason != staleDataReason {
+		t.Errorf("LatestSignals() = %+v, want the entry rejected for %q", signals, staleDataReason)
+	}
+	// Notified once for the outage, not on every pass
+	if want := []string{"[warning] BTCUSDT data stale"}; !reflect.DeepEqual(channel.subjects, want) {
+		t.Errorf("notified %v, want %v", channel.subjects, want)
+	}
+	health := h.SymbolHealth()
+	if len(health) != 1 || !health[0].Stale || health[0].Healthy || health[0].DataAge != "1h5m0s" || !health[0].LatestCandle.Equal(dbTestStart) {
+		t.Errorf("SymbolHealth() = %+v, want BTCUSDT stale since its candle closing at %v", health, dbTestStart)
+	}
+
+	// The recorder catches up
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(time.Hour), 120)
+	current, err := h.checkFreshness("BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current == nil || current.stale {
+		t.Errorf("checkFreshness() = %+v after a current candle, want entries allowed again", current)
+	}
+}
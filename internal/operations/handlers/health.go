@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultAnalysisConcurrency is how many analysis passes run at once across the accounts sharing a pool
+	DefaultAnalysisConcurrency = 8
+
+	// analysisJitter spreads the symbols' passes over this window so they don't query the database together
+	analysisJitter = 5 * time.Second
+)
+
+// AnalysisPool caps how many analysis passes run at once, shared by every handler given it
+type AnalysisPool struct {
+	slots chan struct{}
+}
+
+// NewAnalysisPool creates a new instance of AnalysisPool running up to size passes at once
+func NewAnalysisPool(size int) *AnalysisPool {
+	return &AnalysisPool{slots: make(chan struct{}, max(size, 1))}
+}
+
+// acquire waits for a free slot, failing when ctx is done first
+func (p *AnalysisPool) acquire(ctx context.Context) error {
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (p *AnalysisPool) release() {
+	<-p.slots
+}
+
+// UsePool runs this handler's analysis passes through pool instead of all at once
+// Call it before Start
+func (h *AnalysisHandler) UsePool(pool *AnalysisPool) {
+	h.pool = pool
+}
+
+// SymbolHealth is how the analysis of a symbol has been going
+type SymbolHealth struct {
+	Symbol              string    `json:"symbol"`
+	Healthy             bool      `json:"healthy"`
+	LastSuccess         time.Time `json:"last_success"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+
+	// Set while the symbol is held for too short a history, with when its analysis starts
+	InsufficientHistory bool       `json:"insufficient_history,omitempty"`
+	ActiveAt            *time.Time `json:"active_at,omitempty"`
+
+	// Set while the symbol's stored 5m candles fall short of the strategies' window, no analysis runs meanwhile
+	WarmingUp       bool `json:"warming_up,omitempty"`
+	Candles         int  `json:"candles,omitempty"`
+	RequiredCandles int  `json:"required_candles,omitempty"`
+
+	// How current the symbol's candles were at its latest pass, entries are blocked while Stale
+	LatestCandle *time.Time `json:"latest_candle,omitempty"` // Close of the latest stored 5m candle
+	DataAge      string     `json:"data_age,omitempty"`
+	Stale        bool       `json:"stale,omitempty"`
+}
+
+// healthBoard keeps the SymbolHealth of each analyzed symbol for read-only consumers
+type healthBoard struct {
+	mu      sync.RWMutex
+	symbols map[string]SymbolHealth
+	fresh   map[string]freshness // Kept apart, a pass's outcome replaces its SymbolHealth
+}
+
+func newHealthBoard() *healthBoard {
+	return &healthBoard{symbols: make(map[string]SymbolHealth), fresh: make(map[string]freshness)}
+}
+
+// succeeded marks a pass of symbol that finished at at
+func (b *healthBoard) succeeded(symbol string, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.symbols[symbol] = SymbolHealth{Symbol: symbol, Healthy: true, LastSuccess: at}
+}
+
+// failed marks a pass of symbol that failed with err, leaving the symbol unhealthy until one succeeds
+func (b *healthBoard) failed(symbol string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	health := b.symbols[symbol]
+	health.Symbol = symbol
+	health.Healthy = false
+	health.ConsecutiveFailures++
+	health.LastError = err.Error()
+	b.symbols[symbol] = health
+}
+
+// observe records how current symbol's candles are, returning whether they were stale before
+func (b *healthBoard) observe(symbol string, current freshness) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous := b.fresh[symbol]
+	b.fresh[symbol] = current
+	return previous.stale
+}
+
+// remove forgets the health of symbol
+func (b *healthBoard) remove(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.symbols, symbol)
+	delete(b.fresh, symbol)
+}
+
+// SymbolHealth returns the analysis health of every symbol analyzed so far, sorted by symbol
+func (h *AnalysisHandler) SymbolHealth() []SymbolHealth {
+	h.health.mu.RLock()
+	defer h.health.mu.RUnlock()
+
+	healths := make([]SymbolHealth, 0, len(h.health.symbols))
+	for symbol, health := range h.health.symbols {
+		if fresh, ok := h.health.fresh[symbol]; ok {
+			latestClose := fresh.latestClose
+			health.LatestCandle = &latestClose
+			health.DataAge = fresh.age.Round(time.Second).String()
+			health.Stale = fresh.stale
+			health.Healthy = health.Healthy && !fresh.stale
+		}
+		healths = append(healths, health)
+	}
+	h.warmUpHealth(healths)
+	healths = append(healths, h.historyHealth()...)
+	sort.Slice(healths, func(i, j int) bool {
+		return healths[i].Symbol < healths[j].Symbol
+	})
+	return healths
+}
+
+// runPass analyzes symbol once through the pool, recovering from a panic so the symbol
+// is analyzed again on its next wake instead of its loop dying
+func (h *AnalysisHandler) runPass(ctx context.Context, symbol string, state *symbolState) {
+	h.clock.Sleep(symbolJitter(symbol))
+
+	if h.pool != nil {
+		if err := h.pool.acquire(ctx); err != nil {
+			return
+		}
+		defer h.pool.release()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Panic analyzing %s: %v\n%s", symbol, r, debug.Stack())
+			h.health.failed(symbol, fmt.Errorf("panic: %v", r))
+		}
+	}()
+
+	if h.awaitingHistory(symbol) {
+		return
+	}
+	if err := h.analyzeOnce(ctx, symbol, state); err != nil {
+		log.Printf("Error analyzing %s: %v", symbol, err)
+		h.health.failed(symbol, err)
+		return
+	}
+	h.health.succeeded(symbol, h.clock.Now())
+}
+
+// symbolJitter returns symbol's fixed offset within analysisJitter, so each symbol keeps its own slot
+func symbolJitter(symbol string) time.Duration {
+	hash := fnv.New32a()
+	hash.Write([]byte(symbol))
+	return time.Duration(hash.Sum32()) % analysisJitter
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAnalysisPoolCapsConcurrentPasses(t *testing.T) {
+	pool := NewAnalysisPool(2)
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := pool.acquire(context.Background()); err != nil {
+				t.Error(err)
+				return
+			}
+			defer pool.release()
+			now := running.Add(1)
+			for {
+				highest := peak.Load()
+				if now <= highest || peak.CompareAndSwap(highest, now) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if got := peak.Load(); got != 2 {
+		t.Errorf("peak concurrent passes = %d, want 2", got)
+	}
+}
+
+func TestAnalysisPoolAcquireStopsWithItsContext(t *testing.T) {
+	pool := NewAnalysisPool(1)
+	if err := pool.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pool.acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("acquire() on a full pool = %v, want context.Canceled", err)
+	}
+}
+
+func TestSymbolJitter(t *testing.T) {
+	offsets := make(map[time.Duration]bool)
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "XRPUSDT", "SOLUSDT", "DOGEUSDT"} {
+		jitter := symbolJitter(symbol)
+		if jitter < 0 || jitter >= analysisJitter {
+			t.Errorf("symbolJitter(%s) = %v, want within [0, %v)", symbol, jitter, analysisJitter)
+		}
+		if again := symbolJitter(symbol); again != jitter {
+			t.Errorf("symbolJitter(%s) = %v then %v, want the same slot every pass", symbol, jitter, again)
+		}
+		offsets[jitter] = true
+	}
+	if len(offsets) < 2 {
+		t.Errorf("every symbol got the same offset %v, want them spread", offsets)
+	}
+}
+
+func TestHealthBoardFailuresAndRecovery(t *testing.T) {
+	board := newHealthBoard()
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	board.succeeded("BTCUSDT", at)
+	board.failed("BTCUSDT", errors.New("panic: boom"))
+	board.failed("BTCUSDT", errors.New("panic: boom"))
+
+	health := board.symbols["BTCUSDT"]
+	if health.Healthy || health.ConsecutiveFailures != 2 || health.LastError != "panic: boom" || !health.LastSuccess.Equal(at) {
+		t.Errorf("after two failures = %+v, want unhealthy twice over, keeping the last success", health)
+	}
+
+	board.succeeded("BTCUSDT", at.Add(time.Minute))
+	if health := board.symbols["BTCUSDT"]; !health.Healthy || health.ConsecutiveFailures != 0 || health.LastError != "" {
+		t.Errorf("after recovering = %+v, want healthy with the failures cleared", health)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHedgedLegsCloseIndependently(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.entryConfig.HedgeMode = true
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	ctx := context.Background()
+
+	// Long exits at 90 or 110, short at 110 or 90: a close at 111 is the long's target and the short's stop
+	long := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	short := storePosition(t, h, "BTCUSDT", models.PositionSideShort, 100, 0.5)
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 111)
+
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+
+	var total float64
+	for _, want := range []struct {
+		position *models.Position
+		reason   string
+		profit   bool
+	}{
+		{long, "take_profit", true},
+		{short, "stop_loss", false},
+	} {
+		stored, err := h.positionRepo.FindByID(want.position.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != models.PositionStatusClosed || stored.CloseReason != want.reason || (stored.PnL > 0) != want.profit {
+			t.Errorf("%s leg = %s %s PnL %v, want closed by %s", stored.Side, stored.Status, stored.CloseReason, stored.PnL, want.reason)
+		}
+		if wantPnL := calculatePnL(want.position, 111); math.Abs(stored.PnL-wantPnL) > 1e-9 {
+			t.Errorf("%s leg PnL = %v, want %v", stored.Side, stored.PnL, wantPnL)
+		}
+		total += stored.PnL
+	}
+	if got := usdtBalance(t, h); math.Abs(got-(1000+total)) > 1e-9 {
+		t.Errorf("balance = %v, want both legs booked: %v", got, 1000+total)
+	}
+}
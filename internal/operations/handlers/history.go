@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"fmt"
+	"log"
+	"time"
+)
+
+// AwaitHistory holds the analysis of symbol, listed at listed, until its candles span the strategies'
+// window; before that every higher timeframe would be resampled from a few days of candles.
+// Its prices keep being recorded meanwhile, and a symbol listed long enough ago is not held at all
+func (h *AnalysisHandler) AwaitHistory(symbol string, listed time.Time) {
+	activeAt := listed.Add(time.Duration(h.window) * models.TimeFrameDurations[models.PriceTimeFrame5m])
+	if !activeAt.After(h.clock.Now()) {
+		return
+	}
+
+	h.historyMu.Lock()
+	h.awaiting[symbol] = activeAt
+	h.historyMu.Unlock()
+	log.Printf("%s has insufficient history (listed %s), %s analysis starts %s", symbol,
+		listed.UTC().Format(time.RFC3339), h.positionRepo.Account(), activeAt.UTC().Format(time.RFC3339))
+}
+
+// awaitingHistory reports whether symbol is still held by AwaitHistory, announcing its activation
+// on the first pass after it no longer is
+func (h *AnalysisHandler) awaitingHistory(symbol string) bool {
+	h.historyMu.Lock()
+	activeAt, ok := h.awaiting[symbol]
+	if !ok {
+		h.historyMu.Unlock()
+		return false
+	}
+	if h.clock.Now().Before(activeAt) {
+		h.historyMu.Unlock()
+		return true
+	}
+	delete(h.awaiting, symbol)
+	h.historyMu.Unlock()
+
+	message := fmt.Sprintf("%s has enough history for the strategies' window, analysis started", symbol)
+	log.Printf("%s (%s)", message, h.positionRepo.Account())
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityInfo,
+		Title:    symbol + " activated",
+		Message:  message,
+		Account:  h.positionRepo.Account(),
+	})
+	return false
+}
+
+// forgetHistory drops the hold on a removed symbol
+func (h *AnalysisHandler) forgetHistory(symbol string) {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+	delete(h.awaiting, symbol)
+}
+
+// historyHealth returns the SymbolHealth of every symbol held for its history
+func (h *AnalysisHandler) historyHealth() []SymbolHealth {
+	h.historyMu.Lock()
+	defer h.historyMu.Unlock()
+
+	healths := make([]SymbolHealth, 0, len(h.awaiting))
+	for symbol, activeAt := range h.awaiting {
+		healths = append(healths, SymbolHealth{Symbol: symbol, InsufficientHistory: true, ActiveAt: &activeAt})
+	}
+	return healths
+}
@@ -0,0 +1,61 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/notifications"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecentListingIsHeldUntilItsHistorySpansTheWindow(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart)
+	h.SetClock(clk)
+	channel := &subjectsChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+	h.notifier = notifier
+
+	// ONDOUSDT listed 6 hours ago, its few candles still recorded; BTCUSDT listed long before the window
+	listed := dbTestStart.Add(-6 * time.Hour)
+	for open := listed; open.Before(dbTestStart); open = open.Add(5 * time.Minute) {
+		storeCandle(t, h, "ONDOUSDT", open, 1)
+	}
+	h.AwaitHistory("ONDOUSDT", listed)
+	h.AwaitHistory("BTCUSDT", dbTestStart.AddDate(-1, 0, 0))
+
+	activeAt := listed.Add(time.Duration(h.window) * 5 * time.Minute)
+	want := []SymbolHealth{{Symbol: "ONDOUSDT", InsufficientHistory: true, ActiveAt: &activeAt}}
+	if got := h.SymbolHealth(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SymbolHealth() = %+v, want ONDOUSDT held until %v", got, activeAt)
+	}
+	if h.awaitingHistory("BTCUSDT") {
+		t.Error("BTCUSDT is held though listed a year ago")
+	}
+
+	// Held until a candle short of the window
+	clk.Set(activeAt.Add(-time.Second))
+	if !h.awaitingHistory("ONDOUSDT") {
+		t.Fatal("ONDOUSDT was released before its history spans the window")
+	}
+	if len(channel.subjects) != 0 {
+		t.Fatalf("notified %v while still held", channel.subjects)
+	}
+
+	// Then activated once, announced and dropped from the status
+	clk.Set(activeAt)
+	if h.awaitingHistory("ONDOUSDT") || h.awaitingHistory("ONDOUSDT") {
+		t.Fatal("ONDOUSDT is still held once its history spans the window")
+	}
+	if want := []string{"[info] ONDOUSDT activated"}; !reflect.DeepEqual(channel.subjects, want) {
+		t.Errorf("notified %v, want %v", channel.subjects, want)
+	}
+	for _, health := range h.SymbolHealth() {
+		if health.InsufficientHistory {
+			t.Errorf("%s still reported with insufficient history", health.Symbol)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dbTestStart is when the positions and candles of the database tests open
+var dbTestStart = testdb.FixtureStart
+
+// newDBHandler returns a handler on a fresh database of the default account, holding balance USDT
+func newDBHandler(t *testing.T, balance float64) (*AnalysisHandler, *gorm.DB) {
+	t.Helper()
+	db := testdb.Open(t)
+	return newAccountHandler(t, db, models.DefaultAccount, strategy.DefaultParams(), balance), db
+}
+
+// newAccountHandler returns a handler trading account on db with params, holding balance USDT
+func newAccountHandler(t *testing.T, db *gorm.DB, account string, params strategy.Params, balance float64) *AnalysisHandler {
+	t.Helper()
+	balances := repositories.NewBalanceRepository(db).ForAccount(account)
+	if err := balances.Create(&models.Balance{Symbol: "USDT", Balance: balance, LastUpdated: dbTestStart}); err != nil {
+		t.Fatal(err)
+	}
+	return accountHandler(t, db, account, params)
+}
+
+// accountHandler returns a handler trading account on db with params, over what the database holds,
+// the way a restarted bot comes up
+func accountHandler(t *testing.T, db *gorm.DB, account string, params strategy.Params) *AnalysisHandler {
+	t.Helper()
+	strategies, err := strategy.NewStrategyManager(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewAnalysisHandler(
+		strategies,
+		repositories.NewPriceRepository(db),
+		repositories.NewPositionRepository(db).ForAccount(account),
+		trading.NewAccountService(repositories.NewBalanceRepository(db).ForAccount(account), "USDT"),
+		repositories.NewPendingOrderRepository(db).ForAccount(account),
+		nil,
+		trading.DefaultEntryConfig(),
+		trading.DefaultReversalConfig(),
+	)
+}
+
+// storeCandle stores a 5m candle of symbol closing at close, opening at openTime
+func storeCandle(t *testing.T, h *AnalysisHandler, symbol string, openTime time.Time, close float64) {
+	t.Helper()
+	price := &models.Price{
+		Symbol:    symbol,
+		TimeFrame: models.PriceTimeFrame5m,
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+		Open:      close,
+		High:      close,
+		Low:       close,
+		Close:     close,
+		Volume:    100,
+	}
+	if err := h.priceRepo.Create(context.Background(), price); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// storePosition stores an open position of size on symbol entered at entry
+func storePosition(t *testing.T, h *AnalysisHandler, symbol, side string, entry, size float64) *models.Position {
+	t.Helper()
+	stop, target := entry*0.9, entry*1.1
+	if side == models.PositionSideShort {
+		stop, target = target, stop
+	}
+	position := &models.Position{
+		Symbol:          symbol,
+		Side:            side,
+		Size:            size,
+		Leverage:        Leverage,
+		EntryPrice:      entry,
+		StopLossPrice:   stop,
+		TakeProfitPrice: target,
+		OpenTime:        dbTestStart,
+		Status:          models.PositionStatusOpen,
+	}
+	if err := h.positionRepo.Create(context.Background(), position); err != nil {
+		t.Fatal(err)
+	}
+	return position
+}
+
+// usdtBalance returns the stored USDT balance of the default account
+func usdtBalance(t *testing.T, h *AnalysisHandler) float64 {
+	t.Helper()
+	balance, err := h.account.Balance()
+	if err != nil || balance == nil {
+		t.Fatalf("Balance() = %v, %v", balance, err)
+	}
+	return balance.Balance
+}
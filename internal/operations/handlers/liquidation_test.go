@@ -0,0 +1,59 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLiquidationYieldsToStopInsideIt(t *testing.T) {
+	tests := []struct {
+		name   string
+		stop   float64
+		reason string
+	}{
+		{"stop inside liquidation", 99, "stop_loss"},
+		{"stop beyond liquidation", 97, trading.CloseReasonLiquidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newDBHandler(t, 1000)
+			h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+
+			// At 50x a long BTCUSDT entry at 100 liquidates near 98.4, and the candle closes through it at 98
+			position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+			position.StopLossPrice = tt.stop
+			position.LiquidationPrice = trading.PositionLiquidationPrice("BTCUSDT", position.Side, 100, 1, Leverage)
+			if err := h.positionRepo.Update(position); err != nil {
+				t.Fatal(err)
+			}
+			storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 98)
+
+			if err := h.checkOpenPositions(context.Background()); err != nil {
+				t.Fatalf("checkOpenPositions() error = %v", err)
+			}
+			stored, err := h.positionRepo.FindByID(position.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The stop books its own price, a liquidation the whole margin
+			pnl := calculatePnL(position, tt.stop)
+			if tt.reason == trading.CloseReasonLiquidation {
+				pnl = -positionMargin(position)
+			}
+			if stored.Status != models.PositionStatusClosed || stored.CloseReason != tt.reason {
+				t.Fatalf("position = %s %s, want closed by %s", stored.Status, stored.CloseReason, tt.reason)
+			}
+			if math.Abs(stored.PnL-pnl) > 1e-9 {
+				t.Errorf("PnL = %v, want %v", stored.PnL, pnl)
+			}
+		})
+	}
+}
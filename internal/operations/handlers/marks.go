@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// markPriceTimeout bounds a mark price request before falling back to the candle close
+const markPriceTimeout = 2 * time.Second
+
+// MarkPricer supplies the live price of a symbol, priceOperations.TickerService among others
+type MarkPricer interface {
+	MarkPrice(ctx context.Context, symbol string) (float64, error)
+}
+
+// UseTicker fills market entries, exits and reversals at the live mark price instead of
+// the latest stored candle close, which can be up to a candle old
+func (h *AnalysisHandler) UseTicker(ticker MarkPricer) {
+	h.ticker = ticker
+}
+
+// markPrice returns symbol's live mark price and its source, or fallback, the latest candle
+// close, when there is no ticker or it fails
+func (h *AnalysisHandler) markPrice(symbol string, fallback float64) (float64, string) {
+	if h.ticker == nil {
+		return fallback, models.PriceSourceCandle
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), markPriceTimeout)
+	defer cancel()
+
+	price, err := h.ticker.MarkPrice(ctx, symbol)
+	if errors.Is(err, priceOperations.ErrDegraded) {
+		return fallback, models.PriceSourceCandle // Reported once by the ExchangeHealth
+	}
+	if err != nil {
+		log.Printf("Using candle close for %s: %v", symbol, err)
+		return fallback, models.PriceSourceCandle
+	}
+	return price, models.PriceSourceMark
+}
+
+// fillAtMarket moves a market signal's entry from the candle close it was analyzed at to
+// the live price, percent exits keeping their distance, see AnalysisResult.AtFill
+func (h *AnalysisHandler) fillAtMarket(result *analysis.AnalysisResult) (*analysis.AnalysisResult, string) {
+	fill, source := h.markPrice(result.Symbol, result.EntryPrice)
+	if source != models.PriceSourceMark {
+		return result, source
+	}
+	return result.AtFill(fill), source
+}
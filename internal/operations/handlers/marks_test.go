@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// fakeTicker returns a fixed mark price for every symbol, or err
+type fakeTicker struct {
+	price float64
+	err   error
+}
+
+func (t *fakeTicker) MarkPrice(ctx context.Context, symbol string) (float64, error) {
+	return t.price, t.err
+}
+
+func TestMarkPrice(t *testing.T) {
+	tests := []struct {
+		name       string
+		ticker     MarkPricer
+		wantPrice  float64
+		wantSource string
+	}{
+		{"no ticker", nil, 100, models.PriceSourceCandle},
+		{"live price", &fakeTicker{price: 101.5}, 101.5, models.PriceSourceMark},
+		{"ticker fails", &fakeTicker{err: errors.New("timeout")}, 100, models.PriceSourceCandle},
+		{"exchange degraded", &fakeTicker{err: priceOperations.ErrDegraded}, 100, models.PriceSourceCandle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AnalysisHandler{}
+			if tt.ticker != nil {
+				h.UseTicker(tt.ticker)
+			}
+			price, source := h.markPrice("BTCUSDT", 100)
+			if price != tt.wantPrice || source != tt.wantSource {
+				t.Errorf("markPrice() = %v, %s, want %v, %s", price, source, tt.wantPrice, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestFillAtMarket(t *testing.T) {
+	signal := analysis.AnalysisResult{
+		Symbol:     "BTCUSDT",
+		Direction:  models.PositionSideLong,
+		EntryPrice: 100,
+		TakeProfit: 104,
+		StopLoss:   98,
+		TargetMode: analysis.TargetModePercent,
+	}
+	volatility := signal
+	volatility.TargetMode = analysis.TargetModeVolatility
+
+	tests := []struct {
+		name       string
+		signal     analysis.AnalysisResult
+		ticker     *fakeTicker
+		wantEntry  float64
+		wantTarget float64
+		wantStop   float64
+		wantSource string
+	}{
+		{"percent exits keep their distance", signal, &fakeTicker{price: 101}, 101, 105.04, 98.98, models.PriceSourceMark},
+		{"volatility exits stay put", volatility, &fakeTicker{price: 101}, 101, 104, 98, models.PriceSourceMark},
+		{"candle close without the ticker", signal, &fakeTicker{err: errors.New("down")}, 100, 104, 98, models.PriceSourceCandle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AnalysisHandler{}
+			h.UseTicker(tt.ticker)
+			signal := tt.signal
+			fill, source := h.fillAtMarket(&signal)
+			if source != tt.wantSource {
+				t.Errorf("source = %s, want %s", source, tt.wantSource)
+			}
+			if math.Abs(fill.EntryPrice-tt.wantEntry) > 1e-9 || math.Abs(fill.TakeProfit-tt.wantTarget) > 1e-9 || math.Abs(fill.StopLoss-tt.wantStop) > 1e-9 {
+				t.Errorf("fill = entry %v, target %v, stop %v, want %v, %v, %v",
+					fill.EntryPrice, fill.TakeProfit, fill.StopLoss, tt.wantEntry, tt.wantTarget, tt.wantStop)
+			}
+			if signal.EntryPrice != tt.signal.EntryPrice {
+				t.Errorf("signal entry = %v, want the analyzed %v left untouched", signal.EntryPrice, tt.signal.EntryPrice)
+			}
+		})
+	}
+}
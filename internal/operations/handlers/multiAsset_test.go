@@ -0,0 +1,87 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBTCQuotedSymbolBooksPnLInBTC(t *testing.T) {
+	h, db := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	h.account.UsePrices(h.priceRepo)
+	ctx := context.Background()
+
+	if err := repositories.NewBalanceRepository(db).Create(&models.Balance{Symbol: "BTC", Balance: 2, LastUpdated: dbTestStart}); err != nil {
+		t.Fatal(err)
+	}
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 50000)
+
+	// Both balances valued in USDT
+	if total, err := h.account.TotalBalance(ctx); err != nil || math.Abs(total-101000) > 1e-6 {
+		t.Fatalf("TotalBalance() = %v, %v, want 1000 USDT plus 2 BTC at 50000", total, err)
+	}
+
+	result := h.analyze("ETHBTC", risingWindow("ETHBTC", 250))
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	position, err := h.openPosition(ctx, result, "test")
+	if err != nil {
+		t.Fatalf("openPosition() error = %v", err)
+	}
+
+	// Equity converts the open position's BTC PnL at the BTCUSDT close
+	mark := position.EntryPrice * 1.001
+	equity, err := h.account.Equity(ctx, []models.Position{*position}, map[string]float64{"ETHBTC": mark})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 101000 + trading.PositionPnL(position, mark)*50000; math.Abs(equity-want) > 1e-6 {
+		t.Errorf("Equity() = %v, want %v", equity, want)
+	}
+
+	storeCandle(t, h, "ETHBTC", dbTestStart.Add(5*time.Minute), position.TakeProfitPrice*1.001)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	stored, err := h.positionRepo.FindByID(position.ID)
+	if err != nil || stored.Status != models.PositionStatusClosed || stored.PnL <= 0 {
+		t.Fatalf("position = %+v, %v, want closed at its target in profit", stored, err)
+	}
+	// Balances are stored to 8 decimals
+	if got, want := balanceOf(t, db, "BTC"), 2+stored.PnL; math.Abs(got-want) > 1e-6 {
+		t.Errorf("BTC balance = %v, want 2 plus the %v won", got, stored.PnL)
+	}
+	if got := usdtBalance(t, h); got != 1000 {
+		t.Errorf("USDT balance = %v, want 1000 untouched", got)
+	}
+	if total, err := h.account.TotalBalance(ctx); err != nil || math.Abs(total-(1000+(2+stored.PnL)*50000)) > 1e-2 {
+		t.Errorf("TotalBalance() = %v, %v, want the BTC won valued at 50000", total, err)
+	}
+}
+
+func TestSymbolWithoutItsQuoteBalanceIsRefused(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+
+	// No BTC balance, so ETHBTC's PnL has nowhere to go rather than landing in USDT
+	result := h.analyze("ETHBTC", risingWindow("ETHBTC", 250))
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	if _, err := h.openPosition(context.Background(), result, "test"); err == nil || !strings.Contains(err.Error(), "no BTC balance") {
+		t.Errorf("openPosition(ETHBTC) error = %v, want refused without a BTC balance", err)
+	}
+	if got := usdtBalance(t, h); got != 1000 {
+		t.Errorf("USDT balance = %v, want 1000 untouched", got)
+	}
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+)
+
+// OrderFlowSource reports a symbol's recent order flow, false while it is unavailable,
+// implemented by priceOperations.AggTradeCollector
+type OrderFlowSource interface {
+	OrderFlow(symbol string) (analysis.OrderFlow, bool)
+}
+
+// UseOrderFlow confirms the entries of symbols whose parameters set OrderFlowFilter against source
+// Without a source those entries go through as if the trade stream were down
+func (h *AnalysisHandler) UseOrderFlow(source OrderFlowSource) {
+	h.orderFlow = source
+}
+
+// confirmOrderFlow blocks an entry whose symbol filters on order flow when the flow does not lean its way,
+// recording the flow on result. An unavailable flow lets the entry through marked as unconfirmed
+func (h *AnalysisHandler) confirmOrderFlow(result *analysis.AnalysisResult) (bool, string) {
+	params := h.strategies.ParamsFor(result.Symbol)
+	if !params.OrderFlowFilter {
+		return false, ""
+	}
+
+	var flow analysis.OrderFlow
+	available := false
+	if h.orderFlow != nil {
+		flow, available = h.orderFlow.OrderFlow(result.Symbol)
+	}
+	if !available {
+		result.OrderFlow, result.OrderFlowUnavailable = nil, true
+		metrics.OrderFlowSkipped.WithLabelValues(result.Symbol).Inc()
+		return false, ""
+	}
+
+	result.OrderFlow, result.OrderFlowUnavailable = &flow, false
+	if !flow.Confirms(result.Direction, params.OrderFlowImbalance) {
+		return true, fmt.Sprintf("order flow imbalance %+.2f does not confirm the %s entry", flow.Imbalance, result.Direction)
+	}
+	return false, ""
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubOrderFlow reports the same flow for every symbol
+type stubOrderFlow struct {
+	flow      analysis.OrderFlow
+	available bool
+}
+
+func (s stubOrderFlow) OrderFlow(symbol string) (analysis.OrderFlow, bool) {
+	return s.flow, s.available
+}
+
+func TestConfirmOrderFlow(t *testing.T) {
+	filtered := strategy.DefaultParams()
+	filtered.OrderFlowFilter = true // At an imbalance of 0.1
+	buying := analysis.OrderFlow{BuyVolume: 300, SellVolume: 100, Trades: 3, Imbalance: 0.5}
+
+	tests := []struct {
+		name        string
+		params      strategy.Params
+		source      OrderFlowSource
+		side        string
+		blocked     bool
+		recorded    bool // Whether the flow is recorded on the result
+		unavailable bool
+	}{
+		{"filter off", strategy.DefaultParams(), stubOrderFlow{buying, true}, models.PositionSideLong, false, false, false},
+		{"confirming long", filtered, stubOrderFlow{buying, true}, models.PositionSideLong, false, true, false},
+		{"short against the buying", filtered, stubOrderFlow{buying, true}, models.PositionSideShort, true, true, false},
+		{"no volume", filtered, stubOrderFlow{analysis.OrderFlow{}, true}, models.PositionSideLong, true, true, false},
+		{"stream down", filtered, stubOrderFlow{available: false}, models.PositionSideLong, false, false, true},
+		{"no source", filtered, nil, models.PositionSideShort, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategies, err := strategy.NewStrategyManager(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := &AnalysisHandler{strategies: strategies, orderFlow: tt.source}
+			skipped := testutil.ToFloat64(metrics.OrderFlowSkipped.WithLabelValues("FLOWUSDT"))
+
+			result := setup("FLOWUSDT", tt.side, 100, 0.8)
+			blocked, reason := h.confirmOrderFlow(result)
+			if blocked != tt.blocked || (reason != "") != tt.blocked {
+				t.Errorf("confirmOrderFlow() = %v, %q, want blocked %v", blocked, reason, tt.blocked)
+			}
+			if (result.OrderFlow != nil) != tt.recorded || result.OrderFlowUnavailable != tt.unavailable {
+				t.Errorf("recorded flow %+v unavailable %v, want recorded %v unavailable %v",
+					result.OrderFlow, result.OrderFlowUnavailable, tt.recorded, tt.unavailable)
+			}
+			wantSkipped := 0.0
+			if tt.unavailable {
+				wantSkipped = 1
+			}
+			if got := testutil.ToFloat64(metrics.OrderFlowSkipped.WithLabelValues("FLOWUSDT")) - skipped; got != wantSkipped {
+				t.Errorf("skipped metric rose by %v, want %v", got, wantSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// panicking makes the panicky strategy panic while set
+var panicking atomic.Bool
+
+// panickyStrategy panics on every window while panicking is set, as an analyzer indexing past a short slice does
+type panickyStrategy struct{}
+
+func (panickyStrategy) Analyze(prices []models.Price) *analysis.AnalysisResult {
+	if panicking.Load() {
+		panic(fmt.Sprintf("runtime error: index out of range [%d] with length %d", len(prices), len(prices)))
+	}
+	return &analysis.AnalysisResult{Symbol: prices[len(prices)-1].Symbol, Reason: "no setup"}
+}
+
+func init() {
+	strategy.Register("test_panicky", func(analysis.Config) strategy.Strategy { return panickyStrategy{} })
+}
+
+// pass runs one analysis pass of symbol on h, advancing clk past the symbol's jitter
+func pass(t *testing.T, h *AnalysisHandler, clk *clock.Fake, symbol string) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.runPass(context.Background(), symbol, &symbolState{})
+	}()
+	eventually(t, symbol+" to wait out its jitter", func() bool { return clk.Waiters() == 1 })
+	clk.Advance(analysisJitter)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s pass did not finish", symbol)
+	}
+}
+
+// healthOf returns symbol's entry in h's symbol health
+func healthOf(t *testing.T, h *AnalysisHandler, symbol string) SymbolHealth {
+	t.Helper()
+	for _, health := range h.SymbolHealth() {
+		if health.Symbol == symbol {
+			return health
+		}
+	}
+	t.Fatalf("no health for %s", symbol)
+	return SymbolHealth{}
+}
+
+func TestPanickingAnalyzerOnlyFailsItsSymbol(t *testing.T) {
+	h, db := newDBHandler(t, 1000)
+	panicky := strategy.DefaultParams()
+	panicky.Strategy = "test_panicky"
+	strategies, err := strategy.NewStrategyManagerWithOverrides(strategy.DefaultParams(), map[string]strategy.Params{"ETHUSDT": panicky}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h = NewAnalysisHandler(strategies, h.priceRepo, h.positionRepo, h.account, h.orderRepo, nil, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	clk := clock.NewFake(dbTestStart)
+	h.SetClock(clk)
+	h.UsePool(NewAnalysisPool(1))
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		if err := db.Create(risingWindow(symbol, h.window)).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	panicking.Store(true)
+	t.Cleanup(func() { panicking.Store(false) })
+	for range 2 {
+		pass(t, h, clk, "BTCUSDT")
+		pass(t, h, clk, "ETHUSDT")
+	}
+	if btc := healthOf(t, h, "BTCUSDT"); !btc.Healthy || btc.ConsecutiveFailures != 0 || !btc.LastSuccess.Equal(clk.Now().Add(-analysisJitter)) {
+		t.Errorf("BTCUSDT health = %+v, want analyzed on through ETHUSDT's panics", btc)
+	}
+	eth := healthOf(t, h, "ETHUSDT")
+	if eth.Healthy || eth.ConsecutiveFailures != 2 || !strings.HasPrefix(eth.LastError, "panic:") || !eth.LastSuccess.IsZero() {
+		t.Errorf("ETHUSDT health = %+v, want unhealthy after two panics", eth)
+	}
+
+	// The pool slot was released by the panicking passes, so the next pass is not held up
+	panicking.Store(false)
+	pass(t, h, clk, "ETHUSDT")
+	if eth := healthOf(t, h, "ETHUSDT"); !eth.Healthy || eth.ConsecutiveFailures != 0 || !eth.LastSuccess.Equal(clk.Now()) {
+		t.Errorf("ETHUSDT health = %+v, want recovered on the pass after the panics stop", eth)
+	}
+}
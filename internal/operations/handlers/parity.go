@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"time"
+)
+
+// ParityAnalyzer runs an AnalysisHandler's live analysis on given candles at given times through a fake
+// clock, the live side of backtesting's Parity check
+type ParityAnalyzer struct {
+	handler *AnalysisHandler
+	clock   *clock.Fake
+}
+
+// NewParityAnalyzer puts h on a fake clock driven by AnalyzeAt, h must not be started
+func NewParityAnalyzer(h *AnalysisHandler) *ParityAnalyzer {
+	fake := clock.NewFake(time.Time{})
+	h.SetClock(fake)
+	return &ParityAnalyzer{handler: h, clock: fake}
+}
+
+// Window returns how many 5m candles the handler reads for an analysis, see recentPrices
+func (p *ParityAnalyzer) Window() int {
+	return p.handler.window
+}
+
+// AnalyzeAt runs the handler's analysis at at on prices, as recentPrices would return them then,
+// and daily, as the handler loads its 1d candles
+func (p *ParityAnalyzer) AnalyzeAt(symbol string, prices, daily []models.Price, at time.Time) *analysis.AnalysisResult {
+	p.clock.Set(at)
+	return p.handler.analyzeWith(symbol, prices, func() ([]models.Price, error) {
+		return daily, nil
+	})
+}
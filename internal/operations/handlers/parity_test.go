@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/backtesting"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"testing"
+	"time"
+)
+
+// TestLiveAndBacktestDecideAlike replays the price fixture through the backtest's analysis and the live
+// handler's, no database involved, and fails on any candle they decide differently
+func TestLiveAndBacktestDecideAlike(t *testing.T) {
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const days = 5 // Of the fixture, enough for both sides' warm-up and a few days of signals
+	var prices []models.Price
+	symbols := make([]string, 0, len(testdb.FixtureSymbols))
+	for symbol := range testdb.FixtureSymbols {
+		prices = append(prices, testdb.FixturePrices(symbol, days)...)
+		symbols = append(symbols, symbol)
+	}
+
+	// The live handler only analyzes here, it needs none of its repositories
+	handler := NewAnalysisHandler(strategies, nil, nil, nil, nil, nil, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	engine := backtesting.NewBacktestWithConfig(backtesting.NewSliceSource(prices), strategies, backtesting.DefaultConfig())
+
+	// Two days in, so both windows are full and the daily bias has candles to read
+	start := testdb.FixtureStart.Add(2 * 24 * time.Hour)
+	end := testdb.FixtureStart.Add(days*24*time.Hour - time.Millisecond)
+	report, err := engine.Parity(context.Background(), NewParityAnalyzer(handler), start, end, symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Candles == 0 || report.Signals == 0 {
+		t.Fatalf("compared %d candles with %d signals, want the fixture's entries compared", report.Candles, report.Signals)
+	}
+	for i, divergence := range report.Divergences {
+		if i == 10 {
+			t.Errorf("... and %d more", len(report.Divergences)-10)
+			break
+		}
+		t.Errorf("%s %s: backtest %s, live %s", divergence.Backtest.Time.UTC().Format("2006-01-02 15:04"),
+			divergence.Backtest.Symbol, divergence.Backtest, divergence.Live)
+	}
+}
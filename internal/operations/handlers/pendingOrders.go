@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// placeLimitOrder rests a limit entry below (long) or above (short) the signal price
+func (h *AnalysisHandler) placeLimitOrder(result *analysis.AnalysisResult) error {
+	interval := models.TimeFrameDurations[models.PriceTimeFrame5m]
+
+	order := &models.PendingOrder{
+		Symbol:          result.Symbol,
+		Side:            result.Direction,
+		LimitPrice:      trading.LimitPrice(result.Direction, result.EntryPrice, h.entryConfig.LimitOffset),
+		SignalPrice:     result.EntryPrice,
+		StopLossPrice:   result.StopLoss,
+		TakeProfitPrice: result.TakeProfit,
+		Confidence:      result.Confidence,
+		SizeMultiplier:  result.SizeFactor(),
+		Confluence:      result.Confluence.JSON(),
+		EntryContext:    result.EntryContext().JSON(),
+		ExpiresAt:       h.clock.Now().Add(interval * time.Duration(h.entryConfig.ExpiryCandles)),
+		Status:          models.PendingOrderStatusPending,
+	}
+
+	if err := h.orderRepo.Create(order); err != nil {
+		return err
+	}
+
+	log.Printf("Placed %s limit order for %s at %.8f (signal %.8f)",
+		order.Side, order.Symbol, order.LimitPrice, order.SignalPrice)
+	return nil
+}
+
+// checkPendingOrders fills, expires or invalidates resting limit entries
+func (h *AnalysisHandler) checkPendingOrders(ctx context.Context) error {
+	orders, err := h.orderRepo.FindPending()
+	if err != nil {
+		return fmt.Errorf("failed to get pending orders: %v", err)
+	}
+
+	for i := range orders {
+		if err := h.checkPendingOrder(ctx, &orders[i]); err != nil {
+			log.Printf("Error checking pending order %d: %v", orders[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *AnalysisHandler) checkPendingOrder(ctx context.Context, order *models.PendingOrder) error {
+	latest, err := h.priceRepo.GetLatestPrice(ctx, order.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get price: %v", err)
+	}
+	if latest == nil {
+		return nil
+	}
+
+	currentPrice := latest.Close
+
+	switch {
+	case trading.LimitFilled(order.Side, order.LimitPrice, currentPrice, currentPrice):
+		confluence, err := analysis.ParseConfluence(order.Confluence)
+		if err != nil {
+			log.Printf("Limit order %d: %v", order.ID, err)
+		}
+		entryContext, err := analysis.ParseEntryContext(order.EntryContext)
+		if err != nil {
+			log.Printf("Limit order %d: %v", order.ID, err)
+		}
+		position, err := h.openPosition(ctx, &analysis.AnalysisResult{
+			Symbol:     order.Symbol,
+			Timestamp:  h.clock.Now(),
+			IsValid:    true,
+			Direction:  order.Side,
+			EntryPrice: order.LimitPrice,
+			TakeProfit: order.TakeProfitPrice,
+			StopLoss:   order.StopLossPrice,
+			Confidence: order.Confidence,
+			Confluence: confluence,
+			Context:    entryContext,
+
+			SizeMultiplier: order.SizeMultiplier,
+		}, models.PriceSourceLimit)
+		if errors.Is(err, errInvalidSignal) || errors.Is(err, trading.ErrSizeRejected) {
+			// Retrying would refuse the same levels or size on every pass until the order expires
+			order.Status = models.PendingOrderStatusInvalidated
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to open position: %v", err)
+		}
+		order.Status = models.PendingOrderStatusFilled
+		order.PositionID = position.ID
+
+	case trading.LimitInvalidated(order.Side, order.TakeProfitPrice, currentPrice, currentPrice):
+		order.Status = models.PendingOrderStatusInvalidated
+
+	case h.clock.Now().After(order.ExpiresAt):
+		order.Status = models.PendingOrderStatusExpired
+
+	default:
+		return nil
+	}
+
+	if err := h.orderRepo.Update(order); err != nil {
+		return fmt.Errorf("failed to update pending order: %v", err)
+	}
+
+	log.Printf("Limit order %d for %s %s", order.ID, order.Symbol, order.Status)
+	h.logFillRate()
+	return nil
+}
+
+// logFillRate reports how many limit entries have filled out of all resolved signals
+func (h *AnalysisHandler) logFillRate() {
+	counts, err := h.orderRepo.CountByStatus()
+	if err != nil {
+		return
+	}
+
+	resolved := counts[models.PendingOrderStatusFilled] +
+		counts[models.PendingOrderStatusExpired] +
+		counts[models.PendingOrderStatusInvalidated]
+	if resolved == 0 {
+		return
+	}
+
+	log.Printf("Limit entry fill rate: %d/%d (%.1f%%)",
+		counts[models.PendingOrderStatusFilled], resolved,
+		float64(counts[models.PendingOrderStatusFilled])/float64(resolved)*100)
+}
@@ -0,0 +1,71 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPaperLimitEntry(t *testing.T) {
+	tests := []struct {
+		name   string
+		closes []float64 // Latest price at each check, a 5m candle apart
+		status string
+		opened bool
+	}{
+		{"fills once price trades through", []float64{100.2, 99.9}, models.PendingOrderStatusFilled, true},
+		{"rests inside its window", []float64{100.2, 100.3}, models.PendingOrderStatusPending, false},
+		{"expires after its window", []float64{100.2, 100.3, 100.4, 100.5}, models.PendingOrderStatusExpired, false},
+		{"is dropped once price runs to the target", []float64{103.1}, models.PendingOrderStatusInvalidated, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newDBHandler(t, 1000)
+			h.entryConfig.Mode = trading.EntryModeLimit
+			h.entryConfig.ExpiryCandles = 3
+			clk := clock.NewFake(dbTestStart)
+			h.SetClock(clk)
+			ctx := context.Background()
+
+			// A long signalled at 100 rests at 99.95
+			if err := h.placeLimitOrder(&analysis.AnalysisResult{
+				Symbol: "BTCUSDT", IsValid: true, Direction: models.PositionSideLong,
+				EntryPrice: 100, StopLoss: 98, TakeProfit: 103, Confidence: 0.8,
+			}); err != nil {
+				t.Fatalf("placeLimitOrder() error = %v", err)
+			}
+
+			for i, close := range tt.closes {
+				clk.Advance(5*time.Minute + time.Second)
+				storeCandle(t, h, "BTCUSDT", dbTestStart.Add(time.Duration(i)*5*time.Minute), close)
+				if err := h.checkPendingOrders(ctx); err != nil {
+					t.Fatalf("checkPendingOrders() error = %v", err)
+				}
+			}
+
+			counts, err := h.orderRepo.CountByStatus()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if counts[tt.status] != 1 {
+				t.Errorf("orders by status = %v, want one %s", counts, tt.status)
+			}
+			positions, err := h.positionRepo.FindOpenPositionsBySymbol(ctx, "BTCUSDT")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(positions) == 1; got != tt.opened {
+				t.Fatalf("opened = %v, want %v", got, tt.opened)
+			}
+			if tt.opened && positions[0].EntryPrice != trading.LimitPrice(models.PositionSideLong, 100, h.entryConfig.LimitOffset) {
+				t.Errorf("EntryPrice = %v, want the limit", positions[0].EntryPrice)
+			}
+		})
+	}
+}
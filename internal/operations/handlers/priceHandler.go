@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/models"
 	"CryptoTradeBot/internal/operations/priceOperations"
 	"CryptoTradeBot/internal/repositories"
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
@@ -13,32 +18,102 @@ import (
 type PriceHandler struct {
 	priceRepo     *repositories.PriceRepository
 	futuresClient *futures.Client
+	limiter       *priceOperations.WeightLimiter
 	priceRecorder *priceOperations.PriceRecorder
 	priceFetcher  *priceOperations.PriceFetcher
+	clock         clock.Clock
+	bus           *events.Bus
+	spillPath     string
+	health        *priceOperations.ExchangeHealth    // Nil unless UseHealth was called
+	trades        *priceOperations.AggTradeCollector // Nil unless CollectOrderFlow was called
 }
 
-func NewPriceHandler(priceRepo *repositories.PriceRepository) *PriceHandler {
-	futuresClient := futures.NewClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"))
+func NewPriceHandler(priceRepo *repositories.PriceRepository, limiter *priceOperations.WeightLimiter) *PriceHandler {
+	futuresClient := priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), limiter)
 
 	return &PriceHandler{
 		priceRepo:     priceRepo,
 		futuresClient: futuresClient,
+		limiter:       limiter,
 		// Note: symbols will be passed in Start method
-		priceFetcher: priceOperations.NewPriceFetcher(futuresClient, nil),
+		priceFetcher: priceOperations.NewPriceFetcher(priceOperations.NewKlineClient(futuresClient), limiter, nil),
+		clock:        clock.Real,
+		spillPath:    priceOperations.DefaultSpillPath,
 	}
 }
 
+// SetClock replaces the wall clock that paces price recording
+func (h *PriceHandler) SetClock(c clock.Clock) {
+	h.clock = c
+}
+
+// PublishTo announces every recorded candle on bus, call it before Start
+func (h *PriceHandler) PublishTo(bus *events.Bus) {
+	h.bus = bus
+}
+
+// SpillTo sets the file recorded candles overflow to while the database is unavailable, call it before Start
+// An empty path keeps them in memory only, dropping what the write queue cannot hold
+func (h *PriceHandler) SpillTo(path string) {
+	h.spillPath = path
+}
+
+// UseHealth holds candle requests back while Binance or their symbol is degraded, call it before Start
+func (h *PriceHandler) UseHealth(health *priceOperations.ExchangeHealth) {
+	h.health = health
+}
+
+// CollectOrderFlow streams the trades of every recorded symbol into collector, call it before Start
+func (h *PriceHandler) CollectOrderFlow(collector *priceOperations.AggTradeCollector) {
+	h.trades = collector
+}
+
+// klines returns the kline client of the recorder and fetcher, guarded by the exchange health if any
+func (h *PriceHandler) klines() priceOperations.KlineClient {
+	client := priceOperations.NewKlineClient(h.futuresClient)
+	if h.health != nil {
+		return h.health.Guard(client)
+	}
+	return client
+}
+
+// Done is closed once recording has stopped after ctx was cancelled and its candles are saved or spilled
+func (h *PriceHandler) Done() <-chan struct{} {
+	if h.priceRecorder == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	return h.priceRecorder.Done()
+}
+
 func (h *PriceHandler) Start(ctx context.Context, symbols []string) error {
 	// Clear price table before starting
 	if err := h.priceRepo.ClearTable(); err != nil {
 		return err
 	}
 
+	// Candles an earlier run spilled are part of the history fetched below
+	if h.spillPath != "" {
+		if err := os.Remove(h.spillPath); err == nil {
+			log.Printf("Discarded spilled candles in %s, history is refetched", h.spillPath)
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear spill file: %v", err)
+		}
+	}
+
 	// Initialize PriceRecorder with symbols
-	h.priceRecorder = priceOperations.NewPriceRecorder(h.futuresClient, h.priceRepo, symbols)
+	h.priceRecorder = priceOperations.NewPriceRecorder(h.klines(), h.limiter, h.priceRepo, symbols, h.spillPath)
+	h.priceRecorder.SetClock(h.clock)
+	if h.bus != nil {
+		h.priceRecorder.PublishTo(h.bus)
+	}
 
 	// Update PriceFetcher with symbols
-	h.priceFetcher = priceOperations.NewPriceFetcher(h.futuresClient, symbols)
+	h.priceFetcher = priceOperations.NewPriceFetcher(h.klines(), h.limiter, symbols)
+	for _, symbol := range symbols {
+		h.probeListing(ctx, symbol)
+	}
 
 	// Fetch initial historical data
 	if err := h.fetchHistoricalData(ctx, symbols); err != nil {
@@ -47,32 +122,120 @@ func (h *PriceHandler) Start(ctx context.Context, symbols []string) error {
 
 	// Start real-time price recording
 	go h.priceRecorder.StartRecording(ctx)
+	if h.trades != nil {
+		h.trades.Start(ctx, symbols)
+	}
+
+	// Repair recorded candles Binance had not finalized when they were recorded
+	reconciler := priceOperations.NewReconciler(h.priceFetcher, h.priceRepo, h.priceRecorder.Symbols, h.priceRecorder.TimeFrames())
+	reconciler.SetClock(h.clock)
+	go reconciler.Run(ctx, priceOperations.DefaultReconcileInterval)
 
 	return nil
 }
 
-func (h *PriceHandler) fetchHistoricalData(ctx context.Context, symbols []string) error {
-	timeframes := map[string]int{
-		"5m":  30, // 30 days
-		"15m": 30, // 30 days
-		"1h":  30, // 30 days
-		"4h":  30, // 30 days
+// historyDays is how far back each timeframe is backfilled
+var historyDays = map[string]int{
+	"5m":  30,  // 30 days
+	"15m": 30,  // 30 days
+	"1h":  30,  // 30 days
+	"4h":  30,  // 30 days
+	"1d":  250, // EMA200 of the daily bias with some margin
+}
+
+// AddSymbol backfills the history of symbol and starts recording it
+// History already stored, say from an earlier time in rotation, is skipped.
+// It fails when the exchange does not know the symbol, leaving nothing recorded
+func (h *PriceHandler) AddSymbol(ctx context.Context, symbol string) error {
+	if h.priceRecorder == nil {
+		return fmt.Errorf("price handler not started")
 	}
 
-	for timeframe, days := range timeframes {
-		log.Printf("Fetching %s historical data for %d days", timeframe, days)
+	h.probeListing(ctx, symbol)
 
-		prices, err := h.priceFetcher.GetHistoricalPrices(ctx, timeframe, days)
+	end := h.clock.Now()
+	for timeframe, days := range historyDays {
+		result, err := h.priceFetcher.Backfill(ctx, []string{symbol}, timeframe, end.AddDate(0, 0, -days), end, h.storeHistory,
+			priceOperations.BackfillOptions{Coverage: h.priceRepo})
 		if err != nil {
 			return err
 		}
+		if err := result.Err(); err != nil {
+			return err
+		}
+	}
 
-		for _, price := range prices {
-			if err := h.priceRepo.Create(&price); err != nil {
-				log.Printf("Error saving historical price: %v", err)
-			}
+	h.priceRecorder.AddSymbol(symbol)
+	if h.trades != nil {
+		h.trades.AddSymbol(symbol)
+	}
+	return nil
+}
+
+// BackfillRange fetches and stores symbol's timeframe candles between start and end not stored yet
+func (h *PriceHandler) BackfillRange(ctx context.Context, symbol, timeframe string, start, end time.Time) error {
+	if h.priceFetcher == nil {
+		return fmt.Errorf("price handler not started")
+	}
+	result, err := h.priceFetcher.Backfill(ctx, []string{symbol}, timeframe, start, end, h.storeHistory,
+		priceOperations.BackfillOptions{Coverage: h.priceRepo})
+	if err != nil {
+		return err
+	}
+	return result.Err()
+}
+
+// probeListing finds when symbol was listed, a failure is logged and the symbol treated as long listed
+func (h *PriceHandler) probeListing(ctx context.Context, symbol string) {
+	if _, err := h.priceFetcher.ProbeListing(ctx, symbol); err != nil {
+		log.Printf("Error probing the listing of %s, assuming its history is complete: %v", symbol, err)
+	}
+}
+
+// Listing returns when symbol was listed on the exchange, false when it was not found
+func (h *PriceHandler) Listing(symbol string) (time.Time, bool) {
+	if h.priceFetcher == nil {
+		return time.Time{}, false
+	}
+	return h.priceFetcher.Listing(symbol)
+}
+
+// RemoveSymbol stops recording symbol, its stored history is kept
+func (h *PriceHandler) RemoveSymbol(symbol string) {
+	if h.priceRecorder != nil {
+		h.priceRecorder.RemoveSymbol(symbol)
+	}
+	if h.trades != nil {
+		h.trades.RemoveSymbol(symbol)
+	}
+}
+
+// fetchHistoricalData backfills every timeframe of symbols, topping up what is stored already
+// A symbol failing is logged and recorded from now on, its history filled by a later restart
+func (h *PriceHandler) fetchHistoricalData(ctx context.Context, symbols []string) error {
+	end := h.clock.Now()
+	for timeframe, days := range historyDays {
+		log.Printf("Fetching %s historical data for %d days", timeframe, days)
+
+		result, err := h.priceFetcher.Backfill(ctx, symbols, timeframe, end.AddDate(0, 0, -days), end, h.storeHistory,
+			priceOperations.BackfillOptions{Coverage: h.priceRepo})
+		if err != nil {
+			return err
+		}
+		if err := result.Err(); err != nil {
+			log.Printf("Error fetching %s historical data: %v", timeframe, err)
 		}
 	}
 
 	return nil
 }
+
+// storeHistory saves backfilled candles, a candle failing is logged and skipped
+func (h *PriceHandler) storeHistory(ctx context.Context, symbol string, prices []models.Price) error {
+	for i := range prices {
+		if err := h.priceRepo.Upsert(ctx, &prices[i]); err != nil {
+			log.Printf("Error saving historical price: %v", err)
+		}
+	}
+	return nil
+}
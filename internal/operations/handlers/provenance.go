@@ -0,0 +1,7 @@
+package handlers
+
+// StampConfig records hash, a stored models.ConfigSnapshot of the build and settings the account
+// runs, on every position it opens from now on
+func (h *AnalysisHandler) StampConfig(hash string) {
+	h.configHash = hash
+}
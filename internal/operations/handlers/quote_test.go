@@ -0,0 +1,134 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// quoteHandler returns a handler of the default account on db trading in the QUOTE_ASSET environment variable,
+// its balance created the way the bot initializes it
+func quoteHandler(t *testing.T, db *gorm.DB, initial float64) *AnalysisHandler {
+	t.Helper()
+	quote, err := trading.QuoteAssetFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	account := trading.NewAccountService(repositories.NewBalanceRepository(db), quote)
+	if err := account.Init(initial); err != nil {
+		t.Fatal(err)
+	}
+
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewAnalysisHandler(
+		strategies,
+		repositories.NewPriceRepository(db),
+		repositories.NewPositionRepository(db),
+		account,
+		repositories.NewPendingOrderRepository(db),
+		nil,
+		trading.DefaultEntryConfig(),
+		trading.DefaultReversalConfig(),
+	)
+}
+
+// balanceOf returns the default account's stored balance in asset, failing when it has none
+func balanceOf(t *testing.T, db *gorm.DB, asset string) float64 {
+	t.Helper()
+	balance, err := repositories.NewBalanceRepository(db).FindBySymbol(asset)
+	if err != nil || balance == nil {
+		t.Fatalf("FindBySymbol(%s) = %v, %v", asset, balance, err)
+	}
+	return balance.Balance
+}
+
+func TestUSDCQuoteOpensAndClosesOnTheUSDCBalance(t *testing.T) {
+	t.Setenv("QUOTE_ASSET", "usdc")
+	db := testdb.Open(t)
+	ctx := context.Background()
+	// A USDT balance beside it must be left alone
+	if err := repositories.NewBalanceRepository(db).Create(&models.Balance{Symbol: "USDT", Balance: 500, LastUpdated: dbTestStart}); err != nil {
+		t.Fatal(err)
+	}
+	h := quoteHandler(t, db, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+
+	if quote := h.account.QuoteAsset(); quote != "USDC" {
+		t.Fatalf("QuoteAsset() = %q, want USDC", quote)
+	}
+	if balance, err := h.account.Balance(); err != nil || balance == nil || balance.Symbol != "USDC" || balance.Balance != 1000 {
+		t.Fatalf("Balance() = %+v, %v, want the initial 1000 USDC", balance, err)
+	}
+
+	result := h.analyze("BTCUSDC", risingWindow("BTCUSDC", 250))
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	position, err := h.openPosition(ctx, result, "test")
+	if err != nil {
+		t.Fatalf("openPosition() error = %v", err)
+	}
+
+	rally := position.TakeProfitPrice * 1.001
+	storeCandle(t, h, "BTCUSDC", dbTestStart.Add(5*time.Minute), rally)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+
+	stored, err := h.positionRepo.FindByID(position.ID)
+	if err != nil || stored.Status != models.PositionStatusClosed {
+		t.Fatalf("position = %+v, %v, want closed at its target", stored, err)
+	}
+	// Balances are stored to 8 decimals
+	if got, want := balanceOf(t, db, "USDC"), 1000+stored.PnL; math.Abs(got-want) > 1e-6 || stored.PnL <= 0 {
+		t.Errorf("USDC balance = %v, want 1000 plus the %v won", got, stored.PnL)
+	}
+	if got := balanceOf(t, db, "USDT"); got != 500 {
+		t.Errorf("USDT balance = %v, want 500 untouched", got)
+	}
+	var transactions []models.Transaction
+	if err := db.Where("position_id = ?", position.ID).Find(&transactions).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || transactions[0].Symbol != "USDC" {
+		t.Errorf("transactions = %+v, want the close booked once in USDC", transactions)
+	}
+}
+
+func TestUSDCQuoteRefusesSymbolsWithoutTheirBalance(t *testing.T) {
+	t.Setenv("QUOTE_ASSET", "USDC")
+	db := testdb.Open(t)
+	h := quoteHandler(t, db, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+
+	if err := h.account.CheckSymbols([]string{"BTCUSDC", "ETHUSDT"}); err == nil || !strings.Contains(err.Error(), "no USDT balance") {
+		t.Errorf("CheckSymbols() error = %v, want ETHUSDT refused without a USDT balance", err)
+	}
+
+	// Booking a USDT symbol's PnL in USDC would mix currencies, so the entry is refused outright
+	result := h.analyze("ETHUSDT", risingWindow("ETHUSDT", 250))
+	if !result.IsValid {
+		t.Fatalf("no entry read from the window: %s", result.Reason)
+	}
+	if position, err := h.openPosition(context.Background(), result, "test"); err == nil {
+		t.Errorf("openPosition(ETHUSDT) = %+v, want refused on a USDC account", position)
+	}
+	if got := balanceOf(t, db, "USDC"); got != 1000 {
+		t.Errorf("USDC balance = %v, want 1000 untouched", got)
+	}
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// recoverOffline settles the open positions the monitor lost track of while the bot was down: each one's
+// stored 5m candles since its last check are replayed, and a position whose liquidation, stop or target
+// was crossed is closed at that level and at that candle as trading.CloseReasonRecoveredOffline. The
+// others resume monitoring. It runs once at startup, after the price history is refetched
+func (h *AnalysisHandler) recoverOffline(ctx context.Context) {
+	positions, err := h.positionRepo.FindOpenPositions()
+	if err != nil {
+		log.Printf("Error loading open positions of %s for offline recovery: %v", h.positionRepo.Account(), err)
+		return
+	}
+	if len(positions) == 0 {
+		return
+	}
+
+	interval := models.TimeFrameDurations[models.PriceTimeFrame5m]
+	now := h.clock.Now()
+	closed := 0
+	for i := range positions {
+		position := &positions[i]
+		exit, ok, err := h.replayOffline(ctx, position, interval, now)
+		if err != nil {
+			log.Printf("Error recovering position %d (%s): %v", position.ID, position.Symbol, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		log.Printf("Recovered %s %s (position %d): %s %.8f crossed at %s while offline",
+			position.Symbol, position.Side, position.ID, exit.Level, exit.Price, exit.At.UTC().Format(time.RFC3339))
+		pnl := calculatePnL(position, exit.Price)
+		if exit.Level == trading.OfflineLevelLiquidation {
+			pnl = -positionMargin(position)
+		}
+		position.CloseReason = trading.CloseReasonRecoveredOffline
+		position.ClosePriceSource = models.PriceSourceCandle
+		position.CloseTime = exit.At
+		if err := h.closePosition(ctx, position, exit.Price, pnl); err != nil {
+			log.Printf("Error closing recovered position %d: %v", position.ID, err)
+			continue
+		}
+		closed++
+	}
+	log.Printf("Offline recovery of %s: %d open positions checked, %d closed, %d resume monitoring",
+		h.positionRepo.Account(), len(positions), closed, len(positions)-closed)
+}
+
+// replayOffline replays the closed candles position missed up to now, from its last check or, when never
+// checked, from its opening, saving the stop and excursions the replay moved when it stays open
+func (h *AnalysisHandler) replayOffline(ctx context.Context, position *models.Position, interval time.Duration, now time.Time) (trading.OfflineExit, bool, error) {
+	// The candle the last check fell in is replayed again, its range may have grown after the check
+	start := position.OpenTime
+	if checked := position.CheckedAt.Add(-interval); checked.After(start) {
+		start = checked
+	}
+	end := now.Add(-interval)
+	if end.Before(start) {
+		return trading.OfflineExit{}, false, nil
+	}
+
+	candles, err := h.priceRepo.GetPricesByTimeFrame(ctx, position.Symbol, models.PriceTimeFrame5m, start, end)
+	if err != nil {
+		return trading.OfflineExit{}, false, fmt.Errorf("failed to get candles: %v", err)
+	}
+	if expected := int(end.Sub(start) / interval); len(candles) < expected {
+		log.Printf("Warning: %s has %d of %d 5m candles since %s, crossings in the gaps are missed",
+			position.Symbol, len(candles), expected, start.UTC().Format(time.RFC3339))
+	}
+
+	stop := position.StopLossPrice
+	mae, mfe := position.MAE, position.MFE
+	exit, ok := trading.ReplayOffline(position, candles, h.breakevenAtR)
+	if ok {
+		return exit, true, nil
+	}
+	if position.StopLossPrice != stop || position.MAE != mae || position.MFE != mfe {
+		position.UpdatedAt = now
+		if err := h.positionRepo.Update(position); err != nil {
+			return exit, false, fmt.Errorf("failed to save replayed position: %v", err)
+		}
+	}
+	return exit, false, nil
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRecoveryClosesAtTheStopCrossedWhileDown(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	ctx := context.Background()
+
+	// Stop at 90 inside the liquidation price at 89: a fall through both fills the stop on the way
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	position.LiquidationPrice = 89
+	if err := h.positionRepo.Update(position); err != nil {
+		t.Fatal(err)
+	}
+	stillOpen := storePosition(t, h, "ETHUSDT", models.PositionSideLong, 100, 1)
+
+	// Down for two hours; BTCUSDT fell to 88 on the candle at 00:30
+	crossedAt := dbTestStart.Add(30 * time.Minute)
+	for open := dbTestStart; open.Before(dbTestStart.Add(2 * time.Hour)); open = open.Add(5 * time.Minute) {
+		for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+			low := 99.0
+			if symbol == "BTCUSDT" && open.Equal(crossedAt) {
+				low = 88
+			}
+			price := &models.Price{Symbol: symbol, TimeFrame: models.PriceTimeFrame5m, OpenTime: open,
+				CloseTime: open.Add(5*time.Minute - time.Millisecond), Open: 100, High: 101, Low: low, Close: 100, Volume: 10}
+			if err := h.priceRepo.Create(ctx, price); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	h.SetClock(clock.NewFake(dbTestStart.Add(2 * time.Hour)))
+
+	h.recoverOffline(ctx)
+
+	recovered, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered.Status != models.PositionStatusClosed || recovered.CloseReason != trading.CloseReasonRecoveredOffline || !recovered.CloseTime.Equal(crossedAt) {
+		t.Errorf("position %s as %q at %v, want closed as %q at %v",
+			recovered.Status, recovered.CloseReason, recovered.CloseTime, trading.CloseReasonRecoveredOffline, crossedAt)
+	}
+	if want := calculatePnL(position, 90); math.Abs(recovered.PnL-want) > 1e-9 {
+		t.Errorf("recovered PnL %v, want %v from the 90 stop, not the liquidation", recovered.PnL, want)
+	}
+	if recovered.ClosePriceSource != models.PriceSourceCandle {
+		t.Errorf("close price from %q, want the candle", recovered.ClosePriceSource)
+	}
+
+	resumed, err := h.positionRepo.FindByID(stillOpen.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resumed.Status != models.PositionStatusOpen {
+		t.Errorf("ETHUSDT %s, want it left open to resume monitoring", resumed.Status)
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// checkReversal analyzes an open position's symbol once per candle and flips the position
+// when an opposite signal clears the reversal guards
+func (h *AnalysisHandler) checkReversal(ctx context.Context, position *models.Position, lastCheck *time.Time) error {
+	if !h.reversals.Enabled {
+		return nil
+	}
+
+	prices, err := h.recentPrices(position.Symbol)
+	if err != nil {
+		return err
+	}
+	if len(prices) < 10 {
+		return nil
+	}
+
+	candle := prices[len(prices)-1].OpenTime
+	if !candle.After(*lastCheck) {
+		return nil
+	}
+	*lastCheck = candle
+
+	result := h.analyze(position.Symbol, prices)
+	if !result.IsValid || result.Direction == position.Side {
+		return nil
+	}
+
+	now := h.clock.Now()
+	today, err := h.positionRepo.CountReversalsSince(position.Symbol, now.UTC().Truncate(24*time.Hour))
+	if err != nil {
+		return fmt.Errorf("failed to count reversals: %v", err)
+	}
+
+	ok, reason := trading.ShouldReverse(h.reversals, position.Side, position.Confidence, position.OpenTime,
+		result, now, int(today))
+	if !ok {
+		log.Printf("Reversal of %s %s refused: %s", position.Symbol, position.Side, reason)
+		return nil
+	}
+
+	if blocked, reason := h.checkVetoes(ctx, result); blocked {
+		log.Printf("Reversal of %s vetoed: %s", position.Symbol, reason)
+		return nil
+	}
+
+	return h.reversePosition(ctx, position, result)
+}
+
+// reversePosition closes position and opens the opposite side at market, the live price when available
+func (h *AnalysisHandler) reversePosition(ctx context.Context, position *models.Position, result *analysis.AnalysisResult) error {
+	result, source := h.fillAtMarket(result)
+	if err := h.validateResult(result); err != nil {
+		return fmt.Errorf("failed to reverse position %d: %v", position.ID, err)
+	}
+	closePrice := result.EntryPrice
+	pnl := calculatePnL(position, closePrice)
+	now := h.clock.Now()
+
+	position.CloseTime = now
+	position.Status = models.PositionStatusClosed
+	position.CloseReason = "reversal"
+	position.PnL = pnl
+	trading.RecordRMultiple(position)
+	position.ClosePriceSource = source
+	position.UpdatedAt = now
+
+	opening := newPosition(result, now, h.riskMultiplier(pnl))
+	opening.EntryPriceSource = source
+	opening.ConfigHash = h.configHash
+
+	// The opening is sized against the balance once the closing position's PnL is booked
+	balance, err := h.account.BalanceFor(position.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to reverse position %d: %v", position.ID, err)
+	}
+	if err := h.guardSize(opening, balance.Balance+pnl); err != nil {
+		return fmt.Errorf("failed to reverse position %d: %v", position.ID, err)
+	}
+
+	quote, err := h.account.QuoteFor(position.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to reverse position %d: %v", position.ID, err)
+	}
+	if _, err := h.positionRepo.Reverse(position, opening, quote); err != nil {
+		return fmt.Errorf("failed to reverse position %d: %v", position.ID, err)
+	}
+	h.recordBalance(ctx)
+
+	log.Printf("Reversed %s %s -> %s at %.8f | PnL: %.2f %s | Confidence %.2f -> %.2f",
+		position.Symbol, position.Side, opening.Side, closePrice, pnl, quote, position.Confidence, opening.Confidence)
+	h.notify(notifications.Event{
+		Severity: notifications.SeverityTrade,
+		Title:    "Position reversed",
+		Message: fmt.Sprintf("%s %s -> %s at %.8f | PnL: %.2f %s\nTimeframes: %s",
+			position.Symbol, position.Side, opening.Side, closePrice, pnl, quote, result.Confluence),
+		Symbol:  position.Symbol,
+		PnL:     pnl,
+		Account: h.positionRepo.Account(),
+	})
+
+	return nil
+}
@@ -0,0 +1,67 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReversePositionFlipsOpenPosition(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(30 * time.Minute))
+	h.SetClock(clk)
+	ctx := context.Background()
+
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	position.Confidence = 0.7
+	if err := h.positionRepo.Update(position); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stronger short signal at 98 closes the long at a loss of 2 and opens the short in its place
+	signal := &analysis.AnalysisResult{
+		Symbol: "BTCUSDT", IsValid: true, Direction: models.PositionSideShort,
+		EntryPrice: 98, StopLoss: 99.5, TakeProfit: 95, Confidence: 0.85,
+	}
+	if err := h.reversePosition(ctx, position, signal); err != nil {
+		t.Fatalf("reversePosition() error = %v", err)
+	}
+
+	closed, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.Status != models.PositionStatusClosed || closed.CloseReason != "reversal" || math.Abs(closed.PnL+2) > 1e-9 {
+		t.Errorf("reversed position = %s %s PnL %v, want closed by reversal with PnL -2", closed.Status, closed.CloseReason, closed.PnL)
+	}
+	if got := usdtBalance(t, h); math.Abs(got-998) > 1e-9 {
+		t.Errorf("balance = %v, want 998", got)
+	}
+
+	open, err := h.positionRepo.FindOpenPositionsBySymbol(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 {
+		t.Fatalf("%d open positions, want the short alone", len(open))
+	}
+	short := open[0]
+	if short.Side != models.PositionSideShort || short.ReversedFromID != position.ID || short.EntryPrice != 98 {
+		t.Errorf("opened %s at %v reversed from %d, want a short at 98 from %d", short.Side, short.EntryPrice, short.ReversedFromID, position.ID)
+	}
+
+	// The next reversal compares against the new position's confidence and counts towards the daily cap
+	if short.Confidence != 0.85 {
+		t.Errorf("stored Confidence = %v, want 0.85", short.Confidence)
+	}
+	count, err := h.positionRepo.CountReversalsSince("BTCUSDT", dbTestStart.Truncate(24*time.Hour))
+	if err != nil || count != 1 {
+		t.Errorf("CountReversalsSince() = %d, %v, want 1", count, err)
+	}
+}
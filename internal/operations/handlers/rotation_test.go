@@ -0,0 +1,60 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSymbolsAddedAndRemovedAtRuntime(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(10 * time.Minute))
+	h.SetClock(clk)
+
+	// An open BTCUSDT long exiting at 90 or 110
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 105)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Start(ctx, []string{"BTCUSDT"})
+	}()
+	defer func() {
+		cancel()
+		clk.Advance(analysisJitter) // Wakes a pass sleeping out its jitter
+		<-done
+	}()
+
+	// The position monitor and one analysis loop per symbol wait on the clock
+	eventually(t, "the BTCUSDT analysis", func() bool { return clk.Waiters() == 2 })
+
+	h.AddSymbol("ETHUSDT")
+	h.AddSymbol("ETHUSDT")
+	eventually(t, "the ETHUSDT analysis", func() bool { return clk.Waiters() == 3 })
+	if got := h.Symbols(); !reflect.DeepEqual(got, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Fatalf("Symbols() = %v after adding ETHUSDT", got)
+	}
+
+	if err := h.RemoveSymbol("BTCUSDT"); err != nil {
+		t.Fatalf("RemoveSymbol() error = %v", err)
+	}
+	eventually(t, "the BTCUSDT analysis to stop", func() bool { return clk.Waiters() == 2 })
+	if got := h.Symbols(); !reflect.DeepEqual(got, []string{"ETHUSDT"}) {
+		t.Fatalf("Symbols() = %v after removing BTCUSDT", got)
+	}
+
+	// The removed symbol's open position is still monitored to its exit
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 111)
+	clk.Advance(15 * time.Second)
+	eventually(t, "the BTCUSDT close", func() bool {
+		p, err := h.positionRepo.FindByID(position.ID)
+		return err == nil && p.Status == models.PositionStatusClosed && p.CloseReason == "take_profit"
+	})
+}
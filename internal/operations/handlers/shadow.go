@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/notifications"
+	"log"
+)
+
+// RunAsShadow makes the account a shadow of the primary: it analyzes the same feed and its monitor
+// tracks its virtual positions against the same prices, but its decisions are only recorded,
+// logged instead of notified, so a parameter set can be compared before it is promoted
+func (h *AnalysisHandler) RunAsShadow() {
+	h.shadow = true
+}
+
+// Shadow reports whether the account runs as a shadow
+func (h *AnalysisHandler) Shadow() bool {
+	return h.shadow
+}
+
+// notify sends event through the notifier, or only logs it for a shadow account
+func (h *AnalysisHandler) notify(event notifications.Event) {
+	if h.shadow {
+		log.Printf("[shadow %s] %s: %s", h.positionRepo.Account(), event.Title, event.Message)
+		return
+	}
+	h.notifier.Notify(event)
+}
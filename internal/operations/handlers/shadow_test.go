@@ -0,0 +1,123 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// subjectsChannel keeps the subjects of the notifications sent to it
+type subjectsChannel struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (c *subjectsChannel) Name() string { return "subjects" }
+
+func (c *subjectsChannel) Send(ctx context.Context, subject, body string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func TestShadowTracksItsOwnPositionsAndBalance(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	clk := clock.NewFake(dbTestStart.Add(10 * time.Minute))
+	channel := &subjectsChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+
+	// The shadow runs a candidate parameter set with a further target beside the primary
+	live, candidate := strategy.DefaultParams(), strategy.DefaultParams()
+	live.TargetProfit, candidate.TargetProfit = 0.01, 0.03
+	primary := newAccountHandler(t, db, "primary", live, 1000)
+	shadow := newAccountHandler(t, db, "candidate", candidate, 1000)
+	shadow.RunAsShadow()
+	entries := make(map[*AnalysisHandler]*models.Position)
+	for _, h := range []*AnalysisHandler{primary, shadow} {
+		h.SetClock(clk)
+		h.notifier = notifier
+		result := h.analyze("BTCUSDT", risingWindow("BTCUSDT", 250))
+		if !result.IsValid {
+			t.Fatalf("%s read no entry from the window: %s", h.positionRepo.Account(), result.Reason)
+		}
+		position, err := h.openPosition(ctx, result, "test")
+		if err != nil {
+			t.Fatalf("%s openPosition() error = %v", h.positionRepo.Account(), err)
+		}
+		entries[h] = position
+	}
+	if primary.Shadow() || !shadow.Shadow() {
+		t.Fatalf("Shadow() = %v and %v, want only the candidate a shadow", primary.Shadow(), shadow.Shadow())
+	}
+	entry := entries[primary].EntryPrice
+
+	// Scripted feed: 2% up closes the primary's trade only, 3.5% up then closes the shadow's
+	script := []struct {
+		move                    float64
+		primaryOpen, shadowOpen bool
+	}{
+		{1.02, false, true},
+		{1.035, false, false},
+	}
+	for i, step := range script {
+		openTime := dbTestStart.Add(time.Duration(i+1) * 5 * time.Minute)
+		clk.Set(openTime.Add(5 * time.Minute))
+		storeCandle(t, primary, "BTCUSDT", openTime, entry*step.move)
+		for _, h := range []*AnalysisHandler{primary, shadow} {
+			if err := h.checkOpenPositions(ctx); err != nil {
+				t.Fatalf("%s checkOpenPositions() error = %v", h.positionRepo.Account(), err)
+			}
+		}
+		for h, open := range map[*AnalysisHandler]bool{primary: step.primaryOpen, shadow: step.shadowOpen} {
+			stored, err := h.positionRepo.FindByID(entries[h].ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (stored.Status == models.PositionStatusOpen) != open {
+				t.Errorf("after %vx %s position is %s, want open %v", step.move, h.positionRepo.Account(), stored.Status, open)
+			}
+		}
+	}
+
+	// Each account holds its own position and booked only its own PnL
+	for h, rally := range map[*AnalysisHandler]float64{primary: entry * 1.02, shadow: entry * 1.035} {
+		positions, err := h.positionRepo.FindAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(positions) != 1 || positions[0].ID != entries[h].ID {
+			t.Errorf("%s positions = %+v, want only its own", h.positionRepo.Account(), positions)
+		}
+		// Balances are stored to 8 decimals
+		if got, want := usdtBalance(t, h), 1000+calculatePnL(entries[h], rally); math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s balance = %v, want %v", h.positionRepo.Account(), got, want)
+		}
+	}
+
+	// The shadow's decisions are only logged
+	channel.mu.Lock()
+	defer channel.mu.Unlock()
+	notified := false
+	for _, subject := range channel.subjects {
+		if strings.Contains(subject, "(candidate)") {
+			t.Errorf("the shadow notified %q", subject)
+		}
+		notified = notified || strings.Contains(subject, "(primary)")
+	}
+	if !notified {
+		t.Errorf("notifications %v, want the primary's trades notified", channel.subjects)
+	}
+}
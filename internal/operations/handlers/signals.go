@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SignalStatus is the outcome of the latest analysis of a symbol
+type SignalStatus struct {
+	Symbol     string    `json:"symbol"`
+	Time       time.Time `json:"time"`
+	Valid      bool      `json:"valid"`
+	Direction  string    `json:"direction,omitempty"`
+	Confidence float64   `json:"confidence"`
+	Reason     string    `json:"reason"`
+
+	// A valid setup repeated by later passes: when it was first seen and how often it was refreshed since
+	Since     time.Time `json:"since,omitempty"`
+	Refreshes int       `json:"refreshes,omitempty"`
+}
+
+// signalBoard keeps the latest SignalStatus per symbol for read-only consumers
+type signalBoard struct {
+	mu     sync.RWMutex
+	latest map[string]SignalStatus
+	setups map[string]seenSignal // Valid setup each symbol's latest status belongs to, see refreshed
+}
+
+func newSignalBoard() *signalBoard {
+	return &signalBoard{latest: make(map[string]SignalStatus), setups: make(map[string]seenSignal)}
+}
+
+// record stores the outcome of an analysis at time at, reason overrides the result's own when set
+func (b *signalBoard) record(at time.Time, result *analysis.AnalysisResult, reason string) {
+	if reason == "" {
+		reason = result.Reason
+	}
+	if reason == "" && result.IsValid {
+		reason = fmt.Sprintf("%s entry %s", result.Direction, result.Confluence)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := SignalStatus{
+		Symbol:     result.Symbol,
+		Time:       at,
+		Valid:      result.IsValid,
+		Direction:  result.Direction,
+		Confidence: result.Confidence,
+		Reason:     reason,
+	}
+	if setup, ok := b.setups[result.Symbol]; ok && result.IsValid && setup.Fingerprint.Direction == result.Direction {
+		status.Since, status.Refreshes = setup.FirstSeen, setup.Refreshes
+	} else {
+		delete(b.setups, result.Symbol)
+	}
+	b.latest[result.Symbol] = status
+}
+
+// refreshed notes the setup symbol's next valid status belongs to
+func (b *signalBoard) refreshed(symbol string, setup seenSignal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setups[symbol] = setup
+}
+
+// remove forgets the latest outcome of symbol
+func (b *signalBoard) remove(symbol string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.latest, symbol)
+	delete(b.setups, symbol)
+}
+
+// LatestSignals returns the latest analysis outcome of every symbol, sorted by symbol
+func (h *AnalysisHandler) LatestSignals() []SignalStatus {
+	h.signals.mu.RLock()
+	defer h.signals.mu.RUnlock()
+
+	statuses := make([]SignalStatus, 0, len(h.signals.latest))
+	for _, status := range h.signals.latest {
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Symbol < statuses[j].Symbol
+	})
+	return statuses
+}
+
+// TallyRejections records why signals were rejected so reports can rank the reasons
+func (h *AnalysisHandler) TallyRejections(signalRepo *repositories.SignalRepository) {
+	h.signalRepo = signalRepo
+}
+
+// tallyRejection counts a rejection once per candle, the analysis loop polls each candle several times
+func (h *AnalysisHandler) tallyRejection(symbol, reason string, candle time.Time, lastTallied *time.Time) {
+	if h.signalRepo == nil || !candle.After(*lastTallied) {
+		return
+	}
+	*lastTallied = candle
+	if err := h.signalRepo.RecordRejection(symbol, reason, candle); err != nil {
+		log.Printf("Error tallying rejection for %s: %v", symbol, err)
+	}
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"log"
+)
+
+// UseSizing bounds the size of every position the handler opens from now on, see trading.SizingConfig
+func (h *AnalysisHandler) UseSizing(config trading.SizingConfig) {
+	h.sizing = &config
+}
+
+// guardSize applies the sizing guards to an unsaved position for an account holding balance, recording
+// the size asked for and the guard that changed it; the liquidation price follows the granted size
+func (h *AnalysisHandler) guardSize(position *models.Position, balance float64) error {
+	if h.sizing == nil {
+		return nil
+	}
+
+	decision, err := h.sizing.Guard(position.Symbol, position.Size, position.EntryPrice, balance, position.Leverage)
+	if err != nil {
+		log.Printf("%s %s not opened: %v", position.Symbol, position.Side, err)
+		return err
+	}
+
+	position.RequestedSize = decision.Requested
+	position.SizeGuard = decision.Reason
+	if decision.Reason != "" {
+		log.Printf("Sized %s %s to %.8f instead of %.8f (%s)",
+			position.Symbol, position.Side, decision.Granted, decision.Requested, decision.Reason)
+		position.Size = decision.Granted
+		position.LiquidationPrice = trading.PositionLiquidationPrice(position.Symbol, position.Side,
+			position.EntryPrice, position.Size, position.Leverage)
+	}
+	return nil
+}
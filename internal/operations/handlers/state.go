@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"log"
+	"time"
+)
+
+// symbolStateVersion is the layout of symbolState, bump it when the fields change meaning
+const symbolStateVersion = 1
+
+// symbolState is what an analysis loop remembers about its symbol between candles
+type symbolState struct {
+	LastReversalCheck time.Time `json:"last_reversal_check"` // Open time of the last candle checked for a reversal
+	LastTallied       time.Time `json:"last_tallied"`        // Open time of the last candle whose rejection was tallied
+	LastCadenceClose  time.Time `json:"last_cadence_close"`  // Close of the last cadence candle analyzed, for cadences above 5m
+}
+
+// PersistState keeps each symbol's analysis state and the account's pause across restarts,
+// so a restarted bot does not re-check or re-tally the candles it already handled
+func (h *AnalysisHandler) PersistState(stateRepo *repositories.BotStateRepository) {
+	h.stateRepo = stateRepo
+	h.restorePause()
+}
+
+func symbolStateKey(symbol string) string {
+	return "analysis/" + symbol
+}
+
+// loadSymbolState restores the state saved for symbol, starting fresh when there is none
+func (h *AnalysisHandler) loadSymbolState(symbol string) symbolState {
+	var state symbolState
+	if h.stateRepo == nil {
+		return state
+	}
+
+	found, err := h.stateRepo.Load(symbolStateKey(symbol), symbolStateVersion, &state)
+	if err != nil {
+		log.Printf("Error loading state for %s: %v", symbol, err)
+	}
+	if !found {
+		return symbolState{}
+	}
+	log.Printf("Restored %s state: last reversal check %s, last tally %s", symbol,
+		state.LastReversalCheck.UTC().Format(time.RFC3339), state.LastTallied.UTC().Format(time.RFC3339))
+	return state
+}
+
+// saveSymbolState stores state when it differs from saved, then makes it the saved one
+func (h *AnalysisHandler) saveSymbolState(symbol string, state symbolState, saved *symbolState) {
+	if h.stateRepo == nil || state == *saved {
+		return
+	}
+	if err := h.stateRepo.Save(symbolStateKey(symbol), symbolStateVersion, state); err != nil {
+		log.Printf("Error saving state for %s: %v", symbol, err)
+		return
+	}
+	*saved = state
+}
@@ -0,0 +1,100 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/strategy"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// restarted returns the default account's handler as a restarted bot builds it: fresh in memory,
+// with its state and equity stop read back from db, reading time from clk
+func restarted(t *testing.T, db *gorm.DB, clk clock.Clock) *AnalysisHandler {
+	t.Helper()
+	h := accountHandler(t, db, models.DefaultAccount, strategy.DefaultParams())
+	h.SetClock(clk)
+	stateRepo := repositories.NewBotStateRepository(db)
+	h.PersistState(stateRepo)
+	h.UseEquityStop(risk.NewEquityStop(risk.DefaultEquityStopConfig(), stateRepo))
+	return h
+}
+
+func TestRestartMidCooldownKeepsEntriesBlocked(t *testing.T) {
+	_, db := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(time.Hour))
+	ctx := context.Background()
+	signal := &analysis.AnalysisResult{Symbol: "BTCUSDT", IsValid: true, Direction: models.PositionSideLong,
+		EntryPrice: 100, StopLoss: 99, TakeProfit: 102, Confidence: 0.8}
+
+	// The session starts at 1000 and trips below 900, blocking entries for the rest of the UTC day
+	before := restarted(t, db, clk)
+	if _, err := before.equityStop.Check(1000, clk.Now()); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(time.Hour)
+	if tripped, err := before.equityStop.Check(880, clk.Now()); err != nil || !tripped {
+		t.Fatalf("Check(880) = %v, %v, want tripped", tripped, err)
+	}
+
+	// Restarted an hour later, mid-cooldown, the stop read back still blocks entries
+	clk.Advance(time.Hour)
+	after := restarted(t, db, clk)
+	if blocked, reason := after.checkVetoes(ctx, signal); !blocked || !strings.Contains(reason, "equity stop") {
+		t.Fatalf("checkVetoes() after the restart = %v, %q, want blocked by the equity stop", blocked, reason)
+	}
+
+	// The next UTC day the cooldown is over
+	clk.Set(dbTestStart.Add(24*time.Hour + 5*time.Minute))
+	if blocked, reason := restarted(t, db, clk).checkVetoes(ctx, signal); blocked {
+		t.Errorf("checkVetoes() the next day blocked: %s", reason)
+	}
+}
+
+func TestRestartKeepsPauseAndSymbolState(t *testing.T) {
+	_, db := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart.Add(time.Hour))
+
+	before := restarted(t, db, clk)
+	if err := before.Pause("manual"); err != nil {
+		t.Fatal(err)
+	}
+	state := symbolState{
+		LastReversalCheck: dbTestStart.Add(40 * time.Minute),
+		LastTallied:       dbTestStart.Add(45 * time.Minute),
+		LastCadenceClose:  dbTestStart.Add(time.Hour),
+	}
+	before.saveSymbolState("BTCUSDT", state, &symbolState{})
+
+	after := restarted(t, db, clk)
+	if pause := after.PauseState(); !pause.Paused || pause.Reason != "manual" {
+		t.Errorf("PauseState() after the restart = %+v, want paused manually", pause)
+	}
+	got := after.loadSymbolState("BTCUSDT")
+	if !got.LastReversalCheck.Equal(state.LastReversalCheck) || !got.LastTallied.Equal(state.LastTallied) ||
+		!got.LastCadenceClose.Equal(state.LastCadenceClose) {
+		t.Errorf("loadSymbolState() after the restart = %+v, want %+v", got, state)
+	}
+
+	// State saved under another layout is dropped, the symbol starts fresh rather than misreading it
+	stateRepo := repositories.NewBotStateRepository(db)
+	if err := stateRepo.Save(symbolStateKey("ETHUSDT"), symbolStateVersion+1, map[string]int{"last_tallied": 7}); err != nil {
+		t.Fatal(err)
+	}
+	if got := after.loadSymbolState("ETHUSDT"); got != (symbolState{}) {
+		t.Errorf("loadSymbolState() of another version = %+v, want a fresh state", got)
+	}
+	var discarded symbolState
+	if found, err := stateRepo.Load(symbolStateKey("ETHUSDT"), symbolStateVersion+1, &discarded); err != nil || found {
+		t.Errorf("Load() of the discarded state = %v, %v, want it deleted", found, err)
+	}
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"log"
+)
+
+// UpdateStops moves an open position's stop loss and take profit, checked against price by
+// trading.ValidateStops, and records the move in the position's stop changes
+// An update leaving both levels where they are writes nothing
+func (h *AnalysisHandler) UpdateStops(position *models.Position, update trading.StopUpdate, price float64) error {
+	previousStop, previousTarget := position.StopLossPrice, position.TakeProfitPrice
+	change, err := trading.ApplyStopUpdate(position, update, price)
+	if err != nil || change == nil {
+		return err
+	}
+
+	position.UpdatedAt = h.clock.Now()
+	if err := h.positionRepo.UpdateStops(position, change); err != nil {
+		position.StopLossPrice, position.TakeProfitPrice = previousStop, previousTarget
+		return err
+	}
+
+	log.Printf("Moving %s %s stops (%s): stop %.8f -> %.8f, target %.8f -> %.8f", position.Symbol, position.Side,
+		update.Reason, change.OldStopLoss, change.NewStopLoss, change.OldTakeProfit, change.NewTakeProfit)
+	return nil
+}
+
+// manageStops lets the position's strategy move its levels once per 5m candle closed while it is open
+// Runs on the monitor only, after the exit check, so new levels apply from its next pass
+func (h *AnalysisHandler) manageStops(position *models.Position, price float64) {
+	latest, err := h.priceRepo.GetLatestPriceByTimeFrame(position.Symbol, models.PriceTimeFrame5m)
+	if err != nil || latest == nil {
+		return
+	}
+	if latest.OpenTime.Before(position.OpenTime) || !latest.OpenTime.After(h.managed[position.ID]) {
+		return
+	}
+	h.managed[position.ID] = latest.OpenTime
+
+	prices, err := h.recentPrices(position.Symbol)
+	if err != nil || len(prices) == 0 {
+		return
+	}
+	adjustment := h.strategies.ManagePosition(position, prices)
+	if adjustment == nil {
+		return
+	}
+
+	update := trading.StopUpdate{StopLoss: adjustment.StopLoss, TakeProfit: adjustment.TakeProfit, Reason: adjustment.Reason}
+	if err := h.UpdateStops(position, update, price); err != nil {
+		log.Printf("Skipping %s stop adjustment of position %d: %v", adjustment.Reason, position.ID, err)
+	}
+}
+
+// forgetManaged drops the managed candle of every position no longer in open
+func (h *AnalysisHandler) forgetManaged(open []models.Position) {
+	ids := make(map[uint]bool, len(open))
+	for _, position := range open {
+		ids[position.ID] = true
+	}
+	for id := range h.managed {
+		if !ids[id] {
+			delete(h.managed, id)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// stopRaiser is a strategy that never enters and asks for an open long's stop at 101
+type stopRaiser struct{}
+
+func (stopRaiser) Analyze(prices []models.Price) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{Symbol: prices[len(prices)-1].Symbol, Reason: "stop raiser"}
+}
+
+func (stopRaiser) ManagePosition(position *models.Position, prices []models.Price) *analysis.StopAdjustment {
+	return &analysis.StopAdjustment{StopLoss: 101, Reason: "stop-raiser"}
+}
+
+func TestManagedStopIsRecordedAndExitsTheLong(t *testing.T) {
+	strategy.Register("stop-raiser", func(analysis.Config) strategy.Strategy { return stopRaiser{} })
+	params := strategy.DefaultParams()
+	params.Strategy = "stop-raiser"
+	h := newAccountHandler(t, testdb.Open(t), models.DefaultAccount, params, 1000)
+	clk := clock.NewFake(dbTestStart.Add(10 * time.Minute))
+	h.SetClock(clk)
+	ctx := context.Background()
+
+	// A long from 100 with its stop at 90; the candle closing at 105 leaves it open and raises the stop
+	position := storePosition(t, h, "BTCUSDT", models.PositionSideLong, 100, 1)
+	storeCandle(t, h, "BTCUSDT", dbTestStart, 105)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	stored, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.PositionStatusOpen || stored.StopLossPrice != 101 || stored.TakeProfitPrice != 110 {
+		t.Fatalf("position %+v, want it open with its stop raised to 101", stored)
+	}
+	changes, err := h.positionRepo.StopChanges(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].OldStopLoss != 90 || changes[0].NewStopLoss != 101 || changes[0].Reason != "stop-raiser" || changes[0].Price != 105 {
+		t.Fatalf("StopChanges() = %+v, want the one raise from 90 to 101 at 105", changes)
+	}
+
+	// The next candle dips to 100.5, above the original stop but through the raised one
+	clk.Advance(5 * time.Minute)
+	storeCandle(t, h, "BTCUSDT", dbTestStart.Add(5*time.Minute), 100.5)
+	if err := h.checkOpenPositions(ctx); err != nil {
+		t.Fatalf("checkOpenPositions() error = %v", err)
+	}
+	closed, err := h.positionRepo.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if closed.Status != models.PositionStatusClosed || closed.CloseReason != "stop_loss" || math.Abs(closed.PnL-calculatePnL(stored, 100.5)) > 1e-6 {
+		t.Errorf("position %+v, want a stop loss at 100.5", closed)
+	}
+
+	// A closed position's stops no longer move
+	if err := h.UpdateStops(closed, trading.StopUpdate{StopLoss: 95, TakeProfit: 120, Override: true, Reason: "manual"}, 100); !errors.Is(err, repositories.ErrPositionNotOpen) {
+		t.Errorf("UpdateStops() on a closed position error = %v, want ErrPositionNotOpen", err)
+	}
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// startSymbol launches the analysis loop of symbol, the caller holds symbolsMu and Start has run
+func (h *AnalysisHandler) startSymbol(symbol string) {
+	ctx, cancel := context.WithCancel(h.ctx)
+	h.entries[symbol] = cancel
+	h.wg.Add(1)
+	go h.analyzeSymbol(ctx, symbol, &h.wg)
+}
+
+// AddSymbol starts looking for entries on symbol, a no-op when it is already traded
+func (h *AnalysisHandler) AddSymbol(symbol string) {
+	h.symbolsMu.Lock()
+	defer h.symbolsMu.Unlock()
+
+	if _, ok := h.entries[symbol]; ok || !h.allowed(symbol) {
+		return
+	}
+	if h.ctx == nil {
+		h.entries[symbol] = nil // Started by Start
+		return
+	}
+	h.startSymbol(symbol)
+}
+
+// LimitSymbols keeps the account's entries to symbols, so a book only trades its own while the
+// price feed and rotation cover every book's. Call it before Start
+func (h *AnalysisHandler) LimitSymbols(symbols []string) {
+	h.symbolsMu.Lock()
+	defer h.symbolsMu.Unlock()
+
+	h.limit = make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		h.limit[symbol] = true
+	}
+}
+
+// allowed reports whether symbol may take entries, the caller holds symbolsMu
+func (h *AnalysisHandler) allowed(symbol string) bool {
+	return h.limit == nil || h.limit[symbol]
+}
+
+// RemoveSymbol stops new entries on symbol and cancels its resting limit entries
+// Open positions on it are left alone; the position monitor keeps managing them until they close
+func (h *AnalysisHandler) RemoveSymbol(symbol string) error {
+	h.symbolsMu.Lock()
+	cancel, ok := h.entries[symbol]
+	delete(h.entries, symbol)
+	h.symbolsMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	if cancel != nil {
+		cancel()
+	}
+	h.signals.remove(symbol)
+	h.dedup.forget(symbol)
+	h.health.remove(symbol)
+	h.forgetHistory(symbol)
+	h.forgetWarmUp(symbol)
+
+	orders, err := h.orderRepo.FindPendingBySymbol(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get pending orders for %s: %v", symbol, err)
+	}
+	for i := range orders {
+		orders[i].Status = models.PendingOrderStatusCancelled
+		if err := h.orderRepo.Update(&orders[i]); err != nil {
+			return fmt.Errorf("failed to cancel pending order %d: %v", orders[i].ID, err)
+		}
+		log.Printf("Limit order %d for %s cancelled, symbol removed", orders[i].ID, symbol)
+	}
+	return nil
+}
+
+// Symbols returns the symbols taking new entries, sorted
+func (h *AnalysisHandler) Symbols() []string {
+	h.symbolsMu.Lock()
+	defer h.symbolsMu.Unlock()
+
+	symbols := make([]string, 0, len(h.entries))
+	for symbol := range h.entries {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// hasOpenPositions reports whether any position on symbol is still open
+func (h *AnalysisHandler) hasOpenPositions(ctx context.Context, symbol string) (bool, error) {
+	positions, err := h.positionRepo.FindOpenPositionsBySymbol(ctx, symbol)
+	if err != nil {
+		return false, err
+	}
+	return len(positions) > 0, nil
+}
+
+// SymbolRotation adds and removes traded symbols while the bot runs
+//
+// An added symbol gets its history backfilled and recorded before the analysis
+// loops start on it. A removed symbol stops taking entries right away, but its
+// prices keep being recorded until every account's positions on it have closed,
+// so their stops and targets are still checked against live prices.
+type SymbolRotation struct {
+	mu       sync.Mutex
+	prices   *PriceHandler
+	analysis []*AnalysisHandler
+	active   map[string]bool
+	draining map[string]bool // Removed, still recorded for open positions
+}
+
+// NewSymbolRotation creates a new instance of SymbolRotation over the symbols already started
+func NewSymbolRotation(prices *PriceHandler, analysis []*AnalysisHandler, symbols []string) *SymbolRotation {
+	active := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		active[symbol] = true
+	}
+	return &SymbolRotation{
+		prices:   prices,
+		analysis: analysis,
+		active:   active,
+		draining: make(map[string]bool),
+	}
+}
+
+// Symbols returns the symbols taking new entries, sorted
+func (r *SymbolRotation) Symbols() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	symbols := make([]string, 0, len(r.active))
+	for symbol := range r.active {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// Apply makes symbols the traded set, adding and removing the difference
+// A symbol that fails to start is skipped and reported; the others still apply
+func (r *SymbolRotation) Apply(ctx context.Context, symbols []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+
+	var failed []string
+	for symbol := range wanted {
+		if r.active[symbol] {
+			continue
+		}
+		if !r.draining[symbol] {
+			if err := r.prices.AddSymbol(ctx, symbol); err != nil {
+				log.Printf("Error adding %s: %v", symbol, err)
+				failed = append(failed, symbol)
+				continue
+			}
+		}
+		delete(r.draining, symbol)
+		for _, handler := range r.analysis {
+			handler.AddSymbol(symbol)
+		}
+		r.awaitHistory(symbol)
+		r.active[symbol] = true
+		log.Printf("Added %s to rotation", symbol)
+	}
+
+	for symbol := range r.active {
+		if wanted[symbol] {
+			continue
+		}
+		for _, handler := range r.analysis {
+			if err := handler.RemoveSymbol(symbol); err != nil {
+				log.Printf("Error removing %s: %v", symbol, err)
+			}
+		}
+		delete(r.active, symbol)
+		r.draining[symbol] = true
+		log.Printf("Removed %s from rotation, open positions are kept until they close", symbol)
+	}
+	r.retireDrained(ctx)
+
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("failed to add %v", failed)
+	}
+	return nil
+}
+
+// AwaitHistory holds the analysis of every traded symbol listed too recently for the strategies'
+// window, see AnalysisHandler.AwaitHistory. Call it once the price handler has started
+func (r *SymbolRotation) AwaitHistory() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for symbol := range r.active {
+		r.awaitHistory(symbol)
+	}
+}
+
+// awaitHistory passes symbol's listing on to every account, the caller holds mu
+func (r *SymbolRotation) awaitHistory(symbol string) {
+	listed, ok := r.prices.Listing(symbol)
+	if !ok {
+		return
+	}
+	for _, handler := range r.analysis {
+		handler.AwaitHistory(symbol, listed)
+	}
+}
+
+// Run stops recording removed symbols once their positions have closed, until ctx is cancelled
+func (r *SymbolRotation) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			r.retireDrained(ctx)
+			r.mu.Unlock()
+		}
+	}
+}
+
+// retireDrained stops recording removed symbols no account holds a position on, the caller holds mu
+func (r *SymbolRotation) retireDrained(ctx context.Context) {
+	for symbol := range r.draining {
+		open := false
+		for _, handler := range r.analysis {
+			has, err := handler.hasOpenPositions(ctx, symbol)
+			if err != nil {
+				log.Printf("Error checking open positions on %s: %v", symbol, err)
+				open = true
+				break
+			}
+			if has {
+				open = true
+				break
+			}
+		}
+		if open {
+			continue
+		}
+
+		r.prices.RemoveSymbol(symbol)
+		delete(r.draining, symbol)
+		log.Printf("Stopped recording %s", symbol)
+	}
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"reflect"
+	"testing"
+)
+
+func TestLimitSymbolsKeepsABookToItsSymbols(t *testing.T) {
+	strategies, err := strategy.NewStrategyManager(strategy.DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewAnalysisHandler(strategies, nil, nil, nil, nil, nil, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	h.LimitSymbols([]string{"BTCUSDT", "SOLUSDT"})
+
+	// The rotation adds every book's symbols to every account
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"} {
+		h.AddSymbol(symbol)
+	}
+	if got := h.Symbols(); !reflect.DeepEqual(got, []string{"BTCUSDT", "SOLUSDT"}) {
+		t.Errorf("Symbols() = %v, want the book's BTCUSDT and SOLUSDT", got)
+	}
+}
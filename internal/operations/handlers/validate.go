@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// errInvalidSignal wraps the reason validateResult refused a signal
+var errInvalidSignal = errors.New("invalid signal")
+
+// validateResult refuses a signal whose levels make no sense to trade, such as a long with its stop
+// above entry, logging the whole result and counting it instead of opening the position
+func (h *AnalysisHandler) validateResult(result *analysis.AnalysisResult) error {
+	if err := strategy.ValidateResult(result); err != nil {
+		log.Printf("Invalid %s signal not traded: %v: %+v", result.Symbol, err, *result)
+		metrics.InvalidSignals.WithLabelValues(h.positionRepo.Account(), result.Symbol).Inc()
+		return fmt.Errorf("%w: %v", errInvalidSignal, err)
+	}
+	return nil
+}
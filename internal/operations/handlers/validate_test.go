@@ -0,0 +1,47 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInvertedLevelsAreNotOpened(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	h.SetClock(clock.NewFake(dbTestStart.Add(10 * time.Minute)))
+	ctx := context.Background()
+	result := h.analyze("BTCUSDT", risingWindow("BTCUSDT", 250))
+	if !result.IsValid {
+		t.Fatalf("read no entry from the window: %s", result.Reason)
+	}
+
+	// The strategy's long with its stop and target swapped, then the mirrored short
+	inverted := *result
+	inverted.StopLoss, inverted.TakeProfit = result.TakeProfit, result.StopLoss
+	mirrored := inverted
+	mirrored.Direction = models.PositionSideShort
+	mirrored.StopLoss, mirrored.TakeProfit = result.StopLoss, result.TakeProfit
+	for _, signal := range []*analysis.AnalysisResult{&inverted, &mirrored} {
+		if position, err := h.openPosition(ctx, signal, "test"); !errors.Is(err, errInvalidSignal) || position != nil {
+			t.Errorf("%s openPosition() = %+v, %v, want errInvalidSignal", signal.Direction, position, err)
+		}
+	}
+	if positions, _ := h.positionRepo.FindAll(); len(positions) != 0 {
+		t.Fatalf("stored %+v from invalid signals", positions)
+	}
+
+	// The valid result opens with the levels it came with
+	position, err := h.openPosition(ctx, result, "test")
+	if err != nil {
+		t.Fatalf("openPosition() error = %v", err)
+	}
+	if position.StopLossPrice != result.StopLoss || position.TakeProfitPrice != result.TakeProfit {
+		t.Errorf("opened with stop %v and target %v, want %v and %v", position.StopLossPrice, position.TakeProfitPrice, result.StopLoss, result.TakeProfit)
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// warmUpBackfillEvery is the least time between two backfills requested for a symbol still warming up
+const warmUpBackfillEvery = 5 * time.Minute
+
+// Backfiller fetches and stores the candles of a symbol missing between start and end, see UseBackfill
+type Backfiller interface {
+	BackfillRange(ctx context.Context, symbol, timeframe string, start, end time.Time) error
+}
+
+// UseBackfill lets the handler fill a symbol's shortfall of 5m candles for the strategies' window through
+// backfiller, instead of waiting for the recorder to accumulate them; nil leaves the wait
+func (h *AnalysisHandler) UseBackfill(backfiller Backfiller) {
+	h.backfiller = backfiller
+}
+
+// warmUp tracks the symbols whose stored candles do not yet fill the strategies' window
+type warmUp struct {
+	mu        sync.Mutex
+	candles   map[string]int       // Candles stored at the latest pass, by symbol still warming up
+	requested map[string]time.Time // Latest backfill requested, by symbol
+	running   map[string]bool      // Backfills in flight, by symbol
+}
+
+func newWarmUp() *warmUp {
+	return &warmUp{
+		candles:   make(map[string]int),
+		requested: make(map[string]time.Time),
+		running:   make(map[string]bool),
+	}
+}
+
+// warmingUp reports whether symbol's prices fall short of the window the strategies need, logging its
+// status when it changes and requesting a backfill of the shortfall. Analysis on fewer candles would
+// either return nothing or run the indicators on a handful of them, so the symbol's pass stops here
+func (h *AnalysisHandler) warmingUp(ctx context.Context, symbol string, prices []models.Price) bool {
+	have, need := len(prices), h.window
+
+	h.warmUp.mu.Lock()
+	previous, was := h.warmUp.candles[symbol]
+	if have >= need {
+		delete(h.warmUp.candles, symbol)
+		delete(h.warmUp.requested, symbol)
+		h.warmUp.mu.Unlock()
+		if was {
+			log.Printf("%s warmed up (%d/%d candles), %s analysis started", symbol, have, need, h.positionRepo.Account())
+		}
+		return false
+	}
+	h.warmUp.candles[symbol] = have
+	h.warmUp.mu.Unlock()
+
+	if !was || previous != have {
+		log.Printf("%s warming up (%d/%d candles) for %s", symbol, have, need, h.positionRepo.Account())
+	}
+	h.requestBackfill(ctx, symbol)
+	return true
+}
+
+// requestBackfill fetches symbol's 5m candles over the window in the background, at most one at a time
+// and one per warmUpBackfillEvery; candles already stored are skipped by the backfill
+func (h *AnalysisHandler) requestBackfill(ctx context.Context, symbol string) {
+	if h.backfiller == nil {
+		return
+	}
+	now := h.clock.Now()
+
+	h.warmUp.mu.Lock()
+	if h.warmUp.running[symbol] || now.Sub(h.warmUp.requested[symbol]) < warmUpBackfillEvery {
+		h.warmUp.mu.Unlock()
+		return
+	}
+	h.warmUp.running[symbol] = true
+	h.warmUp.requested[symbol] = now
+	h.warmUp.mu.Unlock()
+
+	start := now.Add(-time.Duration(h.window+1) * models.TimeFrameDurations[models.PriceTimeFrame5m])
+	log.Printf("Backfilling %s 5m candles from %s for its warm-up", symbol, start.UTC().Format(time.RFC3339))
+	go func() {
+		defer func() {
+			h.warmUp.mu.Lock()
+			delete(h.warmUp.running, symbol)
+			h.warmUp.mu.Unlock()
+		}()
+		if err := h.backfiller.BackfillRange(ctx, symbol, models.PriceTimeFrame5m, start, now); err != nil {
+			log.Printf("Error backfilling %s for its warm-up: %v", symbol, err)
+		}
+	}()
+}
+
+// forgetWarmUp drops the warm-up status of a removed symbol
+func (h *AnalysisHandler) forgetWarmUp(symbol string) {
+	h.warmUp.mu.Lock()
+	defer h.warmUp.mu.Unlock()
+	delete(h.warmUp.candles, symbol)
+	delete(h.warmUp.requested, symbol)
+}
+
+// warmUpHealth sets the warm-up status of each symbol still warming up on healths
+func (h *AnalysisHandler) warmUpHealth(healths []SymbolHealth) {
+	h.warmUp.mu.Lock()
+	defer h.warmUp.mu.Unlock()
+	for i := range healths {
+		if have, ok := h.warmUp.candles[healths[i].Symbol]; ok {
+			healths[i].WarmingUp = true
+			healths[i].Candles = have
+			healths[i].RequiredCandles = h.window
+		}
+	}
+}
@@ -0,0 +1,106 @@
+//go:build integration
+
+package handlers
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+// backfillRequest is a range a fakeBackfiller was asked to fill
+type backfillRequest struct {
+	symbol, timeframe string
+	start, end        time.Time
+}
+
+// fakeBackfiller stores its candles once released, reporting each request on requests
+type fakeBackfiller struct {
+	h        *AnalysisHandler
+	candles  []models.Price
+	requests chan backfillRequest
+	release  chan struct{}
+	done     chan struct{}
+}
+
+func (f *fakeBackfiller) BackfillRange(ctx context.Context, symbol, timeframe string, start, end time.Time) error {
+	f.requests <- backfillRequest{symbol, timeframe, start, end}
+	<-f.release
+	defer close(f.done)
+	for _, price := range f.candles {
+		if err := f.h.priceRepo.Create(ctx, &price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestColdStartBackfillsTheWindowBeforeAnalysing(t *testing.T) {
+	h, _ := newDBHandler(t, 1000)
+	clk := clock.NewFake(dbTestStart)
+	h.SetClock(clk)
+	ctx := context.Background()
+
+	// The recorder has only caught the last 20 candles of a window the strategy enters on
+	window := risingWindow("BTCUSDT", 250)
+	recorded := window[len(window)-20:]
+	for _, price := range recorded {
+		if err := h.priceRepo.Create(ctx, &price); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backfiller := &fakeBackfiller{
+		h:        h,
+		candles:  window[:len(window)-20],
+		requests: make(chan backfillRequest, 2),
+		release:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	h.UseBackfill(backfiller)
+
+	// The first pass holds the symbol and requests the shortfall
+	state := &symbolState{}
+	if err := h.analyzeOnce(ctx, "BTCUSDT", state); err != nil {
+		t.Fatalf("analyzeOnce() error = %v", err)
+	}
+	request := <-backfiller.requests
+	wantStart := dbTestStart.Add(-time.Duration(h.window+1) * 5 * time.Minute)
+	if request.symbol != "BTCUSDT" || request.timeframe != models.PriceTimeFrame5m || !request.start.Equal(wantStart) || !request.end.Equal(dbTestStart) {
+		t.Errorf("backfill requested %+v, want BTCUSDT 5m from %v to %v", request, wantStart, dbTestStart)
+	}
+	health := h.SymbolHealth()
+	if len(health) != 1 || !health[0].WarmingUp || health[0].Candles != 20 || health[0].RequiredCandles != h.window {
+		t.Errorf("SymbolHealth() = %+v, want BTCUSDT warming up at 20/%d candles", health, h.window)
+	}
+
+	// Still short while the backfill runs, without asking again
+	clk.Advance(5 * time.Minute)
+	if err := h.analyzeOnce(ctx, "BTCUSDT", state); err != nil {
+		t.Fatalf("analyzeOnce() error = %v", err)
+	}
+	if open, _ := h.positionRepo.FindOpenPositions(); len(open) != 0 || len(h.LatestSignals()) != 0 {
+		t.Fatalf("analysed on %d candles: opened %+v, signals %+v", len(recorded), open, h.LatestSignals())
+	}
+	select {
+	case request := <-backfiller.requests:
+		t.Fatalf("backfill requested again as %+v while the first runs", request)
+	default:
+	}
+
+	// Once the window is stored analysis begins and the symbol leaves the warm-up status
+	close(backfiller.release)
+	<-backfiller.done
+	if err := h.analyzeOnce(ctx, "BTCUSDT", state); err != nil {
+		t.Fatalf("analyzeOnce() error = %v", err)
+	}
+	if open, _ := h.positionRepo.FindOpenPositions(); len(open) != 1 {
+		t.Errorf("%d positions open once warmed up, want the strategy's entry", len(open))
+	}
+	for _, health := range h.SymbolHealth() {
+		if health.WarmingUp {
+			t.Errorf("%s still reported warming up", health.Symbol)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package health
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck verifies the database connection responds
+func DatabaseCheck(db *gorm.DB) Check {
+	return Check{
+		Name:      "database",
+		Transient: true,
+		Run: func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+	}
+}
+
+// APIKeyCheck verifies the Binance credentials are configured
+func APIKeyCheck() Check {
+	return Check{
+		Name: "api keys",
+		Run: func(ctx context.Context) error {
+			if os.Getenv("BINANCE_API_KEY") == "" || os.Getenv("BINANCE_SECRET_KEY") == "" {
+				return errors.New("BINANCE_API_KEY and BINANCE_SECRET_KEY must be set")
+			}
+			return nil
+		},
+	}
+}
+
+// ExchangeCheck verifies the Binance futures API is reachable
+func ExchangeCheck(client *futures.Client) Check {
+	return Check{
+		Name:      "exchange",
+		Transient: true,
+		Run: func(ctx context.Context) error {
+			return client.NewPingService().Do(ctx)
+		},
+	}
+}
+
+// ClockSkewCheck verifies clk is within maxSkew of the exchange server time
+func ClockSkewCheck(client *futures.Client, maxSkew time.Duration, clk clock.Clock) Check {
+	return Check{
+		Name:      "clock skew",
+		Transient: true,
+		Run: func(ctx context.Context) error {
+			serverTime, err := client.NewServerTimeService().Do(ctx)
+			if err != nil {
+				return err
+			}
+
+			skew := clk.Now().Sub(time.UnixMilli(serverTime))
+			if skew < 0 {
+				skew = -skew
+			}
+			if skew > maxSkew {
+				return fmt.Errorf("local clock is %s off exchange time (max %s)", skew, maxSkew)
+			}
+			return nil
+		},
+	}
+}
+
+// DataCoverageCheck verifies every symbol has a candle for the timeframe opened within maxAge of clk
+func DataCoverageCheck(priceRepo *repositories.PriceRepository, symbols []string, timeFrame string, maxAge time.Duration, clk clock.Clock) Check {
+	return Check{
+		Name:      "data coverage",
+		Transient: true,
+		Run: func(ctx context.Context) error {
+			for _, symbol := range symbols {
+				latest, err := priceRepo.GetLatestPriceByTimeFrame(symbol, timeFrame)
+				if err != nil {
+					return fmt.Errorf("failed to get latest %s price for %s: %v", timeFrame, symbol, err)
+				}
+				if latest == nil {
+					return fmt.Errorf("no %s data for %s", timeFrame, symbol)
+				}
+				if age := clk.Now().Sub(latest.OpenTime); age > maxAge {
+					return fmt.Errorf("latest %s candle for %s is %s old", timeFrame, symbol, age.Round(time.Second))
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// LiveTradingChecks returns the critical checks required before live trading begins, timed on the gate's clk
+func LiveTradingChecks(db *gorm.DB, client *futures.Client, priceRepo *repositories.PriceRepository, symbols []string, clk clock.Clock) []Check {
+	return []Check{
+		DatabaseCheck(db),
+		APIKeyCheck(),
+		ExchangeCheck(client),
+		ClockSkewCheck(client, time.Second, clk),
+		DataCoverageCheck(priceRepo, symbols, models.PriceTimeFrame5m, 3*models.TimeFrameDurations[models.PriceTimeFrame5m], clk),
+	}
+}
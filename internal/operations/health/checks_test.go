@@ -0,0 +1,54 @@
+package health
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// serverTimeClient returns a futures client whose exchange reports serverTime
+func serverTimeClient(t *testing.T, serverTime time.Time) *futures.Client {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/time" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"serverTime": %d}`, serverTime.UnixMilli())
+	}))
+	t.Cleanup(server.Close)
+	client := futures.NewClient("", "")
+	client.BaseURL = server.URL
+	return client
+}
+
+func TestClockSkewCheckMeasuresTheGivenClock(t *testing.T) {
+	// Years from the wall clock, so only the injected one can pass
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		server  time.Time
+		wantErr bool
+	}{
+		{"in step", now, false},
+		{"exchange ahead within the limit", now.Add(900 * time.Millisecond), false},
+		{"exchange behind within the limit", now.Add(-900 * time.Millisecond), false},
+		{"exchange ahead", now.Add(2 * time.Second), true},
+		{"exchange behind", now.Add(-2 * time.Second), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := ClockSkewCheck(serverTimeClient(t, tt.server), time.Second, clock.NewFake(now))
+			if err := check.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Run() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
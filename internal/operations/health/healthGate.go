@@ -0,0 +1,107 @@
+package health
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	initialBackoff = 2 * time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// Check is a single startup health check
+// Transient checks are retried until the grace period expires, others fail the gate immediately
+type Check struct {
+	Name      string
+	Transient bool
+	Run       func(ctx context.Context) error
+}
+
+type HealthGate struct {
+	checks      []Check
+	gracePeriod time.Duration
+	clock       clock.Clock
+	notifier    *notifications.Notifier // Told the first time each check blocks trading, nil to only log it
+}
+
+// NewHealthGate creates a new instance of HealthGate
+func NewHealthGate(gracePeriod time.Duration, checks ...Check) *HealthGate {
+	return &HealthGate{
+		checks:      checks,
+		gracePeriod: gracePeriod,
+		clock:       clock.Real,
+	}
+}
+
+// SetClock replaces the wall clock the grace period and backoff are measured on
+func (g *HealthGate) SetClock(clk clock.Clock) {
+	g.clock = clk
+}
+
+// SetNotifier makes the gate notify the first time each check blocks trading, not only once it gives up
+func (g *HealthGate) SetNotifier(notifier *notifications.Notifier) {
+	g.notifier = notifier
+}
+
+// Wait blocks until every check passes, a non-transient check fails, or the grace period runs out
+func (g *HealthGate) Wait(ctx context.Context) error {
+	deadline := g.clock.Now().Add(g.gracePeriod)
+
+	for _, check := range g.checks {
+		if err := g.waitForCheck(ctx, check, deadline); err != nil {
+			return err
+		}
+		log.Printf("Health check passed: %s", check.Name)
+	}
+
+	return nil
+}
+
+// waitForCheck runs a check, retrying it with exponential backoff if it is transient
+func (g *HealthGate) waitForCheck(ctx context.Context, check Check, deadline time.Time) error {
+	backoff := initialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := check.Run(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if !check.Transient {
+			return fmt.Errorf("health check %s failed: %v", check.Name, err)
+		}
+
+		if g.clock.Now().Add(backoff).After(deadline) {
+			return fmt.Errorf("health check %s still failing after %d attempts: %v", check.Name, attempt, err)
+		}
+
+		log.Printf("Trading blocked by health check %s (attempt %d): %v, retrying in %s",
+			check.Name, attempt, err, backoff)
+		if attempt == 1 && g.notifier != nil {
+			g.notifier.Notify(notifications.Event{
+				Severity: notifications.SeverityWarning,
+				Title:    "Trading blocked by health check " + check.Name,
+				Message:  fmt.Sprintf("%v, retrying until %s", err, deadline.UTC().Format("15:04:05 MST")),
+			})
+		}
+
+		timer := g.clock.NewTicker(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C():
+		}
+		timer.Stop()
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package health
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// awaitRetry blocks until the check ran runs times and the gate waits on clk to retry it,
+// failing the test after a second
+func awaitRetry(t *testing.T, clk *clock.Fake, counter *atomic.Int32, runs int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for counter.Load() < runs || clk.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("gate did not wait to retry after %d runs", runs)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// flakyCheck fails its first failures runs and passes after
+func flakyCheck(failures int32, runs *atomic.Int32) Check {
+	return Check{
+		Name:      "flaky",
+		Transient: true,
+		Run: func(ctx context.Context) error {
+			if runs.Add(1) <= failures {
+				return errors.New("not yet")
+			}
+			return nil
+		},
+	}
+}
+
+func TestHealthGateWaitsForFailingCheckToPass(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var runs atomic.Int32
+	gate := NewHealthGate(time.Minute, flakyCheck(2, &runs))
+	gate.SetClock(clk)
+
+	done := make(chan error, 1)
+	go func() { done <- gate.Wait(context.Background()) }()
+
+	// Trading must not start while the check is still failing
+	for attempt := 1; attempt <= 2; attempt++ {
+		awaitRetry(t, clk, &runs, int32(attempt))
+		select {
+		case err := <-done:
+			t.Fatalf("Wait returned %v after %d failed attempts", err, attempt)
+		default:
+		}
+		clk.Advance(initialBackoff << (attempt - 1))
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return once the check passed")
+	}
+	if got := runs.Load(); got != 3 {
+		t.Errorf("check ran %d times, want 3", got)
+	}
+}
+
+// recordingChannel keeps the subjects of the notifications sent to it
+type recordingChannel struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(ctx context.Context, subject, body string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func (c *recordingChannel) sent() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.subjects...)
+}
+
+func TestHealthGateNotifiesWhenACheckFirstBlocks(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	channel := &recordingChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+	var runs atomic.Int32
+	gate := NewHealthGate(time.Minute, flakyCheck(3, &runs))
+	gate.SetClock(clk)
+	gate.SetNotifier(notifier)
+
+	done := make(chan error, 1)
+	go func() { done <- gate.Wait(context.Background()) }()
+
+	// Told as soon as the check blocks, long before the gate would give up
+	awaitRetry(t, clk, &runs, 1)
+	want := []string{"[warning] Trading blocked by health check flaky"}
+	if sent := channel.sent(); !slices.Equal(sent, want) {
+		t.Fatalf("notified %q after the first failure, want %q", sent, want)
+	}
+	for attempt := 1; attempt <= 3; attempt++ {
+		awaitRetry(t, clk, &runs, int32(attempt))
+		clk.Advance(initialBackoff << (attempt - 1))
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+
+	// Once per check, not per retry
+	if sent := channel.sent(); !slices.Equal(sent, want) {
+		t.Errorf("notified %q, want %q only", sent, want)
+	}
+}
+
+func TestHealthGateFailsNonTransientCheckImmediately(t *testing.T) {
+	var later atomic.Int32
+	gate := NewHealthGate(time.Minute,
+		Check{Name: "api keys", Run: func(ctx context.Context) error { return errors.New("missing") }},
+		flakyCheck(0, &later),
+	)
+	gate.SetClock(clock.NewFake(time.Now()))
+
+	if err := gate.Wait(context.Background()); err == nil {
+		t.Fatal("Wait() succeeded with a failing non-transient check")
+	}
+	if later.Load() != 0 {
+		t.Error("checks after the failed one ran")
+	}
+}
+
+func TestHealthGateGivesUpAfterGracePeriod(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var runs atomic.Int32
+	gate := NewHealthGate(5*time.Second, flakyCheck(100, &runs))
+	gate.SetClock(clk)
+
+	done := make(chan error, 1)
+	go func() { done <- gate.Wait(context.Background()) }()
+
+	// 2s then 4s of backoff: the second retry would end past the 5s grace period
+	awaitRetry(t, clk, &runs, 1)
+	clk.Advance(initialBackoff)
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Wait() succeeded with a check that never passes")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not give up after the grace period")
+	}
+	if got := runs.Load(); got != 2 {
+		t.Errorf("check ran %d times, want 2", got)
+	}
+}
+
+func TestHealthGateStopsOnCancel(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	var runs atomic.Int32
+	gate := NewHealthGate(time.Hour, flakyCheck(100, &runs))
+	gate.SetClock(clk)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- gate.Wait(ctx) }()
+	awaitRetry(t, clk, &runs, 1)
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Wait() error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return on cancel")
+	}
+}
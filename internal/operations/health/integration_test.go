@@ -0,0 +1,36 @@
+//go:build integration
+
+package health
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDataCoverageCheckMeasuresTheGivenClock(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Seeded(t))
+	end := testdb.FixtureStart.Add(testdb.FixtureDays * 24 * time.Hour)
+	maxAge := 3 * models.TimeFrameDurations[models.PriceTimeFrame5m]
+
+	tests := []struct {
+		name    string
+		now     time.Time
+		wantErr bool
+	}{
+		{"just after the fixture", end.Add(time.Minute), false},
+		{"an hour after the fixture", end.Add(time.Hour), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := DataCoverageCheck(prices, []string{"BTCUSDT", "ETHUSDT"}, models.PriceTimeFrame5m, maxAge, clock.NewFake(tt.now))
+			if err := check.Run(context.Background()); (err != nil) != tt.wantErr {
+				t.Errorf("Run() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
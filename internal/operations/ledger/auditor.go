@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"fmt"
+	"math"
+)
+
+// Tolerance absorbs rounding from the decimal(20,8) columns
+const Tolerance = 1e-6
+
+type Auditor struct {
+	balanceRepo     *repositories.BalanceRepository
+	transactionRepo *repositories.TransactionRepository
+	initialBalance  float64
+}
+
+// AuditReport compares the stored balance against the one implied by the ledger
+type AuditReport struct {
+	Symbol         string
+	InitialBalance float64
+	TransactionSum float64
+	Expected       float64 // InitialBalance + TransactionSum
+	Actual         float64
+}
+
+// Difference returns how far the stored balance is from the ledger
+func (r *AuditReport) Difference() float64 {
+	return r.Actual - r.Expected
+}
+
+// Reconciled reports whether the balance matches the ledger
+func (r *AuditReport) Reconciled() bool {
+	return math.Abs(r.Difference()) <= Tolerance
+}
+
+// NewAuditor creates a new instance of Auditor
+func NewAuditor(balanceRepo *repositories.BalanceRepository, transactionRepo *repositories.TransactionRepository, initialBalance float64) *Auditor {
+	return &Auditor{
+		balanceRepo:     balanceRepo,
+		transactionRepo: transactionRepo,
+		initialBalance:  initialBalance,
+	}
+}
+
+// Audit checks that the initial balance plus every recorded transaction equals the current balance
+func (a *Auditor) Audit(symbol string) (*AuditReport, error) {
+	balance, err := a.balanceRepo.FindBySymbol(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+	if balance == nil {
+		return nil, fmt.Errorf("no %s balance found", symbol)
+	}
+
+	sum, err := a.transactionRepo.SumAmounts(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum transactions: %v", err)
+	}
+
+	return &AuditReport{
+		Symbol:         symbol,
+		InitialBalance: a.initialBalance,
+		TransactionSum: sum,
+		Expected:       a.initialBalance + sum,
+		Actual:         balance.Balance,
+	}, nil
+}
@@ -0,0 +1,83 @@
+//go:build integration
+
+package ledger
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// closeWithPnL opens a long on symbol and closes it through the repository, booking pnl
+func closeWithPnL(t *testing.T, positions *repositories.PositionRepository, symbol string, pnl float64) {
+	t.Helper()
+	position := &models.Position{
+		Symbol:          symbol,
+		Side:            models.PositionSideLong,
+		Size:            0.01,
+		Leverage:        50,
+		EntryPrice:      40000,
+		StopLossPrice:   39600,
+		TakeProfitPrice: 40400,
+		OpenTime:        testdb.FixtureStart,
+		Status:          models.PositionStatusOpen,
+	}
+	if err := positions.Create(context.Background(), position); err != nil {
+		t.Fatal(err)
+	}
+	closed := *position
+	closed.Status = models.PositionStatusClosed
+	closed.CloseReason = "take_profit"
+	closed.CloseTime = testdb.FixtureStart.Add(time.Hour)
+	closed.PnL = pnl
+	if _, err := positions.Close(&closed, "USDT"); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestAuditFlagsCorruptedBalance(t *testing.T) {
+	db := testdb.Open(t)
+	balances := repositories.NewBalanceRepository(db)
+	if err := balances.Create(&models.Balance{Symbol: "USDT", Balance: 1000, LastUpdated: testdb.FixtureStart}); err != nil {
+		t.Fatal(err)
+	}
+	positions := repositories.NewPositionRepository(db)
+	closeWithPnL(t, positions, "BTCUSDT", 4)
+	closeWithPnL(t, positions, "ETHUSDT", -1.5)
+
+	auditor := NewAuditor(balances, repositories.NewTransactionRepository(db), 1000)
+	report, err := auditor.Audit("USDT")
+	if err != nil {
+		t.Fatalf("Audit() error = %v", err)
+	}
+	if !report.Reconciled() || math.Abs(report.TransactionSum-2.5) > Tolerance {
+		t.Fatalf("report = %+v, want the 2.5 of booked PnL reconciled", report)
+	}
+
+	// A balance written around the ledger
+	if err := db.Model(&models.Balance{}).Where("symbol = ?", "USDT").Update("balance", 740).Error; err != nil {
+		t.Fatal(err)
+	}
+	report, err = auditor.Audit("USDT")
+	if err != nil {
+		t.Fatalf("Audit() error = %v", err)
+	}
+	if report.Reconciled() {
+		t.Fatalf("report = %+v, want the corrupted balance flagged", report)
+	}
+	if got := report.Difference(); math.Abs(got-(740-1002.5)) > Tolerance {
+		t.Errorf("Difference() = %v, want %v", got, 740-1002.5)
+	}
+}
+
+func TestAuditWithoutBalance(t *testing.T) {
+	db := testdb.Open(t)
+	auditor := NewAuditor(repositories.NewBalanceRepository(db), repositories.NewTransactionRepository(db), 1000)
+	if _, err := auditor.Audit("USDT"); err == nil {
+		t.Error("Audit() succeeded without a balance")
+	}
+}
@@ -0,0 +1,253 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// DefaultOrderFlowWindow is how far back the order flow of a symbol reaches
+const DefaultOrderFlowWindow = 2 * time.Minute
+
+// Backoff between attempts to reconnect a symbol's trade stream
+const (
+	AggTradeReconnectBase = time.Second
+	AggTradeReconnectMax  = time.Minute
+)
+
+// AggTrade is one aggregated trade, the fills of a single taker order at one price
+type AggTrade struct {
+	Time       time.Time // Trade time as reported by the exchange
+	Price      float64
+	Quantity   float64
+	BuyerMaker bool // The buyer rested on the book, so the taker sold
+}
+
+// aggTradeServe opens a symbol's trade stream, futures.WsAggTradeServe outside tests
+type aggTradeServe func(symbol string, handler futures.WsAggTradeHandler, errHandler futures.ErrHandler) (doneC, stopC chan struct{}, err error)
+
+// AggTradeCollector follows the aggTrade stream of every recorded symbol and keeps each one's trades of the
+// last window in memory, summed as they come and go, for the order flow of entries about to be opened.
+// A symbol's flow is unavailable until its stream has been up for a whole window, and again once it drops
+type AggTradeCollector struct {
+	window time.Duration
+	clock  clock.Clock
+	serve  aggTradeServe
+
+	mu      sync.Mutex
+	ctx     context.Context // Set by Start, parent of the streams
+	flows   map[string]*tradeWindow
+	streams map[string]context.CancelFunc
+}
+
+// tradeWindow is a symbol's trades within the window, oldest first, with their volume summed by side
+type tradeWindow struct {
+	trades    []AggTrade
+	buy, sell float64   // Quote volume whose taker bought and sold
+	since     time.Time // When the stream last connected, zero while it is down
+}
+
+// NewAggTradeCollector creates a collector keeping window of trades per symbol
+func NewAggTradeCollector(window time.Duration) *AggTradeCollector {
+	return &AggTradeCollector{
+		window:  window,
+		clock:   clock.Real,
+		serve:   futures.WsAggTradeServe,
+		flows:   make(map[string]*tradeWindow),
+		streams: make(map[string]context.CancelFunc),
+	}
+}
+
+// SetClock replaces the wall clock trades are evicted by
+func (c *AggTradeCollector) SetClock(clk clock.Clock) {
+	c.clock = clk
+}
+
+// Start streams the trades of symbols until ctx is cancelled
+func (c *AggTradeCollector) Start(ctx context.Context, symbols []string) {
+	c.mu.Lock()
+	c.ctx = ctx
+	c.mu.Unlock()
+
+	for _, symbol := range symbols {
+		c.AddSymbol(symbol)
+	}
+}
+
+// AddSymbol starts streaming symbol's trades, a no-op before Start or when it is streamed already
+func (c *AggTradeCollector) AddSymbol(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ctx == nil || c.streams[symbol] != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.ctx)
+	c.streams[symbol] = cancel
+	c.flows[symbol] = &tradeWindow{}
+	go c.stream(ctx, symbol)
+}
+
+// RemoveSymbol stops streaming symbol's trades and forgets them
+func (c *AggTradeCollector) RemoveSymbol(symbol string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cancel := c.streams[symbol]; cancel != nil {
+		cancel()
+	}
+	delete(c.streams, symbol)
+	delete(c.flows, symbol)
+}
+
+// stream keeps symbol's trade stream connected until ctx is cancelled, backing off between attempts
+func (c *AggTradeCollector) stream(ctx context.Context, symbol string) {
+	handler := func(event *futures.WsAggTradeEvent) {
+		trade, err := aggTradeFromEvent(event)
+		if err != nil {
+			log.Printf("Skipping malformed aggTrade of %s: %v", symbol, err)
+			return
+		}
+		c.Add(symbol, trade)
+	}
+	errHandler := func(err error) {
+		log.Printf("aggTrade stream of %s: %v", symbol, err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		doneC, stopC, err := c.serve(symbol, handler, errHandler)
+		if err != nil {
+			log.Printf("Error connecting the aggTrade stream of %s: %v", symbol, err)
+		} else {
+			c.connected(symbol, true)
+			select {
+			case <-ctx.Done():
+				close(stopC)
+				<-doneC
+				return
+			case <-doneC:
+				c.connected(symbol, false)
+				attempt = 0
+			}
+		}
+
+		timer := time.NewTimer(min(AggTradeReconnectBase<<min(attempt, 16), AggTradeReconnectMax))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// connected records symbol's stream going up or down; trades missed while down leave the window
+// incomplete, so it starts over
+func (c *AggTradeCollector) connected(symbol string, up bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flow, ok := c.flows[symbol]
+	if !ok {
+		return
+	}
+	*flow = tradeWindow{}
+	if up {
+		flow.since = c.clock.Now()
+	}
+}
+
+// Add records a trade of symbol, ignored for symbols not streamed or while their stream is down
+func (c *AggTradeCollector) Add(symbol string, trade AggTrade) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flow, ok := c.flows[symbol]
+	if !ok || flow.since.IsZero() {
+		return
+	}
+	flow.add(trade)
+	flow.evict(c.clock.Now().Add(-c.window))
+}
+
+// OrderFlow returns symbol's order flow over the window, false while its stream has not covered a whole window
+func (c *AggTradeCollector) OrderFlow(symbol string) (analysis.OrderFlow, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flow, ok := c.flows[symbol]
+	now := c.clock.Now()
+	if !ok || flow.since.IsZero() || now.Sub(flow.since) < c.window {
+		return analysis.OrderFlow{}, false
+	}
+	flow.evict(now.Add(-c.window))
+
+	snapshot := analysis.OrderFlow{
+		Window:     c.window,
+		BuyVolume:  flow.buy,
+		SellVolume: flow.sell,
+		Trades:     len(flow.trades),
+		TradeRate:  float64(len(flow.trades)) / c.window.Seconds(),
+	}
+	if volume := snapshot.Volume(); volume > 0 {
+		snapshot.Imbalance = (flow.buy - flow.sell) / volume
+	}
+	return snapshot, true
+}
+
+// add appends trade, trades arriving out of order are slotted in by time
+func (w *tradeWindow) add(trade AggTrade) {
+	i := len(w.trades)
+	for i > 0 && w.trades[i-1].Time.After(trade.Time) {
+		i--
+	}
+	w.trades = append(w.trades, AggTrade{})
+	copy(w.trades[i+1:], w.trades[i:])
+	w.trades[i] = trade
+
+	if trade.BuyerMaker {
+		w.sell += trade.Price * trade.Quantity
+	} else {
+		w.buy += trade.Price * trade.Quantity
+	}
+}
+
+// evict drops the trades at or before cutoff
+func (w *tradeWindow) evict(cutoff time.Time) {
+	n := 0
+	for n < len(w.trades) && !w.trades[n].Time.After(cutoff) {
+		trade := w.trades[n]
+		if trade.BuyerMaker {
+			w.sell -= trade.Price * trade.Quantity
+		} else {
+			w.buy -= trade.Price * trade.Quantity
+		}
+		n++
+	}
+	w.trades = w.trades[n:]
+
+	// Sums emptied by subtraction keep float residue, an empty window has none
+	if len(w.trades) == 0 {
+		w.buy, w.sell = 0, 0
+	}
+}
+
+// aggTradeFromEvent converts a streamed aggTrade, rejecting malformed values
+func aggTradeFromEvent(event *futures.WsAggTradeEvent) (AggTrade, error) {
+	price, err := strconv.ParseFloat(event.Price, 64)
+	if err != nil {
+		return AggTrade{}, err
+	}
+	quantity, err := strconv.ParseFloat(event.Quantity, 64)
+	if err != nil {
+		return AggTrade{}, err
+	}
+	return AggTrade{
+		Time:       time.UnixMilli(event.TradeTime),
+		Price:      price,
+		Quantity:   quantity,
+		BuyerMaker: event.Maker,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// fakeTradeStreams stands in for the aggTrade websocket, one stream per symbol left open until closed
+type fakeTradeStreams struct {
+	mu       sync.Mutex
+	handlers map[string]futures.WsAggTradeHandler
+	done     map[string]chan struct{}
+}
+
+func newFakeTradeStreams() *fakeTradeStreams {
+	return &fakeTradeStreams{handlers: make(map[string]futures.WsAggTradeHandler), done: make(map[string]chan struct{})}
+}
+
+func (s *fakeTradeStreams) serve(symbol string, handler futures.WsAggTradeHandler, errHandler futures.ErrHandler) (chan struct{}, chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	doneC, stopC := make(chan struct{}), make(chan struct{})
+	s.handlers[symbol], s.done[symbol] = handler, doneC
+	go func() {
+		<-stopC
+		close(doneC)
+	}()
+	return doneC, stopC, nil
+}
+
+// trade streams a trade of symbol at, false for a sell by a taker
+func (s *fakeTradeStreams) trade(symbol string, at time.Time, price, quantity float64, takerBuys bool) {
+	s.mu.Lock()
+	handler := s.handlers[symbol]
+	s.mu.Unlock()
+	handler(&futures.WsAggTradeEvent{
+		Symbol:    symbol,
+		Price:     strconv.FormatFloat(price, 'f', -1, 64),
+		Quantity:  strconv.FormatFloat(quantity, 'f', -1, 64),
+		TradeTime: at.UnixMilli(),
+		Maker:     !takerBuys,
+	})
+}
+
+// drop closes symbol's stream as the exchange would
+func (s *fakeTradeStreams) drop(symbol string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	close(s.done[symbol])
+}
+
+// waitStream waits until symbol's stream is up or, with up false, down
+func waitStream(t *testing.T, c *AggTradeCollector, symbol string, up bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.mu.Lock()
+		flow := c.flows[symbol]
+		connected := flow != nil && !flow.since.IsZero()
+		c.mu.Unlock()
+		if connected == up {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stream of %s up = %v, want %v", symbol, connected, up)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestAggTradeCollectorWindow(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	streams := newFakeTradeStreams()
+	c := NewAggTradeCollector(time.Minute)
+	c.SetClock(clk)
+	c.serve = streams.serve
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.Start(ctx, []string{"BTCUSDT"})
+	waitStream(t, c, "BTCUSDT", true)
+
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	streams.trade("BTCUSDT", at(10), 100, 2, true)   // 200 bought
+	streams.trade("BTCUSDT", at(20), 100, 1, false)  // 100 sold
+	streams.trade("BTCUSDT", at(30), 200, 0.5, true) // 100 bought
+	streams.handlers["BTCUSDT"](&futures.WsAggTradeEvent{Price: "n/a", Quantity: "1", TradeTime: at(30).UnixMilli()})
+
+	if _, ok := c.OrderFlow("BTCUSDT"); ok {
+		t.Fatal("order flow available before the stream covered a whole window")
+	}
+
+	tests := []struct {
+		name      string
+		now       int // Seconds after start
+		buy, sell float64
+		trades    int
+		imbalance float64
+	}{
+		{"window covered", 60, 300, 100, 3, 0.5},
+		{"first trade evicted", 75, 100, 100, 2, 0},
+		{"trade at the cutoff evicted", 80, 100, 0, 1, 1},
+		{"every trade evicted", 95, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		clk.Set(at(tt.now))
+		flow, ok := c.OrderFlow("BTCUSDT")
+		if !ok {
+			t.Fatalf("%s: order flow unavailable", tt.name)
+		}
+		want := analysis.OrderFlow{
+			Window:     time.Minute,
+			BuyVolume:  tt.buy,
+			SellVolume: tt.sell,
+			Trades:     tt.trades,
+			Imbalance:  tt.imbalance,
+			TradeRate:  float64(tt.trades) / 60,
+		}
+		if math.Abs(flow.BuyVolume-want.BuyVolume) > 1e-9 || math.Abs(flow.SellVolume-want.SellVolume) > 1e-9 ||
+			math.Abs(flow.Imbalance-want.Imbalance) > 1e-9 || flow.Trades != want.Trades ||
+			flow.TradeRate != want.TradeRate || flow.Window != want.Window {
+			t.Errorf("%s: OrderFlow() = %+v, want %+v", tt.name, flow, want)
+		}
+	}
+
+	// A trade arriving late is slotted in by time and evicted with its peers
+	streams.trade("BTCUSDT", at(100), 10, 1, false)
+	streams.trade("BTCUSDT", at(90), 10, 3, true)
+	clk.Set(at(155))
+	if flow, _ := c.OrderFlow("BTCUSDT"); flow.Trades != 1 || flow.BuyVolume != 0 || flow.SellVolume != 10 {
+		t.Errorf("after the late trade left the window OrderFlow() = %+v, want the 10 sold", flow)
+	}
+}
+
+func TestAggTradeCollectorStartsOverAfterADrop(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	streams := newFakeTradeStreams()
+	c := NewAggTradeCollector(time.Minute)
+	c.SetClock(clk)
+	c.serve = streams.serve
+
+	// Symbols are only streamed once started, within the universe given
+	c.AddSymbol("ETHUSDT")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.Start(ctx, []string{"BTCUSDT"})
+	waitStream(t, c, "BTCUSDT", true)
+	if _, ok := c.flows["ETHUSDT"]; ok {
+		t.Error("ETHUSDT added before Start is streamed")
+	}
+
+	streams.trade("BTCUSDT", start.Add(10*time.Second), 100, 1, true)
+	clk.Advance(time.Minute)
+	if flow, ok := c.OrderFlow("BTCUSDT"); !ok || flow.Trades != 1 {
+		t.Fatalf("OrderFlow() = %+v, %v, want the trade", flow, ok)
+	}
+
+	streams.drop("BTCUSDT")
+	waitStream(t, c, "BTCUSDT", false)
+	c.Add("BTCUSDT", AggTrade{Time: clk.Now(), Price: 100, Quantity: 1})
+	if flow, ok := c.OrderFlow("BTCUSDT"); ok {
+		t.Errorf("OrderFlow() = %+v while the stream is down, want unavailable", flow)
+	}
+
+	c.RemoveSymbol("BTCUSDT")
+	if _, ok := c.OrderFlow("BTCUSDT"); ok {
+		t.Error("order flow of a removed symbol available")
+	}
+}
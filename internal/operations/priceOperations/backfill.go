@@ -0,0 +1,241 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backfillLogEvery is the least time between two progress lines of a backfill logging its own progress
+const backfillLogEvery = 10 * time.Second
+
+// CoverageSource tells a backfill which candles are stored already, so it skips or filters them
+type CoverageSource interface {
+	StoredOpenTimes(symbol, timeFrame string, start, end time.Time) ([]time.Time, error)
+}
+
+// BackfillProgress is how far a backfill has come, reported after every chunk
+type BackfillProgress struct {
+	TimeFrame     string
+	Symbols       int
+	SymbolsDone   int
+	Chunks        int // Chunks of up to klinePageLimit candles over every symbol
+	ChunksDone    int // Including those skipped
+	ChunksSkipped int // Already stored in full
+	Rows          int // Candles fetched and not stored before
+	Elapsed       time.Duration
+	ETA           time.Duration // From the average latency of the chunks fetched so far, 0 until one was
+}
+
+func (p BackfillProgress) String() string {
+	eta := "unknown"
+	if p.ETA > 0 || p.ChunksDone == p.Chunks {
+		eta = p.ETA.Round(time.Second).String()
+	}
+	return fmt.Sprintf("Backfill %s: %d/%d symbols, %d/%d chunks (%d already stored), %d rows in %s, ETA %s",
+		p.TimeFrame, p.SymbolsDone, p.Symbols, p.ChunksDone, p.Chunks, p.ChunksSkipped, p.Rows,
+		p.Elapsed.Round(time.Second), eta)
+}
+
+// BackfillResult is the outcome of a backfill, one failing symbol leaving the others fetched
+type BackfillResult struct {
+	Progress BackfillProgress
+	Failed   map[string]error // By symbol
+}
+
+// Err summarizes the failed symbols, nil when every one was fetched
+func (r BackfillResult) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	symbols := make([]string, 0, len(r.Failed))
+	for symbol := range r.Failed {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	failures := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		failures[i] = fmt.Sprintf("%s: %v", symbol, r.Failed[symbol])
+	}
+	return fmt.Errorf("%d of %d symbols failed: %s", len(symbols), r.Progress.Symbols, strings.Join(failures, "; "))
+}
+
+// BackfillOptions tunes Backfill
+type BackfillOptions struct {
+	Coverage CoverageSource         // Stored candles to skip, nil to fetch everything
+	Progress func(BackfillProgress) // Called after every chunk, nil to log every backfillLogEvery
+}
+
+// backfillChunk is one range of up to klinePageLimit candle opens
+type backfillChunk struct {
+	start, end time.Time
+	expected   int // Candles in the range closed by the end of the backfill
+}
+
+// Backfill fetches every symbol's timeframe candles opening between start and end and hands the ones not
+// stored yet to store, chunk by chunk. Chunks Coverage holds in full are not requested, and candles it
+// holds are dropped from the others, so overlapping a stored range stores nothing twice. Candles still
+// forming are dropped too, the recorder stores them once closed. A symbol that fails is recorded in the
+// result and the backfill moves on; the error is only set when ctx ends it
+func (f *PriceFetcher) Backfill(ctx context.Context, symbols []string, timeframe string, start, end time.Time,
+	store func(ctx context.Context, symbol string, prices []models.Price) error, options BackfillOptions) (BackfillResult, error) {
+	interval, known := models.TimeFrameDurations[timeframe]
+	if !known {
+		return BackfillResult{}, fmt.Errorf("unknown timeframe %s", timeframe)
+	}
+
+	chunks := make(map[string][]backfillChunk, len(symbols))
+	result := BackfillResult{
+		Progress: BackfillProgress{TimeFrame: timeframe, Symbols: len(symbols)},
+		Failed:   make(map[string]error),
+	}
+	for _, symbol := range symbols {
+		chunks[symbol] = backfillChunks(f.clipToListing(symbol, start), end, interval)
+		result.Progress.Chunks += len(chunks[symbol])
+	}
+
+	report := options.Progress
+	if report == nil {
+		report = logBackfillProgress()
+	}
+
+	began := time.Now()
+	var fetching time.Duration // Spent on chunks that were fetched, for the ETA
+	fetched := 0
+	for _, symbol := range symbols {
+		for i, chunk := range chunks[symbol] {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+
+			chunkStarted := time.Now()
+			rows, skipped, err := f.backfillChunk(ctx, symbol, timeframe, chunk, store, options.Coverage)
+			if err != nil {
+				if ctx.Err() != nil {
+					return result, ctx.Err()
+				}
+				// The rest of the symbol's chunks count as done, none of them will be fetched
+				log.Printf("Backfill of %s-%s failed, moving on: %v", symbol, timeframe, err)
+				result.Failed[symbol] = err
+				result.Progress.ChunksDone += len(chunks[symbol]) - i
+				result.Progress.SymbolsDone++
+				result.Progress.Elapsed = time.Since(began)
+				report(result.Progress)
+				break
+			}
+
+			result.Progress.ChunksDone++
+			if i == len(chunks[symbol])-1 {
+				result.Progress.SymbolsDone++
+			}
+			result.Progress.Rows += rows
+			if skipped {
+				result.Progress.ChunksSkipped++
+			} else {
+				fetching += time.Since(chunkStarted)
+				fetched++
+			}
+			result.Progress.Elapsed = time.Since(began)
+			result.Progress.ETA = 0
+			if fetched > 0 {
+				result.Progress.ETA = fetching / time.Duration(fetched) * time.Duration(result.Progress.Chunks-result.Progress.ChunksDone)
+			}
+			report(result.Progress)
+		}
+		if len(chunks[symbol]) == 0 {
+			result.Progress.SymbolsDone++
+		}
+	}
+
+	result.Progress.Elapsed = time.Since(began)
+	result.Progress.ETA = 0
+	return result, nil
+}
+
+// backfillChunk fetches and stores one chunk, returning how many candles it stored and whether it was
+// skipped as already stored
+func (f *PriceFetcher) backfillChunk(ctx context.Context, symbol, timeframe string, chunk backfillChunk,
+	store func(ctx context.Context, symbol string, prices []models.Price) error, coverage CoverageSource) (int, bool, error) {
+	stored := make(map[time.Time]bool)
+	if coverage != nil {
+		openTimes, err := coverage.StoredOpenTimes(symbol, timeframe, chunk.start, chunk.end)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to check stored candles: %v", err)
+		}
+		if len(openTimes) >= chunk.expected {
+			return 0, true, nil
+		}
+		for _, openTime := range openTimes {
+			stored[openTime.UTC()] = true
+		}
+	}
+
+	prices, _, err := f.GetPricesInRange(ctx, symbol, timeframe, chunk.start, chunk.end)
+	if err != nil {
+		return 0, false, err
+	}
+
+	now := time.Now()
+	missing := prices[:0]
+	for _, price := range prices {
+		if !price.CloseTime.Before(now) {
+			continue // Still forming, its close would change after being stored
+		}
+		if !stored[price.OpenTime.UTC()] {
+			missing = append(missing, price)
+		}
+	}
+	if len(missing) == 0 {
+		return 0, false, nil
+	}
+	if err := store(ctx, symbol, missing); err != nil {
+		return 0, false, fmt.Errorf("failed to store candles: %v", err)
+	}
+	return len(missing), false, nil
+}
+
+// backfillChunks splits the candle opens between start and end into chunks of klinePageLimit candles
+func backfillChunks(start, end time.Time, interval time.Duration) []backfillChunk {
+	first := start.Truncate(interval)
+	if first.Before(start) {
+		first = first.Add(interval)
+	}
+
+	var chunks []backfillChunk
+	span := interval * klinePageLimit
+	for from := first; !from.After(end); from = from.Add(span) {
+		// Binance treats the end time as inclusive, so stop just short of the next chunk
+		to := from.Add(span - time.Millisecond)
+		if to.After(end) {
+			to = end
+		}
+
+		// Only candles closed by end are expected, the forming one may be missing
+		expected := 0
+		if closed := end.Add(-interval); !closed.Before(from) {
+			if closed.After(to) {
+				closed = to
+			}
+			expected = int(closed.Sub(from)/interval) + 1
+		}
+		chunks = append(chunks, backfillChunk{start: from, end: to, expected: expected})
+	}
+	return chunks
+}
+
+// logBackfillProgress returns a progress callback logging at most every backfillLogEvery, and at the end
+func logBackfillProgress() func(BackfillProgress) {
+	var last time.Time
+	return func(p BackfillProgress) {
+		if p.ChunksDone < p.Chunks && time.Since(last) < backfillLogEvery {
+			return
+		}
+		last = time.Now()
+		log.Print(p)
+	}
+}
@@ -0,0 +1,297 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// BybitBaseURL is Bybit's public REST API, its market data needs no keys
+	BybitBaseURL = "https://api.bybit.com"
+
+	// bybitPageLimit is the most klines one Bybit request returns
+	bybitPageLimit = 1000
+
+	// bybitDefaultLimit is how many klines a request without a limit returns, as on Bybit
+	bybitDefaultLimit = 200
+)
+
+// bybitIntervals maps timeframes to Bybit's kline intervals
+var bybitIntervals = map[string]string{
+	models.PriceTimeFrame1m:  "1",
+	models.PriceTimeFrame5m:  "5",
+	models.PriceTimeFrame15m: "15",
+	models.PriceTimeFrame1h:  "60",
+	models.PriceTimeFrame4h:  "240",
+	models.PriceTimeFrame1d:  "D",
+}
+
+// bybitProvider serves the market data of Bybit's linear perpetuals from its public REST API
+type bybitProvider struct {
+	baseURL string
+	client  *http.Client
+
+	mu       sync.Mutex
+	launches map[string]time.Time // Launch of each symbol asked for, where its klines start
+}
+
+// NewBybitProvider creates an ExchangeDataProvider over Bybit's linear perpetuals at baseURL, BybitBaseURL when empty
+func NewBybitProvider(baseURL string) ExchangeDataProvider {
+	if baseURL == "" {
+		baseURL = BybitBaseURL
+	}
+	return &bybitProvider{
+		baseURL:  baseURL,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		launches: make(map[string]time.Time),
+	}
+}
+
+func (p *bybitProvider) Exchange() string {
+	return models.ExchangeBybit
+}
+
+func (p *bybitProvider) LatestKline(ctx context.Context, symbol, interval string) (*futures.Kline, error) {
+	return latestKline(ctx, p, symbol, interval)
+}
+
+// Klines returns up to req.Limit klines oldest first. Bybit answers a range with its latest candles, so a
+// request with a start is split into windows of bybitPageLimit candles walked forward from it, starting no
+// earlier than the symbol's launch
+func (p *bybitProvider) Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	interval, ok := bybitIntervals[req.Interval]
+	if !ok {
+		return nil, fmt.Errorf("bybit has no %s klines", req.Interval)
+	}
+	duration := models.TimeFrameDurations[req.Interval]
+	limit := req.Limit
+	if limit <= 0 {
+		limit = bybitDefaultLimit
+	}
+
+	if req.StartTime.IsZero() {
+		return p.klinePage(ctx, req.Symbol, interval, duration, time.Time{}, req.EndTime, min(limit, bybitPageLimit))
+	}
+
+	start := req.StartTime
+	launch, err := p.launch(ctx, req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if launch.After(start) {
+		start = launch.Truncate(duration)
+	}
+	end := req.EndTime
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	var klines []*futures.Kline
+	for from := start; len(klines) < limit && !from.After(end); {
+		// Whole windows, so a stretch without candles, say before trading opened, is crossed in few requests
+		to := from.Add(bybitPageLimit*duration - time.Millisecond)
+		if to.After(end) {
+			to = end
+		}
+		page, err := p.klinePage(ctx, req.Symbol, interval, duration, from, to, bybitPageLimit)
+		if err != nil {
+			return klines, err
+		}
+		klines = append(klines, page...)
+		from = to.Add(time.Millisecond)
+	}
+	if len(klines) > limit {
+		klines = klines[:limit]
+	}
+	return klines, nil
+}
+
+// klinePage requests one page of klines between start and end, either zero for Bybit's default
+func (p *bybitProvider) klinePage(ctx context.Context, symbol, interval string, duration time.Duration, start, end time.Time, limit int) ([]*futures.Kline, error) {
+	query := url.Values{
+		"category": {"linear"},
+		"symbol":   {symbol},
+		"interval": {interval},
+		"limit":    {strconv.Itoa(limit)},
+	}
+	if !start.IsZero() {
+		query.Set("start", strconv.FormatInt(start.UnixMilli(), 10))
+	}
+	if !end.IsZero() {
+		query.Set("end", strconv.FormatInt(end.UnixMilli(), 10))
+	}
+
+	body, err := p.get(ctx, "/v5/market/kline", query)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBybitKlines(body, duration)
+}
+
+// SymbolInfo looks symbol up among Bybit's linear contracts, naming its contract type and status as Binance does
+func (p *bybitProvider) SymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	instrument, err := p.instrument(ctx, symbol)
+	if err != nil || instrument == nil {
+		return nil, err
+	}
+	return instrument.symbolInfo(), nil
+}
+
+// launch returns when symbol was launched on Bybit, cached after the first lookup
+func (p *bybitProvider) launch(ctx context.Context, symbol string) (time.Time, error) {
+	p.mu.Lock()
+	launch, ok := p.launches[symbol]
+	p.mu.Unlock()
+	if ok {
+		return launch, nil
+	}
+
+	instrument, err := p.instrument(ctx, symbol)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if instrument == nil {
+		return time.Time{}, fmt.Errorf("%s is not a Bybit linear contract", symbol)
+	}
+	millis, _ := strconv.ParseInt(instrument.LaunchTime, 10, 64)
+	launch = time.UnixMilli(millis)
+
+	p.mu.Lock()
+	p.launches[symbol] = launch
+	p.mu.Unlock()
+	return launch, nil
+}
+
+// instrument fetches symbol's linear contract, nil when Bybit does not list it
+func (p *bybitProvider) instrument(ctx context.Context, symbol string) (*bybitInstrument, error) {
+	body, err := p.get(ctx, "/v5/market/instruments-info", url.Values{"category": {"linear"}, "symbol": {symbol}})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		List []bybitInstrument `json:"list"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse bybit instruments: %v", err)
+	}
+	for i := range result.List {
+		if result.List[i].Symbol == symbol {
+			return &result.List[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// get calls a Bybit endpoint and returns the result of its response envelope
+func (p *bybitProvider) get(ctx context.Context, path string, query url.Values) (json.RawMessage, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("bybit %s: %v", path, err)
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("bybit %s: %v", path, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bybit %s: status %d", path, response.StatusCode)
+	}
+
+	var envelope struct {
+		RetCode int             `json:"retCode"`
+		RetMsg  string          `json:"retMsg"`
+		Result  json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("bybit %s: invalid response: %v", path, err)
+	}
+	if envelope.RetCode != 0 {
+		return nil, fmt.Errorf("bybit %s: %s (code %d)", path, envelope.RetMsg, envelope.RetCode)
+	}
+	return envelope.Result, nil
+}
+
+// ParseBybitKlines converts the result of a Bybit kline response, candles of duration each, to Binance
+// klines oldest first. Bybit lists each candle as [start, open, high, low, close, volume, turnover], newest
+// first, and reports no trade counts
+func ParseBybitKlines(result []byte, duration time.Duration) ([]*futures.Kline, error) {
+	var page struct {
+		List [][]string `json:"list"`
+	}
+	if err := json.Unmarshal(result, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse bybit klines: %v", err)
+	}
+
+	klines := make([]*futures.Kline, 0, len(page.List))
+	for _, row := range page.List {
+		if len(row) < 7 {
+			return nil, fmt.Errorf("bybit kline has %d fields, expected 7", len(row))
+		}
+		openTime, err := strconv.ParseInt(row[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bybit kline start %q: %v", row[0], err)
+		}
+		klines = append(klines, &futures.Kline{
+			OpenTime:         openTime,
+			Open:             row[1],
+			High:             row[2],
+			Low:              row[3],
+			Close:            row[4],
+			Volume:           row[5],
+			QuoteAssetVolume: row[6],
+			CloseTime:        openTime + duration.Milliseconds() - 1,
+		})
+	}
+	sort.Slice(klines, func(i, j int) bool { return klines[i].OpenTime < klines[j].OpenTime })
+	return klines, nil
+}
+
+// bybitInstrument is the part of a Bybit linear contract SymbolInfo needs
+type bybitInstrument struct {
+	Symbol       string `json:"symbol"`
+	ContractType string `json:"contractType"`
+	Status       string `json:"status"`
+	QuoteCoin    string `json:"quoteCoin"`
+	LaunchTime   string `json:"launchTime"`
+	PriceFilter  struct {
+		TickSize string `json:"tickSize"`
+	} `json:"priceFilter"`
+	LotSizeFilter struct {
+		QtyStep string `json:"qtyStep"`
+	} `json:"lotSizeFilter"`
+}
+
+// symbolInfo describes the contract in the terms of Binance's exchange info
+func (i *bybitInstrument) symbolInfo() *SymbolInfo {
+	info := &SymbolInfo{
+		Symbol:       i.Symbol,
+		Status:       i.Status,
+		ContractType: i.ContractType,
+		QuoteAsset:   i.QuoteCoin,
+	}
+	if i.Status == "Trading" {
+		info.Status = symbolStatusTrading
+	}
+	if i.ContractType == "LinearPerpetual" {
+		info.ContractType = string(futures.ContractTypePerpetual)
+	}
+	info.TickSize, _ = strconv.ParseFloat(i.PriceFilter.TickSize, 64)
+	info.StepSize, _ = strconv.ParseFloat(i.LotSizeFilter.QtyStep, 64)
+	return info
+}
@@ -0,0 +1,163 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// bybitKlinesResult is the result of a Bybit kline response of the 5m candles from 00:00 to 00:10 on
+// March 1st 2024, newest first
+const bybitKlinesResult = `{"category":"linear","symbol":"BTCUSDT","list":[
+	["1709251800000","62150.2","62210","62100","62190.5","98.25","6108500.75"],
+	["1709251500000","62080","62160.4","62050.1","62150.2","120.5","7488000.1"],
+	["1709251200000","62000","62100","61950.5","62080","150","9300000"]
+]}`
+
+// bybitInstrumentsJSON is Bybit's linear contract of BTCUSDT, launched at 00:05 on March 1st 2024 to clip requests
+const bybitInstrumentsJSON = `{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[
+	{"symbol":"BTCUSDT","contractType":"LinearPerpetual","status":"Trading","quoteCoin":"USDT","launchTime":"1709251500000",
+	 "priceFilter":{"tickSize":"0.10"},"lotSizeFilter":{"qtyStep":"0.001"}}
+]}}`
+
+// bybitServer serves the canned responses the way Bybit's public API does, recording each kline query
+func bybitServer(t *testing.T, queries *[]map[string]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v5/market/kline":
+			query := make(map[string]string)
+			for key := range r.URL.Query() {
+				query[key] = r.URL.Query().Get(key)
+			}
+			*queries = append(*queries, query)
+			w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":` + bybitKlinesResult + `,"time":1709252100000}`))
+		case "/v5/market/instruments-info":
+			if r.URL.Query().Get("symbol") != "BTCUSDT" {
+				w.Write([]byte(`{"retCode":0,"retMsg":"OK","result":{"category":"linear","list":[]}}`))
+				return
+			}
+			w.Write([]byte(bybitInstrumentsJSON))
+		default:
+			w.Write([]byte(`{"retCode":10001,"retMsg":"params error","result":{}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestParseBybitKlines(t *testing.T) {
+	klines, err := ParseBybitKlines([]byte(bybitKlinesResult), 5*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []futures.Kline{
+		{OpenTime: 1709251200000, Open: "62000", High: "62100", Low: "61950.5", Close: "62080", Volume: "150", QuoteAssetVolume: "9300000", CloseTime: 1709251499999},
+		{OpenTime: 1709251500000, Open: "62080", High: "62160.4", Low: "62050.1", Close: "62150.2", Volume: "120.5", QuoteAssetVolume: "7488000.1", CloseTime: 1709251799999},
+		{OpenTime: 1709251800000, Open: "62150.2", High: "62210", Low: "62100", Close: "62190.5", Volume: "98.25", QuoteAssetVolume: "6108500.75", CloseTime: 1709252099999},
+	}
+	if len(klines) != len(want) {
+		t.Fatalf("%d klines parsed, want %d", len(klines), len(want))
+	}
+	for i := range want {
+		if *klines[i] != want[i] {
+			t.Errorf("kline %d = %+v, want %+v", i, *klines[i], want[i])
+		}
+	}
+
+	// Converted like Binance's klines, the recorder stores them alike
+	price, err := klineToPrice("BTCUSDT", models.PriceTimeFrame5m, klines[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !price.OpenTime.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || price.Close != 62080 || price.Volume != 150 {
+		t.Errorf("klineToPrice() = %+v, want the 00:00 candle closing at 62080", price)
+	}
+}
+
+func TestParseBybitKlinesRejectsMalformedRows(t *testing.T) {
+	for name, result := range map[string]string{
+		"short row":     `{"list":[["1709251200000","62000","62100"]]}`,
+		"invalid start": `{"list":[["yesterday","62000","62100","61950.5","62080","150","9300000"]]}`,
+		"not json":      `{"list":`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if klines, err := ParseBybitKlines([]byte(result), 5*time.Minute); err == nil {
+				t.Errorf("ParseBybitKlines() = %v, want an error", klines)
+			}
+		})
+	}
+}
+
+func TestBybitProviderKlinesStartAtLaunch(t *testing.T) {
+	var queries []map[string]string
+	provider := NewBybitProvider(bybitServer(t, &queries).URL)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	launch := start.Add(5 * time.Minute)
+
+	klines, err := provider.Klines(context.Background(), KlineRequest{
+		Symbol: "BTCUSDT", Interval: models.PriceTimeFrame5m, StartTime: start, EndTime: start.Add(15*time.Minute - time.Millisecond), Limit: 1500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 3 || klines[0].OpenTime >= klines[2].OpenTime {
+		t.Errorf("Klines() returned %d klines, want the 3 canned ones oldest first", len(klines))
+	}
+	if len(queries) != 1 {
+		t.Fatalf("%d kline requests for 15 minutes, want 1", len(queries))
+	}
+	want := map[string]string{
+		"category": "linear", "symbol": "BTCUSDT", "interval": "5", "limit": "1000",
+		"start": strconv.FormatInt(launch.UnixMilli(), 10),
+		"end":   strconv.FormatInt(start.Add(15*time.Minute-time.Millisecond).UnixMilli(), 10),
+	}
+	for key, value := range want {
+		if queries[0][key] != value {
+			t.Errorf("requested %s=%q, want %q", key, queries[0][key], value)
+		}
+	}
+
+	if _, err := provider.Klines(context.Background(), KlineRequest{Symbol: "BTCUSDT", Interval: "3m"}); err == nil {
+		t.Error("Klines() of an interval Bybit does not serve succeeded")
+	}
+	if provider.Exchange() != models.ExchangeBybit {
+		t.Errorf("Exchange() = %q, want %q", provider.Exchange(), models.ExchangeBybit)
+	}
+}
+
+func TestBybitProviderSymbolInfo(t *testing.T) {
+	var queries []map[string]string
+	provider := NewBybitProvider(bybitServer(t, &queries).URL)
+	ctx := context.Background()
+
+	info, err := provider.SymbolInfo(ctx, "BTCUSDT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SymbolInfo{Symbol: "BTCUSDT", Status: symbolStatusTrading, ContractType: string(futures.ContractTypePerpetual),
+		QuoteAsset: "USDT", TickSize: 0.1, StepSize: 0.001}
+	if info == nil || *info != want {
+		t.Errorf("SymbolInfo() = %+v, want %+v in Binance's terms", info, want)
+	}
+
+	if info, err := provider.SymbolInfo(ctx, "ONDOUSDT"); err != nil || info != nil {
+		t.Errorf("SymbolInfo() of an unlisted symbol = %+v, %v, want nil", info, err)
+	}
+}
+
+func TestBybitErrorsSurface(t *testing.T) {
+	var queries []map[string]string
+	provider := NewBybitProvider(bybitServer(t, &queries).URL).(*bybitProvider)
+	if _, err := provider.get(context.Background(), "/v5/market/tickers", nil); err == nil {
+		t.Error("get() of an error response succeeded")
+	}
+}
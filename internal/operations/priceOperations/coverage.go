@@ -0,0 +1,116 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// PriceStreamer streams a stored series in open time order, the repository and backtest sources are
+type PriceStreamer interface {
+	StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error
+}
+
+// MissingRanges returns the stretches of symbol's timeFrame series between start and end that are not stored
+// Only candles that have closed by end are expected, so a range ending now does not count the forming one
+func (v *PriceVerifier) MissingRanges(symbol, timeFrame string, start, end time.Time) ([]PriceGap, error) {
+	return MissingRanges(v.priceRepo, symbol, timeFrame, start, end)
+}
+
+// MissingRanges returns the stretches of symbol's timeFrame series in store between start and end that are missing
+func MissingRanges(store PriceStreamer, symbol, timeFrame string, start, end time.Time) ([]PriceGap, error) {
+	interval, known := models.TimeFrameDurations[timeFrame]
+	if !known {
+		return nil, fmt.Errorf("unknown timeframe %s", timeFrame)
+	}
+
+	// Open times of the first and last candle the range should hold
+	first := start.Truncate(interval)
+	if first.Before(start) {
+		first = first.Add(interval)
+	}
+	last := end.Add(-interval).Truncate(interval)
+	if last.Before(first) {
+		return nil, nil
+	}
+
+	var gaps []PriceGap
+	next := first // Open time of the next candle expected
+	err := store.StreamPricesByTimeFrame(symbol, timeFrame, first, last, func(price models.Price) error {
+		if price.OpenTime.After(next) {
+			gaps = append(gaps, PriceGap{From: next, To: price.OpenTime.Add(-interval)})
+		}
+		if !price.OpenTime.Before(next) {
+			next = price.OpenTime.Add(interval)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s-%s: %v", symbol, timeFrame, err)
+	}
+	if !next.After(last) {
+		gaps = append(gaps, PriceGap{From: next, To: last})
+	}
+	return gaps, nil
+}
+
+// CheckCoverage fails listing every stretch of the symbols' timeFrame candles missing between start and end,
+// with the download command that fills them
+func (v *PriceVerifier) CheckCoverage(symbols []string, timeFrame string, start, end time.Time) error {
+	var missing []string
+	for _, symbol := range symbols {
+		gaps, err := v.MissingRanges(symbol, timeFrame, start, end)
+		if err != nil {
+			return err
+		}
+		for _, gap := range gaps {
+			missing = append(missing, fmt.Sprintf("  %s %s: %s to %s", symbol, timeFrame,
+				gap.From.UTC().Format("2006-01-02 15:04"), gap.To.UTC().Format("2006-01-02 15:04")))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("stored candles do not cover the backtest period and its warm-up, missing:\n%s\n"+
+		"download them with -mode download -from %s -to %s",
+		strings.Join(missing, "\n"), start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+}
+
+// Download fetches and stores the candles missing from symbol's timeFrame series between start and end,
+// returning how many were stored
+func (v *PriceVerifier) Download(ctx context.Context, symbol, timeFrame string, start, end time.Time) (int, error) {
+	if v.priceFetcher == nil {
+		return 0, fmt.Errorf("a price fetcher is required to download prices")
+	}
+
+	gaps, err := v.MissingRanges(symbol, timeFrame, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	interval := models.TimeFrameDurations[timeFrame]
+	stored := 0
+	for _, gap := range gaps {
+		// Binance treats the end time as inclusive, so stop just short of the next candle
+		prices, _, err := v.priceFetcher.GetPricesInRange(ctx, symbol, timeFrame, gap.From, gap.To.Add(interval-time.Millisecond))
+		if err != nil {
+			return stored, err
+		}
+
+		for i := range prices {
+			if reason := checkCandle(prices[i]); reason != "" {
+				log.Printf("Skipping invalid %s-%s candle at %s: %s",
+					symbol, timeFrame, prices[i].OpenTime.Format("2006-01-02 15:04"), reason)
+				continue
+			}
+			if err := v.priceRepo.Upsert(ctx, &prices[i]); err != nil {
+				return stored, fmt.Errorf("failed to save price: %v", err)
+			}
+			stored++
+		}
+	}
+	return stored, nil
+}
@@ -0,0 +1,84 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storeSeries stores n 5m candles of symbol from start
+func storeSeries(store *memoryPriceStore, symbol string, start time.Time, n int) {
+	for i := range n {
+		store.insert(testPrice(symbol, models.PriceTimeFrame5m, start.Add(time.Duration(i)*5*time.Minute), 100))
+	}
+}
+
+func TestMissingRanges(t *testing.T) {
+	store := &memoryPriceStore{}
+	// 00:00 to 00:45, then 01:00 to 01:25
+	storeSeries(store, "BTCUSDT", testStart, 10)
+	storeSeries(store, "BTCUSDT", testStart.Add(time.Hour), 6)
+	verifier := NewPriceVerifier(store, nil)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       []PriceGap
+	}{
+		{"covered", testStart, testStart.Add(50 * time.Minute), nil},
+		{"gap between stored stretches", testStart, testStart.Add(90 * time.Minute),
+			[]PriceGap{{From: testStart.Add(50 * time.Minute), To: testStart.Add(55 * time.Minute)}}},
+		{"missing before and after", testStart.Add(-10 * time.Minute), testStart.Add(100 * time.Minute), []PriceGap{
+			{From: testStart.Add(-10 * time.Minute), To: testStart.Add(-5 * time.Minute)},
+			{From: testStart.Add(50 * time.Minute), To: testStart.Add(55 * time.Minute)},
+			{From: testStart.Add(90 * time.Minute), To: testStart.Add(95 * time.Minute)},
+		}},
+		// Only candles closed by the end are expected, the one forming at 00:45 is not
+		{"forming candle", testStart, testStart.Add(47 * time.Minute), nil},
+		{"start mid-candle", testStart.Add(2 * time.Minute), testStart.Add(50 * time.Minute), nil},
+		{"empty range", testStart, testStart.Add(3 * time.Minute), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verifier.MissingRanges("BTCUSDT", models.PriceTimeFrame5m, tt.start, tt.end)
+			if err != nil {
+				t.Fatalf("MissingRanges() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("MissingRanges() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := verifier.MissingRanges("BTCUSDT", "7m", testStart, testStart.Add(time.Hour)); err == nil {
+		t.Error("MissingRanges(7m) succeeded, want unknown timeframe")
+	}
+}
+
+func TestCheckCoverageFailsFastOnAnUncoveredRange(t *testing.T) {
+	store := &memoryPriceStore{}
+	storeSeries(store, "BTCUSDT", testStart, 12)
+	storeSeries(store, "ETHUSDT", testStart, 6)
+	verifier := NewPriceVerifier(store, nil)
+
+	if err := verifier.CheckCoverage([]string{"BTCUSDT"}, models.PriceTimeFrame5m, testStart, testStart.Add(time.Hour)); err != nil {
+		t.Fatalf("CheckCoverage() on a covered hour = %v, want nil", err)
+	}
+
+	err := verifier.CheckCoverage([]string{"BTCUSDT", "ETHUSDT", "XRPUSDT"}, models.PriceTimeFrame5m, testStart, testStart.Add(time.Hour))
+	if err == nil {
+		t.Fatal("CheckCoverage() on an uncovered hour succeeded")
+	}
+	want := "stored candles do not cover the backtest period and its warm-up, missing:\n" +
+		"  ETHUSDT 5m: 2024-03-01 00:30 to 2024-03-01 00:55\n" +
+		"  XRPUSDT 5m: 2024-03-01 00:00 to 2024-03-01 00:55\n" +
+		"download them with -mode download -from 2024-03-01T00:00:00Z -to 2024-03-01T01:00:00Z"
+	if err.Error() != want {
+		t.Errorf("CheckCoverage() error =\n%s\nwant\n%s", err, want)
+	}
+	if strings.Contains(err.Error(), "BTCUSDT") {
+		t.Error("the covered BTCUSDT is listed as missing")
+	}
+}
@@ -0,0 +1,82 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// ExchangeDataProvider is the market data of one exchange the price layer fetches candles from
+// Klines come back in Binance's shape whatever the exchange, so the fetcher and recorder read them alike
+type ExchangeDataProvider interface {
+	KlineClient
+
+	// Exchange names the provider, one of models.Exchanges
+	Exchange() string
+
+	// LatestKline returns symbol's latest closed kline of interval, nil when none has closed
+	LatestKline(ctx context.Context, symbol, interval string) (*futures.Kline, error)
+
+	// SymbolInfo describes symbol in the terms of Binance's exchange info, nil when the exchange does not list it
+	SymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error)
+}
+
+// binanceProvider serves Binance futures market data
+type binanceProvider struct {
+	KlineClient
+	validator *SymbolValidator
+}
+
+// NewBinanceProvider adapts a Binance futures client to ExchangeDataProvider, weighing exchange info
+// requests against limiter
+func NewBinanceProvider(client *futures.Client, limiter *WeightLimiter) ExchangeDataProvider {
+	return &binanceProvider{
+		KlineClient: NewKlineClient(client),
+		validator:   NewSymbolValidator(client, limiter, ""),
+	}
+}
+
+func (p *binanceProvider) Exchange() string {
+	return models.ExchangeBinance
+}
+
+func (p *binanceProvider) LatestKline(ctx context.Context, symbol, interval string) (*futures.Kline, error) {
+	return latestKline(ctx, p, symbol, interval)
+}
+
+func (p *binanceProvider) SymbolInfo(ctx context.Context, symbol string) (*SymbolInfo, error) {
+	info, err := p.validator.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range info {
+		if info[i].Symbol == symbol {
+			return &info[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// NewDataProvider returns the provider of exchange, client serving Binance's
+func NewDataProvider(exchange string, client *futures.Client, limiter *WeightLimiter) (ExchangeDataProvider, error) {
+	switch exchange {
+	case models.ExchangeBinance:
+		return NewBinanceProvider(client, limiter), nil
+	case models.ExchangeBybit:
+		return NewBybitProvider(""), nil
+	}
+	return nil, fmt.Errorf("unknown exchange %q", exchange)
+}
+
+// latestKline asks client for symbol's two latest klines, the last usually still forming, and returns
+// the latest one closed
+func latestKline(ctx context.Context, client KlineClient, symbol, interval string) (*futures.Kline, error) {
+	klines, err := client.Klines(ctx, KlineRequest{Symbol: symbol, Interval: interval, Limit: 2})
+	if err != nil {
+		return nil, err
+	}
+	return lastClosed(klines, time.Now()), nil
+}
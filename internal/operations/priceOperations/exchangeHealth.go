@@ -0,0 +1,258 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Classes of a failed Binance request, see ClassifyError
+const (
+	ErrorClassMaintenance   = "maintenance"    // Binance is down for maintenance or overloaded
+	ErrorClassInvalidSymbol = "invalid_symbol" // The symbol is unknown or not trading, e.g. halted
+	ErrorClassRateLimit     = "rate_limit"     // Too many requests, also paced by the WeightLimiter
+	ErrorClassNetwork       = "network"        // Binance could not be reached
+	ErrorClassOther         = "other"          // Anything else, it does not degrade
+)
+
+const (
+	// DefaultProbeInterval is the wait before the first probe of a degraded exchange or symbol,
+	// doubled after every failed probe up to MaxProbeInterval
+	DefaultProbeInterval = 30 * time.Second
+	MaxProbeInterval     = 15 * time.Minute
+
+	// exchangeScope is the metrics scope of exchange-wide degradation
+	exchangeScope = "exchange"
+)
+
+// ErrDegraded is returned instead of calling Binance while the exchange or symbol waits for its next probe
+var ErrDegraded = errors.New("binance degraded, waiting for the next probe")
+
+// Binance error codes ClassifyError recognizes
+var (
+	maintenanceCodes   = map[int64]bool{-1001: true, -1008: true} // Disconnected, overloaded
+	invalidSymbolCodes = map[int64]bool{-1121: true, -1122: true} // Invalid symbol, invalid symbol status
+	rateLimitCodes     = map[int64]bool{-1003: true, -1015: true} // Too many requests, too many orders
+)
+
+// ClassifyError sorts a failed Binance request into one of the ErrorClass values
+func ClassifyError(err error) string {
+	var apiErr *common.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case maintenanceCodes[apiErr.Code]:
+			return ErrorClassMaintenance
+		case invalidSymbolCodes[apiErr.Code]:
+			return ErrorClassInvalidSymbol
+		case rateLimitCodes[apiErr.Code]:
+			return ErrorClassRateLimit
+		}
+		// Maintenance pages are not always JSON, the body lands in Response
+		text := strings.ToLower(apiErr.Message + " " + string(apiErr.Response))
+		if strings.Contains(text, "maintenance") {
+			return ErrorClassMaintenance
+		}
+		return ErrorClassOther
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
+
+// Degradation is why and since when requests to the exchange or one symbol are held back
+type Degradation struct {
+	Class     string    `json:"class"`
+	Since     time.Time `json:"since"`
+	Failures  int       `json:"failures"` // Failed requests and probes since it started
+	NextProbe time.Time `json:"next_probe"`
+	LastError string    `json:"last_error"`
+}
+
+// HealthStatus is the exchange's health as served by the dashboard
+type HealthStatus struct {
+	Degraded bool                   `json:"degraded"`           // Exchange-wide, new entries are blocked
+	Exchange *Degradation           `json:"exchange,omitempty"` // Set while Degraded
+	Symbols  map[string]Degradation `json:"symbols,omitempty"`  // Symbols degraded on their own
+}
+
+// ExchangeHealth tracks whether Binance, or a symbol on it, is failing and paces requests while it is
+// Maintenance, network failures and rate limits degrade the whole exchange; an invalid or halted
+// symbol degrades only itself. A degraded scope lets one probe through at exponentially growing
+// intervals until a request succeeds. Each transition is logged and notified once.
+type ExchangeHealth struct {
+	notifier *notifications.Notifier // Told about transitions, nil to only log them
+
+	mu       sync.Mutex
+	exchange *Degradation
+	symbols  map[string]*Degradation
+	now      func() time.Time
+}
+
+// NewExchangeHealth creates a new instance of ExchangeHealth, notifier may be nil
+func NewExchangeHealth(notifier *notifications.Notifier) *ExchangeHealth {
+	return &ExchangeHealth{
+		notifier: notifier,
+		symbols:  make(map[string]*Degradation),
+		now:      time.Now,
+	}
+}
+
+// Allow reports whether a request for symbol may be sent now, an empty symbol meaning an exchange-wide one
+// A due probe is allowed once, callers racing it wait for the next
+func (h *ExchangeHealth) Allow(symbol string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	scopes := []*Degradation{h.exchange, h.symbols[symbol]}
+	for _, d := range scopes {
+		if d != nil && now.Before(d.NextProbe) {
+			return false
+		}
+	}
+	for _, d := range scopes {
+		if d != nil {
+			d.NextProbe = now.Add(probeInterval(d.Failures))
+		}
+	}
+	return true
+}
+
+// Observe records the outcome of a request for symbol, err nil for success
+// A success recovers the exchange and symbol; errors that do not degrade are ignored
+func (h *ExchangeHealth) Observe(symbol string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	if err == nil {
+		if h.exchange != nil {
+			h.transition("Binance recovered", notifications.SeverityWarning, exchangeScope, false,
+				fmt.Sprintf("Requests resumed after %s of %s", now.Sub(h.exchange.Since).Round(time.Second), h.exchange.Class))
+			h.exchange = nil
+		}
+		if d, ok := h.symbols[symbol]; ok {
+			h.transition(symbol+" recovered", notifications.SeverityWarning, symbol, false,
+				fmt.Sprintf("Requests for %s resumed after %s of %s", symbol, now.Sub(d.Since).Round(time.Second), d.Class))
+			delete(h.symbols, symbol)
+		}
+		return
+	}
+
+	class := ClassifyError(err)
+	switch class {
+	case ErrorClassOther:
+		return
+	case ErrorClassInvalidSymbol:
+		if symbol == "" {
+			return
+		}
+		d, ok := h.symbols[symbol]
+		if !ok {
+			d = &Degradation{Class: class, Since: now}
+			h.symbols[symbol] = d
+			h.transition(symbol+" degraded", notifications.SeverityWarning, symbol, true,
+				fmt.Sprintf("%s is not trading (%v), probing every %s and up", symbol, err, DefaultProbeInterval))
+		}
+		bump(d, err, now)
+	default:
+		if h.exchange == nil {
+			h.exchange = &Degradation{Class: class, Since: now}
+			h.transition("Binance degraded", notifications.SeverityCritical, exchangeScope, true,
+				fmt.Sprintf("%s (%v), new entries are blocked and requests probe every %s and up", class, err, DefaultProbeInterval))
+		}
+		bump(h.exchange, err, now)
+	}
+}
+
+// bump counts a failure of d and schedules its next probe
+func bump(d *Degradation, err error, now time.Time) {
+	d.Failures++
+	d.LastError = err.Error()
+	d.NextProbe = now.Add(probeInterval(d.Failures))
+}
+
+// probeInterval returns the wait after failures failed requests, doubling from DefaultProbeInterval
+func probeInterval(failures int) time.Duration {
+	interval := DefaultProbeInterval
+	for i := 1; i < failures && interval < MaxProbeInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, MaxProbeInterval)
+}
+
+// transition logs and notifies scope becoming degraded or recovering and updates its gauge
+func (h *ExchangeHealth) transition(title string, severity notifications.Severity, scope string, degraded bool, message string) {
+	log.Printf("%s: %s", title, message)
+	if degraded {
+		metrics.ExchangeDegraded.WithLabelValues(scope).Set(1)
+	} else {
+		metrics.ExchangeDegraded.DeleteLabelValues(scope)
+	}
+	if h.notifier != nil {
+		h.notifier.Notify(notifications.Event{Severity: severity, Title: title, Message: message})
+	}
+}
+
+// Degraded reports whether the whole exchange is degraded
+func (h *ExchangeHealth) Degraded() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.exchange != nil
+}
+
+// Status returns a snapshot of the exchange's and every degraded symbol's health
+func (h *ExchangeHealth) Status() HealthStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	status := HealthStatus{Degraded: h.exchange != nil}
+	if h.exchange != nil {
+		exchange := *h.exchange
+		status.Exchange = &exchange
+	}
+	if len(h.symbols) > 0 {
+		status.Symbols = make(map[string]Degradation, len(h.symbols))
+		for symbol, d := range h.symbols {
+			status.Symbols[symbol] = *d
+		}
+	}
+	return status
+}
+
+// Guard returns client with its requests held back while their symbol or the exchange is degraded
+// and their outcomes observed; held back requests fail with ErrDegraded
+func (h *ExchangeHealth) Guard(client KlineClient) KlineClient {
+	return &guardedKlineClient{client: client, health: h}
+}
+
+type guardedKlineClient struct {
+	client KlineClient
+	health *ExchangeHealth
+}
+
+func (c *guardedKlineClient) Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	if !c.health.Allow(req.Symbol) {
+		return nil, ErrDegraded
+	}
+	klines, err := c.client.Klines(ctx, req)
+	// A cancelled request says nothing about Binance
+	if ctx.Err() == nil {
+		c.health.Observe(req.Symbol, err)
+	}
+	return klines, err
+}
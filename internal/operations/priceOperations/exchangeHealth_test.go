@@ -0,0 +1,187 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/common"
+)
+
+// recordingChannel keeps the subjects of the notifications sent to it
+type recordingChannel struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(ctx context.Context, subject, body string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.subjects = append(c.subjects, subject)
+	return nil
+}
+
+func (c *recordingChannel) sent() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.subjects...)
+}
+
+// newTestHealth returns an ExchangeHealth reading *now, notifying channel
+func newTestHealth(now *time.Time) (*ExchangeHealth, *recordingChannel) {
+	channel := &recordingChannel{}
+	notifier := notifications.NewNotifier(notifications.QuietHours{})
+	notifier.AddChannel(channel, notifications.SeverityInfo)
+	health := NewExchangeHealth(notifier)
+	health.now = func() time.Time { return *now }
+	return health, channel
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"overloaded", &common.APIError{Code: -1008, Message: "Server is currently overloaded"}, ErrorClassMaintenance},
+		{"disconnected", &common.APIError{Code: -1001}, ErrorClassMaintenance},
+		{"maintenance page", &common.APIError{Response: []byte("<html>System maintenance</html>")}, ErrorClassMaintenance},
+		{"invalid symbol", &common.APIError{Code: -1121, Message: "Invalid symbol."}, ErrorClassInvalidSymbol},
+		{"halted symbol", fmt.Errorf("fetching: %w", &common.APIError{Code: -1122}), ErrorClassInvalidSymbol},
+		{"rate limited", &common.APIError{Code: -1003}, ErrorClassRateLimit},
+		{"bad parameter", &common.APIError{Code: -1102, Message: "Mandatory parameter missing"}, ErrorClassOther},
+		{"unreachable", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, ErrorClassNetwork},
+		{"cut short", io.ErrUnexpectedEOF, ErrorClassNetwork},
+		{"anything else", errors.New("boom"), ErrorClassOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeInterval(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{5, 8 * time.Minute},
+		{6, MaxProbeInterval},
+		{50, MaxProbeInterval},
+	}
+	for _, tt := range tests {
+		if got := probeInterval(tt.failures); got != tt.want {
+			t.Errorf("probeInterval(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestExchangeHealthBacksOffAndRecovers(t *testing.T) {
+	now := testStart
+	health, channel := newTestHealth(&now)
+	fake := newFakeKlineClient()
+	fake.add("BTCUSDT", models.PriceTimeFrame5m, testKlines(testStart, 5*time.Minute, 3)...)
+	client := health.Guard(fake)
+	request := KlineRequest{Symbol: "BTCUSDT", Interval: models.PriceTimeFrame5m, Limit: 2}
+
+	// Binance goes into maintenance
+	fake.err = &common.APIError{Code: -1008, Message: "Server is currently overloaded"}
+	for range 3 {
+		client.Klines(context.Background(), request)
+	}
+	if fake.requestCount() != 1 || !health.Degraded() {
+		t.Fatalf("%d requests sent, degraded %v, want one request and the exchange degraded", fake.requestCount(), health.Degraded())
+	}
+
+	// Probes are let through at growing intervals while the maintenance lasts
+	steps := []struct {
+		after time.Duration
+		sent  bool
+	}{
+		{29 * time.Second, false},
+		{time.Second, true}, // First probe 30s after the failure, fails
+		{59 * time.Second, false},
+		{time.Second, true}, // Then after a minute, fails
+		{119 * time.Second, false},
+		{time.Second, true}, // Then after two
+	}
+	for i, step := range steps {
+		now = now.Add(step.after)
+		before := fake.requestCount()
+		_, err := client.Klines(context.Background(), request)
+		if sent := fake.requestCount() > before; sent != step.sent {
+			t.Fatalf("step %d, %v later: sent %v, want %v", i, step.after, sent, step.sent)
+		}
+		if !step.sent && !errors.Is(err, ErrDegraded) {
+			t.Errorf("step %d: held back request error = %v, want ErrDegraded", i, err)
+		}
+	}
+	status := health.Status()
+	if !status.Degraded || status.Exchange.Class != ErrorClassMaintenance || status.Exchange.Failures != 4 {
+		t.Errorf("status = %+v, want maintenance after 4 failures", status.Exchange)
+	}
+
+	// The maintenance ends, the next probe recovers the exchange
+	fake.err = nil
+	now = now.Add(4 * time.Minute)
+	if _, err := client.Klines(context.Background(), request); err != nil {
+		t.Fatalf("probe after the maintenance error = %v", err)
+	}
+	if health.Degraded() || health.Status().Exchange != nil {
+		t.Errorf("still degraded after a successful probe: %+v", health.Status())
+	}
+	if _, err := client.Klines(context.Background(), request); err != nil {
+		t.Errorf("request after recovering error = %v, want it sent right away", err)
+	}
+
+	// Each transition was notified once, not per request
+	if sent := channel.sent(); len(sent) != 2 {
+		t.Errorf("notified %q, want the degradation and the recovery once each", sent)
+	}
+}
+
+func TestHaltedSymbolDegradesOnlyItself(t *testing.T) {
+	now := testStart
+	health, _ := newTestHealth(&now)
+	fake := newFakeKlineClient()
+	client := health.Guard(fake)
+
+	fake.err = &common.APIError{Code: -1122, Message: "Invalid symbol status."}
+	client.Klines(context.Background(), KlineRequest{Symbol: "LUNAUSDT", Interval: models.PriceTimeFrame5m})
+	fake.err = nil
+
+	if _, err := client.Klines(context.Background(), KlineRequest{Symbol: "LUNAUSDT", Interval: models.PriceTimeFrame5m}); !errors.Is(err, ErrDegraded) {
+		t.Errorf("halted symbol request error = %v, want ErrDegraded", err)
+	}
+	if _, err := client.Klines(context.Background(), KlineRequest{Symbol: "BTCUSDT", Interval: models.PriceTimeFrame5m}); err != nil {
+		t.Errorf("other symbol request error = %v, want it sent", err)
+	}
+	status := health.Status()
+	if status.Degraded || status.Symbols["LUNAUSDT"].Class != ErrorClassInvalidSymbol || len(status.Symbols) != 1 {
+		t.Errorf("status = %+v, want only LUNAUSDT degraded", status)
+	}
+
+	// A cancelled request is not counted against the exchange
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake.err = context.Canceled
+	client.Klines(ctx, KlineRequest{Symbol: "BTCUSDT", Interval: models.PriceTimeFrame5m})
+	if _, ok := health.Status().Symbols["BTCUSDT"]; ok || health.Degraded() {
+		t.Errorf("a cancelled request degraded %+v", health.Status())
+	}
+}
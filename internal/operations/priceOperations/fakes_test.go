@@ -0,0 +1,258 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+var testStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testKline returns a kline of interval opening at openTime around close
+func testKline(openTime time.Time, interval time.Duration, close float64) *futures.Kline {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return &futures.Kline{
+		OpenTime:  openTime.UnixMilli(),
+		CloseTime: openTime.Add(interval).UnixMilli() - 1,
+		Open:      format(close),
+		High:      format(close + 1),
+		Low:       format(close - 1),
+		Close:     format(close),
+		Volume:    "10",
+		TradeNum:  5,
+	}
+}
+
+// testKlines returns n consecutive klines of interval from start, closing at 100, 101, ...
+func testKlines(start time.Time, interval time.Duration, n int) []*futures.Kline {
+	klines := make([]*futures.Kline, n)
+	for i := range klines {
+		klines[i] = testKline(start.Add(time.Duration(i)*interval), interval, 100+float64(i))
+	}
+	return klines
+}
+
+// fakeKlineClient serves klines kept in memory the way Binance pages them: those opening
+// between StartTime and EndTime, Limit at most, oldest first
+type fakeKlineClient struct {
+	mu       sync.Mutex
+	klines   map[string][]*futures.Kline // By symbol and interval
+	requests []KlineRequest
+	err      error // Returned by every call when set
+	pageCap  int   // Most klines one response holds whatever the limit asked, as Binance truncates; 0 for none
+}
+
+func newFakeKlineClient() *fakeKlineClient {
+	return &fakeKlineClient{klines: make(map[string][]*futures.Kline)}
+}
+
+func (c *fakeKlineClient) add(symbol, interval string, klines ...*futures.Kline) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := symbol + "|" + interval
+	c.klines[key] = append(c.klines[key], klines...)
+	sort.SliceStable(c.klines[key], func(i, j int) bool { return c.klines[key][i].OpenTime < c.klines[key][j].OpenTime })
+}
+
+func (c *fakeKlineClient) Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 500
+	}
+	if c.pageCap > 0 {
+		limit = min(limit, c.pageCap)
+	}
+	var page []*futures.Kline
+	for _, k := range c.klines[req.Symbol+"|"+req.Interval] {
+		if !req.StartTime.IsZero() && k.OpenTime < req.StartTime.UnixMilli() {
+			continue
+		}
+		if !req.EndTime.IsZero() && k.OpenTime > req.EndTime.UnixMilli() {
+			continue
+		}
+		copied := *k
+		page = append(page, &copied)
+	}
+	// Without a start Binance returns the latest candles
+	if len(page) > limit {
+		if req.StartTime.IsZero() {
+			page = page[len(page)-limit:]
+		} else {
+			page = page[:limit]
+		}
+	}
+	return page, nil
+}
+
+func (c *fakeKlineClient) requestCount() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+// memoryPriceStore keeps candles in memory in place of a PriceRepository
+type memoryPriceStore struct {
+	mu     sync.Mutex
+	prices []models.Price
+	nextID uint
+	fail   error // Returned by writes when set
+}
+
+func (s *memoryPriceStore) insert(prices ...models.Price) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range prices {
+		s.nextID++
+		p.ID = s.nextID
+		s.prices = append(s.prices, p)
+	}
+}
+
+// setFail makes writes return err from now on, nil lets them through again
+func (s *memoryPriceStore) setFail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = err
+}
+
+func (s *memoryPriceStore) Create(ctx context.Context, price *models.Price) error {
+	if s.fail != nil {
+		return s.fail
+	}
+	s.insert(*price)
+	return nil
+}
+
+func (s *memoryPriceStore) Upsert(ctx context.Context, price *models.Price) error {
+	s.mu.Lock()
+	if s.fail != nil {
+		s.mu.Unlock()
+		return s.fail
+	}
+	for i, p := range s.prices {
+		if p.Symbol == price.Symbol && p.TimeFrame == price.TimeFrame && p.OpenTime.Equal(price.OpenTime) {
+			price.ID = p.ID
+			s.prices[i] = *price
+			s.mu.Unlock()
+			return nil
+		}
+	}
+	s.mu.Unlock()
+	s.insert(*price)
+	return nil
+}
+
+func (s *memoryPriceStore) Update(price *models.Price) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail != nil {
+		return s.fail
+	}
+	for i, p := range s.prices {
+		if p.ID == price.ID {
+			s.prices[i] = *price
+			return nil
+		}
+	}
+	return fmt.Errorf("price %d not found", price.ID)
+}
+
+func (s *memoryPriceStore) GetPriceRowsByTimeFrame(symbol, timeFrame string, start, end time.Time) ([]models.Price, error) {
+	var prices []models.Price
+	err := s.StreamPricesByTimeFrame(symbol, timeFrame, start, end, func(p models.Price) error {
+		prices = append(prices, p)
+		return nil
+	})
+	return prices, err
+}
+
+func (s *memoryPriceStore) GetSeries() ([]repositories.PriceSeries, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[repositories.PriceSeries]bool)
+	var series []repositories.PriceSeries
+	for _, p := range s.prices {
+		key := repositories.PriceSeries{Symbol: p.Symbol, TimeFrame: p.TimeFrame}
+		if !seen[key] {
+			seen[key] = true
+			series = append(series, key)
+		}
+	}
+	return series, nil
+}
+
+func (s *memoryPriceStore) series(symbol, timeFrame string) []models.Price {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var prices []models.Price
+	for _, p := range s.prices {
+		if p.Symbol == symbol && p.TimeFrame == timeFrame {
+			prices = append(prices, p)
+		}
+	}
+	sort.SliceStable(prices, func(i, j int) bool {
+		if prices[i].OpenTime.Equal(prices[j].OpenTime) {
+			return prices[i].ID < prices[j].ID
+		}
+		return prices[i].OpenTime.Before(prices[j].OpenTime)
+	})
+	return prices
+}
+
+func (s *memoryPriceStore) StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error {
+	for _, p := range s.series(symbol, timeFrame) {
+		if p.OpenTime.Before(start) || p.OpenTime.After(end) { // Inclusive, as the repository's BETWEEN
+			continue
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *memoryPriceStore) DeleteByIDs(ids []uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	drop := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		drop[id] = true
+	}
+	kept := s.prices[:0]
+	for _, p := range s.prices {
+		if !drop[p.ID] {
+			kept = append(kept, p)
+		}
+	}
+	s.prices = kept
+	return nil
+}
+
+// testPrice returns a sound candle of timeFrame opening at openTime
+func testPrice(symbol, timeFrame string, openTime time.Time, close float64) models.Price {
+	return models.Price{
+		Symbol:    symbol,
+		TimeFrame: timeFrame,
+		OpenTime:  openTime,
+		CloseTime: openTime.Add(models.TimeFrameDurations[timeFrame] - time.Millisecond),
+		Open:      close,
+		High:      close + 1,
+		Low:       close - 1,
+		Close:     close,
+		Volume:    10,
+	}
+}
@@ -0,0 +1,269 @@
+//go:build integration
+
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// fixtureKlines returns symbol's timeFrame fixture candles the way Binance serves them
+func fixtureKlines(symbol, timeFrame string) []*futures.Kline {
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	var klines []*futures.Kline
+	for _, price := range testdb.FixturePrices(symbol, testdb.FixtureDays) {
+		if price.TimeFrame != timeFrame {
+			continue
+		}
+		klines = append(klines, &futures.Kline{
+			OpenTime:  price.OpenTime.UnixMilli(),
+			CloseTime: price.CloseTime.UnixMilli(),
+			Open:      format(price.Open),
+			High:      format(price.High),
+			Low:       format(price.Low),
+			Close:     format(price.Close),
+			Volume:    format(price.Volume),
+			TradeNum:  price.TradeCount,
+		})
+	}
+	return klines
+}
+
+func TestDownloadFillsGapInStoredSeries(t *testing.T) {
+	db := testdb.Seeded(t)
+	prices := repositories.NewPriceRepository(db)
+
+	// Three hours of BTCUSDT 5m candles lost to an outage
+	holeStart := testdb.FixtureStart.Add(4*24*time.Hour + 10*time.Hour)
+	holeEnd := holeStart.Add(3 * time.Hour)
+	err := db.Unscoped().
+		Where("symbol = ? AND time_frame = ? AND open_time >= ? AND open_time < ?", "BTCUSDT", models.PriceTimeFrame5m, holeStart, holeEnd).
+		Delete(&models.Price{}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", models.PriceTimeFrame5m, fixtureKlines("BTCUSDT", models.PriceTimeFrame5m)...)
+	fetcher := NewPriceFetcher(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{"BTCUSDT"})
+	verifier := NewPriceVerifier(prices, fetcher)
+
+	start, end := testdb.FixtureStart, testdb.FixtureStart.Add(testdb.FixtureDays*24*time.Hour)
+	gaps, err := verifier.MissingRanges("BTCUSDT", models.PriceTimeFrame5m, start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PriceGap{From: holeStart, To: holeEnd.Add(-5 * time.Minute)}
+	if len(gaps) != 1 || !gaps[0].From.Equal(want.From) || !gaps[0].To.Equal(want.To) {
+		t.Fatalf("MissingRanges() = %v, want [%v]", gaps, want)
+	}
+
+	stored, err := verifier.Download(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, start, end)
+	if err != nil {
+		t.Fatalf("Download() error = %v", err)
+	}
+	if stored != 36 {
+		t.Errorf("Download() stored %d candles, want 36", stored)
+	}
+	if gaps, err := verifier.MissingRanges("BTCUSDT", models.PriceTimeFrame5m, start, end); err != nil || len(gaps) != 0 {
+		t.Errorf("MissingRanges() after the download = %v, %v, want none", gaps, err)
+	}
+
+	filled, err := prices.GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, holeStart, holeEnd.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filled) != 36 {
+		t.Fatalf("got %d candles in the hole, want 36", len(filled))
+	}
+	want5m := testdb.Price(t, "BTCUSDT", models.PriceTimeFrame5m, holeStart)
+	if filled[0].Close != want5m.Close || filled[0].Volume != want5m.Volume {
+		t.Errorf("first filled candle = %+v, want the fixture's %+v", filled[0], want5m)
+	}
+}
+
+func TestUniverseSelectionIsStoredForReplay(t *testing.T) {
+	db := testdb.Open(t)
+	repo := repositories.NewUniverseRepository(db)
+	s := cannedUniverse(UniverseConfig{Size: 2, MinQuoteVolume: 50_000_000, QuoteAsset: "USDT"})
+	s.repo = repo
+
+	symbols, err := s.Select(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	snapshots, err := repo.FindForPeriod(today, today.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || !snapshots[0].Day.Equal(today) || strings.Join(snapshots[0].SymbolList(), ",") != strings.Join(symbols, ",") {
+		t.Fatalf("stored %+v, want today's selection %v", snapshots, symbols)
+	}
+
+	// A second refresh the same day replaces the selection rather than adding one
+	s.config.Size = 1
+	if _, err := s.Select(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err = repo.FindForPeriod(today, today.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Symbols != "BTCUSDT" {
+		t.Errorf("after reselecting stored %+v, want only BTCUSDT", snapshots)
+	}
+}
+
+func TestPruneKeepsCandlesWithinRetention(t *testing.T) {
+	db := testdb.Seeded(t)
+	prices := repositories.NewPriceRepository(db)
+	end := testdb.FixtureStart.Add(testdb.FixtureDays * 24 * time.Hour)
+
+	// 5m keeps two days, 1h keeps a day but the warm-up protects three, 4h and 1d are kept forever
+	policy := RetentionPolicy{
+		models.PriceTimeFrame5m: 2 * 24 * time.Hour,
+		models.PriceTimeFrame1h: 24 * time.Hour,
+	}
+	s := NewRetentionService(prices, policy, 3*24*time.Hour)
+	cutoffs := map[string]time.Time{
+		models.PriceTimeFrame5m: end.Add(-2 * 24 * time.Hour),
+		models.PriceTimeFrame1h: end.Add(-3 * 24 * time.Hour),
+	}
+
+	// What should survive, per symbol and timeframe, and what should go
+	survivors := make(map[string]int64)
+	pruned := make(map[string]int64)
+	for symbol := range testdb.FixtureSymbols {
+		for _, price := range testdb.FixturePrices(symbol, testdb.FixtureDays) {
+			key := symbol + " " + price.TimeFrame
+			if cutoff, ok := cutoffs[price.TimeFrame]; ok && price.OpenTime.Before(cutoff) {
+				pruned[key]++
+				continue
+			}
+			survivors[key]++
+		}
+	}
+	count := func() map[string]int64 {
+		counts := make(map[string]int64)
+		var rows []struct {
+			Symbol    string
+			TimeFrame string
+			N         int64
+		}
+		if err := db.Model(&models.Price{}).Select("symbol, time_frame, count(*) AS n").Group("symbol, time_frame").Scan(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			counts[row.Symbol+" "+row.TimeFrame] = row.N
+		}
+		return counts
+	}
+	before := count()
+
+	// A dry run reports the same rows and deletes none
+	dry, err := s.Prune(end, true)
+	if err != nil {
+		t.Fatalf("Prune(dry run) error = %v", err)
+	}
+	if after := count(); !maps.Equal(after, before) {
+		t.Errorf("dry run changed the table: %v, want %v", after, before)
+	}
+
+	results, err := s.Prune(end, false)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if !slices.Equal(dry, results) {
+		t.Errorf("dry run reported %+v, the prune removed %+v", dry, results)
+	}
+	reported := make(map[string]int64)
+	for _, result := range results {
+		reported[result.Symbol+" "+result.TimeFrame] = result.Rows
+	}
+	if !maps.Equal(reported, pruned) {
+		t.Errorf("pruned %v, want %v", reported, pruned)
+	}
+	if after := count(); !maps.Equal(after, survivors) {
+		t.Errorf("rows left %v, want %v", after, survivors)
+	}
+
+	// Nothing left to prune the second time
+	if results, err := s.Prune(end, false); err != nil || len(results) != 0 {
+		t.Errorf("second Prune() = %+v, %v, want nothing", results, err)
+	}
+}
+
+// racingStore is a PriceRepository running between the reconciler's read and its write, as a recorder would
+type racingStore struct {
+	*repositories.PriceRepository
+	afterRead func()
+}
+
+func (s *racingStore) GetPriceRowsByTimeFrame(symbol, timeFrame string, start, end time.Time) ([]models.Price, error) {
+	stored, err := s.PriceRepository.GetPriceRowsByTimeFrame(symbol, timeFrame, start, end)
+	if s.afterRead != nil {
+		s.afterRead()
+	}
+	return stored, err
+}
+
+func TestReconcilerStoresACandleTheRecorderWroteMeanwhile(t *testing.T) {
+	const symbol = "RACEUSDT"
+	interval := 5 * time.Minute
+	now := testStart.Add(12*time.Hour + time.Minute)
+	closed := testStart.Add(11*time.Hour + 55*time.Minute)
+	db := testdb.Open(t)
+	prices := repositories.NewPriceRepository(db)
+
+	// The recorder snapshots 11:55 before Binance finalized it, the reconciler fetches the final one
+	recorded := newFakeKlineClient()
+	recorded.add(symbol, models.PriceTimeFrame5m, testKline(closed, interval, 101.5), testKline(now.Truncate(interval), interval, 200))
+	recorder := NewPriceRecorder(recorded, stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now), nil, []string{symbol}, "")
+	recorder.SetClock(clock.NewFake(now))
+
+	final := newFakeKlineClient()
+	final.add(symbol, models.PriceTimeFrame5m, testKline(closed, interval, 102))
+	fetcher := NewPriceFetcher(final, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{symbol})
+
+	// The reconciler finds 11:55 missing, then the recorder stores it before the reconciler does
+	store := &racingStore{PriceRepository: prices}
+	store.afterRead = func() {
+		store.afterRead = nil
+		ctx, cancel := context.WithCancel(context.Background())
+		recorder.writes = NewWriteQueue(prices, DefaultWriteQueueSize, "", func(models.Price) {})
+		go recorder.writes.Run(ctx)
+		recorder.recordPrices(ctx, models.PriceTimeFrame5m)
+		cancel()
+		<-recorder.writes.Done()
+	}
+	r := NewReconciler(fetcher, store, func() []string { return []string{symbol} }, []string{models.PriceTimeFrame5m})
+	r.SetClock(clock.NewFake(now))
+
+	corrected, err := r.ReconcileOnce(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileOnce() error = %v", err)
+	}
+	if corrected != 1 {
+		t.Errorf("corrected %d candles, want the one it found missing", corrected)
+	}
+
+	stored, err := prices.GetPriceRowsByTimeFrame(symbol, models.PriceTimeFrame5m, closed, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 || !stored[0].OpenTime.Equal(closed) || stored[0].Close != 102 {
+		t.Fatalf("stored %+v, want a single 11:55 candle with the final close 102", stored)
+	}
+}
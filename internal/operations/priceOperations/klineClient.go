@@ -0,0 +1,47 @@
+package priceOperations
+
+import (
+	"context"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// KlineRequest is one klines call, zero times and limit leave Binance's defaults
+type KlineRequest struct {
+	Symbol    string
+	Interval  string
+	StartTime time.Time
+	EndTime   time.Time
+	Limit     int
+}
+
+// KlineClient is the part of the futures API the candle fetcher and recorder use
+// NewKlineClient implements it over Binance; anything else, such as a fake, can stand in
+type KlineClient interface {
+	Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error)
+}
+
+// futuresKlineClient serves klines from a Binance futures client
+type futuresKlineClient struct {
+	client *futures.Client
+}
+
+// NewKlineClient adapts a Binance futures client to KlineClient
+func NewKlineClient(client *futures.Client) KlineClient {
+	return &futuresKlineClient{client: client}
+}
+
+func (c *futuresKlineClient) Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	service := c.client.NewKlinesService().Symbol(req.Symbol).Interval(req.Interval)
+	if !req.StartTime.IsZero() {
+		service = service.StartTime(req.StartTime.UnixMilli())
+	}
+	if !req.EndTime.IsZero() {
+		service = service.EndTime(req.EndTime.UnixMilli())
+	}
+	if req.Limit > 0 {
+		service = service.Limit(req.Limit)
+	}
+	return service.Do(ctx)
+}
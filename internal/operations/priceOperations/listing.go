@@ -0,0 +1,54 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// listingTimeFrame is the series probed for a symbol's listing, the analysis resamples every higher
+// timeframe from it
+const listingTimeFrame = models.PriceTimeFrame5m
+
+// ProbeListing returns the open time of symbol's first candle on the exchange and remembers it,
+// so ranges fetched afterwards start at the listing instead of paging through time before it
+func (f *PriceFetcher) ProbeListing(ctx context.Context, symbol string) (time.Time, error) {
+	if err := f.limiter.Wait(ctx, KlineWeight(1)); err != nil {
+		return time.Time{}, err
+	}
+	klines, err := f.client.Klines(ctx, KlineRequest{
+		Symbol:    symbol,
+		Interval:  listingTimeFrame,
+		StartTime: time.UnixMilli(0),
+		Limit:     1,
+	})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to probe %s listing: %v", symbol, err)
+	}
+	if len(klines) == 0 {
+		return time.Time{}, fmt.Errorf("%s has no candles", symbol)
+	}
+
+	listed := time.UnixMilli(klines[0].OpenTime)
+	f.mu.Lock()
+	f.listings[symbol] = listed
+	f.mu.Unlock()
+	return listed, nil
+}
+
+// Listing returns when symbol was listed, false until ProbeListing found it
+func (f *PriceFetcher) Listing(symbol string) (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	listed, ok := f.listings[symbol]
+	return listed, ok
+}
+
+// clipToListing moves start up to symbol's listing when it is known and later
+func (f *PriceFetcher) clipToListing(symbol string, start time.Time) time.Time {
+	if listed, ok := f.Listing(symbol); ok && listed.After(start) {
+		return listed
+	}
+	return start
+}
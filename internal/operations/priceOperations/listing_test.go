@@ -0,0 +1,54 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestProbedListingClipsHistoricalRanges(t *testing.T) {
+	// ONDOUSDT listed two days into a 30 day history request
+	listed := testStart.Add(28 * 24 * time.Hour)
+	end := testStart.Add(30*24*time.Hour - time.Millisecond)
+	client := newFakeKlineClient()
+	client.add("ONDOUSDT", models.PriceTimeFrame5m, testKlines(listed, 5*time.Minute, 576)...)
+	fetcher := newTestFetcher(client)
+	ctx := context.Background()
+
+	if _, ok := fetcher.Listing("ONDOUSDT"); ok {
+		t.Fatal("Listing() found ONDOUSDT before it was probed")
+	}
+	got, err := fetcher.ProbeListing(ctx, "ONDOUSDT")
+	if err != nil {
+		t.Fatalf("ProbeListing() error = %v", err)
+	}
+	if !got.Equal(listed) {
+		t.Fatalf("ProbeListing() = %v, want %v", got, listed)
+	}
+	if remembered, ok := fetcher.Listing("ONDOUSDT"); !ok || !remembered.Equal(listed) {
+		t.Fatalf("Listing() = %v, %v after probing", remembered, ok)
+	}
+
+	// The range starts at the listing, so its 28 empty days are neither requested nor counted missing
+	client.requests = nil
+	prices, summary, err := fetcher.GetPricesInRange(ctx, "ONDOUSDT", models.PriceTimeFrame5m, testStart, end)
+	if err != nil {
+		t.Fatalf("GetPricesInRange() error = %v", err)
+	}
+	checkSeries(t, prices, listed, 5*time.Minute, 576)
+	if !client.requests[0].StartTime.Equal(listed) {
+		t.Errorf("first page starts at %v, want the listing %v", client.requests[0].StartTime, listed)
+	}
+	if summary.Expected != 576 || !summary.Complete() {
+		t.Errorf("summary = %+v, want the 576 candles since the listing complete", summary)
+	}
+
+	// A symbol without candles is not remembered
+	if _, err := fetcher.ProbeListing(ctx, "NEWUSDT"); err == nil {
+		t.Error("ProbeListing() found a listing without candles")
+	}
+	if _, ok := fetcher.Listing("NEWUSDT"); ok {
+		t.Error("Listing() remembered a failed probe")
+	}
+}
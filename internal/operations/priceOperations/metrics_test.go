@@ -0,0 +1,95 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// roundTripFunc serves requests from a function in place of the network
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// scrape returns the registry's series the way Prometheus reads them from /metrics
+func scrape(t *testing.T) string {
+	t.Helper()
+	server := httptest.NewServer(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	defer server.Close()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestMetricsScrapeAfterOperations(t *testing.T) {
+	// A failed fetch, every attempt counted
+	klines := newFakeKlineClient()
+	klines.err = errors.New("connection reset")
+	fetcher := NewPriceFetcher(klines, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{"METRICUSDT"})
+	fetcher.sleep = func(context.Context, time.Duration) error { return nil }
+	if _, _, err := fetcher.GetPricesInRange(context.Background(), "METRICUSDT", models.PriceTimeFrame5m, testStart, testStart.Add(time.Hour)); err == nil {
+		t.Fatal("GetPricesInRange() succeeded against a failing client")
+	}
+
+	// A rate limited response carrying the used weight, after the fetch so the gauge keeps its value
+	limiter := NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold)
+	client := &http.Client{Transport: limiter.Transport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set(usedWeightHeader, "1234")
+		header.Set("Retry-After", "1")
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Status: "429 Too Many Requests", Header: header,
+			Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))}
+	resp, err := client.Get("http://binance.test/fapi/v1/klines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// A recorded candle
+	recorder := &PriceRecorder{}
+	queue := NewWriteQueue(&memoryPriceStore{}, 4, "", recorder.saved)
+	ctx, cancel := context.WithCancel(context.Background())
+	go queue.Run(ctx)
+	queue.Enqueue(testPrice("METRICUSDT", models.PriceTimeFrame5m, testStart, 100))
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(scrape(t), `tradebot_candles_recorded_total{symbol="METRICUSDT",timeframe="5m"} 1`) {
+		if time.Now().After(deadline) {
+			t.Fatal("the recorded candle was not counted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-queue.Done()
+
+	body := scrape(t)
+	for _, series := range []string{
+		`tradebot_api_rate_limited_total{status="429"} 1`,
+		`tradebot_api_weight_used 1234`,
+		fmt.Sprintf(`tradebot_api_errors_total{symbol="METRICUSDT",timeframe="5m"} %d`, KlineAttempts),
+		`tradebot_candles_recorded_total{symbol="METRICUSDT",timeframe="5m"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Errorf("scrape is missing %s", series)
+		}
+	}
+}
@@ -1,61 +1,200 @@
 package priceOperations
 
 import (
+	"CryptoTradeBot/internal/metrics"
 	"CryptoTradeBot/internal/models"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
 
 type PriceFetcher struct {
-	client  *futures.Client
+	client  KlineClient
+	limiter *WeightLimiter
 	symbols []string
+	sleep   func(ctx context.Context, d time.Duration) error // Waits out the backoff between attempts at a page
+
+	mu       sync.Mutex
+	listings map[string]time.Time // First candle of each symbol probed, see ProbeListing
 }
 
 // NewPriceFetcher creates a new instance of PriceFetcher
-func NewPriceFetcher(client *futures.Client, symbols []string) *PriceFetcher {
+func NewPriceFetcher(client KlineClient, limiter *WeightLimiter, symbols []string) *PriceFetcher {
 	return &PriceFetcher{
-		client:  client,
-		symbols: symbols,
+		client:   client,
+		limiter:  limiter,
+		symbols:  symbols,
+		sleep:    sleepContext,
+		listings: make(map[string]time.Time),
 	}
 }
 
-// getHistoricalPrices retrieves historical price data for the specified timeframe and number of days
+// Attempts at each klines page and the backoff between them, doubling from KlineRetryBase
+const (
+	KlineAttempts  = 3
+	KlineRetryBase = time.Second
+	KlineRetryMax  = 10 * time.Second
+)
+
+// klinePageLimit is how many candles one klines request asks for, the most Binance futures returns
+const klinePageLimit = 1500
+
+// FetchSummary is how completely a range of candles was fetched
+type FetchSummary struct {
+	Symbol    string
+	TimeFrame string
+	Expected  int // Candles opening within the range
+	Fetched   int // Candles returned, without duplicates or malformed ones
+	Pages     int
+	Gaps      int // Breaks in continuity between consecutive candles
+	Malformed int // Candles skipped for unparseable values
+}
+
+// Complete reports whether every expected candle was fetched without gaps
+func (s FetchSummary) Complete() bool {
+	return s.Fetched >= s.Expected && s.Gaps == 0
+}
+
+func (s FetchSummary) String() string {
+	return fmt.Sprintf("%s-%s: fetched %d of %d expected candles in %d pages, %d gaps, %d malformed",
+		s.Symbol, s.TimeFrame, s.Fetched, s.Expected, s.Pages, s.Gaps, s.Malformed)
+}
+
+// GetHistoricalPrices retrieves the given number of days of every symbol's candles on timeframe
+// A symbol that fails is logged and left out, see Backfill
 func (f *PriceFetcher) GetHistoricalPrices(ctx context.Context, timeframe string, days int) ([]models.Price, error) {
 	endTime := time.Now()
 	startTime := endTime.AddDate(0, 0, -days)
 
 	var allPrices []models.Price
+	result, err := f.Backfill(ctx, f.symbols, timeframe, startTime, endTime, func(ctx context.Context, symbol string, prices []models.Price) error {
+		allPrices = append(allPrices, prices...)
+		return nil
+	}, BackfillOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := result.Err(); err != nil {
+		log.Printf("Error fetching %s historical data: %v", timeframe, err)
+	}
+	return allPrices, nil
+}
 
-	for _, symbol := range f.symbols {
-		klines, err := f.client.NewKlinesService().
-			Symbol(symbol).
-			Interval(timeframe).
-			StartTime(startTime.UnixNano() / int64(time.Millisecond)).
-			EndTime(endTime.UnixNano() / int64(time.Millisecond)).
-			Do(ctx)
+// GetPricesInRange retrieves every candle of a symbol and timeframe opening between start and end
+// Binance truncates each response, so pages are requested from the last candle's close until the
+// range is exhausted; duplicates across pages are dropped and breaks in continuity counted.
+// A range starting before the symbol's probed listing starts at the listing
+func (f *PriceFetcher) GetPricesInRange(ctx context.Context, symbol, timeframe string, start, end time.Time) ([]models.Price, FetchSummary, error) {
+	start = f.clipToListing(symbol, start)
+	summary := FetchSummary{Symbol: symbol, TimeFrame: timeframe}
+	interval, known := models.TimeFrameDurations[timeframe]
+	if known {
+		first := start.Truncate(interval)
+		if first.Before(start) {
+			first = first.Add(interval)
+		}
+		if !first.After(end) {
+			summary.Expected = int(end.Sub(first)/interval) + 1
+		}
+	}
 
+	var prices []models.Price
+	for from := start; !from.After(end); {
+		klines, err := f.fetchPage(ctx, KlineRequest{
+			Symbol:    symbol,
+			Interval:  timeframe,
+			StartTime: from,
+			EndTime:   end,
+			Limit:     klinePageLimit,
+		})
 		if err != nil {
-			log.Printf("Error fetching historical data for %s-%s: %v", symbol, timeframe, err)
-			continue
+			return prices, summary, err
+		}
+		summary.Pages++
+		if len(klines) == 0 {
+			break
 		}
 
 		for _, k := range klines {
-			price := models.Price{
-				Symbol:    symbol,
-				TimeFrame: timeframe,
-				OpenTime:  time.Unix(k.OpenTime/1000, 0),
-				Open:      parseFloat(k.Open),
-				High:      parseFloat(k.High),
-				Low:       parseFloat(k.Low),
-				Close:     parseFloat(k.Close),
-				Volume:    parseFloat(k.Volume),
+			price, err := klineToPrice(symbol, timeframe, k)
+			if err != nil {
+				log.Printf("Skipping malformed kline for %s-%s: %v", symbol, timeframe, err)
+				summary.Malformed++
+				continue
 			}
-			allPrices = append(allPrices, price)
+			if n := len(prices); n > 0 {
+				last := prices[n-1].OpenTime
+				if !price.OpenTime.After(last) {
+					continue // Repeated by the next page
+				}
+				if known && price.OpenTime.Sub(last) > interval {
+					summary.Gaps++
+				}
+			}
+			prices = append(prices, *price)
+		}
+
+		// Binance may cap a page below the limit asked for, so a short page only ends the range
+		// early when the next candle has not opened yet
+		next := time.UnixMilli(klines[len(klines)-1].CloseTime + 1)
+		if !next.After(from) || (len(klines) < klinePageLimit && next.After(time.Now())) {
+			break
 		}
+		from = next
 	}
 
-	return allPrices, nil
+	summary.Fetched = len(prices)
+	if !summary.Complete() {
+		log.Printf("Incomplete fetch, %s", summary)
+	}
+	return prices, summary, nil
+}
+
+// fetchPage requests one page of klines, retrying a failed request with backoff up to KlineAttempts times
+// A degraded exchange or a cancelled ctx is not retried
+func (f *PriceFetcher) fetchPage(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	var err error
+	for attempt := 0; attempt < KlineAttempts; attempt++ {
+		if attempt > 0 {
+			backoff := min(KlineRetryBase<<(attempt-1), KlineRetryMax)
+			log.Printf("Retrying %s-%s klines in %s: %v", req.Symbol, req.Interval, backoff, err)
+			if sleepErr := f.sleep(ctx, backoff); sleepErr != nil {
+				return nil, sleepErr
+			}
+		}
+		if waitErr := f.limiter.Wait(ctx, KlineWeight(req.Limit)); waitErr != nil {
+			return nil, waitErr
+		}
+
+		var klines []*futures.Kline
+		klines, err = f.client.Klines(ctx, req)
+		if err == nil {
+			return klines, nil
+		}
+		if errors.Is(err, ErrDegraded) {
+			return nil, err
+		}
+		metrics.APIErrors.WithLabelValues(req.Symbol, req.Interval).Inc()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+	return nil, fmt.Errorf("error fetching %s-%s: %v", req.Symbol, req.Interval, err)
+}
+
+// sleepContext waits d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
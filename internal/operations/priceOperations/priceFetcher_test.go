@@ -0,0 +1,209 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// newTestFetcher returns a fetcher on client with an idle weight budget
+func newTestFetcher(client KlineClient) *PriceFetcher {
+	return NewPriceFetcher(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{"BTCUSDT"})
+}
+
+// checkSeries fails t unless prices open every interval from start, n of them
+func checkSeries(t *testing.T, prices []models.Price, start time.Time, interval time.Duration, n int) {
+	t.Helper()
+	if len(prices) != n {
+		t.Fatalf("got %d candles, want %d", len(prices), n)
+	}
+	for i, price := range prices {
+		if want := start.Add(time.Duration(i) * interval); !price.OpenTime.Equal(want) {
+			t.Fatalf("candle %d opens at %s, want %s without gaps or duplicates", i, price.OpenTime, want)
+		}
+	}
+}
+
+func TestGetPricesInRangeAssemblesCappedPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageCap   int
+		wantPages int
+	}{
+		{"capped at 500", 500, 3},
+		{"capped at 1000", 1000, 2},
+		{"uncapped", 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A day of 1m candles is 1440, beyond what one capped page holds
+			client := newFakeKlineClient()
+			client.pageCap = tt.pageCap
+			client.add("BTCUSDT", models.PriceTimeFrame1m, testKlines(testStart, time.Minute, 1440)...)
+
+			end := testStart.Add(24*time.Hour - time.Millisecond)
+			prices, summary, err := newTestFetcher(client).GetPricesInRange(context.Background(), "BTCUSDT", models.PriceTimeFrame1m, testStart, end)
+			if err != nil {
+				t.Fatalf("GetPricesInRange() error = %v", err)
+			}
+			checkSeries(t, prices, testStart, time.Minute, 1440)
+			want := FetchSummary{Symbol: "BTCUSDT", TimeFrame: models.PriceTimeFrame1m, Expected: 1440, Fetched: 1440, Pages: tt.wantPages}
+			if summary != want || !summary.Complete() {
+				t.Errorf("summary = %+v, want %+v", summary, want)
+			}
+
+			// Each page starts just after the previous page's last candle closed
+			for i := 1; i < len(client.requests); i++ {
+				if want := testStart.Add(time.Duration(i*tt.pageCap) * time.Minute); !client.requests[i].StartTime.Equal(want) {
+					t.Errorf("page %d starts at %s, want %s", i, client.requests[i].StartTime, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetPricesInRangeCountsGapsAndDropsDuplicates(t *testing.T) {
+	client := newFakeKlineClient()
+	client.pageCap = 4
+	klines := testKlines(testStart, 5*time.Minute, 12)
+	// The candle at 00:30 is missing and the one at 00:15 served twice, once at a page boundary
+	client.add("BTCUSDT", models.PriceTimeFrame5m, append(klines[:6], klines[7:]...)...)
+	client.add("BTCUSDT", models.PriceTimeFrame5m, testKline(testStart.Add(15*time.Minute), 5*time.Minute, 103))
+
+	end := testStart.Add(time.Hour - time.Millisecond)
+	prices, summary, err := newTestFetcher(client).GetPricesInRange(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, testStart, end)
+	if err != nil {
+		t.Fatalf("GetPricesInRange() error = %v", err)
+	}
+	if len(prices) != 11 {
+		t.Fatalf("got %d candles, want the 11 stored once each", len(prices))
+	}
+	for i := 1; i < len(prices); i++ {
+		if !prices[i].OpenTime.After(prices[i-1].OpenTime) {
+			t.Fatalf("candle %d at %s repeats or precedes %s", i, prices[i].OpenTime, prices[i-1].OpenTime)
+		}
+	}
+	if summary.Expected != 12 || summary.Fetched != 11 || summary.Gaps != 1 || summary.Complete() {
+		t.Errorf("summary = %+v, want 11 of 12 with one gap, incomplete", summary)
+	}
+}
+
+func TestGetPricesInRangeStopsAtTheFormingCandle(t *testing.T) {
+	// The last candle is still forming, so a short page holds everything there is
+	interval := 5 * time.Minute
+	start := time.Now().Truncate(interval).Add(-9 * interval)
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", models.PriceTimeFrame5m, testKlines(start, interval, 10)...)
+
+	prices, summary, err := newTestFetcher(client).GetPricesInRange(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetPricesInRange() error = %v", err)
+	}
+	checkSeries(t, prices, start, interval, 10)
+	if summary.Pages != 1 || client.requestCount() != 1 {
+		t.Errorf("pages = %d in %d requests, want the one short page", summary.Pages, client.requestCount())
+	}
+}
+
+func TestGetPricesInRangePagesAtTheLimit(t *testing.T) {
+	// Two full pages of 1500 and a last one of 600
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", models.PriceTimeFrame1m, testKlines(testStart, time.Minute, 3600)...)
+
+	end := testStart.Add(60*time.Hour - time.Millisecond)
+	prices, summary, err := newTestFetcher(client).GetPricesInRange(context.Background(), "BTCUSDT", models.PriceTimeFrame1m, testStart, end)
+	if err != nil {
+		t.Fatalf("GetPricesInRange() error = %v", err)
+	}
+	checkSeries(t, prices, testStart, time.Minute, 3600)
+	if summary.Pages != 3 {
+		t.Errorf("fetched in %d pages, want 3", summary.Pages)
+	}
+	for i, req := range client.requests {
+		if want := testStart.Add(time.Duration(i*klinePageLimit) * time.Minute); req.Limit != klinePageLimit || !req.StartTime.Equal(want) || !req.EndTime.Equal(end) {
+			t.Errorf("page %d = %+v, want %d from %s to %s", i, req, klinePageLimit, want, end)
+		}
+	}
+}
+
+// flakyClient fails its first fails calls with err, then serves from its KlineClient
+type flakyClient struct {
+	KlineClient
+	fails int
+	err   error
+	calls int
+}
+
+func (c *flakyClient) Klines(ctx context.Context, req KlineRequest) ([]*futures.Kline, error) {
+	c.calls++
+	if c.calls <= c.fails {
+		return nil, c.err
+	}
+	return c.KlineClient.Klines(ctx, req)
+}
+
+func TestGetPricesInRangeRetriesWithBackoff(t *testing.T) {
+	reset := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		fails    int
+		err      error
+		attempts int
+		sleeps   []time.Duration
+		wantErr  error // nil for success
+	}{
+		{"first attempt succeeds", 0, reset, 1, nil, nil},
+		{"recovers on the last attempt", 2, reset, 3, []time.Duration{time.Second, 2 * time.Second}, nil},
+		{"gives up after every attempt", 3, reset, 3, []time.Duration{time.Second, 2 * time.Second}, reset},
+		{"degraded exchange not retried", 1, ErrDegraded, 1, nil, ErrDegraded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeKlineClient()
+			fake.add("BTCUSDT", models.PriceTimeFrame5m, testKlines(testStart, 5*time.Minute, 12)...)
+			client := &flakyClient{KlineClient: fake, fails: tt.fails, err: tt.err}
+			fetcher := newTestFetcher(client)
+			var sleeps []time.Duration
+			fetcher.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}
+
+			prices, _, err := fetcher.GetPricesInRange(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, testStart, testStart.Add(time.Hour-time.Millisecond))
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("GetPricesInRange() error = %v", err)
+			case tt.wantErr == nil:
+				checkSeries(t, prices, testStart, 5*time.Minute, 12)
+			case err == nil || (!errors.Is(err, tt.wantErr) && !strings.Contains(err.Error(), tt.wantErr.Error())):
+				t.Fatalf("GetPricesInRange() error = %v, want %v", err, tt.wantErr)
+			}
+			if client.calls != tt.attempts || !slices.Equal(sleeps, tt.sleeps) {
+				t.Errorf("%d attempts sleeping %v, want %d sleeping %v", client.calls, sleeps, tt.attempts, tt.sleeps)
+			}
+		})
+	}
+}
+
+func TestGetPricesInRangeStopsRetryingWhenCancelled(t *testing.T) {
+	client := &flakyClient{KlineClient: newFakeKlineClient(), fails: KlineAttempts, err: errors.New("timeout")}
+	fetcher := newTestFetcher(client)
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcher.sleep = func(ctx context.Context, d time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	if _, _, err := fetcher.GetPricesInRange(ctx, "BTCUSDT", models.PriceTimeFrame5m, testStart, testStart.Add(time.Hour)); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetPricesInRange() error = %v, want context.Canceled", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("%d attempts, want none after the cancel", client.calls)
+	}
+}
@@ -1,51 +1,118 @@
 package priceOperations
 
 import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/events"
+	"CryptoTradeBot/internal/metrics"
 	"CryptoTradeBot/internal/models"
 	"CryptoTradeBot/internal/repositories"
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/adshao/go-binance/v2/futures"
 )
 
 type PriceRecorder struct {
-	client    *futures.Client
+	client    KlineClient
+	limiter   *WeightLimiter
 	priceRepo *repositories.PriceRepository
-	symbols   []string
+	clock     clock.Clock
+	bus       *events.Bus // Told about every saved candle, nil when nothing listens
+	writes    *WriteQueue
+
+	mu      sync.Mutex
+	symbols []string
 }
 
 // NewPriceRecorder creates a new instance of PriceRecorder
-func NewPriceRecorder(client *futures.Client, priceRepo *repositories.PriceRepository, symbols []string) *PriceRecorder {
-	return &PriceRecorder{
+// Candles are saved through a write queue spilling to spillPath, empty to drop what the queue cannot hold
+func NewPriceRecorder(client KlineClient, limiter *WeightLimiter, priceRepo *repositories.PriceRepository, symbols []string, spillPath string) *PriceRecorder {
+	r := &PriceRecorder{
 		client:    client,
+		limiter:   limiter,
 		priceRepo: priceRepo,
 		symbols:   symbols,
+		clock:     clock.Real,
 	}
+	r.writes = NewWriteQueue(priceRepo, DefaultWriteQueueSize, spillPath, r.saved)
+	return r
 }
 
-// StartRecording begins recording price data for the specified symbols
-func (r *PriceRecorder) StartRecording(ctx context.Context) {
+// SetClock replaces the wall clock that paces recording
+func (r *PriceRecorder) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// PublishTo announces every saved candle on bus
+func (r *PriceRecorder) PublishTo(bus *events.Bus) {
+	r.bus = bus
+}
+
+// AddSymbol starts recording symbol from the next tick of every timeframe
+func (r *PriceRecorder) AddSymbol(symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.symbols, symbol) {
+		r.symbols = append(r.symbols, symbol)
+	}
+}
+
+// RemoveSymbol stops recording symbol
+func (r *PriceRecorder) RemoveSymbol(symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.symbols = slices.DeleteFunc(slices.Clone(r.symbols), func(s string) bool { return s == symbol })
+}
 
-	// choose timeframes to record
-	timeframes := map[string]time.Duration{
-		"5m":  5 * time.Minute,
-		"15m": 15 * time.Minute,
-		"1h":  time.Hour,
-		"4h":  4 * time.Hour,
-		"1d":  24 * time.Hour,
+// Symbols returns the symbols being recorded
+func (r *PriceRecorder) Symbols() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.symbols)
+}
+
+// Done is closed once recording has stopped and every recorded candle is saved or spilled
+func (r *PriceRecorder) Done() <-chan struct{} {
+	return r.writes.Done()
+}
+
+// recordedTimeframes are the timeframes recorded, each polled at its interval
+var recordedTimeframes = map[string]time.Duration{
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// TimeFrames returns the timeframes recorded
+func (r *PriceRecorder) TimeFrames() []string {
+	timeframes := make([]string, 0, len(recordedTimeframes))
+	for timeframe := range recordedTimeframes {
+		timeframes = append(timeframes, timeframe)
 	}
+	slices.Sort(timeframes)
+	return timeframes
+}
 
-	for timeframe, interval := range timeframes {
+// StartRecording begins recording price data for the specified symbols
+func (r *PriceRecorder) StartRecording(ctx context.Context) {
+	go r.writes.Run(ctx)
+
+	for timeframe, interval := range recordedTimeframes {
 		go r.recordTimeframe(ctx, timeframe, interval)
 	}
 }
 
 // recordTimeframe records price data for the specified timeframe at the specified interval
 func (r *PriceRecorder) recordTimeframe(ctx context.Context, timeframe string, interval time.Duration) {
-	ticker := time.NewTicker(interval)
+	ticker := r.clock.NewTicker(interval)
 	defer ticker.Stop()
 
 	log.Printf("Starting %s price recording...", timeframe)
@@ -54,53 +121,93 @@ func (r *PriceRecorder) recordTimeframe(ctx context.Context, timeframe string, i
 		case <-ctx.Done():
 			log.Printf("Stopping %s price recording...", timeframe)
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			r.recordPrices(ctx, timeframe)
 		}
 	}
 }
 
-// recordPrices retrieves the latest price data for each symbol and saves it to the database
+// recordPrices retrieves the latest closed candle of each symbol and saves it to the database
 func (r *PriceRecorder) recordPrices(ctx context.Context, timeframe string) {
-	for _, symbol := range r.symbols {
-		klines, err := r.client.NewKlinesService().
-			Symbol(symbol).
-			Interval(timeframe).
-			Limit(1).
-			Do(ctx)
+	for _, symbol := range r.Symbols() {
+		if err := r.limiter.Wait(ctx, KlineWeight(2)); err != nil {
+			return
+		}
+
+		// The last kline is usually the one still forming, so ask for the one before it too
+		klines, err := r.client.Klines(ctx, KlineRequest{Symbol: symbol, Interval: timeframe, Limit: 2})
 
+		if errors.Is(err, ErrDegraded) {
+			continue // Reported once by the ExchangeHealth
+		}
 		if err != nil {
 			log.Printf("Error getting kline for %s-%s: %v", symbol, timeframe, err)
+			metrics.APIErrors.WithLabelValues(symbol, timeframe).Inc()
 			continue
 		}
 
-		if len(klines) > 0 {
-			k := klines[0]
-			price := &models.Price{
-				Symbol:    symbol,
-				TimeFrame: timeframe,
-				OpenTime:  time.Unix(k.OpenTime/1000, 0),
-				Open:      parseFloat(k.Open),
-				High:      parseFloat(k.High),
-				Low:       parseFloat(k.Low),
-				Close:     parseFloat(k.Close),
-				Volume:    parseFloat(k.Volume),
-			}
-
-			if err := r.priceRepo.Create(price); err != nil {
-				log.Printf("Error saving price for %s-%s: %v", symbol, timeframe, err)
-			} else {
-				log.Printf("Recorded %s price for %s: %v", timeframe, symbol, price.Close)
-			}
+		closed := lastClosed(klines, r.clock.Now())
+		if closed == nil {
+			continue
 		}
+		price, err := klineToPrice(symbol, timeframe, closed)
+		if err != nil {
+			log.Printf("Error converting kline for %s-%s: %v", symbol, timeframe, err)
+			continue
+		}
+
+		r.writes.Enqueue(*price)
 	}
 }
 
-func parseFloat(s string) float64 {
+// lastClosed returns the latest of klines that closed before now, nil when none has
+func lastClosed(klines []*futures.Kline, now time.Time) *futures.Kline {
+	for i := len(klines) - 1; i >= 0; i-- {
+		if klines[i].CloseTime < now.UnixMilli() {
+			return klines[i]
+		}
+	}
+	return nil
+}
+
+// saved reports a candle the write queue has stored
+func (r *PriceRecorder) saved(price models.Price) {
+	metrics.CandlesRecorded.WithLabelValues(price.Symbol, price.TimeFrame).Inc()
+	log.Printf("Recorded %s price for %s: %v", price.TimeFrame, price.Symbol, price.Close)
+	if r.bus != nil {
+		r.bus.Publish(events.CandleClosed{Symbol: price.Symbol, TimeFrame: price.TimeFrame, Price: price})
+	}
+}
+
+// klineToPrice converts a Binance kline into a Price, rejecting malformed values instead of storing zeros
+func klineToPrice(symbol, timeframe string, k *futures.Kline) (*models.Price, error) {
+	values := make([]float64, 5)
+	for i, raw := range []string{k.Open, k.High, k.Low, k.Close, k.Volume} {
+		v, err := parseFloat(raw)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+
+	return &models.Price{
+		Symbol:     symbol,
+		TimeFrame:  timeframe,
+		OpenTime:   time.Unix(k.OpenTime/1000, 0),
+		CloseTime:  time.UnixMilli(k.CloseTime),
+		TradeCount: k.TradeNum,
+		Open:       values[0],
+		High:       values[1],
+		Low:        values[2],
+		Close:      values[3],
+		Volume:     values[4],
+	}, nil
+}
+
+func parseFloat(s string) (float64, error) {
 	f, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		log.Printf("Error parsing float: %v", err)
-		return 0
+		return 0, fmt.Errorf("error parsing float %q: %v", s, err)
 	}
-	return f
+	return f, nil
 }
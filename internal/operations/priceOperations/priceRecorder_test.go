@@ -0,0 +1,148 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+func TestRecorderFollowsSymbolChanges(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := newFakeKlineClient()
+	for _, symbol := range []string{"BTCUSDT", "ETHUSDT"} {
+		client.add(symbol, "5m", testKlines(now.Add(-time.Hour), 5*time.Minute, 12)...)
+	}
+	r := NewPriceRecorder(client, stubbedLimiter(2400, 0.8, &now), nil, []string{"BTCUSDT"}, "")
+
+	// recorded runs one 5m pass and returns the symbols it asked the exchange for
+	recorded := func() []string {
+		before := client.requestCount()
+		r.recordPrices(context.Background(), "5m")
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		var symbols []string
+		for _, req := range client.requests[before:] {
+			symbols = append(symbols, req.Symbol)
+		}
+		return symbols
+	}
+
+	if got := recorded(); !reflect.DeepEqual(got, []string{"BTCUSDT"}) {
+		t.Fatalf("recorded %v, want BTCUSDT", got)
+	}
+
+	r.AddSymbol("ETHUSDT")
+	r.AddSymbol("ETHUSDT")
+	if got := recorded(); !reflect.DeepEqual(got, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Errorf("recorded %v after adding ETHUSDT twice", got)
+	}
+
+	r.RemoveSymbol("BTCUSDT")
+	if got := recorded(); !reflect.DeepEqual(got, []string{"ETHUSDT"}) {
+		t.Errorf("recorded %v after removing BTCUSDT", got)
+	}
+	if got := r.Symbols(); !reflect.DeepEqual(got, []string{"ETHUSDT"}) {
+		t.Errorf("Symbols() = %v", got)
+	}
+}
+
+func TestKlineToPrice(t *testing.T) {
+	openTime := testStart.Add(5 * time.Minute)
+	kline := &futures.Kline{
+		OpenTime:  openTime.UnixMilli(),
+		CloseTime: openTime.Add(5*time.Minute).UnixMilli() - 1,
+		Open:      "0.07234",
+		High:      "0.0725",
+		Low:       "0.0721",
+		Close:     "0.07241",
+		Volume:    "123456.5",
+		TradeNum:  842,
+	}
+	price, err := klineToPrice("XRPUSDT", models.PriceTimeFrame5m, kline)
+	if err != nil {
+		t.Fatalf("klineToPrice() error = %v", err)
+	}
+	want := models.Price{
+		Symbol:     "XRPUSDT",
+		TimeFrame:  models.PriceTimeFrame5m,
+		OpenTime:   openTime,
+		CloseTime:  openTime.Add(5*time.Minute - time.Millisecond),
+		TradeCount: 842,
+		Open:       0.07234,
+		High:       0.0725,
+		Low:        0.0721,
+		Close:      0.07241,
+		Volume:     123456.5,
+	}
+	if !price.OpenTime.Equal(want.OpenTime) || !price.CloseTime.Equal(want.CloseTime) {
+		t.Errorf("times = %s to %s, want %s to %s", price.OpenTime, price.CloseTime, want.OpenTime, want.CloseTime)
+	}
+	price.OpenTime, price.CloseTime = want.OpenTime, want.CloseTime
+	if *price != want {
+		t.Errorf("klineToPrice() = %+v, want %+v", *price, want)
+	}
+
+	for _, field := range []*string{&kline.Open, &kline.High, &kline.Low, &kline.Close, &kline.Volume} {
+		saved := *field
+		*field = "n/a"
+		if _, err := klineToPrice("XRPUSDT", models.PriceTimeFrame5m, kline); err == nil {
+			t.Errorf("klineToPrice() accepted a kline with an unparseable value")
+		}
+		*field = saved
+	}
+}
+
+func TestRecorderStoresCandlesOnceClosed(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 2, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", "5m", testKline(now.Add(-7*time.Minute), 5*time.Minute, 100), testKline(now.Add(-2*time.Minute), 5*time.Minute, 90))
+
+	store := &memoryPriceStore{}
+	// The 12:00 candle stored while forming, as the recorder used to, at its 12:02 close
+	store.insert(testPrice("BTCUSDT", models.PriceTimeFrame5m, now.Add(-2*time.Minute), 90))
+
+	r := NewPriceRecorder(client, stubbedLimiter(2400, 0.8, &now), nil, []string{"BTCUSDT"}, "")
+	r.SetClock(fake)
+	r.writes = NewWriteQueue(store, DefaultWriteQueueSize, "", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.writes.Run(ctx)
+
+	// stored waits until the series holds closes, oldest first
+	stored := func(closes ...float64) {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for {
+			var got []float64
+			for _, p := range store.series("BTCUSDT", models.PriceTimeFrame5m) {
+				got = append(got, p.Close)
+			}
+			if reflect.DeepEqual(got, closes) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("stored closes %v, want %v", got, closes)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// At 12:02 only 11:55 has closed
+	r.recordPrices(ctx, "5m")
+	stored(100, 90)
+
+	// 12:00 closes at 95 and 12:05 is forming: the partial 12:00 is corrected, not stored again
+	fake.Advance(5 * time.Minute)
+	client.mu.Lock()
+	client.klines["BTCUSDT|5m"] = client.klines["BTCUSDT|5m"][:1]
+	client.mu.Unlock()
+	client.add("BTCUSDT", "5m", testKline(now.Add(-2*time.Minute), 5*time.Minute, 95), testKline(now.Add(3*time.Minute), 5*time.Minute, 80))
+	r.recordPrices(ctx, "5m")
+	stored(100, 95)
+}
@@ -0,0 +1,195 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// VerifierStore is the candle storage PriceVerifier scans and repairs, a PriceRepository outside tests
+type VerifierStore interface {
+	GetSeries() ([]repositories.PriceSeries, error)
+	StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error
+	DeleteByIDs(ids []uint) error
+	Upsert(ctx context.Context, price *models.Price) error
+}
+
+type PriceVerifier struct {
+	priceRepo    VerifierStore
+	priceFetcher *PriceFetcher
+}
+
+// PriceGap describes a missing stretch of candles between two stored ones
+type PriceGap struct {
+	From time.Time
+	To   time.Time
+}
+
+// SeriesReport summarizes the integrity issues found for one symbol and timeframe
+type SeriesReport struct {
+	Symbol       string
+	TimeFrame    string
+	Candles      int
+	InvalidIDs   []uint
+	DuplicateIDs []uint
+	Gaps         []PriceGap
+	invalidTimes []time.Time
+}
+
+// HasIssues reports whether any problem was found in the series
+func (s *SeriesReport) HasIssues() bool {
+	return len(s.InvalidIDs) > 0 || len(s.DuplicateIDs) > 0 || len(s.Gaps) > 0
+}
+
+// NewPriceVerifier creates a new instance of PriceVerifier
+// priceFetcher may be nil when no fixing is required
+func NewPriceVerifier(priceRepo VerifierStore, priceFetcher *PriceFetcher) *PriceVerifier {
+	return &PriceVerifier{
+		priceRepo:    priceRepo,
+		priceFetcher: priceFetcher,
+	}
+}
+
+// Verify scans every stored series and logs issues as they are found
+// When fix is set, offending candles are deleted and refetched along with any gaps
+func (v *PriceVerifier) Verify(ctx context.Context, fix bool) ([]*SeriesReport, error) {
+	if fix && v.priceFetcher == nil {
+		return nil, fmt.Errorf("a price fetcher is required to fix prices")
+	}
+
+	series, err := v.priceRepo.GetSeries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price series: %v", err)
+	}
+
+	var reports []*SeriesReport
+	for _, s := range series {
+		report, err := v.verifySeries(s.Symbol, s.TimeFrame)
+		if err != nil {
+			return reports, err
+		}
+		reports = append(reports, report)
+
+		log.Printf("%s-%s: %d candles, %d invalid, %d duplicate, %d gaps",
+			report.Symbol, report.TimeFrame, report.Candles,
+			len(report.InvalidIDs), len(report.DuplicateIDs), len(report.Gaps))
+
+		if fix && report.HasIssues() {
+			if err := v.fixSeries(ctx, report); err != nil {
+				log.Printf("Error fixing %s-%s: %v", report.Symbol, report.TimeFrame, err)
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+// verifySeries streams a single series and checks each candle against its predecessor
+func (v *PriceVerifier) verifySeries(symbol, timeframe string) (*SeriesReport, error) {
+	report := &SeriesReport{Symbol: symbol, TimeFrame: timeframe}
+	interval, known := models.TimeFrameDurations[timeframe]
+	if !known {
+		log.Printf("Unknown timeframe %s for %s, gap detection disabled", timeframe, symbol)
+	}
+
+	var prev *models.Price
+	err := v.priceRepo.StreamPricesByTimeFrame(symbol, timeframe, time.Time{}, time.Now(), func(price models.Price) error {
+		report.Candles++
+
+		if prev != nil {
+			if price.OpenTime.Equal(prev.OpenTime) {
+				log.Printf("Duplicate candle %s-%s at %s (id %d)",
+					symbol, timeframe, price.OpenTime.Format("2006-01-02 15:04:05"), price.ID)
+				report.DuplicateIDs = append(report.DuplicateIDs, price.ID)
+				return nil
+			}
+
+			if known && price.OpenTime.Sub(prev.OpenTime) > interval {
+				gap := PriceGap{From: prev.OpenTime.Add(interval), To: price.OpenTime.Add(-interval)}
+				log.Printf("Gap in %s-%s from %s to %s",
+					symbol, timeframe,
+					gap.From.Format("2006-01-02 15:04:05"),
+					gap.To.Format("2006-01-02 15:04:05"))
+				report.Gaps = append(report.Gaps, gap)
+			}
+		}
+
+		if reason := checkCandle(price); reason != "" {
+			log.Printf("Invalid candle %s-%s at %s (id %d): %s",
+				symbol, timeframe, price.OpenTime.Format("2006-01-02 15:04:05"), price.ID, reason)
+			report.InvalidIDs = append(report.InvalidIDs, price.ID)
+			report.invalidTimes = append(report.invalidTimes, price.OpenTime)
+		}
+
+		prev = &price
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s-%s: %v", symbol, timeframe, err)
+	}
+
+	return report, nil
+}
+
+// fixSeries removes bad and duplicate candles then refetches the missing ranges
+func (v *PriceVerifier) fixSeries(ctx context.Context, report *SeriesReport) error {
+	if err := v.priceRepo.DeleteByIDs(append(report.InvalidIDs, report.DuplicateIDs...)); err != nil {
+		return fmt.Errorf("failed to delete bad candles: %v", err)
+	}
+
+	interval := models.TimeFrameDurations[report.TimeFrame]
+	ranges := make([]PriceGap, 0, len(report.Gaps)+len(report.invalidTimes))
+	ranges = append(ranges, report.Gaps...)
+	for _, openTime := range report.invalidTimes {
+		ranges = append(ranges, PriceGap{From: openTime, To: openTime})
+	}
+
+	refetched := 0
+	for _, r := range ranges {
+		// Binance treats the end time as inclusive, so stop just short of the next candle
+		prices, _, err := v.priceFetcher.GetPricesInRange(ctx, report.Symbol, report.TimeFrame,
+			r.From, r.To.Add(interval-time.Millisecond))
+		if err != nil {
+			log.Printf("Error refetching %s-%s from %s: %v",
+				report.Symbol, report.TimeFrame, r.From.Format("2006-01-02 15:04:05"), err)
+			continue
+		}
+
+		for i := range prices {
+			if reason := checkCandle(prices[i]); reason != "" {
+				log.Printf("Refetched candle %s-%s is still invalid: %s", report.Symbol, report.TimeFrame, reason)
+				continue
+			}
+			if err := v.priceRepo.Upsert(ctx, &prices[i]); err != nil {
+				log.Printf("Error saving refetched price: %v", err)
+				continue
+			}
+			refetched++
+		}
+	}
+
+	log.Printf("Fixed %s-%s: removed %d candles, refetched %d",
+		report.Symbol, report.TimeFrame, len(report.InvalidIDs)+len(report.DuplicateIDs), refetched)
+
+	return nil
+}
+
+// checkCandle returns a description of what is wrong with a candle, or an empty string if it is sound
+func checkCandle(price models.Price) string {
+	switch {
+	case price.Open <= 0 || price.High <= 0 || price.Low <= 0 || price.Close <= 0:
+		return "zero or negative OHLC value"
+	case price.Volume < 0:
+		return "negative volume"
+	case price.High < price.Low:
+		return "high below low"
+	case price.Close < price.Low || price.Close > price.High:
+		return "close outside low/high range"
+	case price.Open < price.Low || price.Open > price.High:
+		return "open outside low/high range"
+	}
+	return ""
+}
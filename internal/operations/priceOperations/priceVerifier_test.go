@@ -0,0 +1,135 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckCandle(t *testing.T) {
+	sound := models.Price{Open: 10, High: 12, Low: 9, Close: 11, Volume: 5}
+	tests := []struct {
+		name   string
+		modify func(p *models.Price)
+		want   string
+	}{
+		{"sound", func(p *models.Price) {}, ""},
+		{"zero close", func(p *models.Price) { p.Close = 0 }, "zero or negative OHLC value"},
+		{"negative open", func(p *models.Price) { p.Open = -1 }, "zero or negative OHLC value"},
+		{"negative volume", func(p *models.Price) { p.Volume = -1 }, "negative volume"},
+		{"high below low", func(p *models.Price) { p.High, p.Low = 8, 9 }, "high below low"},
+		{"close above high", func(p *models.Price) { p.Close = 13 }, "close outside low/high range"},
+		{"open below low", func(p *models.Price) { p.Open = 8 }, "open outside low/high range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := sound
+			tt.modify(&price)
+			if got := checkCandle(price); got != tt.want {
+				t.Errorf("checkCandle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKlineToPriceRejectsMalformedValues(t *testing.T) {
+	kline := testKline(testStart, 5*time.Minute, 100)
+	price, err := klineToPrice("BTCUSDT", models.PriceTimeFrame5m, kline)
+	if err != nil {
+		t.Fatalf("klineToPrice() error = %v", err)
+	}
+	if price.Close != 100 || price.High != 101 || price.Low != 99 || price.Volume != 10 {
+		t.Errorf("klineToPrice() = %+v", price)
+	}
+	if !price.OpenTime.Equal(testStart) {
+		t.Errorf("OpenTime = %v, want %v", price.OpenTime, testStart)
+	}
+
+	for _, field := range []*string{&kline.Open, &kline.High, &kline.Low, &kline.Close, &kline.Volume} {
+		saved := *field
+		*field = "not-a-number"
+		if price, err := klineToPrice("BTCUSDT", models.PriceTimeFrame5m, kline); err == nil {
+			t.Errorf("klineToPrice() = %+v, want an error instead of a zero", price)
+		}
+		*field = saved
+	}
+}
+
+// corruptSeries stores a 5m series with a zero close at 2, a duplicate of 4 and candles 6 and 7 missing
+func corruptSeries(store *memoryPriceStore) {
+	for i := 0; i < 10; i++ {
+		if i == 6 || i == 7 {
+			continue
+		}
+		price := testPrice("BTCUSDT", models.PriceTimeFrame5m, testStart.Add(time.Duration(i)*5*time.Minute), 100+float64(i))
+		if i == 2 {
+			price.Close = 0
+		}
+		store.insert(price)
+		if i == 4 {
+			store.insert(price)
+		}
+	}
+}
+
+func TestVerifyReportsIssues(t *testing.T) {
+	store := &memoryPriceStore{}
+	corruptSeries(store)
+
+	reports, err := NewPriceVerifier(store, nil).Verify(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if len(reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(reports))
+	}
+	report := reports[0]
+	if report.Candles != 9 {
+		t.Errorf("Candles = %d, want 9", report.Candles)
+	}
+	if len(report.InvalidIDs) != 1 || len(report.DuplicateIDs) != 1 {
+		t.Errorf("invalid %v, duplicate %v, want one of each", report.InvalidIDs, report.DuplicateIDs)
+	}
+	wantGap := PriceGap{From: testStart.Add(30 * time.Minute), To: testStart.Add(35 * time.Minute)}
+	if len(report.Gaps) != 1 || report.Gaps[0] != wantGap {
+		t.Errorf("Gaps = %v, want [%v]", report.Gaps, wantGap)
+	}
+	if len(store.prices) != 9 {
+		t.Errorf("verifying without -fix changed the store to %d candles", len(store.prices))
+	}
+}
+
+func TestVerifyFixRefetchesBadCandlesAndGaps(t *testing.T) {
+	store := &memoryPriceStore{}
+	corruptSeries(store)
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", models.PriceTimeFrame5m, testKlines(testStart, 5*time.Minute, 10)...)
+	fetcher := NewPriceFetcher(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{"BTCUSDT"})
+
+	verifier := NewPriceVerifier(store, fetcher)
+	if _, err := verifier.Verify(context.Background(), true); err != nil {
+		t.Fatalf("Verify(fix) error = %v", err)
+	}
+
+	reports, err := verifier.Verify(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if reports[0].HasIssues() {
+		t.Errorf("issues left after fixing: %+v", reports[0])
+	}
+	prices := store.series("BTCUSDT", models.PriceTimeFrame5m)
+	if len(prices) != 10 {
+		t.Fatalf("got %d candles after fixing, want 10", len(prices))
+	}
+	if prices[2].Close != 102 {
+		t.Errorf("refetched close = %v, want 102", prices[2].Close)
+	}
+}
+
+func TestVerifyFixNeedsFetcher(t *testing.T) {
+	if _, err := NewPriceVerifier(&memoryPriceStore{}, nil).Verify(context.Background(), true); err == nil {
+		t.Error("Verify(fix) without a fetcher succeeded")
+	}
+}
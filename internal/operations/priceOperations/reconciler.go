@@ -0,0 +1,191 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultReconcileInterval is how often recorded candles are compared with the exchange's final values
+	DefaultReconcileInterval = 15 * time.Minute
+
+	// DefaultReconcileCandles is how many of each series' latest closed candles are compared
+	DefaultReconcileCandles = 3
+
+	// DefaultReconcileTolerance is the relative difference in any OHLCV value a stored candle may have
+	DefaultReconcileTolerance = 1e-6
+)
+
+// Correction kinds, the values of the kind label of metrics.PriceCorrections
+const (
+	CorrectionChanged = "changed" // A stored candle differed from the exchange's
+	CorrectionMissing = "missing" // A closed candle was never stored
+)
+
+// ReconcileStore is the part of the PriceRepository the Reconciler reads and repairs
+type ReconcileStore interface {
+	GetPriceRowsByTimeFrame(symbol, timeFrame string, start, end time.Time) ([]models.Price, error)
+	Upsert(ctx context.Context, price *models.Price) error
+	Update(price *models.Price) error
+}
+
+// Reconciler re-fetches the latest closed candles of every recorded series and repairs the stored ones
+// The recorder snapshots a kline just after it closes, when Binance may not have finalized it yet,
+// so its volume or close can differ slightly from what a later historical fetch returns
+type Reconciler struct {
+	fetcher    *PriceFetcher
+	priceRepo  ReconcileStore
+	symbols    func() []string
+	timeframes []string
+	candles    int
+	tolerance  float64
+	clock      clock.Clock
+}
+
+// NewReconciler creates a new instance of Reconciler over the timeframes of symbols' series
+// symbols is called on every pass so symbols added or removed since are followed
+func NewReconciler(fetcher *PriceFetcher, priceRepo ReconcileStore, symbols func() []string, timeframes []string) *Reconciler {
+	return &Reconciler{
+		fetcher:    fetcher,
+		priceRepo:  priceRepo,
+		symbols:    symbols,
+		timeframes: timeframes,
+		candles:    DefaultReconcileCandles,
+		tolerance:  DefaultReconcileTolerance,
+		clock:      clock.Real,
+	}
+}
+
+// SetClock replaces the wall clock that paces reconciliation and decides which candles closed
+func (r *Reconciler) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Run reconciles every interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	ticker := r.clock.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if corrected, err := r.ReconcileOnce(ctx); err != nil {
+				log.Printf("Error reconciling candles: %v", err)
+			} else if corrected > 0 {
+				log.Printf("Reconciled %d recorded candles with the exchange", corrected)
+			}
+		}
+	}
+}
+
+// ReconcileOnce compares and repairs every series once, returning how many candles it corrected
+// A series that fails is logged and skipped, the error reports only a cancelled ctx
+func (r *Reconciler) ReconcileOnce(ctx context.Context) (int, error) {
+	corrected := 0
+	for _, symbol := range r.symbols() {
+		for _, timeframe := range r.timeframes {
+			n, err := r.reconcileSeries(ctx, symbol, timeframe)
+			corrected += n
+			if ctx.Err() != nil {
+				return corrected, ctx.Err()
+			}
+			if err != nil && !errors.Is(err, ErrDegraded) {
+				log.Printf("Error reconciling %s-%s: %v", symbol, timeframe, err)
+			}
+		}
+	}
+	return corrected, nil
+}
+
+// reconcileSeries repairs the latest closed candles of one series, returning how many it corrected
+func (r *Reconciler) reconcileSeries(ctx context.Context, symbol, timeframe string) (int, error) {
+	interval, known := models.TimeFrameDurations[timeframe]
+	if !known {
+		return 0, fmt.Errorf("unknown timeframe %s", timeframe)
+	}
+
+	// Open times of the oldest compared candle and of the forming one
+	now := r.clock.Now()
+	forming := now.Truncate(interval)
+	start := forming.Add(-time.Duration(r.candles) * interval)
+
+	fetched, _, err := r.fetcher.GetPricesInRange(ctx, symbol, timeframe, start, forming.Add(-time.Millisecond))
+	if err != nil {
+		return 0, err
+	}
+	stored, err := r.priceRepo.GetPriceRowsByTimeFrame(symbol, timeframe, start, forming.Add(-time.Millisecond))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stored candles: %v", err)
+	}
+	byOpen := make(map[int64]*models.Price, len(stored))
+	for i := range stored {
+		byOpen[stored[i].OpenTime.Unix()] = &stored[i]
+	}
+
+	corrected := 0
+	for _, final := range fetched {
+		if !final.OpenTime.Before(forming) {
+			continue
+		}
+		if reason := checkCandle(final); reason != "" {
+			continue
+		}
+
+		current, ok := byOpen[final.OpenTime.Unix()]
+		if !ok {
+			if err := r.priceRepo.Upsert(ctx, &final); err != nil {
+				return corrected, fmt.Errorf("failed to save missing candle: %v", err)
+			}
+			r.corrected(final, CorrectionMissing, "stored it")
+			corrected++
+			continue
+		}
+		if !r.differs(*current, final) {
+			continue
+		}
+
+		before := *current
+		current.Open, current.High, current.Low, current.Close, current.Volume =
+			final.Open, final.High, final.Low, final.Close, final.Volume
+		current.TradeCount = final.TradeCount
+		if err := r.priceRepo.Update(current); err != nil {
+			return corrected, fmt.Errorf("failed to correct candle: %v", err)
+		}
+		r.corrected(final, CorrectionChanged, fmt.Sprintf("close %v -> %v, volume %v -> %v",
+			before.Close, final.Close, before.Volume, final.Volume))
+		corrected++
+	}
+	return corrected, nil
+}
+
+// differs reports whether any OHLCV value of stored is further from final than the tolerance
+func (r *Reconciler) differs(stored, final models.Price) bool {
+	pairs := [][2]float64{
+		{stored.Open, final.Open},
+		{stored.High, final.High},
+		{stored.Low, final.Low},
+		{stored.Close, final.Close},
+		{stored.Volume, final.Volume},
+	}
+	for _, p := range pairs {
+		if math.Abs(p[0]-p[1]) > r.tolerance*math.Max(math.Abs(p[1]), 1e-8) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Reconciler) corrected(price models.Price, kind, detail string) {
+	metrics.PriceCorrections.WithLabelValues(price.Symbol, price.TimeFrame, kind).Inc()
+	log.Printf("Corrected %s %s-%s candle at %s: %s",
+		kind, price.Symbol, price.TimeFrame, price.OpenTime.UTC().Format("2006-01-02 15:04"), detail)
+}
@@ -0,0 +1,128 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/clock"
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorderStoresTheLastClosedKline(t *testing.T) {
+	now := testStart.Add(12*time.Hour + 30*time.Second)
+	client := newFakeKlineClient()
+	client.add("BTCUSDT", models.PriceTimeFrame5m, testKlines(now.Add(-10*time.Minute).Truncate(5*time.Minute), 5*time.Minute, 3)...)
+
+	store := &memoryPriceStore{}
+	r := NewPriceRecorder(client, stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now), nil, []string{"BTCUSDT"}, "")
+	r.SetClock(clock.NewFake(now))
+	r.writes = NewWriteQueue(store, DefaultWriteQueueSize, "", func(models.Price) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	go r.writes.Run(ctx)
+
+	r.recordPrices(ctx, models.PriceTimeFrame5m)
+	cancel()
+	<-r.writes.Done()
+
+	if req := client.requests[0]; req.Limit != 2 {
+		t.Errorf("asked for %d klines, want the forming one and the one before it", req.Limit)
+	}
+	stored := store.series("BTCUSDT", models.PriceTimeFrame5m)
+	// 11:55 closed before 12:00:30, 12:00 is still forming
+	if want := testStart.Add(11*time.Hour + 55*time.Minute); len(stored) != 1 || !stored[0].OpenTime.Equal(want) {
+		t.Fatalf("stored %+v, want only the closed 11:55 candle", stored)
+	}
+}
+
+func TestLastClosed(t *testing.T) {
+	klines := testKlines(testStart, 5*time.Minute, 2) // Closing at 00:04:59.999 and 00:09:59.999
+	tests := []struct {
+		name string
+		now  time.Time
+		want int // Index into klines, -1 for none
+	}{
+		{"second still forming", testStart.Add(7 * time.Minute), 0},
+		{"both closed", testStart.Add(10 * time.Minute), 1},
+		{"first still forming", testStart.Add(time.Minute), -1},
+		{"at the close millisecond", testStart.Add(5*time.Minute - time.Millisecond), -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lastClosed(klines, tt.now)
+			if (tt.want < 0 && got != nil) || (tt.want >= 0 && got != klines[tt.want]) {
+				t.Errorf("lastClosed() = %+v, want kline %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReconcilerRepairsPreliminaryCandles(t *testing.T) {
+	const symbol = "RECONUSDT"
+	interval := 5 * time.Minute
+	now := testStart.Add(12*time.Hour + time.Minute)
+
+	// The exchange's final 11:45, 11:50 and 11:55 candles
+	client := newFakeKlineClient()
+	final := testKlines(testStart.Add(11*time.Hour+45*time.Minute), interval, 3)
+	client.add(symbol, models.PriceTimeFrame5m, final...)
+	client.add(symbol, models.PriceTimeFrame5m, testKline(now.Truncate(interval), interval, 200)) // Forming
+	fetcher := NewPriceFetcher(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), []string{symbol})
+
+	// The recorder missed 11:45, saw 11:50 within the tolerance and 11:55 before Binance finalized it
+	store := &memoryPriceStore{}
+	nearly := mustPrice(t, symbol, final[1])
+	nearly.Close *= 1 + 1e-9
+	preliminary := mustPrice(t, symbol, final[2])
+	preliminary.Close, preliminary.Volume = 101.5, 6
+	store.insert(nearly, preliminary)
+	recorded := store.series(symbol, models.PriceTimeFrame5m)
+
+	r := NewReconciler(fetcher, store, func() []string { return []string{symbol} }, []string{models.PriceTimeFrame5m})
+	r.SetClock(clock.NewFake(now))
+	changed := testutil.ToFloat64(metrics.PriceCorrections.WithLabelValues(symbol, models.PriceTimeFrame5m, CorrectionChanged))
+	missing := testutil.ToFloat64(metrics.PriceCorrections.WithLabelValues(symbol, models.PriceTimeFrame5m, CorrectionMissing))
+
+	corrected, err := r.ReconcileOnce(context.Background())
+	if err != nil {
+		t.Fatalf("ReconcileOnce() error = %v", err)
+	}
+	if corrected != 2 {
+		t.Errorf("corrected %d candles, want the preliminary and the missing one", corrected)
+	}
+	if got := testutil.ToFloat64(metrics.PriceCorrections.WithLabelValues(symbol, models.PriceTimeFrame5m, CorrectionChanged)) - changed; got != 1 {
+		t.Errorf("%v changed corrections counted, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.PriceCorrections.WithLabelValues(symbol, models.PriceTimeFrame5m, CorrectionMissing)) - missing; got != 1 {
+		t.Errorf("%v missing corrections counted, want 1", got)
+	}
+
+	stored := store.series(symbol, models.PriceTimeFrame5m)
+	if len(stored) != 3 {
+		t.Fatalf("stored %d candles, want 11:45 to 11:55 without the forming one", len(stored))
+	}
+	if repaired := stored[2]; repaired.ID != recorded[1].ID || repaired.Close != 102 || repaired.Volume != 10 {
+		t.Errorf("11:55 = close %v, volume %v, want the final 102 and 10 in place", repaired.Close, repaired.Volume)
+	}
+	if stored[1].Close != nearly.Close {
+		t.Errorf("11:50 close rewritten to %v, a difference within the tolerance", stored[1].Close)
+	}
+
+	// Once repaired the series matches the exchange
+	if corrected, err := r.ReconcileOnce(context.Background()); err != nil || corrected != 0 {
+		t.Errorf("second ReconcileOnce() = %d, %v, want nothing left to correct", corrected, err)
+	}
+}
+
+// mustPrice converts a fake kline of symbol's 5m series into its stored candle
+func mustPrice(t *testing.T, symbol string, k *futures.Kline) models.Price {
+	t.Helper()
+	price, err := klineToPrice(symbol, models.PriceTimeFrame5m, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *price
+}
@@ -0,0 +1,45 @@
+package priceOperations
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// RecorderConfig is how prices and trades are recorded from the exchange
+type RecorderConfig struct {
+	WeightThreshold   float64       // Fraction of the Binance request weight limit at which requests start waiting
+	ExchangeInfoCache string        // File the exchange info is cached in, empty to never cache
+	SpillPath         string        // File recorded candles overflow to while the database is down, empty for memory only
+	OrderFlowWindow   time.Duration // Trailing window of trades kept for the order flow filter
+}
+
+// DefaultRecorderConfig returns the default recorder settings
+func DefaultRecorderConfig() RecorderConfig {
+	return RecorderConfig{
+		WeightThreshold:   DefaultWeightThreshold,
+		ExchangeInfoCache: DefaultExchangeInfoCache,
+		SpillPath:         DefaultSpillPath,
+		OrderFlowWindow:   DefaultOrderFlowWindow,
+	}
+}
+
+// RegisterFlags defines the flags of c on fs, defaulting to the default settings
+func (c *RecorderConfig) RegisterFlags(fs *flag.FlagSet) {
+	defaults := DefaultRecorderConfig()
+	fs.Float64Var(&c.WeightThreshold, "api-weight-threshold", defaults.WeightThreshold, "Fraction of the Binance request weight limit at which requests start waiting")
+	fs.StringVar(&c.ExchangeInfoCache, "exchange-info-cache", defaults.ExchangeInfoCache, "File the futures exchange info is cached in, validating symbols when Binance is unreachable; empty to never cache")
+	fs.StringVar(&c.SpillPath, "spill-file", defaults.SpillPath, "File recorded candles overflow to while the database is unavailable, empty to keep them in memory only")
+	fs.DurationVar(&c.OrderFlowWindow, "order-flow-window", defaults.OrderFlowWindow, "Trailing window of trades entries are confirmed against when a strategy sets order_flow_filter")
+}
+
+// Validate checks the settings are usable
+func (c RecorderConfig) Validate() error {
+	if c.WeightThreshold <= 0 || c.WeightThreshold > 1 {
+		return fmt.Errorf("api weight threshold must be in (0, 1], got %v", c.WeightThreshold)
+	}
+	if c.OrderFlowWindow <= 0 {
+		return fmt.Errorf("order flow window must be positive, got %v", c.OrderFlowWindow)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PruneBatchSize is how many candles one delete statement removes at most
+	PruneBatchSize = 5000
+
+	// DefaultPruneTime is when candles are pruned each night, as an offset from UTC midnight
+	DefaultPruneTime = 3*time.Hour + 30*time.Minute
+)
+
+// RetentionPolicy is how long candles of each timeframe are kept, timeframes missing from it are kept forever
+type RetentionPolicy map[string]time.Duration
+
+// DefaultRetention keeps lower timeframes for as long as they are useful for backtests, and 4h and 1d forever
+func DefaultRetention() RetentionPolicy {
+	return RetentionPolicy{
+		models.PriceTimeFrame1m:  30 * 24 * time.Hour,
+		models.PriceTimeFrame5m:  90 * 24 * time.Hour,
+		models.PriceTimeFrame15m: 365 * 24 * time.Hour,
+		models.PriceTimeFrame1h:  2 * 365 * 24 * time.Hour,
+	}
+}
+
+// ParseRetention applies tf=age overrides, comma separated, to the default policy
+// Ages are Go durations or whole days like 90d; 0 or forever keeps the timeframe forever
+func ParseRetention(spec string) (RetentionPolicy, error) {
+	policy := DefaultRetention()
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tf, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid retention %q, want timeframe=age", entry)
+		}
+		if _, known := models.TimeFrameDurations[tf]; !known {
+			return nil, fmt.Errorf("unknown timeframe %q in retention", tf)
+		}
+
+		age, err := parseAge(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retention age %q for %s", value, tf)
+		}
+		if age == 0 {
+			delete(policy, tf)
+			continue
+		}
+		policy[tf] = age
+	}
+	return policy, nil
+}
+
+// parseAge reads a Go duration or a number of days like 90d, 0 for forever
+func parseAge(value string) (time.Duration, error) {
+	if value == "forever" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid days %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return age, nil
+}
+
+// PruneResult is the candles pruned, or that would be in a dry run, from one symbol and timeframe
+type PruneResult struct {
+	Symbol    string
+	TimeFrame string
+	Cutoff    time.Time // Candles opened before this are pruned
+	Rows      int64
+}
+
+// RetentionService prunes candles older than the retention policy allows
+type RetentionService struct {
+	priceRepo *repositories.PriceRepository
+	policy    RetentionPolicy
+	minAge    time.Duration // Candles younger than this are never pruned, whatever the policy
+}
+
+// NewRetentionService creates a new instance of RetentionService
+// minAge protects the warm-up window the active strategies read, see Cutoff
+func NewRetentionService(priceRepo *repositories.PriceRepository, policy RetentionPolicy, minAge time.Duration) *RetentionService {
+	return &RetentionService{
+		priceRepo: priceRepo,
+		policy:    policy,
+		minAge:    minAge,
+	}
+}
+
+// Cutoff returns the open time before which timeFrame's candles are pruned at now, false when they are kept forever
+// The cutoff never falls inside the last minAge, so a short policy cannot starve the strategies' warm-up
+func (s *RetentionService) Cutoff(timeFrame string, now time.Time) (time.Time, bool) {
+	age, ok := s.policy[timeFrame]
+	if !ok {
+		return time.Time{}, false
+	}
+	return now.Add(-max(age, s.minAge)), true
+}
+
+// Prune removes every stored candle past its timeframe's retention, or only counts them when dryRun is set
+func (s *RetentionService) Prune(now time.Time, dryRun bool) ([]PruneResult, error) {
+	series, err := s.priceRepo.GetSeries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list price series: %v", err)
+	}
+
+	var results []PruneResult
+	for _, ps := range series {
+		cutoff, ok := s.Cutoff(ps.TimeFrame, now)
+		if !ok {
+			continue
+		}
+
+		result := PruneResult{Symbol: ps.Symbol, TimeFrame: ps.TimeFrame, Cutoff: cutoff}
+		if dryRun {
+			result.Rows, err = s.priceRepo.CountOlderThanByTimeframe(ps.Symbol, ps.TimeFrame, cutoff)
+		} else {
+			result.Rows, err = s.priceRepo.DeleteOlderThanByTimeframe(ps.Symbol, ps.TimeFrame, cutoff, PruneBatchSize)
+		}
+		if err != nil {
+			return results, fmt.Errorf("failed to prune %s %s: %v", ps.Symbol, ps.TimeFrame, err)
+		}
+		if result.Rows == 0 {
+			continue
+		}
+
+		verb := "Pruned"
+		if dryRun {
+			verb = "Would prune"
+		}
+		log.Printf("%s %d %s %s candles before %s", verb, result.Rows, ps.Symbol, ps.TimeFrame, cutoff.UTC().Format(time.RFC3339))
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Symbol != results[j].Symbol {
+			return results[i].Symbol < results[j].Symbol
+		}
+		return models.TimeFrameDurations[results[i].TimeFrame] < models.TimeFrameDurations[results[j].TimeFrame]
+	})
+	return results, nil
+}
+
+// Run prunes every day at the given UTC time of day until ctx is cancelled
+func (s *RetentionService) Run(ctx context.Context, at time.Duration) {
+	for {
+		next := time.Now().UTC().Truncate(24 * time.Hour).Add(at)
+		if !next.After(time.Now()) {
+			next = next.AddDate(0, 0, 1)
+		}
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// A failed run leaves the rest for the next night
+		results, err := s.Prune(time.Now(), false)
+		if err != nil {
+			log.Printf("Error pruning candles: %v", err)
+		}
+		var rows int64
+		for _, result := range results {
+			rows += result.Rows
+		}
+		log.Printf("Candle retention: pruned %d rows", rows)
+	}
+}
@@ -0,0 +1,59 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+func TestParseRetention(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		spec    string
+		want    RetentionPolicy
+		wantErr bool
+	}{
+		{"", DefaultRetention(), false},
+		{"5m=30d", RetentionPolicy{"1m": 30 * day, "5m": 30 * day, "15m": 365 * day, "1h": 730 * day}, false},
+		{"1h=forever, 4h=400d", RetentionPolicy{"1m": 30 * day, "5m": 90 * day, "15m": 365 * day, "4h": 400 * day}, false},
+		{"1m=0,15m=36h", RetentionPolicy{"5m": 90 * day, "15m": 36 * time.Hour, "1h": 730 * day}, false},
+		{"5m", nil, true},
+		{"3m=10d", nil, true},
+		{"5m=-1d", nil, true},
+		{"5m=soon", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRetention(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseRetention(%q) error = %v, want error %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseRetention(%q) = %v, want %v", tt.spec, got, tt.want)
+			continue
+		}
+		for tf, age := range tt.want {
+			if got[tf] != age {
+				t.Errorf("ParseRetention(%q) = %v, want %v", tt.spec, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRetentionCutoffKeepsTheWarmUp(t *testing.T) {
+	now := time.Date(2024, 6, 1, 3, 30, 0, 0, time.UTC)
+	policy := RetentionPolicy{models.PriceTimeFrame5m: 90 * 24 * time.Hour, models.PriceTimeFrame1h: time.Hour}
+	s := NewRetentionService(nil, policy, 48*time.Hour)
+
+	if cutoff, ok := s.Cutoff(models.PriceTimeFrame5m, now); !ok || !cutoff.Equal(now.AddDate(0, 0, -90)) {
+		t.Errorf("5m cutoff = %v, %v, want 90 days back", cutoff, ok)
+	}
+	// An hour of 1h candles would starve a strategy reading two days of them
+	if cutoff, ok := s.Cutoff(models.PriceTimeFrame1h, now); !ok || !cutoff.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("1h cutoff = %v, %v, want the 48h warm-up kept", cutoff, ok)
+	}
+	if cutoff, ok := s.Cutoff(models.PriceTimeFrame4h, now); ok {
+		t.Errorf("4h cutoff = %v, want 4h kept forever", cutoff)
+	}
+}
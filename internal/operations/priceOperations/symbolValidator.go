@@ -0,0 +1,244 @@
+package priceOperations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// DefaultExchangeInfoCache is where the last fetched exchange info is kept for when Binance is unreachable
+	DefaultExchangeInfoCache = "exchangeinfo.cache.json"
+
+	// maxSuggestionDistance is the furthest edit distance a near match is suggested from
+	maxSuggestionDistance = 2
+
+	symbolStatusTrading = "TRADING"
+)
+
+// SymbolInfo is what the validator needs to know about one futures symbol
+type SymbolInfo struct {
+	Symbol       string  `json:"symbol"`
+	Status       string  `json:"status"`
+	ContractType string  `json:"contract_type"`
+	QuoteAsset   string  `json:"quote_asset"`
+	TickSize     float64 `json:"tick_size,omitempty"` // Price increment, 0 when unknown
+	StepSize     float64 `json:"step_size,omitempty"` // Quantity increment, 0 when unknown
+}
+
+// exchangeInfoCache is the layout of the exchange info cache file
+type exchangeInfoCache struct {
+	FetchedAt time.Time    `json:"fetched_at"`
+	Symbols   []SymbolInfo `json:"symbols"`
+}
+
+// SymbolValidator checks configured symbols against Binance futures exchange info
+// Every fetch is cached to a file, used with a warning while the exchange is unreachable
+type SymbolValidator struct {
+	client    *futures.Client
+	limiter   *WeightLimiter
+	cachePath string // Empty to never cache
+}
+
+// NewSymbolValidator creates a new instance of SymbolValidator
+func NewSymbolValidator(client *futures.Client, limiter *WeightLimiter, cachePath string) *SymbolValidator {
+	return &SymbolValidator{
+		client:    client,
+		limiter:   limiter,
+		cachePath: cachePath,
+	}
+}
+
+// Validate normalizes symbols to upper case and checks each is a perpetual contract currently trading
+// The error lists every bad entry, suggesting near matches for unknown ones
+func (v *SymbolValidator) Validate(ctx context.Context, symbols []string) ([]string, error) {
+	info, err := v.exchangeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSymbols(symbols, info)
+}
+
+// ValidateSymbols normalizes symbols and checks them against info, see Validate
+func ValidateSymbols(symbols []string, info []SymbolInfo) ([]string, error) {
+	known := make(map[string]SymbolInfo, len(info))
+	for _, s := range info {
+		known[s.Symbol] = s
+	}
+
+	normalized := make([]string, 0, len(symbols))
+	var problems []string
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		s, ok := known[symbol]
+		switch {
+		case !ok:
+			problem := symbol + " is not a Binance futures symbol"
+			if suggestion := nearestSymbol(symbol, info); suggestion != "" {
+				problem += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			problems = append(problems, problem)
+		case s.ContractType != string(futures.ContractTypePerpetual):
+			problems = append(problems, fmt.Sprintf("%s is a %s contract, not a perpetual", symbol, s.ContractType))
+		case s.Status != symbolStatusTrading:
+			problems = append(problems, fmt.Sprintf("%s is not trading (status %s)", symbol, s.Status))
+		default:
+			normalized = append(normalized, symbol)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid symbols: %s", strings.Join(problems, "; "))
+	}
+	return normalized, nil
+}
+
+// nearestSymbol returns the trading perpetual closest to symbol by edit distance, empty when none is near
+func nearestSymbol(symbol string, info []SymbolInfo) string {
+	candidates := make([]SymbolInfo, 0, len(info))
+	for _, s := range info {
+		if s.ContractType == string(futures.ContractTypePerpetual) && s.Status == symbolStatusTrading {
+			candidates = append(candidates, s)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Symbol < candidates[j].Symbol })
+
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, s := range candidates {
+		if d := editDistance(symbol, s.Symbol); d < bestDistance {
+			best, bestDistance = s.Symbol, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// TickSizes returns the price tick size of every futures symbol that reports one
+func (v *SymbolValidator) TickSizes(ctx context.Context) (map[string]float64, error) {
+	info, err := v.exchangeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]float64, len(info))
+	for _, symbol := range info {
+		if symbol.TickSize > 0 {
+			sizes[symbol.Symbol] = symbol.TickSize
+		}
+	}
+	return sizes, nil
+}
+
+// StepSizes returns the quantity step size of every futures symbol that reports one
+func (v *SymbolValidator) StepSizes(ctx context.Context) (map[string]float64, error) {
+	info, err := v.exchangeInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizes := make(map[string]float64, len(info))
+	for _, symbol := range info {
+		if symbol.StepSize > 0 {
+			sizes[symbol.Symbol] = symbol.StepSize
+		}
+	}
+	return sizes, nil
+}
+
+// exchangeInfo fetches every futures symbol and caches them, falling back to the cache when the fetch fails
+func (v *SymbolValidator) exchangeInfo(ctx context.Context) ([]SymbolInfo, error) {
+	info, err := v.fetch(ctx)
+	if err == nil {
+		v.save(info)
+		return info, nil
+	}
+
+	cached, cacheErr := v.load()
+	if cacheErr != nil {
+		return nil, fmt.Errorf("%v, and no cached exchange info: %v", err, cacheErr)
+	}
+	log.Printf("Warning: %v, validating symbols against exchange info cached %s",
+		err, cached.FetchedAt.UTC().Format(time.RFC3339))
+	return cached.Symbols, nil
+}
+
+func (v *SymbolValidator) fetch(ctx context.Context) ([]SymbolInfo, error) {
+	if err := v.limiter.Wait(ctx, exchangeInfoWeight); err != nil {
+		return nil, err
+	}
+	info, err := v.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange info: %v", err)
+	}
+
+	symbols := make([]SymbolInfo, len(info.Symbols))
+	for i, s := range info.Symbols {
+		symbols[i] = SymbolInfo{
+			Symbol:       s.Symbol,
+			Status:       s.Status,
+			ContractType: string(s.ContractType),
+			QuoteAsset:   s.QuoteAsset,
+		}
+		if filter := s.PriceFilter(); filter != nil {
+			symbols[i].TickSize, _ = strconv.ParseFloat(filter.TickSize, 64)
+		}
+		if filter := s.LotSizeFilter(); filter != nil {
+			symbols[i].StepSize, _ = strconv.ParseFloat(filter.StepSize, 64)
+		}
+	}
+	return symbols, nil
+}
+
+// save writes info to the cache file, a failure only costs the fallback
+func (v *SymbolValidator) save(info []SymbolInfo) {
+	if v.cachePath == "" {
+		return
+	}
+	data, err := json.Marshal(exchangeInfoCache{FetchedAt: time.Now(), Symbols: info})
+	if err == nil {
+		err = os.WriteFile(v.cachePath, data, 0644)
+	}
+	if err != nil {
+		log.Printf("Failed to cache exchange info: %v", err)
+	}
+}
+
+func (v *SymbolValidator) load() (*exchangeInfoCache, error) {
+	if v.cachePath == "" {
+		return nil, fmt.Errorf("caching disabled")
+	}
+	data, err := os.ReadFile(v.cachePath)
+	if err != nil {
+		return nil, err
+	}
+	var cached exchangeInfoCache
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("malformed %s: %v", v.cachePath, err)
+	}
+	return &cached, nil
+}
@@ -0,0 +1,130 @@
+package priceOperations
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// exchangeInfoJSON is a trimmed /fapi/v1/exchangeInfo response: two perpetuals trading, one delisted
+// and a quarterly contract
+const exchangeInfoJSON = `{"symbols": [
+	{"symbol": "BTCUSDT", "status": "TRADING", "contractType": "PERPETUAL", "quoteAsset": "USDT", "filters": [
+		{"filterType": "PRICE_FILTER", "tickSize": "0.10"}, {"filterType": "LOT_SIZE", "stepSize": "0.001"}]},
+	{"symbol": "ONDOUSDT", "status": "TRADING", "contractType": "PERPETUAL", "quoteAsset": "USDT", "filters": []},
+	{"symbol": "LUNAUSDT", "status": "SETTLING", "contractType": "PERPETUAL", "quoteAsset": "USDT", "filters": []},
+	{"symbol": "BTCUSDT_250328", "status": "TRADING", "contractType": "CURRENT_QUARTER", "quoteAsset": "USDT", "filters": []}
+]}`
+
+// exchangeInfoServer serves exchangeInfoJSON the way Binance futures does
+func exchangeInfoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fapi/v1/exchangeInfo" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(exchangeInfoJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestValidator returns a validator against the server at url, caching to cachePath
+func newTestValidator(url, cachePath string) *SymbolValidator {
+	client := futures.NewClient("", "")
+	client.BaseURL = url
+	return NewSymbolValidator(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), cachePath)
+}
+
+func TestValidateSymbols(t *testing.T) {
+	validator := newTestValidator(exchangeInfoServer(t).URL, "")
+
+	tests := []struct {
+		name    string
+		symbols []string
+		want    []string
+		problem string
+	}{
+		{"normalizes case and spaces", []string{" btcusdt", "OndoUsdt "}, []string{"BTCUSDT", "ONDOUSDT"}, ""},
+		{"misspelled", []string{"BTCUSDT", "ONDOUSTD"}, nil, "ONDOUSTD is not a Binance futures symbol, did you mean ONDOUSDT?"},
+		{"delisted", []string{"LUNAUSDT"}, nil, "LUNAUSDT is not trading (status SETTLING)"},
+		{"spot only", []string{"BTCEUR"}, nil, "BTCEUR is not a Binance futures symbol"},
+		{"not a perpetual", []string{"BTCUSDT_250328"}, nil, "BTCUSDT_250328 is a CURRENT_QUARTER contract, not a perpetual"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validator.Validate(context.Background(), tt.symbols)
+			if tt.problem == "" {
+				if err != nil || strings.Join(got, ",") != strings.Join(tt.want, ",") {
+					t.Errorf("Validate(%q) = %v, %v, want %v", tt.symbols, got, err, tt.want)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Validate(%q) error = %v, want it to say %q", tt.symbols, err, tt.problem)
+			}
+		})
+	}
+
+	// Every bad entry is listed at once
+	_, err := validator.Validate(context.Background(), []string{"ONDOUSTD", "BTCUSDT", "LUNAUSDT"})
+	if err == nil || !strings.Contains(err.Error(), "ONDOUSTD") || !strings.Contains(err.Error(), "LUNAUSDT") {
+		t.Errorf("Validate() error = %v, want both bad symbols listed", err)
+	}
+}
+
+func TestNearestSymbolOnlySuggestsCloseTradingPerpetuals(t *testing.T) {
+	info := []SymbolInfo{
+		{Symbol: "ETHUSDT", Status: symbolStatusTrading, ContractType: "PERPETUAL"},
+		{Symbol: "LUNAUSDT", Status: "SETTLING", ContractType: "PERPETUAL"},
+	}
+	tests := []struct{ symbol, want string }{
+		{"ETHUSTD", "ETHUSDT"},
+		{"EHTUSDT", "ETHUSDT"},
+		{"LUNAUSDC", ""}, // Near only a delisted symbol
+		{"DOGEUSDT", ""},
+	}
+	for _, tt := range tests {
+		if got := nearestSymbol(tt.symbol, info); got != tt.want {
+			t.Errorf("nearestSymbol(%q) = %q, want %q", tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestValidatorFallsBackToTheCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "exchangeinfo.json")
+	server := exchangeInfoServer(t)
+	validator := newTestValidator(server.URL, cachePath)
+
+	sizes, err := validator.TickSizes(context.Background())
+	if err != nil || sizes["BTCUSDT"] != 0.1 {
+		t.Fatalf("TickSizes() = %v, %v, want BTCUSDT's 0.1 from the exchange", sizes, err)
+	}
+
+	// Binance unreachable: the symbols are checked against the copy cached by the last fetch
+	server.Close()
+	got, err := validator.Validate(context.Background(), []string{"btcusdt"})
+	if err != nil || len(got) != 1 || got[0] != "BTCUSDT" {
+		t.Errorf("Validate() from the cache = %v, %v, want BTCUSDT", got, err)
+	}
+	if _, err := validator.Validate(context.Background(), []string{"LUNAUSDT"}); err == nil {
+		t.Error("Validate() from the cache accepted a delisted symbol")
+	}
+	steps, err := validator.StepSizes(context.Background())
+	if err != nil || steps["BTCUSDT"] != 0.001 {
+		t.Errorf("StepSizes() from the cache = %v, %v, want BTCUSDT's 0.001", steps, err)
+	}
+
+	// Without a cache an unreachable exchange is an error
+	uncached := newTestValidator(server.URL, filepath.Join(t.TempDir(), "missing.json"))
+	if _, err := uncached.Validate(context.Background(), []string{"BTCUSDT"}); err == nil || !strings.Contains(err.Error(), "no cached exchange info") {
+		t.Errorf("Validate() without a cache error = %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package priceOperations
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+const (
+	// DefaultMarkPriceTTL is how long a fetched mark price is reused before asking Binance again
+	DefaultMarkPriceTTL = time.Second
+
+	premiumIndexWeight = 1 // Weight of premiumIndex for a single symbol
+)
+
+// markPrice is a fetched mark price and when it was fetched
+type markPrice struct {
+	price     float64
+	fetchedAt time.Time
+}
+
+// TickerService fetches the live mark price of futures symbols, caching each briefly
+// so every position checked in one pass shares a single request per symbol
+type TickerService struct {
+	client  *futures.Client
+	limiter *WeightLimiter
+	ttl     time.Duration
+	health  *ExchangeHealth // Holds requests back while Binance is degraded, nil for none
+
+	mu    sync.Mutex
+	cache map[string]markPrice
+	now   func() time.Time
+}
+
+// NewTickerService creates a new instance of TickerService
+func NewTickerService(client *futures.Client, limiter *WeightLimiter, ttl time.Duration) *TickerService {
+	return &TickerService{
+		client:  client,
+		limiter: limiter,
+		ttl:     ttl,
+		cache:   make(map[string]markPrice),
+		now:     time.Now,
+	}
+}
+
+// UseHealth holds mark price requests back while the exchange or their symbol is degraded, failing with ErrDegraded
+func (s *TickerService) UseHealth(health *ExchangeHealth) {
+	s.health = health
+}
+
+// MarkPrice returns symbol's mark price, from the cache when fetched within the TTL
+func (s *TickerService) MarkPrice(ctx context.Context, symbol string) (float64, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[symbol]
+	s.mu.Unlock()
+	if ok && s.now().Sub(cached.fetchedAt) < s.ttl {
+		return cached.price, nil
+	}
+
+	if err := s.limiter.Wait(ctx, premiumIndexWeight); err != nil {
+		return 0, err
+	}
+	if s.health != nil && !s.health.Allow(symbol) {
+		return 0, ErrDegraded
+	}
+	indexes, err := s.client.NewPremiumIndexService().Symbol(symbol).Do(ctx)
+	if s.health != nil && ctx.Err() == nil {
+		s.health.Observe(symbol, err)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get mark price of %s: %v", symbol, err)
+	}
+	if len(indexes) == 0 {
+		return 0, fmt.Errorf("no mark price for %s", symbol)
+	}
+
+	price, err := strconv.ParseFloat(indexes[0].MarkPrice, 64)
+	if err != nil || price <= 0 {
+		return 0, fmt.Errorf("malformed mark price %q for %s", indexes[0].MarkPrice, symbol)
+	}
+
+	s.mu.Lock()
+	s.cache[symbol] = markPrice{price: price, fetchedAt: s.now()}
+	s.mu.Unlock()
+	return price, nil
+}
@@ -0,0 +1,177 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Request weights of the calls a universe refresh makes
+const (
+	exchangeInfoWeight = 1
+	allTickersWeight   = 40
+)
+
+// UniverseRefreshTime is when the universe is reselected each day, as an offset from UTC midnight
+const UniverseRefreshTime = 15 * time.Minute
+
+// UniverseConfig controls which symbols are traded
+type UniverseConfig struct {
+	Size           int      // Symbols traded, the best ranked by 24h quote volume
+	MinQuoteVolume float64  // Symbols trading less of the quote asset in 24h are never selected
+	Exclude        []string // Symbols never selected whatever their volume
+	QuoteAsset     string   // Only perpetuals quoted in this asset are selected
+}
+
+// TickerVolume is the 24h quote volume of one symbol
+type TickerVolume struct {
+	Symbol      string
+	QuoteVolume float64
+}
+
+// RankUniverse selects the eligible symbols with the highest quote volume, best first
+// Excluded symbols and those below MinQuoteVolume are dropped; ties rank alphabetically
+func RankUniverse(tickers []TickerVolume, eligible map[string]bool, config UniverseConfig) []string {
+	excluded := make(map[string]bool, len(config.Exclude))
+	for _, symbol := range config.Exclude {
+		excluded[symbol] = true
+	}
+
+	var ranked []TickerVolume
+	for _, t := range tickers {
+		if eligible[t.Symbol] && !excluded[t.Symbol] && t.QuoteVolume >= config.MinQuoteVolume {
+			ranked = append(ranked, t)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].QuoteVolume != ranked[j].QuoteVolume {
+			return ranked[i].QuoteVolume > ranked[j].QuoteVolume
+		}
+		return ranked[i].Symbol < ranked[j].Symbol
+	})
+	if len(ranked) > config.Size {
+		ranked = ranked[:config.Size]
+	}
+
+	symbols := make([]string, len(ranked))
+	for i, t := range ranked {
+		symbols[i] = t.Symbol
+	}
+	return symbols
+}
+
+// UniverseService picks the traded symbols from Binance's 24h futures volume and keeps a daily record of them
+type UniverseService struct {
+	client  *futures.Client
+	limiter *WeightLimiter
+	repo    *repositories.UniverseRepository
+	config  UniverseConfig
+}
+
+// NewUniverseService creates a new instance of UniverseService
+func NewUniverseService(client *futures.Client, limiter *WeightLimiter, repo *repositories.UniverseRepository, config UniverseConfig) *UniverseService {
+	return &UniverseService{
+		client:  client,
+		limiter: limiter,
+		repo:    repo,
+		config:  config,
+	}
+}
+
+// Select ranks the perpetuals quoted in the configured asset by 24h quote volume and stores the pick as today's universe
+func (s *UniverseService) Select(ctx context.Context) ([]string, error) {
+	eligible, err := s.perpetuals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tickers, err := s.volumes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := RankUniverse(tickers, eligible, s.config)
+	if len(symbols) == 0 {
+		return nil, fmt.Errorf("no symbol trades at least %.0f %s a day", s.config.MinQuoteVolume, s.config.QuoteAsset)
+	}
+	if err := s.repo.Save(time.Now(), symbols); err != nil {
+		return nil, fmt.Errorf("failed to save universe: %v", err)
+	}
+	log.Printf("Universe: %s", strings.Join(symbols, ", "))
+	return symbols, nil
+}
+
+// Run reselects the universe every day at UniverseRefreshTime and hands it to apply, until ctx is cancelled
+func (s *UniverseService) Run(ctx context.Context, apply func(context.Context, []string) error) {
+	for {
+		next := time.Now().UTC().Truncate(24 * time.Hour).Add(UniverseRefreshTime)
+		if !next.After(time.Now()) {
+			next = next.AddDate(0, 0, 1)
+		}
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// A failed refresh keeps trading yesterday's universe
+		symbols, err := s.Select(ctx)
+		if err != nil {
+			log.Printf("Error refreshing universe: %v", err)
+			continue
+		}
+		if err := apply(ctx, symbols); err != nil {
+			log.Printf("Universe refresh incomplete: %v", err)
+		}
+	}
+}
+
+// perpetuals returns the perpetual contracts quoted in the configured asset currently trading
+func (s *UniverseService) perpetuals(ctx context.Context) (map[string]bool, error) {
+	if err := s.limiter.Wait(ctx, exchangeInfoWeight); err != nil {
+		return nil, err
+	}
+	info, err := s.client.NewExchangeInfoService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exchange info: %v", err)
+	}
+
+	eligible := make(map[string]bool)
+	for _, symbol := range info.Symbols {
+		if symbol.ContractType == futures.ContractTypePerpetual && symbol.QuoteAsset == s.config.QuoteAsset && symbol.Status == "TRADING" {
+			eligible[symbol.Symbol] = true
+		}
+	}
+	return eligible, nil
+}
+
+// volumes returns the 24h quote volume of every futures symbol
+func (s *UniverseService) volumes(ctx context.Context) ([]TickerVolume, error) {
+	if err := s.limiter.Wait(ctx, allTickersWeight); err != nil {
+		return nil, err
+	}
+	stats, err := s.client.NewListPriceChangeStatsService().Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get 24h tickers: %v", err)
+	}
+
+	tickers := make([]TickerVolume, 0, len(stats))
+	for _, stat := range stats {
+		volume, err := strconv.ParseFloat(stat.QuoteVolume, 64)
+		if err != nil {
+			log.Printf("Skipping %s, malformed quote volume %q", stat.Symbol, stat.QuoteVolume)
+			continue
+		}
+		tickers = append(tickers, TickerVolume{Symbol: stat.Symbol, QuoteVolume: volume})
+	}
+	return tickers, nil
+}
@@ -0,0 +1,107 @@
+package priceOperations
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// cannedExchangeInfo lists USDT and USDC perpetuals, a quarterly contract and a delisted symbol
+const cannedExchangeInfo = `{"symbols": [
+	{"symbol": "BTCUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "ETHUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "SOLUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "DOGEUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "XRPUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "LUNAUSDT", "contractType": "PERPETUAL", "quoteAsset": "USDT", "status": "SETTLING"},
+	{"symbol": "BTCUSDT_240628", "contractType": "CURRENT_QUARTER", "quoteAsset": "USDT", "status": "TRADING"},
+	{"symbol": "BTCUSDC", "contractType": "PERPETUAL", "quoteAsset": "USDC", "status": "TRADING"}
+]}`
+
+// cannedTickers is the 24h ticker list for cannedExchangeInfo, one volume malformed
+const cannedTickers = `[
+	{"symbol": "BTCUSDT", "quoteVolume": "9000000000"},
+	{"symbol": "ETHUSDT", "quoteVolume": "4000000000"},
+	{"symbol": "SOLUSDT", "quoteVolume": "800000000"},
+	{"symbol": "DOGEUSDT", "quoteVolume": "800000000"},
+	{"symbol": "XRPUSDT", "quoteVolume": "20000000"},
+	{"symbol": "LUNAUSDT", "quoteVolume": "9900000000"},
+	{"symbol": "BTCUSDT_240628", "quoteVolume": "7000000000"},
+	{"symbol": "BTCUSDC", "quoteVolume": "3000000000"},
+	{"symbol": "BADUSDT", "quoteVolume": "n/a"}
+]`
+
+// cannedUniverse returns a universe service reading the canned exchange info and tickers, without a repository
+func cannedUniverse(config UniverseConfig) *UniverseService {
+	client := futures.NewClient("", "")
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body := "{}"
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/exchangeInfo"):
+			body = cannedExchangeInfo
+		case strings.HasSuffix(req.URL.Path, "/ticker/24hr"):
+			body = cannedTickers
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Header: http.Header{},
+			Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})}
+	return NewUniverseService(client, NewWeightLimiter(BinanceWeightLimit, DefaultWeightThreshold), nil, config)
+}
+
+func TestUniverseRanksCannedTickers(t *testing.T) {
+	tests := []struct {
+		name   string
+		config UniverseConfig
+		want   []string
+	}{
+		{"top three, ties alphabetical",
+			UniverseConfig{Size: 3, MinQuoteVolume: 50_000_000, QuoteAsset: "USDT"},
+			[]string{"BTCUSDT", "ETHUSDT", "DOGEUSDT"}},
+		{"volume floor",
+			UniverseConfig{Size: 10, MinQuoteVolume: 50_000_000, QuoteAsset: "USDT"},
+			[]string{"BTCUSDT", "ETHUSDT", "DOGEUSDT", "SOLUSDT"}},
+		{"exclusions make room",
+			UniverseConfig{Size: 3, MinQuoteVolume: 50_000_000, Exclude: []string{"ETHUSDT", "DOGEUSDT"}, QuoteAsset: "USDT"},
+			[]string{"BTCUSDT", "SOLUSDT"}},
+		{"other quote asset",
+			UniverseConfig{Size: 3, MinQuoteVolume: 50_000_000, QuoteAsset: "USDC"},
+			[]string{"BTCUSDC"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := cannedUniverse(tt.config)
+			ctx := context.Background()
+			eligible, err := s.perpetuals(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tickers, err := s.volumes(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := RankUniverse(tickers, eligible, tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RankUniverse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniverseSkipsMalformedVolumes(t *testing.T) {
+	tickers, err := cannedUniverse(UniverseConfig{QuoteAsset: "USDT"}).volumes(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ticker := range tickers {
+		if ticker.Symbol == "BADUSDT" {
+			t.Errorf("volumes() kept BADUSDT with a malformed quote volume")
+		}
+	}
+	if len(tickers) != 8 {
+		t.Errorf("volumes() returned %d tickers, want 8", len(tickers))
+	}
+}
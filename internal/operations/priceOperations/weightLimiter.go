@@ -0,0 +1,176 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adshao/go-binance/v2/futures"
+)
+
+// Binance futures request weight limits
+const (
+	BinanceWeightLimit     = 2400 // Weight allowed per minute per IP
+	DefaultWeightThreshold = 0.8  // Fraction of the limit at which requests start waiting
+
+	usedWeightHeader = "X-Mbx-Used-Weight-1m"
+
+	defaultBanBackoff = 2 * time.Minute // Used for a 418 without Retry-After
+)
+
+// WeightLimiter paces Binance requests by request weight instead of request count
+// One instance should be shared by every client using the same API key and IP
+type WeightLimiter struct {
+	mu           sync.Mutex
+	limit        int
+	threshold    float64
+	used         int
+	window       time.Time // Minute the used weight belongs to
+	blockedUntil time.Time // Set by 429 and 418 responses
+	now          func() time.Time
+}
+
+// NewWeightLimiter creates a new instance of WeightLimiter
+func NewWeightLimiter(limit int, threshold float64) *WeightLimiter {
+	return &WeightLimiter{
+		limit:     limit,
+		threshold: threshold,
+		now:       time.Now,
+	}
+}
+
+// Wait blocks until weight can be spent without crossing the threshold or ctx is done
+func (l *WeightLimiter) Wait(ctx context.Context, weight int) error {
+	for {
+		delay := l.reserve(weight)
+		if delay <= 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Used returns the weight spent in the current minute
+func (l *WeightLimiter) Used() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.roll(l.now())
+	return l.used
+}
+
+// reserve spends weight if allowed, otherwise returns how long to wait
+func (l *WeightLimiter) reserve(weight int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.blockedUntil) {
+		return l.blockedUntil.Sub(now)
+	}
+
+	l.roll(now)
+	budget := int(float64(l.limit) * l.threshold)
+	if l.used > 0 && l.used+weight > budget {
+		delay := l.window.Add(time.Minute).Sub(now)
+		log.Printf("Binance weight %d/%d near limit, waiting %s", l.used, l.limit, delay.Round(time.Second))
+		return delay
+	}
+
+	l.used += weight
+	metrics.APIWeightUsed.Set(float64(l.used))
+	return 0
+}
+
+// roll starts a fresh budget when the minute changes
+func (l *WeightLimiter) roll(now time.Time) {
+	if minute := now.Truncate(time.Minute); !minute.Equal(l.window) {
+		l.window = minute
+		l.used = 0
+	}
+}
+
+// observe updates usage from the server's view and backs off on rate limit responses
+func (l *WeightLimiter) observe(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if used, err := strconv.Atoi(resp.Header.Get(usedWeightHeader)); err == nil {
+		l.roll(now)
+		l.used = used
+		metrics.APIWeightUsed.Set(float64(used))
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusTeapot {
+		return
+	}
+
+	backoff := time.Minute
+	if resp.StatusCode == http.StatusTeapot {
+		backoff = defaultBanBackoff
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		backoff = time.Duration(seconds) * time.Second
+	}
+
+	if until := now.Add(backoff); until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+	metrics.APIRateLimited.WithLabelValues(strconv.Itoa(resp.StatusCode)).Inc()
+	log.Printf("Binance returned %s, pausing requests for %s", resp.Status, backoff)
+}
+
+// Transport wraps base so every response updates the limiter
+func (l *WeightLimiter) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &weightTransport{limiter: l, base: base}
+}
+
+type weightTransport struct {
+	limiter *WeightLimiter
+	base    http.RoundTripper
+}
+
+func (t *weightTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.limiter.observe(resp)
+	return resp, nil
+}
+
+// NewFuturesClient creates a Binance futures client whose responses feed limiter
+func NewFuturesClient(apiKey, secretKey string, limiter *WeightLimiter) *futures.Client {
+	client := futures.NewClient(apiKey, secretKey)
+	client.HTTPClient = &http.Client{Transport: limiter.Transport(nil)}
+	return client
+}
+
+// KlineWeight returns the request weight of a klines call with the given limit, 0 meaning the default of 500
+func KlineWeight(limit int) int {
+	switch {
+	case limit == 0:
+		return 5
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	}
+	return 10
+}
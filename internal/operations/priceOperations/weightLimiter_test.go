@@ -0,0 +1,166 @@
+package priceOperations
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubbedLimiter returns a limiter whose clock reads *now
+func stubbedLimiter(limit int, threshold float64, now *time.Time) *WeightLimiter {
+	limiter := NewWeightLimiter(limit, threshold)
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+// craftedClient answers every request with status and headers through limiter's transport
+func craftedClient(limiter *WeightLimiter, status int, headers map[string]string) *http.Client {
+	return &http.Client{Transport: limiter.Transport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		for key, value := range headers {
+			header.Set(key, value)
+		}
+		return &http.Response{StatusCode: status, Status: http.StatusText(status), Header: header,
+			Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+	}))}
+}
+
+func get(t *testing.T, client *http.Client) {
+	t.Helper()
+	resp, err := client.Get("http://binance.test/fapi/v1/klines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+}
+
+func TestWeightLimiterTracksServerWeight(t *testing.T) {
+	now := testStart.Add(10 * time.Second)
+	limiter := stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now)
+
+	get(t, craftedClient(limiter, http.StatusOK, map[string]string{"X-MBX-USED-WEIGHT-1M": "1500"}))
+	if got := limiter.Used(); got != 1500 {
+		t.Errorf("Used() = %d after the server reported 1500", got)
+	}
+
+	// A response without the header leaves the usage alone
+	get(t, craftedClient(limiter, http.StatusOK, nil))
+	if got := limiter.Used(); got != 1500 {
+		t.Errorf("Used() = %d after a response without weight, want 1500", got)
+	}
+
+	now = testStart.Add(time.Minute)
+	if got := limiter.Used(); got != 0 {
+		t.Errorf("Used() = %d in the next minute, want 0", got)
+	}
+}
+
+func TestWeightLimiterWaitsNearThreshold(t *testing.T) {
+	now := testStart.Add(15 * time.Second)
+	limiter := stubbedLimiter(100, 0.8, &now)
+
+	// 80 of 100 may be spent before waiting
+	if delay := limiter.reserve(50); delay != 0 {
+		t.Fatalf("reserve(50) waited %s on a fresh budget", delay)
+	}
+	if delay := limiter.reserve(30); delay != 0 {
+		t.Fatalf("reserve(30) waited %s reaching the threshold exactly", delay)
+	}
+	if delay := limiter.reserve(1); delay != 45*time.Second {
+		t.Fatalf("reserve(1) past the threshold waited %s, want 45s to the next minute", delay)
+	}
+	if got := limiter.Used(); got != 80 {
+		t.Errorf("Used() = %d, a refused reservation must not be spent", got)
+	}
+
+	// Server-reported weight counts against the budget too
+	now = testStart.Add(time.Minute)
+	get(t, craftedClient(limiter, http.StatusOK, map[string]string{usedWeightHeader: "79"}))
+	if delay := limiter.reserve(5); delay != time.Minute {
+		t.Errorf("reserve(5) at 79 used waited %s, want 1m", delay)
+	}
+
+	now = testStart.Add(2 * time.Minute)
+	if delay := limiter.reserve(5); delay != 0 {
+		t.Errorf("reserve(5) in a fresh minute waited %s", delay)
+	}
+}
+
+func TestWeightLimiterBacksOffOnRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		want       time.Duration
+	}{
+		{"429 with Retry-After", http.StatusTooManyRequests, "7", 7 * time.Second},
+		{"429 without Retry-After", http.StatusTooManyRequests, "", time.Minute},
+		{"418 with Retry-After", http.StatusTeapot, "30", 30 * time.Second},
+		{"418 without Retry-After", http.StatusTeapot, "", defaultBanBackoff},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := testStart.Add(5 * time.Second)
+			limiter := stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now)
+			headers := map[string]string{}
+			if tt.retryAfter != "" {
+				headers["Retry-After"] = tt.retryAfter
+			}
+			get(t, craftedClient(limiter, tt.status, headers))
+
+			// Even a weight-1 request waits out the whole backoff with the budget unused
+			if delay := limiter.reserve(1); delay != tt.want {
+				t.Errorf("reserve(1) waited %s, want %s", delay, tt.want)
+			}
+			now = now.Add(tt.want - time.Second)
+			if delay := limiter.reserve(1); delay != time.Second {
+				t.Errorf("reserve(1) a second before the backoff ends waited %s", delay)
+			}
+			now = now.Add(time.Second)
+			if delay := limiter.reserve(1); delay != 0 {
+				t.Errorf("reserve(1) after the backoff waited %s", delay)
+			}
+		})
+	}
+}
+
+func TestWeightLimiterKeepsLongerBackoff(t *testing.T) {
+	now := testStart
+	limiter := stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now)
+	get(t, craftedClient(limiter, http.StatusTeapot, map[string]string{"Retry-After": "120"}))
+	get(t, craftedClient(limiter, http.StatusTooManyRequests, map[string]string{"Retry-After": "5"}))
+
+	if delay := limiter.reserve(1); delay != 2*time.Minute {
+		t.Errorf("reserve(1) waited %s, a later shorter 429 must not lift the ban", delay)
+	}
+}
+
+func TestWeightLimiterWaitStopsOnCancel(t *testing.T) {
+	now := testStart
+	limiter := stubbedLimiter(BinanceWeightLimit, DefaultWeightThreshold, &now)
+	get(t, craftedClient(limiter, http.StatusTeapot, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() during a ban = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestKlineWeight(t *testing.T) {
+	tests := []struct {
+		limit int
+		want  int
+	}{
+		{0, 5}, {1, 1}, {99, 1}, {100, 2}, {499, 2}, {500, 5}, {1000, 5}, {1500, 10},
+	}
+	for _, tt := range tests {
+		if got := KlineWeight(tt.limit); got != tt.want {
+			t.Errorf("KlineWeight(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
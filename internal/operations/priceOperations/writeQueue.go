@@ -0,0 +1,306 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Backoff between attempts to save a queued candle while the database is failing
+const (
+	WriteRetryBase = time.Second
+	WriteRetryMax  = 30 * time.Second
+)
+
+const (
+	DefaultWriteQueueSize = 1024
+	DefaultSpillPath      = "candles.spill.jsonl"
+
+	// spillCheckInterval is how often the worker tries to drain spilled candles while idle
+	spillCheckInterval = 10 * time.Second
+)
+
+// PriceWriter saves candles, a candle already stored at the same open time being replaced,
+// implemented by repositories.PriceRepository
+type PriceWriter interface {
+	Upsert(ctx context.Context, price *models.Price) error
+}
+
+// WriteQueue saves recorded candles in the background so a stalled database delays them instead of losing them
+// Candles wait in a bounded in-memory queue, overflow is appended to a spill file as JSON lines and
+// written once the database recovers. A candle is only dropped when it can be neither queued nor spilled
+type WriteQueue struct {
+	writer    PriceWriter
+	queue     chan models.Price
+	spillPath string
+	onSaved   func(models.Price) // Called after each candle is saved, nil for nothing
+
+	mu      sync.Mutex // Guards the spill file and spilled
+	spilled int        // Candles waiting in the spill file
+
+	done chan struct{}
+}
+
+// NewWriteQueue creates a queue of size candles in front of writer, spilling to spillPath
+// Candles spilled by an earlier run are picked up and written like new ones
+func NewWriteQueue(writer PriceWriter, size int, spillPath string, onSaved func(models.Price)) *WriteQueue {
+	q := &WriteQueue{
+		writer:    writer,
+		queue:     make(chan models.Price, size),
+		spillPath: spillPath,
+		onSaved:   onSaved,
+		done:      make(chan struct{}),
+	}
+
+	if spilled, err := q.readSpill(); err != nil {
+		log.Printf("Write queue: cannot read spill file %s: %v", spillPath, err)
+	} else if len(spilled) > 0 {
+		log.Printf("Write queue: %d candles spilled by an earlier run will be saved", len(spilled))
+		q.spilled = len(spilled)
+	}
+	metrics.PriceWriteSpilled.Set(float64(q.spilled))
+
+	return q
+}
+
+// Enqueue hands price to the worker without waiting for the database
+func (q *WriteQueue) Enqueue(price models.Price) {
+	select {
+	case q.queue <- price:
+		metrics.PriceWriteQueueDepth.Set(float64(len(q.queue)))
+		return
+	default:
+	}
+
+	if err := q.spill(price); err != nil {
+		log.Printf("Write queue: dropped %s %s candle at %s, queue full and spill failed: %v",
+			price.Symbol, price.TimeFrame, price.OpenTime.Format(time.RFC3339), err)
+		metrics.PriceWritesDropped.WithLabelValues(price.Symbol, price.TimeFrame).Inc()
+	}
+}
+
+// Depth returns the candles waiting in memory and in the spill file
+func (q *WriteQueue) Depth() (queued, spilled int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue), q.spilled
+}
+
+// Done is closed once Run has flushed the queue and returned
+func (q *WriteQueue) Done() <-chan struct{} {
+	return q.done
+}
+
+// Run saves queued candles until ctx is cancelled, then flushes what is left
+func (q *WriteQueue) Run(ctx context.Context) {
+	defer close(q.done)
+
+	ticker := time.NewTicker(spillCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			q.flush()
+			return
+		case price := <-q.queue:
+			metrics.PriceWriteQueueDepth.Set(float64(len(q.queue)))
+			if !q.save(ctx, price) {
+				// Shutting down mid-retry, keep the candle for the next run
+				q.Enqueue(price)
+			}
+		case <-ticker.C:
+			q.drainSpill(ctx)
+		}
+	}
+}
+
+// save writes price, retrying with backoff until it lands or ctx is cancelled
+func (q *WriteQueue) save(ctx context.Context, price models.Price) bool {
+	for attempt := 0; ; attempt++ {
+		if q.write(ctx, price) {
+			if attempt > 0 {
+				// The database is back, catch up on what overflowed meanwhile
+				q.drainSpill(ctx)
+			}
+			return true
+		}
+
+		timer := time.NewTimer(min(WriteRetryBase<<min(attempt, 16), WriteRetryMax))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+	}
+}
+
+// write makes one attempt at saving price
+func (q *WriteQueue) write(ctx context.Context, price models.Price) bool {
+	price.ID = 0
+	if err := q.writer.Upsert(ctx, &price); err != nil {
+		log.Printf("Error saving price for %s-%s: %v", price.Symbol, price.TimeFrame, err)
+		metrics.PriceWriteRetries.Inc()
+		return false
+	}
+	if q.onSaved != nil {
+		q.onSaved(price)
+	}
+	return true
+}
+
+// flush makes one attempt at every queued candle on shutdown, spilling those the database refuses
+// Run's context is done by then, so each write is only bounded by the query timeout
+func (q *WriteQueue) flush() {
+	for {
+		select {
+		case price := <-q.queue:
+			if !q.write(context.Background(), price) {
+				if err := q.spill(price); err != nil {
+					log.Printf("Write queue: dropped %s %s candle on shutdown: %v", price.Symbol, price.TimeFrame, err)
+					metrics.PriceWritesDropped.WithLabelValues(price.Symbol, price.TimeFrame).Inc()
+				}
+			}
+		default:
+			metrics.PriceWriteQueueDepth.Set(0)
+			queued, spilled := q.Depth()
+			log.Printf("Write queue stopped, %d candles queued, %d spilled to %s", queued, spilled, q.spillPath)
+			return
+		}
+	}
+}
+
+// spill appends price to the spill file
+func (q *WriteQueue) spill(price models.Price) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.spillPath == "" {
+		return errors.New("no spill file")
+	}
+	data, err := json.Marshal(price)
+	if err != nil {
+		return fmt.Errorf("failed to encode candle: %v", err)
+	}
+
+	file, err := os.OpenFile(q.spillPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open spill file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write spill file: %v", err)
+	}
+
+	q.spilled++
+	metrics.PriceWriteSpilled.Set(float64(q.spilled))
+	return nil
+}
+
+// drainSpill saves spilled candles oldest first, stopping at the first failure so the rest keep waiting
+func (q *WriteQueue) drainSpill(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.spilled == 0 {
+		return
+	}
+
+	prices, err := q.readSpill()
+	if err != nil {
+		log.Printf("Write queue: cannot read spill file %s: %v", q.spillPath, err)
+		return
+	}
+
+	saved := 0
+	for _, price := range prices {
+		if !q.write(ctx, price) {
+			break
+		}
+		saved++
+	}
+	if saved == 0 {
+		return
+	}
+
+	if err := q.rewriteSpill(prices[saved:]); err != nil {
+		// The saved candles stay in the file and would be saved twice, so stop draining until fixed
+		log.Printf("Write queue: cannot rewrite spill file %s: %v", q.spillPath, err)
+		return
+	}
+	q.spilled = len(prices) - saved
+	metrics.PriceWriteSpilled.Set(float64(q.spilled))
+	log.Printf("Write queue: saved %d spilled candles, %d left", saved, q.spilled)
+}
+
+// readSpill returns the spilled candles, none when there is no spill file
+func (q *WriteQueue) readSpill() ([]models.Price, error) {
+	if q.spillPath == "" {
+		return nil, nil
+	}
+	file, err := os.Open(q.spillPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var prices []models.Price
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var price models.Price
+		if err := json.Unmarshal(scanner.Bytes(), &price); err != nil {
+			// A line cut short by a crash mid-write, the candles after it are still good
+			log.Printf("Write queue: skipping unreadable spill line: %v", err)
+			continue
+		}
+		prices = append(prices, price)
+	}
+	return prices, scanner.Err()
+}
+
+// rewriteSpill replaces the spill file with prices, removing it when there are none
+func (q *WriteQueue) rewriteSpill(prices []models.Price) error {
+	if len(prices) == 0 {
+		if err := os.Remove(q.spillPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	tmp := q.spillPath + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(file)
+	for _, price := range prices {
+		data, err := json.Marshal(price)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		w.Write(append(data, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, q.spillPath)
+}
@@ -0,0 +1,141 @@
+package priceOperations
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// queuedCandles returns n consecutive 5m BTCUSDT candles from testStart
+func queuedCandles(n int) []models.Price {
+	prices := make([]models.Price, n)
+	for i := range prices {
+		prices[i] = testPrice("BTCUSDT", models.PriceTimeFrame5m, testStart.Add(time.Duration(i)*5*time.Minute), 100+float64(i))
+	}
+	return prices
+}
+
+// assertAllSaved fails unless store holds each of want once
+func assertAllSaved(t *testing.T, store *memoryPriceStore, want []models.Price) {
+	t.Helper()
+	saved := store.series("BTCUSDT", models.PriceTimeFrame5m)
+	if len(saved) != len(want) {
+		t.Fatalf("saved %d candles, want %d", len(saved), len(want))
+	}
+	seen := make(map[time.Time]int)
+	for _, p := range saved {
+		seen[p.OpenTime]++
+	}
+	for _, p := range want {
+		if seen[p.OpenTime] != 1 {
+			t.Errorf("candle at %s saved %d times, want once", p.OpenTime.Format(time.RFC3339), seen[p.OpenTime])
+		}
+	}
+}
+
+// flakyWriter fails the first failures writes, as a database does while it stalls, then saves to store
+type flakyWriter struct {
+	store    *memoryPriceStore
+	failures atomic.Int32
+}
+
+func (w *flakyWriter) Upsert(ctx context.Context, price *models.Price) error {
+	if w.failures.Add(-1) >= 0 {
+		return errors.New("database stalled")
+	}
+	return w.store.Upsert(ctx, price)
+}
+
+// waitSaved waits until store holds n candles
+func waitSaved(t *testing.T, store *memoryPriceStore, n int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for len(store.series("BTCUSDT", models.PriceTimeFrame5m)) < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("saved %d candles, want %d", len(store.series("BTCUSDT", models.PriceTimeFrame5m)), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWriteQueueLosesNoCandlesWhileTheDatabaseFails(t *testing.T) {
+	store := &memoryPriceStore{}
+	writer := &flakyWriter{store: store}
+	writer.failures.Store(2)
+	spillPath := filepath.Join(t.TempDir(), "candles.spill.jsonl")
+	q := NewWriteQueue(writer, 4, spillPath, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Run(ctx)
+
+	// More candles than the queue holds arrive while writes fail
+	candles := queuedCandles(20)
+	for _, price := range candles {
+		q.Enqueue(price)
+	}
+	queued, spilled := q.Depth()
+	if spilled == 0 || queued+spilled < len(candles)-1 {
+		t.Fatalf("%d queued and %d spilled of %d candles, want the overflow spilled", queued, spilled, len(candles))
+	}
+
+	// The database recovers, a retry lands and the spill drains behind it
+	waitSaved(t, store, len(candles))
+	cancel()
+	<-q.Done()
+
+	assertAllSaved(t, store, candles)
+	if queued, spilled := q.Depth(); queued != 0 || spilled != 0 {
+		t.Errorf("Depth() = %d, %d after recovery, want empty", queued, spilled)
+	}
+	if _, err := os.Stat(spillPath); !os.IsNotExist(err) {
+		t.Errorf("spill file left behind after draining: %v", err)
+	}
+}
+
+func TestWriteQueueSpillSurvivesARestart(t *testing.T) {
+	store := &memoryPriceStore{}
+	store.setFail(errors.New("database down"))
+	spillPath := filepath.Join(t.TempDir(), "candles.spill.jsonl")
+	candles := queuedCandles(10)
+
+	// Shutting down with the database still failing keeps every candle on disk
+	q := NewWriteQueue(store, 4, spillPath, nil)
+	for _, price := range candles {
+		q.Enqueue(price)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Run(ctx)
+	if queued, spilled := q.Depth(); queued != 0 || spilled != len(candles) {
+		t.Fatalf("Depth() after shutdown = %d, %d, want all %d spilled", queued, spilled, len(candles))
+	}
+
+	// The next run picks them up and saves them once the database is back
+	store.setFail(nil)
+	restarted := NewWriteQueue(store, 4, spillPath, nil)
+	if _, spilled := restarted.Depth(); spilled != len(candles) {
+		t.Fatalf("restarted queue sees %d spilled candles, want %d", spilled, len(candles))
+	}
+	restarted.drainSpill(context.Background())
+	assertAllSaved(t, store, candles)
+	if _, spilled := restarted.Depth(); spilled != 0 {
+		t.Errorf("%d candles still spilled after draining", spilled)
+	}
+}
+
+func TestWriteQueueDropsOnlyWithoutASpillFile(t *testing.T) {
+	store := &memoryPriceStore{}
+	q := NewWriteQueue(store, 2, "", nil)
+	for _, price := range queuedCandles(5) {
+		q.Enqueue(price)
+	}
+	// Nothing runs the queue, so two wait in memory and the rest had nowhere to go
+	if queued, spilled := q.Depth(); queued != 2 || spilled != 0 {
+		t.Errorf("Depth() = %d, %d, want 2 queued and none spilled", queued, spilled)
+	}
+}
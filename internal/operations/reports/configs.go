@@ -0,0 +1,91 @@
+package reports
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/provenance"
+	"CryptoTradeBot/internal/repositories"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ConfigSummary is the closed positions opened under one config snapshot
+type ConfigSummary struct {
+	Hash   string // Shortened, empty for positions opened before configs were recorded
+	Closed int
+	PnL    float64
+
+	firstOpen time.Time
+	fullHash  string
+}
+
+// UseConfigSnapshots lists what changed between the configs a report's positions were opened under
+func (s *ReportService) UseConfigSnapshots(configRepo *repositories.ConfigSnapshotRepository) {
+	s.configRepo = configRepo
+}
+
+// summarizeConfigs groups closed positions by the config they were opened under, earliest used first
+func summarizeConfigs(closed []models.Position) []ConfigSummary {
+	byHash := make(map[string]*ConfigSummary)
+	for _, position := range closed {
+		summary, ok := byHash[position.ConfigHash]
+		if !ok {
+			summary = &ConfigSummary{Hash: shortHash(position.ConfigHash), fullHash: position.ConfigHash, firstOpen: position.OpenTime}
+			byHash[position.ConfigHash] = summary
+		}
+		summary.Closed++
+		summary.PnL += position.PnL
+		if position.OpenTime.Before(summary.firstOpen) {
+			summary.firstOpen = position.OpenTime
+		}
+	}
+
+	summaries := make([]ConfigSummary, 0, len(byHash))
+	for _, summary := range byHash {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].firstOpen.Before(summaries[j].firstOpen) })
+	return summaries
+}
+
+// configChanges diffs the first and last recorded config used over the period, nil when it used one
+func (s *ReportService) configChanges(configs []ConfigSummary) ([]string, error) {
+	var hashes []string
+	for _, config := range configs {
+		if config.fullHash != "" {
+			hashes = append(hashes, config.fullHash)
+		}
+	}
+	if s.configRepo == nil || len(hashes) < 2 {
+		return nil, nil
+	}
+
+	first, err := s.loadConfig(hashes[0])
+	if err != nil || first == nil {
+		return nil, err
+	}
+	last, err := s.loadConfig(hashes[len(hashes)-1])
+	if err != nil || last == nil {
+		return nil, err
+	}
+	return provenance.Diff(first, last)
+}
+
+func (s *ReportService) loadConfig(hash string) (*provenance.Snapshot, error) {
+	stored, err := s.configRepo.Find(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config %s: %v", shortHash(hash), err)
+	}
+	if stored == nil {
+		return nil, nil
+	}
+	return &provenance.Snapshot{Hash: stored.Hash, Version: stored.Version, Config: json.RawMessage(stored.Config)}, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > provenance.ShortHash {
+		return hash[:provenance.ShortHash]
+	}
+	return hash
+}
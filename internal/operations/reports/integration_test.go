@@ -0,0 +1,139 @@
+//go:build integration
+
+package reports
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newReportService returns a report service on a fresh database, starting from 1000 USDT
+func newReportService(t *testing.T) *ReportService {
+	t.Helper()
+	db := testdb.Open(t)
+	return NewReportService(
+		repositories.NewPriceRepository(db),
+		repositories.NewPositionRepository(db),
+		repositories.NewTransactionRepository(db),
+		repositories.NewSignalRepository(db),
+		nil,
+		1000,
+		"USDT",
+	)
+}
+
+// storeClosed stores a BTCUSDT long opened an hour before closing at closeTime with pnl
+func storeClosed(t *testing.T, s *ReportService, closeTime time.Time, pnl float64) *models.Position {
+	t.Helper()
+	position := &models.Position{
+		Symbol:          "BTCUSDT",
+		Side:            models.PositionSideLong,
+		Size:            1,
+		Leverage:        50,
+		EntryPrice:      100,
+		StopLossPrice:   99,
+		TakeProfitPrice: 102,
+		OpenTime:        closeTime.Add(-time.Hour),
+		CloseTime:       closeTime,
+		Status:          models.PositionStatusClosed,
+		PnL:             pnl,
+	}
+	if err := s.positionRepo.Create(context.Background(), position); err != nil {
+		t.Fatal(err)
+	}
+	return position
+}
+
+func TestBuildEmptyDay(t *testing.T) {
+	s := newReportService(t)
+	period := DayPeriod(utc("2024-05-01 12:00"))
+
+	report, err := s.Build(context.Background(), period, period.End.Add(5*time.Minute))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if !report.Empty() || report.Closed != 0 || report.WinRate != 0 || report.RealizedPnL != 0 {
+		t.Errorf("report = %+v, want an empty day", report)
+	}
+	if report.OpeningBalance != 1000 || report.ClosingBalance != 1000 || len(report.OpenPositions) != 0 || len(report.Rejections) != 0 {
+		t.Errorf("balance %v -> %v with %d open positions and %d rejections, want 1000 flat and nothing else",
+			report.OpeningBalance, report.ClosingBalance, len(report.OpenPositions), len(report.Rejections))
+	}
+}
+
+func TestBuildCountsTradesByUTCDay(t *testing.T) {
+	s := newReportService(t)
+	storeClosed(t, s, utc("2024-04-30 23:59"), 5) // The evening before in UTC
+	storeClosed(t, s, utc("2024-05-01 00:00"), 2) // The first minute of the day
+	storeClosed(t, s, utc("2024-05-01 23:59"), -1)
+	storeClosed(t, s, utc("2024-05-02 00:00"), 7) // The next day
+
+	// Asked from New York on the evening of April 30th, the day is still May 1st in UTC
+	period := DayPeriod(time.Date(2024, 4, 30, 21, 0, 0, 0, newYork))
+	report, err := s.Build(context.Background(), period, utc("2024-05-02 00:05"))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if report.Closed != 2 || report.Wins != 1 || report.Losses != 1 || report.RealizedPnL != 1 || report.WinRate != 50 {
+		t.Errorf("closed %d (%d won, %d lost), PnL %v, win rate %v, want the two trades of May 1st UTC",
+			report.Closed, report.Wins, report.Losses, report.RealizedPnL, report.WinRate)
+	}
+	// Each trade opened an hour before closing, so May 1st saw the last two open
+	if report.Opened != 2 {
+		t.Errorf("Opened = %d, want 2", report.Opened)
+	}
+}
+
+func TestBuildExcludesTaggedTrades(t *testing.T) {
+	s := newReportService(t)
+	spike := storeClosed(t, s, utc("2024-05-01 09:00"), 30)
+	fill := storeClosed(t, s, utc("2024-05-01 10:00"), -4)
+	storeClosed(t, s, utc("2024-05-01 11:00"), 5)
+	storeClosed(t, s, utc("2024-05-01 12:00"), -2)
+	for id, tags := range map[uint][]string{spike.ID: {"news spike", "bad fill"}, fill.ID: {"bad fill"}} {
+		for _, tag := range tags {
+			if err := s.positionRepo.AddTag(id, tag); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	period := DayPeriod(utc("2024-05-01 12:00"))
+
+	report, err := s.Build(context.Background(), period, utc("2024-05-02 00:05"))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if report.Closed != 4 || report.RealizedPnL != 29 || report.Excluded != 0 {
+		t.Errorf("closed %d, PnL %v, excluded %d, want all 4 trades for 29 without a filter", report.Closed, report.RealizedPnL, report.Excluded)
+	}
+	want := []TagSummary{{Tag: "bad fill", Closed: 2, Wins: 1, PnL: 26}, {Tag: "news spike", Closed: 1, Wins: 1, PnL: 30}}
+	if !slices.Equal(report.Tags, want) {
+		t.Errorf("Tags = %+v, want %+v", report.Tags, want)
+	}
+
+	// PnL without the news spike trades
+	if err := s.ExcludeTags([]string{"News Spike"}); err != nil {
+		t.Fatal(err)
+	}
+	report, err = s.Build(context.Background(), period, utc("2024-05-02 00:05"))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if report.Closed != 3 || report.Wins != 1 || report.RealizedPnL != -1 || report.Excluded != 1 || report.ExcludedPnL != 30 {
+		t.Errorf("closed %d (%d won), PnL %v, excluded %d for %v, want 3 trades for -1 with the 30 spike left out",
+			report.Closed, report.Wins, report.RealizedPnL, report.Excluded, report.ExcludedPnL)
+	}
+	// The by-tag breakdown still covers every trade
+	if !slices.Equal(report.Tags, want) {
+		t.Errorf("Tags with a filter = %+v, want %+v", report.Tags, want)
+	}
+
+	if err := s.ExcludeTags([]string{"spike!"}); err == nil {
+		t.Error("ExcludeTags() with an invalid tag succeeded, want an error")
+	}
+}
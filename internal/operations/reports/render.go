@@ -0,0 +1,182 @@
+package reports
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// configName labels a config hash, positions opened before configs were recorded have none
+func configName(hash string) string {
+	if hash == "" {
+		return "unrecorded"
+	}
+	return hash
+}
+
+// RenderText formats the report as plain text for chat channels
+func RenderText(r *Report) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s to %s UTC\n",
+		r.Period.Start.Format("2006-01-02 15:04"), r.Period.End.Format("2006-01-02 15:04"))
+
+	if r.Empty() {
+		b.WriteString("No trades\n")
+	} else {
+		fmt.Fprintf(&b, "Trades opened: %d\n", r.Opened)
+		fmt.Fprintf(&b, "Trades closed: %d (%d won, %d lost, win rate %.1f%%)\n", r.Closed, r.Wins, r.Losses, r.WinRate)
+		fmt.Fprintf(&b, "Realized PnL: %+.2f %s\n", r.RealizedPnL, r.QuoteAsset)
+		if r.R.Trades > 0 {
+			fmt.Fprintf(&b, "R: %+.2fR total, %+.2fR average, %.1f%% at 2R or better\n", r.R.Total, r.R.Average, r.R.AtLeast2R*100)
+		}
+	}
+	if r.Excluded > 0 {
+		fmt.Fprintf(&b, "Excluded (%s): %d trades, %+.2f %s\n", strings.Join(r.ExcludedTags, ", "), r.Excluded, r.ExcludedPnL, r.QuoteAsset)
+	}
+	if r.Funding != 0 {
+		fmt.Fprintf(&b, "Funding: %+.2f %s\n", r.Funding, r.QuoteAsset)
+	}
+	fmt.Fprintf(&b, "Balance: %.2f -> %.2f %s (%+.2f)\n", r.OpeningBalance, r.ClosingBalance, r.QuoteAsset, r.BalanceChange())
+	if r.HoldsOtherAssets() {
+		fmt.Fprintf(&b, "All balances: %.2f %s\n", r.TotalBalance, r.QuoteAsset)
+	}
+	if len(r.Configs) == 1 {
+		fmt.Fprintf(&b, "Config: %s\n", configName(r.Configs[0].Hash))
+	}
+
+	if len(r.OpenPositions) > 0 {
+		fmt.Fprintf(&b, "\nOpen positions (unrealized %+.2f %s):\n", r.UnrealizedPnL, r.QuoteAsset)
+		for _, p := range r.OpenPositions {
+			fmt.Fprintf(&b, "%s %s %.8g @ %.8g, mark %.8g, %+.2f %s\n",
+				p.Symbol, p.Side, p.Size, p.EntryPrice, p.MarkPrice, p.UnrealizedPnL, r.QuoteAsset)
+		}
+	}
+
+	if len(r.Shadows) > 0 {
+		b.WriteString("\nPrimary vs shadows:\n")
+		for _, account := range append([]AccountEquity{r.Standing()}, r.Shadows...) {
+			fmt.Fprintf(&b, "%s: equity %.2f %s, %d trades closed, %+.2f %s\n",
+				account.Account, account.Equity, r.QuoteAsset, account.Closed, account.RealizedPnL, r.QuoteAsset)
+		}
+	}
+
+	if r.R.Trades > 0 {
+		fmt.Fprintf(&b, "\nR distribution (%d trades without an initial stop left out):\n", r.R.Excluded)
+		for _, bucket := range r.R.Buckets {
+			fmt.Fprintf(&b, "%s: %d\n", bucket.Label, bucket.Count)
+		}
+	}
+
+	if len(r.Confidence) > 0 {
+		b.WriteString("\nBy confidence:\n")
+		for _, bucket := range r.Confidence {
+			fmt.Fprintf(&b, "%s: %d trades (%d won), %+.2f %s, average %.2fR, size x%.2f\n",
+				bucket.Label, bucket.Trades, bucket.Wins, bucket.PnL, r.QuoteAsset, bucket.AverageR, bucket.SizeMultiplier)
+		}
+	}
+
+	if len(r.Tags) > 0 {
+		b.WriteString("\nBy tag:\n")
+		for _, tag := range r.Tags {
+			fmt.Fprintf(&b, "%s: %d trades (%d won), %+.2f %s\n", tag.Tag, tag.Closed, tag.Wins, tag.PnL, r.QuoteAsset)
+		}
+	}
+
+	if len(r.Configs) > 1 {
+		b.WriteString("\nBy config:\n")
+		for _, config := range r.Configs {
+			fmt.Fprintf(&b, "%s: %d trades, %+.2f %s\n", configName(config.Hash), config.Closed, config.PnL, r.QuoteAsset)
+		}
+		for _, change := range r.ConfigChanges {
+			fmt.Fprintf(&b, "  %s\n", change)
+		}
+	}
+
+	if len(r.Rejections) > 0 {
+		b.WriteString("\nTop rejection reasons:\n")
+		for _, rejection := range r.Rejections {
+			fmt.Fprintf(&b, "%s: %d\n", rejection.Reason, rejection.Count)
+		}
+	}
+
+	return b.String()
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"signed": func(v float64) string { return fmt.Sprintf("%+.2f", v) },
+	"money":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"price":  func(v float64) string { return fmt.Sprintf("%.8g", v) },
+	"join":   func(v []string) string { return strings.Join(v, ", ") },
+	"config": configName,
+	"pct":    func(v float64) float64 { return v * 100 },
+	"pnl": func(v float64) string {
+		if v < 0 {
+			return "color:#c62828"
+		}
+		return "color:#2e7d32"
+	},
+}).Parse(`<!DOCTYPE html>
+<html><body style="font-family:sans-serif;font-size:14px">
+<h2>{{.Title}}</h2>
+<p style="color:#666">{{.Period.Start.Format "2006-01-02 15:04"}} to {{.Period.End.Format "2006-01-02 15:04"}} UTC</p>
+<table cellpadding="4">
+{{if .Empty}}<tr><td colspan="2">No trades</td></tr>{{else}}
+<tr><td>Trades opened</td><td>{{.Opened}}</td></tr>
+<tr><td>Trades closed</td><td>{{.Closed}} ({{.Wins}} won, {{.Losses}} lost, win rate {{printf "%.1f" .WinRate}}%)</td></tr>
+<tr><td>Realized PnL</td><td style="{{pnl .RealizedPnL}}">{{signed .RealizedPnL}} {{.QuoteAsset}}</td></tr>
+{{if .R.Trades}}<tr><td>R</td><td>{{signed .R.Total}}R total, {{signed .R.Average}}R average, {{printf "%.1f" (pct .R.AtLeast2R)}}% at 2R or better</td></tr>
+{{end}}{{end}}{{if .Excluded}}<tr><td>Excluded ({{join .ExcludedTags}})</td><td>{{.Excluded}} trades, <span style="{{pnl .ExcludedPnL}}">{{signed .ExcludedPnL}} {{.QuoteAsset}}</span></td></tr>
+{{end}}{{if .Funding}}<tr><td>Funding</td><td style="{{pnl .Funding}}">{{signed .Funding}} {{.QuoteAsset}}</td></tr>
+{{end}}<tr><td>Balance</td><td>{{money .OpeningBalance}} &rarr; {{money .ClosingBalance}} {{.QuoteAsset}} ({{signed .BalanceChange}})</td></tr>
+{{if .HoldsOtherAssets}}<tr><td>All balances</td><td>{{money .TotalBalance}} {{.QuoteAsset}}</td></tr>
+{{end}}{{if eq (len .Configs) 1}}<tr><td>Config</td><td>{{config (index .Configs 0).Hash}}</td></tr>
+{{end}}</table>
+{{if .OpenPositions}}<h3>Open positions (unrealized <span style="{{pnl .UnrealizedPnL}}">{{signed .UnrealizedPnL}} {{.QuoteAsset}}</span>)</h3>
+<table cellpadding="4">
+<tr><th align="left">Symbol</th><th align="left">Side</th><th align="right">Size</th><th align="right">Entry</th><th align="right">Mark</th><th align="right">PnL</th></tr>
+{{range .OpenPositions}}<tr><td>{{.Symbol}}</td><td>{{.Side}}</td><td align="right">{{price .Size}}</td><td align="right">{{price .EntryPrice}}</td><td align="right">{{price .MarkPrice}}</td><td align="right" style="{{pnl .UnrealizedPnL}}">{{signed .UnrealizedPnL}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Shadows}}<h3>Primary vs shadows</h3>
+<table cellpadding="4">
+<tr><th align="left">Account</th><th align="right">Equity</th><th align="right">Closed</th><th align="right">Realized PnL</th></tr>
+{{with .Standing}}<tr><td><b>{{.Account}}</b></td><td align="right">{{money .Equity}}</td><td align="right">{{.Closed}}</td><td align="right" style="{{pnl .RealizedPnL}}">{{signed .RealizedPnL}}</td></tr>
+{{end}}{{range .Shadows}}<tr><td>{{.Account}}</td><td align="right">{{money .Equity}}</td><td align="right">{{.Closed}}</td><td align="right" style="{{pnl .RealizedPnL}}">{{signed .RealizedPnL}}</td></tr>
+{{end}}</table>
+{{end}}{{if .R.Trades}}<h3>R distribution</h3>
+<table cellpadding="4">
+<tr><th align="left">R</th><th align="right">Trades</th></tr>
+{{range .R.Buckets}}<tr><td>{{.Label}}</td><td align="right">{{.Count}}</td></tr>
+{{end}}{{if .R.Excluded}}<tr><td>Without an initial stop</td><td align="right">{{.R.Excluded}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Confidence}}<h3>By confidence</h3>
+<table cellpadding="4">
+<tr><th align="left">Confidence</th><th align="right">Trades</th><th align="right">Won</th><th align="right">PnL</th><th align="right">Average R</th><th align="right">Size</th></tr>
+{{range .Confidence}}<tr><td>{{.Label}}</td><td align="right">{{.Trades}}</td><td align="right">{{.Wins}}</td><td align="right" style="{{pnl .PnL}}">{{signed .PnL}}</td><td align="right">{{printf "%.2f" .AverageR}}R</td><td align="right">x{{money .SizeMultiplier}}</td></tr>
+{{end}}</table>
+{{end}}{{if .Tags}}<h3>By tag</h3>
+<table cellpadding="4">
+<tr><th align="left">Tag</th><th align="right">Trades</th><th align="right">Won</th><th align="right">PnL</th></tr>
+{{range .Tags}}<tr><td>{{.Tag}}</td><td align="right">{{.Closed}}</td><td align="right">{{.Wins}}</td><td align="right" style="{{pnl .PnL}}">{{signed .PnL}}</td></tr>
+{{end}}</table>
+{{end}}{{if gt (len .Configs) 1}}<h3>By config</h3>
+<table cellpadding="4">
+<tr><th align="left">Config</th><th align="right">Trades</th><th align="right">PnL</th></tr>
+{{range .Configs}}<tr><td>{{config .Hash}}</td><td align="right">{{.Closed}}</td><td align="right" style="{{pnl .PnL}}">{{signed .PnL}}</td></tr>
+{{end}}</table>
+{{if .ConfigChanges}}<ul>{{range .ConfigChanges}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{end}}{{if .Rejections}}<h3>Top rejection reasons</h3>
+<table cellpadding="4">
+{{range .Rejections}}<tr><td>{{.Reason}}</td><td align="right">{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}</body></html>
+`))
+
+// RenderHTML formats the report as an HTML document for email
+func RenderHTML(r *Report) (string, error) {
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, r); err != nil {
+		return "", fmt.Errorf("failed to render report: %v", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,474 @@
+package reports
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/trading"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+const topRejections = 5
+
+// Period kinds
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// Period is a UTC reporting window from Start up to but not including End
+type Period struct {
+	Kind  string
+	Start time.Time
+	End   time.Time
+}
+
+// DayPeriod returns the UTC day containing t
+func DayPeriod(t time.Time) Period {
+	start := t.UTC().Truncate(24 * time.Hour)
+	return Period{Kind: PeriodDay, Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+// WeekPeriod returns the ISO week, Monday to Sunday in UTC, containing t
+func WeekPeriod(t time.Time) Period {
+	day := t.UTC().Truncate(24 * time.Hour)
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	start := day.AddDate(0, 0, -offset)
+	return Period{Kind: PeriodWeek, Start: start, End: start.AddDate(0, 0, 7)}
+}
+
+// NewPeriod returns the period of kind containing t
+func NewPeriod(kind string, t time.Time) (Period, error) {
+	switch kind {
+	case PeriodDay:
+		return DayPeriod(t), nil
+	case PeriodWeek:
+		return WeekPeriod(t), nil
+	}
+	return Period{}, fmt.Errorf("unknown period %q, use 'day' or 'week'", kind)
+}
+
+// Label names the period, 2024-05-01 for a day and 2024-W18 for a week
+func (p Period) Label() string {
+	if p.Kind == PeriodWeek {
+		year, week := p.Start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return p.Start.Format("2006-01-02")
+}
+
+// OpenPosition is a position still open when the report was generated
+type OpenPosition struct {
+	Symbol        string
+	Side          string
+	Size          float64
+	EntryPrice    float64
+	MarkPrice     float64 // Latest 5m close, 0 when no price is known
+	UnrealizedPnL float64
+	OpenTime      time.Time
+}
+
+// Report summarizes trading over one period
+type Report struct {
+	Account     string
+	Period      Period
+	GeneratedAt time.Time
+	QuoteAsset  string // Asset the PnL and balances are in, PnL in other quote assets is converted to it
+
+	Opened  int // Positions opened during the period
+	Closed  int // Positions closed during the period
+	Wins    int
+	Losses  int
+	WinRate float64 // Percentage of closed positions with positive PnL
+
+	RealizedPnL float64
+	Funding     float64 // Net funding transactions
+
+	// Outcomes in R of the closed positions counted above
+	R trading.RStats
+
+	// Closed positions by the confidence they were opened at, see trading.ConfidenceBucketEdges
+	Confidence []trading.ConfidenceBucket
+
+	OpeningBalance float64
+	ClosingBalance float64
+
+	// Every balance valued in QuoteAsset at the latest prices as of GeneratedAt,
+	// 0 unless UseAccount was called and the account holds other assets
+	TotalBalance float64
+
+	OpenPositions []OpenPosition // As of GeneratedAt
+	UnrealizedPnL float64
+
+	Rejections []repositories.RejectionCount // Most frequent first
+
+	// Closed positions by journal tag, a position with several tags counts under each
+	Tags []TagSummary
+
+	// Closed positions by the config they were opened under, and what changed from the first to the
+	// last recorded one when there were several
+	Configs       []ConfigSummary
+	ConfigChanges []string
+
+	// Closed positions carrying an excluded tag, left out of the counts and PnL above
+	ExcludedTags []string
+	Excluded     int
+	ExcludedPnL  float64
+
+	// The shadow accounts' standing over the same period, set when the report compares them
+	Shadows []AccountEquity
+}
+
+// AccountEquity is one account's standing in a comparison of the primary account and its shadows
+type AccountEquity struct {
+	Account     string
+	Closed      int
+	RealizedPnL float64
+	Equity      float64
+}
+
+// TagSummary is the closed positions carrying one journal tag
+type TagSummary struct {
+	Tag    string
+	Closed int
+	Wins   int
+	PnL    float64
+}
+
+// BalanceChange returns how much the balance moved over the period
+func (r *Report) BalanceChange() float64 {
+	return r.ClosingBalance - r.OpeningBalance
+}
+
+// Equity returns the closing balance, or every balance when the account holds other assets,
+// plus the unrealized PnL of the open positions
+func (r *Report) Equity() float64 {
+	if r.HoldsOtherAssets() {
+		return r.TotalBalance + r.UnrealizedPnL
+	}
+	return r.ClosingBalance + r.UnrealizedPnL
+}
+
+// Standing returns the report's account as compared with shadows
+func (r *Report) Standing() AccountEquity {
+	return AccountEquity{Account: r.Account, Closed: r.Closed, RealizedPnL: r.RealizedPnL, Equity: r.Equity()}
+}
+
+// HoldsOtherAssets reports whether the account holds balances besides the QuoteAsset one
+func (r *Report) HoldsOtherAssets() bool {
+	return r.TotalBalance != 0
+}
+
+// Empty reports whether nothing was opened or closed during the period
+func (r *Report) Empty() bool {
+	return r.Opened == 0 && r.Closed == 0
+}
+
+// Title is the subject line of the report
+func (r *Report) Title() string {
+	kind := "Daily"
+	if r.Period.Kind == PeriodWeek {
+		kind = "Weekly"
+	}
+	title := fmt.Sprintf("%s summary %s", kind, r.Period.Label())
+	if r.Account != "" && r.Account != models.DefaultAccount {
+		title += " (" + r.Account + ")"
+	}
+	return title
+}
+
+type ReportService struct {
+	priceRepo       *repositories.PriceRepository
+	positionRepo    *repositories.PositionRepository
+	transactionRepo *repositories.TransactionRepository
+	signalRepo      *repositories.SignalRepository
+	notifier        *notifications.Notifier
+	initialBalance  float64
+	quoteAsset      string
+	excludeTags     []string
+	account         *trading.AccountService                // Values balances in other quote assets, nil to skip
+	shadows         []*ReportService                       // Shadow accounts compared in each report
+	configRepo      *repositories.ConfigSnapshotRepository // Diffs the configs used, nil to skip
+}
+
+// NewReportService creates a new instance of ReportService
+// The repositories decide which account is reported on
+func NewReportService(
+	priceRepo *repositories.PriceRepository,
+	positionRepo *repositories.PositionRepository,
+	transactionRepo *repositories.TransactionRepository,
+	signalRepo *repositories.SignalRepository,
+	notifier *notifications.Notifier,
+	initialBalance float64,
+	quoteAsset string,
+) *ReportService {
+	return &ReportService{
+		priceRepo:       priceRepo,
+		positionRepo:    positionRepo,
+		transactionRepo: transactionRepo,
+		signalRepo:      signalRepo,
+		notifier:        notifier,
+		initialBalance:  initialBalance,
+		quoteAsset:      quoteAsset,
+	}
+}
+
+// UseAccount reports account's balances in every quote asset valued together, see AccountService.TotalBalance
+func (s *ReportService) UseAccount(account *trading.AccountService) {
+	s.account = account
+}
+
+// CompareShadows adds the standing of the accounts shadows report on to every report, beside this account's
+func (s *ReportService) CompareShadows(shadows ...*ReportService) {
+	s.shadows = append(s.shadows, shadows...)
+}
+
+// ExcludeTags leaves closed positions carrying any of tags out of the trade counts and realized PnL,
+// reporting them separately, e.g. to see PnL without "news spike" trades
+func (s *ReportService) ExcludeTags(tags []string) error {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := repositories.NormalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		normalized = append(normalized, tag)
+	}
+	s.excludeTags = normalized
+	return nil
+}
+
+// Build gathers the report for period, with open positions as of now
+func (s *ReportService) Build(ctx context.Context, period Period, now time.Time) (*Report, error) {
+	report := &Report{
+		Account:     s.positionRepo.Account(),
+		Period:      period,
+		GeneratedAt: now.UTC(),
+		QuoteAsset:  s.quoteAsset,
+	}
+
+	opened, err := s.positionRepo.CountOpenedBetween(period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count opened positions: %v", err)
+	}
+	report.Opened = int(opened)
+
+	closed, err := s.positionRepo.FindClosedBetween(period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closed positions: %v", err)
+	}
+	ids := make([]uint, len(closed))
+	for i, position := range closed {
+		ids[i] = position.ID
+	}
+	tags, err := s.positionRepo.Tags(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get position tags: %v", err)
+	}
+	report.Tags = summarizeTags(closed, tags)
+	report.Configs = summarizeConfigs(closed)
+	if report.ConfigChanges, err = s.configChanges(report.Configs); err != nil {
+		return nil, err
+	}
+	report.ExcludedTags = s.excludeTags
+
+	var rs []float64
+	var outcomes []trading.ConfidenceOutcome
+	noR := 0
+	for _, position := range closed {
+		pnl, err := s.convert(ctx, position.Symbol, position.PnL)
+		if err != nil {
+			return nil, err
+		}
+		if excluded(tags[position.ID], s.excludeTags) {
+			report.Excluded++
+			report.ExcludedPnL += pnl
+			continue
+		}
+		report.Closed++
+		report.RealizedPnL += pnl
+		if position.PnL > 0 {
+			report.Wins++
+		} else {
+			report.Losses++
+		}
+		outcome := trading.ConfidenceOutcome{Confidence: position.Confidence, SizeMultiplier: position.ConfidenceMultiplier, PnL: pnl}
+		if r, ok := trading.PositionR(&position); ok {
+			rs = append(rs, r)
+			outcome.R = &r
+		} else {
+			noR++
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	if report.Closed > 0 {
+		report.WinRate = float64(report.Wins) / float64(report.Closed) * 100
+	}
+	report.R = trading.RDistribution(rs, noR)
+	report.Confidence = trading.ConfidenceBuckets(outcomes)
+
+	report.Funding, err = s.transactionRepo.SumByType(s.quoteAsset, models.TransactionTypeFunding, period.Start, period.End)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum funding: %v", err)
+	}
+
+	if report.OpeningBalance, err = s.balanceAt(period.Start); err != nil {
+		return nil, err
+	}
+	if report.ClosingBalance, err = s.balanceAt(period.End); err != nil {
+		return nil, err
+	}
+	if err := s.addTotalBalance(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if err := s.addOpenPositions(ctx, report); err != nil {
+		return nil, err
+	}
+
+	if s.signalRepo != nil {
+		report.Rejections, err = s.signalRepo.TopRejections(period.Start, period.End, topRejections)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rejections: %v", err)
+		}
+	}
+
+	for _, shadow := range s.shadows {
+		shadowReport, err := shadow.Build(ctx, period, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build shadow %s: %v", shadow.positionRepo.Account(), err)
+		}
+		report.Shadows = append(report.Shadows, shadowReport.Standing())
+	}
+
+	return report, nil
+}
+
+// summarizeTags groups closed positions by tag, most traded tag first
+func summarizeTags(closed []models.Position, tags map[uint][]string) []TagSummary {
+	byTag := make(map[string]*TagSummary)
+	for _, position := range closed {
+		for _, tag := range tags[position.ID] {
+			summary, ok := byTag[tag]
+			if !ok {
+				summary = &TagSummary{Tag: tag}
+				byTag[tag] = summary
+			}
+			summary.Closed++
+			summary.PnL += position.PnL
+			if position.PnL > 0 {
+				summary.Wins++
+			}
+		}
+	}
+
+	summaries := make([]TagSummary, 0, len(byTag))
+	for _, summary := range byTag {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Closed != summaries[j].Closed {
+			return summaries[i].Closed > summaries[j].Closed
+		}
+		return summaries[i].Tag < summaries[j].Tag
+	})
+	return summaries
+}
+
+// excluded reports whether any of tags is in exclude
+func excluded(tags, exclude []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(exclude, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// balanceAt returns the balance just before t from the ledger, the initial balance before any entry
+func (s *ReportService) balanceAt(t time.Time) (float64, error) {
+	latest, err := s.transactionRepo.FindLatestBefore(s.quoteAsset, t)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get balance at %s: %v", t.Format(time.RFC3339), err)
+	}
+	if latest == nil {
+		return s.initialBalance, nil
+	}
+	return latest.BalanceAfter, nil
+}
+
+// addTotalBalance values every balance of an account holding more than its quote asset
+func (s *ReportService) addTotalBalance(ctx context.Context, report *Report) error {
+	if s.account == nil {
+		return nil
+	}
+	balances, err := s.account.Balances()
+	if err != nil {
+		return err
+	}
+	if len(balances) < 2 {
+		return nil
+	}
+	if report.TotalBalance, err = s.account.TotalBalance(ctx); err != nil {
+		return fmt.Errorf("failed to value balances: %v", err)
+	}
+	return nil
+}
+
+// convert values pnl of a position in symbol in the report's quote asset at the latest prices
+func (s *ReportService) convert(ctx context.Context, symbol string, pnl float64) (float64, error) {
+	converted, err := trading.Convert(ctx, s.priceRepo, pnl, trading.QuoteOf(symbol, s.quoteAsset), s.quoteAsset)
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert PnL of %s: %v", symbol, err)
+	}
+	return converted, nil
+}
+
+// addOpenPositions marks every open position to the latest 5m close
+func (s *ReportService) addOpenPositions(ctx context.Context, report *Report) error {
+	positions, err := s.positionRepo.FindOpenPositions()
+	if err != nil {
+		return fmt.Errorf("failed to get open positions: %v", err)
+	}
+
+	for i := range positions {
+		position := &positions[i]
+		open := OpenPosition{
+			Symbol:     position.Symbol,
+			Side:       position.Side,
+			Size:       position.Size,
+			EntryPrice: position.EntryPrice,
+			OpenTime:   position.OpenTime,
+		}
+
+		latest, err := s.priceRepo.GetLatestPriceByTimeFrame(position.Symbol, models.PriceTimeFrame5m)
+		if err != nil {
+			return fmt.Errorf("failed to get latest price for %s: %v", position.Symbol, err)
+		}
+		if latest != nil {
+			open.MarkPrice = latest.Close
+			open.UnrealizedPnL, err = s.convert(ctx, position.Symbol, trading.PositionPnL(position, latest.Close))
+			if err != nil {
+				return err
+			}
+			report.UnrealizedPnL += open.UnrealizedPnL
+		}
+
+		report.OpenPositions = append(report.OpenPositions, open)
+	}
+	return nil
+}
+
+// Send renders the report and delivers it through every notification channel
+func (s *ReportService) Send(report *Report) error {
+	html, err := RenderHTML(report)
+	if err != nil {
+		return err
+	}
+	s.notifier.SendReport(report.Title(), RenderText(report), html)
+	return nil
+}
@@ -0,0 +1,210 @@
+package reports
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/trading"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	newYork = time.FixedZone("EST", -5*60*60)
+	tokyo   = time.FixedZone("JST", 9*60*60)
+)
+
+func utc(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestPeriodsAreUTC(t *testing.T) {
+	tests := []struct {
+		name   string
+		period Period
+		start  time.Time
+		label  string
+	}{
+		// 19:30 on April 30th in New York is already May 1st in UTC
+		{"day behind UTC", DayPeriod(time.Date(2024, 4, 30, 19, 30, 0, 0, newYork)), utc("2024-05-01 00:00"), "2024-05-01"},
+		// 08:00 on May 2nd in Tokyo is still May 1st in UTC
+		{"day ahead of UTC", DayPeriod(time.Date(2024, 5, 2, 8, 0, 0, 0, tokyo)), utc("2024-05-01 00:00"), "2024-05-01"},
+		{"last instant of the day", DayPeriod(utc("2024-05-01 23:59").Add(59*time.Second + 999*time.Millisecond)), utc("2024-05-01 00:00"), "2024-05-01"},
+		{"week from its Sunday", WeekPeriod(utc("2024-05-05 23:00")), utc("2024-04-29 00:00"), "2024-W18"},
+		{"week from a Sunday evening in New York", WeekPeriod(time.Date(2024, 5, 5, 20, 0, 0, 0, newYork)), utc("2024-05-06 00:00"), "2024-W19"},
+		{"week across the new year", WeekPeriod(utc("2025-01-01 12:00")), utc("2024-12-30 00:00"), "2025-W01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			length := 24 * time.Hour
+			if tt.period.Kind == PeriodWeek {
+				length *= 7
+			}
+			if !tt.period.Start.Equal(tt.start) || tt.period.End.Sub(tt.period.Start) != length || tt.period.Start.Location() != time.UTC {
+				t.Errorf("period %s to %s, want %s for %s in UTC", tt.period.Start, tt.period.End, tt.start, length)
+			}
+			if got := tt.period.Label(); got != tt.label {
+				t.Errorf("Label() = %q, want %q", got, tt.label)
+			}
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"before the time", utc("2024-05-01 00:04"), utc("2024-05-01 00:05")},
+		{"at the time", utc("2024-05-01 00:05"), utc("2024-05-02 00:05")},
+		{"after the time", utc("2024-05-01 12:00"), utc("2024-05-02 00:05")},
+		// 20:00 in New York on April 30th is 01:00 UTC on May 1st, past the run
+		{"outside UTC", time.Date(2024, 4, 30, 20, 0, 0, 0, newYork), utc("2024-05-02 00:05")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextRun(tt.now, DefaultReportTime); !got.Equal(tt.want) {
+				t.Errorf("NextRun(%s) = %s, want %s", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	if got, err := ParseTimeOfDay("18:30"); err != nil || got != 18*time.Hour+30*time.Minute {
+		t.Errorf("ParseTimeOfDay(18:30) = %v, %v", got, err)
+	}
+	for _, value := range []string{"", "24:00", "6pm", "18:30:00"} {
+		if _, err := ParseTimeOfDay(value); err == nil {
+			t.Errorf("ParseTimeOfDay(%q) accepted", value)
+		}
+	}
+}
+
+func TestRenderEmptyDay(t *testing.T) {
+	report := &Report{
+		Period:         DayPeriod(utc("2024-05-01 12:00")),
+		QuoteAsset:     "USDT",
+		OpeningBalance: 1000,
+		ClosingBalance: 1000,
+	}
+	if !report.Empty() {
+		t.Fatal("a report without trades is not Empty()")
+	}
+
+	text := RenderText(report)
+	html, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	for name, rendered := range map[string]string{"text": text, "html": html} {
+		for _, want := range []string{"2024-05-01 00:00 to 2024-05-02 00:00 UTC", "No trades", "1000.00"} {
+			if !strings.Contains(rendered, want) {
+				t.Errorf("%s report does not contain %q:\n%s", name, want, rendered)
+			}
+		}
+		for _, unwanted := range []string{"Trades opened", "Open positions", "rejection"} {
+			if strings.Contains(rendered, unwanted) {
+				t.Errorf("empty %s report contains %q:\n%s", name, unwanted, rendered)
+			}
+		}
+	}
+	if got := report.Title(); got != "Daily summary 2024-05-01" {
+		t.Errorf("Title() = %q", got)
+	}
+}
+
+func TestSummarizeTags(t *testing.T) {
+	closed := []models.Position{{ID: 1, PnL: 30}, {ID: 2, PnL: -4}, {ID: 3, PnL: 5}, {ID: 4, PnL: -2}}
+	tags := map[uint][]string{1: {"bad fill", "news spike"}, 2: {"bad fill"}, 4: {"late entry"}}
+
+	want := []TagSummary{
+		{Tag: "bad fill", Closed: 2, Wins: 1, PnL: 26},
+		{Tag: "late entry", Closed: 1, Wins: 0, PnL: -2}, // Ties go alphabetically
+		{Tag: "news spike", Closed: 1, Wins: 1, PnL: 30},
+	}
+	if got := summarizeTags(closed, tags); !slices.Equal(got, want) {
+		t.Errorf("summarizeTags() = %+v, want %+v", got, want)
+	}
+	if got := summarizeTags(closed, nil); len(got) != 0 {
+		t.Errorf("summarizeTags() without tags = %+v, want none", got)
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	exclude := []string{"news spike"}
+	if !excluded([]string{"bad fill", "news spike"}, exclude) {
+		t.Error("a trade carrying an excluded tag was kept")
+	}
+	if excluded([]string{"bad fill"}, exclude) || excluded(nil, exclude) || excluded([]string{"news spike"}, nil) {
+		t.Error("a trade without an excluded tag was left out")
+	}
+}
+
+func TestRenderComparesShadows(t *testing.T) {
+	report := &Report{
+		Account:        "primary",
+		Period:         DayPeriod(utc("2024-05-01 12:00")),
+		QuoteAsset:     "USDT",
+		OpeningBalance: 1000,
+		ClosingBalance: 1010,
+		Closed:         2,
+		RealizedPnL:    10,
+		Shadows:        []AccountEquity{{Account: "candidate", Closed: 1, RealizedPnL: -4, Equity: 996}},
+	}
+	if got := report.Standing(); got != (AccountEquity{Account: "primary", Closed: 2, RealizedPnL: 10, Equity: 1010}) {
+		t.Errorf("Standing() = %+v", got)
+	}
+
+	text := RenderText(report)
+	html, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	for _, want := range []string{"Primary vs shadows", "primary: equity 1010.00 USDT, 2 trades closed, +10.00 USDT", "candidate: equity 996.00 USDT, 1 trades closed, -4.00 USDT"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, want := range []string{"Primary vs shadows", "candidate", "996.00"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html report does not contain %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestRenderBreaksOutConfidenceBuckets(t *testing.T) {
+	report := &Report{
+		Period:         DayPeriod(utc("2024-05-01 12:00")),
+		QuoteAsset:     "USDT",
+		OpeningBalance: 1000,
+		ClosingBalance: 1003,
+		Closed:         3,
+		RealizedPnL:    3,
+		Confidence: []trading.ConfidenceBucket{
+			{Label: "0.70 to 0.80", Trades: 2, Wins: 1, WinRate: 0.5, PnL: -1, AverageR: -0.5, SizeMultiplier: 0.7},
+			{Label: "0.90 and up", Trades: 1, Wins: 1, WinRate: 1, PnL: 4, AverageR: 2, SizeMultiplier: 1.4},
+		},
+	}
+
+	text := RenderText(report)
+	html, err := RenderHTML(report)
+	if err != nil {
+		t.Fatalf("RenderHTML() error = %v", err)
+	}
+	for _, want := range []string{"By confidence:", "0.70 to 0.80: 2 trades (1 won), -1.00 USDT, average -0.50R, size x0.70", "0.90 and up: 1 trades (1 won), +4.00 USDT, average 2.00R, size x1.40"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text report does not contain %q:\n%s", want, text)
+		}
+	}
+	for _, want := range []string{"By confidence", "0.90 and up", "2.00R", "x1.40"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("html report does not contain %q:\n%s", want, html)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package reports
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// DefaultReportTime is when the daily summary goes out, as an offset from UTC midnight
+const DefaultReportTime = 5 * time.Minute
+
+// ParseTimeOfDay reads an HH:MM UTC time as an offset from midnight
+func ParseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", value)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// NextRun returns the first time after now that is at after a UTC midnight
+func NextRun(now time.Time, at time.Duration) time.Time {
+	next := now.UTC().Truncate(24 * time.Hour).Add(at)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// Run sends the previous day's summary every day at the given UTC time of day, and the
+// previous ISO week's summary on Mondays, until ctx is cancelled
+func (s *ReportService) Run(ctx context.Context, at time.Duration) {
+	for {
+		next := NextRun(time.Now(), at)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// The run belongs to the day it fires on, so report the one before it
+		previous := next.AddDate(0, 0, -1)
+		s.deliver(ctx, DayPeriod(previous))
+		if next.Weekday() == time.Monday {
+			s.deliver(ctx, WeekPeriod(previous))
+		}
+	}
+}
+
+func (s *ReportService) deliver(ctx context.Context, period Period) {
+	report, err := s.Build(ctx, period, time.Now())
+	if err != nil {
+		log.Printf("Error building %s report %s: %v", period.Kind, period.Label(), err)
+		return
+	}
+	if err := s.Send(report); err != nil {
+		log.Printf("Error sending %s report %s: %v", period.Kind, period.Label(), err)
+	}
+}
@@ -0,0 +1,157 @@
+// Package provenance records which build and settings produced a backtest run or a live position
+package provenance
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Version is the git revision the binary was built from, injected at build time with
+//
+//	go build -ldflags "-X CryptoTradeBot/internal/provenance.Version=$(git rev-parse --short HEAD)"
+var Version = "dev"
+
+// ShortHash is how many characters of a hash are shown
+const ShortHash = 12
+
+// Snapshot is the build and settings behind a result, Hash identifying both
+type Snapshot struct {
+	Hash    string          `json:"hash"`
+	Version string          `json:"version"`
+	Config  json.RawMessage `json:"config"` // Canonical JSON, see Canonical
+}
+
+// New snapshots config as run by this build
+func New(config any) (*Snapshot, error) {
+	return NewWithVersion(Version, config)
+}
+
+// NewWithVersion snapshots config as run by the build at version
+// The same version and settings always hash the same, whatever order they were declared in
+func NewWithVersion(version string, config any) (*Snapshot, error) {
+	canonical, err := Canonical(config)
+	if err != nil {
+		return nil, err
+	}
+	return &Snapshot{Hash: Hash(version, canonical), Version: version, Config: canonical}, nil
+}
+
+// Hash returns the hex SHA-256 of version and the canonical settings
+func Hash(version string, canonical []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(version))
+	sum.Write([]byte{0})
+	sum.Write(canonical)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// Short returns the hash shortened for display
+func (s *Snapshot) Short() string {
+	if s == nil {
+		return "unknown"
+	}
+	if len(s.Hash) > ShortHash {
+		return s.Hash[:ShortHash]
+	}
+	return s.Hash
+}
+
+// Canonical encodes v as compact JSON with object keys sorted at every level
+// Numbers keep the digits encoding/json gave them, so equal values encode to equal bytes
+func Canonical(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %v", err)
+	}
+	value, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	// Maps marshal with sorted keys, which makes the re-encoded generic value canonical
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %v", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// Diff lists what changed from a to b, one "path: old -> new" line per setting, sorted by path
+func Diff(a, b *Snapshot) ([]string, error) {
+	var lines []string
+	if a.Version != b.Version {
+		lines = append(lines, fmt.Sprintf("version: %s -> %s", a.Version, b.Version))
+	}
+
+	va, err := decode(a.Config)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := decode(b.Config)
+	if err != nil {
+		return nil, err
+	}
+	var changes []string
+	diffValues("", va, vb, &changes)
+	sort.Strings(changes)
+	return append(lines, changes...), nil
+}
+
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %v", err)
+	}
+	return value, nil
+}
+
+func diffValues(path string, a, b any, changes *[]string) {
+	ma, aIsMap := a.(map[string]any)
+	mb, bIsMap := b.(map[string]any)
+	if aIsMap && bIsMap {
+		keys := make(map[string]bool, len(ma)+len(mb))
+		for key := range ma {
+			keys[key] = true
+		}
+		for key := range mb {
+			keys[key] = true
+		}
+		for key := range keys {
+			child := key
+			if path != "" {
+				child = path + "." + key
+			}
+			va, inA := ma[key]
+			vb, inB := mb[key]
+			switch {
+			case !inA:
+				*changes = append(*changes, fmt.Sprintf("%s: (unset) -> %s", child, describe(vb)))
+			case !inB:
+				*changes = append(*changes, fmt.Sprintf("%s: %s -> (unset)", child, describe(va)))
+			default:
+				diffValues(child, va, vb, changes)
+			}
+		}
+		return
+	}
+
+	if da, db := describe(a), describe(b); da != db {
+		*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", path, da, db))
+	}
+}
+
+// describe formats a decoded value as its canonical JSON
+func describe(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
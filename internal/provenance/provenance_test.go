@@ -0,0 +1,85 @@
+package provenance_test
+
+import (
+	"CryptoTradeBot/internal/provenance"
+	"CryptoTradeBot/internal/services/strategy"
+	"reflect"
+	"testing"
+)
+
+func snapshot(t *testing.T, version string, config any) *provenance.Snapshot {
+	t.Helper()
+	s, err := provenance.NewWithVersion(version, config)
+	if err != nil {
+		t.Fatalf("NewWithVersion() error = %v", err)
+	}
+	return s
+}
+
+func TestSameParamsHashTheSame(t *testing.T) {
+	// Overrides declared in another order, the maps iterating differently
+	settings := func(symbols ...string) strategy.Settings {
+		s := strategy.Settings{Default: strategy.DefaultParams(), Symbols: make(map[string]strategy.Params)}
+		for _, symbol := range symbols {
+			params := strategy.DefaultParams()
+			params.MinConfidence = 0.8
+			s.Symbols[symbol] = params
+		}
+		return s
+	}
+	first := snapshot(t, "abc1234", settings("BTCUSDT", "ETHUSDT", "SOLUSDT"))
+	for i := 0; i < 20; i++ {
+		again := snapshot(t, "abc1234", settings("SOLUSDT", "ETHUSDT", "BTCUSDT"))
+		if again.Hash != first.Hash || string(again.Config) != string(first.Config) {
+			t.Fatalf("the same settings hashed %s then %s", first.Hash, again.Hash)
+		}
+	}
+	if len(first.Hash) != 64 || first.Short() != first.Hash[:provenance.ShortHash] {
+		t.Errorf("Hash %q, Short %q, want a hex SHA-256 shown by its first %d characters", first.Hash, first.Short(), provenance.ShortHash)
+	}
+}
+
+func TestChangedSettingsChangeTheHash(t *testing.T) {
+	base := snapshot(t, "abc1234", strategy.DefaultParams())
+
+	stricter := strategy.DefaultParams()
+	stricter.MinConfidence += 0.05
+	changed := snapshot(t, "abc1234", stricter)
+	if changed.Hash == base.Hash {
+		t.Fatal("a changed min_confidence kept the hash")
+	}
+	changes, err := provenance.Diff(base, changed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"min_confidence: 0.7 -> 0.75"}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Diff() = %v, want %v", changes, want)
+	}
+
+	// Another build of the same settings is another provenance
+	rebuilt := snapshot(t, "def5678", strategy.DefaultParams())
+	if rebuilt.Hash == base.Hash {
+		t.Fatal("another version kept the hash")
+	}
+	if changes, _ := provenance.Diff(base, rebuilt); !reflect.DeepEqual(changes, []string{"version: abc1234 -> def5678"}) {
+		t.Errorf("Diff() = %v, want only the version", changes)
+	}
+}
+
+func TestCanonicalSortsKeysAtEveryLevel(t *testing.T) {
+	type inner struct {
+		Zeta  int `json:"zeta"`
+		Alpha int `json:"alpha"`
+	}
+	got, err := provenance.Canonical(struct {
+		B inner          `json:"b"`
+		A map[string]int `json:"a"`
+	}{B: inner{Zeta: 1, Alpha: 2}, A: map[string]int{"y": 1, "x": 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":{"x":2,"y":1},"b":{"alpha":2,"zeta":1}}`; string(got) != want {
+		t.Errorf("Canonical() = %s, want %s", got, want)
+	}
+}
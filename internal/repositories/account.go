@@ -0,0 +1,9 @@
+package repositories
+
+import "gorm.io/gorm"
+
+// scopeAccount returns a session whose every query is restricted to one account's rows
+// The session is safe to reuse, each statement built from it starts with only the account condition
+func scopeAccount(db *gorm.DB, account string) *gorm.DB {
+	return db.Where("account = ?", account).Session(&gorm.Session{})
+}
@@ -3,17 +3,33 @@ package repositories
 import (
 	"CryptoTradeBot/internal/models"
 	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BalanceRepository struct {
-	db *gorm.DB
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
 }
 
 // NewBalanceRepository creates a new instance of BalanceRepository
+// Its queries see only the default account, use ForAccount for another
 func NewBalanceRepository(db *gorm.DB) *BalanceRepository {
-	return &BalanceRepository{db: db}
+	return &BalanceRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a BalanceRepository reading and writing only the given account's rows
+func (r *BalanceRepository) ForAccount(account string) *BalanceRepository {
+	return &BalanceRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *BalanceRepository) Account() string {
+	return r.account
 }
 
 // Create adds a new Balance record to the database
@@ -21,6 +37,7 @@ func (r *BalanceRepository) Create(balance *models.Balance) error {
 	if balance == nil {
 		return errors.New("balance cannot be nil")
 	}
+	balance.Account = r.account
 	return r.db.Create(balance).Error
 }
 
@@ -73,6 +90,13 @@ func (r *BalanceRepository) FindBySymbol(symbol string) (*models.Balance, error)
 	return &balance, err
 }
 
+// Accounts lists every account holding a balance, across all accounts
+func (r *BalanceRepository) Accounts() ([]string, error) {
+	var accounts []string
+	err := r.base.Model(&models.Balance{}).Distinct("account").Order("account ASC").Pluck("account", &accounts).Error
+	return accounts, err
+}
+
 // FindByUserID retrieves all balances for a specific user
 func (r *BalanceRepository) FindByUserID(userID uint) ([]models.Balance, error) {
 	var balances []models.Balance
@@ -88,3 +112,76 @@ func (r *BalanceRepository) UpdateAmount(id uint, amount float64) error {
 	return r.db.Model(&models.Balance{}).Where("id = ?", id).
 		Update("amount", amount).Error
 }
+
+// ApplyChange adds amount to the balance for symbol and records a Transaction for it
+// Both writes happen in one database transaction so the ledger cannot drift from the balance
+func (r *BalanceRepository) ApplyChange(symbol, txType string, amount float64, positionID *uint) (*models.Balance, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+
+	var balance *models.Balance
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		balance, err = applyBalanceChange(tx, symbol, txType, amount, positionID, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// applyBalanceChange updates the balance and writes its Transaction inside tx
+// A non-empty key makes the change apply once: when a Transaction with that key exists,
+// the balance is returned untouched
+func applyBalanceChange(tx *gorm.DB, symbol, txType string, amount float64, positionID *uint, key string) (*models.Balance, error) {
+	var balance models.Balance
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("symbol = ?", symbol).
+		First(&balance).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %v", err)
+	}
+
+	// The balance lock serializes retries, so the check cannot race the insert below
+	var idempotencyKey *string
+	if key != "" {
+		var applied int64
+		if err := tx.Model(&models.Transaction{}).Where("idempotency_key = ?", key).Count(&applied).Error; err != nil {
+			return nil, fmt.Errorf("failed to check transaction %s: %v", key, err)
+		}
+		if applied > 0 {
+			return &balance, nil
+		}
+		idempotencyKey = &key
+	}
+
+	// Amounts are kept at the columns' precision so float error cannot compound in the balance
+	amount = models.RoundAmount(amount)
+	before := balance.Balance
+	balance.Balance = models.RoundAmount(balance.Balance + amount)
+	balance.LastUpdated = time.Now()
+
+	if err := tx.Save(&balance).Error; err != nil {
+		return nil, fmt.Errorf("failed to update balance: %v", err)
+	}
+
+	err = tx.Create(&models.Transaction{
+		Account:       balance.Account,
+		PositionID:    positionID,
+		Symbol:        symbol,
+		Type:          txType,
+		Amount:        amount,
+		BalanceBefore: before,
+		BalanceAfter:  balance.Balance,
+
+		IdempotencyKey: idempotencyKey,
+	}).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to record transaction: %v", err)
+	}
+
+	return &balance, nil
+}
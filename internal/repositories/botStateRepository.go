@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BotStateRepository struct {
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
+}
+
+// NewBotStateRepository creates a new instance of BotStateRepository
+// Its queries see only the default account, use ForAccount for another
+func NewBotStateRepository(db *gorm.DB) *BotStateRepository {
+	return &BotStateRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a BotStateRepository reading and writing only the given account's rows
+func (r *BotStateRepository) ForAccount(account string) *BotStateRepository {
+	return &BotStateRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *BotStateRepository) Account() string {
+	return r.account
+}
+
+// Save stores value as JSON under key with its layout version, replacing what was there
+func (r *BotStateRepository) Save(key string, version int, value interface{}) error {
+	if key == "" {
+		return errors.New("invalid key")
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode state %s: %v", key, err)
+	}
+	state := &models.BotState{
+		Account: r.account,
+		Key:     key,
+		Version: version,
+		Value:   string(data),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "account"}, {Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":    version,
+			"value":      state.Value,
+			"updated_at": time.Now(),
+		}),
+	}).Create(state).Error
+}
+
+// Load decodes the value stored under key into dest, reporting whether there was one
+// A value saved with another version, or one that no longer decodes, is deleted and reported missing
+func (r *BotStateRepository) Load(key string, version int, dest interface{}) (bool, error) {
+	if key == "" {
+		return false, errors.New("invalid key")
+	}
+	var state models.BotState
+	err := r.db.Where("key = ?", key).First(&state).Error
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if state.Version != version {
+		log.Printf("Discarding %s state %s: version %d, want %d", r.account, key, state.Version, version)
+		return false, r.Delete(key)
+	}
+	if err := json.Unmarshal([]byte(state.Value), dest); err != nil {
+		log.Printf("Discarding %s state %s: %v", r.account, key, err)
+		return false, r.Delete(key)
+	}
+	return true, nil
+}
+
+// Delete removes the value stored under key
+func (r *BotStateRepository) Delete(key string) error {
+	if key == "" {
+		return errors.New("invalid key")
+	}
+	return r.db.Where("key = ?", key).Delete(&models.BotState{}).Error
+}
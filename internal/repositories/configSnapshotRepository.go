@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConfigSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewConfigSnapshotRepository creates a new instance of ConfigSnapshotRepository
+func NewConfigSnapshotRepository(db *gorm.DB) *ConfigSnapshotRepository {
+	return &ConfigSnapshotRepository{db: db}
+}
+
+// Save stores snapshot unless one with its hash already is
+func (r *ConfigSnapshotRepository) Save(snapshot *models.ConfigSnapshot) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(snapshot).Error
+}
+
+// Find retrieves the snapshot with hash, nil when there is none
+func (r *ConfigSnapshotRepository) Find(hash string) (*models.ConfigSnapshot, error) {
+	var snapshot models.ConfigSnapshot
+	err := r.db.Where("hash = ?", hash).First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Models lists every table the bot uses, in migration order
+var Models = []interface{}{
+	&models.Price{},
+	&models.Position{},
+	&models.PositionTag{},
+	&models.StopChange{},
+	&models.ConfigSnapshot{},
+	&models.Balance{},
+	&models.Transaction{},
+	&models.PendingOrder{},
+	&models.SymbolSuspension{},
+	&models.SignalTally{},
+	&models.BotState{},
+	&models.UniverseSnapshot{},
+}
+
+// DSNFromEnv builds the Postgres DSN from the DB_* environment variables
+func DSNFromEnv() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_PORT"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"))
+}
+
+// DatabaseOptions tunes the connection OpenDatabaseWithOptions opens
+type DatabaseOptions struct {
+	// PrepareStmt caches a prepared statement per query, saving the planning of the repeated series queries
+	PrepareStmt bool
+	// Pool limits, each left to the driver when 0
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// QueryTimeout bounds each query of the hot path methods taking a context, DefaultQueryTimeout when 0
+	QueryTimeout time.Duration
+}
+
+// Pool defaults, sized for the recorder, the analysis pool and the dashboard sharing one database
+const (
+	DefaultMaxOpenConns    = 25
+	DefaultMaxIdleConns    = 10
+	DefaultConnMaxLifetime = 30 * time.Minute
+)
+
+// DatabaseOptionsFromEnv reads the options from the environment, DB_PREPARE_STATEMENTS=true enables PrepareStmt
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME and DB_QUERY_TIMEOUT override the pool defaults
+// and the query timeout, durations written like 30m or 5s
+func DatabaseOptionsFromEnv() (DatabaseOptions, error) {
+	prepare, _ := strconv.ParseBool(os.Getenv("DB_PREPARE_STATEMENTS"))
+	options := DatabaseOptions{
+		PrepareStmt:     prepare,
+		MaxOpenConns:    DefaultMaxOpenConns,
+		MaxIdleConns:    DefaultMaxIdleConns,
+		ConnMaxLifetime: DefaultConnMaxLifetime,
+		QueryTimeout:    DefaultQueryTimeout,
+	}
+
+	var err error
+	if options.MaxOpenConns, err = intFromEnv("DB_MAX_OPEN_CONNS", options.MaxOpenConns); err != nil {
+		return options, err
+	}
+	if options.MaxIdleConns, err = intFromEnv("DB_MAX_IDLE_CONNS", options.MaxIdleConns); err != nil {
+		return options, err
+	}
+	if options.ConnMaxLifetime, err = durationFromEnv("DB_CONN_MAX_LIFETIME", options.ConnMaxLifetime); err != nil {
+		return options, err
+	}
+	if options.QueryTimeout, err = durationFromEnv("DB_QUERY_TIMEOUT", options.QueryTimeout); err != nil {
+		return options, err
+	}
+	return options, nil
+}
+
+// intFromEnv parses the non-negative integer in the environment variable key, fallback when it is unset
+func intFromEnv(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return n, nil
+}
+
+// durationFromEnv parses the non-negative duration in the environment variable key, fallback when it is unset
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return d, nil
+}
+
+// OpenDatabase connects to the Postgres database at dsn and migrates every table in Models
+// The returned connection only logs errors
+func OpenDatabase(dsn string) (*gorm.DB, error) {
+	return OpenDatabaseWithOptions(dsn, DatabaseOptions{})
+}
+
+// OpenDatabaseWithOptions is OpenDatabase with the connection tuned by options
+// Migrating also creates indexes added since, such as idx_prices_series, on existing deployments
+// An idx_prices_series built before it was unique is rebuilt, see migratePriceSeriesKey
+func OpenDatabaseWithOptions(dsn string, options DatabaseOptions) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{PrepareStmt: options.PrepareStmt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %v", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database pool: %v", err)
+	}
+	if options.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(options.MaxOpenConns)
+	}
+	if options.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(options.MaxIdleConns)
+	}
+	if options.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(options.ConnMaxLifetime)
+	}
+	if options.QueryTimeout > 0 {
+		queryTimeout = options.QueryTimeout
+	}
+
+	if err := migratePriceSeriesKey(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
+	if err := db.AutoMigrate(Models...); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %v", err)
+	}
+
+	db.Logger = db.Logger.LogMode(logger.Error)
+	return db, nil
+}
+
+// migratePriceSeriesKey prepares a prices table whose idx_prices_series is missing or not unique for the
+// unique one AutoMigrate creates: candles stored more than once at the same open time are reduced to the
+// last saved, a soft deleted copy going first, and the old index is dropped
+func migratePriceSeriesKey(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Price{}) {
+		return nil
+	}
+	var unique bool
+	err := db.Raw(`SELECT EXISTS (SELECT 1 FROM pg_indexes
+		WHERE tablename = 'prices' AND indexname = 'idx_prices_series' AND indexdef LIKE 'CREATE UNIQUE%')`).
+		Scan(&unique).Error
+	if err != nil || unique {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`DELETE FROM prices WHERE id IN (
+			SELECT id FROM (
+				SELECT id, row_number() OVER (
+					PARTITION BY symbol, time_frame, open_time, exchange
+					ORDER BY deleted_at IS NULL DESC, updated_at DESC, id DESC) AS copy
+				FROM prices) AS copies
+			WHERE copy > 1)`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("DROP INDEX IF EXISTS idx_prices_series").Error
+	})
+}
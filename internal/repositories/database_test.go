@@ -0,0 +1,64 @@
+package repositories_test
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"testing"
+	"time"
+)
+
+func TestPrepareStatementsFromEnv(t *testing.T) {
+	tests := []struct {
+		env  string
+		want bool
+	}{
+		{"", false},
+		{"true", true},
+		{"1", true},
+		{"false", false},
+		{"yes", false}, // Not a bool strconv reads, so left off
+	}
+	for _, tt := range tests {
+		t.Setenv("DB_PREPARE_STATEMENTS", tt.env)
+		options, err := repositories.DatabaseOptionsFromEnv()
+		if err != nil || options.PrepareStmt != tt.want {
+			t.Errorf("DB_PREPARE_STATEMENTS=%q: PrepareStmt = %v, %v, want %v", tt.env, options.PrepareStmt, err, tt.want)
+		}
+	}
+}
+
+func TestPoolSettingsFromEnv(t *testing.T) {
+	options, err := repositories.DatabaseOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.MaxOpenConns != repositories.DefaultMaxOpenConns || options.MaxIdleConns != repositories.DefaultMaxIdleConns ||
+		options.ConnMaxLifetime != repositories.DefaultConnMaxLifetime || options.QueryTimeout != repositories.DefaultQueryTimeout {
+		t.Errorf("DatabaseOptionsFromEnv() = %+v without overrides, want the defaults", options)
+	}
+
+	t.Setenv("DB_MAX_OPEN_CONNS", "8")
+	t.Setenv("DB_MAX_IDLE_CONNS", "0")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "5m")
+	t.Setenv("DB_QUERY_TIMEOUT", "2s")
+	options, err = repositories.DatabaseOptionsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options.MaxOpenConns != 8 || options.MaxIdleConns != 0 || options.ConnMaxLifetime != 5*time.Minute || options.QueryTimeout != 2*time.Second {
+		t.Errorf("DatabaseOptionsFromEnv() = %+v, want the overrides", options)
+	}
+
+	for key, value := range map[string]string{
+		"DB_MAX_OPEN_CONNS":    "-1",
+		"DB_MAX_IDLE_CONNS":    "many",
+		"DB_CONN_MAX_LIFETIME": "30",
+		"DB_QUERY_TIMEOUT":     "-5s",
+	} {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, value)
+			if _, err := repositories.DatabaseOptionsFromEnv(); err == nil {
+				t.Errorf("%s=%q accepted", key, value)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedBalance stores a USDT balance of amount for the default account
+func seedBalance(t *testing.T, db *gorm.DB, amount float64) *repositories.BalanceRepository {
+	t.Helper()
+	balances := repositories.NewBalanceRepository(db)
+	if err := balances.Create(&models.Balance{Symbol: "USDT", Balance: amount, LastUpdated: testdb.FixtureStart}); err != nil {
+		t.Fatal(err)
+	}
+	return balances
+}
+
+// openPosition stores an open long on symbol entered at entry
+func openPosition(t *testing.T, positions *repositories.PositionRepository, symbol string, entry float64) *models.Position {
+	t.Helper()
+	position := &models.Position{
+		Symbol:          symbol,
+		Side:            models.PositionSideLong,
+		Size:            0.01,
+		Leverage:        50,
+		EntryPrice:      entry,
+		StopLossPrice:   entry * 0.99,
+		TakeProfitPrice: entry * 1.01,
+		OpenTime:        testdb.FixtureStart,
+		Status:          models.PositionStatusOpen,
+	}
+	if err := positions.Create(context.Background(), position); err != nil {
+		t.Fatal(err)
+	}
+	return position
+}
+
+// closing returns a copy of position closed for reason with pnl
+func closing(position *models.Position, reason string, pnl float64) *models.Position {
+	closed := *position
+	closed.Status = models.PositionStatusClosed
+	closed.CloseReason = reason
+	closed.CloseTime = testdb.FixtureStart.Add(time.Hour)
+	closed.PnL = pnl
+	return &closed
+}
+
+func usdt(t *testing.T, balances *repositories.BalanceRepository) float64 {
+	t.Helper()
+	balance, err := balances.FindBySymbol("USDT")
+	if err != nil || balance == nil {
+		t.Fatalf("FindBySymbol(USDT) = %v, %v", balance, err)
+	}
+	return balance.Balance
+}
+
+func TestSeededFixtureStreamsEverySeries(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Seeded(t))
+	end := testdb.FixtureStart.Add(testdb.FixtureDays * 24 * time.Hour)
+	for symbol := range testdb.FixtureSymbols {
+		for timeFrame, interval := range models.TimeFrameDurations {
+			count := 0
+			err := prices.StreamPricesByTimeFrame(symbol, timeFrame, testdb.FixtureStart, end, func(models.Price) error {
+				count++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("StreamPricesByTimeFrame(%s, %s) error = %v", symbol, timeFrame, err)
+			}
+			if want := int(testdb.FixtureDays * 24 * time.Hour / interval); count != want {
+				t.Errorf("%s-%s has %d candles, want %d", symbol, timeFrame, count, want)
+			}
+		}
+	}
+}
+
+func TestPositionCloseBooksPnLOnce(t *testing.T) {
+	db := testdb.Open(t)
+	balances := seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	position := openPosition(t, positions, "BTCUSDT", 40000)
+
+	if _, err := positions.Close(closing(position, "take_profit", 4), "USDT"); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	// A retry of the same close, its outcome lost, must neither save nor book it again
+	if _, err := positions.Close(closing(position, "take_profit", 4), "USDT"); !errors.Is(err, repositories.ErrPositionNotOpen) {
+		t.Errorf("second Close() error = %v, want ErrPositionNotOpen", err)
+	}
+
+	if got := usdt(t, balances); got != 104 {
+		t.Errorf("balance = %v, want 104", got)
+	}
+	stored, err := positions.FindByID(position.ID)
+	if err != nil || stored.Status != models.PositionStatusClosed || stored.PnL != 4 {
+		t.Errorf("stored position = %+v, %v, want closed with PnL 4", stored, err)
+	}
+	var transactions int64
+	db.Model(&models.Transaction{}).Where("position_id = ?", position.ID).Count(&transactions)
+	if transactions != 1 {
+		t.Errorf("%d transactions booked for the close, want 1", transactions)
+	}
+}
+
+func TestPositionReverseClosesAndOpensTogether(t *testing.T) {
+	db := testdb.Open(t)
+	balances := seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	position := openPosition(t, positions, "ETHUSDT", 2500)
+
+	opening := &models.Position{
+		Symbol:          "ETHUSDT",
+		Side:            models.PositionSideShort,
+		Size:            0.2,
+		Leverage:        50,
+		EntryPrice:      2480,
+		StopLossPrice:   2505,
+		TakeProfitPrice: 2455,
+		OpenTime:        testdb.FixtureStart.Add(time.Hour),
+		Status:          models.PositionStatusOpen,
+	}
+	if _, err := positions.Reverse(closing(position, "reversal", -2), opening, "USDT"); err != nil {
+		t.Fatalf("Reverse() error = %v", err)
+	}
+
+	open, err := positions.FindOpenPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 1 || open[0].ID != opening.ID || open[0].ReversedFromID != position.ID {
+		t.Errorf("open positions = %+v, want only the reversal of %d", open, position.ID)
+	}
+	if got := usdt(t, balances); got != 98 {
+		t.Errorf("balance = %v, want 98", got)
+	}
+}
+
+func TestPositionReverseRollsBackWhenTheOpenFails(t *testing.T) {
+	db := testdb.Open(t)
+	balances := seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	position := openPosition(t, positions, "ETHUSDT", 2500)
+	other := openPosition(t, positions, "BTCUSDT", 40000)
+
+	// Reusing a stored ID makes the insert of the replacement fail inside the transaction
+	opening := *other
+	if _, err := positions.Reverse(closing(position, "reversal", -2), &opening, "USDT"); err == nil {
+		t.Fatal("Reverse() succeeded opening a duplicate position")
+	}
+
+	stored, err := positions.FindByID(position.ID)
+	if err != nil || stored.Status != models.PositionStatusOpen {
+		t.Errorf("reversed position = %+v, %v, want it still open", stored, err)
+	}
+	if got := usdt(t, balances); got != 100 {
+		t.Errorf("balance = %v, want 100 untouched", got)
+	}
+}
+
+// closedPosition stores a closed long on symbol and returns it
+func closedPosition(t *testing.T, positions *repositories.PositionRepository, symbol string, pnl float64) *models.Position {
+	t.Helper()
+	position := openPosition(t, positions, symbol, 100)
+	if _, err := positions.Close(closing(position, "take_profit", pnl), "USDT"); err != nil {
+		t.Fatal(err)
+	}
+	return position
+}
+
+func TestPositionTagsAreQueryable(t *testing.T) {
+	db := testdb.Open(t)
+	seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	spiked := closedPosition(t, positions, "BTCUSDT", 4)
+	plain := closedPosition(t, positions, "ETHUSDT", -2)
+	open := openPosition(t, positions, "SOLUSDT", 100)
+
+	for _, tag := range []string{"News Spike", "news  spike", "bad_fill"} {
+		if err := positions.AddTag(spiked.ID, tag); err != nil {
+			t.Fatalf("AddTag(%q) error = %v", tag, err)
+		}
+	}
+	if err := positions.AddTag(plain.ID, "bad_fill"); err != nil {
+		t.Fatal(err)
+	}
+	if err := positions.AddTag(open.ID, "news spike"); err == nil {
+		t.Error("AddTag() on an open position succeeded, want an error")
+	}
+	if err := positions.AddTag(spiked.ID, "spike!"); err == nil {
+		t.Error("AddTag() with an invalid tag succeeded, want an error")
+	}
+
+	tags, err := positions.Tags([]uint{spiked.ID, plain.ID, open.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(tags[spiked.ID], []string{"bad_fill", "news spike"}) || !slices.Equal(tags[plain.ID], []string{"bad_fill"}) {
+		t.Errorf("Tags() = %v, want bad_fill and news spike once each on %d, bad_fill on %d", tags, spiked.ID, plain.ID)
+	}
+	if _, ok := tags[open.ID]; ok {
+		t.Errorf("Tags() has %v on the open position, want none", tags[open.ID])
+	}
+
+	found, err := positions.FindByTag("NEWS SPIKE")
+	if err != nil || len(found) != 1 || found[0].ID != spiked.ID {
+		t.Errorf("FindByTag(news spike) = %v, %v, want only position %d", found, err, spiked.ID)
+	}
+	if found, err := positions.FindByTag("bad_fill"); err != nil || len(found) != 2 {
+		t.Errorf("FindByTag(bad_fill) = %d positions, %v, want 2", len(found), err)
+	}
+	if found, err := positions.ForAccount("shadow").FindByTag("bad_fill"); err != nil || len(found) != 0 {
+		t.Errorf("another account's FindByTag(bad_fill) = %d positions, %v, want none", len(found), err)
+	}
+
+	if err := positions.RemoveTag(spiked.ID, "News Spike"); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := positions.FindByTag("news spike"); err != nil || len(found) != 0 {
+		t.Errorf("FindByTag(news spike) after RemoveTag = %d positions, %v, want none", len(found), err)
+	}
+
+	if err := positions.SetNotes(plain.ID, "entered into resistance"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := positions.FindByID(plain.ID)
+	if err != nil || stored.Notes != "entered into resistance" || stored.PnL != -2 || stored.Status != models.PositionStatusClosed {
+		t.Errorf("stored position = %+v, %v, want the notes set and the rest untouched", stored, err)
+	}
+}
+
+func TestConcurrentTaggingAddsEachTagOnce(t *testing.T) {
+	db := testdb.Open(t)
+	seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	position := closedPosition(t, positions, "BTCUSDT", 4)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- positions.AddTag(position.ID, "news spike")
+		}()
+		go func() {
+			defer wg.Done()
+			errs <- positions.SetNotes(position.ID, fmt.Sprintf("note %d", i))
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent journal update error = %v", err)
+		}
+	}
+
+	var rows int64
+	db.Model(&models.PositionTag{}).Where("position_id = ?", position.ID).Count(&rows)
+	if rows != 1 {
+		t.Errorf("%d rows for the tag, want 1", rows)
+	}
+	stored, err := positions.FindByID(position.ID)
+	if err != nil || !strings.HasPrefix(stored.Notes, "note ") || stored.PnL != 4 {
+		t.Errorf("stored position = %+v, %v, want one writer's notes and the close untouched", stored, err)
+	}
+}
+
+// contextPosition stores a position of symbol entered with entry context, closed with pnl unless pnl is 0
+func contextPosition(t *testing.T, positions *repositories.PositionRepository, symbol string, context *analysis.EntryContext, pnl float64) *models.Position {
+	t.Helper()
+	position := openPosition(t, positions, symbol, 100)
+	position.EntryContext = context.JSON()
+	if err := positions.Update(position); err != nil {
+		t.Fatal(err)
+	}
+	if pnl != 0 {
+		if _, err := positions.Close(closing(position, "stop_loss", pnl), "USDT"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return position
+}
+
+func TestEntryContextRoundTripsThroughTheDatabase(t *testing.T) {
+	db := testdb.Open(t)
+	seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+
+	want := &analysis.EntryContext{
+		Version:     analysis.EntryContextVersion,
+		Direction:   models.PositionSideLong,
+		Confidence:  0.82,
+		TargetMode:  analysis.TargetModePercent,
+		RSI:         61.5,
+		EMAFast:     101.2,
+		EMASlow:     100.4,
+		VolumeRatio: 0.7,
+		OBVTrend:    1,
+		POC:         99.5,
+		Pattern:     "bullish_engulfing",
+		Support:     98,
+		SuperTrend:  map[string]int{"1h": 1, "4h": -1},
+		DailyBias:   "bullish",
+		Cadence:     models.PriceTimeFrame15m,
+	}
+	position := contextPosition(t, positions, "BTCUSDT", want, -3)
+
+	stored, err := positions.FindByID(position.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := analysis.ParseEntryContext(stored.EntryContext)
+	if err != nil {
+		t.Fatalf("ParseEntryContext() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("stored context = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindClosedByContext(t *testing.T) {
+	db := testdb.Open(t)
+	seedBalance(t, db, 100)
+	positions := repositories.NewPositionRepository(db)
+	withVolume := func(ratio float64) *analysis.EntryContext {
+		return &analysis.EntryContext{Version: analysis.EntryContextVersion, Direction: models.PositionSideLong, VolumeRatio: ratio, Pattern: "hammer"}
+	}
+
+	thinLoss := contextPosition(t, positions, "BTCUSDT", withVolume(0.6), -2)
+	thickLoss := contextPosition(t, positions, "ETHUSDT", withVolume(1.8), -1)
+	thinWin := contextPosition(t, positions, "SOLUSDT", withVolume(0.9), 3)
+	contextPosition(t, positions, "XRPUSDT", withVolume(0.5), 0) // Still open
+	contextPosition(t, positions, "ADAUSDT", nil, -4)            // Opened before contexts were stored
+
+	one, two := 1.0, 2.0
+	tests := []struct {
+		name   string
+		filter repositories.ContextFilter
+		want   []uint
+	}{
+		{"losing trades on thin volume", repositories.ContextFilter{Field: "volume_ratio", Max: &one, Outcome: repositories.ContextOutcomeLoss}, []uint{thinLoss.ID}},
+		{"thin volume", repositories.ContextFilter{Field: "volume_ratio", Max: &one}, []uint{thinLoss.ID, thinWin.ID}},
+		{"volume between bounds", repositories.ContextFilter{Field: "volume_ratio", Min: &one, Max: &two}, []uint{thickLoss.ID}},
+		{"every loss with a context", repositories.ContextFilter{Outcome: repositories.ContextOutcomeLoss}, []uint{thinLoss.ID, thickLoss.ID}},
+		{"non-numeric field", repositories.ContextFilter{Field: "pattern", Min: &one}, nil},
+		{"missing field", repositories.ContextFilter{Field: "no_such_field"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := positions.FindClosedByContext(tt.filter)
+			if err != nil {
+				t.Fatalf("FindClosedByContext() error = %v", err)
+			}
+			var got []uint
+			for _, position := range found {
+				got = append(got, position.ID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindClosedByContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := positions.FindClosedByContext(repositories.ContextFilter{Outcome: "draw"}); err == nil {
+		t.Error("FindClosedByContext(draw) succeeded, want an unknown outcome")
+	}
+}
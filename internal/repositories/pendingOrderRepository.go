@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type PendingOrderRepository struct {
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
+}
+
+// NewPendingOrderRepository creates a new instance of PendingOrderRepository
+// Its queries see only the default account, use ForAccount for another
+func NewPendingOrderRepository(db *gorm.DB) *PendingOrderRepository {
+	return &PendingOrderRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a PendingOrderRepository reading and writing only the given account's rows
+func (r *PendingOrderRepository) ForAccount(account string) *PendingOrderRepository {
+	return &PendingOrderRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *PendingOrderRepository) Account() string {
+	return r.account
+}
+
+// Create adds a new PendingOrder record to the database
+func (r *PendingOrderRepository) Create(order *models.PendingOrder) error {
+	if order == nil {
+		return errors.New("pending order cannot be nil")
+	}
+	order.Account = r.account
+	return r.db.Create(order).Error
+}
+
+// Update modifies an existing PendingOrder record
+func (r *PendingOrderRepository) Update(order *models.PendingOrder) error {
+	if order == nil {
+		return errors.New("pending order cannot be nil")
+	}
+	return r.db.Save(order).Error
+}
+
+// FindPending retrieves all orders still waiting for a fill
+func (r *PendingOrderRepository) FindPending() ([]models.PendingOrder, error) {
+	var orders []models.PendingOrder
+	err := r.db.Where("status = ?", models.PendingOrderStatusPending).Find(&orders).Error
+	return orders, err
+}
+
+// FindPendingBySymbol retrieves the orders waiting for a fill on a specific symbol
+func (r *PendingOrderRepository) FindPendingBySymbol(symbol string) ([]models.PendingOrder, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+	var orders []models.PendingOrder
+	err := r.db.Where("symbol = ? AND status = ?", symbol, models.PendingOrderStatusPending).Find(&orders).Error
+	return orders, err
+}
+
+// CountByStatus returns the number of orders in each status
+func (r *PendingOrderRepository) CountByStatus() (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&models.PendingOrder{}).
+		Select("status, COUNT(*) as count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
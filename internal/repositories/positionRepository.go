@@ -2,27 +2,50 @@ package repositories
 
 import (
 	"CryptoTradeBot/internal/models"
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrPositionNotOpen is returned when closing a position something else already closed
+var ErrPositionNotOpen = errors.New("position is no longer open")
+
 type PositionRepository struct {
-	db *gorm.DB
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
 }
 
 // NewPositionRepository creates a new instance of PositionRepository
+// Its queries see only the default account, use ForAccount for another
 func NewPositionRepository(db *gorm.DB) *PositionRepository {
-	return &PositionRepository{db: db}
+	return &PositionRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a PositionRepository reading and writing only the given account's rows
+func (r *PositionRepository) ForAccount(account string) *PositionRepository {
+	return &PositionRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *PositionRepository) Account() string {
+	return r.account
 }
 
 // Create adds a new Position record to the database
-func (r *PositionRepository) Create(position *models.Position) error {
+func (r *PositionRepository) Create(ctx context.Context, position *models.Position) error {
 	if position == nil {
 		return errors.New("position cannot be nil")
 	}
-	return r.db.Create(position).Error
+	position.Account = r.account
+	db, ctx, cancel := withTimeout(ctx, r.db)
+	defer cancel()
+	return queryError(ctx, db.Create(position).Error)
 }
 
 // FindByID retrieves a Position record by its ID
@@ -68,6 +91,17 @@ func (r *PositionRepository) FindOpenPositions() ([]models.Position, error) {
 	return positions, err
 }
 
+// Checkpoint records that the monitor checked the open positions among ids at at
+// Only the checked_at column is written, so a concurrent update of other fields is never overwritten
+func (r *PositionRepository) Checkpoint(ids []uint, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Position{}).
+		Where("id IN ? AND status = ?", ids, models.PositionStatusOpen).
+		UpdateColumn("checked_at", at).Error
+}
+
 // FindClosedPositions retrieves all closed Position records
 func (r *PositionRepository) FindPositionsBySymbol(symbol string) ([]models.Position, error) {
 	if symbol == "" {
@@ -79,15 +113,80 @@ func (r *PositionRepository) FindPositionsBySymbol(symbol string) ([]models.Posi
 }
 
 // FindOpenPositionsBySymbol retrieves all open Position records for a specific symbol
-func (r *PositionRepository) FindOpenPositionsBySymbol(symbol string) ([]models.Position, error) {
+func (r *PositionRepository) FindOpenPositionsBySymbol(ctx context.Context, symbol string) ([]models.Position, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+	db, ctx, cancel := withTimeout(ctx, r.db)
+	defer cancel()
+	var positions []models.Position
+	err := db.Where("symbol = ? AND status = ?", symbol, models.PositionStatusOpen).Find(&positions).Error
+	return positions, queryError(ctx, err)
+}
+
+// FindOpenPositionsBySymbolAndSide retrieves the open Position records for one side of a symbol
+func (r *PositionRepository) FindOpenPositionsBySymbolAndSide(symbol, side string) ([]models.Position, error) {
+	if symbol == "" || side == "" {
+		return nil, errors.New("invalid symbol or side")
+	}
+	var positions []models.Position
+	err := r.db.Where("symbol = ? AND side = ? AND status = ?", symbol, side, models.PositionStatusOpen).Find(&positions).Error
+	return positions, err
+}
+
+// FindRecentClosedBySymbol retrieves up to limit positions on symbol closed after since, newest first
+func (r *PositionRepository) FindRecentClosedBySymbol(symbol string, since time.Time, limit int) ([]models.Position, error) {
 	if symbol == "" {
 		return nil, errors.New("invalid symbol")
 	}
 	var positions []models.Position
-	err := r.db.Where("symbol = ? AND status = ?", symbol, models.PositionStatusOpen).Find(&positions).Error
+	err := r.db.Where("symbol = ? AND status = ? AND close_time > ?", symbol, models.PositionStatusClosed, since).
+		Order("close_time DESC").
+		Limit(limit).
+		Find(&positions).Error
+	return positions, err
+}
+
+// FindRecentClosed retrieves the limit most recently closed positions on any symbol, newest first
+func (r *PositionRepository) FindRecentClosed(limit int) ([]models.Position, error) {
+	var positions []models.Position
+	err := r.db.Where("status = ?", models.PositionStatusClosed).
+		Order("close_time DESC").
+		Limit(limit).
+		Find(&positions).Error
 	return positions, err
 }
 
+// FindClosedBetween retrieves positions closed from start up to but not including end, oldest first
+func (r *PositionRepository) FindClosedBetween(start, end time.Time) ([]models.Position, error) {
+	var positions []models.Position
+	err := r.db.Where("status = ? AND close_time >= ? AND close_time < ?", models.PositionStatusClosed, start, end).
+		Order("close_time ASC").
+		Find(&positions).Error
+	return positions, err
+}
+
+// CountOpenedBetween counts positions opened from start up to but not including end
+func (r *PositionRepository) CountOpenedBetween(start, end time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Position{}).
+		Where("open_time >= ? AND open_time < ?", start, end).
+		Count(&count).Error
+	return count, err
+}
+
+// CountOpenedBySymbolBetween counts positions on symbol opened from start up to but not including end
+func (r *PositionRepository) CountOpenedBySymbolBetween(symbol string, start, end time.Time) (int64, error) {
+	if symbol == "" {
+		return 0, errors.New("invalid symbol")
+	}
+	var count int64
+	err := r.db.Model(&models.Position{}).
+		Where("symbol = ? AND open_time >= ? AND open_time < ?", symbol, start, end).
+		Count(&count).Error
+	return count, err
+}
+
 // FindClosedPositions retrieves all closed Position records
 func (r *PositionRepository) GetPositionsByTimeRange(start, end time.Time) ([]models.Position, error) {
 	var positions []models.Position
@@ -104,3 +203,283 @@ func (r *PositionRepository) GetTotalPnL(start, end time.Time) (float64, error)
 		Scan(&totalPnL).Error
 	return totalPnL, err
 }
+
+// CloseKey identifies the balance booking of a position close, so it is applied once
+func CloseKey(positionID uint, reason string) string {
+	return fmt.Sprintf("close:%d:%s", positionID, reason)
+}
+
+// Close saves a closed position and books its PnL against the balance for balanceSymbol in one
+// database transaction. The booking is keyed by CloseKey, so retrying a close whose outcome was
+// lost never books the PnL twice
+func (r *PositionRepository) Close(position *models.Position, balanceSymbol string) (*models.Balance, error) {
+	if position == nil {
+		return nil, errors.New("position cannot be nil")
+	}
+
+	var balance *models.Balance
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveClosing(tx, position); err != nil {
+			return err
+		}
+
+		var err error
+		balance, err = applyBalanceChange(tx, balanceSymbol, models.TransactionTypeTrade, position.PnL, &position.ID, CloseKey(position.ID, position.CloseReason))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// saveClosing stores a position being closed, failing with ErrPositionNotOpen when it was closed meanwhile
+// so concurrent closes, such as a flatten racing the monitor, book its PnL once
+func saveClosing(tx *gorm.DB, position *models.Position) error {
+	result := tx.Model(position).
+		Where("status = ?", models.PositionStatusOpen).
+		Select("*").
+		Updates(position)
+	if result.Error != nil {
+		return fmt.Errorf("failed to close position: %v", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrPositionNotOpen
+	}
+	return nil
+}
+
+// Reverse closes a position, books its PnL against the balance for balanceSymbol and opens
+// its replacement in one database transaction, so a failure never leaves the symbol flat or doubled
+func (r *PositionRepository) Reverse(closing, opening *models.Position, balanceSymbol string) (*models.Balance, error) {
+	if closing == nil || opening == nil {
+		return nil, errors.New("positions cannot be nil")
+	}
+
+	var balance *models.Balance
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := saveClosing(tx, closing); err != nil {
+			return err
+		}
+
+		var err error
+		balance, err = applyBalanceChange(tx, balanceSymbol, models.TransactionTypeTrade, closing.PnL, &closing.ID, CloseKey(closing.ID, closing.CloseReason))
+		if err != nil {
+			return err
+		}
+
+		opening.ReversedFromID = closing.ID
+		opening.Account = r.account
+		if err := tx.Create(opening).Error; err != nil {
+			return fmt.Errorf("failed to open reversed position: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balance, nil
+}
+
+// UpdateStops writes the stop loss and take profit of an open position and records change in one
+// database transaction. Only those columns are written, and ErrPositionNotOpen is returned when
+// the position was closed meanwhile
+func (r *PositionRepository) UpdateStops(position *models.Position, change *models.StopChange) error {
+	if position == nil || change == nil {
+		return errors.New("position and change cannot be nil")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(position).
+			Where("status = ?", models.PositionStatusOpen).
+			UpdateColumns(map[string]interface{}{
+				"stop_loss_price":   position.StopLossPrice,
+				"take_profit_price": position.TakeProfitPrice,
+				"updated_at":        position.UpdatedAt,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to update stops: %v", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrPositionNotOpen
+		}
+
+		change.PositionID = position.ID
+		if err := tx.Create(change).Error; err != nil {
+			return fmt.Errorf("failed to record stop change: %v", err)
+		}
+		return nil
+	})
+}
+
+// StopChanges returns the stop and target moves of the account's position id, oldest first
+func (r *PositionRepository) StopChanges(positionID uint) ([]models.StopChange, error) {
+	position, err := r.FindByID(positionID)
+	if err != nil {
+		return nil, err
+	}
+	if position == nil {
+		return nil, fmt.Errorf("position %d not found", positionID)
+	}
+	var changes []models.StopChange
+	err = r.base.Where("position_id = ?", positionID).Order("created_at ASC, id ASC").Find(&changes).Error
+	return changes, err
+}
+
+// CountReversalsSince counts positions on symbol opened by a reversal since the given time
+func (r *PositionRepository) CountReversalsSince(symbol string, since time.Time) (int64, error) {
+	if symbol == "" {
+		return 0, errors.New("invalid symbol")
+	}
+	var count int64
+	err := r.db.Model(&models.Position{}).
+		Where("symbol = ? AND reversed_from_id > 0 AND open_time >= ?", symbol, since).
+		Count(&count).Error
+	return count, err
+}
+
+// NormalizeTag trims and lowercases a journal tag and checks it is 1 to models.MaxTagLength
+// letters, digits, spaces, dashes or underscores
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if tag == "" {
+		return "", errors.New("tag cannot be empty")
+	}
+	if len(tag) > models.MaxTagLength {
+		return "", fmt.Errorf("tag %q is longer than %d characters", tag, models.MaxTagLength)
+	}
+	for _, c := range tag {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == ' ' || c == '-' || c == '_') {
+			return "", fmt.Errorf("tag %q may only contain letters, digits, spaces, dashes and underscores", tag)
+		}
+	}
+	return tag, nil
+}
+
+// closedPosition returns the account's closed position id, erroring when it is missing or still open
+func (r *PositionRepository) closedPosition(tx *gorm.DB, id uint) (*models.Position, error) {
+	var position models.Position
+	err := tx.First(&position, id).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("position %d not found", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if position.Status != models.PositionStatusClosed {
+		return nil, fmt.Errorf("position %d is still open", id)
+	}
+	return &position, nil
+}
+
+// AddTag tags a closed position, tagging it twice with the same tag is a no-op
+// The unique index on position and tag keeps concurrent taggers from adding duplicates
+func (r *PositionRepository) AddTag(positionID uint, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	if _, err := r.closedPosition(r.db, positionID); err != nil {
+		return err
+	}
+	return r.base.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.PositionTag{PositionID: positionID, Tag: tag}).Error
+}
+
+// RemoveTag removes a tag from a position, removing a tag it does not have is a no-op
+func (r *PositionRepository) RemoveTag(positionID uint, tag string) error {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return err
+	}
+	if _, err := r.closedPosition(r.db, positionID); err != nil {
+		return err
+	}
+	return r.base.Where("position_id = ? AND tag = ?", positionID, tag).Delete(&models.PositionTag{}).Error
+}
+
+// SetNotes replaces the journal notes of a closed position
+// Only the notes column is written, so a concurrent update of other fields is never overwritten
+func (r *PositionRepository) SetNotes(positionID uint, notes string) error {
+	position, err := r.closedPosition(r.db, positionID)
+	if err != nil {
+		return err
+	}
+	return r.db.Model(position).UpdateColumn("notes", notes).Error
+}
+
+// Tags returns the tags of each position in ids, sorted, positions without tags are left out
+func (r *PositionRepository) Tags(ids []uint) (map[uint][]string, error) {
+	tags := make(map[uint][]string)
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	var rows []models.PositionTag
+	if err := r.base.Where("position_id IN ?", ids).Order("tag ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		tags[row.PositionID] = append(tags[row.PositionID], row.Tag)
+	}
+	return tags, nil
+}
+
+// FindByTag retrieves the account's positions carrying tag, most recently closed first
+func (r *PositionRepository) FindByTag(tag string) ([]models.Position, error) {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	var positions []models.Position
+	err = r.db.Where("id IN (?)", r.base.Model(&models.PositionTag{}).Select("position_id").Where("tag = ?", tag)).
+		Order("close_time DESC").
+		Find(&positions).Error
+	return positions, err
+}
+
+// ContextFilter selects closed positions by a numeric field of their entry context
+type ContextFilter struct {
+	Field   string   // JSON key of the field, e.g. volume_ratio
+	Min     *float64 // Inclusive lower bound, nil for none
+	Max     *float64 // Exclusive upper bound, nil for none
+	Outcome string   // ContextOutcomeWin, ContextOutcomeLoss or empty for both
+}
+
+const (
+	ContextOutcomeWin  = "win"
+	ContextOutcomeLoss = "loss"
+)
+
+// FindClosedByContext retrieves the account's closed positions whose entry context matches filter,
+// most recently closed first. Positions without a context, or whose field is not a number, never match
+func (r *PositionRepository) FindClosedByContext(filter ContextFilter) ([]models.Position, error) {
+	query := r.db.Where("status = ? AND entry_context <> ''", models.PositionStatusClosed)
+
+	switch filter.Outcome {
+	case "":
+	case ContextOutcomeWin:
+		query = query.Where("pnl > 0")
+	case ContextOutcomeLoss:
+		query = query.Where("pnl < 0")
+	default:
+		return nil, fmt.Errorf("unknown outcome %q", filter.Outcome)
+	}
+
+	if filter.Field != "" {
+		// The key is bound as a parameter, the CASE keeps non-numeric fields from failing the cast
+		value := "CASE WHEN jsonb_typeof(entry_context::jsonb -> ?) = 'number' THEN (entry_context::jsonb ->> ?)::numeric END"
+		query = query.Where(value+" IS NOT NULL", filter.Field, filter.Field)
+		if filter.Min != nil {
+			query = query.Where(value+" >= ?", filter.Field, filter.Field, *filter.Min)
+		}
+		if filter.Max != nil {
+			query = query.Where(value+" < ?", filter.Field, filter.Field, *filter.Max)
+		}
+	}
+
+	var positions []models.Position
+	err := query.Order("close_time DESC").Find(&positions).Error
+	return positions, err
+}
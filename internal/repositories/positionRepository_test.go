@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    string
+		wantErr bool
+	}{
+		{"news spike", "news spike", false},
+		{"  News   Spike ", "news spike", false}, // Case and inner whitespace never make a second tag
+		{"bad_fill", "bad_fill", false},
+		{"should-have-skipped", "should-have-skipped", false},
+		{"fomc 2024", "fomc 2024", false},
+		{strings.Repeat("a", models.MaxTagLength), strings.Repeat("a", models.MaxTagLength), false},
+		{strings.Repeat("a", models.MaxTagLength+1), "", true},
+		{"", "", true},
+		{"   ", "", true},
+		{"news;drop table", "", true},
+		{"spike!", "", true},
+		{"café", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeTag(tt.tag)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeTag(%q) = %q, %v, want %q, error %v", tt.tag, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedSeries stores candles 5m candles for each of symbols, generated by Postgres so millions of rows seed in seconds
+func seedSeries(tb testing.TB, db *gorm.DB, symbols []string, candles int) {
+	tb.Helper()
+	for _, symbol := range symbols {
+		err := db.Exec(`INSERT INTO prices (exchange, symbol, time_frame, open_time, close_time, open, high, low, close, volume, trade_count, created_at, updated_at)
+			SELECT 'binance', ?, '5m', t, t + interval '5 minutes' - interval '1 millisecond', 100 + i % 50, 101 + i % 50, 99 + i % 50, 100 + i % 50, 10, 100, now(), now()
+			FROM generate_series(0, ? - 1) AS i, LATERAL (SELECT ?::timestamptz + i * interval '5 minutes' AS t) AS open_times`,
+			symbol, candles, testdb.FixtureStart).Error
+		if err != nil {
+			tb.Fatalf("failed to seed %s: %v", symbol, err)
+		}
+	}
+	if err := db.Exec("ANALYZE prices").Error; err != nil {
+		tb.Fatal(err)
+	}
+}
+
+// seriesSymbols returns n symbols to seed
+func seriesSymbols(n int) []string {
+	symbols := make([]string, n)
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("SYM%02dUSDT", i)
+	}
+	return symbols
+}
+
+// seriesPlan returns Postgres' plan for the day of a symbol's series GetPricesByTimeFrame loads
+func seriesPlan(t *testing.T, db *gorm.DB, symbol string) string {
+	t.Helper()
+	start := testdb.FixtureStart.Add(30 * 24 * time.Hour)
+	query := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		var rows []models.PriceOHLCV
+		return tx.Model(&models.Price{}).
+			Select("symbol, time_frame, open_time, close_time, open, high, low, close, volume, trade_count").
+			Where("symbol = ? AND time_frame = ?", symbol, models.PriceTimeFrame5m).
+			Where("open_time BETWEEN ? AND ?", start, start.Add(24*time.Hour)).
+			Order("open_time ASC").
+			Find(&rows)
+	})
+
+	var lines []string
+	if err := db.Raw("EXPLAIN " + query).Scan(&lines).Error; err != nil {
+		t.Fatal(err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestSeriesQueryUsesTheCompositeIndex(t *testing.T) {
+	db := testdb.Open(t)
+	seedSeries(t, db, seriesSymbols(10), 20000)
+
+	if plan := seriesPlan(t, db, "SYM03USDT"); !strings.Contains(plan, "idx_prices_series") {
+		t.Errorf("series query plan does not use idx_prices_series:\n%s", plan)
+	}
+
+	// The projection loads the same candles a full row would
+	prices := repositories.NewPriceRepository(db)
+	start := testdb.FixtureStart.Add(30 * 24 * time.Hour)
+	got, err := prices.GetPricesByTimeFrame(context.Background(), "SYM03USDT", models.PriceTimeFrame5m, start, start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 289 {
+		t.Fatalf("GetPricesByTimeFrame() = %d candles, want the 289 of the day", len(got))
+	}
+	// The day starts 8640 candles in
+	if first := got[0]; !first.OpenTime.Equal(start) || first.Close != 100+float64(8640%50) || first.TradeCount != 100 {
+		t.Errorf("first candle = %+v, want the one opening at %s", first, start)
+	}
+}
+
+// BenchmarkGetPricesByTimeFrame loads a day of one series out of a 2M row table, with idx_prices_series
+// and then with only the separate symbol and open_time indexes, run with
+//
+//	go test -tags integration -run NONE -bench GetPricesByTimeFrame ./internal/repositories
+func BenchmarkGetPricesByTimeFrame(b *testing.B) {
+	db := testdb.Open(b)
+	symbols := seriesSymbols(20)
+	seedSeries(b, db, symbols, 100000)
+	prices := repositories.NewPriceRepository(db)
+	ctx := context.Background()
+	start := testdb.FixtureStart.Add(30 * 24 * time.Hour)
+
+	load := func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := prices.GetPricesByTimeFrame(ctx, symbols[i%len(symbols)], models.PriceTimeFrame5m, start, start.Add(24*time.Hour)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("composite index", load)
+	if err := db.Exec("DROP INDEX idx_prices_series").Error; err != nil {
+		b.Fatal(err)
+	}
+	if err := db.Exec("ANALYZE prices").Error; err != nil {
+		b.Fatal(err)
+	}
+	b.Run("separate indexes", load)
+}
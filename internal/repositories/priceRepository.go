@@ -2,28 +2,79 @@ package repositories
 
 import (
 	"CryptoTradeBot/internal/models"
+	"context"
 	"errors"
 	"log"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// PriceSeries identifies a symbol and timeframe pair stored in the price table
+type PriceSeries struct {
+	Symbol    string
+	TimeFrame string
+}
+
+// ohlcvColumns are the columns of a models.PriceOHLCV, what series queries load
+var ohlcvColumns = []string{"symbol", "time_frame", "open_time", "close_time", "open", "high", "low", "close", "volume", "trade_count"}
+
 type PriceRepository struct {
-	db *gorm.DB
+	base     *gorm.DB // Unscoped, used to derive other exchanges
+	db       *gorm.DB // Scoped to exchange
+	exchange string
 }
 
 // NewPriceRepository creates a new instance of PriceRepository
+// Its queries see only Binance's candles, use ForExchange for another exchange's
 func NewPriceRepository(db *gorm.DB) *PriceRepository {
-	return &PriceRepository{db: db}
+	return &PriceRepository{base: db, db: scopeExchange(db, models.ExchangeBinance), exchange: models.ExchangeBinance}
+}
+
+// ForExchange returns a PriceRepository reading and writing only the given exchange's candles
+func (r *PriceRepository) ForExchange(exchange string) *PriceRepository {
+	return &PriceRepository{base: r.base, db: scopeExchange(r.base, exchange), exchange: exchange}
+}
+
+// Exchange returns the exchange this repository is scoped to
+func (r *PriceRepository) Exchange() string {
+	return r.exchange
+}
+
+func scopeExchange(db *gorm.DB, exchange string) *gorm.DB {
+	return db.Where("exchange = ?", exchange).Session(&gorm.Session{})
 }
 
 // Create adds a new Price record to the database
-func (r *PriceRepository) Create(price *models.Price) error {
+func (r *PriceRepository) Create(ctx context.Context, price *models.Price) error {
+	if price == nil {
+		return errors.New("price cannot be nil")
+	}
+	price.Exchange = r.exchange
+	db, ctx, cancel := withTimeout(ctx, r.db)
+	defer cancel()
+	return queryError(ctx, db.Create(price).Error)
+}
+
+// Upsert saves price, updating the candle stored at the same open time instead when there is one, so a
+// candle stored while still forming is corrected once it closes. It is one insert on idx_prices_series,
+// so concurrent writers of the same candle leave a single row; a soft deleted one is restored
+func (r *PriceRepository) Upsert(ctx context.Context, price *models.Price) error {
 	if price == nil {
 		return errors.New("price cannot be nil")
 	}
-	return r.db.Create(price).Error
+	price.Exchange = r.exchange
+	db, ctx, cancel := withTimeout(ctx, r.db)
+	defer cancel()
+
+	err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "symbol"}, {Name: "time_frame"}, {Name: "open_time"}, {Name: "exchange"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"close_time", "open", "high", "low", "close", "volume", "trade_count", "updated_at", "deleted_at",
+		}),
+	}).Create(price).Error
+	return queryError(ctx, err)
 }
 
 // FindByID retrieves a Price record by its ID
@@ -132,17 +183,38 @@ func (r *PriceRepository) GetMultiSymbolPrices(symbols []string) (map[string]*mo
 	return result, nil
 }
 
+// series selects the OHLCV columns of one symbol and timeframe, a query idx_prices_series serves
+func (r *PriceRepository) series(symbol, timeFrame string) *gorm.DB {
+	return r.db.Model(&models.Price{}).
+		Select(ohlcvColumns).
+		Where("symbol = ? AND time_frame = ?", symbol, timeFrame)
+}
+
+// toPrices converts loaded OHLCV rows to Prices
+func toPrices(rows []models.PriceOHLCV) []models.Price {
+	prices := make([]models.Price, len(rows))
+	for i := range rows {
+		prices[i] = rows[i].Price()
+	}
+	return prices
+}
+
 // GetPricesByTimeFrame gets price data for a specific symbol and timeframe
-func (r *PriceRepository) GetPricesByTimeFrame(symbol string, timeFrame string, start, end time.Time) ([]models.Price, error) {
+// Only the OHLCV columns are loaded, use GetPriceRowsByTimeFrame for candles to update
+func (r *PriceRepository) GetPricesByTimeFrame(ctx context.Context, symbol string, timeFrame string, start, end time.Time) ([]models.Price, error) {
 	if symbol == "" || timeFrame == "" {
 		return nil, errors.New("invalid symbol or timeframe")
 	}
 
-	var prices []models.Price
-	err := r.db.Where("symbol = ? AND time_frame = ? AND open_time BETWEEN ? AND ?",
-		symbol, timeFrame, start, end).
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	var rows []models.PriceOHLCV
+	err := queryError(ctx, r.series(symbol, timeFrame).
+		WithContext(ctx).
+		Where("open_time BETWEEN ? AND ?", start, end).
 		Order("open_time ASC").
-		Find(&prices).Error
+		Find(&rows).Error)
+	prices := toPrices(rows)
 
 	// Log the query results
 	log.Printf("Got %d prices for %s from %s to %s",
@@ -154,21 +226,185 @@ func (r *PriceRepository) GetPricesByTimeFrame(symbol string, timeFrame string,
 	return prices, err
 }
 
+// GetPriceRowsByTimeFrame gets the complete rows of a symbol and timeframe between start and end,
+// IDs included so they can be updated
+func (r *PriceRepository) GetPriceRowsByTimeFrame(symbol, timeFrame string, start, end time.Time) ([]models.Price, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+
+	var prices []models.Price
+	err := r.db.Where("symbol = ? AND time_frame = ? AND open_time BETWEEN ? AND ?",
+		symbol, timeFrame, start, end).
+		Order("open_time ASC").
+		Find(&prices).Error
+	return prices, err
+}
+
+// StoredOpenTimes returns the open times of a symbol's stored candles of timeFrame between start and end,
+// in ascending order
+func (r *PriceRepository) StoredOpenTimes(symbol, timeFrame string, start, end time.Time) ([]time.Time, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+
+	var openTimes []time.Time
+	err := r.db.Model(&models.Price{}).
+		Where("symbol = ? AND time_frame = ? AND open_time BETWEEN ? AND ?", symbol, timeFrame, start, end).
+		Order("open_time ASC").
+		Pluck("open_time", &openTimes).Error
+	return openTimes, err
+}
+
+// GetLastNPrices gets the n most recent candles for a symbol and timeframe in ascending order
+func (r *PriceRepository) GetLastNPrices(symbol, timeFrame string, n int) ([]models.Price, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+	if n <= 0 {
+		return nil, errors.New("invalid limit")
+	}
+
+	var rows []models.PriceOHLCV
+	err := r.series(symbol, timeFrame).
+		Order("open_time DESC").
+		Limit(n).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	prices := toPrices(rows)
+	for i, j := 0, len(prices)-1; i < j; i, j = i+1, j-1 {
+		prices[i], prices[j] = prices[j], prices[i]
+	}
+	return prices, nil
+}
+
+// GetPricesByTimeFrameWithLimit gets at most limit candles from the start of a range in ascending order
+func (r *PriceRepository) GetPricesByTimeFrameWithLimit(symbol, timeFrame string, start, end time.Time, limit int) ([]models.Price, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+	if limit <= 0 {
+		return nil, errors.New("invalid limit")
+	}
+
+	var rows []models.PriceOHLCV
+	err := r.series(symbol, timeFrame).
+		Where("open_time BETWEEN ? AND ?", start, end).
+		Order("open_time ASC").
+		Limit(limit).
+		Find(&rows).Error
+	return toPrices(rows), err
+}
+
+// GetSeries lists every distinct symbol and timeframe pair in the price table
+func (r *PriceRepository) GetSeries() ([]PriceSeries, error) {
+	var series []PriceSeries
+	err := r.db.Model(&models.Price{}).
+		Distinct("symbol", "time_frame").
+		Order("symbol ASC, time_frame ASC").
+		Scan(&series).Error
+	return series, err
+}
+
+// StreamPricesByTimeFrame walks prices for a symbol and timeframe in open_time order
+// one row at a time, so callers never hold the whole range in memory
+func (r *PriceRepository) StreamPricesByTimeFrame(symbol, timeFrame string, start, end time.Time, fn func(models.Price) error) error {
+	if symbol == "" || timeFrame == "" {
+		return errors.New("invalid symbol or timeframe")
+	}
+
+	rows, err := r.series(symbol, timeFrame).
+		Where("open_time BETWEEN ? AND ?", start, end).
+		Order("open_time ASC, id ASC").
+		Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.PriceOHLCV
+		if err := r.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row.Price()); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// DeleteByIDs permanently removes the Price records with the given IDs
+func (r *PriceRepository) DeleteByIDs(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Unscoped().Delete(&models.Price{}, ids).Error
+}
+
+// CountOlderThanByTimeframe counts a symbol's candles of timeFrame opened before cutoff
+func (r *PriceRepository) CountOlderThanByTimeframe(symbol, timeFrame string, cutoff time.Time) (int64, error) {
+	if symbol == "" || timeFrame == "" {
+		return 0, errors.New("invalid symbol or timeframe")
+	}
+
+	var count int64
+	err := r.db.Unscoped().Model(&models.Price{}).
+		Where("symbol = ? AND time_frame = ? AND open_time < ?", symbol, timeFrame, cutoff).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteOlderThanByTimeframe permanently removes a symbol's candles of timeFrame opened before cutoff,
+// at most batchSize rows per statement so no delete holds its locks for long, returning the rows removed
+func (r *PriceRepository) DeleteOlderThanByTimeframe(symbol, timeFrame string, cutoff time.Time, batchSize int) (int64, error) {
+	if symbol == "" || timeFrame == "" {
+		return 0, errors.New("invalid symbol or timeframe")
+	}
+	if batchSize <= 0 {
+		return 0, errors.New("batch size must be positive")
+	}
+
+	var deleted int64
+	for {
+		batch := r.db.Unscoped().Model(&models.Price{}).
+			Select("id").
+			Where("symbol = ? AND time_frame = ? AND open_time < ?", symbol, timeFrame, cutoff).
+			Order("open_time ASC").
+			Limit(batchSize)
+
+		result := r.db.Unscoped().Where("id IN (?)", batch).Delete(&models.Price{})
+		if result.Error != nil {
+			return deleted, result.Error
+		}
+		deleted += result.RowsAffected
+		if result.RowsAffected < int64(batchSize) {
+			return deleted, nil
+		}
+	}
+}
+
 // GetLatestPriceByTimeFrame gets the most recent price for a symbol and timeframe
-func (r *PriceRepository) GetLatestPrice(symbol string) (*models.Price, error) {
+func (r *PriceRepository) GetLatestPrice(ctx context.Context, symbol string) (*models.Price, error) {
 	if symbol == "" {
 		return nil, errors.New("invalid symbol")
 	}
 
+	db, ctx, cancel := withTimeout(ctx, r.db)
+	defer cancel()
 	var price models.Price
-	err := r.db.Where("symbol = ?", symbol).
+	err := db.Where("symbol = ?", symbol).
 		Order("open_time DESC").
 		First(&price).Error
 
 	if err == gorm.ErrRecordNotFound {
 		return nil, nil
 	}
-	return &price, err
+	return &price, queryError(ctx, err)
 }
 
 func (r *PriceRepository) GetLatestPriceByTimeFrame(symbol, timeFrame string) (*models.Price, error) {
@@ -187,7 +423,24 @@ func (r *PriceRepository) GetLatestPriceByTimeFrame(symbol, timeFrame string) (*
 	return &price, err
 }
 
-// ClearTable removes all records from the Price table
+// GetEarliestPriceByTimeFrame gets the oldest stored price for a symbol and timeframe, nil when there is none
+func (r *PriceRepository) GetEarliestPriceByTimeFrame(symbol, timeFrame string) (*models.Price, error) {
+	if symbol == "" || timeFrame == "" {
+		return nil, errors.New("invalid symbol or timeframe")
+	}
+
+	var price models.Price
+	err := r.db.Where("symbol = ? AND time_frame = ?", symbol, timeFrame).
+		Order("open_time ASC").
+		First(&price).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &price, err
+}
+
+// ClearTable removes all of the exchange's records from the Price table
 func (r *PriceRepository) ClearTable() error {
 	if r.db == nil {
 		return errors.New("database connection is nil")
@@ -0,0 +1,286 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+)
+
+// storeCandles stores the 5m candles of symbol at the given indexes after the fixture start, in that order,
+// each closing at 100 plus its index
+func storeCandles(t *testing.T, prices *repositories.PriceRepository, symbol string, indexes ...int) {
+	t.Helper()
+	for _, i := range indexes {
+		openTime := testdb.FixtureStart.Add(time.Duration(i) * 5 * time.Minute)
+		price := &models.Price{
+			Symbol:    symbol,
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+			Open:      100,
+			High:      200,
+			Low:       50,
+			Close:     100 + float64(i),
+			Volume:    10,
+		}
+		if err := prices.Create(context.Background(), price); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// closes returns the close of each price
+func closes(prices []models.Price) []float64 {
+	values := make([]float64, len(prices))
+	for i, p := range prices {
+		values[i] = p.Close
+	}
+	return values
+}
+
+func equalCloses(got []models.Price, want ...float64) bool {
+	values := closes(got)
+	if len(values) != len(want) {
+		return false
+	}
+	for i := range want {
+		if values[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGetLastNPrices(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Open(t))
+	// Inserted out of order, with another symbol's candles in between
+	storeCandles(t, prices, "BTCUSDT", 3, 0, 5, 1, 4, 2)
+	storeCandles(t, prices, "ETHUSDT", 6, 7)
+
+	tests := []struct {
+		n    int
+		want []float64
+	}{
+		{1, []float64{105}},
+		{3, []float64{103, 104, 105}},
+		{6, []float64{100, 101, 102, 103, 104, 105}},
+		{50, []float64{100, 101, 102, 103, 104, 105}},
+	}
+	for _, tt := range tests {
+		got, err := prices.GetLastNPrices("BTCUSDT", models.PriceTimeFrame5m, tt.n)
+		if err != nil {
+			t.Fatalf("GetLastNPrices(%d) error = %v", tt.n, err)
+		}
+		if !equalCloses(got, tt.want...) {
+			t.Errorf("GetLastNPrices(%d) closes = %v, want %v oldest first", tt.n, closes(got), tt.want)
+		}
+	}
+
+	if got, err := prices.GetLastNPrices("SOLUSDT", models.PriceTimeFrame5m, 5); err != nil || len(got) != 0 {
+		t.Errorf("GetLastNPrices() of an empty series = %v, %v", closes(got), err)
+	}
+	if _, err := prices.GetLastNPrices("BTCUSDT", models.PriceTimeFrame5m, 0); err == nil {
+		t.Error("GetLastNPrices(0) succeeded")
+	}
+}
+
+func TestGetPricesByTimeFrameWithLimit(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Open(t))
+	storeCandles(t, prices, "BTCUSDT", 4, 2, 0, 3, 1, 5)
+	at := func(i int) time.Time { return testdb.FixtureStart.Add(time.Duration(i) * 5 * time.Minute) }
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		limit      int
+		want       []float64
+	}{
+		{"limit cuts the range from its start", at(1), at(5), 2, []float64{101, 102}},
+		{"range cuts below the limit", at(2), at(3), 10, []float64{102, 103}},
+		{"range ends are inclusive", at(0), at(5), 6, []float64{100, 101, 102, 103, 104, 105}},
+		{"empty range", at(6), at(9), 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := prices.GetPricesByTimeFrameWithLimit("BTCUSDT", models.PriceTimeFrame5m, tt.start, tt.end, tt.limit)
+			if err != nil {
+				t.Fatalf("GetPricesByTimeFrameWithLimit() error = %v", err)
+			}
+			if !equalCloses(got, tt.want...) {
+				t.Errorf("closes = %v, want %v", closes(got), tt.want)
+			}
+		})
+	}
+
+	if _, err := prices.GetPricesByTimeFrameWithLimit("BTCUSDT", models.PriceTimeFrame5m, at(0), at(5), 0); err == nil {
+		t.Error("GetPricesByTimeFrameWithLimit() with limit 0 succeeded")
+	}
+}
+
+func TestDeleteOlderThanByTimeframeInBatches(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Open(t))
+	storeCandles(t, prices, "BTCUSDT", 0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	storeCandles(t, prices, "ETHUSDT", 0, 1, 2)
+	at := func(i int) time.Time { return testdb.FixtureStart.Add(time.Duration(i) * 5 * time.Minute) }
+
+	if n, err := prices.CountOlderThanByTimeframe("BTCUSDT", models.PriceTimeFrame5m, at(7)); err != nil || n != 7 {
+		t.Errorf("CountOlderThanByTimeframe() = %d, %v, want 7", n, err)
+	}
+
+	// Three batches of 3 and a last one of 1, stopping at the first short batch
+	deleted, err := prices.DeleteOlderThanByTimeframe("BTCUSDT", models.PriceTimeFrame5m, at(7), 3)
+	if err != nil || deleted != 7 {
+		t.Fatalf("DeleteOlderThanByTimeframe() = %d, %v, want 7", deleted, err)
+	}
+	left, err := prices.GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, at(0), at(9))
+	if err != nil || !equalCloses(left, 107, 108, 109) {
+		t.Errorf("BTCUSDT left %v, %v, want the candles from the cutoff on", closes(left), err)
+	}
+	// Other symbols and timeframes are untouched
+	if n, _ := prices.CountOlderThanByTimeframe("ETHUSDT", models.PriceTimeFrame5m, at(7)); n != 3 {
+		t.Errorf("%d ETHUSDT candles left, want 3", n)
+	}
+
+	// A batch exactly filled by the last rows still ends the loop
+	if deleted, err := prices.DeleteOlderThanByTimeframe("ETHUSDT", models.PriceTimeFrame5m, at(7), 3); err != nil || deleted != 3 {
+		t.Errorf("DeleteOlderThanByTimeframe(ETHUSDT) = %d, %v, want 3", deleted, err)
+	}
+	if _, err := prices.DeleteOlderThanByTimeframe("BTCUSDT", models.PriceTimeFrame5m, at(7), 0); err == nil {
+		t.Error("DeleteOlderThanByTimeframe() with batch size 0 succeeded")
+	}
+}
+
+func TestUpsertCorrectsAFormingCandle(t *testing.T) {
+	prices := repositories.NewPriceRepository(testdb.Open(t))
+	openTime := testdb.FixtureStart
+	candle := func(close, volume float64) *models.Price {
+		return &models.Price{
+			Symbol:    "BTCUSDT",
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  openTime,
+			CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+			Open:      100,
+			High:      max(100, close),
+			Low:       min(100, close),
+			Close:     close,
+			Volume:    volume,
+		}
+	}
+
+	forming, closed := candle(101, 4), candle(103, 10)
+	if err := prices.Upsert(context.Background(), forming); err != nil {
+		t.Fatal(err)
+	}
+	if err := prices.Upsert(context.Background(), closed); err != nil {
+		t.Fatal(err)
+	}
+	if closed.ID != forming.ID {
+		t.Errorf("closed candle saved as %d, want the forming row %d updated", closed.ID, forming.ID)
+	}
+	stored, err := prices.GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, openTime, openTime)
+	if err != nil || len(stored) != 1 || stored[0].Close != 103 || stored[0].Volume != 10 || stored[0].High != 103 {
+		t.Fatalf("stored %+v, %v, want the closed candle once", stored, err)
+	}
+
+	// The same candle of another exchange is its own row
+	bybit := prices.ForExchange(models.ExchangeBybit)
+	if err := bybit.Upsert(context.Background(), candle(99, 7)); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = bybit.GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, openTime, openTime)
+	if err != nil || !equalCloses(stored, 99) {
+		t.Errorf("Bybit stored %v, %v, want its own candle", closes(stored), err)
+	}
+	if stored, _ := prices.GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, openTime, openTime); !equalCloses(stored, 103) {
+		t.Errorf("Binance candle changed to %v by Bybit's", closes(stored))
+	}
+}
+
+func TestConcurrentUpsertsKeepOneCandle(t *testing.T) {
+	db := testdb.Open(t)
+	prices := repositories.NewPriceRepository(db)
+	openTime := testdb.FixtureStart
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(close float64) {
+			defer wg.Done()
+			errs <- prices.Upsert(context.Background(), &models.Price{
+				Symbol:    "BTCUSDT",
+				TimeFrame: models.PriceTimeFrame5m,
+				OpenTime:  openTime,
+				CloseTime: openTime.Add(5*time.Minute - time.Millisecond),
+				Open:      100,
+				High:      110,
+				Low:       90,
+				Close:     close,
+				Volume:    10,
+			})
+		}(100 + float64(i))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&models.Price{}).Count(&count).Error; err != nil || count != 1 {
+		t.Errorf("%d rows after 8 concurrent upserts of one candle, %v, want 1", count, err)
+	}
+}
+
+func TestMigrationDedupesPricesBeforeTheUniqueKey(t *testing.T) {
+	db := testdb.Open(t)
+	prices := repositories.NewPriceRepository(db)
+
+	// A deployment from before the key: the series index is plain and a candle is stored twice,
+	// its later copy holding the closed values
+	if err := db.Exec("DROP INDEX idx_prices_series").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE INDEX idx_prices_series ON prices (symbol, time_frame, open_time, exchange)").Error; err != nil {
+		t.Fatal(err)
+	}
+	storeCandles(t, prices, "BTCUSDT", 0, 1)
+	if err := db.Exec("UPDATE prices SET updated_at = updated_at - interval '1 minute'").Error; err != nil {
+		t.Fatal(err)
+	}
+	storeCandles(t, prices, "BTCUSDT", 1)
+	if err := db.Exec("UPDATE prices SET close = 150 WHERE id = (SELECT max(id) FROM prices)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := repositories.OpenDatabase(db.Dialector.(*postgres.Dialector).DSN)
+	if err != nil {
+		t.Fatalf("migrating a table with duplicate candles: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := reopened.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	at := func(i int) time.Time { return testdb.FixtureStart.Add(time.Duration(i) * 5 * time.Minute) }
+	stored, err := repositories.NewPriceRepository(reopened).GetPricesByTimeFrame(context.Background(), "BTCUSDT", models.PriceTimeFrame5m, at(0), at(1))
+	if err != nil || !equalCloses(stored, 100, 150) {
+		t.Errorf("stored %v, %v, want each candle once, the later copy kept", closes(stored), err)
+	}
+	var unique bool
+	err = reopened.Raw(`SELECT indexdef LIKE 'CREATE UNIQUE%' FROM pg_indexes WHERE indexname = 'idx_prices_series'`).Scan(&unique).Error
+	if err != nil || !unique {
+		t.Errorf("idx_prices_series unique = %v, %v after migrating", unique, err)
+	}
+}
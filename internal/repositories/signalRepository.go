@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SignalRepository struct {
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
+}
+
+// RejectionCount is how often signals were rejected for one reason
+type RejectionCount struct {
+	Reason string
+	Count  int
+}
+
+// NewSignalRepository creates a new instance of SignalRepository
+// Its queries see only the default account, use ForAccount for another
+func NewSignalRepository(db *gorm.DB) *SignalRepository {
+	return &SignalRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a SignalRepository reading and writing only the given account's rows
+func (r *SignalRepository) ForAccount(account string) *SignalRepository {
+	return &SignalRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *SignalRepository) Account() string {
+	return r.account
+}
+
+// RecordRejection adds one to the tally of reason on symbol for the UTC day of at
+func (r *SignalRepository) RecordRejection(symbol, reason string, at time.Time) error {
+	if symbol == "" || reason == "" {
+		return errors.New("invalid symbol or reason")
+	}
+	tally := &models.SignalTally{
+		Account: r.account,
+		Day:     at.UTC().Truncate(24 * time.Hour),
+		Symbol:  symbol,
+		Reason:  reason,
+		Count:   1,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "account"}, {Name: "day"}, {Name: "symbol"}, {Name: "reason"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("signal_tallies.count + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(tally).Error
+}
+
+// TopRejections retrieves the most frequent rejection reasons on days from start up to end, most frequent first
+func (r *SignalRepository) TopRejections(start, end time.Time, limit int) ([]RejectionCount, error) {
+	if limit <= 0 {
+		return nil, errors.New("invalid limit")
+	}
+	var counts []RejectionCount
+	err := r.db.Model(&models.SignalTally{}).
+		Select("reason, SUM(count) AS count").
+		Where("day >= ? AND day < ?", start, end).
+		Group("reason").
+		Order("count DESC, reason ASC").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SymbolSuspensionRepository struct {
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
+}
+
+// NewSymbolSuspensionRepository creates a new instance of SymbolSuspensionRepository
+// Its queries see only the default account, use ForAccount for another
+func NewSymbolSuspensionRepository(db *gorm.DB) *SymbolSuspensionRepository {
+	return &SymbolSuspensionRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a SymbolSuspensionRepository reading and writing only the given account's rows
+func (r *SymbolSuspensionRepository) ForAccount(account string) *SymbolSuspensionRepository {
+	return &SymbolSuspensionRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *SymbolSuspensionRepository) Account() string {
+	return r.account
+}
+
+// Create adds a new SymbolSuspension record to the database
+func (r *SymbolSuspensionRepository) Create(suspension *models.SymbolSuspension) error {
+	if suspension == nil {
+		return errors.New("suspension cannot be nil")
+	}
+	suspension.Account = r.account
+	return r.db.Create(suspension).Error
+}
+
+// Update modifies an existing SymbolSuspension record
+func (r *SymbolSuspensionRepository) Update(suspension *models.SymbolSuspension) error {
+	if suspension == nil {
+		return errors.New("suspension cannot be nil")
+	}
+	return r.db.Save(suspension).Error
+}
+
+// FindLatest retrieves the most recent suspension for a symbol, active or not
+func (r *SymbolSuspensionRepository) FindLatest(symbol string) (*models.SymbolSuspension, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+	var suspension models.SymbolSuspension
+	err := r.db.Where("symbol = ?", symbol).Order("suspended_at DESC").First(&suspension).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &suspension, err
+}
+
+// FindActive retrieves every suspension still in force at the given time
+func (r *SymbolSuspensionRepository) FindActive(at time.Time) ([]models.SymbolSuspension, error) {
+	var suspensions []models.SymbolSuspension
+	err := r.db.Where("resume_at > ?", at).Order("symbol ASC").Find(&suspensions).Error
+	return suspensions, err
+}
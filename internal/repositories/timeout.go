@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultQueryTimeout bounds a hot path query when DatabaseOptions leaves QueryTimeout unset
+const DefaultQueryTimeout = 10 * time.Second
+
+// ErrQueryTimeout is returned, wrapped with the driver's error, when a query outlives its timeout
+var ErrQueryTimeout = errors.New("query timed out")
+
+// queryTimeout bounds each query of the context taking methods, set by OpenDatabaseWithOptions
+var queryTimeout = DefaultQueryTimeout
+
+// withTimeout returns a session of db bound to ctx cut off after queryTimeout, and the cancel releasing it
+func withTimeout(ctx context.Context, db *gorm.DB) (*gorm.DB, context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	return db.WithContext(ctx), ctx, cancel
+}
+
+// queryError marks err as ErrQueryTimeout when ctx's deadline passed, leaving other errors and nil as they are
+func queryError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w after %s: %v", ErrQueryTimeout, queryTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,51 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSlowQueryReturnsWithinItsTimeout(t *testing.T) {
+	db := testdb.Open(t)
+	prices := repositories.NewPriceRepository(db)
+	storeCandles(t, prices, "BTCUSDT", 0, 1, 2)
+
+	// Another session holds the prices table through a slow query, so reads of it wait on the lock
+	locked, released := make(chan error, 1), make(chan struct{})
+	go func() {
+		defer close(released)
+		tx := db.Begin()
+		defer tx.Rollback()
+		if err := tx.Exec("LOCK TABLE prices IN ACCESS EXCLUSIVE MODE").Error; err != nil {
+			locked <- err
+			return
+		}
+		locked <- nil
+		tx.Exec("SELECT pg_sleep(3)")
+	}()
+	if err := <-locked; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { <-released })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	_, err := prices.GetPricesByTimeFrame(ctx, "BTCUSDT", models.PriceTimeFrame5m,
+		testdb.FixtureStart, testdb.FixtureStart.Add(time.Hour))
+	elapsed := time.Since(started)
+
+	if !errors.Is(err, repositories.ErrQueryTimeout) {
+		t.Errorf("GetPricesByTimeFrame() error = %v, want ErrQueryTimeout", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("GetPricesByTimeFrame() returned after %s behind a 3s query, want it cut off at 300ms", elapsed)
+	}
+}
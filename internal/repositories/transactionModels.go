@@ -9,12 +9,25 @@ import (
 )
 
 type TransactionRepository struct {
-	db *gorm.DB
+	base    *gorm.DB // Unscoped, used to derive other accounts
+	db      *gorm.DB // Scoped to account
+	account string
 }
 
 // NewTransactionRepository creates a new instance of TransactionRepository
+// Its queries see only the default account, use ForAccount for another
 func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+	return &TransactionRepository{base: db, db: scopeAccount(db, models.DefaultAccount), account: models.DefaultAccount}
+}
+
+// ForAccount returns a TransactionRepository reading and writing only the given account's rows
+func (r *TransactionRepository) ForAccount(account string) *TransactionRepository {
+	return &TransactionRepository{base: r.base, db: scopeAccount(r.base, account), account: account}
+}
+
+// Account returns the account this repository is scoped to
+func (r *TransactionRepository) Account() string {
+	return r.account
 }
 
 // Create adds a new Transaction record to the database
@@ -22,6 +35,7 @@ func (r *TransactionRepository) Create(transaction *models.Transaction) error {
 	if transaction == nil {
 		return errors.New("transaction cannot be nil")
 	}
+	transaction.Account = r.account
 	return r.db.Create(transaction).Error
 }
 
@@ -89,3 +103,67 @@ func (r *TransactionRepository) GetTotalVolume(start, end time.Time) (float64, e
 		Scan(&totalVolume).Error
 	return totalVolume, err
 }
+
+// FindLatestBefore retrieves the last transaction on symbol created before t, nil when there is none
+func (r *TransactionRepository) FindLatestBefore(symbol string, t time.Time) (*models.Transaction, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+	var transaction models.Transaction
+	err := r.db.Where("symbol = ? AND created_at < ?", symbol, t).
+		Order("created_at DESC, id DESC").
+		First(&transaction).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	return &transaction, err
+}
+
+// SumByType retrieves the net of one type of transaction on symbol from start up to but not including end
+func (r *TransactionRepository) SumByType(symbol, txType string, start, end time.Time) (float64, error) {
+	if symbol == "" || txType == "" {
+		return 0, errors.New("invalid symbol or type")
+	}
+	var total float64
+	err := r.db.Model(&models.Transaction{}).
+		Where("symbol = ? AND type = ? AND created_at >= ? AND created_at < ?", symbol, txType, start, end).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// DailyPnL is the net of trade and funding transactions for one UTC day
+type DailyPnL struct {
+	Day    time.Time
+	PnL    float64
+	Trades int
+}
+
+// GetDailyPnL retrieves per-day PnL for a symbol within a time range
+func (r *TransactionRepository) GetDailyPnL(symbol string, start, end time.Time) ([]DailyPnL, error) {
+	if symbol == "" {
+		return nil, errors.New("invalid symbol")
+	}
+	var days []DailyPnL
+	err := r.db.Model(&models.Transaction{}).
+		Select("date_trunc('day', created_at AT TIME ZONE 'UTC') AS day, SUM(amount) AS pn_l, COUNT(*) FILTER (WHERE type = ?) AS trades", models.TransactionTypeTrade).
+		Where("symbol = ? AND type IN ? AND created_at BETWEEN ? AND ?",
+			symbol, []string{models.TransactionTypeTrade, models.TransactionTypeFunding}, start, end).
+		Group("day").
+		Order("day ASC").
+		Scan(&days).Error
+	return days, err
+}
+
+// SumAmounts retrieves the net of every transaction for a symbol
+func (r *TransactionRepository) SumAmounts(symbol string) (float64, error) {
+	if symbol == "" {
+		return 0, errors.New("invalid symbol")
+	}
+	var total float64
+	err := r.db.Model(&models.Transaction{}).
+		Where("symbol = ?", symbol).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"CryptoTradeBot/internal/models"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UniverseRepository struct {
+	db *gorm.DB
+}
+
+// NewUniverseRepository creates a new instance of UniverseRepository
+func NewUniverseRepository(db *gorm.DB) *UniverseRepository {
+	return &UniverseRepository{db: db}
+}
+
+// Save stores symbols as the universe of the UTC day of day, replacing an earlier selection that day
+func (r *UniverseRepository) Save(day time.Time, symbols []string) error {
+	if len(symbols) == 0 {
+		return errors.New("universe cannot be empty")
+	}
+	snapshot := &models.UniverseSnapshot{
+		Day:     day.UTC().Truncate(24 * time.Hour),
+		Symbols: strings.Join(symbols, ","),
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"symbols":    snapshot.Symbols,
+			"updated_at": time.Now(),
+		}),
+	}).Create(snapshot).Error
+}
+
+// FindForPeriod retrieves the snapshots in force from start up to end, oldest first
+// The first is the latest one taken on or before start's day, when there is one
+func (r *UniverseRepository) FindForPeriod(start, end time.Time) ([]models.UniverseSnapshot, error) {
+	startDay := start.UTC().Truncate(24 * time.Hour)
+
+	var snapshots []models.UniverseSnapshot
+	var first models.UniverseSnapshot
+	err := r.db.Where("day <= ?", startDay).Order("day DESC").First(&first).Error
+	if err == nil {
+		snapshots = append(snapshots, first)
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	var rest []models.UniverseSnapshot
+	err = r.db.Where("day > ? AND day < ?", startDay, end).Order("day ASC").Find(&rest).Error
+	return append(snapshots, rest...), err
+}
@@ -3,7 +3,10 @@ package analysis
 import (
 	"CryptoTradeBot/internal/models"
 	"CryptoTradeBot/internal/services/indicators"
+	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -16,62 +19,530 @@ const (
 	// Lookback periods
 	ShortLook  = 5  // Immediate price action
 	MediumLook = 10 // Recent trend
+
+	// Candles needed before MACD 12/26/9 produces a value
+	MinIndicatorCandles = 26 + 9 - 1
+)
+
+// Target modes
+const (
+	TargetModePercent    = "percent"    // Fixed TargetProfit and StopLoss fractions of entry
+	TargetModeVolatility = "volatility" // Multiples of ATR
 )
 
+// Config holds the tunable analysis settings
+type Config struct {
+	TargetProfit  float64 `json:"target_profit"`
+	StopLoss      float64 `json:"stop_loss"`
+	MinConfidence float64 `json:"min_confidence"`
+
+	// EMA, RSI and threshold settings per timeframe, see IndicatorSet
+	Indicators IndicatorSet `json:"indicators"`
+
+	// Share of the confidence each check contributes when it agrees with the entry, summing to 1
+	TrendWeight float64 `json:"trend_weight"` // Fast over slow EMA alignment with momentum
+	RSIWeight   float64 `json:"rsi_weight"`   // RSI inside its band
+	MACDWeight  float64 `json:"macd_weight"`  // MACD on the momentum side of its signal line
+
+	GapMode            string `json:"gap_mode"`             // GapModeReset or GapModeFill
+	GapSuppressCandles int    `json:"gap_suppress_candles"` // Skip entries for this many candles after a gap, 0 disables
+
+	PatternWeight       float64 `json:"pattern_weight"`        // Confidence added by an agreeing pattern at full strength
+	PatternVetoStrength float64 `json:"pattern_veto_strength"` // Opposing patterns at or above this strength block the entry
+
+	LevelTargets     bool    `json:"level_targets"`      // Place stop and target at support/resistance instead of fixed percentages
+	PivotLookback    int     `json:"pivot_lookback"`     // Candles on each side of a swing high or low
+	LevelTolerance   float64 `json:"level_tolerance"`    // Relative distance within which swing points form one level
+	LevelBuffer      float64 `json:"level_buffer"`       // Stop is placed this far beyond the opposing level
+	MaxLevelDistance float64 `json:"max_level_distance"` // Levels further than this from entry fall back to percentage targets
+
+	// Tighten open positions' stops to just beyond 15m and 1h levels that form past entry, see ManagePosition
+	StructureStop bool `json:"structure_stop"`
+
+	TargetMode        string  `json:"target_mode"`         // TargetModePercent or TargetModeVolatility
+	ATRPeriod         int     `json:"atr_period"`          // ATR length on the analysis timeframe
+	ATRStopMultiple   float64 `json:"atr_stop_multiple"`   // Stop distance in ATRs
+	ATRTargetMultiple float64 `json:"atr_target_multiple"` // Target distance in ATRs
+	MinRewardRisk     float64 `json:"min_reward_risk"`     // Setups with a lower target to stop ratio are rejected, 0 disables
+
+	SuperTrendPeriod   int     `json:"supertrend_period"`   // ATR length of the 1h and 4h SuperTrend
+	SuperTrendMultiple float64 `json:"supertrend_multiple"` // Band distance in ATRs
+	RequireSuperTrend  bool    `json:"require_supertrend"`  // Only enter in the direction of the 1h SuperTrend
+	SuperTrendBoost    float64 `json:"supertrend_boost"`    // Confidence added when 1h and 4h SuperTrend agree with the entry
+
+	RequireIchimoku bool `json:"require_ichimoku"` // Only enter when the 4h Ichimoku bias agrees, neutral blocks entries
+
+	OBVPeriod      int     `json:"obv_period"`      // EMA length the On-Balance Volume is compared to for its trend
+	ProfileHours   int     `json:"profile_hours"`   // Hours of candles in the session volume profile
+	ProfileBuckets int     `json:"profile_buckets"` // Price buckets the session's range is split into
+	POCFilter      bool    `json:"poc_filter"`      // Skip entries right in front of the session's point of control
+	POCDistance    float64 `json:"poc_distance"`    // How close ahead of the entry the point of control blocks it, as a fraction
+
+	DailyBiasFilter bool    `json:"daily_bias_filter"` // Block entries against a bullish or bearish 1d bias
+	DailyBiasRSI    float64 `json:"daily_bias_rsi"`    // Distance of the 1d RSI from 50 a bias needs, see DailyBiasAnalyzer
+
+	// Timeframe whose candle closes trigger analysis, one of Cadences; the window stays 5m candles
+	Cadence string `json:"cadence"`
+	// Share of the confidence the cadence timeframe's confluence vote makes up when it is above the window's
+	CadenceWeight float64 `json:"cadence_weight"`
+
+	// Scale each entry's size by its confidence, see SizeMultiplier; off sizes every entry alike
+	ConfidenceSizing  bool    `json:"confidence_sizing"`
+	MinSizeMultiplier float64 `json:"min_size_multiplier"` // Size factor at min_confidence
+	MaxSizeMultiplier float64 `json:"max_size_multiplier"` // Size factor at full confidence
+
+	// Realized volatility windows and regime thresholds per timeframe, see VolatilitySet
+	Volatility VolatilitySet `json:"volatility"`
+
+	// Confirm live entries against the last minutes of aggressive buying and selling, see OrderFlow;
+	// backtests have no trades and skip it, as do live entries while the trade stream is unavailable
+	OrderFlowFilter    bool    `json:"order_flow_filter"`
+	OrderFlowImbalance float64 `json:"order_flow_imbalance"` // Imbalance towards the entry the flow needs, 0 to 1
+}
+
+// Cadences are the timeframes analysis can be scheduled on
+var Cadences = []string{models.PriceTimeFrame5m, models.PriceTimeFrame15m, models.PriceTimeFrame1h}
+
+// DefaultConfig returns the default analysis settings
+func DefaultConfig() Config {
+	return Config{
+		TargetProfit:  TargetProfit,
+		StopLoss:      StopLoss,
+		MinConfidence: MinConfidence,
+
+		TrendWeight: 0.4,
+		RSIWeight:   0.3,
+		MACDWeight:  0.3,
+
+		GapMode:            GapModeReset,
+		GapSuppressCandles: 3,
+
+		PatternWeight:       0.1,
+		PatternVetoStrength: 0.7,
+
+		LevelTargets:     false,
+		PivotLookback:    2,
+		LevelTolerance:   0.002,
+		LevelBuffer:      0.001,
+		MaxLevelDistance: 0.02,
+		StructureStop:    false,
+
+		TargetMode:        TargetModePercent,
+		ATRPeriod:         14,
+		ATRStopMultiple:   1.5,
+		ATRTargetMultiple: 2.5,
+		MinRewardRisk:     1.2,
+
+		SuperTrendPeriod:   10,
+		SuperTrendMultiple: 3.0,
+		RequireSuperTrend:  false,
+		SuperTrendBoost:    0.05,
+
+		OBVPeriod:      20,
+		ProfileHours:   24,
+		ProfileBuckets: 50,
+		POCFilter:      false,
+		POCDistance:    0.003,
+
+		DailyBiasFilter: false,
+		DailyBiasRSI:    5,
+
+		Cadence:       models.PriceTimeFrame5m,
+		CadenceWeight: 0.5,
+
+		ConfidenceSizing:  false,
+		MinSizeMultiplier: 0.5,
+		MaxSizeMultiplier: 1.5,
+
+		OrderFlowFilter:    false,
+		OrderFlowImbalance: 0.1,
+	}
+}
+
 type Analysis struct {
-	ema  *indicators.EMAService
-	rsi  *indicators.RSIService
-	macd *indicators.MACDService
+	ema        *indicators.EMAService
+	rsi        *indicators.RSIService
+	macd       *indicators.MACDService
+	atr        *indicators.ATRService
+	supertrend *indicators.SuperTrendService
+	ichimoku   *indicators.IchimokuService
+	obv        *indicators.OBVService
+	patterns   *PatternAnalyzer
+	levels     *SupportResistanceService
+	cache      *indicatorCache // Nil unless EnableIncremental was called
+	resamples  *resampleCache  // Likewise
+	config     Config
+
+	volatilities *VolatilityService
 }
 
 func NewAnalysis() *Analysis {
+	return NewAnalysisWithConfig(DefaultConfig())
+}
+
+// NewAnalysisWithConfig creates an Analysis using the given settings
+func NewAnalysisWithConfig(config Config) *Analysis {
 	return &Analysis{
-		ema:  indicators.NewEMAService(),
-		rsi:  indicators.NewRSIService(),
-		macd: indicators.NewMACDService(),
+		ema:        indicators.NewEMAService(),
+		rsi:        indicators.NewRSIService(),
+		macd:       indicators.NewMACDService(),
+		atr:        indicators.NewATRService(),
+		supertrend: indicators.NewSuperTrendService(),
+		ichimoku:   indicators.NewIchimokuService(),
+		obv:        indicators.NewOBVService(),
+		patterns:   NewPatternAnalyzer(),
+		levels:     NewSupportResistanceService(config.PivotLookback, config.LevelTolerance),
+		config:     config,
+
+		volatilities: NewVolatilityService(),
 	}
 }
 
+// Config returns the settings this Analysis was built with
+func (a *Analysis) Config() Config {
+	return a.config
+}
+
+// WarmUp returns the candles each timeframe needs before the analysis has meaningful values
+// Higher timeframes are resampled from the 5m window, so the window must span them too
+func (a *Analysis) WarmUp() map[string]int {
+	pivots := 2*a.config.PivotLookback + 1
+	warmUp := map[string]int{
+		models.PriceTimeFrame5m:  a.baseCandles(models.PriceTimeFrame5m),
+		models.PriceTimeFrame15m: max(a.config.Indicators.For(models.PriceTimeFrame15m).Candles(), pivots),
+		models.PriceTimeFrame1h:  max(a.config.Indicators.For(models.PriceTimeFrame1h).Candles(), pivots),
+	}
+
+	// SuperTrend reads closed candles only, so it is skipped entirely when it has no effect
+	if a.config.RequireSuperTrend || a.config.SuperTrendBoost > 0 {
+		for _, tf := range SuperTrendTimeFrames {
+			warmUp[tf] = max(warmUp[tf], a.superTrendWarmUp())
+		}
+	}
+	if a.config.RequireIchimoku {
+		for _, tf := range IchimokuTimeFrames {
+			warmUp[tf] = max(warmUp[tf], a.ichimokuWarmUp())
+		}
+	}
+
+	// The filter needs the whole session in the window, the reported profile uses what there is
+	if a.config.POCFilter {
+		session := time.Duration(a.config.ProfileHours) * time.Hour
+		warmUp[models.PriceTimeFrame5m] = max(warmUp[models.PriceTimeFrame5m], int(session/models.TimeFrameDurations[models.PriceTimeFrame5m]))
+	}
+	return warmUp
+}
+
+// Validate checks the settings are usable
+func (c Config) Validate() error {
+	if c.TargetProfit <= 0 || c.TargetProfit >= 1 {
+		return fmt.Errorf("target_profit must be between 0 and 1, got %v", c.TargetProfit)
+	}
+	if c.StopLoss <= 0 || c.StopLoss >= 1 {
+		return fmt.Errorf("stop_loss must be between 0 and 1, got %v", c.StopLoss)
+	}
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence must be between 0 and 1, got %v", c.MinConfidence)
+	}
+	if err := c.Indicators.Validate(); err != nil {
+		return err
+	}
+	if err := c.Volatility.Validate(); err != nil {
+		return err
+	}
+	if c.TrendWeight < 0 || c.RSIWeight < 0 || c.MACDWeight < 0 {
+		return fmt.Errorf("confidence weights cannot be negative")
+	}
+	if sum := c.TrendWeight + c.RSIWeight + c.MACDWeight; math.Abs(sum-1) > 1e-9 {
+		return fmt.Errorf("trend_weight, rsi_weight and macd_weight must sum to 1, got %v", sum)
+	}
+	if c.GapMode != GapModeReset && c.GapMode != GapModeFill {
+		return fmt.Errorf("unknown gap_mode %q", c.GapMode)
+	}
+	if c.GapSuppressCandles < 0 {
+		return fmt.Errorf("gap_suppress_candles cannot be negative")
+	}
+	if c.PatternWeight < 0 || c.PatternVetoStrength < 0 {
+		return fmt.Errorf("pattern settings cannot be negative")
+	}
+	if c.PivotLookback < 1 {
+		return fmt.Errorf("pivot_lookback must be at least 1, got %d", c.PivotLookback)
+	}
+	if c.LevelTolerance < 0 || c.LevelBuffer < 0 {
+		return fmt.Errorf("level settings cannot be negative")
+	}
+	if c.MaxLevelDistance <= 0 || c.MaxLevelDistance >= 1 {
+		return fmt.Errorf("max_level_distance must be between 0 and 1, got %v", c.MaxLevelDistance)
+	}
+	if c.TargetMode != TargetModePercent && c.TargetMode != TargetModeVolatility {
+		return fmt.Errorf("unknown target_mode %q", c.TargetMode)
+	}
+	if c.ATRPeriod < 1 || c.ATRPeriod >= MinIndicatorCandles {
+		return fmt.Errorf("atr_period must be between 1 and %d, got %d", MinIndicatorCandles-1, c.ATRPeriod)
+	}
+	if c.ATRStopMultiple <= 0 || c.ATRTargetMultiple <= 0 {
+		return fmt.Errorf("atr multiples must be positive")
+	}
+	if c.MinRewardRisk < 0 {
+		return fmt.Errorf("min_reward_risk cannot be negative")
+	}
+	if c.SuperTrendPeriod < 1 {
+		return fmt.Errorf("supertrend_period must be at least 1, got %d", c.SuperTrendPeriod)
+	}
+	if c.SuperTrendMultiple <= 0 {
+		return fmt.Errorf("supertrend_multiple must be positive, got %v", c.SuperTrendMultiple)
+	}
+	if c.SuperTrendBoost < 0 {
+		return fmt.Errorf("supertrend_boost cannot be negative")
+	}
+	if c.OBVPeriod < 1 {
+		return fmt.Errorf("obv_period must be at least 1, got %d", c.OBVPeriod)
+	}
+	if c.ProfileHours < 1 || c.ProfileBuckets < 2 {
+		return fmt.Errorf("profile_hours must be at least 1 and profile_buckets at least 2")
+	}
+	if c.POCDistance < 0 || c.POCDistance >= 1 {
+		return fmt.Errorf("poc_distance must be between 0 and 1, got %v", c.POCDistance)
+	}
+	if c.DailyBiasRSI < 0 || c.DailyBiasRSI >= 50 {
+		return fmt.Errorf("daily_bias_rsi must be between 0 and 50, got %v", c.DailyBiasRSI)
+	}
+	if !slices.Contains(Cadences, c.Cadence) {
+		return fmt.Errorf("cadence must be one of %s, got %q", strings.Join(Cadences, ", "), c.Cadence)
+	}
+	if c.CadenceWeight < 0 || c.CadenceWeight > 1 {
+		return fmt.Errorf("cadence_weight must be between 0 and 1, got %v", c.CadenceWeight)
+	}
+	if c.OrderFlowImbalance < 0 || c.OrderFlowImbalance >= 1 {
+		return fmt.Errorf("order_flow_imbalance must be between 0 and 1, got %v", c.OrderFlowImbalance)
+	}
+	if c.ConfidenceSizing && (c.MinSizeMultiplier <= 0 || c.MaxSizeMultiplier < c.MinSizeMultiplier) {
+		return fmt.Errorf("size multipliers must be positive with min_size_multiplier at most max_size_multiplier, got %v and %v",
+			c.MinSizeMultiplier, c.MaxSizeMultiplier)
+	}
+	return nil
+}
+
 // Analyze performs quick market analysis optimized for 1% moves
 func (a *Analysis) Analyze(prices []models.Price) *AnalysisResult {
+	return a.AnalyzeOnCadence(prices, "")
+}
+
+// AnalyzeOnCadence is Analyze triggered by the close of a cadence candle, see weightCadence
+// An empty cadence is an unscheduled analysis, weighting no timeframe
+func (a *Analysis) AnalyzeOnCadence(prices []models.Price, cadence string) *AnalysisResult {
 	if len(prices) < MediumLook {
 		return newInvalidResult(prices[len(prices)-1].Symbol, "insufficient data")
 	}
 
+	// Handle discontinuities such as exchange downtime
+	prices, sinceGap := a.prepareWindow(prices)
+	if sinceGap >= 0 && sinceGap < a.config.GapSuppressCandles {
+		return newInvalidResult(prices[len(prices)-1].Symbol, "recent data gap")
+	}
+
+	base := prices[len(prices)-1].TimeFrame
+	if len(prices) < a.baseCandles(base) {
+		return newInvalidResult(prices[len(prices)-1].Symbol, insufficientData(base))
+	}
+
 	// Calculate indicators
-	indicators := a.calculateIndicators(prices)
+	params := a.config.Indicators.For(base)
+	indicators, err := a.calculateIndicators(prices, params)
+	if err != nil {
+		return newInvalidResult(prices[len(prices)-1].Symbol, insufficientData(base))
+	}
+
+	// Realized volatility and its regime, reported alongside the decision
+	volatility := a.volatility(prices)
 
 	// Quick momentum check
 	momentum := a.checkMomentum(prices[len(prices)-ShortLook:])
 
 	// Volume analysis
-	volume := a.checkVolume(prices[len(prices)-ShortLook:])
+	volumeRatio := a.volumeRatio(prices[len(prices)-ShortLook:])
+	volume := volumeRatio > 1.2
 
 	// Calculate setup confidence
-	confidence := a.calculateConfidence(indicators, momentum, volume)
+	confidence := a.calculateConfidence(indicators, params, momentum, volume)
 
 	// Determine direction
 	direction := a.determineDirection(indicators, momentum)
 
-	if confidence < MinConfidence {
-		return newInvalidResult(prices[len(prices)-1].Symbol, "low confidence")
+	// Per-timeframe breakdown, reported alongside the decision and weighted by the cadence that fired
+	confluence := a.confluence(prices)
+	confidence = a.weightCadence(confidence, direction, cadence, base, confluence)
+
+	// Candlestick pattern confirmation
+	pattern := a.patterns.Analyze(prices)
+	confidence, vetoed := a.applyPattern(confidence, direction, pattern)
+	if vetoed {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "opposing pattern")
+		result.Confluence = confluence
+		return result
+	}
+
+	// Higher timeframe regime
+	var trends map[string]int
+	if a.config.RequireSuperTrend || a.config.SuperTrendBoost > 0 {
+		trends = a.superTrends(prices)
+		var agrees bool
+		confidence, agrees = a.applySuperTrend(confidence, direction, trends)
+		if !agrees {
+			result := newInvalidResult(prices[len(prices)-1].Symbol, "supertrend disagrees")
+			result.Confluence = confluence
+			result.SuperTrend = trends
+			return result
+		}
+	}
+
+	// Higher timeframe Ichimoku bias, 4h must agree with the entry
+	var biases map[string]int
+	if a.config.RequireIchimoku && direction != "" {
+		biases = a.ichimokuBiases(prices)
+		if !ichimokuAgrees(direction, biases) {
+			result := newInvalidResult(prices[len(prices)-1].Symbol, "ichimoku disagrees")
+			result.Confluence = confluence
+			result.SuperTrend = trends
+			result.Ichimoku = biases
+			return result
+		}
+	}
+
+	if confidence < a.config.MinConfidence {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "low confidence")
+		result.Confluence = confluence
+		return result
+	}
+
+	// RSI and MACD alone can clear the threshold while trend and momentum disagree on a side
+	if direction == "" {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "no direction")
+		result.Confluence = confluence
+		return result
 	}
 
 	currentPrice := prices[len(prices)-1].Close
 
-	return &AnalysisResult{
+	// Session volume profile, a heavy node just ahead of the entry tends to stall the move
+	flow := a.volumeData(prices)
+	if a.config.POCFilter && a.nearVolumeNode(direction, currentPrice, flow) {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "near volume node")
+		result.Confluence = confluence
+		result.Volume = flow
+		return result
+	}
+
+	// Volatility for ATR based exits, unusable during warm-up or on flat data
+	atr := a.calculateATR(prices)
+	if a.config.TargetMode == TargetModeVolatility && !(atr > 0) {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "atr unavailable")
+		result.Confluence = confluence
+		return result
+	}
+
+	// Structure from the higher timeframes
+	levels := a.levels.Levels(a.resample(prices, models.PriceTimeFrame15m), a.resample(prices, models.PriceTimeFrame1h))
+	support, resistance := NearestLevels(levels, currentPrice)
+
+	takeProfit, stopLoss, targetAtLevel, stopAtLevel := a.placeExits(currentPrice, direction, atr, support, resistance)
+
+	risk := math.Abs(currentPrice - stopLoss)
+	if a.config.MinRewardRisk > 0 && (risk == 0 || math.Abs(takeProfit-currentPrice)/risk < a.config.MinRewardRisk) {
+		result := newInvalidResult(prices[len(prices)-1].Symbol, "reward risk too low")
+		result.Confluence = confluence
+		return result
+	}
+
+	result := &AnalysisResult{
 		Symbol:     prices[len(prices)-1].Symbol,
 		Timestamp:  time.Now(),
 		IsValid:    true,
 		Direction:  direction,
 		EntryPrice: currentPrice,
-		TakeProfit: calculateTarget(currentPrice, direction),
-		StopLoss:   calculateStop(currentPrice, direction),
+		TakeProfit: takeProfit,
+		StopLoss:   stopLoss,
 		Confidence: confidence,
+		Pattern:    pattern,
+		Support:    support,
+		Resistance: resistance,
+		Confluence: confluence,
+		TargetMode: a.config.TargetMode,
+		ATR:        atr,
+		SuperTrend: trends,
+		Ichimoku:   biases,
+		Volume:     flow,
+	}
+	result.Indicators, result.VolumeRatio = indicators, volumeRatio
+	result.Volatility = volatility
+	result.TargetAtLevel, result.StopAtLevel = targetAtLevel, stopAtLevel
+	return result
+}
+
+// placeExits returns the take profit and stop loss for an entry, and whether each was placed at a level
+// With LevelTargets the stop sits just beyond the opposing level and the target at the favorable one,
+// each falling back to the TargetMode placement when no level is within MaxLevelDistance
+func (a *Analysis) placeExits(price float64, direction string, atr float64, support, resistance *Level) (float64, float64, bool, bool) {
+	targetDistance := price * a.config.TargetProfit
+	stopDistance := price * a.config.StopLoss
+	if a.config.TargetMode == TargetModeVolatility {
+		targetDistance = atr * a.config.ATRTargetMultiple
+		stopDistance = atr * a.config.ATRStopMultiple
+	}
+
+	takeProfit := a.calculateTarget(price, targetDistance, direction)
+	stopLoss := a.calculateStop(price, stopDistance, direction)
+
+	if !a.config.LevelTargets {
+		return takeProfit, stopLoss, false, false
+	}
+
+	favorable, opposing := resistance, support
+	buffer := -a.config.LevelBuffer
+	if direction == "short" {
+		favorable, opposing = support, resistance
+		buffer = a.config.LevelBuffer
+	}
+
+	targetAtLevel, stopAtLevel := false, false
+	if favorable != nil && a.withinLevelDistance(price, favorable.Price) {
+		takeProfit = favorable.Price
+		targetAtLevel = true
+	}
+	if opposing != nil {
+		if stop := opposing.Price * (1 + buffer); a.withinLevelDistance(price, stop) {
+			stopLoss = stop
+			stopAtLevel = true
+		}
+	}
+
+	return takeProfit, stopLoss, targetAtLevel, stopAtLevel
+}
+
+func (a *Analysis) withinLevelDistance(price, level float64) bool {
+	return math.Abs(level-price)/price <= a.config.MaxLevelDistance
+}
+
+// applyPattern adjusts confidence for a pattern that agrees with the direction
+// and reports whether a strong opposing pattern should veto the entry
+func (a *Analysis) applyPattern(confidence float64, direction string, pattern *PatternResult) (float64, bool) {
+	if pattern == nil || pattern.Signal == PatternNeutral || direction == "" {
+		return confidence, false
+	}
+
+	want := PatternBullish
+	if direction == "short" {
+		want = PatternBearish
 	}
+
+	if pattern.Signal != want {
+		return confidence, pattern.Strength >= a.config.PatternVetoStrength
+	}
+
+	return math.Min(confidence+a.config.PatternWeight*pattern.Strength, 1.0), false
 }
 
-// checkMomentum analyzes short-term price movement
+// checkMomentum returns the net relative close change over prices, positive rising and negative falling
 func (a *Analysis) checkMomentum(prices []models.Price) float64 {
 	if len(prices) < 2 {
 		return 0
@@ -83,11 +554,28 @@ func (a *Analysis) checkMomentum(prices []models.Price) float64 {
 		changes[i-1] = (prices[i].Close - prices[i-1].Close) / prices[i-1].Close
 	}
 
-	// Return recent momentum strength
-	return math.Abs(sum(changes))
+	// Signed, so a falling market reads as short momentum
+	return sum(changes)
 }
 
-func (a *Analysis) calculateIndicators(prices []models.Price) *IndicatorValues {
+// calculateIndicators returns the latest indicator values
+// It fails rather than read values from before an indicator's first valid index
+func (a *Analysis) calculateIndicators(prices []models.Price, params IndicatorParams) (*IndicatorValues, error) {
+	// The incremental state has no RSI smoothing, smoothed settings are recomputed every time
+	if a.cache != nil && params.RSISmoothing <= 1 {
+		if snapshot := a.cache.indicators(prices, params.periods()); snapshot != nil {
+			return &IndicatorValues{
+				RSI:       snapshot.RSI,
+				MACD:      snapshot.MACD,
+				Signal:    snapshot.Signal,
+				Histogram: snapshot.Histogram,
+				EMA8:      snapshot.EMAFast,
+				EMA21:     snapshot.EMASlow,
+				Volume:    prices[len(prices)-1].Volume,
+			}, nil
+		}
+	}
+
 	// Extract close prices
 	closes := make([]float64, len(prices))
 	volumes := make([]float64, len(prices))
@@ -97,65 +585,84 @@ func (a *Analysis) calculateIndicators(prices []models.Price) *IndicatorValues {
 	}
 
 	// Calculate EMAs
-	ema8 := a.ema.Calculate(closes, 8)
-	ema21 := a.ema.Calculate(closes, 21)
+	emaFast := a.ema.CalculateValid(closes, params.EMAFast)
+	emaSlow := a.ema.CalculateValid(closes, params.EMASlow)
 
 	// Calculate RSI
-	rsi := a.rsi.Calculate(closes, 14)
+	rsi := a.calculateRSI(closes, params)
 
 	// Calculate MACD
 	macdResult := a.macd.Calculate(closes, 12, 26, 9)
+	if macdResult == nil {
+		return nil, fmt.Errorf("insufficient data for indicators: %d candles", len(closes))
+	}
+	macd, signal, histogram := macdResult.Valid()
+
+	// Only valid values, all ending on the latest candle
+	aligned := indicators.Align(emaFast, emaSlow, rsi, macd, signal, histogram)
+	if aligned == nil {
+		return nil, fmt.Errorf("insufficient data for indicators: %d candles", len(closes))
+	}
+	last := len(aligned[0]) - 1
 
 	// Get latest volume
 	currentVolume := volumes[len(volumes)-1]
 
 	return &IndicatorValues{
-		RSI:       rsi[len(rsi)-1],
-		MACD:      macdResult.MACD[len(macdResult.MACD)-1],
-		Signal:    macdResult.Signal[len(macdResult.Signal)-1],
-		Histogram: macdResult.Histogram[len(macdResult.Histogram)-1],
-		EMA8:      ema8[len(ema8)-1],
-		EMA21:     ema21[len(ema21)-1],
+		EMA8:      aligned[0][last],
+		EMA21:     aligned[1][last],
+		RSI:       aligned[2][last],
+		MACD:      aligned[3][last],
+		Signal:    aligned[4][last],
+		Histogram: aligned[5][last],
 		Volume:    currentVolume,
-	}
+	}, nil
 }
 
-func (a *Analysis) checkVolume(prices []models.Price) bool {
+// volumeRatio returns the latest volume over the average of the candles before it, 0 without any
+func (a *Analysis) volumeRatio(prices []models.Price) float64 {
 	if len(prices) < 2 {
-		return false
+		return 0
 	}
 
-	// Calculate average volume
+	// Calculate average volume, ignoring synthetic gap candles
 	var avgVolume float64
+	count := 0
 	for i := 0; i < len(prices)-1; i++ {
+		if prices[i].IsGapFill {
+			continue
+		}
 		avgVolume += prices[i].Volume
+		count++
+	}
+	if count == 0 || avgVolume == 0 {
+		return 0
 	}
-	avgVolume /= float64(len(prices) - 1)
+	avgVolume /= float64(count)
 
-	// Check if current volume is higher
-	return prices[len(prices)-1].Volume > avgVolume*1.2
+	return prices[len(prices)-1].Volume / avgVolume
 }
 
 // calculateConfidence determines entry probability
-func (a *Analysis) calculateConfidence(ind *IndicatorValues, momentum float64, volume bool) float64 {
+func (a *Analysis) calculateConfidence(ind *IndicatorValues, params IndicatorParams, momentum float64, volume bool) float64 {
 	baseConf := 0.0
 
 	// Trend alignment check
 	if ind.EMA8 > ind.EMA21 && momentum > 0 {
-		baseConf += 0.4
+		baseConf += a.config.TrendWeight
 	} else if ind.EMA8 < ind.EMA21 && momentum < 0 {
-		baseConf += 0.4
+		baseConf += a.config.TrendWeight
 	}
 
 	// RSI check (favor swings back from extremes)
-	if ind.RSI > 40 && ind.RSI < 60 {
-		baseConf += 0.3
+	if ind.RSI > params.RSILow && ind.RSI < params.RSIHigh {
+		baseConf += a.config.RSIWeight
 	}
 
 	// MACD confirmation
 	if (ind.MACD > ind.Signal && momentum > 0) ||
 		(ind.MACD < ind.Signal && momentum < 0) {
-		baseConf += 0.3
+		baseConf += a.config.MACDWeight
 	}
 
 	// Volume adjustment
@@ -181,18 +688,37 @@ func (a *Analysis) determineDirection(ind *IndicatorValues, momentum float64) st
 }
 
 // Helper functions for price calculations
-func calculateTarget(price float64, direction string) float64 {
+func (a *Analysis) calculateTarget(price, distance float64, direction string) float64 {
 	if direction == "long" {
-		return price * (1 + TargetProfit)
+		return price + distance
 	}
-	return price * (1 - TargetProfit)
+	return price - distance
 }
 
-func calculateStop(price float64, direction string) float64 {
+func (a *Analysis) calculateStop(price, distance float64, direction string) float64 {
 	if direction == "long" {
-		return price * (1 - StopLoss)
+		return price - distance
+	}
+	return price + distance
+}
+
+// calculateATR returns the latest ATR, ignoring synthetic gap candles, or 0 when there is too little data
+func (a *Analysis) calculateATR(prices []models.Price) float64 {
+	var highs, lows, closes []float64
+	for _, p := range prices {
+		if p.IsGapFill {
+			continue
+		}
+		highs = append(highs, p.High)
+		lows = append(lows, p.Low)
+		closes = append(closes, p.Close)
+	}
+
+	atr := a.atr.CalculateValid(highs, lows, closes, a.config.ATRPeriod)
+	if len(atr) == 0 || math.IsNaN(atr[len(atr)-1]) {
+		return 0
 	}
-	return price * (1 + StopLoss)
+	return atr[len(atr)-1]
 }
 
 func sum(values []float64) float64 {
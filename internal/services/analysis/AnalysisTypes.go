@@ -14,6 +14,57 @@ type AnalysisResult struct {
 	StopLoss   float64
 	Confidence float64
 	Reason     string
+	Pattern    *PatternResult
+	Support    *Level // Nearest level below entry, nil when none
+	Resistance *Level // Nearest level above entry, nil when none
+	Confluence Confluence
+	TargetMode string                 // Mode that placed TakeProfit and StopLoss
+	ATR        float64                // ATR at entry, 0 when unavailable
+	SuperTrend map[string]int         // SuperTrend direction per higher timeframe, missing until warmed up
+	Ichimoku   map[string]int         // Ichimoku bias per higher timeframe, missing until its cloud is warmed up
+	Volume     *VolumeData            // OBV trend and session volume profile at entry
+	DailyBias  *DailyBias             // Macro regime from the closed 1d candles, nil until warmed up
+	Volatility map[string]*Volatility // Realized volatility and regime per timeframe, missing until warmed up
+	Cadence    string                 // Timeframe whose candle close triggered the analysis, empty when unscheduled
+
+	// Factor the entry's size is scaled by for its confidence, 0 when unset and read as 1, see SizeFactor
+	SizeMultiplier float64
+
+	// Exits placed at a support or resistance level instead of by TargetMode
+	TargetAtLevel bool
+	StopAtLevel   bool
+
+	// Order flow the entry was confirmed against, nil unless the order flow filter ran; Unavailable marks
+	// an entry the filter had to let through because the trade stream was down
+	OrderFlow            *OrderFlow
+	OrderFlowUnavailable bool
+
+	// Analysis timeframe indicators and latest volume over the recent average, nil and 0 on rejected signals
+	Indicators  *IndicatorValues
+	VolumeRatio float64
+
+	// Entry context restored from storage, e.g. for a limit entry filling, returned as is by EntryContext
+	Context *EntryContext
+}
+
+// AtFill returns a copy of the result entered at fill instead of EntryPrice
+// Exits placed as percentages keep their distance as a fraction of the entry; ATR and level
+// exits are price levels computed before the entry and stay where they are
+func (r *AnalysisResult) AtFill(fill float64) *AnalysisResult {
+	filled := *r
+	filled.EntryPrice = fill
+	if r.TargetMode != TargetModePercent || r.EntryPrice <= 0 {
+		return &filled
+	}
+
+	scale := fill / r.EntryPrice
+	if !r.TargetAtLevel {
+		filled.TakeProfit *= scale
+	}
+	if !r.StopAtLevel {
+		filled.StopLoss *= scale
+	}
+	return &filled
 }
 
 type IndicatorValues struct {
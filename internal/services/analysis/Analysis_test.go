@@ -0,0 +1,149 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+// swinging returns the zigzag window with every price's distance from 100 scaled by k, the same
+// shape and signals at k times the volatility
+func swinging(k float64) []models.Price {
+	prices := zigzagCandles("BTCUSDT", testStart, 250)
+	scale := func(v float64) float64 { return 100 + (v-100)*k }
+	for i := range prices {
+		p := &prices[i]
+		p.Open, p.High, p.Low, p.Close = scale(p.Open), scale(p.High), scale(p.Low), scale(p.Close)
+	}
+	return prices
+}
+
+func volatilityConfig() Config {
+	config := DefaultConfig()
+	config.TargetMode = TargetModeVolatility
+	return config
+}
+
+func TestVolatilityTargetsScaleWithATR(t *testing.T) {
+	config := volatilityConfig()
+	calm := NewAnalysisWithConfig(config).Analyze(swinging(1))
+	wild := NewAnalysisWithConfig(config).Analyze(swinging(10))
+	for name, result := range map[string]*AnalysisResult{"calm": calm, "wild": wild} {
+		if !result.IsValid {
+			t.Fatalf("%s series: %s, want a valid entry", name, result.Reason)
+		}
+		if result.TargetMode != TargetModeVolatility || !(result.ATR > 0) {
+			t.Errorf("%s series recorded mode %q and ATR %v", name, result.TargetMode, result.ATR)
+		}
+
+		// Both exits sit at their multiple of the ATR the result records
+		stop := math.Abs(result.EntryPrice - result.StopLoss)
+		target := math.Abs(result.TakeProfit - result.EntryPrice)
+		if math.Abs(stop-config.ATRStopMultiple*result.ATR) > 1e-9 || math.Abs(target-config.ATRTargetMultiple*result.ATR) > 1e-9 {
+			t.Errorf("%s series: stop %v and target %v away at ATR %v, want %v and %v ATRs",
+				name, stop, target, result.ATR, config.ATRStopMultiple, config.ATRTargetMultiple)
+		}
+	}
+
+	// Ten times the swing, ten times the room, where a percentage stop keeps its fraction
+	calmStop := math.Abs(calm.EntryPrice - calm.StopLoss)
+	wildStop := math.Abs(wild.EntryPrice - wild.StopLoss)
+	if ratio := wildStop / calmStop; math.Abs(ratio-10) > 1e-6 {
+		t.Errorf("stop distances %v and %v, ratio %v, want 10", calmStop, wildStop, ratio)
+	}
+	for _, result := range []*AnalysisResult{NewAnalysis().Analyze(swinging(1)), NewAnalysis().Analyze(swinging(10))} {
+		if fraction := math.Abs(result.EntryPrice-result.StopLoss) / result.EntryPrice; math.Abs(fraction-StopLoss) > 1e-9 {
+			t.Errorf("percent stop %v of entry, want %v on either series", fraction, StopLoss)
+		}
+	}
+}
+
+func TestVolatilityTargetsRejectLowRewardRisk(t *testing.T) {
+	config := volatilityConfig()
+	config.ATRTargetMultiple = 1.5 // 1:1 against the 1.5 ATR stop, below MinRewardRisk 1.2
+	if result := NewAnalysisWithConfig(config).Analyze(swinging(1)); result.IsValid || result.Reason != "reward risk too low" {
+		t.Errorf("Analyze() = valid %v, %q, want rejected for reward risk", result.IsValid, result.Reason)
+	}
+
+	config.MinRewardRisk = 0
+	if result := NewAnalysisWithConfig(config).Analyze(swinging(1)); !result.IsValid {
+		t.Errorf("Analyze() without a minimum = %q, want valid", result.Reason)
+	}
+}
+
+func TestVolatilityTargetsNeedATR(t *testing.T) {
+	a := NewAnalysisWithConfig(volatilityConfig())
+
+	// Flat candles have no range to size exits from
+	prices := swinging(0)
+	if atr := a.calculateATR(prices); atr != 0 {
+		t.Fatalf("calculateATR() on flat candles = %v", atr)
+	}
+	if atr := a.calculateATR(prices[:14]); atr != 0 {
+		t.Errorf("calculateATR() before its period = %v, want 0", atr)
+	}
+	if result := a.Analyze(prices); result.IsValid {
+		t.Errorf("Analyze() placed exits %v/%v without an ATR", result.TakeProfit, result.StopLoss)
+	}
+}
+
+func TestIndicatorsRefuseValuesBeforeMACDIsValid(t *testing.T) {
+	a := NewAnalysis()
+	prices := zigzagCandles("BTCUSDT", testStart, MinIndicatorCandles)
+	short := prices[1:]
+	if _, err := a.calculateIndicators(short, DefaultIndicatorParams()); err == nil {
+		t.Errorf("calculateIndicators() read values from %d candles, MACD's signal needs %d", len(short), MinIndicatorCandles)
+	}
+	if _, err := a.calculateIndicators(prices, DefaultIndicatorParams()); err != nil {
+		t.Errorf("calculateIndicators() on %d candles error = %v", len(prices), err)
+	}
+	if result := a.Analyze(short); result.IsValid || result.Reason != insufficientData(models.PriceTimeFrame5m) {
+		t.Errorf("Analyze() on a short window = valid %v, %q, want insufficient data", result.IsValid, result.Reason)
+	}
+}
+
+// inRange reports whether every value lies within the prices' range, which no zero padding does
+func inRange(prices []models.Price, values ...float64) bool {
+	low, high := prices[0].Low, prices[0].High
+	for _, p := range prices {
+		low, high = math.Min(low, p.Low), math.Max(high, p.High)
+	}
+	for _, v := range values {
+		if v < low || v > high {
+			return false
+		}
+	}
+	return true
+}
+
+func TestMinimumWindowsReadNoPadding(t *testing.T) {
+	a := NewAnalysis()
+	params := DefaultIndicatorParams()
+
+	prices := zigzagCandles("BTCUSDT", testStart, MinIndicatorCandles)
+	values, err := a.calculateIndicators(prices, params)
+	if err != nil {
+		t.Fatalf("calculateIndicators() at the minimum window error = %v", err)
+	}
+	if !inRange(prices, values.EMA8, values.EMA21) || !(values.RSI > 0 && values.RSI < 100) {
+		t.Errorf("EMA %v/%v, RSI %v at the minimum window, want values from real data", values.EMA8, values.EMA21, values.RSI)
+	}
+	closes := make([]float64, len(prices))
+	for i, p := range prices {
+		closes[i] = p.Close
+	}
+	macd := a.macd.Calculate(closes, 12, 26, 9)
+	if last := len(closes) - 1; values.MACD != macd.MACD[last] || values.Signal != macd.Signal[last] || values.Signal == 0 {
+		t.Errorf("MACD %v signal %v, want %v and %v from the last valid index", values.MACD, values.Signal, macd.MACD[last], macd.Signal[last])
+	}
+
+	// A confluence timeframe votes on its own minimum too
+	short := prices[:params.Candles()]
+	fast, slow, rsi, ok := a.trend(short, params)
+	if !ok || !inRange(short, fast, slow) || !(rsi > 0 && rsi < 100) {
+		t.Errorf("trend() on %d candles = %v/%v/%v, %v, want values from real data", len(short), fast, slow, rsi, ok)
+	}
+	if _, _, _, ok := a.trend(prices[:params.EMASlow-1], params); ok {
+		t.Errorf("trend() on %d candles reported values, EMA %d needs %d", params.EMASlow-1, params.EMASlow, params.EMASlow)
+	}
+}
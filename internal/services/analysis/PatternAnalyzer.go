@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+)
+
+// Pattern signals
+const (
+	PatternBullish = 1
+	PatternBearish = -1
+	PatternNeutral = 0
+)
+
+type PatternResult struct {
+	Name     string
+	Signal   int     // PatternBullish, PatternBearish or PatternNeutral
+	Strength float64 // 0 to 1
+}
+
+type PatternAnalyzer struct{}
+
+func NewPatternAnalyzer() *PatternAnalyzer {
+	return &PatternAnalyzer{}
+}
+
+// Analyze returns the strongest candlestick pattern formed by the latest candles, or nil if none
+// Directional patterns take precedence over neutral ones
+func (p *PatternAnalyzer) Analyze(prices []models.Price) *PatternResult {
+	if len(prices) < 3 {
+		return nil
+	}
+
+	detectors := []func([]models.Price) *PatternResult{
+		p.engulfing,
+		p.pinbar,
+		p.higherLows,
+		p.doji,
+		p.insideBar,
+	}
+
+	var best *PatternResult
+	for _, detect := range detectors {
+		result := detect(prices)
+		if result == nil {
+			continue
+		}
+		if best == nil || outranks(result, best) {
+			best = result
+		}
+	}
+
+	return best
+}
+
+// engulfing detects a candle whose body fully covers the opposite-colored body before it
+func (p *PatternAnalyzer) engulfing(prices []models.Price) *PatternResult {
+	prev, cur := prices[len(prices)-2], prices[len(prices)-1]
+	prevBody, curBody := body(prev), body(cur)
+	if prevBody == 0 || curBody <= prevBody {
+		return nil
+	}
+
+	strength := math.Min(curBody/(prevBody*2), 1)
+
+	if prev.Close < prev.Open && cur.Close > cur.Open &&
+		cur.Open <= prev.Close && cur.Close >= prev.Open {
+		return &PatternResult{Name: "bullish_engulfing", Signal: PatternBullish, Strength: strength}
+	}
+
+	if prev.Close > prev.Open && cur.Close < cur.Open &&
+		cur.Open >= prev.Close && cur.Close <= prev.Open {
+		return &PatternResult{Name: "bearish_engulfing", Signal: PatternBearish, Strength: strength}
+	}
+
+	return nil
+}
+
+// pinbar detects a long rejection wick at least twice the body
+func (p *PatternAnalyzer) pinbar(prices []models.Price) *PatternResult {
+	cur := prices[len(prices)-1]
+	r := cur.High - cur.Low
+	if r == 0 {
+		return nil
+	}
+
+	b := body(cur)
+	upper := cur.High - math.Max(cur.Open, cur.Close)
+	lower := math.Min(cur.Open, cur.Close) - cur.Low
+
+	if lower >= 2*b && lower/r >= 0.6 {
+		return &PatternResult{Name: "bullish_pinbar", Signal: PatternBullish, Strength: lower / r}
+	}
+
+	if upper >= 2*b && upper/r >= 0.6 {
+		return &PatternResult{Name: "bearish_pinbar", Signal: PatternBearish, Strength: upper / r}
+	}
+
+	return nil
+}
+
+// higherLows detects three rising lows, or three falling highs for the bearish case
+func (p *PatternAnalyzer) higherLows(prices []models.Price) *PatternResult {
+	a, b, c := prices[len(prices)-3], prices[len(prices)-2], prices[len(prices)-1]
+
+	if a.Low < b.Low && b.Low < c.Low {
+		return &PatternResult{Name: "higher_lows", Signal: PatternBullish, Strength: 0.5}
+	}
+
+	if a.High > b.High && b.High > c.High {
+		return &PatternResult{Name: "lower_highs", Signal: PatternBearish, Strength: 0.5}
+	}
+
+	return nil
+}
+
+// doji detects an indecision candle whose body is a small fraction of its range
+func (p *PatternAnalyzer) doji(prices []models.Price) *PatternResult {
+	cur := prices[len(prices)-1]
+	r := cur.High - cur.Low
+	if r == 0 || body(cur)/r > 0.1 {
+		return nil
+	}
+
+	return &PatternResult{Name: "doji", Signal: PatternNeutral, Strength: 1 - body(cur)/r}
+}
+
+// insideBar detects a candle contained within the previous candle's range
+func (p *PatternAnalyzer) insideBar(prices []models.Price) *PatternResult {
+	prev, cur := prices[len(prices)-2], prices[len(prices)-1]
+	if cur.High < prev.High && cur.Low > prev.Low {
+		return &PatternResult{Name: "inside_bar", Signal: PatternNeutral, Strength: 0.5}
+	}
+
+	return nil
+}
+
+// outranks reports whether a should be preferred over b
+func outranks(a, b *PatternResult) bool {
+	if (a.Signal != PatternNeutral) != (b.Signal != PatternNeutral) {
+		return a.Signal != PatternNeutral
+	}
+	return a.Strength > b.Strength
+}
+
+func body(price models.Price) float64 {
+	return math.Abs(price.Close - price.Open)
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+// ohlc returns a candle with the given prices
+func ohlc(open, high, low, close float64) models.Price {
+	return models.Price{Symbol: "BTCUSDT", TimeFrame: models.PriceTimeFrame5m, Open: open, High: high, Low: low, Close: close}
+}
+
+func TestPatternAnalyzerDetects(t *testing.T) {
+	// Flat lead-in candles that form no pattern of their own
+	lead := ohlc(100, 101, 99, 100.5)
+	tests := []struct {
+		name   string
+		last   []models.Price
+		want   string
+		signal int
+	}{
+		{"bullish engulfing", []models.Price{ohlc(101, 101.2, 99.8, 100), ohlc(99.9, 102.2, 99.7, 102)}, "bullish_engulfing", PatternBullish},
+		{"bearish engulfing", []models.Price{ohlc(100, 101.2, 99.8, 101), ohlc(101.1, 101.3, 98.8, 99)}, "bearish_engulfing", PatternBearish},
+		{"bullish pinbar", []models.Price{lead, ohlc(100.6, 100.8, 97, 100.7)}, "bullish_pinbar", PatternBullish},
+		{"bearish pinbar", []models.Price{lead, ohlc(100.4, 104, 100.2, 100.3)}, "bearish_pinbar", PatternBearish},
+		{"doji", []models.Price{lead, ohlc(100, 101.5, 98.4, 100.05)}, "doji", PatternNeutral},
+		{"inside bar", []models.Price{ohlc(100, 103, 97, 101), ohlc(100.5, 101.5, 99.5, 100.8)}, "inside_bar", PatternNeutral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prices := append([]models.Price{lead}, tt.last...)
+			got := NewPatternAnalyzer().Analyze(prices)
+			if got == nil || got.Name != tt.want || got.Signal != tt.signal {
+				t.Fatalf("Analyze() = %+v, want %s", got, tt.want)
+			}
+			if got.Strength <= 0 || got.Strength > 1 {
+				t.Errorf("Strength = %v, want within (0, 1]", got.Strength)
+			}
+		})
+	}
+}
+
+func TestPatternAnalyzerPrefersDirectionalPatterns(t *testing.T) {
+	// An inside bar that also closes the third higher low
+	prices := []models.Price{ohlc(100, 103, 97, 101), ohlc(101, 103.5, 97.5, 102), ohlc(101.9, 103, 98, 102.1)}
+	if got := NewPatternAnalyzer().Analyze(prices); got == nil || got.Signal != PatternBullish {
+		t.Errorf("Analyze() = %+v, want the bullish pattern over the neutral one", got)
+	}
+}
+
+func TestApplyPattern(t *testing.T) {
+	a := NewAnalysis()
+	strong := &PatternResult{Name: "bullish_engulfing", Signal: PatternBullish, Strength: 0.8}
+	weak := &PatternResult{Name: "higher_lows", Signal: PatternBullish, Strength: 0.5}
+	tests := []struct {
+		name       string
+		confidence float64
+		direction  string
+		pattern    *PatternResult
+		want       float64
+		vetoed     bool
+	}{
+		{"agreement boosts", 0.7, "long", strong, 0.78, false},
+		{"boost is capped", 0.95, "long", &PatternResult{Signal: PatternBullish, Strength: 1}, 1, false},
+		{"strong disagreement vetoes", 0.7, "short", strong, 0.7, true},
+		{"weak disagreement only withholds the boost", 0.7, "short", weak, 0.7, false},
+		{"no pattern is neutral", 0.7, "long", nil, 0.7, false},
+		{"neutral pattern is neutral", 0.7, "long", &PatternResult{Name: "doji", Signal: PatternNeutral, Strength: 1}, 0.7, false},
+		{"no direction is neutral", 0.7, "", strong, 0.7, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, vetoed := a.applyPattern(tt.confidence, tt.direction, tt.pattern)
+			if math.Abs(got-tt.want) > 1e-9 || vetoed != tt.vetoed {
+				t.Errorf("applyPattern() = %v, %v, want %v, %v", got, vetoed, tt.want, tt.vetoed)
+			}
+		})
+	}
+}
+
+func TestAnalyzeVetoesLongOnBearishEngulfing(t *testing.T) {
+	prices := zigzagCandles("BTCUSDT", testStart, 300)
+	if result := NewAnalysis().Analyze(prices); !result.IsValid || result.Direction != "long" {
+		t.Fatalf("fixture does not signal a long: %+v", result)
+	}
+
+	// The last candle opens above the previous close and falls through its open, the previous body
+	// kept small so the engulfing is strong while the last few candles still net a rise
+	prev := &prices[len(prices)-2]
+	prev.Open = prev.Close - 0.05
+	last := &prices[len(prices)-1]
+	last.Open = prev.Close + 0.01
+	last.Close = prev.Open - 0.06
+	last.High = last.Open + 0.01
+	last.Low = last.Close - 0.01
+	result := NewAnalysis().Analyze(prices)
+	if result.IsValid || result.Reason != "opposing pattern" {
+		t.Errorf("Analyze() = valid %v for %q, want rejected for an opposing pattern", result.IsValid, result.Reason)
+	}
+}
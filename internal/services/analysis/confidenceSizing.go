@@ -0,0 +1,26 @@
+package analysis
+
+import "math"
+
+// SizeMultiplier returns the factor an entry of confidence scales its size by: MinSizeMultiplier at
+// MinConfidence rising linearly to MaxSizeMultiplier at full confidence, clamped to the two
+// It returns 1 when ConfidenceSizing is off
+func (c Config) SizeMultiplier(confidence float64) float64 {
+	if !c.ConfidenceSizing {
+		return 1
+	}
+	if c.MinConfidence >= 1 {
+		return c.MaxSizeMultiplier
+	}
+	share := (confidence - c.MinConfidence) / (1 - c.MinConfidence)
+	share = math.Max(0, math.Min(1, share))
+	return c.MinSizeMultiplier + share*(c.MaxSizeMultiplier-c.MinSizeMultiplier)
+}
+
+// SizeFactor returns the factor the entry's size is scaled by for its confidence, 1 when unset
+func (r *AnalysisResult) SizeFactor() float64 {
+	if r.SizeMultiplier <= 0 {
+		return 1
+	}
+	return r.SizeMultiplier
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSizeMultiplierScalesWithConfidence(t *testing.T) {
+	on := DefaultConfig()
+	on.ConfidenceSizing = true // 0.5x at the 0.7 minimum up to 1.5x at full confidence
+
+	tests := []struct {
+		name       string
+		config     Config
+		confidence float64
+		want       float64
+	}{
+		{"off", DefaultConfig(), 0.95, 1},
+		{"at the minimum", on, 0.7, 0.5},
+		{"halfway", on, 0.85, 1},
+		{"full confidence", on, 1, 1.5},
+		{"clamped below the minimum", on, 0.5, 0.5},
+		{"clamped above full confidence", on, 1.2, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.SizeMultiplier(tt.confidence); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("SizeMultiplier(%v) = %v, want %v", tt.confidence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSizeFactorDefaultsToOne(t *testing.T) {
+	if got := (&AnalysisResult{}).SizeFactor(); got != 1 {
+		t.Errorf("SizeFactor() without a multiplier = %v, want 1", got)
+	}
+	if got := (&AnalysisResult{SizeMultiplier: 1.25}).SizeFactor(); got != 1.25 {
+		t.Errorf("SizeFactor() = %v, want 1.25", got)
+	}
+}
@@ -0,0 +1,92 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestCalculateConfidenceWeighsEachComponent(t *testing.T) {
+	config := DefaultConfig()
+	config.TrendWeight, config.RSIWeight, config.MACDWeight = 0.5, 0.2, 0.3
+	a := NewAnalysisWithConfig(config)
+	params := DefaultIndicatorParams()
+	inBand := (params.RSILow + params.RSIHigh) / 2
+
+	tests := []struct {
+		name     string
+		ind      IndicatorValues
+		momentum float64
+		volume   bool
+		want     float64
+	}{
+		{"long, every component, volume", IndicatorValues{EMA8: 101, EMA21: 100, RSI: inBand, MACD: 1, Signal: 0}, 1, true, 1.0},
+		{"long, every component, no volume", IndicatorValues{EMA8: 101, EMA21: 100, RSI: inBand, MACD: 1, Signal: 0}, 1, false, 0.8},
+		{"long, trend only", IndicatorValues{EMA8: 101, EMA21: 100, RSI: params.RSIHigh + 5, MACD: -1, Signal: 0}, 1, false, 0.5 * 0.8},
+		{"long, RSI and MACD", IndicatorValues{EMA8: 99, EMA21: 100, RSI: inBand, MACD: 1, Signal: 0}, 1, true, 0.5 * 1.2},
+		{"short mirrors long", IndicatorValues{EMA8: 99, EMA21: 100, RSI: inBand, MACD: -1, Signal: 0}, -1, false, 0.8},
+		{"short, trend only", IndicatorValues{EMA8: 99, EMA21: 100, RSI: params.RSILow - 5, MACD: 1, Signal: 0}, -1, true, 0.5 * 1.2},
+		// An uptrend lends a falling market no confidence, nor a downtrend a rising one
+		{"uptrend against falling momentum", IndicatorValues{EMA8: 101, EMA21: 100, RSI: params.RSILow - 5, MACD: 1, Signal: 0}, -1, true, 0},
+		{"downtrend against rising momentum", IndicatorValues{EMA8: 99, EMA21: 100, RSI: params.RSILow - 5, MACD: -1, Signal: 0}, 1, true, 0},
+		{"RSI only", IndicatorValues{EMA8: 100, EMA21: 100, RSI: inBand}, 0, false, 0.2 * 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.calculateConfidence(&tt.ind, params, tt.momentum, tt.volume); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("calculateConfidence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfidenceWeightsMustSumToOne(t *testing.T) {
+	tests := []struct {
+		name             string
+		trend, rsi, macd float64
+		wantErr          bool
+	}{
+		{"default", 0.4, 0.3, 0.3, false},
+		{"trend only", 1, 0, 0, false},
+		{"short of one", 0.4, 0.3, 0.2, true},
+		{"over one", 0.5, 0.3, 0.3, true},
+		{"negative", 1.2, -0.2, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.TrendWeight, config.RSIWeight, config.MACDWeight = tt.trend, tt.rsi, tt.macd
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMomentumIsSigned(t *testing.T) {
+	a := NewAnalysis()
+	rising := zigzagCandles("BTCUSDT", testStart, ShortLook)
+	falling := append([]models.Price(nil), rising...)
+	for i := range falling {
+		falling[i].Close = 200 - falling[i].Close
+	}
+	if got := a.checkMomentum(rising); got <= 0 {
+		t.Errorf("checkMomentum() of rising closes = %v, want positive", got)
+	}
+	if got := a.checkMomentum(falling); got >= 0 {
+		t.Errorf("checkMomentum() of falling closes = %v, want negative", got)
+	}
+}
+
+func TestFallingMarketReadsShort(t *testing.T) {
+	// The rising zigzag mirrored around 100
+	prices := zigzagCandles("BTCUSDT", testStart, 250)
+	for i := range prices {
+		p := &prices[i]
+		p.Open, p.Close, p.High, p.Low = 200-p.Open, 200-p.Close, 200-p.Low, 200-p.High
+	}
+	result := NewAnalysis().Analyze(prices)
+	if result.IsValid && result.Direction != "short" {
+		t.Errorf("Analyze() of a falling market = valid %q entry, want a short or none", result.Direction)
+	}
+}
@@ -0,0 +1,177 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ConfluenceTimeFrames are the timeframes reported in a confluence breakdown
+// 4h only gets a vote when the window spans enough of it, such as with a 4h SuperTrend or Ichimoku
+var ConfluenceTimeFrames = []string{
+	models.PriceTimeFrame5m,
+	models.PriceTimeFrame15m,
+	models.PriceTimeFrame1h,
+	models.PriceTimeFrame4h,
+}
+
+// TimeFrameVote is how one timeframe leans, for observability only
+type TimeFrameVote struct {
+	Signal       int     `json:"signal"` // 1 bullish, -1 bearish, 0 undecided
+	Confidence   float64 `json:"confidence"`
+	RSI          float64 `json:"rsi"`
+	EMADirection int     `json:"ema_direction"`          // Sign of the fast EMA minus the slow one
+	Insufficient bool    `json:"insufficient,omitempty"` // Too few candles for the timeframe's indicators, no vote
+}
+
+// Confluence is a per-timeframe breakdown of a signal, keyed by timeframe
+type Confluence map[string]TimeFrameVote
+
+// String renders the breakdown shortest timeframe first, e.g. "5m:+1(0.80) 1h:-1(0.55)"
+func (c Confluence) String() string {
+	timeFrames := make([]string, 0, len(c))
+	for tf := range c {
+		timeFrames = append(timeFrames, tf)
+	}
+	sort.Slice(timeFrames, func(i, j int) bool {
+		return timeFrameRank(timeFrames[i]) < timeFrameRank(timeFrames[j])
+	})
+
+	parts := make([]string, len(timeFrames))
+	for i, tf := range timeFrames {
+		if c[tf].Insufficient {
+			parts[i] = tf + ":n/a"
+			continue
+		}
+		parts[i] = fmt.Sprintf("%s:%+d(%.2f)", tf, c[tf].Signal, c[tf].Confidence)
+	}
+	return strings.Join(parts, " ")
+}
+
+// JSON serializes the breakdown for storage, empty when there is nothing to store
+func (c Confluence) JSON() string {
+	if len(c) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseConfluence reads a breakdown stored with JSON
+func ParseConfluence(data string) (Confluence, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var c Confluence
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil, fmt.Errorf("invalid confluence data: %v", err)
+	}
+	return c, nil
+}
+
+// confluence votes on every ConfluenceTimeFrame from the analysis timeframe up, marking
+// those the window has too few candles for. Higher timeframes are built by resampling the window
+func (a *Analysis) confluence(prices []models.Price) Confluence {
+	base := prices[len(prices)-1].TimeFrame
+	result := make(Confluence)
+
+	for _, tf := range ConfluenceTimeFrames {
+		series := prices
+		if tf != base {
+			if timeFrameRank(tf) < timeFrameRank(base) {
+				continue
+			}
+			series = a.resample(prices, tf)
+		}
+		result[tf] = a.vote(series, a.config.Indicators.For(tf))
+	}
+
+	return result
+}
+
+// weightCadence blends confidence with the vote of the cadence timeframe, CadenceWeight of it,
+// so a 15m cadence leans on how the 15m candles read rather than on the 5m ones alone.
+// A vote against direction or undecided counts as no confidence; a cadence of the window's own
+// timeframe base, or one the window is too short to vote on, leaves confidence as it is
+func (a *Analysis) weightCadence(confidence float64, direction, cadence, base string, confluence Confluence) float64 {
+	vote, ok := confluence[cadence]
+	if !ok || vote.Insufficient || cadence == base {
+		return confidence
+	}
+
+	want := 1
+	if direction == "short" {
+		want = -1
+	}
+	agreeing := 0.0
+	if direction != "" && vote.Signal == want {
+		agreeing = vote.Confidence
+	}
+	return (1-a.config.CadenceWeight)*confidence + a.config.CadenceWeight*agreeing
+}
+
+// vote scores one series from EMA trend and the RSI side of its midline
+func (a *Analysis) vote(prices []models.Price, params IndicatorParams) TimeFrameVote {
+	if len(prices) < params.Candles() {
+		return TimeFrameVote{Insufficient: true}
+	}
+
+	emaFast, emaSlow, rsi, ok := a.trend(prices, params)
+	if !ok {
+		return TimeFrameVote{Insufficient: true}
+	}
+
+	v := TimeFrameVote{RSI: rsi}
+	switch diff := emaFast - emaSlow; {
+	case diff > 0:
+		v.EMADirection = 1
+	case diff < 0:
+		v.EMADirection = -1
+	}
+
+	midline := params.RSIMidline()
+	if v.EMADirection == 1 && v.RSI > midline {
+		v.Signal = 1
+	} else if v.EMADirection == -1 && v.RSI < midline {
+		v.Signal = -1
+	}
+
+	if v.Signal != 0 {
+		v.Confidence = 0.5 + 0.5*math.Min(math.Abs(v.RSI-midline)/20, 1)
+	}
+
+	return v
+}
+
+// trend returns the latest fast and slow EMA and RSI of prices, from the incremental state when
+// enabled so each resampled candle is folded in once rather than the whole series every call
+func (a *Analysis) trend(prices []models.Price, params IndicatorParams) (float64, float64, float64, bool) {
+	if a.cache != nil && params.RSISmoothing <= 1 {
+		if snapshot := a.cache.indicators(prices, params.periods()); snapshot != nil {
+			return snapshot.EMAFast, snapshot.EMASlow, snapshot.RSI, true
+		}
+	}
+
+	closes := make([]float64, len(prices))
+	for i, p := range prices {
+		closes[i] = p.Close
+	}
+
+	aligned := indicators.Align(
+		a.ema.CalculateValid(closes, params.EMAFast),
+		a.ema.CalculateValid(closes, params.EMASlow),
+		a.calculateRSI(closes, params),
+	)
+	if aligned == nil {
+		return 0, 0, 0, false
+	}
+	last := len(aligned[0]) - 1
+	return aligned[0][last], aligned[1][last], aligned[2][last], true
+}
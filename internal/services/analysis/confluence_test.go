@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fallThenRally returns 5m candles sliding 0.1 a candle for days, the last 36 rallying 0.3 each.
+// The final 2h do not complete a 4h candle, so the 4h series ends inside the slide
+func fallThenRally() []models.Price {
+	const n, rally = 48*25 + 24, 36
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	prices := make([]models.Price, n)
+	close := 300.0
+	for i := range prices {
+		open := close
+		if i < n-rally {
+			close -= 0.1
+		} else {
+			close += 0.3
+		}
+		prices[i] = models.Price{
+			Symbol:    "BTCUSDT",
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  start.Add(time.Duration(i) * 5 * time.Minute),
+			CloseTime: start.Add(time.Duration(i+1)*5*time.Minute - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 0.05,
+			Low:       min(open, close) - 0.05,
+			Close:     close,
+			Volume:    10,
+		}
+	}
+	return prices
+}
+
+func TestConfluenceBullish5mBearish4h(t *testing.T) {
+	result := NewAnalysis().Analyze(fallThenRally())
+	c := result.Confluence
+
+	fast, slow := c[models.PriceTimeFrame5m], c[models.PriceTimeFrame4h]
+	if fast.Signal != 1 || fast.EMADirection != 1 || fast.RSI <= 50 || fast.Confidence <= 0.5 {
+		t.Errorf("5m vote = %+v, want bullish", fast)
+	}
+	if slow.Insufficient || slow.Signal != -1 || slow.EMADirection != -1 || slow.RSI >= 50 || slow.Confidence <= 0.5 {
+		t.Errorf("4h vote = %+v, want bearish", slow)
+	}
+
+	rendered := c.String()
+	if !strings.HasPrefix(rendered, "5m:+1(") || !strings.Contains(rendered, " 4h:-1(") {
+		t.Errorf("String() = %q, want 5m bullish first and 4h bearish", rendered)
+	}
+
+	// The breakdown survives the signal log's storage
+	stored, err := ParseConfluence(c.JSON())
+	if err != nil {
+		t.Fatalf("ParseConfluence() error = %v", err)
+	}
+	if stored[models.PriceTimeFrame5m] != fast || stored[models.PriceTimeFrame4h] != slow {
+		t.Errorf("stored breakdown = %v, want %v", stored, c)
+	}
+}
+
+func TestConfluenceMarksShortWindowInsufficient(t *testing.T) {
+	prices := fallThenRally()
+	c := NewAnalysis().confluence(prices[len(prices)-300:])
+	if !c[models.PriceTimeFrame4h].Insufficient {
+		t.Errorf("4h vote = %+v over 25h of candles, want insufficient", c[models.PriceTimeFrame4h])
+	}
+	if !strings.Contains(c.String(), "4h:n/a") {
+		t.Errorf("String() = %q, want 4h:n/a", c.String())
+	}
+}
+
+func TestWeightCadence(t *testing.T) {
+	a := NewAnalysis()
+	confluence := Confluence{
+		models.PriceTimeFrame5m:  {Signal: 1, Confidence: 0.9},
+		models.PriceTimeFrame15m: {Signal: 1, Confidence: 0.6},
+		models.PriceTimeFrame1h:  {Signal: -1, Confidence: 0.8},
+		models.PriceTimeFrame4h:  {Insufficient: true},
+	}
+	tests := []struct {
+		name      string
+		direction string
+		cadence   string
+		want      float64
+	}{
+		{"unscheduled", "long", "", 0.8},
+		{"5m cadence is the window's own", "long", models.PriceTimeFrame5m, 0.8},
+		{"15m agrees", "long", models.PriceTimeFrame15m, 0.5*0.8 + 0.5*0.6},
+		{"1h disagrees", "long", models.PriceTimeFrame1h, 0.5 * 0.8},
+		{"1h agrees with a short", "short", models.PriceTimeFrame1h, 0.5*0.8 + 0.5*0.8},
+		{"no direction", "", models.PriceTimeFrame15m, 0.5 * 0.8},
+		{"too few candles to vote", "long", models.PriceTimeFrame4h, 0.8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := a.weightCadence(0.8, tt.direction, tt.cadence, models.PriceTimeFrame5m, confluence)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("weightCadence(%s, %q) = %v, want %v", tt.direction, tt.cadence, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeOnCadenceWeighsTheCadenceVote(t *testing.T) {
+	prices := fallThenRally()
+	config := DefaultConfig()
+	config.MinConfidence = 0
+	a := NewAnalysisWithConfig(config)
+	plain := a.Analyze(prices)
+	if plain.Direction != "long" {
+		t.Fatalf("Analyze() = %+v, want the rally read long", plain)
+	}
+	if fiveMinute := a.AnalyzeOnCadence(prices, models.PriceTimeFrame5m); fiveMinute.Confidence != plain.Confidence {
+		t.Errorf("5m cadence confidence = %v, want the unscheduled %v", fiveMinute.Confidence, plain.Confidence)
+	}
+
+	// The 15m candles already rally with the long, the hourly ones have not turned yet
+	quarter := a.AnalyzeOnCadence(prices, models.PriceTimeFrame15m)
+	if vote := quarter.Confluence[models.PriceTimeFrame15m]; vote.Signal != 1 || quarter.Confidence <= plain.Confidence {
+		t.Errorf("15m cadence confidence = %v from %v on vote %+v, want raised by the agreeing vote", quarter.Confidence, plain.Confidence, vote)
+	}
+	hourly := a.AnalyzeOnCadence(prices, models.PriceTimeFrame1h)
+	if vote := hourly.Confluence[models.PriceTimeFrame1h]; vote.Signal == 1 || hourly.Confidence >= plain.Confidence {
+		t.Errorf("1h cadence confidence = %v from %v on vote %+v, want lowered by the undecided vote", hourly.Confidence, plain.Confidence, vote)
+	}
+}
+
+func TestValidateCadence(t *testing.T) {
+	tests := []struct {
+		name    string
+		cadence string
+		weight  float64
+		wantErr bool
+	}{
+		{"default", models.PriceTimeFrame5m, 0.5, false},
+		{"hourly leaning fully on its vote", models.PriceTimeFrame1h, 1, false},
+		{"not a cadence", models.PriceTimeFrame4h, 0.5, true},
+		{"negative weight", models.PriceTimeFrame15m, -0.1, true},
+		{"weight over one", models.PriceTimeFrame15m, 1.1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Cadence, config.CadenceWeight = tt.cadence, tt.weight
+			if err := config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
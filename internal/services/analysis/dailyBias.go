@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"time"
+)
+
+// Daily bias settings, fixed like the MACD periods
+const (
+	DailyEMAFast   = 50
+	DailyEMASlow   = 200
+	DailyRSIPeriod = 14
+
+	// DailyBiasCandles is how many closed 1d candles the bias needs
+	DailyBiasCandles = DailyEMASlow
+)
+
+// Daily biases
+const (
+	DailyBiasBullish = "bullish"
+	DailyBiasBearish = "bearish"
+	DailyBiasNeutral = "neutral"
+)
+
+// DailyBias is the macro regime of a symbol read from its closed 1d candles
+type DailyBias struct {
+	Bias    string  `json:"bias"`
+	EMAFast float64 `json:"ema_fast"`
+	EMASlow float64 `json:"ema_slow"`
+	RSI     float64 `json:"rsi"`
+}
+
+// Opposes reports whether the bias is against an entry in direction, a neutral bias opposes nothing
+func (b *DailyBias) Opposes(direction string) bool {
+	if b == nil {
+		return false
+	}
+	if direction == "short" {
+		return b.Bias == DailyBiasBullish
+	}
+	return b.Bias == DailyBiasBearish
+}
+
+// DailyBiasAnalyzer reads the 1d EMA50/EMA200 relationship and RSI
+// The bias is bullish when EMA50 is above EMA200 and RSI is at least band above 50,
+// bearish in the mirror case and neutral otherwise
+type DailyBiasAnalyzer struct {
+	ema  *indicators.EMAService
+	rsi  *indicators.RSIService
+	band float64
+}
+
+// NewDailyBiasAnalyzer creates a new instance of DailyBiasAnalyzer
+func NewDailyBiasAnalyzer(band float64) *DailyBiasAnalyzer {
+	return &DailyBiasAnalyzer{
+		ema:  indicators.NewEMAService(),
+		rsi:  indicators.NewRSIService(),
+		band: band,
+	}
+}
+
+// Analyze returns the bias of the 1d candles that closed by at, nil while there are too few
+// The day still forming at at is dropped, so a backtest never sees its final close
+func (d *DailyBiasAnalyzer) Analyze(daily []models.Price, at time.Time) *DailyBias {
+	closed := len(daily)
+	for closed > 0 && daily[closed-1].OpenTime.Add(models.TimeFrameDurations[models.PriceTimeFrame1d]).After(at) {
+		closed--
+	}
+	if closed < DailyBiasCandles {
+		return nil
+	}
+
+	closes := make([]float64, closed)
+	for i := range closes {
+		closes[i] = daily[i].Close
+	}
+	fast := d.ema.CalculateValid(closes, DailyEMAFast)
+	slow := d.ema.CalculateValid(closes, DailyEMASlow)
+	rsi := d.rsi.CalculateValid(closes, DailyRSIPeriod)
+	if len(fast) == 0 || len(slow) == 0 || len(rsi) == 0 {
+		return nil
+	}
+
+	bias := &DailyBias{
+		Bias:    DailyBiasNeutral,
+		EMAFast: fast[len(fast)-1],
+		EMASlow: slow[len(slow)-1],
+		RSI:     rsi[len(rsi)-1],
+	}
+	switch {
+	case bias.EMAFast > bias.EMASlow && bias.RSI >= 50+d.band:
+		bias.Bias = DailyBiasBullish
+	case bias.EMAFast < bias.EMASlow && bias.RSI <= 50-d.band:
+		bias.Bias = DailyBiasBearish
+	}
+	return bias
+}
+
+// ApplyDailyBias records bias on a valid result and, with DailyBiasFilter set, rejects it when the bias opposes it
+// A symbol without enough daily history has no bias and is never blocked by it
+func (c Config) ApplyDailyBias(result *AnalysisResult, bias *DailyBias) {
+	if !result.IsValid {
+		return
+	}
+	result.DailyBias = bias
+	if c.DailyBiasFilter && bias.Opposes(result.Direction) {
+		result.IsValid = false
+		result.Reason = "daily bias disagrees"
+	}
+}
@@ -0,0 +1,133 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+// dailyCandles returns n 1d candles of BTCUSDT from testStart stepping up by up then down by down in turn
+func dailyCandles(n int, up, down float64) []models.Price {
+	day := 24 * time.Hour
+	prices := make([]models.Price, n)
+	close := 1000.0
+	for i := range prices {
+		open := close
+		if i%2 == 0 {
+			close += up
+		} else {
+			close -= down
+		}
+		prices[i] = models.Price{
+			Symbol:    "BTCUSDT",
+			TimeFrame: models.PriceTimeFrame1d,
+			OpenTime:  testStart.Add(time.Duration(i) * day),
+			CloseTime: testStart.Add(time.Duration(i+1)*day - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 1,
+			Low:       min(open, close) - 1,
+			Close:     close,
+			Volume:    1000,
+		}
+	}
+	return prices
+}
+
+// afterDays returns the moment the n-th daily candle from testStart closes
+func afterDays(n int) time.Time {
+	return testStart.Add(time.Duration(n) * 24 * time.Hour)
+}
+
+func TestDailyBiasAnalyzer(t *testing.T) {
+	tests := []struct {
+		name  string
+		daily []models.Price
+		at    time.Time
+		want  string // Empty for no bias
+	}{
+		{"rising", dailyCandles(220, 20, 10), afterDays(220), DailyBiasBullish},
+		{"falling", dailyCandles(220, 10, 20), afterDays(220), DailyBiasBearish},
+		{"ranging", dailyCandles(220, 10, 10), afterDays(220), DailyBiasNeutral},
+		{"too few closed days", dailyCandles(DailyBiasCandles-1, 20, 10), afterDays(DailyBiasCandles), ""},
+		{"the forming day does not count", dailyCandles(DailyBiasCandles, 20, 10), afterDays(DailyBiasCandles).Add(-time.Hour), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bias := NewDailyBiasAnalyzer(5).Analyze(tt.daily, tt.at)
+			if tt.want == "" {
+				if bias != nil {
+					t.Errorf("Analyze() = %+v, want no bias", bias)
+				}
+				return
+			}
+			if bias == nil || bias.Bias != tt.want {
+				t.Fatalf("Analyze() = %+v, want %s", bias, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyBiasIgnoresTheFormingDay(t *testing.T) {
+	// A crash on the day still forming must not move the bias before that day closes
+	daily := dailyCandles(221, 20, 10)
+	daily[220].Close = 1
+	at := afterDays(221).Add(-time.Minute)
+
+	analyzer := NewDailyBiasAnalyzer(5)
+	got := analyzer.Analyze(daily, at)
+	want := analyzer.Analyze(daily[:220], at)
+	if got == nil || want == nil || *got != *want {
+		t.Errorf("Analyze() with the forming day = %+v, want %+v as without it", got, want)
+	}
+	if after := analyzer.Analyze(daily, afterDays(221)); after == nil || after.EMAFast == got.EMAFast {
+		t.Errorf("Analyze() once the day closed = %+v, want the crash counted", after)
+	}
+}
+
+func TestApplyDailyBiasGatesDisagreeingEntries(t *testing.T) {
+	bullish := &DailyBias{Bias: DailyBiasBullish}
+	bearish := &DailyBias{Bias: DailyBiasBearish}
+	neutral := &DailyBias{Bias: DailyBiasNeutral}
+	tests := []struct {
+		name      string
+		filter    bool
+		direction string
+		bias      *DailyBias
+		valid     bool
+	}{
+		{"short against a bullish daily", true, "short", bullish, false},
+		{"long against a bearish daily", true, "long", bearish, false},
+		{"long with a bullish daily", true, "long", bullish, true},
+		{"short with a bearish daily", true, "short", bearish, true},
+		{"neutral daily blocks nothing", true, "short", neutral, true},
+		{"no daily history", true, "long", nil, true},
+		{"filter off only records", false, "short", bullish, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.DailyBiasFilter = tt.filter
+			result := &AnalysisResult{Symbol: "BTCUSDT", Direction: tt.direction, IsValid: true}
+
+			config.ApplyDailyBias(result, tt.bias)
+			if result.IsValid != tt.valid {
+				t.Errorf("valid = %v (%s), want %v", result.IsValid, result.Reason, tt.valid)
+			}
+			if result.DailyBias != tt.bias {
+				t.Errorf("recorded bias %+v, want %+v", result.DailyBias, tt.bias)
+			}
+			if !tt.valid && result.Reason != "daily bias disagrees" {
+				t.Errorf("reason = %q", result.Reason)
+			}
+		})
+	}
+
+	// A rejected signal is left alone
+	config := DefaultConfig()
+	config.DailyBiasFilter = true
+	rejected := &AnalysisResult{Direction: "short", Reason: "no setup"}
+	config.ApplyDailyBias(rejected, bullish)
+	if rejected.DailyBias != nil || rejected.Reason != "no setup" {
+		t.Errorf("rejected signal = %+v, want it untouched", rejected)
+	}
+}
@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// EntryContextVersion is bumped whenever EntryContext changes meaning, stored contexts keep theirs
+	EntryContextVersion = 1
+
+	// MaxEntryContextSize bounds a serialized EntryContext in bytes
+	MaxEntryContextSize = 4096
+)
+
+// EntryContext is a compact snapshot of what the analysis saw when a position was opened,
+// stored with live positions and backtest trades alike for post-trade analysis
+type EntryContext struct {
+	Version    int     `json:"version"`
+	Direction  string  `json:"direction"`
+	Confidence float64 `json:"confidence"`
+	TargetMode string  `json:"target_mode,omitempty"`
+	ATR        float64 `json:"atr,omitempty"`
+
+	// Analysis timeframe indicators
+	RSI        float64 `json:"rsi"`
+	EMAFast    float64 `json:"ema_fast"`
+	EMASlow    float64 `json:"ema_slow"`
+	MACD       float64 `json:"macd"`
+	MACDSignal float64 `json:"macd_signal"`
+	Histogram  float64 `json:"histogram"`
+
+	VolumeRatio float64 `json:"volume_ratio"` // Latest volume over the recent average
+	OBVTrend    int     `json:"obv_trend"`
+	POC         float64 `json:"poc,omitempty"`
+
+	Pattern         string  `json:"pattern,omitempty"`
+	PatternStrength float64 `json:"pattern_strength,omitempty"`
+	Support         float64 `json:"support,omitempty"`    // Nearest level below entry
+	Resistance      float64 `json:"resistance,omitempty"` // Nearest level above entry
+
+	Confluence Confluence             `json:"confluence,omitempty"`
+	SuperTrend map[string]int         `json:"supertrend,omitempty"`
+	Ichimoku   map[string]int         `json:"ichimoku,omitempty"`
+	DailyBias  string                 `json:"daily_bias,omitempty"`
+	Volatility map[string]*Volatility `json:"volatility,omitempty"`
+	Cadence    string                 `json:"cadence,omitempty"`
+
+	OrderFlow            *OrderFlow `json:"order_flow,omitempty"`
+	OrderFlowUnavailable bool       `json:"order_flow_unavailable,omitempty"` // The filter was skipped for want of trades
+}
+
+// EntryContext snapshots the result for storage with the position it opens
+func (r *AnalysisResult) EntryContext() *EntryContext {
+	if r.Context != nil {
+		return r.Context
+	}
+	c := &EntryContext{
+		Version:     EntryContextVersion,
+		Direction:   r.Direction,
+		Confidence:  r.Confidence,
+		TargetMode:  r.TargetMode,
+		ATR:         r.ATR,
+		VolumeRatio: r.VolumeRatio,
+		Confluence:  r.Confluence,
+		SuperTrend:  r.SuperTrend,
+		Ichimoku:    r.Ichimoku,
+		Volatility:  r.Volatility,
+		Cadence:     r.Cadence,
+
+		OrderFlow:            r.OrderFlow,
+		OrderFlowUnavailable: r.OrderFlowUnavailable,
+	}
+	if ind := r.Indicators; ind != nil {
+		c.RSI, c.EMAFast, c.EMASlow = ind.RSI, ind.EMA8, ind.EMA21
+		c.MACD, c.MACDSignal, c.Histogram = ind.MACD, ind.Signal, ind.Histogram
+	}
+	if r.Volume != nil {
+		c.OBVTrend, c.POC = r.Volume.OBVTrend, r.Volume.POC
+	}
+	if r.Pattern != nil {
+		c.Pattern, c.PatternStrength = r.Pattern.Name, r.Pattern.Strength
+	}
+	if r.DailyBias != nil {
+		c.DailyBias = r.DailyBias.Bias
+	}
+	if r.Support != nil {
+		c.Support = r.Support.Price
+	}
+	if r.Resistance != nil {
+		c.Resistance = r.Resistance.Price
+	}
+	return c
+}
+
+// JSON serializes the context for storage, within MaxEntryContextSize
+// The per-timeframe maps are dropped when they would not fit, empty when nothing fits
+func (c *EntryContext) JSON() string {
+	if c == nil {
+		return ""
+	}
+	data, err := json.Marshal(c)
+	if err == nil && len(data) > MaxEntryContextSize {
+		trimmed := *c
+		trimmed.Confluence, trimmed.SuperTrend, trimmed.Ichimoku, trimmed.Volatility = nil, nil, nil, nil
+		data, err = json.Marshal(trimmed)
+	}
+	if err != nil || len(data) > MaxEntryContextSize {
+		return ""
+	}
+	return string(data)
+}
+
+// ParseEntryContext reads a context stored with JSON, nil for none
+// Contexts written by a newer version than this build understands are rejected
+func ParseEntryContext(data string) (*EntryContext, error) {
+	if data == "" {
+		return nil, nil
+	}
+	var c EntryContext
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return nil, fmt.Errorf("invalid entry context: %v", err)
+	}
+	if c.Version < 1 || c.Version > EntryContextVersion {
+		return nil, fmt.Errorf("unsupported entry context version %d", c.Version)
+	}
+	return &c, nil
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntryContextSnapshotsTheResult(t *testing.T) {
+	result := &AnalysisResult{
+		Symbol:      "BTCUSDT",
+		Direction:   "long",
+		Confidence:  0.8,
+		TargetMode:  TargetModeVolatility,
+		ATR:         1.5,
+		VolumeRatio: 1.4,
+		Indicators:  &IndicatorValues{RSI: 58, MACD: 0.3, Signal: 0.2, Histogram: 0.1, EMA8: 101, EMA21: 100},
+		Volume:      &VolumeData{OBVTrend: 1, POC: 99, VAH: 100, VAL: 98},
+		Pattern:     &PatternResult{Name: "hammer", Strength: 0.6},
+		Support:     &Level{Price: 97, Touches: 3},
+		Resistance:  &Level{Price: 104, Touches: 2},
+		DailyBias:   &DailyBias{Bias: "bullish"},
+		Confluence:  Confluence{"1h": {Signal: 1, Confidence: 0.7}},
+		Cadence:     "15m",
+	}
+	want := &EntryContext{
+		Version:         EntryContextVersion,
+		Direction:       "long",
+		Confidence:      0.8,
+		TargetMode:      TargetModeVolatility,
+		ATR:             1.5,
+		RSI:             58,
+		EMAFast:         101,
+		EMASlow:         100,
+		MACD:            0.3,
+		MACDSignal:      0.2,
+		Histogram:       0.1,
+		VolumeRatio:     1.4,
+		OBVTrend:        1,
+		POC:             99,
+		Pattern:         "hammer",
+		PatternStrength: 0.6,
+		Support:         97,
+		Resistance:      104,
+		Confluence:      Confluence{"1h": {Signal: 1, Confidence: 0.7}},
+		DailyBias:       "bullish",
+		Cadence:         "15m",
+	}
+	if got := result.EntryContext(); !reflect.DeepEqual(got, want) {
+		t.Errorf("EntryContext() = %+v, want %+v", got, want)
+	}
+
+	// A context carried over, as by a pending limit order, is kept rather than rebuilt
+	result.Context = &EntryContext{Version: EntryContextVersion, Pattern: "doji"}
+	if got := result.EntryContext(); got != result.Context {
+		t.Errorf("EntryContext() = %+v, want the carried context", got)
+	}
+}
+
+func TestEntryContextRoundTrip(t *testing.T) {
+	want := (&AnalysisResult{
+		Direction:  "short",
+		Confidence: 0.7,
+		Indicators: &IndicatorValues{RSI: 35},
+		SuperTrend: map[string]int{"1h": -1},
+		Volatility: map[string]*Volatility{"1h": {Realized: 0.01, Regime: "high"}},
+	}).EntryContext()
+
+	data := want.JSON()
+	got, err := ParseEntryContext(data)
+	if err != nil {
+		t.Fatalf("ParseEntryContext() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+func TestEntryContextJSONIsBounded(t *testing.T) {
+	confluence := make(Confluence)
+	for i := range 200 {
+		confluence[fmt.Sprintf("tf%d", i)] = TimeFrameVote{Signal: 1, Confidence: 0.5, RSI: 50}
+	}
+	c := &EntryContext{Version: EntryContextVersion, Direction: "long", VolumeRatio: 0.9, Confluence: confluence}
+
+	data := c.JSON()
+	if len(data) == 0 || len(data) > MaxEntryContextSize {
+		t.Fatalf("JSON() = %d bytes, want the trimmed context within %d", len(data), MaxEntryContextSize)
+	}
+	got, err := ParseEntryContext(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Confluence != nil || got.VolumeRatio != 0.9 || got.Direction != "long" {
+		t.Errorf("trimmed context = %+v, want the per-timeframe maps dropped and the rest kept", got)
+	}
+
+	// Nothing fits when the fixed fields alone are too large
+	c.Pattern = strings.Repeat("x", MaxEntryContextSize)
+	if data := c.JSON(); data != "" {
+		t.Errorf("JSON() = %d bytes, want empty when nothing fits", len(data))
+	}
+	if data := (*EntryContext)(nil).JSON(); data != "" {
+		t.Errorf("nil JSON() = %q, want empty", data)
+	}
+}
+
+func TestParseEntryContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantNil bool
+		wantErr bool
+	}{
+		{"none stored", "", true, false},
+		{"current version", `{"version":1,"direction":"long"}`, false, false},
+		{"newer version", fmt.Sprintf(`{"version":%d}`, EntryContextVersion+1), true, true},
+		{"missing version", `{"direction":"long"}`, true, true},
+		{"malformed", `{"version":`, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEntryContext(tt.data)
+			if (err != nil) != tt.wantErr || (got == nil) != tt.wantNil {
+				t.Errorf("ParseEntryContext(%q) = %+v, %v", tt.data, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"time"
+)
+
+var testStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// zigzagCandles returns n 5m candles of symbol from start climbing in a zigzag, up 0.2 then down 0.17.
+// Every candle trades 10 but the last, which trades 20: Analyze reads a long at the end of any window
+// of them spanning the warm-up
+func zigzagCandles(symbol string, start time.Time, n int) []models.Price {
+	prices := make([]models.Price, n)
+	close := 100.0
+	for i := range prices {
+		open := close
+		if i%2 == 0 {
+			close += 0.2
+		} else {
+			close -= 0.17
+		}
+		prices[i] = models.Price{
+			Symbol:    symbol,
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  start.Add(time.Duration(i) * 5 * time.Minute),
+			CloseTime: start.Add(time.Duration(i+1)*5*time.Minute - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 0.05,
+			Low:       min(open, close) - 0.05,
+			Close:     close,
+			Volume:    10,
+		}
+	}
+	prices[n-1].Volume = 20
+	return prices
+}
+
+// spiked returns a copy of prices whose last candle trades twice the usual volume
+func spiked(prices []models.Price) []models.Price {
+	window := append([]models.Price(nil), prices...)
+	window[len(window)-1].Volume = 20
+	return window
+}
+
+// withGap returns zigzag candles with missing candles removed after the first before, the rest shifted
+// later so the series resumes where it left off
+func withGap(symbol string, before, after, missing int) []models.Price {
+	prices := zigzagCandles(symbol, testStart, before+after)
+	shift := time.Duration(missing) * 5 * time.Minute
+	for i := before; i < len(prices); i++ {
+		prices[i].OpenTime = prices[i].OpenTime.Add(shift)
+		prices[i].CloseTime = prices[i].CloseTime.Add(shift)
+	}
+	return prices
+}
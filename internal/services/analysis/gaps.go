@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"time"
+)
+
+// Gap handling modes
+const (
+	GapModeReset = "reset" // Restart indicator warm-up from the first candle after the gap
+	GapModeFill  = "fill"  // Bridge the gap with flat synthetic candles excluded from volume metrics
+)
+
+// prepareWindow applies gap handling to a price window
+// It returns the window to analyze and the number of real candles since the last gap, or -1 if there is none
+func (a *Analysis) prepareWindow(prices []models.Price) ([]models.Price, int) {
+	interval, known := models.TimeFrameDurations[prices[len(prices)-1].TimeFrame]
+	if !known {
+		return prices, -1
+	}
+
+	lastGap := -1
+	for i := 1; i < len(prices); i++ {
+		if prices[i].OpenTime.Sub(prices[i-1].OpenTime) > interval {
+			lastGap = i
+		}
+	}
+
+	if lastGap == -1 {
+		return prices, -1
+	}
+
+	sinceGap := len(prices) - 1 - lastGap
+
+	if a.config.GapMode == GapModeFill {
+		return fillGaps(prices, interval), sinceGap
+	}
+
+	return prices[lastGap:], sinceGap
+}
+
+// fillGaps inserts flat candles at the previous close for every missing interval
+func fillGaps(prices []models.Price, interval time.Duration) []models.Price {
+	filled := make([]models.Price, 0, len(prices))
+	filled = append(filled, prices[0])
+
+	for i := 1; i < len(prices); i++ {
+		prev := prices[i-1]
+		for t := prev.OpenTime.Add(interval); prices[i].OpenTime.Sub(t) > 0; t = t.Add(interval) {
+			filled = append(filled, models.Price{
+				Symbol:    prev.Symbol,
+				TimeFrame: prev.TimeFrame,
+				OpenTime:  t,
+				Open:      prev.Close,
+				High:      prev.Close,
+				Low:       prev.Close,
+				Close:     prev.Close,
+				IsGapFill: true,
+			})
+		}
+		filled = append(filled, prices[i])
+	}
+
+	return filled
+}
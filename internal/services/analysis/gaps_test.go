@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+// threeHours is the 5m candles a 3 hour outage leaves out
+const threeHours = 36
+
+func TestPrepareWindowResetsAfterGap(t *testing.T) {
+	prices := withGap("BTCUSDT", 200, 10, threeHours)
+	a := NewAnalysis()
+
+	window, sinceGap := a.prepareWindow(prices)
+	if sinceGap != 9 {
+		t.Errorf("sinceGap = %d, want 9", sinceGap)
+	}
+	if len(window) != 10 || !window[0].OpenTime.Equal(prices[200].OpenTime) {
+		t.Errorf("reset window has %d candles from %v, want the 10 after the gap", len(window), window[0].OpenTime)
+	}
+
+	if _, sinceGap := a.prepareWindow(prices[:200]); sinceGap != -1 {
+		t.Errorf("sinceGap without a gap = %d, want -1", sinceGap)
+	}
+}
+
+func TestPrepareWindowFillsGapWithFlatCandles(t *testing.T) {
+	prices := withGap("BTCUSDT", 200, 10, threeHours)
+	config := DefaultConfig()
+	config.GapMode = GapModeFill
+	a := NewAnalysisWithConfig(config)
+
+	window, sinceGap := a.prepareWindow(prices)
+	if sinceGap != 9 {
+		t.Errorf("sinceGap = %d, want 9", sinceGap)
+	}
+	if len(window) != len(prices)+threeHours {
+		t.Fatalf("filled window has %d candles, want %d", len(window), len(prices)+threeHours)
+	}
+
+	last := prices[199]
+	for i, p := range window[200 : 200+threeHours] {
+		if !p.IsGapFill {
+			t.Fatalf("candle %d of the gap is not flagged as gap fill", i)
+		}
+		if p.Open != last.Close || p.High != last.Close || p.Low != last.Close || p.Close != last.Close || p.Volume != 0 {
+			t.Fatalf("gap candle %d = %+v, want flat at %v without volume", i, p, last.Close)
+		}
+		if want := last.OpenTime.Add(time.Duration(i+1) * 5 * time.Minute); !p.OpenTime.Equal(want) {
+			t.Fatalf("gap candle %d opens at %v, want %v", i, p.OpenTime, want)
+		}
+	}
+	for i := 1; i < len(window); i++ {
+		if window[i].OpenTime.Sub(window[i-1].OpenTime) != 5*time.Minute {
+			t.Fatalf("filled window still breaks at %v", window[i].OpenTime)
+		}
+	}
+}
+
+func TestVolumeRatioIgnoresGapFills(t *testing.T) {
+	prices := withGap("BTCUSDT", 200, 2, 3)
+	config := DefaultConfig()
+	config.GapMode = GapModeFill
+	a := NewAnalysisWithConfig(config)
+
+	window, _ := a.prepareWindow(spiked(prices))
+	recent := window[len(window)-ShortLook:] // 2 real, 3 gap fills
+	if got := a.volumeRatio(recent); got != 2 {
+		t.Errorf("volumeRatio = %v, want 2 from the real candles alone", got)
+	}
+}
+
+func TestAnalyzeSuppressesEntriesAfterGap(t *testing.T) {
+	prices := withGap("BTCUSDT", 300, 5, threeHours)
+	config := DefaultConfig()
+	config.GapMode = GapModeFill
+	// The flat gap candles push RSI to an extreme the default band would reject on its own
+	params := DefaultIndicatorParams()
+	params.RSILow, params.RSIHigh = 0, 100
+	config.Indicators = IndicatorSet{models.PriceTimeFrame5m: params}
+
+	firstAfter := spiked(prices[:301])
+
+	config.GapSuppressCandles = 0
+	if result := NewAnalysisWithConfig(config).Analyze(firstAfter); !result.IsValid {
+		t.Fatalf("fixture does not signal on the first candle after the gap without suppression: %s", result.Reason)
+	}
+
+	config.GapSuppressCandles = 3
+	a := NewAnalysisWithConfig(config)
+	for n := 301; n <= 303; n++ {
+		if result := a.Analyze(spiked(prices[:n])); result.IsValid || result.Reason != "recent data gap" {
+			t.Errorf("candle %d after the gap: valid %v (%s), want suppressed", n-300, result.IsValid, result.Reason)
+		}
+	}
+	if result := a.Analyze(spiked(prices[:304])); result.Reason == "recent data gap" {
+		t.Error("entries still suppressed 4 candles after the gap")
+	}
+}
+
+func TestAnalyzeResetRestartsWarmUp(t *testing.T) {
+	prices := withGap("BTCUSDT", 300, 5, threeHours)
+	config := DefaultConfig()
+	config.GapSuppressCandles = 0
+
+	result := NewAnalysisWithConfig(config).Analyze(spiked(prices))
+	if result.IsValid {
+		t.Error("signalled on 5 candles after a gap in reset mode")
+	}
+}
@@ -0,0 +1,53 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+)
+
+// IchimokuTimeFrames are the timeframes the Ichimoku bias is reported on
+var IchimokuTimeFrames = []string{
+	models.PriceTimeFrame1h,
+	models.PriceTimeFrame4h,
+}
+
+// ichimokuBiases returns the Ichimoku bias of each closed higher timeframe, resampled from prices
+// Timeframes without enough closed candles for a cloud are left out
+func (a *Analysis) ichimokuBiases(prices []models.Price) map[string]int {
+	biases := make(map[string]int)
+	for _, tf := range IchimokuTimeFrames {
+		series := a.closedCandles(prices, tf)
+		highs := make([]float64, len(series))
+		lows := make([]float64, len(series))
+		closes := make([]float64, len(series))
+		for i, p := range series {
+			highs[i] = p.High
+			lows[i] = p.Low
+			closes[i] = p.Close
+		}
+
+		result := a.ichimoku.Calculate(highs, lows, closes,
+			indicators.IchimokuTenkan, indicators.IchimokuKijun, indicators.IchimokuSenkouB, indicators.IchimokuDisplacement)
+		if result != nil {
+			biases[tf] = result.Bias(closes[len(closes)-1])
+		}
+	}
+	return biases
+}
+
+// ichimokuAgrees reports whether the 4h Ichimoku bias is on the side of direction, a neutral or missing bias never is
+func ichimokuAgrees(direction string, biases map[string]int) bool {
+	bias, ok := biases[models.PriceTimeFrame4h]
+	if !ok {
+		return false
+	}
+	if direction == "short" {
+		return bias == indicators.IchimokuBearish
+	}
+	return bias == indicators.IchimokuBullish
+}
+
+// ichimokuWarmUp returns the closed candles the cloud needs on each higher timeframe
+func (a *Analysis) ichimokuWarmUp() int {
+	return indicators.IchimokuSenkouB + indicators.IchimokuDisplacement
+}
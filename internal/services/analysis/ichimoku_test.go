@@ -0,0 +1,91 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"testing"
+)
+
+func TestIchimokuAgrees(t *testing.T) {
+	bull, bear, neutral := indicators.IchimokuBullish, indicators.IchimokuBearish, indicators.IchimokuNeutral
+	tests := []struct {
+		name      string
+		direction string
+		biases    map[string]int
+		want      bool
+	}{
+		{"bullish 4h passes a long", "long", map[string]int{"4h": bull}, true},
+		{"bearish 4h passes a short", "short", map[string]int{"4h": bear}, true},
+		{"bearish 4h blocks a long", "long", map[string]int{"4h": bear}, false},
+		{"bullish 4h blocks a short", "short", map[string]int{"4h": bull}, false},
+		{"neutral 4h blocks a long", "long", map[string]int{"4h": neutral}, false},
+		{"neutral 4h blocks a short", "short", map[string]int{"4h": neutral}, false},
+		{"1h alone does not count", "long", map[string]int{"1h": bull}, false},
+		{"missing cloud blocks", "short", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ichimokuAgrees(tt.direction, tt.biases); got != tt.want {
+				t.Errorf("ichimokuAgrees(%q, %v) = %v, want %v", tt.direction, tt.biases, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIchimokuWarmUpCoversTheDisplacedCloud(t *testing.T) {
+	need := indicators.IchimokuSenkouB + indicators.IchimokuDisplacement
+	config := DefaultConfig()
+	config.RequireIchimoku = true
+	warmUp := NewAnalysisWithConfig(config).WarmUp()
+	for _, tf := range IchimokuTimeFrames {
+		if warmUp[tf] < need {
+			t.Errorf("WarmUp()[%s] = %d, want at least %d", tf, warmUp[tf], need)
+		}
+	}
+
+	// Without the filter the 4h cloud is not computed, so it needs no candles
+	if warmUp := NewAnalysis().WarmUp(); warmUp[models.PriceTimeFrame4h] >= need {
+		t.Errorf("WarmUp()[4h] without the filter = %d, want less than %d", warmUp[models.PriceTimeFrame4h], need)
+	}
+}
+
+func TestIchimokuBiasesFollowHigherTimeFrames(t *testing.T) {
+	// The zigzag climbs steadily, so both clouds read bullish once the 4h one is warmed up
+	perFourHours := int(models.TimeFrameDurations[models.PriceTimeFrame4h] / models.TimeFrameDurations[models.PriceTimeFrame5m])
+	need := indicators.IchimokuSenkouB + indicators.IchimokuDisplacement
+	a := NewAnalysis()
+
+	biases := a.ichimokuBiases(zigzagCandles("BTCUSDT", testStart, (need+1)*perFourHours))
+	for _, tf := range IchimokuTimeFrames {
+		if biases[tf] != indicators.IchimokuBullish {
+			t.Errorf("%s Ichimoku bias = %d, want bullish", tf, biases[tf])
+		}
+	}
+
+	// One 4h candle short of the cloud leaves 4h out rather than reading it neutral
+	biases = a.ichimokuBiases(zigzagCandles("BTCUSDT", testStart, (need-1)*perFourHours))
+	if bias, ok := biases[models.PriceTimeFrame4h]; ok {
+		t.Errorf("4h Ichimoku bias = %d before the cloud is warmed up, want none", bias)
+	}
+	if _, ok := biases[models.PriceTimeFrame1h]; !ok {
+		t.Error("1h Ichimoku bias missing with enough 1h candles")
+	}
+}
+
+func TestRequireIchimokuBlocksWithoutA4hCloud(t *testing.T) {
+	perFourHours := int(models.TimeFrameDurations[models.PriceTimeFrame4h] / models.TimeFrameDurations[models.PriceTimeFrame5m])
+	need := indicators.IchimokuSenkouB + indicators.IchimokuDisplacement
+	config := DefaultConfig()
+	config.RequireIchimoku = true
+	a := NewAnalysisWithConfig(config)
+
+	short := a.Analyze(zigzagCandles("BTCUSDT", testStart, (need-1)*perFourHours))
+	if short.IsValid || short.Reason != "ichimoku disagrees" {
+		t.Errorf("Analyze() before the 4h cloud = valid %v, %q, want blocked by ichimoku", short.IsValid, short.Reason)
+	}
+
+	warm := a.Analyze(zigzagCandles("BTCUSDT", testStart, (need+1)*perFourHours))
+	if !warm.IsValid || warm.Direction != "long" || warm.Ichimoku[models.PriceTimeFrame4h] != indicators.IchimokuBullish {
+		t.Errorf("Analyze() on a bullish 4h cloud = valid %v, %q, %q, biases %v, want a long", warm.IsValid, warm.Direction, warm.Reason, warm.Ichimoku)
+	}
+}
@@ -0,0 +1,235 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"sync"
+	"time"
+)
+
+// indicatorCache keeps running indicator state per symbol and timeframe
+// so a live tick only folds in the candles added since the previous one
+type indicatorCache struct {
+	mu     sync.Mutex
+	series map[string]*cachedSeries
+}
+
+type cachedSeries struct {
+	state     *indicators.IndicatorState
+	committed time.Time // OpenTime of the last candle folded into state
+}
+
+func newIndicatorCache() *indicatorCache {
+	return &indicatorCache{series: make(map[string]*cachedSeries)}
+}
+
+// EnableIncremental switches indicator calculation to cached per-series state, the analysis timeframe
+// and every resampled confluence timeframe alike, and keeps the higher timeframes resampled across calls.
+// Meant for live trading and long backtests, where consecutive windows overlap almost entirely;
+// indicator values differ from a recomputed window only by where they were seeded
+func (a *Analysis) EnableIncremental() {
+	a.cache = newIndicatorCache()
+	a.resamples = newResampleCache()
+}
+
+// indicators returns values for the last candle, or nil if the series cannot be tracked
+// Every candle but the last is committed to the state; the last may still be forming and is only previewed
+func (c *indicatorCache) indicators(prices []models.Price, periods indicators.Periods) *indicators.Snapshot {
+	last := prices[len(prices)-1]
+	interval, known := models.TimeFrameDurations[last.TimeFrame]
+	if !known || len(prices) < 2 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := last.Symbol + "|" + last.TimeFrame
+	series, ok := c.series[key]
+	if !ok {
+		series = &cachedSeries{state: indicators.NewIndicatorState(periods)}
+		c.series[key] = series
+	}
+
+	if !series.state.Seeded() || !series.advance(prices, interval) {
+		if !series.reseed(prices) {
+			return nil
+		}
+	}
+
+	snapshot := series.state.Preview(last.Close)
+	return &snapshot
+}
+
+// advance commits the candles after the last committed one
+// It returns false on a gap, duplicate or out-of-order candle so the caller can recompute
+func (s *cachedSeries) advance(prices []models.Price, interval time.Duration) bool {
+	start := -1
+	for i := len(prices) - 2; i >= 0; i-- {
+		if prices[i].OpenTime.Equal(s.committed) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return false
+	}
+
+	// Check continuity up to and including the forming candle before touching the state
+	for i := start + 1; i < len(prices); i++ {
+		if prices[i].OpenTime.Sub(prices[i-1].OpenTime) != interval {
+			return false
+		}
+	}
+
+	for i := start + 1; i < len(prices)-1; i++ {
+		s.state.Update(prices[i].Close)
+	}
+	s.committed = prices[len(prices)-2].OpenTime
+	return true
+}
+
+// reseed recomputes the state from the whole window except the forming candle
+func (s *cachedSeries) reseed(prices []models.Price) bool {
+	closed := prices[:len(prices)-1]
+	closes := make([]float64, len(closed))
+	for i, p := range closed {
+		closes[i] = p.Close
+	}
+
+	if !s.state.Seed(closes) {
+		return false
+	}
+	s.committed = closed[len(closed)-1].OpenTime
+	return true
+}
+
+// resampleCache keeps the latest resampled window per symbol and timeframe. The several analyses of
+// one window resample it once, and a window that moved on by a few candles only rebuilds the bucket
+// at each end instead of the whole series
+type resampleCache struct {
+	mu      sync.Mutex
+	windows map[string]*resampledWindow
+}
+
+// resampledWindow is a window of candles resampled to one timeframe, its last bucket kept even
+// while incomplete so the next window can extend it. Buckets are never modified once returned,
+// every change builds new ones
+type resampledWindow struct {
+	first   time.Time    // OpenTime of the window's first candle
+	last    models.Price // The window's last candle
+	length  int
+	buckets []models.Price
+	counts  []int // Window candles in each bucket
+}
+
+func newResampleCache() *resampleCache {
+	return &resampleCache{windows: make(map[string]*resampledWindow)}
+}
+
+// resample returns prices resampled to timeFrame, from the cached window when enabled
+// The series may be shared between callers and must not be modified
+func (a *Analysis) resample(prices []models.Price, timeFrame string) []models.Price {
+	if a.resamples == nil || len(prices) == 0 {
+		return resample(prices, timeFrame)
+	}
+	return a.resamples.resample(prices, timeFrame)
+}
+
+func (c *resampleCache) resample(prices []models.Price, timeFrame string) []models.Price {
+	interval, known := models.TimeFrameDurations[timeFrame]
+	if !known {
+		return nil
+	}
+	last := prices[len(prices)-1]
+	key := last.Symbol + "|" + last.TimeFrame + "|" + timeFrame
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	window, ok := c.windows[key]
+	if !ok || !window.covers(prices) {
+		if window, ok = window.slide(prices, timeFrame, interval); !ok {
+			window = newResampledWindow(prices, prices, timeFrame, interval)
+		}
+		c.windows[key] = window
+	}
+	return completeBuckets(window.buckets, last, interval)
+}
+
+// newResampledWindow resamples candles, the part of window not already resampled
+func newResampledWindow(window, candles []models.Price, timeFrame string, interval time.Duration) *resampledWindow {
+	w := &resampledWindow{
+		first:  window[0].OpenTime,
+		last:   window[len(window)-1],
+		length: len(window),
+	}
+	for _, p := range candles {
+		w.add(p, timeFrame, interval)
+	}
+	return w
+}
+
+func (w *resampledWindow) add(p models.Price, timeFrame string, interval time.Duration) {
+	var started bool
+	if w.buckets, started = addToBucket(w.buckets, p, timeFrame, interval); started {
+		w.counts = append(w.counts, 1)
+	} else {
+		w.counts[len(w.counts)-1]++
+	}
+}
+
+// covers reports whether w was resampled from exactly prices; the last candle may still be forming,
+// it has to match in full
+func (w *resampledWindow) covers(prices []models.Price) bool {
+	return w.length == len(prices) && w.first.Equal(prices[0].OpenTime) && w.last == prices[len(prices)-1]
+}
+
+// slide returns w moved on to prices, reusing every bucket between the first and last
+// It reports false when prices do not continue the window, which then has to be resampled afresh
+func (w *resampledWindow) slide(prices []models.Price, timeFrame string, interval time.Duration) (*resampledWindow, bool) {
+	if w == nil {
+		return nil, false
+	}
+
+	// The window's last candle, unchanged, followed only by newer ones
+	j := len(prices) - 1
+	for j >= 0 && prices[j].OpenTime.After(w.last.OpenTime) {
+		j--
+	}
+	if j < 0 || prices[j] != w.last {
+		return nil, false
+	}
+
+	// Buckets the window moved past are dropped and the first, which may have lost candles, rebuilt
+	first := prices[0].OpenTime.Truncate(interval)
+	drop := 0
+	for drop < len(w.buckets) && w.buckets[drop].OpenTime.Before(first) {
+		drop++
+	}
+	if drop == len(w.buckets) || !w.buckets[drop].OpenTime.Equal(first) {
+		return nil, false
+	}
+	head := 0
+	for head <= j && prices[head].OpenTime.Truncate(interval).Equal(first) {
+		head++
+	}
+
+	next := newResampledWindow(prices, prices[:head], timeFrame, interval)
+	next.buckets = append(next.buckets, w.buckets[drop+1:]...)
+	next.counts = append(next.counts, w.counts[drop+1:]...)
+
+	// Every reused bucket must hold the candles it did, or something in between changed
+	total := 0
+	for _, count := range next.counts {
+		total += count
+	}
+	if total != j+1 {
+		return nil, false
+	}
+
+	for _, p := range prices[j+1:] {
+		next.add(p, timeFrame, interval)
+	}
+	return next, true
+}
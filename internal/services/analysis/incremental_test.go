@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+// assertIndicators compares values from the cache with a recomputation of the same window
+func assertIndicators(t *testing.T, label string, got, want *IndicatorValues) {
+	t.Helper()
+	fields := []struct {
+		name      string
+		got, want float64
+	}{
+		{"EMA8", got.EMA8, want.EMA8},
+		{"EMA21", got.EMA21, want.EMA21},
+		{"RSI", got.RSI, want.RSI},
+		{"MACD", got.MACD, want.MACD},
+		{"Signal", got.Signal, want.Signal},
+		{"Histogram", got.Histogram, want.Histogram},
+		{"Volume", got.Volume, want.Volume},
+	}
+	for _, f := range fields {
+		if math.Abs(f.got-f.want) > 1e-9 {
+			t.Errorf("%s: %s = %v incrementally, %v recomputed", label, f.name, f.got, f.want)
+		}
+	}
+}
+
+func indicatorsOf(t *testing.T, a *Analysis, prices []models.Price) *IndicatorValues {
+	t.Helper()
+	values, err := a.calculateIndicators(prices, DefaultIndicatorParams())
+	if err != nil {
+		t.Fatalf("calculateIndicators() error = %v", err)
+	}
+	return values
+}
+
+func TestIncrementalIndicatorsMatchRecomputation(t *testing.T) {
+	prices := zigzagCandles("BTCUSDT", testStart, 260)
+	incremental, full := NewAnalysis(), NewAnalysis()
+	incremental.EnableIncremental()
+
+	// Live ticks: the window grows a candle at a time, and the last candle is re-read while it forms
+	for n := 60; n <= len(prices); n++ {
+		window := prices[:n]
+		forming := append([]models.Price(nil), window...)
+		forming[n-1].Close -= 0.3
+		assertIndicators(t, "forming", indicatorsOf(t, incremental, forming), indicatorsOf(t, full, forming))
+		assertIndicators(t, "closed", indicatorsOf(t, incremental, window), indicatorsOf(t, full, window))
+	}
+}
+
+func TestIncrementalIndicatorsRecomputeOnGap(t *testing.T) {
+	incremental, full := NewAnalysis(), NewAnalysis()
+	incremental.EnableIncremental()
+	indicatorsOf(t, incremental, zigzagCandles("BTCUSDT", testStart, 100))
+
+	// Candles missing after the cached ones: the state is seeded again from the window
+	gapped := withGap("BTCUSDT", 100, 60, 3)
+	assertIndicators(t, "gap", indicatorsOf(t, incremental, gapped), indicatorsOf(t, full, gapped))
+
+	// A window that went back in time is not folded in either
+	earlier := zigzagCandles("BTCUSDT", testStart, 80)
+	assertIndicators(t, "out of order", indicatorsOf(t, incremental, earlier), indicatorsOf(t, full, earlier))
+}
+
+func TestResampleCacheMatchesResampling(t *testing.T) {
+	prices := zigzagCandles("BTCUSDT", testStart, 400)
+	cached := NewAnalysis()
+	cached.EnableIncremental()
+
+	// A sliding 250 candle window as the backtest steps, then one jumping back to the start
+	var windows [][]models.Price
+	for n := 250; n <= len(prices); n++ {
+		windows = append(windows, prices[n-250:n])
+	}
+	windows = append(windows, prices[:250])
+
+	for _, window := range windows {
+		for _, timeFrame := range []string{models.PriceTimeFrame15m, models.PriceTimeFrame1h} {
+			got, want := cached.resample(window, timeFrame), resample(window, timeFrame)
+			if len(got) != len(want) {
+				t.Fatalf("%s window from %v: %d buckets cached, %d resampled", timeFrame, window[0].OpenTime, len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("%s window from %v: bucket %d = %+v cached, %+v resampled", timeFrame, window[0].OpenTime, i, got[i], want[i])
+				}
+			}
+		}
+	}
+}
+
+// benchmarkTicks runs calculateIndicators over a 250 candle window sliding a candle per tick
+func benchmarkTicks(b *testing.B, a *Analysis) {
+	prices := zigzagCandles("BTCUSDT", testStart, 250+b.N)
+	params := DefaultIndicatorParams()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.calculateIndicators(prices[i:i+250], params); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkIndicatorsRecomputed(b *testing.B) {
+	benchmarkTicks(b, NewAnalysis())
+}
+
+func BenchmarkIndicatorsIncremental(b *testing.B) {
+	a := NewAnalysis()
+	a.EnableIncremental()
+	benchmarkTicks(b, a)
+}
@@ -0,0 +1,146 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/services/indicators"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IndicatorParams are the indicator settings of one timeframe
+type IndicatorParams struct {
+	EMAFast      int     `json:"ema_fast"`
+	EMASlow      int     `json:"ema_slow"`
+	RSIPeriod    int     `json:"rsi_period"`
+	RSISmoothing int     `json:"rsi_smoothing"` // RSI is averaged over this many candles, 1 leaves it raw
+	RSILow       float64 `json:"rsi_low"`       // Entry confidence needs RSI above this, votes lean on the band's midpoint
+	RSIHigh      float64 `json:"rsi_high"`      // and below this
+}
+
+// DefaultIndicatorParams returns EMA 8/21 and an unsmoothed RSI 14 with a 40-60 band
+func DefaultIndicatorParams() IndicatorParams {
+	return IndicatorParams{
+		EMAFast:      8,
+		EMASlow:      21,
+		RSIPeriod:    14,
+		RSISmoothing: 1,
+		RSILow:       40,
+		RSIHigh:      60,
+	}
+}
+
+// Candles returns how many candles the slowest of these indicators needs for a value
+func (p IndicatorParams) Candles() int {
+	return max(p.EMASlow, p.RSIPeriod+p.RSISmoothing) + 1
+}
+
+// RSIMidline is the RSI level separating bullish from bearish votes
+func (p IndicatorParams) RSIMidline() float64 {
+	return (p.RSILow + p.RSIHigh) / 2
+}
+
+// Validate checks the settings are usable
+func (p IndicatorParams) Validate() error {
+	if p.EMAFast < 1 || p.EMASlow < 1 || p.RSIPeriod < 1 || p.RSISmoothing < 1 {
+		return fmt.Errorf("indicator periods must be positive")
+	}
+	if p.EMAFast >= p.EMASlow {
+		return fmt.Errorf("ema_fast must be below ema_slow, got %d and %d", p.EMAFast, p.EMASlow)
+	}
+	if p.RSILow < 0 || p.RSIHigh > 100 || p.RSILow >= p.RSIHigh {
+		return fmt.Errorf("rsi_low and rsi_high must satisfy 0 <= rsi_low < rsi_high <= 100, got %v and %v", p.RSILow, p.RSIHigh)
+	}
+	return nil
+}
+
+// IndicatorSet holds IndicatorParams per timeframe, timeframes left out use DefaultIndicatorParams
+// In a config file an entry only needs the fields that differ from the parameters it overrides
+type IndicatorSet map[string]IndicatorParams
+
+// For returns the settings of timeFrame
+func (s IndicatorSet) For(timeFrame string) IndicatorParams {
+	if p, ok := s[timeFrame]; ok {
+		return p
+	}
+	return DefaultIndicatorParams()
+}
+
+// UnmarshalJSON merges each timeframe's fields over its current settings
+// The result is a new map, so a copied Config never sees another's overrides
+func (s *IndicatorSet) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	merged := make(IndicatorSet, len(*s)+len(raw))
+	for tf, p := range *s {
+		merged[tf] = p
+	}
+	for tf, fields := range raw {
+		p := merged.For(tf)
+		if err := json.Unmarshal(fields, &p); err != nil {
+			return fmt.Errorf("%s: %v", tf, err)
+		}
+		merged[tf] = p
+	}
+	*s = merged
+	return nil
+}
+
+// Validate checks every configured timeframe is one indicators are computed on and its settings are usable
+func (s IndicatorSet) Validate() error {
+	for tf, p := range s {
+		if !slices.Contains(ConfluenceTimeFrames, tf) {
+			return fmt.Errorf("indicators: no indicators are computed on %s, want one of %s", tf, strings.Join(ConfluenceTimeFrames, ", "))
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("indicators %s: %v", tf, err)
+		}
+	}
+	return nil
+}
+
+// periods returns the periods an incremental IndicatorState tracks for p
+func (p IndicatorParams) periods() indicators.Periods {
+	periods := indicators.DefaultPeriods()
+	periods.EMAFast = p.EMAFast
+	periods.EMASlow = p.EMASlow
+	periods.RSI = p.RSIPeriod
+	return periods
+}
+
+// calculateRSI returns the valid RSI values of closes, averaged over RSISmoothing candles
+func (a *Analysis) calculateRSI(closes []float64, p IndicatorParams) []float64 {
+	rsi := a.rsi.CalculateValid(closes, p.RSIPeriod)
+	if p.RSISmoothing <= 1 {
+		return rsi
+	}
+	if len(rsi) < p.RSISmoothing {
+		return nil
+	}
+
+	smoothed := make([]float64, len(rsi)-p.RSISmoothing+1)
+	var window float64
+	for i, v := range rsi {
+		window += v
+		if i >= p.RSISmoothing {
+			window -= rsi[i-p.RSISmoothing]
+		}
+		if i >= p.RSISmoothing-1 {
+			smoothed[i-p.RSISmoothing+1] = window / float64(p.RSISmoothing)
+		}
+	}
+	return smoothed
+}
+
+// baseCandles returns how many candles of the analysis timeframe Analyze needs, MACD included
+func (a *Analysis) baseCandles(timeFrame string) int {
+	return max(MinIndicatorCandles, a.config.Indicators.For(timeFrame).Candles())
+}
+
+// insufficientData is the rejection reason for a window too short on timeFrame
+func insufficientData(timeFrame string) string {
+	return fmt.Sprintf("insufficient %s data", timeFrame)
+}
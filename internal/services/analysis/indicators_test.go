@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/testdb"
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+)
+
+// indicatorPoint is the indicators and confluence of one fixture window, as computed with the
+// EMA 8/21, RSI 14 and 40-60 band that were hardcoded before they became configurable
+type indicatorPoint struct {
+	Symbol     string
+	End        int // Index past the window's last 5m candle
+	Indicators IndicatorValues
+	Confluence Confluence
+}
+
+// fixtureWindow returns the 300 5m fixture candles of symbol ending before end
+func fixtureWindow(symbol string, end int) []models.Price {
+	var series []models.Price
+	for _, p := range testdb.FixturePrices(symbol, testdb.FixtureDays) {
+		if p.TimeFrame == models.PriceTimeFrame5m {
+			series = append(series, p)
+		}
+	}
+	return series[end-300 : end]
+}
+
+func TestDefaultIndicatorsReproduceHardcodedSettings(t *testing.T) {
+	data, err := os.ReadFile("testdata/default_indicators.golden.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var points []indicatorPoint
+	if err := json.Unmarshal(data, &points); err != nil {
+		t.Fatal(err)
+	}
+
+	// An empty indicators section in a strategy file must leave the defaults untouched
+	var config Config
+	if err := json.Unmarshal([]byte(`{"indicators": {}}`), &config); err != nil {
+		t.Fatal(err)
+	}
+	for _, tf := range ConfluenceTimeFrames {
+		if config.Indicators.For(tf) != DefaultIndicatorParams() {
+			t.Errorf("%s indicators from an empty section = %+v, want the defaults", tf, config.Indicators.For(tf))
+		}
+	}
+
+	same := func(a, b float64) bool { return math.Abs(a-b) <= 1e-9*math.Max(1, math.Abs(b)) }
+	for _, want := range points {
+		window := fixtureWindow(want.Symbol, want.End)
+		a := NewAnalysis()
+		got, err := a.calculateIndicators(window, a.config.Indicators.For(models.PriceTimeFrame5m))
+		if err != nil {
+			t.Fatalf("%s to %d: %v", want.Symbol, want.End, err)
+		}
+		if !same(got.EMA8, want.Indicators.EMA8) || !same(got.EMA21, want.Indicators.EMA21) || !same(got.RSI, want.Indicators.RSI) ||
+			!same(got.MACD, want.Indicators.MACD) || !same(got.Signal, want.Indicators.Signal) {
+			t.Errorf("%s to %d: indicators %+v, want %+v", want.Symbol, want.End, *got, want.Indicators)
+		}
+
+		confluence := a.confluence(window)
+		for tf, vote := range want.Confluence {
+			g := confluence[tf]
+			if g.Insufficient || g.Signal != vote.Signal || g.EMADirection != vote.EMADirection ||
+				!same(g.RSI, vote.RSI) || !same(g.Confidence, vote.Confidence) {
+				t.Errorf("%s to %d: %s vote %+v, want %+v", want.Symbol, want.End, tf, g, vote)
+			}
+		}
+	}
+}
+
+func TestIndicatorParamsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(*IndicatorParams)
+		wantErr bool
+	}{
+		{"defaults", func(*IndicatorParams) {}, false},
+		{"slower 4h style", func(p *IndicatorParams) { p.EMAFast, p.EMASlow = 20, 50 }, false},
+		{"fast not below slow", func(p *IndicatorParams) { p.EMAFast = 21 }, true},
+		{"zero period", func(p *IndicatorParams) { p.RSIPeriod = 0 }, true},
+		{"zero smoothing", func(p *IndicatorParams) { p.RSISmoothing = 0 }, true},
+		{"inverted band", func(p *IndicatorParams) { p.RSILow, p.RSIHigh = 60, 40 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := DefaultIndicatorParams()
+			tt.edit(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndicatorSetMergesPartialEntries(t *testing.T) {
+	var set IndicatorSet
+	if err := json.Unmarshal([]byte(`{"1h": {"ema_fast": 20, "ema_slow": 50}}`), &set); err != nil {
+		t.Fatal(err)
+	}
+	want := DefaultIndicatorParams()
+	want.EMAFast, want.EMASlow = 20, 50
+	if got := set.For(models.PriceTimeFrame1h); got != want {
+		t.Errorf("1h = %+v, want %+v", got, want)
+	}
+	if got := set.For(models.PriceTimeFrame5m); got != DefaultIndicatorParams() {
+		t.Errorf("5m = %+v, want the defaults", got)
+	}
+	if err := (IndicatorSet{"1m": DefaultIndicatorParams()}).Validate(); err == nil {
+		t.Error("Validate() accepted a timeframe indicators are not computed on")
+	}
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import "CryptoTradeBot/internal/models"
+
+// Inspection is what the analysis derives from a window before deciding on an entry, kept whether or
+// not it signals so the series a strategy sees can be studied outside the bot
+type Inspection struct {
+	Indicators  *IndicatorValues // Analysis timeframe indicators, nil until warmed up
+	VolumeRatio float64          // 0 without volume to compare against
+	Momentum    float64          // Net close change over the last ShortLook candles
+
+	// Direction and confidence from the indicators alone, before the pattern, SuperTrend, Ichimoku
+	// and exit checks Analyze applies; empty and 0 while Indicators is nil
+	Direction  string
+	Confidence float64
+
+	Confluence Confluence
+}
+
+// Inspect runs the indicator stage of Analyze over prices, nil when the window is too short for any of it
+func (a *Analysis) Inspect(prices []models.Price) *Inspection {
+	if len(prices) < MediumLook {
+		return nil
+	}
+	prices, _ = a.prepareWindow(prices)
+	if len(prices) < ShortLook {
+		return nil
+	}
+
+	recent := prices[len(prices)-ShortLook:]
+	inspection := &Inspection{
+		VolumeRatio: a.volumeRatio(recent),
+		Momentum:    a.checkMomentum(recent),
+		Confluence:  a.confluence(prices),
+	}
+
+	base := prices[len(prices)-1].TimeFrame
+	if len(prices) < a.baseCandles(base) {
+		return inspection
+	}
+	params := a.config.Indicators.For(base)
+	indicators, err := a.calculateIndicators(prices, params)
+	if err != nil {
+		return inspection
+	}
+	inspection.Indicators = indicators
+	inspection.Direction = a.determineDirection(indicators, inspection.Momentum)
+	inspection.Confidence = a.calculateConfidence(indicators, params, inspection.Momentum, inspection.VolumeRatio > 1.2)
+	return inspection
+}
@@ -0,0 +1,181 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// Level is a price zone where swing highs or lows have clustered
+type Level struct {
+	Price     float64
+	Touches   int     // Number of swing points in the cluster
+	Strength  float64 // 0 to 1, relative to the most touched level
+	TimeFrame string  // Highest timeframe that contributed a touch
+}
+
+type SupportResistanceService struct {
+	lookback  int     // Candles on each side a pivot must exceed
+	tolerance float64 // Relative distance within which pivots join the same level
+}
+
+// NewSupportResistanceService creates a new instance of SupportResistanceService
+func NewSupportResistanceService(lookback int, tolerance float64) *SupportResistanceService {
+	return &SupportResistanceService{
+		lookback:  lookback,
+		tolerance: tolerance,
+	}
+}
+
+type pivot struct {
+	price     float64
+	timeFrame string
+}
+
+// Levels detects swing highs and lows across every series and clusters them into levels
+// The result is sorted by price
+func (s *SupportResistanceService) Levels(series ...[]models.Price) []Level {
+	var pivots []pivot
+	for _, prices := range series {
+		pivots = append(pivots, s.pivots(prices)...)
+	}
+	if len(pivots) == 0 {
+		return nil
+	}
+
+	sort.Slice(pivots, func(i, j int) bool { return pivots[i].price < pivots[j].price })
+
+	var levels []Level
+	var total float64
+	for _, p := range pivots {
+		if n := len(levels); n > 0 && math.Abs(p.price-levels[n-1].Price)/levels[n-1].Price <= s.tolerance {
+			last := &levels[n-1]
+			total += p.price
+			last.Touches++
+			last.Price = total / float64(last.Touches)
+			if timeFrameRank(p.timeFrame) > timeFrameRank(last.TimeFrame) {
+				last.TimeFrame = p.timeFrame
+			}
+			continue
+		}
+		total = p.price
+		levels = append(levels, Level{Price: p.price, Touches: 1, TimeFrame: p.timeFrame})
+	}
+
+	maxTouches := 0
+	for _, l := range levels {
+		if l.Touches > maxTouches {
+			maxTouches = l.Touches
+		}
+	}
+	for i := range levels {
+		levels[i].Strength = float64(levels[i].Touches) / float64(maxTouches)
+	}
+
+	return levels
+}
+
+// pivots returns fractal swing highs and lows, candles whose high or low
+// is the extreme of the lookback candles on either side
+func (s *SupportResistanceService) pivots(prices []models.Price) []pivot {
+	var pivots []pivot
+
+	for i := s.lookback; i < len(prices)-s.lookback; i++ {
+		if prices[i].IsGapFill {
+			continue
+		}
+
+		isHigh, isLow := true, true
+		for j := i - s.lookback; j <= i+s.lookback; j++ {
+			if j == i {
+				continue
+			}
+			if prices[j].High >= prices[i].High {
+				isHigh = false
+			}
+			if prices[j].Low <= prices[i].Low {
+				isLow = false
+			}
+		}
+
+		if isHigh {
+			pivots = append(pivots, pivot{price: prices[i].High, timeFrame: prices[i].TimeFrame})
+		}
+		if isLow {
+			pivots = append(pivots, pivot{price: prices[i].Low, timeFrame: prices[i].TimeFrame})
+		}
+	}
+
+	return pivots
+}
+
+// NearestLevels returns the closest level below and above price, nil when there is none
+func NearestLevels(levels []Level, price float64) (*Level, *Level) {
+	var support, resistance *Level
+	for i := range levels {
+		if levels[i].Price < price {
+			support = &levels[i]
+		} else if levels[i].Price > price && resistance == nil {
+			resistance = &levels[i]
+		}
+	}
+	return support, resistance
+}
+
+// resample aggregates candles into the larger timeFrame, skipping the incomplete last bucket
+func resample(prices []models.Price, timeFrame string) []models.Price {
+	interval, known := models.TimeFrameDurations[timeFrame]
+	if !known || len(prices) == 0 {
+		return nil
+	}
+
+	// Sized for the window's span up front, this runs several times per analysis
+	span := prices[len(prices)-1].OpenTime.Sub(prices[0].OpenTime)
+	out := make([]models.Price, 0, int(span/interval)+2)
+	for _, p := range prices {
+		out, _ = addToBucket(out, p, timeFrame, interval)
+	}
+
+	return completeBuckets(out, prices[len(prices)-1], interval)
+}
+
+// addToBucket folds p into the last of buckets, or starts a new bucket when p is past it
+// It reports whether a bucket was started
+func addToBucket(buckets []models.Price, p models.Price, timeFrame string, interval time.Duration) ([]models.Price, bool) {
+	bucket := p.OpenTime.Truncate(interval)
+	if n := len(buckets); n > 0 && buckets[n-1].OpenTime.Equal(bucket) {
+		last := &buckets[n-1]
+		last.High = math.Max(last.High, p.High)
+		last.Low = math.Min(last.Low, p.Low)
+		last.Close = p.Close
+		last.CloseTime = p.CloseTime
+		last.Volume += p.Volume
+		last.IsGapFill = last.IsGapFill && p.IsGapFill
+		return buckets, false
+	}
+	return append(buckets, models.Price{
+		Symbol:    p.Symbol,
+		TimeFrame: timeFrame,
+		OpenTime:  bucket,
+		CloseTime: p.CloseTime,
+		Open:      p.Open,
+		High:      p.High,
+		Low:       p.Low,
+		Close:     p.Close,
+		Volume:    p.Volume,
+		IsGapFill: p.IsGapFill,
+	}), true
+}
+
+// completeBuckets drops the last of buckets when last, the latest candle, does not complete it
+func completeBuckets(buckets []models.Price, last models.Price, interval time.Duration) []models.Price {
+	if last.OpenTime.Add(models.TimeFrameDurations[last.TimeFrame]).Before(buckets[len(buckets)-1].OpenTime.Add(interval)) {
+		return buckets[:len(buckets)-1]
+	}
+	return buckets
+}
+
+func timeFrameRank(timeFrame string) float64 {
+	return models.TimeFrameDurations[timeFrame].Minutes()
+}
@@ -0,0 +1,104 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// doubleBottom returns 15m candles falling to a 95.0 low, rallying to a 105 high, retesting
+// the low at 95.1 and recovering to 100
+func doubleBottom() []models.Price {
+	bars := [][2]float64{ // Low, high
+		{99.5, 100.5}, {98.5, 99.5}, {97.5, 98.5}, {96.5, 97.5},
+		{95.0, 96.5}, // First bottom
+		{96.0, 97.5}, {97.5, 99}, {99, 101}, {101, 104},
+		{102, 105}, // Swing high
+		{100, 103}, {98, 100.5}, {96.5, 98.5},
+		{95.1, 97}, // Second bottom
+		{96, 97.8}, {97, 99}, {98.5, 100.2}, {99.5, 100.5},
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	prices := make([]models.Price, len(bars))
+	for i, bar := range bars {
+		mid := (bar[0] + bar[1]) / 2
+		prices[i] = models.Price{
+			Symbol:    "BTCUSDT",
+			TimeFrame: models.PriceTimeFrame15m,
+			OpenTime:  start.Add(time.Duration(i) * 15 * time.Minute),
+			CloseTime: start.Add(time.Duration(i+1)*15*time.Minute - time.Millisecond),
+			Open:      mid,
+			High:      bar[1],
+			Low:       bar[0],
+			Close:     mid,
+			Volume:    10,
+		}
+	}
+	return prices
+}
+
+func TestLevelsDetectDoubleBottom(t *testing.T) {
+	levels := NewSupportResistanceService(2, 0.003).Levels(doubleBottom())
+	if len(levels) != 2 {
+		t.Fatalf("got levels %+v, want the double bottom and the swing high", levels)
+	}
+
+	bottom, top := levels[0], levels[1]
+	if math.Abs(bottom.Price-95.05) > 1e-9 || bottom.Touches != 2 || bottom.Strength != 1 {
+		t.Errorf("support = %+v, want 95.05 touched twice at full strength", bottom)
+	}
+	if top.Price != 105 || top.Touches != 1 || top.Strength != 0.5 {
+		t.Errorf("resistance = %+v, want 105 touched once at half strength", top)
+	}
+	if bottom.TimeFrame != models.PriceTimeFrame15m {
+		t.Errorf("TimeFrame = %s, want 15m", bottom.TimeFrame)
+	}
+
+	support, resistance := NearestLevels(levels, 100)
+	if support == nil || resistance == nil || support.Price != bottom.Price || resistance.Price != 105 {
+		t.Fatalf("NearestLevels(100) = %+v, %+v", support, resistance)
+	}
+}
+
+func TestLevelsNeedTolerance(t *testing.T) {
+	// At 0.05% the two bottoms stay apart
+	if levels := NewSupportResistanceService(2, 0.0005).Levels(doubleBottom()); len(levels) != 3 {
+		t.Errorf("got %d levels, want 3 unclustered swing points", len(levels))
+	}
+}
+
+func TestPlaceExitsAtLevels(t *testing.T) {
+	levels := NewSupportResistanceService(2, 0.003).Levels(doubleBottom())
+	support, resistance := NearestLevels(levels, 100)
+
+	tests := []struct {
+		name         string
+		direction    string
+		maxDistance  float64
+		target, stop float64
+		atLevel      bool
+	}{
+		{"long targets resistance, stops under support", "long", 0.06, 105, 95.05 * 0.999, true},
+		{"short targets support, stops over resistance", "short", 0.06, 95.05, 105 * 1.001, true},
+		{"long falls back when levels are too far", "long", 0.02, 100 * (1 + TargetProfit), 100 * (1 - StopLoss), false},
+		{"short falls back when levels are too far", "short", 0.02, 100 * (1 - TargetProfit), 100 * (1 + StopLoss), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.LevelTargets = true
+			config.LevelBuffer = 0.001
+			config.MaxLevelDistance = tt.maxDistance
+			a := NewAnalysisWithConfig(config)
+
+			target, stop, targetAtLevel, stopAtLevel := a.placeExits(100, tt.direction, 0, support, resistance)
+			if math.Abs(target-tt.target) > 1e-9 || math.Abs(stop-tt.stop) > 1e-9 {
+				t.Errorf("placeExits() = target %v, stop %v, want %v, %v", target, stop, tt.target, tt.stop)
+			}
+			if targetAtLevel != tt.atLevel || stopAtLevel != tt.atLevel {
+				t.Errorf("at level = %v/%v, want %v", targetAtLevel, stopAtLevel, tt.atLevel)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package analysis
+
+import "CryptoTradeBot/internal/models"
+
+// StopAdjustment is where a strategy wants an open position's stop loss and take profit,
+// a zero level is left as it is
+type StopAdjustment struct {
+	StopLoss   float64
+	TakeProfit float64
+	Reason     string
+}
+
+// ManagePosition tightens the stop of an open position once higher timeframe structure forms in its favor
+// With StructureStop a 15m or 1h support above entry, or resistance below it for a short, carries the
+// stop to LevelBuffer beyond the level; it returns nil when the stop stays
+func (a *Analysis) ManagePosition(position *models.Position, prices []models.Price) *StopAdjustment {
+	if !a.config.StructureStop || len(prices) == 0 {
+		return nil
+	}
+
+	price := prices[len(prices)-1].Close
+	levels := a.levels.Levels(a.resample(prices, models.PriceTimeFrame15m), a.resample(prices, models.PriceTimeFrame1h))
+	support, resistance := NearestLevels(levels, price)
+
+	if position.Side == models.PositionSideLong {
+		if support == nil || support.Price <= position.EntryPrice {
+			return nil
+		}
+		if stop := support.Price * (1 - a.config.LevelBuffer); stop > position.StopLossPrice {
+			return &StopAdjustment{StopLoss: stop, Reason: "structure"}
+		}
+		return nil
+	}
+
+	if resistance == nil || resistance.Price >= position.EntryPrice {
+		return nil
+	}
+	if stop := resistance.Price * (1 + a.config.LevelBuffer); stop < position.StopLossPrice {
+		return &StopAdjustment{StopLoss: stop, Reason: "structure"}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package analysis
+
+import "time"
+
+// OrderFlow is a symbol's aggressive buying and selling over a short trailing window, read from its trades
+// It is only known live: backtests have candles alone, so the order flow filter never runs in them
+type OrderFlow struct {
+	Window     time.Duration `json:"window"`
+	BuyVolume  float64       `json:"buy_volume"`  // Quote volume of the trades whose taker bought
+	SellVolume float64       `json:"sell_volume"` // and of those whose taker sold
+	Trades     int           `json:"trades"`
+	Imbalance  float64       `json:"imbalance"`  // Buy minus sell volume over their sum, -1 to 1, 0 without volume
+	TradeRate  float64       `json:"trade_rate"` // Trades per second
+}
+
+// Volume returns the quote volume traded over the window
+func (f OrderFlow) Volume() float64 {
+	return f.BuyVolume + f.SellVolume
+}
+
+// Confirms reports whether the flow leans towards direction by at least imbalance
+func (f OrderFlow) Confirms(direction string, imbalance float64) bool {
+	if f.Volume() == 0 {
+		return false
+	}
+	if direction == "short" {
+		return -f.Imbalance >= imbalance
+	}
+	return f.Imbalance >= imbalance
+}
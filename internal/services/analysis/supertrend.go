@@ -0,0 +1,86 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"math"
+)
+
+// SuperTrendTimeFrames are the regimes reported on every result
+var SuperTrendTimeFrames = []string{
+	models.PriceTimeFrame1h,
+	models.PriceTimeFrame4h,
+}
+
+// superTrends returns the SuperTrend direction of each closed higher timeframe, resampled from prices
+// Timeframes without enough closed candles are left out
+func (a *Analysis) superTrends(prices []models.Price) map[string]int {
+	directions := make(map[string]int)
+	for _, tf := range SuperTrendTimeFrames {
+		if direction := a.superTrend(a.closedCandles(prices, tf)); direction != 0 {
+			directions[tf] = direction
+		}
+	}
+	return directions
+}
+
+// superTrend returns the latest SuperTrend direction of a series, 0 when it has none yet
+func (a *Analysis) superTrend(prices []models.Price) int {
+	highs := make([]float64, len(prices))
+	lows := make([]float64, len(prices))
+	closes := make([]float64, len(prices))
+	for i, p := range prices {
+		highs[i] = p.High
+		lows[i] = p.Low
+		closes[i] = p.Close
+	}
+
+	result := a.supertrend.Calculate(highs, lows, closes, a.config.SuperTrendPeriod, a.config.SuperTrendMultiple)
+	if result == nil {
+		return 0
+	}
+	return result.Direction[len(result.Direction)-1]
+}
+
+// closedCandles resamples prices to timeFrame and drops the last candle if it is still forming
+func (a *Analysis) closedCandles(prices []models.Price, timeFrame string) []models.Price {
+	series := a.resample(prices, timeFrame)
+	if len(series) == 0 {
+		return nil
+	}
+
+	last := prices[len(prices)-1]
+	end := last.OpenTime.Add(models.TimeFrameDurations[last.TimeFrame])
+	if series[len(series)-1].OpenTime.Add(models.TimeFrameDurations[timeFrame]).After(end) {
+		series = series[:len(series)-1]
+	}
+	return series
+}
+
+// applySuperTrend gates and adjusts confidence by the higher timeframe SuperTrend
+// It reports false when RequireSuperTrend is set and the 1h trend does not agree with direction
+func (a *Analysis) applySuperTrend(confidence float64, direction string, trends map[string]int) (float64, bool) {
+	if direction == "" {
+		return confidence, true
+	}
+
+	want := indicators.SuperTrendUp
+	if direction == "short" {
+		want = indicators.SuperTrendDown
+	}
+
+	hourly := trends[models.PriceTimeFrame1h]
+	if a.config.RequireSuperTrend && hourly != want {
+		return confidence, false
+	}
+
+	if hourly == want && trends[models.PriceTimeFrame4h] == want {
+		confidence = math.Min(confidence+a.config.SuperTrendBoost, 1.0)
+	}
+	return confidence, true
+}
+
+// superTrendWarmUp returns the closed candles SuperTrend needs on each higher timeframe
+func (a *Analysis) superTrendWarmUp() int {
+	return a.config.SuperTrendPeriod + 1
+}
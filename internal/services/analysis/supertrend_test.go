@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/indicators"
+	"math"
+	"testing"
+)
+
+func TestApplySuperTrend(t *testing.T) {
+	up, down := indicators.SuperTrendUp, indicators.SuperTrendDown
+	tests := []struct {
+		name       string
+		require    bool
+		direction  string
+		trends     map[string]int
+		confidence float64
+		agrees     bool
+	}{
+		{"boost when 1h and 4h agree", false, "long", map[string]int{"1h": up, "4h": up}, 0.75, true},
+		{"no boost when 4h disagrees", false, "long", map[string]int{"1h": up, "4h": down}, 0.7, true},
+		{"no boost without 4h", false, "long", map[string]int{"1h": up}, 0.7, true},
+		{"short boosted by downtrends", false, "short", map[string]int{"1h": down, "4h": down}, 0.75, true},
+		{"opposing 1h allowed when not required", false, "long", map[string]int{"1h": down, "4h": down}, 0.7, true},
+		{"opposing 1h blocks when required", true, "long", map[string]int{"1h": down, "4h": up}, 0.7, false},
+		{"missing 1h blocks when required", true, "short", map[string]int{"4h": down}, 0.7, false},
+		{"agreeing 1h passes when required", true, "short", map[string]int{"1h": down}, 0.7, true},
+		{"no direction is left alone", true, "", nil, 0.7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.RequireSuperTrend = tt.require
+			config.SuperTrendBoost = 0.05
+			confidence, agrees := NewAnalysisWithConfig(config).applySuperTrend(0.7, tt.direction, tt.trends)
+			if math.Abs(confidence-tt.confidence) > 1e-9 || agrees != tt.agrees {
+				t.Errorf("applySuperTrend() = %v, %v, want %v, %v", confidence, agrees, tt.confidence, tt.agrees)
+			}
+		})
+	}
+
+	// The boost never lifts confidence past certainty
+	if confidence, _ := NewAnalysis().applySuperTrend(0.98, "long", map[string]int{"1h": up, "4h": up}); confidence != 1 {
+		t.Errorf("boosted 0.98 to %v, want capped at 1", confidence)
+	}
+}
+
+func TestSuperTrendsFollowHigherTimeFrames(t *testing.T) {
+	// The zigzag climbs steadily, so every higher timeframe with enough closed candles trends up
+	a := NewAnalysis()
+	trends := a.superTrends(zigzagCandles("BTCUSDT", testStart, 12*24))
+	if trends[models.PriceTimeFrame1h] != indicators.SuperTrendUp {
+		t.Errorf("1h SuperTrend = %d, want up", trends[models.PriceTimeFrame1h])
+	}
+	if _, ok := trends[models.PriceTimeFrame4h]; ok {
+		t.Errorf("4h SuperTrend = %d from 6 closed candles, want none before its period", trends[models.PriceTimeFrame4h])
+	}
+}
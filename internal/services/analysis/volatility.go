@@ -0,0 +1,338 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Volatility regimes
+const (
+	VolatilityLow    = "low"
+	VolatilityNormal = "normal"
+	VolatilityHigh   = "high"
+)
+
+// DefaultEWMALambda is the RiskMetrics decay of the EWMA variance
+const DefaultEWMALambda = 0.94
+
+// VolatilityParams are the realized volatility settings of one timeframe
+type VolatilityParams struct {
+	Window         int     `json:"window"`          // Log returns the realized volatility is the standard deviation of
+	Baseline       int     `json:"baseline"`        // Trailing realized volatilities the latest is ranked against for its regime
+	EWMALambda     float64 `json:"ewma_lambda"`     // Weight of the previous variance in the EWMA, between 0 and 1
+	LowPercentile  float64 `json:"low_percentile"`  // The regime is low below this percentile of the baseline
+	HighPercentile float64 `json:"high_percentile"` // and high above this one
+}
+
+// DefaultVolatilityParams returns the settings of timeFrame, windows spanning a few hours
+// and baselines about half a day on 5m and 15m and two days on 1h
+func DefaultVolatilityParams(timeFrame string) VolatilityParams {
+	params := VolatilityParams{
+		Window:         24,
+		Baseline:       144,
+		EWMALambda:     DefaultEWMALambda,
+		LowPercentile:  25,
+		HighPercentile: 75,
+	}
+	switch timeFrame {
+	case models.PriceTimeFrame15m:
+		params.Window, params.Baseline = 16, 48
+	case models.PriceTimeFrame1h:
+		params.Window, params.Baseline = 12, 48
+	}
+	return params
+}
+
+// Validate checks the settings are usable
+func (p VolatilityParams) Validate() error {
+	if p.Window < 2 || p.Baseline < 1 {
+		return fmt.Errorf("window must be at least 2 and baseline at least 1, got %d and %d", p.Window, p.Baseline)
+	}
+	if p.EWMALambda <= 0 || p.EWMALambda >= 1 {
+		return fmt.Errorf("ewma_lambda must be between 0 and 1, got %v", p.EWMALambda)
+	}
+	if p.LowPercentile < 0 || p.HighPercentile > 100 || p.LowPercentile >= p.HighPercentile {
+		return fmt.Errorf("percentiles must satisfy 0 <= low_percentile < high_percentile <= 100, got %v and %v",
+			p.LowPercentile, p.HighPercentile)
+	}
+	return nil
+}
+
+// VolatilitySet holds VolatilityParams per timeframe, timeframes left out use DefaultVolatilityParams
+// In a config file an entry only needs the fields that differ from the parameters it overrides
+type VolatilitySet map[string]VolatilityParams
+
+// For returns the settings of timeFrame
+func (s VolatilitySet) For(timeFrame string) VolatilityParams {
+	if p, ok := s[timeFrame]; ok {
+		return p
+	}
+	return DefaultVolatilityParams(timeFrame)
+}
+
+// UnmarshalJSON merges each timeframe's fields over its current settings
+// The result is a new map, so a copied Config never sees another's overrides
+func (s *VolatilitySet) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	merged := make(VolatilitySet, len(*s)+len(raw))
+	for tf, p := range *s {
+		merged[tf] = p
+	}
+	for tf, fields := range raw {
+		p := merged.For(tf)
+		if err := json.Unmarshal(fields, &p); err != nil {
+			return fmt.Errorf("%s: %v", tf, err)
+		}
+		merged[tf] = p
+	}
+	*s = merged
+	return nil
+}
+
+// Validate checks every configured timeframe is one volatility is measured on and its settings are usable
+func (s VolatilitySet) Validate() error {
+	for tf, p := range s {
+		if !slices.Contains(ConfluenceTimeFrames, tf) {
+			return fmt.Errorf("volatility: not measured on %s, want one of %s", tf, strings.Join(ConfluenceTimeFrames, ", "))
+		}
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("volatility %s: %v", tf, err)
+		}
+	}
+	return nil
+}
+
+// Volatility is the realized volatility of one timeframe at its latest candle, per candle and not annualized
+type Volatility struct {
+	Realized   float64 `json:"realized"`         // Sample standard deviation of the last Window log returns
+	EWMA       float64 `json:"ewma"`             // Square root of the exponentially weighted variance of the log returns
+	Percentile float64 `json:"percentile"`       // Share of the baseline below Realized, 0 to 100
+	Regime     string  `json:"regime,omitempty"` // VolatilityLow, VolatilityNormal or VolatilityHigh, empty until the baseline is full
+}
+
+// Classify returns the regime of a realized volatility at percentile of its baseline
+// The thresholds themselves are normal
+func (p VolatilityParams) Classify(percentile float64) string {
+	switch {
+	case percentile < p.LowPercentile:
+		return VolatilityLow
+	case percentile > p.HighPercentile:
+		return VolatilityHigh
+	}
+	return VolatilityNormal
+}
+
+// VolatilityService measures realized volatility per symbol and timeframe. Each series keeps its running
+// sums, EWMA and baseline, so a window that moved on by a few candles only folds those in. The baseline
+// is therefore built across calls and may reach further back than the window at hand
+type VolatilityService struct {
+	mu     sync.Mutex
+	series map[string]*volatilitySeries
+}
+
+// NewVolatilityService creates a new instance of VolatilityService
+func NewVolatilityService() *VolatilityService {
+	return &VolatilityService{series: make(map[string]*volatilitySeries)}
+}
+
+// volatilitySeries is the running state of one symbol and timeframe
+type volatilitySeries struct {
+	params     VolatilityParams
+	returns    []float64 // Committed log returns, the last Window at most, oldest first
+	sum, sumSq float64   // Of returns
+	resum      int       // Returns since sum and sumSq were last recomputed exactly
+	variance   float64   // EWMA variance of every committed return
+	seeded     bool      // Whether variance holds a return yet
+	baseline   []float64 // Realized volatility after each commit with Window returns, the last Baseline at most
+	lastClose  float64   // Close of the last committed candle with a usable one, 0 before any
+	committed  time.Time // OpenTime of the last candle committed
+}
+
+// Measure returns the volatility of prices at their last candle, nil while there are fewer than Window returns
+// Every candle but the last is committed to the series' state; the last may still be forming and is only previewed.
+// Synthetic gap candles carry no return and are skipped
+func (s *VolatilityService) Measure(prices []models.Price, params VolatilityParams) *Volatility {
+	if len(prices) < 2 {
+		return nil
+	}
+	last := prices[len(prices)-1]
+	interval, known := models.TimeFrameDurations[last.TimeFrame]
+	if !known {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := last.Symbol + "|" + last.TimeFrame
+	series, ok := s.series[key]
+	if !ok || series.params != params || !series.advance(prices, interval) {
+		series = &volatilitySeries{params: params}
+		series.commitAll(prices[:len(prices)-1])
+		s.series[key] = series
+	}
+	return series.preview(last)
+}
+
+// advance commits the candles after the last committed one
+// It returns false on a gap, duplicate or out-of-order candle so the caller can reseed
+func (v *volatilitySeries) advance(prices []models.Price, interval time.Duration) bool {
+	start := -1
+	for i := len(prices) - 2; i >= 0; i-- {
+		if prices[i].OpenTime.Equal(v.committed) {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return false
+	}
+	for i := start + 1; i < len(prices); i++ {
+		if prices[i].OpenTime.Sub(prices[i-1].OpenTime) != interval {
+			return false
+		}
+	}
+
+	v.commitAll(prices[start+1 : len(prices)-1])
+	return true
+}
+
+func (v *volatilitySeries) commitAll(prices []models.Price) {
+	for _, p := range prices {
+		v.commit(p)
+	}
+}
+
+// commit folds a closed candle into the state
+func (v *volatilitySeries) commit(p models.Price) {
+	v.committed = p.OpenTime
+	r, ok := v.logReturn(p)
+	if !p.IsGapFill && p.Close > 0 {
+		v.lastClose = p.Close
+	}
+	if !ok {
+		return
+	}
+
+	v.variance = v.ewma(r)
+	v.seeded = true
+
+	v.returns = append(v.returns, r)
+	v.sum += r
+	v.sumSq += r * r
+	if len(v.returns) > v.params.Window {
+		oldest := v.returns[0]
+		v.returns = v.returns[1:]
+		v.sum -= oldest
+		v.sumSq -= oldest * oldest
+	}
+
+	// Rolling sums drift, recomputing them once per window keeps the error bounded at O(1) a candle
+	if v.resum++; v.resum >= v.params.Window {
+		v.sum, v.sumSq = 0, 0
+		for _, x := range v.returns {
+			v.sum += x
+			v.sumSq += x * x
+		}
+		v.resum = 0
+	}
+
+	if len(v.returns) == v.params.Window {
+		v.baseline = append(v.baseline, stdDev(len(v.returns), v.sum, v.sumSq))
+		if len(v.baseline) > v.params.Baseline {
+			v.baseline = v.baseline[1:]
+		}
+	}
+}
+
+// logReturn returns the log return of p over the last committed close, false when either is unusable
+func (v *volatilitySeries) logReturn(p models.Price) (float64, bool) {
+	if p.IsGapFill || !(p.Close > 0) || v.lastClose == 0 {
+		return 0, false
+	}
+	return math.Log(p.Close / v.lastClose), true
+}
+
+// ewma returns the variance with r folded in, r's square on the first return
+func (v *volatilitySeries) ewma(r float64) float64 {
+	if !v.seeded {
+		return r * r
+	}
+	return v.params.EWMALambda*v.variance + (1-v.params.EWMALambda)*r*r
+}
+
+// preview returns the volatility with last's return folded in, leaving the state as it is
+func (v *volatilitySeries) preview(last models.Price) *Volatility {
+	n, sum, sumSq, variance := len(v.returns), v.sum, v.sumSq, v.variance
+	if r, ok := v.logReturn(last); ok {
+		variance = v.ewma(r)
+		sum += r
+		sumSq += r * r
+		n++
+		if n > v.params.Window {
+			oldest := v.returns[0]
+			sum -= oldest
+			sumSq -= oldest * oldest
+			n--
+		}
+	}
+	if n < v.params.Window {
+		return nil
+	}
+
+	realized := stdDev(n, sum, sumSq)
+	vol := &Volatility{Realized: realized, EWMA: math.Sqrt(variance)}
+	if len(v.baseline) > 0 {
+		below := 0
+		for _, b := range v.baseline {
+			if b < realized {
+				below++
+			}
+		}
+		vol.Percentile = 100 * float64(below) / float64(len(v.baseline))
+	}
+	if len(v.baseline) == v.params.Baseline {
+		vol.Regime = v.params.Classify(vol.Percentile)
+	}
+	return vol
+}
+
+// stdDev returns the sample standard deviation of n values from their sum and sum of squares
+func stdDev(n int, sum, sumSq float64) float64 {
+	if n < 2 {
+		return 0
+	}
+	variance := (sumSq - sum*sum/float64(n)) / float64(n-1)
+	return math.Sqrt(math.Max(variance, 0))
+}
+
+// volatility measures every ConfluenceTimeFrame from the analysis timeframe up, leaving out those
+// the window has too few candles for. Higher timeframes are built by resampling the window
+func (a *Analysis) volatility(prices []models.Price) map[string]*Volatility {
+	base := prices[len(prices)-1].TimeFrame
+	result := make(map[string]*Volatility)
+
+	for _, tf := range ConfluenceTimeFrames {
+		series := prices
+		if tf != base {
+			if timeFrameRank(tf) < timeFrameRank(base) {
+				continue
+			}
+			series = a.resample(prices, tf)
+		}
+		if vol := a.volatilities.Measure(series, a.config.Volatility.For(tf)); vol != nil {
+			result[tf] = vol
+		}
+	}
+	return result
+}
@@ -0,0 +1,180 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// closing returns consecutive 5m candles from testStart closing at closes
+func closing(closes ...float64) []models.Price {
+	prices := make([]models.Price, len(closes))
+	for i, c := range closes {
+		prices[i] = models.Price{
+			Symbol:    "BTCUSDT",
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  testStart.Add(time.Duration(i) * 5 * time.Minute),
+			Open:      c,
+			High:      c,
+			Low:       c,
+			Close:     c,
+		}
+	}
+	return prices
+}
+
+// returning returns candles from 100 whose log returns are returns
+func returning(returns ...float64) []models.Price {
+	closes := []float64{100}
+	for _, r := range returns {
+		closes = append(closes, closes[len(closes)-1]*math.Exp(r))
+	}
+	return closing(closes...)
+}
+
+func TestMeasureMatchesHandCalculation(t *testing.T) {
+	params := VolatilityParams{Window: 3, Baseline: 2, EWMALambda: 0.94, LowPercentile: 25, HighPercentile: 75}
+	vol := NewVolatilityService().Measure(closing(100, 110, 99, 108.9), params)
+	if vol == nil {
+		t.Fatal("no volatility from 3 returns with a window of 3")
+	}
+
+	// Returns up 10%, down 10%, up 10%
+	up, down := math.Log(1.1), math.Log(0.9)
+	mean := (2*up + down) / 3
+	realized := math.Sqrt((2*(up-mean)*(up-mean) + (down-mean)*(down-mean)) / 2)
+	variance := up * up
+	variance = 0.94*variance + 0.06*down*down
+	variance = 0.94*variance + 0.06*up*up
+
+	if math.Abs(vol.Realized-realized) > 1e-12 {
+		t.Errorf("Realized = %v, want %v", vol.Realized, realized)
+	}
+	if math.Abs(vol.EWMA-math.Sqrt(variance)) > 1e-12 {
+		t.Errorf("EWMA = %v, want %v", vol.EWMA, math.Sqrt(variance))
+	}
+	if vol.Regime != "" {
+		t.Errorf("Regime = %q with no baseline yet, want none", vol.Regime)
+	}
+}
+
+func TestMeasureNeedsAFullWindow(t *testing.T) {
+	params := VolatilityParams{Window: 3, Baseline: 2, EWMALambda: 0.94, LowPercentile: 25, HighPercentile: 75}
+	if vol := NewVolatilityService().Measure(closing(100, 110, 99), params); vol != nil {
+		t.Errorf("Measure over 2 returns = %+v, want nil", vol)
+	}
+
+	// A gap fill carries no return, so 4 candles with one are still short of the window
+	prices := closing(100, 110, 99, 108.9)
+	prices[2].IsGapFill = true
+	if vol := NewVolatilityService().Measure(prices, params); vol != nil {
+		t.Errorf("Measure with a gap fill = %+v, want nil", vol)
+	}
+}
+
+func TestMeasureRegimeAroundThePercentiles(t *testing.T) {
+	// A window of 2 returns has a realized volatility of |r2 - r1| / √2, so the returns below commit
+	// a baseline of 0.01, 0.02, 0.03 and 0.04 over √2 and the last return sets where the latest falls
+	params := VolatilityParams{Window: 2, Baseline: 4, EWMALambda: 0.94, LowPercentile: 25, HighPercentile: 75}
+	committed := []float64{0, 0.01, 0.03, 0.06, 0.10}
+
+	tests := []struct {
+		name       string
+		step       float64 // From the last committed return to the forming one
+		percentile float64
+		want       string
+	}{
+		{"below the whole baseline", 0.005, 0, VolatilityLow},
+		{"at the low percentile", 0.015, 25, VolatilityNormal},
+		{"mid baseline", 0.025, 50, VolatilityNormal},
+		{"at the high percentile", 0.035, 75, VolatilityNormal},
+		{"above the whole baseline", 0.05, 100, VolatilityHigh},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vol := NewVolatilityService().Measure(returning(append(committed, 0.10+tt.step)...), params)
+			if vol == nil {
+				t.Fatal("no volatility")
+			}
+			if want := tt.step / math.Sqrt2; math.Abs(vol.Realized-want) > 1e-9 {
+				t.Errorf("Realized = %v, want %v", vol.Realized, want)
+			}
+			if vol.Percentile != tt.percentile || vol.Regime != tt.want {
+				t.Errorf("percentile %v regime %q, want %v %q", vol.Percentile, vol.Regime, tt.percentile, tt.want)
+			}
+		})
+	}
+
+	// One baseline value short, the percentile is known but no regime is given
+	params.Baseline = 5
+	vol := NewVolatilityService().Measure(returning(append(committed, 0.15)...), params)
+	if vol == nil || vol.Percentile != 100 || vol.Regime != "" {
+		t.Errorf("Measure with a partial baseline = %+v, want percentile 100 and no regime", vol)
+	}
+}
+
+func TestClassifyKeepsTheThresholdsNormal(t *testing.T) {
+	params := DefaultVolatilityParams(models.PriceTimeFrame5m)
+	tests := []struct {
+		percentile float64
+		want       string
+	}{
+		{0, VolatilityLow},
+		{24.9, VolatilityLow},
+		{25, VolatilityNormal},
+		{75, VolatilityNormal},
+		{75.1, VolatilityHigh},
+		{100, VolatilityHigh},
+	}
+	for _, tt := range tests {
+		if got := params.Classify(tt.percentile); got != tt.want {
+			t.Errorf("Classify(%v) = %q, want %q", tt.percentile, got, tt.want)
+		}
+	}
+}
+
+func TestMeasureIncrementallyMatchesAFreshSeries(t *testing.T) {
+	params := VolatilityParams{Window: 8, Baseline: 20, EWMALambda: 0.94, LowPercentile: 25, HighPercentile: 75}
+	prices := zigzagCandles("BTCUSDT", testStart, 60)
+	for i := 10; i < len(prices); i += 7 {
+		prices[i].Close *= 1.004 // Uneven returns, so the baseline is not constant
+	}
+
+	running := NewVolatilityService()
+	for n := params.Window + 1; n <= len(prices); n++ {
+		got := running.Measure(prices[:n], params)
+		want := NewVolatilityService().Measure(prices[:n], params)
+		if got == nil || want == nil {
+			t.Fatalf("%d candles: running %+v, fresh %+v", n, got, want)
+		}
+		if math.Abs(got.Realized-want.Realized) > 1e-12 || math.Abs(got.EWMA-want.EWMA) > 1e-12 ||
+			got.Percentile != want.Percentile || got.Regime != want.Regime {
+			t.Fatalf("%d candles: running %+v, fresh %+v", n, *got, *want)
+		}
+	}
+}
+
+func TestVolatilityParamsValidate(t *testing.T) {
+	valid := DefaultVolatilityParams(models.PriceTimeFrame5m)
+	tests := []struct {
+		name    string
+		mutate  func(*VolatilityParams)
+		wantErr bool
+	}{
+		{"defaults", func(*VolatilityParams) {}, false},
+		{"window of 1", func(p *VolatilityParams) { p.Window = 1 }, true},
+		{"no baseline", func(p *VolatilityParams) { p.Baseline = 0 }, true},
+		{"lambda of 1", func(p *VolatilityParams) { p.EWMALambda = 1 }, true},
+		{"percentiles crossed", func(p *VolatilityParams) { p.LowPercentile, p.HighPercentile = 80, 20 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.mutate(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"time"
+)
+
+// ValueAreaShare is the share of a session's volume the value area around the point of control holds
+const ValueAreaShare = 0.7
+
+// VolumeData is the volume picture behind a signal
+type VolumeData struct {
+	OBVTrend int     // indicators.OBVRising, OBVFalling or OBVFlat against the OBV's EMA
+	POC      float64 // Point of control, the middle of the session's heaviest price bucket; 0 when the session is too thin
+	VAH      float64 // Value area high, top of the buckets around POC holding ValueAreaShare of the volume
+	VAL      float64 // Value area low
+}
+
+// volumeData returns the OBV trend over the window and the profile of its last ProfileHours
+func (a *Analysis) volumeData(prices []models.Price) *VolumeData {
+	closes := make([]float64, 0, len(prices))
+	volumes := make([]float64, 0, len(prices))
+	for _, p := range prices {
+		if p.IsGapFill {
+			continue
+		}
+		closes = append(closes, p.Close)
+		volumes = append(volumes, p.Volume)
+	}
+
+	data := &VolumeData{}
+	if obv := a.obv.Calculate(closes, volumes); obv != nil {
+		data.OBVTrend = a.obv.Trend(obv, a.config.OBVPeriod)
+	}
+
+	session := time.Duration(a.config.ProfileHours) * time.Hour
+	data.POC, data.VAH, data.VAL, _ = VolumeProfile(sessionCandles(prices, session), a.config.ProfileBuckets)
+	return data
+}
+
+// sessionCandles returns the candles of prices that opened within session of the last one
+func sessionCandles(prices []models.Price, session time.Duration) []models.Price {
+	if len(prices) == 0 {
+		return nil
+	}
+	from := prices[len(prices)-1].OpenTime.Add(-session)
+	for i, p := range prices {
+		if p.OpenTime.After(from) {
+			return prices[i:]
+		}
+	}
+	return nil
+}
+
+// VolumeProfile buckets the volume of prices by price and returns the point of control and value area
+// The session's high to low range is split into buckets of equal size, so the step follows each
+// symbol's recent volatility, and every candle's volume is spread evenly over the range it traded.
+// It reports false, with zero levels, when the candles hold no volume or no range.
+func VolumeProfile(prices []models.Price, buckets int) (poc, vah, val float64, ok bool) {
+	low, high := math.Inf(1), math.Inf(-1)
+	var total float64
+	for _, p := range prices {
+		if p.IsGapFill || !(p.Volume > 0) || p.Low <= 0 || p.High < p.Low {
+			continue
+		}
+		low = math.Min(low, p.Low)
+		high = math.Max(high, p.High)
+		total += p.Volume
+	}
+	if buckets < 1 || total == 0 || !(high > low) {
+		return 0, 0, 0, false
+	}
+
+	step := (high - low) / float64(buckets)
+	bucket := func(price float64) int {
+		return min(int((price-low)/step), buckets-1)
+	}
+
+	volume := make([]float64, buckets)
+	for _, p := range prices {
+		if p.IsGapFill || !(p.Volume > 0) || p.Low <= 0 || p.High < p.Low {
+			continue
+		}
+		if p.High == p.Low {
+			volume[bucket(p.Low)] += p.Volume
+			continue
+		}
+		for b := bucket(p.Low); b <= bucket(p.High); b++ {
+			bottom := low + float64(b)*step
+			overlap := math.Min(p.High, bottom+step) - math.Max(p.Low, bottom)
+			volume[b] += p.Volume * math.Max(overlap, 0) / (p.High - p.Low)
+		}
+	}
+
+	// The heaviest bucket, the lowest of equals
+	center := 0
+	for b := range volume {
+		if volume[b] > volume[center] {
+			center = b
+		}
+	}
+
+	// Grow the value area from the POC towards the heavier neighbour until it holds its share
+	from, to := center, center
+	area := volume[center]
+	for area < ValueAreaShare*total && (from > 0 || to < buckets-1) {
+		below, above := -1.0, -1.0
+		if from > 0 {
+			below = volume[from-1]
+		}
+		if to < buckets-1 {
+			above = volume[to+1]
+		}
+		if above >= below {
+			to++
+			area += above
+		} else {
+			from--
+			area += below
+		}
+	}
+
+	poc = low + (float64(center)+0.5)*step
+	return poc, low + float64(to+1)*step, low + float64(from)*step, true
+}
+
+// nearVolumeNode reports whether the session's point of control sits just ahead of an entry at
+// price, within POCDistance: above a long, where supply waits, or below a short
+func (a *Analysis) nearVolumeNode(direction string, price float64, data *VolumeData) bool {
+	if data == nil || data.POC <= 0 {
+		return false
+	}
+	distance := (data.POC - price) / price
+	if direction == "short" {
+		distance = -distance
+	}
+	return distance > 0 && distance <= a.config.POCDistance
+}
@@ -0,0 +1,152 @@
+package analysis
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+	"time"
+)
+
+// pointCandle returns the i-th 5m candle trading only at price, with volume
+func pointCandle(i int, price, volume float64) models.Price {
+	return models.Price{
+		Symbol:    "BTCUSDT",
+		TimeFrame: models.PriceTimeFrame5m,
+		OpenTime:  testStart.Add(time.Duration(i) * 5 * time.Minute),
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Volume:    volume,
+	}
+}
+
+// profileSession trades each volume at the middle of its bucket of 100 to 110 in steps of 1,
+// the range pinned by a candle at either end
+func profileSession(volumes []float64) []models.Price {
+	prices := []models.Price{pointCandle(0, 100, 1), pointCandle(1, 110, 1)}
+	for b, volume := range volumes {
+		prices = append(prices, pointCandle(len(prices), 100+float64(b)+0.5, volume))
+	}
+	return prices
+}
+
+func TestVolumeProfile(t *testing.T) {
+	spread := pointCandle(0, 100, 10)
+	spread.High = 110 // 1 in each bucket
+
+	gapFill := pointCandle(3, 109.5, 1000)
+	gapFill.IsGapFill = true
+
+	tests := []struct {
+		name          string
+		prices        []models.Price
+		buckets       int
+		poc, vah, val float64
+		ok            bool
+	}{
+		{
+			// Buckets hold 2, 2, 5, 10, 30, 20, 8, 3, 1, 2 of 83: the area grows from 104 up to 106, then
+			// down to 103, where it holds 60, past 70%
+			name:    "constructed distribution",
+			prices:  profileSession([]float64{1, 2, 5, 10, 30, 20, 8, 3, 1, 1}),
+			buckets: 10,
+			poc:     104.5, vah: 106, val: 103,
+			ok: true,
+		},
+		{
+			name:    "volume spread over a candle's range",
+			prices:  []models.Price{spread},
+			buckets: 10,
+			// Every bucket holds 1, the lowest is the POC and the area grows up through 7 buckets
+			poc: 100.5, vah: 107, val: 100,
+			ok: true,
+		},
+		{
+			name:    "gap fills ignored",
+			prices:  append(profileSession([]float64{1, 2, 5, 10, 30, 20, 8, 3, 1, 1}), gapFill),
+			buckets: 10,
+			poc:     104.5, vah: 106, val: 103,
+			ok: true,
+		},
+		{name: "empty session", buckets: 10},
+		{name: "no volume", prices: []models.Price{pointCandle(0, 100, 0), pointCandle(1, 110, 0)}, buckets: 10},
+		{name: "no range", prices: []models.Price{pointCandle(0, 100, 5), pointCandle(1, 100, 5)}, buckets: 10},
+		{name: "no buckets", prices: profileSession([]float64{1, 2}), buckets: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poc, vah, val, ok := VolumeProfile(tt.prices, tt.buckets)
+			for _, level := range []float64{poc, vah, val} {
+				if math.IsNaN(level) || math.IsInf(level, 0) {
+					t.Fatalf("VolumeProfile() = %v, %v, %v, want finite levels", poc, vah, val)
+				}
+			}
+			if ok != tt.ok || math.Abs(poc-tt.poc) > 1e-9 || math.Abs(vah-tt.vah) > 1e-9 || math.Abs(val-tt.val) > 1e-9 {
+				t.Errorf("VolumeProfile() = POC %v, VAH %v, VAL %v, %v, want %v, %v, %v, %v",
+					poc, vah, val, ok, tt.poc, tt.vah, tt.val, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSessionCandles(t *testing.T) {
+	prices := zigzagCandles("BTCUSDT", testStart, 300)
+	session := sessionCandles(prices, 2*time.Hour)
+	// The last candle and the 23 opening after the one exactly two hours before it
+	if len(session) != 24 || session[len(session)-1].OpenTime != prices[len(prices)-1].OpenTime {
+		t.Errorf("session = %d candles, want the last 24", len(session))
+	}
+	if got := sessionCandles(nil, time.Hour); got != nil {
+		t.Errorf("sessionCandles(nil) = %v, want nil", got)
+	}
+}
+
+func TestVolumeDataOnThinSessions(t *testing.T) {
+	a := NewAnalysisWithConfig(DefaultConfig())
+	tests := []struct {
+		name   string
+		prices []models.Price
+	}{
+		{"single candle", []models.Price{pointCandle(0, 100, 5)}},
+		{"no volume", []models.Price{pointCandle(0, 100, 0), pointCandle(1, 101, 0)}},
+		{"zigzag window", zigzagCandles("BTCUSDT", testStart, 300)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := a.volumeData(tt.prices)
+			for _, level := range []float64{data.POC, data.VAH, data.VAL} {
+				if math.IsNaN(level) || math.IsInf(level, 0) {
+					t.Fatalf("volumeData() = %+v, want finite levels", data)
+				}
+			}
+			if data.POC != 0 && !(data.VAL <= data.POC && data.POC <= data.VAH) {
+				t.Errorf("volumeData() = %+v, want VAL <= POC <= VAH", data)
+			}
+		})
+	}
+}
+
+func TestNearVolumeNode(t *testing.T) {
+	a := NewAnalysisWithConfig(DefaultConfig()) // POCDistance 0.3%
+	tests := []struct {
+		name      string
+		direction string
+		poc       float64
+		want      bool
+	}{
+		{"supply just above a long", "long", 100.2, true},
+		{"node beyond the distance", "long", 100.5, false},
+		{"node behind a long", "long", 99.8, false},
+		{"demand just below a short", "short", 99.8, true},
+		{"node behind a short", "short", 100.2, false},
+		{"no profile", "long", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.nearVolumeNode(tt.direction, 100, &VolumeData{POC: tt.poc}); got != tt.want {
+				t.Errorf("nearVolumeNode(%s, POC %v) = %v, want %v", tt.direction, tt.poc, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package indicators
+
+import "math"
+
+type ATRService struct{}
+
+func NewATRService() *ATRService {
+	return &ATRService{}
+}
+
+// Calculate returns the Average True Range using Wilder's smoothing
+// Values before index period are zero
+func (s *ATRService) Calculate(highs, lows, closes []float64, period int) []float64 {
+	if !s.ValidatePeriod(closes, period) || len(highs) != len(closes) || len(lows) != len(closes) {
+		return nil
+	}
+
+	atr := make([]float64, len(closes))
+
+	// Initial ATR is the average of the first period true ranges
+	sum := 0.0
+	for i := 1; i <= period; i++ {
+		sum += trueRange(highs[i], lows[i], closes[i-1])
+	}
+	atr[period] = sum / float64(period)
+
+	// ATR = (Previous ATR × (period - 1) + TR) / period
+	for i := period + 1; i < len(closes); i++ {
+		atr[i] = (atr[i-1]*float64(period-1) + trueRange(highs[i], lows[i], closes[i-1])) / float64(period)
+	}
+
+	return atr
+}
+
+// CalculateValid returns the ATR values from FirstValidIndex on, aligned to the tail of closes
+func (s *ATRService) CalculateValid(highs, lows, closes []float64, period int) []float64 {
+	return Valid(s.Calculate(highs, lows, closes, period), s.FirstValidIndex(period))
+}
+
+// FirstValidIndex returns the first index at which Calculate returns a real ATR value
+func (s *ATRService) FirstValidIndex(period int) int {
+	return period
+}
+
+// ValidatePeriod checks if the period is valid for the given prices
+func (s *ATRService) ValidatePeriod(prices []float64, period int) bool {
+	return len(prices) >= period+1 && period > 0
+}
+
+func trueRange(high, low, prevClose float64) float64 {
+	return math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+}
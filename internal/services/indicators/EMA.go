@@ -29,6 +29,16 @@ func (s *EMAService) Calculate(prices []float64, period int) []float64 {
 	return ema
 }
 
+// CalculateValid returns the EMA values from FirstValidIndex on, aligned to the tail of prices
+func (s *EMAService) CalculateValid(prices []float64, period int) []float64 {
+	return Valid(s.Calculate(prices, period), s.FirstValidIndex(period))
+}
+
+// FirstValidIndex returns the first index at which Calculate returns a real EMA value
+func (s *EMAService) FirstValidIndex(period int) int {
+	return period - 1
+}
+
 // CalculateOne calculates single EMA value using previous EMA
 func (s *EMAService) CalculateOne(currentPrice, previousEMA float64, period int) float64 {
 	multiplier := 2.0 / float64(period+1)
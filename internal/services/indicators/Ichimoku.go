@@ -0,0 +1,121 @@
+package indicators
+
+import "math"
+
+// Standard Ichimoku periods
+const (
+	IchimokuTenkan       = 9
+	IchimokuKijun        = 26
+	IchimokuSenkouB      = 52
+	IchimokuDisplacement = 26
+)
+
+// Ichimoku biases
+const (
+	IchimokuBearish = -1
+	IchimokuNeutral = 0
+	IchimokuBullish = 1
+)
+
+type IchimokuService struct{}
+
+// IchimokuResult holds the Ichimoku lines, each the length of the input and indexed by the
+// candle it is plotted at, zero where it has no value
+//
+// The spans are displaced, so index i does not always hold a value computed from candle i:
+// SenkouA[i] and SenkouB[i] are the cloud at candle i, computed from the candle Displacement
+// periods earlier. The cloud at the latest candle therefore comes from the candle Displacement
+// periods back, and the cloud projected past the end of the input is left out.
+// Chikou[i] is the close Displacement periods after i, plotted back at i, so the last
+// Displacement values are zero.
+type IchimokuResult struct {
+	Tenkan  []float64 // Midpoint of the highest high and lowest low over the Tenkan period
+	Kijun   []float64 // Same over the Kijun period
+	SenkouA []float64 // Midpoint of Tenkan and Kijun, displaced forward
+	SenkouB []float64 // Midpoint over the SenkouB period, displaced forward
+	Chikou  []float64 // Close, displaced back
+
+	Displacement int
+
+	// FirstValidIndex is the first index with both cloud spans, SenkouB period + Displacement - 1.
+	// Tenkan and Kijun are valid earlier, from their period - 1
+	FirstValidIndex int
+}
+
+func NewIchimokuService() *IchimokuService {
+	return &IchimokuService{}
+}
+
+// Calculate returns the Ichimoku lines, nil when there are too few candles for one cloud value
+func (s *IchimokuService) Calculate(highs, lows, closes []float64, tenkan, kijun, senkouB, displacement int) *IchimokuResult {
+	n := len(closes)
+	if tenkan < 1 || kijun < 1 || senkouB < 1 || displacement < 0 ||
+		len(highs) != n || len(lows) != n || !s.ValidatePeriod(closes, senkouB+displacement) {
+		return nil
+	}
+
+	result := &IchimokuResult{
+		Tenkan:          midpoints(highs, lows, tenkan),
+		Kijun:           midpoints(highs, lows, kijun),
+		SenkouA:         make([]float64, n),
+		SenkouB:         make([]float64, n),
+		Chikou:          make([]float64, n),
+		Displacement:    displacement,
+		FirstValidIndex: senkouB + displacement - 1,
+	}
+
+	spanB := midpoints(highs, lows, senkouB)
+	for i := displacement; i < n; i++ {
+		from := i - displacement
+		if from >= max(tenkan, kijun)-1 {
+			result.SenkouA[i] = (result.Tenkan[from] + result.Kijun[from]) / 2
+		}
+		if from >= senkouB-1 {
+			result.SenkouB[i] = spanB[from]
+		}
+	}
+	for i := 0; i+displacement < n; i++ {
+		result.Chikou[i] = closes[i+displacement]
+	}
+
+	return result
+}
+
+// Bias reads the latest candle: bullish above the cloud with Tenkan over Kijun, bearish below it
+// with Tenkan under Kijun, neutral inside the cloud or when the lines disagree with the cloud
+func (r *IchimokuResult) Bias(close float64) int {
+	last := len(r.Tenkan) - 1
+	if last < r.FirstValidIndex {
+		return IchimokuNeutral
+	}
+
+	top := math.Max(r.SenkouA[last], r.SenkouB[last])
+	bottom := math.Min(r.SenkouA[last], r.SenkouB[last])
+	switch {
+	case close > top && r.Tenkan[last] > r.Kijun[last]:
+		return IchimokuBullish
+	case close < bottom && r.Tenkan[last] < r.Kijun[last]:
+		return IchimokuBearish
+	default:
+		return IchimokuNeutral
+	}
+}
+
+// ValidatePeriod checks if the period is valid for the given prices
+func (s *IchimokuService) ValidatePeriod(prices []float64, period int) bool {
+	return period > 0 && len(prices) >= period
+}
+
+// midpoints returns the midpoint of the highest high and lowest low over each window of period candles
+func midpoints(highs, lows []float64, period int) []float64 {
+	out := make([]float64, len(highs))
+	for i := period - 1; i < len(highs); i++ {
+		high, low := highs[i], lows[i]
+		for j := i - period + 1; j < i; j++ {
+			high = math.Max(high, highs[j])
+			low = math.Min(low, lows[j])
+		}
+		out[i] = (high + low) / 2
+	}
+	return out
+}
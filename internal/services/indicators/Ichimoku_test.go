@@ -0,0 +1,132 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference series worked through the standard Ichimoku definition at Tenkan 2, Kijun 3,
+// Senkou B 4 and displacement 2, short enough to check the displacement by hand.
+// It rallies into a top and falls through the cloud
+var (
+	ichimokuHighs  = []float64{10, 11, 12, 11.5, 13, 14, 13.5, 12, 11, 10.5}
+	ichimokuLows   = []float64{9, 10, 10.5, 10, 11.5, 12.5, 12, 10.5, 9.5, 9}
+	ichimokuCloses = []float64{9.5, 10.8, 11.6, 10.6, 12.8, 13.2, 12.4, 11, 10, 9.8}
+)
+
+func TestIchimokuMatchesReference(t *testing.T) {
+	want := []struct{ tenkan, kijun, senkouA, senkouB, chikou float64 }{
+		{0, 0, 0, 0, 11.6},
+		{10, 0, 0, 0, 10.6},
+		{11, 10.5, 0, 0, 12.8},
+		{11, 11, 0, 0, 13.2},
+		{11.5, 11.5, 10.75, 0, 12.4}, // Span A plots Tenkan and Kijun from candle 2 here
+		{12.75, 12, 11, 10.5, 11},    // Span B plots the 4 candle midpoint from candle 3 here
+		{13, 12.75, 11.5, 11.5, 10},
+		{12, 12.25, 12.375, 12, 9.8},
+		{10.75, 11.5, 12.875, 12, 0}, // Chikou has no close 2 candles ahead
+		{10, 10.5, 12.125, 12.25, 0},
+	}
+
+	result := NewIchimokuService().Calculate(ichimokuHighs, ichimokuLows, ichimokuCloses, 2, 3, 4, 2)
+	if result == nil {
+		t.Fatal("Calculate() = nil")
+	}
+	if result.FirstValidIndex != 5 || result.Displacement != 2 {
+		t.Errorf("FirstValidIndex, Displacement = %d, %d, want 5, 2", result.FirstValidIndex, result.Displacement)
+	}
+	for i, w := range want {
+		got := []float64{result.Tenkan[i], result.Kijun[i], result.SenkouA[i], result.SenkouB[i], result.Chikou[i]}
+		for j, v := range []float64{w.tenkan, w.kijun, w.senkouA, w.senkouB, w.chikou} {
+			if math.Abs(got[j]-v) > 1e-9 {
+				t.Errorf("index %d: tenkan, kijun, senkou A, senkou B, chikou = %v, want %v", i, got, w)
+				break
+			}
+		}
+	}
+
+	// The latest close sits under the cloud plotted at it with Tenkan under Kijun
+	if bias := result.Bias(ichimokuCloses[len(ichimokuCloses)-1]); bias != IchimokuBearish {
+		t.Errorf("Bias() = %d, want bearish", bias)
+	}
+}
+
+func TestIchimokuNeedsSenkouBPlusDisplacement(t *testing.T) {
+	s := NewIchimokuService()
+	need := IchimokuSenkouB + IchimokuDisplacement
+	highs, lows, closes := trend(need, 100, 0.5)
+
+	if result := s.Calculate(highs[:need-1], lows[:need-1], closes[:need-1],
+		IchimokuTenkan, IchimokuKijun, IchimokuSenkouB, IchimokuDisplacement); result != nil {
+		t.Errorf("Calculate() on %d candles = %+v, want nil", need-1, result)
+	}
+
+	result := s.Calculate(highs, lows, closes, IchimokuTenkan, IchimokuKijun, IchimokuSenkouB, IchimokuDisplacement)
+	if result == nil {
+		t.Fatalf("Calculate() on %d candles = nil", need)
+	}
+	last := need - 1
+	if result.FirstValidIndex != last {
+		t.Errorf("FirstValidIndex = %d, want %d", result.FirstValidIndex, last)
+	}
+	if result.SenkouB[last] == 0 || result.SenkouB[last-1] != 0 {
+		t.Errorf("Senkou B = %v at %d and %v before it, want the first value at the last candle",
+			result.SenkouB[last], last, result.SenkouB[last-1])
+	}
+
+	// The cloud at the last candle is the Senkou B period ending Displacement candles back
+	from := last - IchimokuDisplacement
+	high, low := highs[from], lows[from-IchimokuSenkouB+1]
+	if want := (high + low) / 2; math.Abs(result.SenkouB[last]-want) > 1e-9 {
+		t.Errorf("Senkou B at the last candle = %v, want %v from candle %d", result.SenkouB[last], want, from)
+	}
+}
+
+func TestIchimokuBias(t *testing.T) {
+	s := NewIchimokuService()
+	calc := func(highs, lows, closes []float64) *IchimokuResult {
+		return s.Calculate(highs, lows, closes, IchimokuTenkan, IchimokuKijun, IchimokuSenkouB, IchimokuDisplacement)
+	}
+	n := IchimokuSenkouB + IchimokuDisplacement + 10
+
+	if bias := calc(trend(n, 100, 0.5)).Bias(200); bias != IchimokuBullish {
+		t.Errorf("rising series: Bias() = %d, want bullish", bias)
+	}
+	if bias := calc(trend(n, 200, -0.5)).Bias(100); bias != IchimokuBearish {
+		t.Errorf("falling series: Bias() = %d, want bearish", bias)
+	}
+
+	// Inside the cloud is neutral whatever Tenkan and Kijun say
+	rising := calc(trend(n, 100, 0.5))
+	last := n - 1
+	inside := (rising.SenkouA[last] + rising.SenkouB[last]) / 2
+	if bias := rising.Bias(inside); bias != IchimokuNeutral {
+		t.Errorf("close inside the cloud: Bias() = %d, want neutral", bias)
+	}
+
+	// Above the cloud but with Tenkan under Kijun after a pullback is neutral too
+	highs, lows, closes := trend(n, 100, 0.5)
+	top := closes[n-10]
+	for i := n - 9; i < n; i++ {
+		c := top - 2*float64(i-n+10)
+		highs[i], lows[i], closes[i] = c+0.5, c-0.5, c
+	}
+	pulled := calc(highs, lows, closes)
+	if pulled.Tenkan[last] >= pulled.Kijun[last] {
+		t.Fatalf("pullback Tenkan %v, Kijun %v, want Tenkan under Kijun", pulled.Tenkan[last], pulled.Kijun[last])
+	}
+	if bias := pulled.Bias(1000); bias != IchimokuNeutral {
+		t.Errorf("above the cloud with Tenkan under Kijun: Bias() = %d, want neutral", bias)
+	}
+}
+
+// trend returns n candles moving step per candle from start, each a point wide
+func trend(n int, start, step float64) (highs, lows, closes []float64) {
+	for i := range n {
+		c := start + step*float64(i)
+		highs = append(highs, c+0.5)
+		lows = append(lows, c-0.5)
+		closes = append(closes, c)
+	}
+	return highs, lows, closes
+}
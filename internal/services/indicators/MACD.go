@@ -8,6 +8,10 @@ type MACDResult struct {
 	MACD      []float64
 	Signal    []float64
 	Histogram []float64
+
+	// Values before these indexes are zero padding, not indicator output
+	MACDStart       int // First valid MACD value, slowPeriod-1
+	FirstValidIndex int // First index where MACD, Signal and Histogram are all valid
 }
 
 func NewMACDService() *MACDService {
@@ -33,22 +37,38 @@ func (s *MACDService) Calculate(prices []float64, fastPeriod, slowPeriod, signal
 		macdLine[i] = fastEMA[i] - slowEMA[i]
 	}
 
-	// Calculate signal line (EMA of MACD line)
-	signalLine := s.ema.Calculate(macdLine, signalPeriod)
+	// Calculate signal line (EMA of MACD line) over the valid MACD region only,
+	// so its SMA seed does not average in the zero padding
+	macdStart := slowPeriod - 1
+	signalLine := make([]float64, len(prices))
+	copy(signalLine[macdStart:], s.ema.Calculate(macdLine[macdStart:], signalPeriod))
 
 	// Calculate histogram (MACD line - signal line)
+	firstValid := s.FirstValidIndex(slowPeriod, signalPeriod)
 	histogram := make([]float64, len(prices))
-	for i := slowPeriod + signalPeriod - 2; i < len(prices); i++ {
+	for i := firstValid; i < len(prices); i++ {
 		histogram[i] = macdLine[i] - signalLine[i]
 	}
 
 	return &MACDResult{
-		MACD:      macdLine,
-		Signal:    signalLine,
-		Histogram: histogram,
+		MACD:            macdLine,
+		Signal:          signalLine,
+		Histogram:       histogram,
+		MACDStart:       macdStart,
+		FirstValidIndex: firstValid,
 	}
 }
 
+// FirstValidIndex returns the first index at which the signal line and histogram hold real values
+func (s *MACDService) FirstValidIndex(slowPeriod, signalPeriod int) int {
+	return slowPeriod + signalPeriod - 2
+}
+
+// Valid returns MACD, Signal and Histogram from FirstValidIndex on, aligned to the tail of the input
+func (r *MACDResult) Valid() (macd, signal, histogram []float64) {
+	return Valid(r.MACD, r.FirstValidIndex), Valid(r.Signal, r.FirstValidIndex), Valid(r.Histogram, r.FirstValidIndex)
+}
+
 // CalculateOne calculates single MACD value using previous values
 func (s *MACDService) CalculateOne(currentPrice float64, prevFastEMA, prevSlowEMA, prevSignal float64,
 	fastPeriod, slowPeriod, signalPeriod int) (float64, float64, float64) {
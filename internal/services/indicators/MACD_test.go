@@ -0,0 +1,88 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// referenceEMA is the textbook EMA written out independently: the SMA of the first period values,
+// then each value pulled 2/(period+1) of the way to the next input. Indexes before the seed are NaN
+func referenceEMA(values []float64, period int) []float64 {
+	ema := make([]float64, len(values))
+	for i := range ema {
+		ema[i] = math.NaN()
+	}
+	if len(values) < period {
+		return ema
+	}
+	sum := 0.0
+	for _, v := range values[:period] {
+		sum += v
+	}
+	ema[period-1] = sum / float64(period)
+	k := 2 / float64(period+1)
+	for i := period; i < len(values); i++ {
+		ema[i] = values[i]*k + ema[i-1]*(1-k)
+	}
+	return ema
+}
+
+// referenceMACD computes MACD with the signal EMA run over the MACD values that exist only
+func referenceMACD(closes []float64, fast, slow, signal int) (macd, signalLine []float64) {
+	fastEMA, slowEMA := referenceEMA(closes, fast), referenceEMA(closes, slow)
+	macd = make([]float64, len(closes))
+	for i := range closes {
+		macd[i] = fastEMA[i] - slowEMA[i]
+	}
+	start := slow - 1
+	signalLine = append(make([]float64, start), referenceEMA(macd[start:], signal)...)
+	return macd, signalLine
+}
+
+func TestMACDSeedsSignalFromValidValues(t *testing.T) {
+	// On a straight line EMA 2 trails by 0.5 and EMA 3 by 1, so MACD is 0.5 throughout and
+	// a signal seeded from it is 0.5 too; averaging in padding would drag it toward zero
+	closes := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	result := NewMACDService().Calculate(closes, 2, 3, 2)
+	if result.MACDStart != 2 || result.FirstValidIndex != 3 {
+		t.Fatalf("MACDStart %d, FirstValidIndex %d, want 2 and 3", result.MACDStart, result.FirstValidIndex)
+	}
+	for i := result.FirstValidIndex; i < len(closes); i++ {
+		if math.Abs(result.MACD[i]-0.5) > 1e-12 || math.Abs(result.Signal[i]-0.5) > 1e-12 || math.Abs(result.Histogram[i]) > 1e-12 {
+			t.Errorf("index %d: MACD %v, Signal %v, Histogram %v, want 0.5, 0.5, 0", i, result.MACD[i], result.Signal[i], result.Histogram[i])
+		}
+	}
+}
+
+func TestMACDMatchesReference(t *testing.T) {
+	closes := wavyCloses(120)
+	result := NewMACDService().Calculate(closes, 12, 26, 9)
+	macd, signal := referenceMACD(closes, 12, 26, 9)
+
+	if result.FirstValidIndex != 33 {
+		t.Fatalf("FirstValidIndex = %d, want 33 for 12/26/9", result.FirstValidIndex)
+	}
+	// The first valid values are the ones a padded seed would get wrong
+	for i := result.FirstValidIndex; i < len(closes); i++ {
+		if math.Abs(result.MACD[i]-macd[i]) > 1e-9 || math.Abs(result.Signal[i]-signal[i]) > 1e-9 ||
+			math.Abs(result.Histogram[i]-(macd[i]-signal[i])) > 1e-9 {
+			t.Fatalf("index %d: got %v/%v/%v, reference %v/%v/%v", i,
+				result.MACD[i], result.Signal[i], result.Histogram[i], macd[i], signal[i], macd[i]-signal[i])
+		}
+	}
+
+	valid, validSignal, validHistogram := result.Valid()
+	if len(valid) != len(closes)-33 || len(validSignal) != len(valid) || len(validHistogram) != len(valid) {
+		t.Errorf("Valid() lengths %d/%d/%d, want %d", len(valid), len(validSignal), len(validHistogram), len(closes)-33)
+	}
+}
+
+func TestMACDNeedsSlowAndSignalPeriods(t *testing.T) {
+	macd := NewMACDService()
+	if result := macd.Calculate(wavyCloses(33), 12, 26, 9); result != nil {
+		t.Error("Calculate() returned values for 33 closes, the first valid signal needs 34")
+	}
+	if result := macd.Calculate(wavyCloses(34), 12, 26, 9); result == nil {
+		t.Error("Calculate() refused 34 closes")
+	}
+}
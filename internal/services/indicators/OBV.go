@@ -0,0 +1,59 @@
+package indicators
+
+// OBV trend directions, see OBVService.Trend
+const (
+	OBVFalling = -1
+	OBVFlat    = 0
+	OBVRising  = 1
+)
+
+type OBVService struct {
+	ema *EMAService
+}
+
+func NewOBVService() *OBVService {
+	return &OBVService{ema: NewEMAService()}
+}
+
+// Calculate returns On-Balance Volume: volume added on up closes and subtracted on down closes
+// The series starts at 0 on the first candle, which has no previous close to compare to
+func (s *OBVService) Calculate(closes, volumes []float64) []float64 {
+	if len(closes) == 0 || len(closes) != len(volumes) {
+		return nil
+	}
+
+	obv := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		switch {
+		case closes[i] > closes[i-1]:
+			obv[i] = obv[i-1] + volumes[i]
+		case closes[i] < closes[i-1]:
+			obv[i] = obv[i-1] - volumes[i]
+		default:
+			obv[i] = obv[i-1]
+		}
+	}
+	return obv
+}
+
+// FirstValidIndex returns the first index at which Calculate returns a real OBV value
+func (s *OBVService) FirstValidIndex() int {
+	return 0
+}
+
+// Trend compares the latest OBV to its EMA over period, OBVFlat when there is too little history
+func (s *OBVService) Trend(obv []float64, period int) int {
+	ema := s.ema.CalculateValid(obv, period)
+	if len(ema) == 0 {
+		return OBVFlat
+	}
+
+	last, average := obv[len(obv)-1], ema[len(ema)-1]
+	switch {
+	case last > average:
+		return OBVRising
+	case last < average:
+		return OBVFalling
+	}
+	return OBVFlat
+}
@@ -0,0 +1,49 @@
+package indicators
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOBVCalculate(t *testing.T) {
+	tests := []struct {
+		name    string
+		closes  []float64
+		volumes []float64
+		want    []float64
+	}{
+		{"up, down and flat closes", []float64{10, 11, 10.5, 10.5, 12}, []float64{5, 3, 2, 4, 6}, []float64{0, 3, 1, 1, 7}},
+		{"single candle", []float64{10}, []float64{5}, []float64{0}},
+		{"empty", nil, nil, nil},
+		{"mismatched lengths", []float64{10, 11}, []float64{5}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewOBVService().Calculate(tt.closes, tt.volumes); !slices.Equal(got, tt.want) {
+				t.Errorf("Calculate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOBVTrend(t *testing.T) {
+	service := NewOBVService()
+	tests := []struct {
+		name   string
+		obv    []float64
+		period int
+		want   int
+	}{
+		{"accumulating", []float64{0, 1, 2, 3, 4, 5}, 3, OBVRising},
+		{"distributing", []float64{0, -1, -2, -3, -4, -5}, 3, OBVFalling},
+		{"flat", []float64{2, 2, 2, 2, 2}, 3, OBVFlat},
+		{"too little history", []float64{0, 1}, 3, OBVFlat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.Trend(tt.obv, tt.period); got != tt.want {
+				t.Errorf("Trend(%v, %d) = %d, want %d", tt.obv, tt.period, got, tt.want)
+			}
+		})
+	}
+}
@@ -17,32 +17,19 @@ func (s *RSIService) Calculate(prices []float64, period int) []float64 {
 		return nil
 	}
 
-	gains := make([]float64, len(prices))
-	losses := make([]float64, len(prices))
+	gains, losses := splitChanges(prices)
 
-	// Calculate price changes and separate gains/losses
-	for i := 1; i < len(prices); i++ {
-		change := prices[i] - prices[i-1]
-		if change > 0 {
-			gains[i] = change
-			losses[i] = 0
-		} else {
-			gains[i] = 0
-			losses[i] = math.Abs(change)
-		}
-	}
+	// Calculate EMAs of gains and losses, skipping the first entry which has no change behind it
+	emaGains := s.ema.Calculate(gains[1:], period)
+	emaLosses := s.ema.Calculate(losses[1:], period)
 
-	// Calculate EMAs of gains and losses
-	emaGains := s.ema.Calculate(gains, period)
-	emaLosses := s.ema.Calculate(losses, period)
-
-	// Calculate RSI
+	// Calculate RSI, change i-1 belongs to price i
 	rsi := make([]float64, len(prices))
-	for i := period; i < len(prices); i++ {
-		if emaLosses[i] == 0 {
+	for i := s.FirstValidIndex(period); i < len(prices); i++ {
+		if emaLosses[i-1] == 0 {
 			rsi[i] = 100
 		} else {
-			rs := emaGains[i] / emaLosses[i]
+			rs := emaGains[i-1] / emaLosses[i-1]
 			rsi[i] = 100 - (100 / (1 + rs))
 		}
 	}
@@ -50,6 +37,16 @@ func (s *RSIService) Calculate(prices []float64, period int) []float64 {
 	return rsi
 }
 
+// CalculateValid returns the RSI values from FirstValidIndex on, aligned to the tail of prices
+func (s *RSIService) CalculateValid(prices []float64, period int) []float64 {
+	return Valid(s.Calculate(prices, period), s.FirstValidIndex(period))
+}
+
+// FirstValidIndex returns the first index at which Calculate returns a real RSI value
+func (s *RSIService) FirstValidIndex(period int) int {
+	return period
+}
+
 func (s *RSIService) CalculateOne(currentPrice, prevPrice, prevGainEMA, prevLossEMA float64, period int) float64 {
 	var currentGain, currentLoss float64
 	change := currentPrice - prevPrice
@@ -76,3 +73,20 @@ func (s *RSIService) CalculateOne(currentPrice, prevPrice, prevGainEMA, prevLoss
 func (s *RSIService) ValidatePeriod(prices []float64, period int) bool {
 	return len(prices) >= period+1 && period > 0
 }
+
+// splitChanges separates price changes into gains and losses, the first entry of each is zero
+func splitChanges(prices []float64) ([]float64, []float64) {
+	gains := make([]float64, len(prices))
+	losses := make([]float64, len(prices))
+
+	for i := 1; i < len(prices); i++ {
+		change := prices[i] - prices[i-1]
+		if change > 0 {
+			gains[i] = change
+		} else {
+			losses[i] = math.Abs(change)
+		}
+	}
+
+	return gains, losses
+}
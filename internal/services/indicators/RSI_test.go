@@ -0,0 +1,54 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// referenceRSI averages gains and losses with referenceEMA over the price changes only
+func referenceRSI(closes []float64, period int) []float64 {
+	gains := make([]float64, len(closes)-1)
+	losses := make([]float64, len(closes)-1)
+	for i := 1; i < len(closes); i++ {
+		gains[i-1] = math.Max(closes[i]-closes[i-1], 0)
+		losses[i-1] = math.Max(closes[i-1]-closes[i], 0)
+	}
+	gainEMA, lossEMA := referenceEMA(gains, period), referenceEMA(losses, period)
+
+	rsi := make([]float64, len(closes))
+	for i := period; i < len(closes); i++ {
+		rsi[i] = 100 - 100/(1+gainEMA[i-1]/lossEMA[i-1])
+	}
+	return rsi
+}
+
+func TestRSISeedsFromPriceChangesOnly(t *testing.T) {
+	// Changes +2, -1, +2: the seed averages gain 1 and loss 0.5, where counting the first close's
+	// missing change as a zero would find no loss at all and report 100
+	closes := []float64{10, 12, 11, 13}
+	rsi := NewRSIService().Calculate(closes, 2)
+	want := []float64{0, 0, 100 - 100/(1+2.0), 100 - 100/(1+10.0)}
+	for i := range want {
+		if math.Abs(rsi[i]-want[i]) > 1e-9 {
+			t.Errorf("RSI[%d] = %v, want %v", i, rsi[i], want[i])
+		}
+	}
+}
+
+func TestRSIMatchesReference(t *testing.T) {
+	closes := wavyCloses(100)
+	service := NewRSIService()
+	rsi, reference := service.Calculate(closes, 14), referenceRSI(closes, 14)
+	first := service.FirstValidIndex(14)
+	if first != 14 {
+		t.Fatalf("FirstValidIndex = %d, want 14", first)
+	}
+	for i := first; i < len(closes); i++ {
+		if math.Abs(rsi[i]-reference[i]) > 1e-9 {
+			t.Fatalf("RSI[%d] = %v, reference %v", i, rsi[i], reference[i])
+		}
+	}
+	if valid := service.CalculateValid(closes, 14); len(valid) != len(closes)-14 || valid[0] != rsi[14] {
+		t.Errorf("CalculateValid() has %d values from %v, want %d from RSI[14]", len(valid), valid[0], len(closes)-14)
+	}
+}
@@ -0,0 +1,105 @@
+package indicators
+
+// SuperTrend directions
+const (
+	SuperTrendDown = -1
+	SuperTrendUp   = 1
+)
+
+type SuperTrendService struct {
+	atr *ATRService
+}
+
+type SuperTrendResult struct {
+	Upper      []float64 // Final upper band, the trailing stop while the trend is down
+	Lower      []float64 // Final lower band, the trailing stop while the trend is up
+	SuperTrend []float64 // Active band: Lower in an uptrend, Upper in a downtrend
+	Direction  []int     // SuperTrendUp or SuperTrendDown, 0 before FirstValidIndex
+
+	FirstValidIndex int // First index with an ATR behind it, equal to the period
+}
+
+func NewSuperTrendService() *SuperTrendService {
+	return &SuperTrendService{
+		atr: NewATRService(),
+	}
+}
+
+// Calculate returns SuperTrend bands and direction using Wilder's ATR
+//
+// The basic bands are hl2 ± multiplier×ATR. Each final band carries forward and only
+// tightens while price stays on its side: the upper band moves down but never up, the
+// lower band moves up but never down, unless the previous close crossed the band, in
+// which case it resets to the basic band. The trend flips up when a close breaks above
+// the upper band and down when a close breaks below the lower band. The first value
+// starts in a downtrend, as the common reference implementation does.
+func (s *SuperTrendService) Calculate(highs, lows, closes []float64, period int, multiplier float64) *SuperTrendResult {
+	if !s.ValidatePeriod(closes, period) || multiplier <= 0 {
+		return nil
+	}
+
+	atr := s.atr.Calculate(highs, lows, closes, period)
+	if atr == nil {
+		return nil
+	}
+
+	n := len(closes)
+	result := &SuperTrendResult{
+		Upper:           make([]float64, n),
+		Lower:           make([]float64, n),
+		SuperTrend:      make([]float64, n),
+		Direction:       make([]int, n),
+		FirstValidIndex: period,
+	}
+
+	for i := period; i < n; i++ {
+		mid := (highs[i] + lows[i]) / 2
+		basicUpper := mid + multiplier*atr[i]
+		basicLower := mid - multiplier*atr[i]
+
+		if i == period {
+			result.Upper[i] = basicUpper
+			result.Lower[i] = basicLower
+			result.Direction[i] = SuperTrendDown
+			result.SuperTrend[i] = basicUpper
+			continue
+		}
+
+		prevUpper := result.Upper[i-1]
+		prevLower := result.Lower[i-1]
+
+		// Bands only tighten while the previous close stays inside them
+		if basicUpper < prevUpper || closes[i-1] > prevUpper {
+			result.Upper[i] = basicUpper
+		} else {
+			result.Upper[i] = prevUpper
+		}
+		if basicLower > prevLower || closes[i-1] < prevLower {
+			result.Lower[i] = basicLower
+		} else {
+			result.Lower[i] = prevLower
+		}
+
+		// Flip only when the close breaks the band that is trailing it
+		direction := result.Direction[i-1]
+		if direction == SuperTrendDown && closes[i] > result.Upper[i] {
+			direction = SuperTrendUp
+		} else if direction == SuperTrendUp && closes[i] < result.Lower[i] {
+			direction = SuperTrendDown
+		}
+		result.Direction[i] = direction
+
+		if direction == SuperTrendUp {
+			result.SuperTrend[i] = result.Lower[i]
+		} else {
+			result.SuperTrend[i] = result.Upper[i]
+		}
+	}
+
+	return result
+}
+
+// ValidatePeriod checks if the period is valid for the given prices
+func (s *SuperTrendService) ValidatePeriod(prices []float64, period int) bool {
+	return s.atr.ValidatePeriod(prices, period)
+}
@@ -0,0 +1,69 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// Reference series worked through the common SuperTrend definition at period 3, multiplier 1.
+// It falls, rallies and falls again, so both bands are held, reset and flipped on
+var (
+	superTrendHighs  = []float64{10.5, 11.0, 10.8, 10.2, 9.8, 9.5, 9.9, 10.6, 11.4, 12.0, 11.8, 11.0, 10.2, 9.6}
+	superTrendLows   = []float64{9.5, 10.0, 10.0, 9.4, 9.0, 8.8, 9.1, 9.8, 10.6, 11.2, 10.9, 10.0, 9.2, 8.8}
+	superTrendCloses = []float64{10.0, 10.8, 10.2, 9.6, 9.2, 9.0, 9.8, 10.5, 11.2, 11.8, 11.0, 10.2, 9.4, 9.0}
+)
+
+func TestSuperTrendMatchesReference(t *testing.T) {
+	want := []struct {
+		upper, lower float64
+		direction    int
+	}{
+		3:  {10.6666666667, 8.9333333333, SuperTrendDown}, // Starts down on the basic bands
+		4:  {10.2444444444, 8.9333333333, SuperTrendDown}, // Upper tightens, lower holds
+		5:  {9.9462962963, 8.9333333333, SuperTrendDown},
+		6:  {9.9462962963, 8.9333333333, SuperTrendDown}, // Upper would widen, held instead
+		7:  {9.9462962963, 9.3794238683, SuperTrendUp},   // 10.5 closes above the held upper band
+		8:  {11.8470507545, 10.1529492455, SuperTrendUp}, // Upper resets after the close above it
+		9:  {11.8470507545, 10.7686328304, SuperTrendUp},
+		10: {11.8470507545, 10.7686328304, SuperTrendUp},   // Lower would loosen, held instead
+		11: {11.4028298532, 10.7686328304, SuperTrendDown}, // 10.2 closes below the trailing lower band
+		12: {10.6352199021, 8.7647800979, SuperTrendDown},  // Lower resets after the close below it
+		13: {10.0901466014, 8.7647800979, SuperTrendDown},
+	}
+
+	result := NewSuperTrendService().Calculate(superTrendHighs, superTrendLows, superTrendCloses, 3, 1)
+	if result == nil {
+		t.Fatal("Calculate() = nil")
+	}
+	if result.FirstValidIndex != 3 {
+		t.Errorf("FirstValidIndex = %d, want 3", result.FirstValidIndex)
+	}
+	for i := range superTrendCloses {
+		if i < 3 {
+			if result.Direction[i] != 0 || result.SuperTrend[i] != 0 {
+				t.Errorf("index %d before the ATR: direction %d, band %v, want none", i, result.Direction[i], result.SuperTrend[i])
+			}
+			continue
+		}
+		w := want[i]
+		active := w.upper
+		if w.direction == SuperTrendUp {
+			active = w.lower
+		}
+		if math.Abs(result.Upper[i]-w.upper) > 1e-9 || math.Abs(result.Lower[i]-w.lower) > 1e-9 ||
+			result.Direction[i] != w.direction || math.Abs(result.SuperTrend[i]-active) > 1e-9 {
+			t.Errorf("index %d: upper %.10f lower %.10f direction %d supertrend %.10f, want %.10f %.10f %d %.10f", i,
+				result.Upper[i], result.Lower[i], result.Direction[i], result.SuperTrend[i], w.upper, w.lower, w.direction, active)
+		}
+	}
+}
+
+func TestSuperTrendRejectsBadInput(t *testing.T) {
+	service := NewSuperTrendService()
+	if service.Calculate(superTrendHighs[:3], superTrendLows[:3], superTrendCloses[:3], 3, 1) != nil {
+		t.Error("Calculate() returned bands without a full ATR period")
+	}
+	if service.Calculate(superTrendHighs, superTrendLows, superTrendCloses, 3, 0) != nil {
+		t.Error("Calculate() accepted a zero multiplier")
+	}
+}
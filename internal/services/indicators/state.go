@@ -0,0 +1,174 @@
+package indicators
+
+import "math"
+
+// Periods are the indicator lengths tracked by an IndicatorState
+type Periods struct {
+	EMAFast    int
+	EMASlow    int
+	RSI        int
+	MACDFast   int
+	MACDSlow   int
+	MACDSignal int
+}
+
+// DefaultPeriods returns EMA 8/21, RSI 14 and MACD 12/26/9
+func DefaultPeriods() Periods {
+	return Periods{
+		EMAFast:    8,
+		EMASlow:    21,
+		RSI:        14,
+		MACDFast:   12,
+		MACDSlow:   26,
+		MACDSignal: 9,
+	}
+}
+
+// Snapshot holds the indicator values at one candle
+type Snapshot struct {
+	EMAFast   float64
+	EMASlow   float64
+	RSI       float64
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+// running is the smoothed state carried from one candle to the next
+type running struct {
+	lastClose float64
+	emaFast   float64
+	emaSlow   float64
+	gainEMA   float64
+	lossEMA   float64
+	macdFast  float64
+	macdSlow  float64
+	signal    float64
+}
+
+// IndicatorState updates EMA, RSI and MACD one candle at a time
+// Once seeded, each update is O(1) and matches a full Calculate over the same closes
+type IndicatorState struct {
+	periods Periods
+	ema     *EMAService
+	values  running
+	seeded  bool
+}
+
+// NewIndicatorState creates a new instance of IndicatorState
+func NewIndicatorState(periods Periods) *IndicatorState {
+	return &IndicatorState{
+		periods: periods,
+		ema:     NewEMAService(),
+	}
+}
+
+// MinSeedLength returns the number of closes Seed needs
+func (s *IndicatorState) MinSeedLength() int {
+	return max(s.periods.EMASlow, s.periods.RSI+1, s.periods.MACDSlow+s.periods.MACDSignal-1)
+}
+
+// Seeded reports whether the state holds values to update from
+func (s *IndicatorState) Seeded() bool {
+	return s.seeded
+}
+
+// Seed recomputes the state from a full series of closes
+// It returns false, leaving the state unseeded, when there are too few closes
+func (s *IndicatorState) Seed(closes []float64) bool {
+	s.seeded = false
+	if len(closes) < s.MinSeedLength() {
+		return false
+	}
+
+	last := len(closes) - 1
+	p := s.periods
+
+	emaFast := s.ema.Calculate(closes, p.EMAFast)
+	emaSlow := s.ema.Calculate(closes, p.EMASlow)
+
+	// Same constructions as RSIService.Calculate and MACDService.Calculate
+	gains, losses := splitChanges(closes)
+	gainEMA := s.ema.Calculate(gains[1:], p.RSI)
+	lossEMA := s.ema.Calculate(losses[1:], p.RSI)
+
+	macdFast := s.ema.Calculate(closes, p.MACDFast)
+	macdSlow := s.ema.Calculate(closes, p.MACDSlow)
+	macdLine := make([]float64, 0, len(closes)-p.MACDSlow+1)
+	for i := p.MACDSlow - 1; i < len(closes); i++ {
+		macdLine = append(macdLine, macdFast[i]-macdSlow[i])
+	}
+	signal := s.ema.Calculate(macdLine, p.MACDSignal)
+
+	s.values = running{
+		lastClose: closes[last],
+		emaFast:   emaFast[last],
+		emaSlow:   emaSlow[last],
+		gainEMA:   gainEMA[len(gainEMA)-1],
+		lossEMA:   lossEMA[len(lossEMA)-1],
+		macdFast:  macdFast[last],
+		macdSlow:  macdSlow[last],
+		signal:    signal[len(signal)-1],
+	}
+	s.seeded = true
+	return true
+}
+
+// Update advances the state by one closed candle
+func (s *IndicatorState) Update(close float64) Snapshot {
+	s.values = s.next(close)
+	return s.values.snapshot()
+}
+
+// Preview returns the values the state would have after close without advancing it
+// Use it for a candle that is still forming
+func (s *IndicatorState) Preview(close float64) Snapshot {
+	return s.next(close).snapshot()
+}
+
+// Current returns the values at the last seeded or updated candle
+func (s *IndicatorState) Current() Snapshot {
+	return s.values.snapshot()
+}
+
+func (s *IndicatorState) next(close float64) running {
+	p := s.periods
+	v := s.values
+
+	gain, loss := 0.0, 0.0
+	if change := close - v.lastClose; change > 0 {
+		gain = change
+	} else {
+		loss = math.Abs(change)
+	}
+
+	n := running{
+		lastClose: close,
+		emaFast:   s.ema.CalculateOne(close, v.emaFast, p.EMAFast),
+		emaSlow:   s.ema.CalculateOne(close, v.emaSlow, p.EMASlow),
+		gainEMA:   s.ema.CalculateOne(gain, v.gainEMA, p.RSI),
+		lossEMA:   s.ema.CalculateOne(loss, v.lossEMA, p.RSI),
+		macdFast:  s.ema.CalculateOne(close, v.macdFast, p.MACDFast),
+		macdSlow:  s.ema.CalculateOne(close, v.macdSlow, p.MACDSlow),
+	}
+	n.signal = s.ema.CalculateOne(n.macdFast-n.macdSlow, v.signal, p.MACDSignal)
+
+	return n
+}
+
+func (v running) snapshot() Snapshot {
+	rsi := 100.0
+	if v.lossEMA != 0 {
+		rsi = 100 - (100 / (1 + v.gainEMA/v.lossEMA))
+	}
+
+	macd := v.macdFast - v.macdSlow
+	return Snapshot{
+		EMAFast:   v.emaFast,
+		EMASlow:   v.emaSlow,
+		RSI:       rsi,
+		MACD:      macd,
+		Signal:    v.signal,
+		Histogram: macd - v.signal,
+	}
+}
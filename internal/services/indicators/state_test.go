@@ -0,0 +1,129 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+const tolerance = 1e-9
+
+// wavyCloses returns n closes drifting up through a wave, so gains, losses and crossovers all occur
+func wavyCloses(n int) []float64 {
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = 100 + 0.05*float64(i) + 3*math.Sin(float64(i)/7) + math.Sin(float64(i)*1.3)
+	}
+	return closes
+}
+
+// fullSnapshot recomputes every indicator over closes and returns the values at the last one
+func fullSnapshot(closes []float64, p Periods) Snapshot {
+	ema := NewEMAService()
+	last := len(closes) - 1
+	macd := NewMACDService().Calculate(closes, p.MACDFast, p.MACDSlow, p.MACDSignal)
+	return Snapshot{
+		EMAFast:   ema.Calculate(closes, p.EMAFast)[last],
+		EMASlow:   ema.Calculate(closes, p.EMASlow)[last],
+		RSI:       NewRSIService().Calculate(closes, p.RSI)[last],
+		MACD:      macd.MACD[last],
+		Signal:    macd.Signal[last],
+		Histogram: macd.Histogram[last],
+	}
+}
+
+func assertSnapshot(t *testing.T, at int, got, want Snapshot) {
+	t.Helper()
+	fields := []struct {
+		name      string
+		got, want float64
+	}{
+		{"EMAFast", got.EMAFast, want.EMAFast},
+		{"EMASlow", got.EMASlow, want.EMASlow},
+		{"RSI", got.RSI, want.RSI},
+		{"MACD", got.MACD, want.MACD},
+		{"Signal", got.Signal, want.Signal},
+		{"Histogram", got.Histogram, want.Histogram},
+	}
+	for _, f := range fields {
+		if math.Abs(f.got-f.want) > tolerance {
+			t.Errorf("candle %d: %s = %v incrementally, %v recomputed", at, f.name, f.got, f.want)
+		}
+	}
+}
+
+func TestIndicatorStateMatchesFullCalculation(t *testing.T) {
+	periods := DefaultPeriods()
+	closes := wavyCloses(300)
+	state := NewIndicatorState(periods)
+
+	seed := state.MinSeedLength()
+	if !state.Seed(closes[:seed]) {
+		t.Fatalf("Seed() refused %d closes, its own minimum", seed)
+	}
+	assertSnapshot(t, seed-1, state.Current(), fullSnapshot(closes[:seed], periods))
+
+	for i := seed; i < len(closes); i++ {
+		// A preview is what the update will give, and leaves the state where it was
+		preview := state.Preview(closes[i])
+		assertSnapshot(t, i-1, state.Current(), fullSnapshot(closes[:i], periods))
+
+		got := state.Update(closes[i])
+		if got != preview {
+			t.Fatalf("candle %d: Update() = %+v, Preview() gave %+v", i, got, preview)
+		}
+		assertSnapshot(t, i, got, fullSnapshot(closes[:i+1], periods))
+	}
+}
+
+func TestIndicatorStateCustomPeriods(t *testing.T) {
+	periods := Periods{EMAFast: 5, EMASlow: 50, RSI: 7, MACDFast: 6, MACDSlow: 13, MACDSignal: 5}
+	closes := wavyCloses(200)
+	state := NewIndicatorState(periods)
+	if !state.Seed(closes[:80]) {
+		t.Fatal("Seed() refused 80 closes")
+	}
+	for i := 80; i < len(closes); i++ {
+		state.Update(closes[i])
+	}
+	assertSnapshot(t, len(closes)-1, state.Current(), fullSnapshot(closes, periods))
+}
+
+func TestIndicatorStateSeedNeedsEnoughCloses(t *testing.T) {
+	state := NewIndicatorState(DefaultPeriods())
+	if got := state.MinSeedLength(); got != 34 {
+		t.Errorf("MinSeedLength() = %d, want 34 for MACD 26+9", got)
+	}
+	if state.Seed(wavyCloses(33)) {
+		t.Error("Seed() accepted 33 closes")
+	}
+	if state.Seeded() {
+		t.Error("a refused seed left the state seeded")
+	}
+}
+
+// Every live tick either recomputes the whole window or folds in one candle
+
+const benchmarkWindow = 250
+
+func BenchmarkFullRecalculation(b *testing.B) {
+	closes := wavyCloses(benchmarkWindow)
+	p := DefaultPeriods()
+	ema, rsi, macd := NewEMAService(), NewRSIService(), NewMACDService()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ema.Calculate(closes, p.EMAFast)
+		ema.Calculate(closes, p.EMASlow)
+		rsi.Calculate(closes, p.RSI)
+		macd.Calculate(closes, p.MACDFast, p.MACDSlow, p.MACDSignal)
+	}
+}
+
+func BenchmarkIncrementalUpdate(b *testing.B) {
+	closes := wavyCloses(benchmarkWindow)
+	state := NewIndicatorState(DefaultPeriods())
+	state.Seed(closes)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		state.Update(closes[i%len(closes)])
+	}
+}
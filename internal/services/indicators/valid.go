@@ -0,0 +1,36 @@
+package indicators
+
+// Indicator series are returned the length of their input, with zero padding before the
+// first index that has enough history behind it. Each service reports that index through
+// FirstValidIndex, and CalculateValid returns only the valid tail.
+
+// Valid returns the part of values from firstValid on, nil when there is none
+// The result stays aligned to the tail of the input: its last value belongs to the last input
+func Valid(values []float64, firstValid int) []float64 {
+	if firstValid < 0 || firstValid >= len(values) {
+		return nil
+	}
+	return values[firstValid:]
+}
+
+// Align trims tail-aligned series to the length of the shortest, so index i refers to the
+// same candle in every series. It returns nil when any series is empty.
+func Align(series ...[]float64) [][]float64 {
+	if len(series) == 0 {
+		return nil
+	}
+
+	shortest := len(series[0])
+	for _, s := range series {
+		shortest = min(shortest, len(s))
+	}
+	if shortest == 0 {
+		return nil
+	}
+
+	aligned := make([][]float64, len(series))
+	for i, s := range series {
+		aligned[i] = s[len(s)-shortest:]
+	}
+	return aligned
+}
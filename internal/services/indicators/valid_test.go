@@ -0,0 +1,94 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	values := []float64{0, 0, 3, 4}
+	tests := []struct {
+		first int
+		want  []float64
+	}{
+		{0, []float64{0, 0, 3, 4}},
+		{2, []float64{3, 4}},
+		{3, []float64{4}},
+		{4, nil},
+		{-1, nil},
+	}
+	for _, tt := range tests {
+		got := Valid(values, tt.first)
+		if len(got) != len(tt.want) {
+			t.Errorf("Valid(%d) = %v, want %v", tt.first, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Valid(%d) = %v, want %v", tt.first, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestAlignKeepsTheCommonTail(t *testing.T) {
+	aligned := Align([]float64{1, 2, 3, 4}, []float64{30, 40}, []float64{200, 300, 400})
+	if len(aligned) != 3 {
+		t.Fatalf("Align() returned %d series", len(aligned))
+	}
+	for i, want := range [][]float64{{3, 4}, {30, 40}, {300, 400}} {
+		if len(aligned[i]) != 2 || aligned[i][0] != want[0] || aligned[i][1] != want[1] {
+			t.Errorf("series %d = %v, want %v", i, aligned[i], want)
+		}
+	}
+	if Align([]float64{1}, nil) != nil {
+		t.Error("Align() with an empty series returned values")
+	}
+	if Align() != nil {
+		t.Error("Align() of nothing returned values")
+	}
+}
+
+// Each indicator at exactly its minimum length returns one valid value and nothing from the padding
+func TestCalculateValidAtMinimumLength(t *testing.T) {
+	closes := wavyCloses(60)
+	highs, lows := make([]float64, len(closes)), make([]float64, len(closes))
+	for i, c := range closes {
+		highs[i], lows[i] = c+0.5, c-0.5
+	}
+	ema, rsi, atr, macd := NewEMAService(), NewRSIService(), NewATRService(), NewMACDService()
+
+	tests := []struct {
+		name      string
+		minimum   int
+		calculate func(n int) []float64
+		low, high float64 // Range a real value falls in, padding zeros fall outside
+	}{
+		{"EMA 21", 21, func(n int) []float64 { return ema.CalculateValid(closes[:n], 21) }, 90, 120},
+		{"RSI 14", 15, func(n int) []float64 { return rsi.CalculateValid(closes[:n], 14) }, 0.0001, 99.9999},
+		{"ATR 14", 15, func(n int) []float64 { return atr.CalculateValid(highs[:n], lows[:n], closes[:n], 14) }, 0.5, 10},
+		{"MACD signal", 34, func(n int) []float64 {
+			result := macd.Calculate(closes[:n], 12, 26, 9)
+			if result == nil {
+				return nil
+			}
+			_, signal, _ := result.Valid()
+			return signal
+		}, -5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.calculate(tt.minimum - 1); len(got) != 0 {
+				t.Errorf("%d values from %d inputs, below the minimum", len(got), tt.minimum-1)
+			}
+			got := tt.calculate(tt.minimum)
+			if len(got) != 1 {
+				t.Fatalf("%d values at the minimum %d, want 1", len(got), tt.minimum)
+			}
+			if got[0] == 0 || math.IsNaN(got[0]) || got[0] < tt.low || got[0] > tt.high {
+				t.Errorf("value %v at the minimum, want a real one in [%v, %v]", got[0], tt.low, tt.high)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// equityStopKey and equityStopVersion store the stop's session in the bot state
+const (
+	equityStopKey     = "risk/equity-stop"
+	equityStopVersion = 1
+)
+
+// EquityStopConfig sets the account-level stop
+type EquityStopConfig struct {
+	Floor float64 // Fraction of the session's starting equity below which the account is flattened
+}
+
+// DefaultEquityStopConfig flattens the account once it has lost 10% of the day's starting equity
+func DefaultEquityStopConfig() EquityStopConfig {
+	return EquityStopConfig{Floor: 0.9}
+}
+
+// Validate checks the floor is a fraction of the starting equity
+func (c EquityStopConfig) Validate() error {
+	if c.Floor <= 0 || c.Floor >= 1 {
+		return fmt.Errorf("equity stop floor must be between 0 and 1, got %v", c.Floor)
+	}
+	return nil
+}
+
+// equityStopState is the session an EquityStop watches
+type equityStopState struct {
+	Day         time.Time  `json:"day"`                  // UTC day the session started on
+	StartEquity float64    `json:"start_equity"`         // Equity first seen that day
+	TrippedAt   *time.Time `json:"tripped_at,omitempty"` // Set once equity fell through the floor
+}
+
+// EquityStop trips when an account's equity falls below Floor of what it was at the start of the session
+// A session starts with the first equity seen on each UTC day, or after Reset. Once tripped, entries are
+// vetoed until the next session.
+type EquityStop struct {
+	config    EquityStopConfig
+	stateRepo *repositories.BotStateRepository // Keeps the session across restarts, nil for memory only
+
+	mu    sync.Mutex
+	state equityStopState
+}
+
+// NewEquityStop creates a new instance of EquityStop
+// With a state repository the stored session is the source of truth, so a Reset made by another
+// process takes effect on the next check
+func NewEquityStop(config EquityStopConfig, stateRepo *repositories.BotStateRepository) *EquityStop {
+	return &EquityStop{config: config, stateRepo: stateRepo}
+}
+
+// Check records the account's equity at now, reporting true the moment it falls through the floor
+// A stop that already tripped this session does not report again
+func (s *EquityStop) Check(equity float64, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return false, err
+	}
+
+	day := now.UTC().Truncate(24 * time.Hour)
+	if !s.state.Day.Equal(day) || s.state.StartEquity <= 0 {
+		s.state = equityStopState{Day: day, StartEquity: equity}
+		return false, s.save()
+	}
+	if s.state.TrippedAt != nil || equity >= s.state.StartEquity*s.config.Floor {
+		return false, nil
+	}
+
+	trippedAt := now
+	s.state.TrippedAt = &trippedAt
+	return true, s.save()
+}
+
+// Blocked reports whether the stop tripped in the session running at now
+func (s *EquityStop) Blocked(now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return false, err
+	}
+	return s.state.TrippedAt != nil && s.state.Day.Equal(now.UTC().Truncate(24*time.Hour)), nil
+}
+
+// StartEquity returns the equity the current session started with
+func (s *EquityStop) StartEquity() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.StartEquity
+}
+
+// Floor returns the equity below which the current session trips
+func (s *EquityStop) Floor() float64 {
+	return s.StartEquity() * s.config.Floor
+}
+
+// Reset lifts a tripped stop, the next check starts a new session
+func (s *EquityStop) Reset() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state = equityStopState{}
+	if s.stateRepo == nil {
+		return nil
+	}
+	if err := s.stateRepo.Delete(equityStopKey); err != nil {
+		return fmt.Errorf("failed to reset equity stop: %v", err)
+	}
+	return nil
+}
+
+// Veto blocks every entry while the stop is tripped
+func (s *EquityStop) Veto(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	blocked, err := s.Blocked(account.Timestamp)
+	if err != nil {
+		return true, fmt.Sprintf("failed to check equity stop: %v", err)
+	}
+	if blocked {
+		return true, "account equity stop tripped, entries resume next UTC day or after -mode resume"
+	}
+	return false, ""
+}
+
+func (s *EquityStop) load() error {
+	if s.stateRepo == nil {
+		return nil
+	}
+	var state equityStopState
+	found, err := s.stateRepo.Load(equityStopKey, equityStopVersion, &state)
+	if err != nil {
+		return fmt.Errorf("failed to load equity stop: %v", err)
+	}
+	if !found {
+		state = equityStopState{}
+	}
+	s.state = state
+	return nil
+}
+
+func (s *EquityStop) save() error {
+	if s.stateRepo == nil {
+		return nil
+	}
+	if err := s.stateRepo.Save(equityStopKey, equityStopVersion, s.state); err != nil {
+		return fmt.Errorf("failed to save equity stop: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"testing"
+	"time"
+)
+
+func TestEquityStopSession(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	stop := NewEquityStop(EquityStopConfig{Floor: 0.9}, nil)
+
+	steps := []struct {
+		name    string
+		equity  float64
+		at      time.Time
+		tripped bool
+		blocked bool
+	}{
+		{"session starts", 1000, day.Add(time.Hour), false, false},
+		{"loss above the floor", 910, day.Add(2 * time.Hour), false, false},
+		{"falls through the floor", 890, day.Add(3 * time.Hour), true, true},
+		{"trips only once", 800, day.Add(4 * time.Hour), false, true},
+		{"recovery keeps entries blocked", 1000, day.Add(5 * time.Hour), false, true},
+		{"next UTC day starts a new session", 700, day.Add(25 * time.Hour), false, false},
+		{"floor follows the new start", 640, day.Add(26 * time.Hour), false, false},
+	}
+	for _, step := range steps {
+		tripped, err := stop.Check(step.equity, step.at)
+		if err != nil {
+			t.Fatalf("%s: Check() error = %v", step.name, err)
+		}
+		blocked, err := stop.Blocked(step.at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tripped != step.tripped || blocked != step.blocked {
+			t.Errorf("%s: tripped %v, blocked %v, want %v, %v", step.name, tripped, blocked, step.tripped, step.blocked)
+		}
+	}
+	if floor := stop.Floor(); floor != 630 {
+		t.Errorf("Floor() = %v, want 90%% of the 700 session start", floor)
+	}
+}
+
+func TestEquityStopVetoesUntilReset(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	stop := NewEquityStop(DefaultEquityStopConfig(), nil)
+	stop.Check(1000, at)
+	if tripped, _ := stop.Check(850, at.Add(time.Minute)); !tripped {
+		t.Fatal("10% floor not tripped at a 15% loss")
+	}
+
+	signal := &analysis.AnalysisResult{Symbol: "BTCUSDT"}
+	if vetoed, reason := stop.Veto(signal, Snapshot{Timestamp: at.Add(2 * time.Minute)}); !vetoed || reason == "" {
+		t.Errorf("Veto() = %v, %q, want the entry blocked", vetoed, reason)
+	}
+	if err := stop.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if vetoed, _ := stop.Veto(signal, Snapshot{Timestamp: at.Add(3 * time.Minute)}); vetoed {
+		t.Error("Veto() still blocks after Reset")
+	}
+}
+
+func TestEquityStopConfigValidate(t *testing.T) {
+	for _, floor := range []float64{0, -0.5, 1, 1.2} {
+		if err := (EquityStopConfig{Floor: floor}).Validate(); err == nil {
+			t.Errorf("Validate() accepted floor %v", floor)
+		}
+	}
+	if err := DefaultEquityStopConfig().Validate(); err != nil {
+		t.Errorf("default config invalid: %v", err)
+	}
+}
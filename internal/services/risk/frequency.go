@@ -0,0 +1,82 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"log"
+	"time"
+)
+
+// FrequencyConfig caps how many positions open per UTC day, 0 leaving a cap off
+type FrequencyConfig struct {
+	MaxTradesPerSymbolPerDay int
+	MaxTradesPerDay          int // Over every symbol of the account
+}
+
+// Enabled reports whether either cap is set
+func (c FrequencyConfig) Enabled() bool {
+	return c.MaxTradesPerSymbolPerDay > 0 || c.MaxTradesPerDay > 0
+}
+
+// Validate checks the caps are not negative
+func (c FrequencyConfig) Validate() error {
+	if c.MaxTradesPerSymbolPerDay < 0 || c.MaxTradesPerDay < 0 {
+		return fmt.Errorf("daily trade limits cannot be negative")
+	}
+	return nil
+}
+
+// Exceeded returns why one more entry on symbol is over a cap, given the positions already opened on
+// the symbol and in total the same UTC day; it returns "" when the entry is allowed
+func (c FrequencyConfig) Exceeded(symbol string, symbolTrades, trades int) string {
+	if c.MaxTradesPerSymbolPerDay > 0 && symbolTrades >= c.MaxTradesPerSymbolPerDay {
+		return fmt.Sprintf("daily trade limit for %s reached (%d/%d)", symbol, symbolTrades, c.MaxTradesPerSymbolPerDay)
+	}
+	if c.MaxTradesPerDay > 0 && trades >= c.MaxTradesPerDay {
+		return fmt.Sprintf("daily trade limit reached (%d/%d)", trades, c.MaxTradesPerDay)
+	}
+	return ""
+}
+
+// TradeDay returns the UTC day at falls in, the day its positions count against
+func TradeDay(at time.Time) time.Time {
+	return at.UTC().Truncate(24 * time.Hour)
+}
+
+// FrequencyLimiter vetoes entries past the daily caps, counting the account's positions, open and
+// closed, opened since UTC midnight. A position opened just before midnight counts for the day before
+type FrequencyLimiter struct {
+	config       FrequencyConfig
+	positionRepo *repositories.PositionRepository
+}
+
+// NewFrequencyLimiter creates a new instance of FrequencyLimiter
+func NewFrequencyLimiter(config FrequencyConfig, positionRepo *repositories.PositionRepository) *FrequencyLimiter {
+	return &FrequencyLimiter{config: config, positionRepo: positionRepo}
+}
+
+func (l *FrequencyLimiter) Veto(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	day := TradeDay(account.Timestamp)
+	next := day.Add(24 * time.Hour)
+
+	var symbolTrades, trades int64
+	var err error
+	if l.config.MaxTradesPerSymbolPerDay > 0 {
+		if symbolTrades, err = l.positionRepo.CountOpenedBySymbolBetween(signal.Symbol, day, next); err != nil {
+			log.Printf("Error counting today's %s positions, allowing the entry: %v", signal.Symbol, err)
+			return false, ""
+		}
+	}
+	if l.config.MaxTradesPerDay > 0 {
+		if trades, err = l.positionRepo.CountOpenedBetween(day, next); err != nil {
+			log.Printf("Error counting today's positions, allowing the entry: %v", err)
+			return false, ""
+		}
+	}
+
+	if reason := l.config.Exceeded(signal.Symbol, int(symbolTrades), int(trades)); reason != "" {
+		return true, reason
+	}
+	return false, ""
+}
@@ -0,0 +1,54 @@
+package risk
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrequencyConfigExceeded(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       FrequencyConfig
+		symbolTrades int
+		trades       int
+		want         string
+	}{
+		{"disabled", FrequencyConfig{}, 40, 400, ""},
+		{"under both caps", FrequencyConfig{MaxTradesPerSymbolPerDay: 3, MaxTradesPerDay: 10}, 2, 9, ""},
+		{"symbol cap", FrequencyConfig{MaxTradesPerSymbolPerDay: 3, MaxTradesPerDay: 10}, 3, 5, "daily trade limit for BTCUSDT reached (3/3)"},
+		{"account cap", FrequencyConfig{MaxTradesPerSymbolPerDay: 3, MaxTradesPerDay: 10}, 1, 10, "daily trade limit reached (10/10)"},
+		{"account cap only", FrequencyConfig{MaxTradesPerDay: 4}, 4, 3, ""},
+		{"symbol cap only", FrequencyConfig{MaxTradesPerSymbolPerDay: 1}, 1, 0, "daily trade limit for BTCUSDT reached (1/1)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.config.Exceeded("BTCUSDT", tt.symbolTrades, tt.trades); got != tt.want {
+				t.Errorf("Exceeded() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := (FrequencyConfig{MaxTradesPerDay: -1}).Validate(); err == nil {
+		t.Error("Validate() accepted a negative cap")
+	}
+}
+
+func TestTradeDayResetsAtUTCMidnight(t *testing.T) {
+	midnight := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	berlin := time.FixedZone("CET", 3600)
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"just before midnight", midnight.Add(-time.Millisecond), midnight.AddDate(0, 0, -1)},
+		{"at midnight", midnight, midnight},
+		// 00:30 in Berlin is still the previous UTC day
+		{"another zone", time.Date(2024, 3, 2, 0, 30, 0, 0, berlin), midnight.AddDate(0, 0, -1)},
+	}
+	for _, tt := range tests {
+		if got := TradeDay(tt.at); !got.Equal(tt.want) {
+			t.Errorf("%s: TradeDay(%v) = %v, want %v", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/metrics"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// PerformanceConfig controls when a symbol is suspended for poor results
+type PerformanceConfig struct {
+	Window        int           // Most recent closed trades considered
+	MaxAge        time.Duration // Ignore trades closed longer ago than this, 0 for no limit
+	MinTrades     int           // Trades needed before a symbol can be judged
+	MinExpectancy float64       // Average PnL per trade below which the symbol is suspended, USDT
+	Probation     time.Duration // How long a suspension lasts
+}
+
+// DefaultPerformanceConfig returns the default suspension rule
+func DefaultPerformanceConfig() PerformanceConfig {
+	return PerformanceConfig{
+		Window:        20,
+		MinTrades:     10,
+		MinExpectancy: 0,
+		Probation:     72 * time.Hour,
+	}
+}
+
+// PerformanceStats summarizes a run of closed trades
+type PerformanceStats struct {
+	Trades     int
+	WinRate    float64
+	Expectancy float64
+}
+
+// EvaluatePerformance scores the PnLs, most recent first, and reports whether they warrant a suspension
+// Only the first Window trades are used
+func EvaluatePerformance(pnls []float64, cfg PerformanceConfig) (PerformanceStats, bool) {
+	if len(pnls) > cfg.Window {
+		pnls = pnls[:cfg.Window]
+	}
+
+	stats := PerformanceStats{Trades: len(pnls)}
+	if stats.Trades == 0 {
+		return stats, false
+	}
+
+	wins := 0
+	total := 0.0
+	for _, pnl := range pnls {
+		if pnl > 0 {
+			wins++
+		}
+		total += pnl
+	}
+	stats.WinRate = float64(wins) / float64(stats.Trades)
+	stats.Expectancy = total / float64(stats.Trades)
+
+	return stats, stats.Trades >= cfg.MinTrades && stats.Expectancy < cfg.MinExpectancy
+}
+
+// SymbolPerformanceTracker vetoes entries on symbols whose recent expectancy is too poor
+// Suspensions are stored so they survive restarts; only trades closed after the last
+// suspension ended count as evidence, so a symbol gets a fresh start after probation
+type SymbolPerformanceTracker struct {
+	mu             sync.Mutex
+	positionRepo   *repositories.PositionRepository
+	suspensionRepo *repositories.SymbolSuspensionRepository
+	notifier       *notifications.Notifier
+	config         PerformanceConfig
+}
+
+// NewSymbolPerformanceTracker creates a new instance of SymbolPerformanceTracker
+func NewSymbolPerformanceTracker(
+	positionRepo *repositories.PositionRepository,
+	suspensionRepo *repositories.SymbolSuspensionRepository,
+	notifier *notifications.Notifier,
+	config PerformanceConfig,
+) *SymbolPerformanceTracker {
+	return &SymbolPerformanceTracker{
+		positionRepo:   positionRepo,
+		suspensionRepo: suspensionRepo,
+		notifier:       notifier,
+		config:         config,
+	}
+}
+
+func (t *SymbolPerformanceTracker) Veto(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	suspension, err := t.Check(signal.Symbol, account.Timestamp)
+	if err != nil {
+		return true, fmt.Sprintf("performance check failed: %v", err)
+	}
+	if suspension != nil {
+		return true, fmt.Sprintf("%s suspended until %s: %s",
+			signal.Symbol, suspension.ResumeAt.UTC().Format("2006-01-02 15:04"), suspension.Reason)
+	}
+	return false, ""
+}
+
+// Check returns the suspension in force for symbol at now, creating one if the evidence calls for it
+func (t *SymbolPerformanceTracker) Check(symbol string, now time.Time) (*models.SymbolSuspension, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	latest, err := t.suspensionRepo.FindLatest(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	since := time.Time{}
+	if latest != nil {
+		if now.Before(latest.ResumeAt) {
+			metrics.SymbolSuspended.WithLabelValues(t.suspensionRepo.Account(), symbol).Set(1)
+			return latest, nil
+		}
+		since = latest.ResumeAt
+		if !latest.Resumed {
+			t.announceResume(latest)
+		}
+	}
+	metrics.SymbolSuspended.WithLabelValues(t.suspensionRepo.Account(), symbol).Set(0)
+
+	if t.config.MaxAge > 0 {
+		if cutoff := now.Add(-t.config.MaxAge); cutoff.After(since) {
+			since = cutoff
+		}
+	}
+
+	positions, err := t.positionRepo.FindRecentClosedBySymbol(symbol, since, t.config.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	pnls := make([]float64, len(positions))
+	for i, p := range positions {
+		pnls[i] = p.PnL
+	}
+
+	stats, suspend := EvaluatePerformance(pnls, t.config)
+	if !suspend {
+		return nil, nil
+	}
+
+	suspension := &models.SymbolSuspension{
+		Symbol:      symbol,
+		Reason:      fmt.Sprintf("expectancy %.4f USDT below %.4f over %d trades", stats.Expectancy, t.config.MinExpectancy, stats.Trades),
+		Trades:      stats.Trades,
+		WinRate:     stats.WinRate,
+		Expectancy:  stats.Expectancy,
+		SuspendedAt: now,
+		ResumeAt:    now.Add(t.config.Probation),
+	}
+	if err := t.suspensionRepo.Create(suspension); err != nil {
+		return nil, fmt.Errorf("failed to save suspension: %v", err)
+	}
+
+	metrics.SymbolSuspended.WithLabelValues(t.suspensionRepo.Account(), symbol).Set(1)
+	log.Printf("Suspending %s until %s: %s", symbol, suspension.ResumeAt.UTC().Format(time.RFC3339), suspension.Reason)
+	t.notifier.Notify(notifications.Event{
+		Severity: notifications.SeverityWarning,
+		Title:    "Symbol suspended",
+		Message: fmt.Sprintf("%s: %s, win rate %.0f%%. Entries resume %s UTC",
+			symbol, suspension.Reason, stats.WinRate*100, suspension.ResumeAt.UTC().Format("2006-01-02 15:04")),
+		Symbol:  symbol,
+		Account: t.suspensionRepo.Account(),
+	})
+
+	return suspension, nil
+}
+
+// Active returns the suspensions in force at now and publishes them to the metrics gauge
+func (t *SymbolPerformanceTracker) Active(now time.Time) ([]models.SymbolSuspension, error) {
+	suspensions, err := t.suspensionRepo.FindActive(now)
+	if err != nil {
+		return nil, err
+	}
+	for _, suspension := range suspensions {
+		metrics.SymbolSuspended.WithLabelValues(t.suspensionRepo.Account(), suspension.Symbol).Set(1)
+	}
+	return suspensions, nil
+}
+
+// Reset ends any suspension on symbol immediately; earlier trades no longer count against it
+func (t *SymbolPerformanceTracker) Reset(symbol string, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	latest, err := t.suspensionRepo.FindLatest(symbol)
+	if err != nil {
+		return err
+	}
+	if latest == nil || !now.Before(latest.ResumeAt) {
+		return fmt.Errorf("%s is not suspended", symbol)
+	}
+
+	latest.ResumeAt = now
+	if err := t.suspensionRepo.Update(latest); err != nil {
+		return fmt.Errorf("failed to update suspension: %v", err)
+	}
+	t.announceResume(latest)
+	return nil
+}
+
+// announceResume records and reports that a suspended symbol is trading again
+func (t *SymbolPerformanceTracker) announceResume(suspension *models.SymbolSuspension) {
+	suspension.Resumed = true
+	if err := t.suspensionRepo.Update(suspension); err != nil {
+		log.Printf("Error marking %s resumed: %v", suspension.Symbol, err)
+	}
+
+	log.Printf("Resuming entries for %s", suspension.Symbol)
+	t.notifier.Notify(notifications.Event{
+		Severity: notifications.SeverityWarning,
+		Title:    "Symbol resumed",
+		Message:  fmt.Sprintf("%s entries re-enabled after suspension (%s)", suspension.Symbol, suspension.Reason),
+		Symbol:   suspension.Symbol,
+		Account:  t.suspensionRepo.Account(),
+	})
+}
@@ -0,0 +1,35 @@
+package risk
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluatePerformance(t *testing.T) {
+	cfg := PerformanceConfig{Window: 5, MinTrades: 3, MinExpectancy: 0}
+	tests := []struct {
+		name       string
+		pnls       []float64 // Newest first
+		trades     int
+		winRate    float64
+		expectancy float64
+		suspend    bool
+	}{
+		{"no trades", nil, 0, 0, 0, false},
+		{"too few losers to judge", []float64{-1, -1}, 2, 0, -1, false},
+		{"losing enough to suspend", []float64{-1, 0.5, -2}, 3, 1.0 / 3, -2.5 / 3, true},
+		{"winning", []float64{2, -1, 1}, 3, 2.0 / 3, 2.0 / 3, false},
+		{"breakeven is not below the threshold", []float64{1, -1, 1, -1}, 4, 0.5, 0, false},
+		{"only the window counts", []float64{1, 1, 1, -1, -1, -10, -10}, 5, 0.6, 0.2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats, suspend := EvaluatePerformance(tt.pnls, cfg)
+			if stats.Trades != tt.trades || math.Abs(stats.WinRate-tt.winRate) > 1e-9 ||
+				math.Abs(stats.Expectancy-tt.expectancy) > 1e-9 || suspend != tt.suspend {
+				t.Errorf("EvaluatePerformance() = %+v, %v, want %d trades, win rate %v, expectancy %v, %v",
+					stats, suspend, tt.trades, tt.winRate, tt.expectancy, tt.suspend)
+			}
+		})
+	}
+}
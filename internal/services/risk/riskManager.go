@@ -0,0 +1,74 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultVetoTimeout bounds how long a single veto hook may run
+const DefaultVetoTimeout = 2 * time.Second
+
+type vetoResult struct {
+	block  bool
+	reason string
+}
+
+type RiskManager struct {
+	mu          sync.RWMutex
+	vetoes      []TradeVeto
+	vetoTimeout time.Duration
+}
+
+// NewRiskManager creates a new instance of RiskManager
+func NewRiskManager(vetoTimeout time.Duration) *RiskManager {
+	return &RiskManager{
+		vetoTimeout: vetoTimeout,
+	}
+}
+
+// RegisterVeto adds a hook that runs after every previously registered one
+func (m *RiskManager) RegisterVeto(veto TradeVeto) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.vetoes = append(m.vetoes, veto)
+}
+
+// CheckEntry runs the veto hooks in order and stops at the first block
+// A hook that does not answer within the timeout blocks the entry
+func (m *RiskManager) CheckEntry(ctx context.Context, signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	m.mu.RLock()
+	vetoes := make([]TradeVeto, len(m.vetoes))
+	copy(vetoes, m.vetoes)
+	m.mu.RUnlock()
+
+	for i, veto := range vetoes {
+		block, reason := m.runVeto(ctx, veto, signal, account)
+		if block {
+			return true, fmt.Sprintf("veto %d: %s", i, reason)
+		}
+	}
+
+	return false, ""
+}
+
+// runVeto executes a single hook bounded by the veto timeout
+func (m *RiskManager) runVeto(ctx context.Context, veto TradeVeto, signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	ctx, cancel := context.WithTimeout(ctx, m.vetoTimeout)
+	defer cancel()
+
+	done := make(chan vetoResult, 1)
+	go func() {
+		block, reason := veto.Veto(signal, account)
+		done <- vetoResult{block: block, reason: reason}
+	}()
+
+	select {
+	case result := <-done:
+		return result.block, result.reason
+	case <-ctx.Done():
+		return true, "veto hook timed out"
+	}
+}
@@ -0,0 +1,78 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func allow(calls *atomic.Int32) TradeVeto {
+	return TradeVetoFunc(func(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+		calls.Add(1)
+		return false, ""
+	})
+}
+
+func block(reason string) TradeVeto {
+	return TradeVetoFunc(func(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+		return true, reason
+	})
+}
+
+func TestCheckEntryAllowsWhenEveryHookAllows(t *testing.T) {
+	var calls atomic.Int32
+	manager := NewRiskManager(time.Second)
+	manager.RegisterVeto(allow(&calls))
+	manager.RegisterVeto(allow(&calls))
+
+	if blocked, reason := manager.CheckEntry(context.Background(), &analysis.AnalysisResult{Symbol: "BTCUSDT"}, Snapshot{}); blocked {
+		t.Errorf("CheckEntry() blocked: %s", reason)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("%d hooks ran, want 2", calls.Load())
+	}
+}
+
+func TestCheckEntryStopsAtFirstBlock(t *testing.T) {
+	var before, after atomic.Int32
+	manager := NewRiskManager(time.Second)
+	manager.RegisterVeto(allow(&before))
+	manager.RegisterVeto(block("too much exposure"))
+	manager.RegisterVeto(allow(&after))
+
+	blocked, reason := manager.CheckEntry(context.Background(), &analysis.AnalysisResult{Symbol: "BTCUSDT"}, Snapshot{})
+	if !blocked || reason != "veto 1: too much exposure" {
+		t.Errorf("CheckEntry() = %v, %q, want blocked by veto 1", blocked, reason)
+	}
+	if before.Load() != 1 || after.Load() != 0 {
+		t.Errorf("hooks before and after the block ran %d and %d times, want 1 and 0", before.Load(), after.Load())
+	}
+}
+
+func TestCheckEntryBlocksOnTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	manager := NewRiskManager(20 * time.Millisecond)
+	manager.RegisterVeto(TradeVetoFunc(func(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+		<-release
+		return false, ""
+	}))
+
+	began := time.Now()
+	blocked, reason := manager.CheckEntry(context.Background(), &analysis.AnalysisResult{Symbol: "BTCUSDT"}, Snapshot{})
+	if !blocked || !strings.Contains(reason, "timed out") {
+		t.Errorf("CheckEntry() = %v, %q, want blocked on the timeout", blocked, reason)
+	}
+	if waited := time.Since(began); waited > time.Second {
+		t.Errorf("CheckEntry() waited %s on a hung hook", waited)
+	}
+}
+
+func TestCheckEntryWithoutHooksAllows(t *testing.T) {
+	if blocked, _ := NewRiskManager(time.Second).CheckEntry(context.Background(), &analysis.AnalysisResult{}, Snapshot{}); blocked {
+		t.Error("CheckEntry() blocked without any hooks")
+	}
+}
@@ -0,0 +1,50 @@
+//go:build integration
+
+package risk
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/testdb"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRiskScalerStreakSpansSymbolsAndRestarts(t *testing.T) {
+	db := testdb.Open(t)
+	positions := repositories.NewPositionRepository(db)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Results alternate symbols, so only a streak across symbols sees two in a row
+	steps := []struct {
+		symbol string
+		pnl    float64
+		want   float64
+	}{
+		{"BTCUSDT", -10, 1},
+		{"ETHUSDT", -10, 0.5},
+		{"BTCUSDT", 20, 1},
+		{"ETHUSDT", 20, 1.5},
+	}
+	for i, step := range steps {
+		storeClosed(t, positions, step.symbol, start.Add(time.Duration(i)*time.Hour), step.pnl)
+
+		// A scaler built per step is a restart, it only knows what the database holds
+		multiplier, _, err := NewRiskScaler(positions, streakConfig()).Multiplier()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(multiplier-step.want) > 1e-9 {
+			t.Errorf("multiplier after trade %d (%s %+.0f) = %v, want %v", i+1, step.symbol, step.pnl, multiplier, step.want)
+		}
+	}
+
+	// A leg closing with the entry, like a reversal, counts before anything saved
+	multiplier, streak, err := NewRiskScaler(positions, streakConfig()).Multiplier(-5, -5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if streak != -2 || math.Abs(multiplier-0.5) > 1e-9 {
+		t.Errorf("Multiplier(-5, -5) = %v, streak %d, want 0.5 for a streak of -2", multiplier, streak)
+	}
+}
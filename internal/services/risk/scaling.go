@@ -0,0 +1,152 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/repositories"
+	"fmt"
+)
+
+// ScalingStep sets the risk per trade once the account is on a streak
+// A negative Streak means at least that many consecutive losses, a positive one that many wins
+type ScalingStep struct {
+	Streak int
+	Risk   float64
+}
+
+// ScalingConfig adapts the risk per trade to the account's latest run of results
+// The step with the longest streak that applies wins; with none, BaseRisk is used
+type ScalingConfig struct {
+	BaseRisk float64 // Risk per trade the fixed position size stands for
+	Steps    []ScalingStep
+	MinRisk  float64 // Hard floor whatever the steps say
+	MaxRisk  float64 // Hard ceiling whatever the steps say
+}
+
+// MaxSaneRisk is the highest risk per trade a configuration may ask for; above it the risk was almost
+// certainly given as a percentage instead of a fraction
+const MaxSaneRisk = 0.1
+
+// DefaultScalingConfig halves risk to 1% after two losses in a row and restores 2% after a win
+func DefaultScalingConfig() ScalingConfig {
+	return ScalingConfig{
+		BaseRisk: 0.02,
+		Steps:    []ScalingStep{{Streak: -2, Risk: 0.01}},
+		MinRisk:  0.005,
+		MaxRisk:  0.03,
+	}
+}
+
+// WithWinStreak adds a step raising risk to risk after wins consecutive wins
+func (c ScalingConfig) WithWinStreak(wins int, risk float64) ScalingConfig {
+	c.Steps = append(append([]ScalingStep(nil), c.Steps...), ScalingStep{Streak: wins, Risk: risk})
+	return c
+}
+
+// Validate rejects risks above MaxSaneRisk and a floor above the ceiling
+func (c ScalingConfig) Validate() error {
+	risks := []float64{c.BaseRisk, c.MinRisk, c.MaxRisk}
+	for _, step := range c.Steps {
+		risks = append(risks, step.Risk)
+	}
+	for _, risk := range risks {
+		if risk < 0 {
+			return fmt.Errorf("risk per trade cannot be negative")
+		}
+		if risk > MaxSaneRisk {
+			return fmt.Errorf("risk per trade %.4f is above the %.2f sanity ceiling, risk is a fraction of the balance (0.02 for 2%%)",
+				risk, MaxSaneRisk)
+		}
+	}
+	if c.MaxRisk > 0 && c.MinRisk > c.MaxRisk {
+		return fmt.Errorf("risk floor %.4f is above the ceiling %.4f", c.MinRisk, c.MaxRisk)
+	}
+	return nil
+}
+
+// Streak counts the run at the start of pnls, most recent first: +n for n wins, -n for n losses
+// A trade that did not make money counts as a loss
+func Streak(pnls []float64) int {
+	if len(pnls) == 0 {
+		return 0
+	}
+	win := pnls[0] > 0
+	n := 0
+	for _, pnl := range pnls {
+		if (pnl > 0) != win {
+			break
+		}
+		n++
+	}
+	if !win {
+		return -n
+	}
+	return n
+}
+
+// Risk returns the risk per trade for streak, within the floor and ceiling
+func (c ScalingConfig) Risk(streak int) float64 {
+	risk := c.BaseRisk
+	longest := 0
+	for _, step := range c.Steps {
+		applies := (step.Streak < 0 && streak <= step.Streak) || (step.Streak > 0 && streak >= step.Streak)
+		if applies && abs(step.Streak) > longest {
+			risk = step.Risk
+			longest = abs(step.Streak)
+		}
+	}
+	return min(max(risk, c.MinRisk), c.MaxRisk)
+}
+
+// Multiplier returns the factor the base position size is scaled by for streak
+func (c ScalingConfig) Multiplier(streak int) float64 {
+	if c.BaseRisk <= 0 {
+		return 1
+	}
+	return c.Risk(streak) / c.BaseRisk
+}
+
+// Lookback is how many closed trades decide the streak, enough to reach the longest step
+func (c ScalingConfig) Lookback() int {
+	n := 1
+	for _, step := range c.Steps {
+		n = max(n, abs(step.Streak))
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// RiskScaler sizes entries from the account's streak across all symbols
+// The streak is read from closed positions on every call, so restarts do not reset it
+type RiskScaler struct {
+	positionRepo *repositories.PositionRepository
+	config       ScalingConfig
+}
+
+// NewRiskScaler creates a new instance of RiskScaler
+func NewRiskScaler(positionRepo *repositories.PositionRepository, config ScalingConfig) *RiskScaler {
+	return &RiskScaler{
+		positionRepo: positionRepo,
+		config:       config,
+	}
+}
+
+// Multiplier returns the position size factor for the next entry and the streak it is based on
+// closing holds the PnL of positions closed together with the entry but not saved yet, like a reversed leg
+func (s *RiskScaler) Multiplier(closing ...float64) (float64, int, error) {
+	positions, err := s.positionRepo.FindRecentClosed(s.config.Lookback())
+	if err != nil {
+		return 1, 0, fmt.Errorf("failed to get closed positions: %v", err)
+	}
+
+	pnls := append([]float64(nil), closing...)
+	for _, p := range positions {
+		pnls = append(pnls, p.PnL)
+	}
+	streak := Streak(pnls)
+	return s.config.Multiplier(streak), streak, nil
+}
@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"math"
+	"testing"
+)
+
+// streakConfig is the default scaling with 3% risk from two wins in a row
+func streakConfig() ScalingConfig {
+	return DefaultScalingConfig().WithWinStreak(2, 0.03)
+}
+
+func TestStreak(t *testing.T) {
+	tests := []struct {
+		name string
+		pnls []float64 // Newest first
+		want int
+	}{
+		{"no trades", nil, 0},
+		{"one win", []float64{5}, 1},
+		{"wins up to a loss", []float64{5, 1, -2, 3}, 2},
+		{"losses up to a win", []float64{-1, -3, -2, 4}, -3},
+		{"breakeven is a loss", []float64{0, -1}, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Streak(tt.pnls); got != tt.want {
+				t.Errorf("Streak(%v) = %d, want %d", tt.pnls, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScalingFollowsLossLossWinWin(t *testing.T) {
+	config := streakConfig()
+	results := []float64{-10, -10, 20, 20, 20, -10}
+	// The multiplier each trade after the result above is sized with
+	want := []float64{1, 0.5, 1, 1.5, 1.5, 1}
+
+	var pnls []float64 // Newest first, as the repository returns them
+	for i, pnl := range results {
+		pnls = append([]float64{pnl}, pnls...)
+		if len(pnls) > config.Lookback() {
+			pnls = pnls[:config.Lookback()]
+		}
+		if got := config.Multiplier(Streak(pnls)); math.Abs(got-want[i]) > 1e-9 {
+			t.Errorf("multiplier after trade %d (%+.0f) = %v, want %v", i+1, pnl, got, want[i])
+		}
+	}
+}
+
+func TestScalingRiskStaysWithinBounds(t *testing.T) {
+	config := ScalingConfig{
+		BaseRisk: 0.02,
+		Steps:    []ScalingStep{{Streak: -2, Risk: 0.001}, {Streak: -4, Risk: 0.0005}, {Streak: 3, Risk: 0.08}},
+		MinRisk:  0.005,
+		MaxRisk:  0.04,
+	}
+	tests := []struct {
+		streak int
+		want   float64
+	}{
+		{0, 0.02},
+		{-1, 0.02},
+		{-2, 0.005}, // Floored
+		{-5, 0.005}, // The longest step applies, floored
+		{2, 0.02},
+		{3, 0.04}, // Capped
+	}
+	for _, tt := range tests {
+		if got := config.Risk(tt.streak); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("Risk(%d) = %v, want %v", tt.streak, got, tt.want)
+		}
+	}
+}
+
+func TestScalingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  ScalingConfig
+		wantErr bool
+	}{
+		{"default", DefaultScalingConfig(), false},
+		{"with a win streak", streakConfig(), false},
+		{"percentage instead of fraction", DefaultScalingConfig().WithWinStreak(3, 3), true},
+		{"base risk of 2 instead of 0.02", ScalingConfig{BaseRisk: 2}, true},
+		{"negative", ScalingConfig{BaseRisk: -0.01}, true},
+		{"floor above ceiling", ScalingConfig{BaseRisk: 0.02, MinRisk: 0.03, MaxRisk: 0.02}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+//go:build integration
+
+package risk
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/testdb"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// storeClosed stores a closed long on symbol for each pnl, an hour apart from start
+func storeClosed(t *testing.T, positions *repositories.PositionRepository, symbol string, start time.Time, pnls ...float64) {
+	t.Helper()
+	for i, pnl := range pnls {
+		at := start.Add(time.Duration(i) * time.Hour)
+		position := &models.Position{
+			Symbol:          symbol,
+			Side:            models.PositionSideLong,
+			Size:            1,
+			Leverage:        50,
+			EntryPrice:      100,
+			StopLossPrice:   99,
+			TakeProfitPrice: 101,
+			OpenTime:        at,
+			CloseTime:       at.Add(30 * time.Minute),
+			Status:          models.PositionStatusClosed,
+			PnL:             pnl,
+		}
+		if err := positions.Create(context.Background(), position); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func newTracker(db *gorm.DB) *SymbolPerformanceTracker {
+	return NewSymbolPerformanceTracker(repositories.NewPositionRepository(db), repositories.NewSymbolSuspensionRepository(db), nil,
+		PerformanceConfig{Window: 20, MinTrades: 5, MinExpectancy: 0, Probation: 72 * time.Hour})
+}
+
+func entry(symbol string) *analysis.AnalysisResult {
+	return &analysis.AnalysisResult{Symbol: symbol, IsValid: true, Direction: models.PositionSideLong}
+}
+
+func TestLosingHistorySuspendsSymbol(t *testing.T) {
+	db := testdb.Open(t)
+	positions := repositories.NewPositionRepository(db)
+	start := testdb.FixtureStart
+	storeClosed(t, positions, "ONDOUSDT", start, -1, 0.5, -1, -1, 0.2, -1)
+	storeClosed(t, positions, "BTCUSDT", start, -1, 2, -1, 2, 1)
+	now := start.Add(12 * time.Hour)
+
+	tracker := newTracker(db)
+	blocked, reason := tracker.Veto(entry("ONDOUSDT"), Snapshot{Timestamp: now})
+	if !blocked || !strings.Contains(reason, "ONDOUSDT suspended until") {
+		t.Fatalf("Veto(ONDOUSDT) = %v, %q, want a suspension", blocked, reason)
+	}
+	if blocked, reason := tracker.Veto(entry("BTCUSDT"), Snapshot{Timestamp: now}); blocked {
+		t.Errorf("Veto(BTCUSDT) blocked a profitable symbol: %s", reason)
+	}
+
+	// The suspension is stored, so a restarted tracker keeps blocking without new evidence
+	restarted := newTracker(db)
+	active, err := restarted.Active(now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].Symbol != "ONDOUSDT" || active[0].Trades != 6 || active[0].Expectancy >= 0 {
+		t.Fatalf("Active() = %+v, want the ONDOUSDT suspension over 6 trades", active)
+	}
+	if blocked, _ := restarted.Veto(entry("ONDOUSDT"), Snapshot{Timestamp: now.Add(71 * time.Hour)}); !blocked {
+		t.Error("restarted tracker allowed ONDOUSDT during probation")
+	}
+
+	// After probation the old losers no longer count
+	resumed, err := restarted.Check("ONDOUSDT", now.Add(72*time.Hour))
+	if err != nil || resumed != nil {
+		t.Fatalf("Check() after probation = %+v, %v, want entries allowed", resumed, err)
+	}
+	latest, err := repositories.NewSymbolSuspensionRepository(db).FindLatest("ONDOUSDT")
+	if err != nil || latest == nil || !latest.Resumed {
+		t.Errorf("latest suspension = %+v, %v, want it marked resumed", latest, err)
+	}
+}
+
+func TestResetEndsSuspension(t *testing.T) {
+	db := testdb.Open(t)
+	start := testdb.FixtureStart
+	storeClosed(t, repositories.NewPositionRepository(db), "ONDOUSDT", start, -1, -1, -1, -1, -1)
+	now := start.Add(12 * time.Hour)
+
+	tracker := newTracker(db)
+	if suspension, err := tracker.Check("ONDOUSDT", now); err != nil || suspension == nil {
+		t.Fatalf("Check() = %+v, %v, want a suspension", suspension, err)
+	}
+	if err := tracker.Reset("ONDOUSDT", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if blocked, reason := tracker.Veto(entry("ONDOUSDT"), Snapshot{Timestamp: now.Add(2 * time.Hour)}); blocked {
+		t.Errorf("Veto() after a reset blocked: %s", reason)
+	}
+	if err := tracker.Reset("ONDOUSDT", now.Add(3*time.Hour)); err == nil {
+		t.Error("Reset() of a symbol that is not suspended succeeded")
+	}
+}
@@ -0,0 +1,56 @@
+package risk
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"time"
+)
+
+// Snapshot is the account state handed to veto hooks
+type Snapshot struct {
+	Balance       float64
+	OpenPositions []models.Position
+	Timestamp     time.Time
+}
+
+// TradeVeto gets the final say on an entry; returning true blocks the trade
+type TradeVeto interface {
+	Veto(signal *analysis.AnalysisResult, account Snapshot) (block bool, reason string)
+}
+
+// TradeVetoFunc adapts a plain function to the TradeVeto interface
+type TradeVetoFunc func(signal *analysis.AnalysisResult, account Snapshot) (bool, string)
+
+func (f TradeVetoFunc) Veto(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	return f(signal, account)
+}
+
+// BTCMoveVeto blocks entries while BTC's latest 1h candle has moved more than MaxChange
+type BTCMoveVeto struct {
+	priceRepo *repositories.PriceRepository
+	MaxChange float64
+}
+
+// NewBTCMoveVeto creates a new instance of BTCMoveVeto
+func NewBTCMoveVeto(priceRepo *repositories.PriceRepository, maxChange float64) *BTCMoveVeto {
+	return &BTCMoveVeto{
+		priceRepo: priceRepo,
+		MaxChange: maxChange,
+	}
+}
+
+func (v *BTCMoveVeto) Veto(signal *analysis.AnalysisResult, account Snapshot) (bool, string) {
+	latest, err := v.priceRepo.GetLatestPriceByTimeFrame("BTCUSDT", models.PriceTimeFrame1h)
+	if err != nil || latest == nil || latest.Open == 0 {
+		return false, ""
+	}
+
+	change := (latest.Close - latest.Open) / latest.Open
+	if change > v.MaxChange || change < -v.MaxChange {
+		return true, fmt.Sprintf("BTC 1h change %.2f%% exceeds %.2f%%", change*100, v.MaxChange*100)
+	}
+
+	return false, ""
+}
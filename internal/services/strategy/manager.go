@@ -0,0 +1,323 @@
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Params is one strategy parameter set as written in the config file
+type Params struct {
+	Strategy string `json:"strategy"`
+	analysis.Config
+}
+
+// FileConfig is the layout of the strategy config file.
+// Symbol entries only need the fields that differ from the default:
+//
+//	{
+//	  "default": {"strategy": "momentum", "min_confidence": 0.7},
+//	  "symbols": {"BTCUSDT": {"stop_loss": 0.004}}
+//	}
+type FileConfig struct {
+	Default json.RawMessage            `json:"default"`
+	Symbols map[string]json.RawMessage `json:"symbols"`
+}
+
+// DefaultParams returns the built-in parameter set
+func DefaultParams() Params {
+	return Params{
+		Strategy: DefaultStrategy,
+		Config:   analysis.DefaultConfig(),
+	}
+}
+
+type StrategyManager struct {
+	fallback  Strategy
+	bySymbol  map[string]Strategy
+	params    map[string]Params
+	defParams Params
+}
+
+// NewStrategyManager creates a manager that uses the same parameters for every symbol
+func NewStrategyManager(params Params) (*StrategyManager, error) {
+	return NewStrategyManagerWithOverrides(params, nil, nil)
+}
+
+// NewStrategyManagerWithOverrides creates a manager with per-symbol parameter sets
+// Overrides for symbols outside the configured universe are rejected; a nil symbols,
+// for a universe only known at run time, accepts overrides for any symbol
+func NewStrategyManagerWithOverrides(defaults Params, overrides map[string]Params, symbols []string) (*StrategyManager, error) {
+	if err := defaults.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid default strategy params: %v", err)
+	}
+
+	fallback, err := New(defaults.Strategy, defaults.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		known[symbol] = true
+	}
+
+	m := &StrategyManager{
+		fallback:  fallback,
+		bySymbol:  make(map[string]Strategy),
+		params:    make(map[string]Params),
+		defParams: defaults,
+	}
+
+	for symbol, params := range overrides {
+		if symbols != nil && !known[symbol] {
+			return nil, fmt.Errorf("strategy override for unknown symbol %s", symbol)
+		}
+		if err := params.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid strategy params for %s: %v", symbol, err)
+		}
+
+		s, err := New(params.Strategy, params.Config)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", symbol, err)
+		}
+		m.bySymbol[symbol] = s
+		m.params[symbol] = params
+	}
+
+	return m, nil
+}
+
+// LoadStrategyManager builds a manager from the JSON file at path
+// An empty path uses the built-in defaults for every symbol
+func LoadStrategyManager(path string, symbols []string) (*StrategyManager, error) {
+	if path == "" {
+		return NewStrategyManager(DefaultParams())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read strategy config: %v", err)
+	}
+	return ParseStrategyManager(data, symbols)
+}
+
+// ParseStrategyManager builds a manager from JSON in the FileConfig layout
+func ParseStrategyManager(data []byte, symbols []string) (*StrategyManager, error) {
+	var file FileConfig
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse strategy config: %v", err)
+	}
+
+	defaults := DefaultParams()
+	if len(file.Default) > 0 {
+		if err := json.Unmarshal(file.Default, &defaults); err != nil {
+			return nil, fmt.Errorf("invalid default strategy params: %v", err)
+		}
+	}
+
+	overrides := make(map[string]Params, len(file.Symbols))
+	for symbol, raw := range file.Symbols {
+		// Start from the defaults so an override only lists what changes
+		params := defaults
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, fmt.Errorf("invalid strategy params for %s: %v", symbol, err)
+		}
+		overrides[symbol] = params
+	}
+
+	return NewStrategyManagerWithOverrides(defaults, overrides, symbols)
+}
+
+// Validate checks the parameter set is usable
+func (p Params) Validate() error {
+	if _, ok := registry[p.Strategy]; !ok {
+		return fmt.Errorf("unknown strategy %q", p.Strategy)
+	}
+	return p.Config.Validate()
+}
+
+// Analyze runs the strategy configured for the symbol on the close of its cadence candle, passing the
+// cadence on to strategies that weight by it and recording it on the result along with,
+// on valid results, the size multiplier its confidence maps to
+func (m *StrategyManager) Analyze(symbol string, prices []models.Price) *analysis.AnalysisResult {
+	cadence := m.Cadence(symbol)
+	var result *analysis.AnalysisResult
+	if s, ok := m.ForSymbol(symbol).(CadenceStrategy); ok {
+		result = s.AnalyzeOnCadence(prices, cadence)
+	} else {
+		result = m.ForSymbol(symbol).Analyze(prices)
+	}
+	result.Cadence = cadence
+	if result.IsValid {
+		result.SizeMultiplier = m.ParamsFor(symbol).SizeMultiplier(result.Confidence)
+	}
+	return result
+}
+
+// Cadence returns the timeframe whose candle closes trigger the symbol's analysis
+func (m *StrategyManager) Cadence(symbol string) string {
+	return m.ParamsFor(symbol).Cadence
+}
+
+// DirectionBoth lets AnalyzeDirection pass entries on either side
+const DirectionBoth = "both"
+
+// AnalyzeDirection runs the strategy configured for the symbol but only passes entries on side,
+// models.PositionSideLong or models.PositionSideShort; DirectionBoth passes every entry
+func (m *StrategyManager) AnalyzeDirection(symbol, side string, prices []models.Price) *analysis.AnalysisResult {
+	result := m.Analyze(symbol, prices)
+	if side != DirectionBoth && result.IsValid && result.Direction != side {
+		result.IsValid = false
+		result.Reason = result.Direction + " entries disabled"
+	}
+	return result
+}
+
+// ApplyDailyBias records the symbol's 1d bias at at on result, rejecting it when the symbol's
+// parameters set DailyBiasFilter and the bias opposes the entry; daily may include the forming day
+func (m *StrategyManager) ApplyDailyBias(symbol string, result *analysis.AnalysisResult, daily []models.Price, at time.Time) {
+	if !result.IsValid {
+		return
+	}
+	config := m.ParamsFor(symbol).Config
+	config.ApplyDailyBias(result, analysis.NewDailyBiasAnalyzer(config.DailyBiasRSI).Analyze(daily, at))
+}
+
+// FiltersOrderFlow reports whether any parameter set confirms entries against the order flow
+func (m *StrategyManager) FiltersOrderFlow() bool {
+	if m.defParams.OrderFlowFilter {
+		return true
+	}
+	for _, params := range m.params {
+		if params.OrderFlowFilter {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagePosition asks the strategy configured for the position's symbol where its stop and target
+// should be after the latest closed candle in prices. Strategies that do not manage positions, and
+// those leaving the levels where they are, return nil
+func (m *StrategyManager) ManagePosition(position *models.Position, prices []models.Price) *analysis.StopAdjustment {
+	type positionManager interface {
+		ManagePosition(position *models.Position, prices []models.Price) *analysis.StopAdjustment
+	}
+
+	if s, ok := m.ForSymbol(position.Symbol).(positionManager); ok {
+		return s.ManagePosition(position, prices)
+	}
+	return nil
+}
+
+// Inspect returns the indicator stage of the analysis the symbol's strategy runs on prices
+// Strategies that do not expose it, and windows too short for it, return nil
+func (m *StrategyManager) Inspect(symbol string, prices []models.Price) *analysis.Inspection {
+	type inspector interface {
+		Inspect(prices []models.Price) *analysis.Inspection
+	}
+
+	if s, ok := m.ForSymbol(symbol).(inspector); ok {
+		return s.Inspect(prices)
+	}
+	return nil
+}
+
+// ForSymbol returns the strategy configured for the symbol
+func (m *StrategyManager) ForSymbol(symbol string) Strategy {
+	if s, ok := m.bySymbol[symbol]; ok {
+		return s
+	}
+	return m.fallback
+}
+
+// ParamsFor returns the parameter set in effect for the symbol
+func (m *StrategyManager) ParamsFor(symbol string) Params {
+	if p, ok := m.params[symbol]; ok {
+		return p
+	}
+	return m.defParams
+}
+
+// Settings is every parameter set a manager runs, as recorded with results
+type Settings struct {
+	Default Params            `json:"default"`
+	Symbols map[string]Params `json:"symbols,omitempty"`
+}
+
+// Settings returns the default parameter set and the per-symbol overrides in effect
+func (m *StrategyManager) Settings() Settings {
+	settings := Settings{Default: m.defParams}
+	if len(m.params) > 0 {
+		settings.Symbols = make(map[string]Params, len(m.params))
+		for symbol, params := range m.params {
+			settings.Symbols[symbol] = params
+		}
+	}
+	return settings
+}
+
+// EnableIncremental turns on cached indicator state for every strategy that supports it
+func (m *StrategyManager) EnableIncremental() {
+	type incremental interface{ EnableIncremental() }
+
+	if s, ok := m.fallback.(incremental); ok {
+		s.EnableIncremental()
+	}
+	for _, strategy := range m.bySymbol {
+		if s, ok := strategy.(incremental); ok {
+			s.EnableIncremental()
+		}
+	}
+}
+
+// WarmUp returns, per timeframe, the most candles any configured strategy needs before it can be trusted
+// Strategies that do not report a warm-up are assumed to need the analysis minimum on 5m
+func (m *StrategyManager) WarmUp() map[string]int {
+	type warmUpper interface{ WarmUp() map[string]int }
+
+	result := make(map[string]int)
+	merge := func(s Strategy) {
+		needs := map[string]int{models.PriceTimeFrame5m: analysis.MinIndicatorCandles}
+		if w, ok := s.(warmUpper); ok {
+			needs = w.WarmUp()
+		}
+		for tf, candles := range needs {
+			result[tf] = max(result[tf], candles)
+		}
+	}
+
+	merge(m.fallback)
+	for _, s := range m.bySymbol {
+		merge(s)
+	}
+	return result
+}
+
+// WindowCandles returns how many base timeframe candles the strategies need to see
+func (m *StrategyManager) WindowCandles(base string) int {
+	return WindowCandles(m.WarmUp(), base)
+}
+
+// WindowCandles converts per-timeframe warm-up counts into candles of the base timeframe
+// One extra candle per higher timeframe covers a partly formed bucket at the window start
+func WindowCandles(warmUp map[string]int, base string) int {
+	baseInterval := models.TimeFrameDurations[base]
+
+	window := 1
+	for tf, candles := range warmUp {
+		interval, known := models.TimeFrameDurations[tf]
+		if !known {
+			continue
+		}
+		if tf != base {
+			candles++
+		}
+		window = max(window, int(time.Duration(candles)*interval/baseInterval))
+	}
+	return window
+}
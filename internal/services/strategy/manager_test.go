@@ -0,0 +1,159 @@
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// zigzagCandles returns n 5m candles of symbol drifting up in alternating steps
+func zigzagCandles(symbol string, n int) []models.Price {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	prices := make([]models.Price, n)
+	close := 100.0
+	for i := range prices {
+		open := close
+		if i%2 == 0 {
+			close += 0.2
+		} else {
+			close -= 0.17
+		}
+		prices[i] = models.Price{
+			Symbol:    symbol,
+			TimeFrame: models.PriceTimeFrame5m,
+			OpenTime:  start.Add(time.Duration(i) * 5 * time.Minute),
+			CloseTime: start.Add(time.Duration(i+1)*5*time.Minute - time.Millisecond),
+			Open:      open,
+			High:      max(open, close) + 0.05,
+			Low:       min(open, close) - 0.05,
+			Close:     close,
+			Volume:    10,
+		}
+	}
+	prices[n-1].Volume = 20
+	return prices
+}
+
+func TestLoadStrategyManagerAppliesSymbolOverrides(t *testing.T) {
+	m, err := LoadStrategyManager("testdata/overrides.json", []string{"BTCUSDT", "ETHUSDT"})
+	if err != nil {
+		t.Fatalf("LoadStrategyManager() error = %v", err)
+	}
+
+	btc, eth := m.ParamsFor("BTCUSDT"), m.ParamsFor("ETHUSDT")
+	if btc.MinConfidence != 0.99 || btc.StopLoss != 0.004 {
+		t.Errorf("BTCUSDT params = %v/%v, want the override's 0.99/0.004", btc.MinConfidence, btc.StopLoss)
+	}
+	defaults := DefaultParams()
+	if eth.MinConfidence != defaults.MinConfidence || eth.StopLoss != defaults.StopLoss {
+		t.Errorf("ETHUSDT params = %v/%v, want the defaults", eth.MinConfidence, eth.StopLoss)
+	}
+	// Fields the override leaves out keep the default
+	if btc.TargetProfit != defaults.TargetProfit {
+		t.Errorf("BTCUSDT TargetProfit = %v, want the default %v", btc.TargetProfit, defaults.TargetProfit)
+	}
+
+	// The same candles clear ETHUSDT's threshold but not BTCUSDT's
+	ethResult := m.Analyze("ETHUSDT", zigzagCandles("ETHUSDT", 300))
+	if !ethResult.IsValid {
+		t.Fatalf("ETHUSDT result invalid: %s", ethResult.Reason)
+	}
+	if ethResult.Confidence >= btc.MinConfidence {
+		t.Fatalf("confidence %v already clears the override's threshold", ethResult.Confidence)
+	}
+	if btcResult := m.Analyze("BTCUSDT", zigzagCandles("BTCUSDT", 300)); btcResult.IsValid {
+		t.Errorf("BTCUSDT result valid at confidence %v below its %v threshold", btcResult.Confidence, btc.MinConfidence)
+	}
+}
+
+func TestParseStrategyManagerRejectsBadConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"unknown symbol", `{"symbols": {"DOGEUSDT": {"stop_loss": 0.01}}}`, "unknown symbol DOGEUSDT"},
+		{"unknown strategy", `{"default": {"strategy": "martingale"}}`, "unknown strategy"},
+		{"malformed value", `{"symbols": {"BTCUSDT": {"stop_loss": "tight"}}}`, "invalid strategy params for BTCUSDT"},
+		{"out of range value", `{"symbols": {"BTCUSDT": {"min_confidence": 2}}}`, "invalid strategy params for BTCUSDT"},
+		{"not json", `strategy: momentum`, "failed to parse strategy config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseStrategyManager([]byte(tt.config), []string{"BTCUSDT", "ETHUSDT"})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseStrategyManager() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConcurrentAnalyzeMatchesSequential(t *testing.T) {
+	m, err := NewStrategyManager(DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	symbols := []string{"BTCUSDT", "ETHUSDT", "SOLUSDT", "XRPUSDT"}
+	windows := make(map[string][]models.Price)
+	want := make(map[string]float64)
+	for _, symbol := range symbols {
+		windows[symbol] = zigzagCandles(symbol, 250)
+		want[symbol] = m.Analyze(symbol, windows[symbol]).Confidence
+	}
+
+	// One shared manager analyzing every symbol at once, as the handlers do; run with -race
+	var wg sync.WaitGroup
+	errs := make(chan string, len(symbols)*20)
+	for range 20 {
+		for _, symbol := range symbols {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if got := m.Analyze(symbol, windows[symbol]).Confidence; got != want[symbol] {
+					errs <- fmt.Sprintf("%s confidence %v, want %v", symbol, got, want[symbol])
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// falling mirrors prices around 100, turning a rally into a sell-off of the same shape
+func falling(prices []models.Price) []models.Price {
+	mirror := func(v float64) float64 { return 200 - v }
+	for i := range prices {
+		p := &prices[i]
+		p.Open, p.Close = mirror(p.Open), mirror(p.Close)
+		p.High, p.Low = mirror(p.Low), mirror(p.High)
+	}
+	return prices
+}
+
+func TestAnalyzeDirectionPassesOnlyItsSide(t *testing.T) {
+	m, err := NewStrategyManager(DefaultParams())
+	if err != nil {
+		t.Fatal(err)
+	}
+	windows := map[string][]models.Price{
+		models.PositionSideLong:  zigzagCandles("BTCUSDT", 250),
+		models.PositionSideShort: falling(zigzagCandles("BTCUSDT", 250)),
+	}
+	for setup, window := range windows {
+		if result := m.Analyze("BTCUSDT", window); !result.IsValid || result.Direction != setup {
+			t.Fatalf("the %s window reads %s valid %v (%s), want a %s setup", setup, result.Direction, result.IsValid, result.Reason, setup)
+		}
+		for _, side := range []string{models.PositionSideLong, models.PositionSideShort, DirectionBoth} {
+			result := m.AnalyzeDirection("BTCUSDT", side, window)
+			if want := side == setup || side == DirectionBoth; result.IsValid != want {
+				t.Errorf("AnalyzeDirection(%s) on a %s setup valid = %v, want %v", side, setup, result.IsValid, want)
+			}
+		}
+	}
+}
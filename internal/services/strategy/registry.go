@@ -0,0 +1,56 @@
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"sort"
+)
+
+// DefaultStrategy is used when a parameter set does not name a strategy
+const DefaultStrategy = "momentum"
+
+// Strategy turns a window of candles into an entry decision
+type Strategy interface {
+	Analyze(prices []models.Price) *analysis.AnalysisResult
+}
+
+// CadenceStrategy is a Strategy told which cadence's candle close triggered it, so it can weight
+// its timeframes accordingly
+type CadenceStrategy interface {
+	Strategy
+	AnalyzeOnCadence(prices []models.Price, cadence string) *analysis.AnalysisResult
+}
+
+// Factory builds a strategy from its parameters
+type Factory func(params analysis.Config) Strategy
+
+var registry = map[string]Factory{
+	DefaultStrategy: func(params analysis.Config) Strategy {
+		return analysis.NewAnalysisWithConfig(params)
+	},
+}
+
+// Register makes a strategy available by name in configuration files
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New builds the named strategy
+func New(name string, params analysis.Config) (Strategy, error) {
+	factory, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy %q (available: %v)", name, Names())
+	}
+	return factory(params), nil
+}
+
+// Names lists the registered strategies
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	// MinStopTicks is how many price ticks the stop must sit from entry, for symbols with a known tick size
+	MinStopTicks = 2
+
+	// MinStopFraction is the least distance between entry and stop as a fraction of entry, whatever the tick size
+	MinStopFraction = 0.0001
+)
+
+var (
+	tickSizesMu sync.RWMutex
+	tickSizes   = map[string]float64{}
+)
+
+// SetTickSizes records each symbol's price tick size from the exchange info, replacing earlier ones
+func SetTickSizes(sizes map[string]float64) {
+	tickSizesMu.Lock()
+	defer tickSizesMu.Unlock()
+	tickSizes = make(map[string]float64, len(sizes))
+	for symbol, size := range sizes {
+		if size > 0 {
+			tickSizes[symbol] = size
+		}
+	}
+}
+
+// TickSize returns the symbol's price tick size, 0 when unknown
+func TickSize(symbol string) float64 {
+	tickSizesMu.RLock()
+	defer tickSizesMu.RUnlock()
+	return tickSizes[symbol]
+}
+
+// MinStopDistance returns the closest a stop may sit to entry on symbol, see MinStopTicks and MinStopFraction
+func MinStopDistance(symbol string, entryPrice float64) float64 {
+	return math.Max(MinStopTicks*TickSize(symbol), entryPrice*MinStopFraction)
+}
+
+// ValidateResult checks a signal makes sense to execute: a known direction, a finite positive
+// confidence and prices, the stop and target on either side of entry for that direction (below and
+// above for a long, above and below for a short), and the stop at least MinStopDistance from entry
+func ValidateResult(result *analysis.AnalysisResult) error {
+	if result == nil {
+		return fmt.Errorf("no result")
+	}
+	if result.Direction != models.PositionSideLong && result.Direction != models.PositionSideShort {
+		return fmt.Errorf("unknown direction %q", result.Direction)
+	}
+	if !finitePositive(result.Confidence) {
+		return fmt.Errorf("confidence %v is not positive", result.Confidence)
+	}
+	if !finitePositive(result.EntryPrice) || !finitePositive(result.StopLoss) || !finitePositive(result.TakeProfit) {
+		return fmt.Errorf("entry %v, stop loss %v and take profit %v must be positive",
+			result.EntryPrice, result.StopLoss, result.TakeProfit)
+	}
+
+	if result.Direction == models.PositionSideLong {
+		if result.StopLoss >= result.EntryPrice {
+			return fmt.Errorf("long stop loss %.8f is not below entry %.8f", result.StopLoss, result.EntryPrice)
+		}
+		if result.TakeProfit <= result.EntryPrice {
+			return fmt.Errorf("long take profit %.8f is not above entry %.8f", result.TakeProfit, result.EntryPrice)
+		}
+	} else {
+		if result.StopLoss <= result.EntryPrice {
+			return fmt.Errorf("short stop loss %.8f is not above entry %.8f", result.StopLoss, result.EntryPrice)
+		}
+		if result.TakeProfit >= result.EntryPrice {
+			return fmt.Errorf("short take profit %.8f is not below entry %.8f", result.TakeProfit, result.EntryPrice)
+		}
+	}
+
+	if distance, least := math.Abs(result.EntryPrice-result.StopLoss), MinStopDistance(result.Symbol, result.EntryPrice); distance < least {
+		return fmt.Errorf("stop loss %.8f is %.8f from entry %.8f, closer than %.8f", result.StopLoss, distance, result.EntryPrice, least)
+	}
+	return nil
+}
+
+func finitePositive(v float64) bool {
+	return v > 0 && !math.IsInf(v, 1)
+}
@@ -0,0 +1,82 @@
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateResult(t *testing.T) {
+	long := analysis.AnalysisResult{Symbol: "BTCUSDT", Direction: models.PositionSideLong, IsValid: true,
+		Confidence: 0.8, EntryPrice: 100, StopLoss: 99, TakeProfit: 102}
+	short := analysis.AnalysisResult{Symbol: "BTCUSDT", Direction: models.PositionSideShort, IsValid: true,
+		Confidence: 0.8, EntryPrice: 100, StopLoss: 101, TakeProfit: 98}
+	with := func(r analysis.AnalysisResult, change func(*analysis.AnalysisResult)) analysis.AnalysisResult {
+		change(&r)
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		result analysis.AnalysisResult
+		want   string // Part of the error, empty when valid
+	}{
+		{"long", long, ""},
+		{"short", short, ""},
+		{"long stop above entry", with(long, func(r *analysis.AnalysisResult) { r.StopLoss = 101 }), "long stop loss"},
+		{"long target below entry", with(long, func(r *analysis.AnalysisResult) { r.TakeProfit = 98 }), "long take profit"},
+		{"long levels swapped", with(long, func(r *analysis.AnalysisResult) { r.StopLoss, r.TakeProfit = 102, 99 }), "long stop loss"},
+		{"short stop below entry", with(short, func(r *analysis.AnalysisResult) { r.StopLoss = 99 }), "short stop loss"},
+		{"short target above entry", with(short, func(r *analysis.AnalysisResult) { r.TakeProfit = 102 }), "short take profit"},
+		{"short levels swapped", with(short, func(r *analysis.AnalysisResult) { r.StopLoss, r.TakeProfit = 98, 101 }), "short stop loss"},
+		{"stop at entry", with(long, func(r *analysis.AnalysisResult) { r.StopLoss = 100 }), "long stop loss"},
+		{"stop within the least distance", with(long, func(r *analysis.AnalysisResult) { r.StopLoss = 99.995 }), "closer than"},
+		{"unknown direction", with(long, func(r *analysis.AnalysisResult) { r.Direction = "" }), "unknown direction"},
+		{"NaN confidence", with(long, func(r *analysis.AnalysisResult) { r.Confidence = math.NaN() }), "confidence"},
+		{"zero confidence", with(short, func(r *analysis.AnalysisResult) { r.Confidence = 0 }), "confidence"},
+		{"infinite target", with(long, func(r *analysis.AnalysisResult) { r.TakeProfit = math.Inf(1) }), "must be positive"},
+		{"NaN entry", with(short, func(r *analysis.AnalysisResult) { r.EntryPrice = math.NaN() }), "must be positive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := tt.result
+			err := ValidateResult(&tt.result)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("ValidateResult() error = %v, want the result accepted", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("ValidateResult() error = %v, want one about %q", err, tt.want)
+			}
+			// Validation never adjusts the result it checks
+			if !reflect.DeepEqual(tt.result, before) && !math.IsNaN(before.Confidence) && !math.IsNaN(before.EntryPrice) {
+				t.Errorf("result changed to %+v", tt.result)
+			}
+		})
+	}
+
+	if err := ValidateResult(nil); err == nil {
+		t.Error("ValidateResult(nil) accepted")
+	}
+}
+
+func TestValidateResultKnowsTheTickSize(t *testing.T) {
+	defer SetTickSizes(nil)
+	result := &analysis.AnalysisResult{Symbol: "DOGEUSDT", Direction: models.PositionSideLong,
+		Confidence: 0.8, EntryPrice: 0.1, StopLoss: 0.09999, TakeProfit: 0.102}
+
+	// A stop one 0.00001 tick below entry is far enough by fraction alone
+	if err := ValidateResult(result); err != nil {
+		t.Fatalf("ValidateResult() error = %v without a tick size", err)
+	}
+	SetTickSizes(map[string]float64{"DOGEUSDT": 0.00001})
+	if err := ValidateResult(result); err == nil || !strings.Contains(err.Error(), "closer than") {
+		t.Errorf("ValidateResult() error = %v, want the stop within %d ticks refused", err, MinStopTicks)
+	}
+	result.StopLoss = 0.09998
+	if err := ValidateResult(result); err != nil {
+		t.Errorf("ValidateResult() error = %v, want a stop %d ticks away accepted", err, MinStopTicks)
+	}
+}
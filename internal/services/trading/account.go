@@ -0,0 +1,234 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/repositories"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultQuoteAsset is the quote asset traded when QUOTE_ASSET is unset
+const DefaultQuoteAsset = "USDT"
+
+// knownQuoteAssets are the quote assets a symbol's quote is recognized by
+var knownQuoteAssets = []string{"FDUSD", "USDT", "USDC", "BUSD", "BTC", "ETH", "BNB"}
+
+// QuoteAssetFromEnv returns the QUOTE_ASSET environment variable, DefaultQuoteAsset when unset
+func QuoteAssetFromEnv() (string, error) {
+	quote := strings.ToUpper(strings.TrimSpace(os.Getenv("QUOTE_ASSET")))
+	if quote == "" {
+		return DefaultQuoteAsset, nil
+	}
+	for _, r := range quote {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return "", fmt.Errorf("invalid QUOTE_ASSET %q", quote)
+		}
+	}
+	return quote, nil
+}
+
+// SymbolQuote returns the known quote asset symbol ends with, empty when it ends with none
+func SymbolQuote(symbol string) string {
+	for _, quote := range knownQuoteAssets {
+		if len(symbol) > len(quote) && strings.HasSuffix(symbol, quote) {
+			return quote
+		}
+	}
+	return ""
+}
+
+// QuoteOf returns the quote asset symbol's PnL is in, quoteAsset when symbol ends with it even if
+// it is not a known one, empty when none is known
+func QuoteOf(symbol, quoteAsset string) string {
+	if strings.HasSuffix(symbol, quoteAsset) && len(symbol) > len(quoteAsset) {
+		return quoteAsset
+	}
+	return SymbolQuote(symbol)
+}
+
+// CheckSymbols rejects symbols without a recognizable quote asset and warns about each one not quoted in quoteAsset
+// Those are only traded on accounts holding a balance in their quote, see AccountService.CheckSymbols
+func CheckSymbols(symbols []string, quoteAsset string) error {
+	var unknown []string
+	for _, symbol := range symbols {
+		quote := QuoteOf(symbol, quoteAsset)
+		if quote == quoteAsset {
+			continue
+		}
+		if quote == "" {
+			unknown = append(unknown, symbol)
+			continue
+		}
+		log.Printf("Warning: %s is quoted in %s, not %s, its PnL needs a %s balance", symbol, quote, quoteAsset, quote)
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("no known quote asset for %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// AccountService owns an account's balances, one per quote asset it trades
+// PnL is booked in the quote asset of its symbol; totals are valued in the account's main quote asset
+type AccountService struct {
+	balanceRepo *repositories.BalanceRepository
+	quoteAsset  string
+	prices      LatestPricer // Values other assets in quoteAsset, nil when all balances are in it
+}
+
+// NewAccountService creates a new instance of AccountService
+// The repository decides which account it serves
+func NewAccountService(balanceRepo *repositories.BalanceRepository, quoteAsset string) *AccountService {
+	return &AccountService{
+		balanceRepo: balanceRepo,
+		quoteAsset:  quoteAsset,
+	}
+}
+
+// ForAccount returns an AccountService for another account in the same quote asset
+func (s *AccountService) ForAccount(account string) *AccountService {
+	other := NewAccountService(s.balanceRepo.ForAccount(account), s.quoteAsset)
+	other.prices = s.prices
+	return other
+}
+
+// UsePrices values balances and PnL in other assets at the latest prices stored in prices
+func (s *AccountService) UsePrices(prices LatestPricer) {
+	s.prices = prices
+}
+
+// Account returns the account served
+func (s *AccountService) Account() string {
+	return s.balanceRepo.Account()
+}
+
+// Accounts returns every account holding a balance
+func (s *AccountService) Accounts() ([]string, error) {
+	return s.balanceRepo.Accounts()
+}
+
+// QuoteAsset returns the account's main quote asset, the one totals are valued in
+func (s *AccountService) QuoteAsset() string {
+	return s.quoteAsset
+}
+
+// Balance returns the account's quote asset balance, nil when it has none yet
+func (s *AccountService) Balance() (*models.Balance, error) {
+	return s.balanceRepo.FindBySymbol(s.quoteAsset)
+}
+
+// QuoteFor returns the asset symbol's PnL is booked in, failing when the account holds no balance in it
+// rather than booking it in the main quote asset
+func (s *AccountService) QuoteFor(symbol string) (string, error) {
+	quote := QuoteOf(symbol, s.quoteAsset)
+	if quote == "" {
+		return "", fmt.Errorf("no known quote asset for %s", symbol)
+	}
+
+	balance, err := s.balanceRepo.FindBySymbol(quote)
+	if err != nil {
+		return "", fmt.Errorf("error checking %s balance: %v", quote, err)
+	}
+	if balance == nil {
+		return "", fmt.Errorf("%s is quoted in %s but account %s has no %s balance", symbol, quote, s.Account(), quote)
+	}
+	return quote, nil
+}
+
+// BalanceFor returns the balance symbol's PnL is booked in, see QuoteFor
+func (s *AccountService) BalanceFor(symbol string) (*models.Balance, error) {
+	quote, err := s.QuoteFor(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.balanceRepo.FindBySymbol(quote)
+}
+
+// CheckSymbols fails on the first symbol the account holds no balance to book PnL in
+func (s *AccountService) CheckSymbols(symbols []string) error {
+	for _, symbol := range symbols {
+		if _, err := s.QuoteFor(symbol); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConvertPnL values pnl of a position in symbol, booked in the symbol's quote asset, in the main quote asset
+func (s *AccountService) ConvertPnL(ctx context.Context, symbol string, pnl float64) (float64, error) {
+	return Convert(ctx, s.prices, pnl, QuoteOf(symbol, s.quoteAsset), s.quoteAsset)
+}
+
+// Balances returns the account's balance in every asset it holds
+func (s *AccountService) Balances() ([]models.Balance, error) {
+	balances, err := s.balanceRepo.FindAll()
+	if err != nil {
+		return nil, fmt.Errorf("error getting balances: %v", err)
+	}
+	return balances, nil
+}
+
+// TotalBalance returns every balance of the account valued in its main quote asset
+func (s *AccountService) TotalBalance(ctx context.Context) (float64, error) {
+	balances, err := s.Balances()
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0.0
+	for _, balance := range balances {
+		value, err := Convert(ctx, s.prices, balance.Balance, balance.Symbol, s.quoteAsset)
+		if err != nil {
+			return 0, err
+		}
+		total += value
+	}
+	return total, nil
+}
+
+// Equity returns TotalBalance plus the unrealized PnL of positions marked at marks, keyed by symbol,
+// each converted from its symbol's quote asset; a position without a mark counts at its entry price
+func (s *AccountService) Equity(ctx context.Context, positions []models.Position, marks map[string]float64) (float64, error) {
+	equity, err := s.TotalBalance(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range positions {
+		mark, ok := marks[positions[i].Symbol]
+		if !ok {
+			continue
+		}
+		pnl, err := s.ConvertPnL(ctx, positions[i].Symbol, PositionPnL(&positions[i], mark))
+		if err != nil {
+			return 0, err
+		}
+		equity += pnl
+	}
+	return equity, nil
+}
+
+// Init creates the quote asset balance with initial unless the account already has one
+func (s *AccountService) Init(initial float64) error {
+	balance, err := s.Balance()
+	if err != nil {
+		return fmt.Errorf("error checking balance: %v", err)
+	}
+	if balance != nil {
+		return nil
+	}
+
+	if err := s.balanceRepo.Create(&models.Balance{
+		Symbol:      s.quoteAsset,
+		Balance:     initial,
+		LastUpdated: time.Now(),
+	}); err != nil {
+		return fmt.Errorf("error creating initial balance: %v", err)
+	}
+	return nil
+}
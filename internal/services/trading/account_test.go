@@ -0,0 +1,61 @@
+package trading
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestQuoteAssetFromEnv(t *testing.T) {
+	tests := []struct {
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{"", DefaultQuoteAsset, false},
+		{"USDC", "USDC", false},
+		{" usdc ", "USDC", false},
+		{"FDUSD", "FDUSD", false},
+		{"US-DC", "", true},
+		{"usd c", "", true},
+	}
+	for _, tt := range tests {
+		t.Setenv("QUOTE_ASSET", tt.env)
+		got, err := QuoteAssetFromEnv()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("QUOTE_ASSET=%q: QuoteAssetFromEnv() = %q, %v, want %q, error %v", tt.env, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestQuoteOf(t *testing.T) {
+	tests := []struct {
+		symbol, quoteAsset, want string
+	}{
+		{"BTCUSDC", "USDC", "USDC"},
+		{"BTCUSDT", "USDC", "USDT"},
+		{"BTCFDUSD", "USDT", "FDUSD"}, // Not read as a USD quote
+		{"ETHBTC", "USDC", "BTC"},
+		{"BTCEUR", "EUR", "EUR"}, // The configured quote counts even when it is not a known one
+		{"BTCEUR", "USDC", ""},
+		{"USDC", "USDC", ""}, // The quote alone is no symbol
+	}
+	for _, tt := range tests {
+		if got := QuoteOf(tt.symbol, tt.quoteAsset); got != tt.want {
+			t.Errorf("QuoteOf(%q, %q) = %q, want %q", tt.symbol, tt.quoteAsset, got, tt.want)
+		}
+	}
+}
+
+func TestCheckSymbols(t *testing.T) {
+	if err := CheckSymbols([]string{"BTCUSDC", "ETHUSDC"}, "USDC"); err != nil {
+		t.Errorf("CheckSymbols() on USDC pairs = %v, want nil", err)
+	}
+	// Another known quote is only warned about, the account decides whether it can book it
+	if err := CheckSymbols([]string{"BTCUSDC", "ETHUSDT"}, "USDC"); err != nil {
+		t.Errorf("CheckSymbols() with a USDT pair = %v, want a warning only", err)
+	}
+	err := CheckSymbols([]string{"BTCUSDC", "XAUEUR", "FOOBAR"}, "USDC")
+	if err == nil || !strings.Contains(err.Error(), "FOOBAR, XAUEUR") {
+		t.Errorf("CheckSymbols() with unknown quotes = %v, want both named", err)
+	}
+}
@@ -0,0 +1,78 @@
+package trading
+
+import (
+	"sync"
+	"time"
+)
+
+// Backoff between attempts to close a position whose close failed
+const (
+	CloseRetryBase = 15 * time.Second
+	CloseRetryMax  = 5 * time.Minute
+)
+
+// PendingClose is a close that failed and is retried at the price and time it was decided
+type PendingClose struct {
+	Reason   string
+	Price    float64
+	PnL      float64
+	At       time.Time // When the close was decided, kept as the close time
+	Attempts int
+	RetryAt  time.Time
+}
+
+// CloseRetries remembers failed closes so the monitor retries them with backoff instead of
+// re-evaluating a position whose exit already triggered
+type CloseRetries struct {
+	mu      sync.Mutex
+	pending map[uint]PendingClose
+}
+
+// NewCloseRetries creates a new instance of CloseRetries
+func NewCloseRetries() *CloseRetries {
+	return &CloseRetries{pending: make(map[uint]PendingClose)}
+}
+
+// Failed records a failed attempt to close positionID and returns the updated pending close
+// The first failure keeps the close as decided; later ones only push the next attempt back
+func (r *CloseRetries) Failed(positionID uint, decided PendingClose, now time.Time) PendingClose {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.pending[positionID]; ok {
+		decided = existing
+	}
+	decided.Attempts++
+	decided.RetryAt = now.Add(min(CloseRetryBase<<min(decided.Attempts-1, 16), CloseRetryMax))
+	r.pending[positionID] = decided
+	return decided
+}
+
+// Done forgets positionID once its close landed or no longer applies
+func (r *CloseRetries) Done(positionID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, positionID)
+}
+
+// Pending reports whether positionID has a close waiting to be retried
+func (r *CloseRetries) Pending(positionID uint) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.pending[positionID]
+	return ok
+}
+
+// Due returns the pending closes whose next attempt is at or before now
+func (r *CloseRetries) Due(now time.Time) map[uint]PendingClose {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	due := make(map[uint]PendingClose)
+	for id, pending := range r.pending {
+		if !pending.RetryAt.After(now) {
+			due[id] = pending
+		}
+	}
+	return due
+}
@@ -0,0 +1,40 @@
+package trading
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCloseRetriesBackOff(t *testing.T) {
+	r := NewCloseRetries()
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	decided := PendingClose{Reason: "stop_loss", Price: 99, PnL: -5, At: now}
+
+	// Each failure doubles the wait up to the cap, the close stays as first decided
+	waits := []time.Duration{15 * time.Second, 30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, CloseRetryMax, CloseRetryMax}
+	for i, wait := range waits {
+		later := PendingClose{Reason: "take_profit", Price: 101, PnL: 5, At: now.Add(time.Hour)}
+		attempt := decided
+		if i > 0 {
+			attempt = later
+		}
+		pending := r.Failed(7, attempt, now)
+		if pending.Attempts != i+1 || !pending.RetryAt.Equal(now.Add(wait)) {
+			t.Errorf("failure %d: attempt %d retrying at %v, want attempt %d after %v", i+1, pending.Attempts, pending.RetryAt.Sub(now), i+1, wait)
+		}
+		if pending.Reason != decided.Reason || pending.PnL != decided.PnL || !pending.At.Equal(decided.At) {
+			t.Errorf("failure %d replaced the decided close with %+v", i+1, pending)
+		}
+	}
+
+	if due := r.Due(now.Add(CloseRetryMax - time.Second)); len(due) != 0 {
+		t.Errorf("Due() before the retry time = %v, want none", due)
+	}
+	if due := r.Due(now.Add(CloseRetryMax)); len(due) != 1 || !r.Pending(7) {
+		t.Errorf("Due() at the retry time = %v, want position 7", due)
+	}
+	r.Done(7)
+	if r.Pending(7) || len(r.Due(now.Add(time.Hour))) != 0 {
+		t.Error("a close marked done is still pending")
+	}
+}
@@ -0,0 +1,87 @@
+package trading
+
+import "fmt"
+
+// ConfidenceBucketEdges split entry confidences into the buckets of ConfidenceBuckets, each from one
+// edge up to the next; the outer buckets are open ended
+var ConfidenceBucketEdges = []float64{0.7, 0.8, 0.9}
+
+// ConfidenceOutcome is one closed trade as ConfidenceBuckets sees it
+type ConfidenceOutcome struct {
+	Confidence     float64
+	SizeMultiplier float64 // Factor the trade's size was scaled by for its confidence, 0 read as 1
+	PnL            float64
+	R              *float64 // nil for trades without an R
+}
+
+// ConfidenceBucket is the performance of the trades entered within one range of confidence
+type ConfidenceBucket struct {
+	Label          string  `json:"label"` // e.g. "0.80 to 0.90" or "below 0.70"
+	Trades         int     `json:"trades"`
+	Wins           int     `json:"wins"`
+	WinRate        float64 `json:"win_rate"`
+	PnL            float64 `json:"pnl"`
+	AverageR       float64 `json:"average_r"`       // Over the trades with an R
+	SizeMultiplier float64 `json:"size_multiplier"` // Average factor the trades' size was scaled by
+}
+
+// ConfidenceBuckets groups outcomes by the ConfidenceBucketEdges range their confidence falls in, lowest
+// first, leaving out empty buckets; a trade is a win when it made money
+func ConfidenceBuckets(outcomes []ConfidenceOutcome) []ConfidenceBucket {
+	buckets := make([]ConfidenceBucket, len(ConfidenceBucketEdges)+1)
+	rTrades := make([]int, len(buckets))
+	for _, outcome := range outcomes {
+		i := confidenceBucket(outcome.Confidence)
+		bucket := &buckets[i]
+		bucket.Trades++
+		if outcome.PnL > 0 {
+			bucket.Wins++
+		}
+		bucket.PnL += outcome.PnL
+		if outcome.R != nil {
+			bucket.AverageR += *outcome.R
+			rTrades[i]++
+		}
+		multiplier := outcome.SizeMultiplier
+		if multiplier <= 0 {
+			multiplier = 1
+		}
+		bucket.SizeMultiplier += multiplier
+	}
+
+	var filled []ConfidenceBucket
+	for i, bucket := range buckets {
+		if bucket.Trades == 0 {
+			continue
+		}
+		bucket.Label = confidenceBucketLabel(i)
+		bucket.WinRate = float64(bucket.Wins) / float64(bucket.Trades)
+		bucket.SizeMultiplier /= float64(bucket.Trades)
+		if rTrades[i] > 0 {
+			bucket.AverageR /= float64(rTrades[i])
+		}
+		filled = append(filled, bucket)
+	}
+	return filled
+}
+
+// confidenceBucket returns the index of the ConfidenceBucketEdges range confidence falls in
+func confidenceBucket(confidence float64) int {
+	for i, edge := range ConfidenceBucketEdges {
+		if confidence < edge {
+			return i
+		}
+	}
+	return len(ConfidenceBucketEdges)
+}
+
+// confidenceBucketLabel names bucket i of ConfidenceBucketEdges
+func confidenceBucketLabel(i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("below %.2f", ConfidenceBucketEdges[0])
+	case i == len(ConfidenceBucketEdges):
+		return fmt.Sprintf("%.2f and up", ConfidenceBucketEdges[i-1])
+	}
+	return fmt.Sprintf("%.2f to %.2f", ConfidenceBucketEdges[i-1], ConfidenceBucketEdges[i])
+}
@@ -0,0 +1,40 @@
+package trading
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestConfidenceBuckets(t *testing.T) {
+	r := func(value float64) *float64 { return &value }
+	outcomes := []ConfidenceOutcome{
+		{Confidence: 0.72, SizeMultiplier: 0.6, PnL: -1, R: r(-1)},
+		{Confidence: 0.78, SizeMultiplier: 0.8, PnL: 2, R: r(2)},
+		{Confidence: 0.95, SizeMultiplier: 1.4, PnL: 3, R: r(2)},
+		{Confidence: 0.9, PnL: 1}, // Saved before multipliers and R were recorded
+		{Confidence: 0.65, SizeMultiplier: 1, PnL: 0, R: r(0)},
+	}
+
+	want := []ConfidenceBucket{
+		{Label: "below 0.70", Trades: 1, Wins: 0, WinRate: 0, PnL: 0, AverageR: 0, SizeMultiplier: 1},
+		{Label: "0.70 to 0.80", Trades: 2, Wins: 1, WinRate: 0.5, PnL: 1, AverageR: 0.5, SizeMultiplier: 0.7},
+		{Label: "0.90 and up", Trades: 2, Wins: 2, WinRate: 1, PnL: 4, AverageR: 2, SizeMultiplier: 1.2},
+	}
+	got := ConfidenceBuckets(outcomes)
+	if len(got) != len(want) {
+		t.Fatalf("ConfidenceBuckets() = %+v, want the empty 0.80 bucket left out", got)
+	}
+	for i := range want {
+		if math.Abs(got[i].SizeMultiplier-want[i].SizeMultiplier) > 1e-9 {
+			t.Errorf("bucket %s size x%v, want x%v", got[i].Label, got[i].SizeMultiplier, want[i].SizeMultiplier)
+		}
+		got[i].SizeMultiplier = want[i].SizeMultiplier
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("bucket %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if buckets := ConfidenceBuckets(nil); len(buckets) != 0 {
+		t.Errorf("ConfidenceBuckets(nil) = %+v, want none", buckets)
+	}
+}
@@ -0,0 +1,48 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"fmt"
+)
+
+// LatestPricer returns the latest stored candle of a symbol, nil when there is none,
+// repositories.PriceRepository among others
+type LatestPricer interface {
+	GetLatestPrice(ctx context.Context, symbol string) (*models.Price, error)
+}
+
+// Convert values amount of asset in target at the latest close of asset quoted in target,
+// or of target quoted in asset when only that pair is stored
+func Convert(ctx context.Context, prices LatestPricer, amount float64, asset, target string) (float64, error) {
+	if asset == target || amount == 0 {
+		return amount, nil
+	}
+	if prices == nil {
+		return 0, fmt.Errorf("no prices to convert %s to %s", asset, target)
+	}
+
+	if price, err := latestClose(ctx, prices, asset+target); err != nil {
+		return 0, err
+	} else if price > 0 {
+		return amount * price, nil
+	}
+	if price, err := latestClose(ctx, prices, target+asset); err != nil {
+		return 0, err
+	} else if price > 0 {
+		return amount / price, nil
+	}
+	return 0, fmt.Errorf("no %s%s or %s%s price to convert %s to %s", asset, target, target, asset, asset, target)
+}
+
+// latestClose returns the latest close of symbol, 0 when it has no candles
+func latestClose(ctx context.Context, prices LatestPricer, symbol string) (float64, error) {
+	latest, err := prices.GetLatestPrice(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s price: %v", symbol, err)
+	}
+	if latest == nil {
+		return 0, nil
+	}
+	return latest.Close, nil
+}
@@ -0,0 +1,53 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"context"
+	"errors"
+	"math"
+	"testing"
+)
+
+// latestCloses is a LatestPricer over a close per symbol
+type latestCloses map[string]float64
+
+func (l latestCloses) GetLatestPrice(ctx context.Context, symbol string) (*models.Price, error) {
+	if symbol == "FAILUSDT" {
+		return nil, errors.New("database unavailable")
+	}
+	close, ok := l[symbol]
+	if !ok {
+		return nil, nil
+	}
+	return &models.Price{Symbol: symbol, Close: close}, nil
+}
+
+func TestConvert(t *testing.T) {
+	prices := latestCloses{"BTCUSDT": 50000, "USDTEUR": 0.9}
+	tests := []struct {
+		name          string
+		amount        float64
+		asset, target string
+		want          float64
+		wantErr       bool
+	}{
+		{"same asset", 12.5, "USDT", "USDT", 12.5, false},
+		{"direct pair", 0.02, "BTC", "USDT", 1000, false},
+		{"inverse pair", 9, "EUR", "USDT", 10, false},
+		{"nothing to convert", 0, "ETH", "USDT", 0, false},
+		{"no pair stored", 1, "ETH", "USDT", 0, true},
+		{"lookup fails", 1, "FAIL", "USDT", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Convert(context.Background(), prices, tt.amount, tt.asset, tt.target)
+			if (err != nil) != tt.wantErr || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Convert(%v %s to %s) = %v, %v, want %v, error %v", tt.amount, tt.asset, tt.target, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := Convert(context.Background(), nil, 1, "BTC", "USDT"); err == nil {
+		t.Error("Convert() without prices succeeded")
+	}
+}
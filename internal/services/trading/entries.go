@@ -0,0 +1,50 @@
+package trading
+
+import "CryptoTradeBot/internal/models"
+
+// Entry modes
+const (
+	EntryModeMarket = "market" // Fill immediately at the signal price
+	EntryModeLimit  = "limit"  // Rest a limit order at an offset and wait for price to trade through it
+)
+
+// EntryConfig controls how signals become positions
+type EntryConfig struct {
+	Mode          string
+	LimitOffset   float64 // Fraction of the signal price to improve the entry by, e.g. 0.0005
+	ExpiryCandles int     // Limit orders expire after this many 5m candles
+	HedgeMode     bool    // Allow a long and a short position on the same symbol at once
+}
+
+// DefaultEntryConfig returns market entries
+func DefaultEntryConfig() EntryConfig {
+	return EntryConfig{
+		Mode:          EntryModeMarket,
+		LimitOffset:   0.0005,
+		ExpiryCandles: 3,
+	}
+}
+
+// LimitPrice returns the resting order price for a signal
+func LimitPrice(side string, signalPrice, offset float64) float64 {
+	if side == models.PositionSideLong {
+		return signalPrice * (1 - offset)
+	}
+	return signalPrice * (1 + offset)
+}
+
+// LimitFilled reports whether a candle traded through the limit price
+func LimitFilled(side string, limitPrice, low, high float64) bool {
+	if side == models.PositionSideLong {
+		return low <= limitPrice
+	}
+	return high >= limitPrice
+}
+
+// LimitInvalidated reports whether price ran to the target before the order filled
+func LimitInvalidated(side string, takeProfit, low, high float64) bool {
+	if side == models.PositionSideLong {
+		return high >= takeProfit
+	}
+	return low <= takeProfit
+}
@@ -0,0 +1,48 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestLimitPrice(t *testing.T) {
+	if got := LimitPrice(models.PositionSideLong, 100, 0.0005); math.Abs(got-99.95) > 1e-9 {
+		t.Errorf("long limit = %v, want 99.95 below the signal", got)
+	}
+	if got := LimitPrice(models.PositionSideShort, 100, 0.0005); math.Abs(got-100.05) > 1e-9 {
+		t.Errorf("short limit = %v, want 100.05 above the signal", got)
+	}
+}
+
+func TestLimitOrderCandle(t *testing.T) {
+	tests := []struct {
+		name        string
+		side        string
+		low, high   float64
+		filled      bool
+		invalidated bool
+	}{
+		{"long waits above the limit", models.PositionSideLong, 100, 101, false, false},
+		{"long fills at the limit", models.PositionSideLong, 99.95, 101, true, false},
+		{"long fills through the limit", models.PositionSideLong, 99, 100.5, true, false},
+		{"long runs away to the target", models.PositionSideLong, 100.2, 103, false, true},
+		{"short waits below the limit", models.PositionSideShort, 99, 100, false, false},
+		{"short fills at the limit", models.PositionSideShort, 99, 100.05, true, false},
+		{"short runs away to the target", models.PositionSideShort, 97, 99.8, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, target := 99.95, 103.0
+			if tt.side == models.PositionSideShort {
+				limit, target = 100.05, 97
+			}
+			if got := LimitFilled(tt.side, limit, tt.low, tt.high); got != tt.filled {
+				t.Errorf("LimitFilled() = %v, want %v", got, tt.filled)
+			}
+			if got := LimitInvalidated(tt.side, target, tt.low, tt.high); got != tt.invalidated {
+				t.Errorf("LimitInvalidated() = %v, want %v", got, tt.invalidated)
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+package trading
+
+// CloseReasonEquityStop marks a position closed by the account-level equity stop
+const CloseReasonEquityStop = "equity_stop"
@@ -0,0 +1,56 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+)
+
+// Excursion returns how far the prices from low to high went against and in favor of
+// a position opened at entryPrice, as price distances that are 0 when price never went that way
+func Excursion(side string, entryPrice, low, high float64) (float64, float64) {
+	adverse, favorable := entryPrice-low, high-entryPrice
+	if side == models.PositionSideShort {
+		adverse, favorable = high-entryPrice, entryPrice-low
+	}
+	return math.Max(adverse, 0), math.Max(favorable, 0)
+}
+
+// InR expresses a price distance in initial stop distances, 0 when the stop distance is unknown
+func InR(distance, initialStopDistance float64) float64 {
+	if initialStopDistance <= 0 {
+		return 0
+	}
+	return distance / initialStopDistance
+}
+
+// ClampRange limits the prices from low to high to those between the lowest and highest bound
+// On the candle a position closes, its exit levels bound what it could have seen
+func ClampRange(low, high float64, bounds ...float64) (float64, float64) {
+	lower, upper := bounds[0], bounds[0]
+	for _, bound := range bounds[1:] {
+		lower, upper = math.Min(lower, bound), math.Max(upper, bound)
+	}
+	return math.Max(low, lower), math.Min(high, upper)
+}
+
+// TrackExcursion widens the position's MAE and MFE to the prices from low to high, clamped to
+// bounds on the candle the position closes. It reports whether either grew, so callers only
+// save positions that changed
+func TrackExcursion(position *models.Position, low, high float64, bounds ...float64) bool {
+	if len(bounds) > 0 {
+		low, high = ClampRange(low, high, bounds...)
+	}
+	if position.InitialStopDistance == 0 {
+		position.InitialStopDistance = InitialStopDistance(position.EntryPrice, position.StopLossPrice)
+	}
+
+	adverse, favorable := Excursion(position.Side, position.EntryPrice, low, high)
+	if adverse <= position.MAE && favorable <= position.MFE {
+		return false
+	}
+	position.MAE = math.Max(position.MAE, adverse)
+	position.MFE = math.Max(position.MFE, favorable)
+	position.MAER = InR(position.MAE, position.InitialStopDistance)
+	position.MFER = InR(position.MFE, position.InitialStopDistance)
+	return true
+}
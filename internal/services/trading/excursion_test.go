@@ -0,0 +1,77 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestTrackExcursionFollowsMonitorPasses(t *testing.T) {
+	// Each pass sees one price, as the live monitor does; the last closes at the target
+	type pass struct {
+		price   float64
+		close   bool // The pass closing the position at the target
+		changed bool
+		mae     float64
+		mfe     float64
+	}
+	tests := []struct {
+		name     string
+		position models.Position
+		passes   []pass
+	}{
+		{"long", models.Position{Side: models.PositionSideLong, EntryPrice: 100, StopLossPrice: 98, TakeProfitPrice: 104}, []pass{
+			{price: 100.5, changed: true, mae: 0, mfe: 0.5},
+			{price: 99, changed: true, mae: 1, mfe: 0.5},
+			{price: 99.5, changed: false, mae: 1, mfe: 0.5},
+			{price: 102.5, changed: true, mae: 1, mfe: 2.5},
+			{price: 104.6, close: true, changed: true, mae: 1, mfe: 4}, // Filled at the target, not the overshoot
+		}},
+		{"short", models.Position{Side: models.PositionSideShort, EntryPrice: 100, StopLossPrice: 102, TakeProfitPrice: 96}, []pass{
+			{price: 99.5, changed: true, mae: 0, mfe: 0.5},
+			{price: 101, changed: true, mae: 1, mfe: 0.5},
+			{price: 100.5, changed: false, mae: 1, mfe: 0.5},
+			{price: 97.5, changed: true, mae: 1, mfe: 2.5},
+			{price: 95.4, close: true, changed: true, mae: 1, mfe: 4},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position := tt.position
+			for i, p := range tt.passes {
+				var bounds []float64
+				if p.close {
+					bounds = []float64{position.StopLossPrice, position.TakeProfitPrice}
+				}
+				changed := TrackExcursion(&position, p.price, p.price, bounds...)
+				if changed != p.changed || math.Abs(position.MAE-p.mae) > 1e-9 || math.Abs(position.MFE-p.mfe) > 1e-9 {
+					t.Errorf("pass %d at %v: changed %v MAE %v MFE %v, want %v, %v, %v",
+						i+1, p.price, changed, position.MAE, position.MFE, p.changed, p.mae, p.mfe)
+				}
+				// The stop is 2 away, so R is half the price distance
+				if math.Abs(position.MAER-p.mae/2) > 1e-9 || math.Abs(position.MFER-p.mfe/2) > 1e-9 {
+					t.Errorf("pass %d: MAE %vR MFE %vR, want %vR, %vR", i+1, position.MAER, position.MFER, p.mae/2, p.mfe/2)
+				}
+			}
+		})
+	}
+}
+
+func TestExcursionOfOneCandle(t *testing.T) {
+	tests := []struct {
+		side               string
+		low, high          float64
+		adverse, favorable float64
+	}{
+		{models.PositionSideLong, 98.5, 101.2, 1.5, 1.2},
+		{models.PositionSideShort, 98.5, 101.2, 1.2, 1.5},
+		{models.PositionSideLong, 100.5, 101, 0, 1}, // Never below the entry
+		{models.PositionSideShort, 100.5, 101, 1, 0},
+	}
+	for _, tt := range tests {
+		adverse, favorable := Excursion(tt.side, 100, tt.low, tt.high)
+		if math.Abs(adverse-tt.adverse) > 1e-9 || math.Abs(favorable-tt.favorable) > 1e-9 {
+			t.Errorf("Excursion(%s, %v-%v) = %v, %v, want %v, %v", tt.side, tt.low, tt.high, adverse, favorable, tt.adverse, tt.favorable)
+		}
+	}
+}
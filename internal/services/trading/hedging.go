@@ -0,0 +1,31 @@
+package trading
+
+import "CryptoTradeBot/internal/models"
+
+// CanOpen reports whether a new position on side may be added to the symbol's open positions
+// In one-way mode a symbol holds at most one position; in hedge mode it holds at most one per side
+func CanOpen(open []models.Position, side string, hedgeMode bool) bool {
+	if !hedgeMode {
+		return len(open) == 0
+	}
+	for _, p := range open {
+		if p.Side == side {
+			return false
+		}
+	}
+	return true
+}
+
+// NetExposure returns the signed notional of the open positions per symbol, longs positive
+// Hedged legs offset each other, so a fully hedged symbol reports zero
+func NetExposure(open []models.Position) map[string]float64 {
+	exposure := make(map[string]float64)
+	for _, p := range open {
+		notional := p.Size * p.EntryPrice
+		if p.Side == models.PositionSideShort {
+			notional = -notional
+		}
+		exposure[p.Symbol] += notional
+	}
+	return exposure
+}
@@ -0,0 +1,49 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestCanOpen(t *testing.T) {
+	long := models.Position{Symbol: "BTCUSDT", Side: models.PositionSideLong}
+	short := models.Position{Symbol: "BTCUSDT", Side: models.PositionSideShort}
+	tests := []struct {
+		name  string
+		open  []models.Position
+		side  string
+		hedge bool
+		want  bool
+	}{
+		{"one-way, flat", nil, models.PositionSideLong, false, true},
+		{"one-way, opposite side held", []models.Position{long}, models.PositionSideShort, false, false},
+		{"hedge, flat", nil, models.PositionSideShort, true, true},
+		{"hedge, opposite side held", []models.Position{long}, models.PositionSideShort, true, true},
+		{"hedge, same side held", []models.Position{long}, models.PositionSideLong, true, false},
+		{"hedge, both legs held", []models.Position{long, short}, models.PositionSideShort, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanOpen(tt.open, tt.side, tt.hedge); got != tt.want {
+				t.Errorf("CanOpen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNetExposure(t *testing.T) {
+	exposure := NetExposure([]models.Position{
+		{Symbol: "BTCUSDT", Side: models.PositionSideLong, Size: 0.02, EntryPrice: 50000},
+		{Symbol: "BTCUSDT", Side: models.PositionSideShort, Size: 0.01, EntryPrice: 52000},
+		{Symbol: "ETHUSDT", Side: models.PositionSideLong, Size: 1, EntryPrice: 3000},
+		{Symbol: "ETHUSDT", Side: models.PositionSideShort, Size: 1, EntryPrice: 3000},
+		{Symbol: "SOLUSDT", Side: models.PositionSideShort, Size: 10, EntryPrice: 150},
+	})
+	want := map[string]float64{"BTCUSDT": 480, "ETHUSDT": 0, "SOLUSDT": -1500}
+	for symbol, w := range want {
+		if math.Abs(exposure[symbol]-w) > 1e-9 {
+			t.Errorf("NetExposure()[%s] = %v, want %v", symbol, exposure[symbol], w)
+		}
+	}
+}
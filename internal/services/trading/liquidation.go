@@ -0,0 +1,91 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"sort"
+)
+
+// CloseReasonLiquidation marks a position force-closed at its liquidation price
+const CloseReasonLiquidation = "liquidation"
+
+// MarginTier is the maintenance margin rate for positions up to MaxNotional USDT
+type MarginTier struct {
+	MaxNotional float64
+	Rate        float64
+}
+
+// DefaultMarginTiers approximates Binance USDT-M tiers for the symbols traded by default
+// Symbols missing from the map use FallbackMarginTiers
+var DefaultMarginTiers = map[string][]MarginTier{
+	"BTCUSDT": {{50000, 0.004}, {500000, 0.005}, {8000000, 0.01}},
+	"ETHUSDT": {{50000, 0.005}, {500000, 0.0065}, {8000000, 0.01}},
+	"XRPUSDT": {{10000, 0.01}, {100000, 0.015}, {1000000, 0.025}},
+}
+
+// FallbackMarginTiers applies to symbols without their own tiers
+var FallbackMarginTiers = []MarginTier{{5000, 0.01}, {50000, 0.015}, {250000, 0.02}}
+
+// MaintenanceMarginRate returns the maintenance margin rate for a position of notional USDT on symbol
+func MaintenanceMarginRate(symbol string, notional float64) float64 {
+	tiers, ok := DefaultMarginTiers[symbol]
+	if !ok {
+		tiers = FallbackMarginTiers
+	}
+
+	i := sort.Search(len(tiers), func(i int) bool {
+		return notional <= tiers[i].MaxNotional
+	})
+	if i == len(tiers) {
+		i = len(tiers) - 1
+	}
+	return tiers[i].Rate
+}
+
+// LiquidationPrice returns the isolated margin liquidation price of a position
+// The position is liquidated once its loss eats the initial margin down to the maintenance margin:
+// long at entry×(1 − 1/leverage + mmr), short at entry×(1 + 1/leverage − mmr)
+func LiquidationPrice(side string, entryPrice float64, leverage int, maintenanceRate float64) float64 {
+	if leverage <= 0 {
+		return 0
+	}
+	initial := 1 / float64(leverage)
+	if side == models.PositionSideLong {
+		return entryPrice * (1 - initial + maintenanceRate)
+	}
+	return entryPrice * (1 + initial - maintenanceRate)
+}
+
+// PositionLiquidationPrice returns the liquidation price for a position's size, side and leverage
+func PositionLiquidationPrice(symbol, side string, entryPrice, size float64, leverage int) float64 {
+	rate := MaintenanceMarginRate(symbol, entryPrice*size)
+	return LiquidationPrice(side, entryPrice, leverage, rate)
+}
+
+// Liquidated reports whether a price range reached the liquidation price
+// A zero liquidation price means none was recorded and never liquidates
+func Liquidated(side string, liquidationPrice, low, high float64) bool {
+	if liquidationPrice <= 0 {
+		return false
+	}
+	if side == models.PositionSideLong {
+		return PriceLTE(low, liquidationPrice)
+	}
+	return PriceGTE(high, liquidationPrice)
+}
+
+// StopBeyondLiquidation reports whether the stop would only trigger after liquidation
+func StopBeyondLiquidation(side string, stopLoss, liquidationPrice float64) bool {
+	if liquidationPrice <= 0 {
+		return false
+	}
+	if side == models.PositionSideLong {
+		return stopLoss <= liquidationPrice
+	}
+	return stopLoss >= liquidationPrice
+}
+
+// LiquidatedBeforeStop reports whether a price range liquidates the position before its stop loss fills
+// A stop inside the liquidation price is reached first on the way, so it closes the position instead
+func LiquidatedBeforeStop(side string, stopLoss, liquidationPrice, low, high float64) bool {
+	return Liquidated(side, liquidationPrice, low, high) && StopBeyondLiquidation(side, stopLoss, liquidationPrice)
+}
@@ -0,0 +1,37 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+)
+
+func TestLiquidatedBeforeStop(t *testing.T) {
+	// 50x BTCUSDT entries at 100 liquidate at 98.4 long and 101.6 short
+	tests := []struct {
+		name      string
+		side      string
+		stop      float64
+		low, high float64
+		want      bool
+	}{
+		{"long stop inside", models.PositionSideLong, 99, 98, 100, false},
+		{"long stop beyond", models.PositionSideLong, 97, 98, 100, true},
+		{"long stop at liquidation", models.PositionSideLong, 98.4, 98, 100, true},
+		{"long range short of liquidation", models.PositionSideLong, 97, 98.5, 100, false},
+		{"short stop inside", models.PositionSideShort, 101, 100, 102, false},
+		{"short stop beyond", models.PositionSideShort, 103, 100, 102, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			liquidation := PositionLiquidationPrice("BTCUSDT", tt.side, 100, 1, 50)
+			if got := LiquidatedBeforeStop(tt.side, tt.stop, liquidation, tt.low, tt.high); got != tt.want {
+				t.Errorf("LiquidatedBeforeStop(stop %v, liquidation %v) = %v, want %v", tt.stop, liquidation, got, tt.want)
+			}
+		})
+	}
+
+	// Positions without a recorded liquidation price never liquidate
+	if LiquidatedBeforeStop(models.PositionSideLong, 97, 0, 1, 100) {
+		t.Error("LiquidatedBeforeStop() liquidated without a liquidation price")
+	}
+}
@@ -0,0 +1,11 @@
+package trading
+
+import "CryptoTradeBot/internal/models"
+
+// PositionPnL returns the PnL of a position if it were closed at price
+func PositionPnL(position *models.Position, price float64) float64 {
+	if position.Side == models.PositionSideLong {
+		return (price - position.EntryPrice) * position.Size
+	}
+	return (position.EntryPrice - price) * position.Size
+}
@@ -0,0 +1,22 @@
+package trading
+
+import "math"
+
+// PriceTolerance is how far apart, relative to the level, two prices may be and still count as equal
+// Prices round-trip through decimal(20,8) columns and float arithmetic, so a stored 0.072340 can come
+// back as 0.07233999999; the tolerance is far below any exchange tick size, so it only absorbs that drift
+const PriceTolerance = 1e-9
+
+// PriceGTE reports whether price has reached level from below
+func PriceGTE(price, level float64) bool {
+	return price >= level-priceSlack(level)
+}
+
+// PriceLTE reports whether price has reached level from above
+func PriceLTE(price, level float64) bool {
+	return price <= level+priceSlack(level)
+}
+
+func priceSlack(level float64) float64 {
+	return math.Abs(level) * PriceTolerance
+}
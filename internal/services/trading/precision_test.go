@@ -0,0 +1,36 @@
+package trading
+
+import "testing"
+
+func TestPriceComparisonsAbsorbStorageDrift(t *testing.T) {
+	// A take profit stored as 0.072340 reads back as 0.07233999999, which a plain comparison never reaches
+	const drifted = 0.07233999999
+	if drifted >= 0.07234 {
+		t.Fatal("the drifted price no longer differs from its level, the case below proves nothing")
+	}
+
+	tests := []struct {
+		name     string
+		price    float64
+		level    float64
+		gte, lte bool
+	}{
+		{"drifted below the level", drifted, 0.07234, true, true},
+		{"drifted above the level", 0.07234000001, 0.07234, true, true},
+		{"exactly at the level", 0.07234, 0.07234, true, true},
+		{"a tick below", 0.07233, 0.07234, false, true},
+		{"a tick above", 0.07235, 0.07234, true, false},
+		{"large price drifted", 64999.99999999, 65000, true, true},
+		{"large price a tick below", 64999.9, 65000, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PriceGTE(tt.price, tt.level); got != tt.gte {
+				t.Errorf("PriceGTE(%v, %v) = %v, want %v", tt.price, tt.level, got, tt.gte)
+			}
+			if got := PriceLTE(tt.price, tt.level); got != tt.lte {
+				t.Errorf("PriceLTE(%v, %v) = %v, want %v", tt.price, tt.level, got, tt.lte)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"time"
+)
+
+// CloseReasonRecoveredOffline marks a position closed at startup for a level its candles crossed while
+// the bot was down
+const CloseReasonRecoveredOffline = "recovered_offline"
+
+// Levels an OfflineExit can close at
+const (
+	OfflineLevelLiquidation = "liquidation"
+	OfflineLevelStopLoss    = "stop_loss"
+	OfflineLevelTakeProfit  = "take_profit"
+)
+
+// OfflineExit is where the candles a position missed say it closed
+type OfflineExit struct {
+	Level string // One of the OfflineLevel constants
+	Price float64
+	At    time.Time // Open time of the candle that crossed the level
+}
+
+// ReplayOffline walks the closed candles a position missed, oldest first, for the first one reaching its
+// liquidation, stop or target, checked in that order so a candle reaching several is read as the worst
+// outcome; a stop inside the liquidation price fills first on the way there, like the monitor reads it.
+// Between candles the stop moves to breakeven after breakevenAtR like the monitor moves it, 0 leaving it
+// where it is, and the position's MAE and MFE widen to each candle; it reports false when the position
+// is still open after the last candle
+func ReplayOffline(position *models.Position, candles []models.Price, breakevenAtR float64) (OfflineExit, bool) {
+	long := position.Side == models.PositionSideLong
+	for _, candle := range candles {
+		exit := OfflineExit{At: candle.OpenTime}
+		switch {
+		case LiquidatedBeforeStop(position.Side, position.StopLossPrice, position.LiquidationPrice, candle.Low, candle.High):
+			exit.Level, exit.Price = OfflineLevelLiquidation, position.LiquidationPrice
+		case position.StopLossPrice > 0 && crossed(!long, candle, position.StopLossPrice):
+			exit.Level, exit.Price = OfflineLevelStopLoss, position.StopLossPrice
+		case position.TakeProfitPrice > 0 && crossed(long, candle, position.TakeProfitPrice):
+			exit.Level, exit.Price = OfflineLevelTakeProfit, position.TakeProfitPrice
+		}
+		if exit.Level != "" {
+			TrackExcursion(position, candle.Low, candle.High, exit.Price)
+			return exit, true
+		}
+
+		TrackExcursion(position, candle.Low, candle.High)
+		if breakevenAtR == 0 {
+			continue
+		}
+		best := candle.High
+		if !long {
+			best = candle.Low
+		}
+		position.StopLossPrice = BreakevenStop(position.Side, position.EntryPrice, position.StopLossPrice,
+			position.InitialStopDistance, best, breakevenAtR)
+	}
+	return OfflineExit{}, false
+}
+
+// crossed reports whether candle reached level from below when up, from above otherwise
+func crossed(up bool, candle models.Price, level float64) bool {
+	if up {
+		return PriceGTE(candle.High, level)
+	}
+	return PriceLTE(candle.Low, level)
+}
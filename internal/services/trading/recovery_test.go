@@ -0,0 +1,93 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+	"time"
+)
+
+var offlineStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// offlineCandle returns the 5m candle i candles after offlineStart ranging from low to high
+func offlineCandle(i int, low, high float64) models.Price {
+	return models.Price{
+		TimeFrame: models.PriceTimeFrame5m,
+		OpenTime:  offlineStart.Add(time.Duration(i) * 5 * time.Minute),
+		Open:      (low + high) / 2,
+		High:      high,
+		Low:       low,
+		Close:     (low + high) / 2,
+	}
+}
+
+func TestReplayOffline(t *testing.T) {
+	tests := []struct {
+		name        string
+		side        string
+		stop        float64
+		target      float64
+		liquidation float64
+		candles     []models.Price
+		level       string
+		price       float64
+		at          int
+	}{
+		{"still open", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 99, 101), offlineCandle(1, 99.5, 101.5)}, "", 0, 0},
+		{"stop crossed while down", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 99, 101), offlineCandle(1, 98.5, 100), offlineCandle(2, 97.8, 99)}, OfflineLevelStopLoss, 98, 2},
+		{"target crossed while down", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 99, 101), offlineCandle(1, 101, 104.2)}, OfflineLevelTakeProfit, 104, 1},
+		{"stop and target on one candle read as the stop", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 97.9, 104.1)}, OfflineLevelStopLoss, 98, 0},
+		{"stop inside the liquidation distance fills first", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 99, 101), offlineCandle(1, 97, 99)}, OfflineLevelStopLoss, 98, 1},
+		{"stop beyond liquidation is liquidated", models.PositionSideLong, 97, 104, 97.5,
+			[]models.Price{offlineCandle(0, 96.8, 100)}, OfflineLevelLiquidation, 97.5, 0},
+		{"short stop inside the liquidation distance fills first", models.PositionSideShort, 102, 96, 102.5,
+			[]models.Price{offlineCandle(0, 100, 103)}, OfflineLevelStopLoss, 102, 0},
+		{"short stop beyond liquidation is liquidated", models.PositionSideShort, 103, 96, 102.5,
+			[]models.Price{offlineCandle(0, 100, 103.2)}, OfflineLevelLiquidation, 102.5, 0},
+		{"stop moved to breakeven on the way", models.PositionSideLong, 98, 104, 97.5,
+			[]models.Price{offlineCandle(0, 100.5, 102), offlineCandle(1, 99.9, 101)}, OfflineLevelStopLoss, 100, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position := &models.Position{
+				Side:             tt.side,
+				EntryPrice:       100,
+				StopLossPrice:    tt.stop,
+				TakeProfitPrice:  tt.target,
+				LiquidationPrice: tt.liquidation,
+			}
+			exit, closed := ReplayOffline(position, tt.candles, DefaultBreakevenAtR)
+			if closed != (tt.level != "") {
+				t.Fatalf("ReplayOffline() = %+v, closed %v, want closed %v", exit, closed, tt.level != "")
+			}
+			if !closed {
+				return
+			}
+			if want := offlineStart.Add(time.Duration(tt.at) * 5 * time.Minute); exit.Level != tt.level || exit.Price != tt.price || !exit.At.Equal(want) {
+				t.Errorf("ReplayOffline() = %+v, want %s at %v on the candle at %v", exit, tt.level, tt.price, want)
+			}
+		})
+	}
+}
+
+func TestReplayOfflineWithoutBreakeven(t *testing.T) {
+	position := &models.Position{
+		Side:             models.PositionSideLong,
+		EntryPrice:       100,
+		StopLossPrice:    98,
+		TakeProfitPrice:  104,
+		LiquidationPrice: 97.5,
+	}
+	// +1R then back under entry: with breakeven off the stop stays at 98 and the position open
+	candles := []models.Price{offlineCandle(0, 100.5, 102), offlineCandle(1, 99.9, 101)}
+	if exit, closed := ReplayOffline(position, candles, 0); closed {
+		t.Fatalf("ReplayOffline() = %+v, want the position still open", exit)
+	}
+	if position.StopLossPrice != 98 {
+		t.Errorf("stop = %v, want the original 98", position.StopLossPrice)
+	}
+}
@@ -0,0 +1,46 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"time"
+)
+
+// ReversalConfig guards against flip-flopping between long and short
+type ReversalConfig struct {
+	Enabled          bool
+	MinHold          time.Duration // Position must be open at least this long
+	MaxPerDay        int           // Reversals allowed per symbol per UTC day
+	ConfidenceMargin float64       // New signal must beat the open position's confidence by this much
+}
+
+// DefaultReversalConfig returns the default reversal guards
+func DefaultReversalConfig() ReversalConfig {
+	return ReversalConfig{
+		Enabled:          true,
+		MinHold:          15 * time.Minute,
+		MaxPerDay:        2,
+		ConfidenceMargin: 0.05,
+	}
+}
+
+// ShouldReverse decides whether result should flip an open position
+// It returns the reason when the reversal is refused
+func ShouldReverse(cfg ReversalConfig, side string, confidence float64, openTime time.Time,
+	result *analysis.AnalysisResult, now time.Time, reversalsToday int) (bool, string) {
+
+	switch {
+	case !cfg.Enabled:
+		return false, "reversals disabled"
+	case result == nil || !result.IsValid:
+		return false, "no signal"
+	case result.Direction == side || result.Direction == "":
+		return false, "same direction"
+	case result.Confidence < confidence+cfg.ConfidenceMargin:
+		return false, "confidence not above open position"
+	case now.Sub(openTime) < cfg.MinHold:
+		return false, "minimum hold time not reached"
+	case reversalsToday >= cfg.MaxPerDay:
+		return false, "daily reversal limit reached"
+	}
+	return true, ""
+}
@@ -0,0 +1,44 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/services/analysis"
+	"testing"
+	"time"
+)
+
+func TestShouldReverse(t *testing.T) {
+	opened := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	held := opened.Add(20 * time.Minute)
+	short := func(confidence float64) *analysis.AnalysisResult {
+		return &analysis.AnalysisResult{IsValid: true, Direction: "short", Confidence: confidence}
+	}
+	disabled := DefaultReversalConfig()
+	disabled.Enabled = false
+
+	tests := []struct {
+		name   string
+		cfg    ReversalConfig
+		result *analysis.AnalysisResult
+		now    time.Time
+		today  int
+		want   string
+	}{
+		{"reverses", DefaultReversalConfig(), short(0.8), held, 1, ""},
+		{"disabled", disabled, short(0.8), held, 0, "reversals disabled"},
+		{"no signal", DefaultReversalConfig(), nil, held, 0, "no signal"},
+		{"invalid signal", DefaultReversalConfig(), &analysis.AnalysisResult{Direction: "short", Confidence: 0.9}, held, 0, "no signal"},
+		{"same side", DefaultReversalConfig(), &analysis.AnalysisResult{IsValid: true, Direction: "long", Confidence: 0.9}, held, 0, "same direction"},
+		{"within the confidence margin", DefaultReversalConfig(), short(0.74), held, 0, "confidence not above open position"},
+		{"at the confidence margin", DefaultReversalConfig(), short(0.75), held, 0, ""},
+		{"held too briefly", DefaultReversalConfig(), short(0.8), opened.Add(14 * time.Minute), 0, "minimum hold time not reached"},
+		{"daily cap reached", DefaultReversalConfig(), short(0.8), held, 2, "daily reversal limit reached"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reason := ShouldReverse(tt.cfg, "long", 0.7, opened, tt.result, tt.now, tt.today)
+			if ok != (tt.want == "") || reason != tt.want {
+				t.Errorf("ShouldReverse() = %v, %q, want %q", ok, reason, tt.want)
+			}
+		})
+	}
+}
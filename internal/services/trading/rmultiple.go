@@ -0,0 +1,107 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"math"
+)
+
+// RBucketEdges split R outcomes into the buckets of RStats, each from one edge up to the next;
+// the outer buckets are open ended
+var RBucketEdges = []float64{-2, -1, 0, 1, 2, 3}
+
+// RMultiple returns pnl in units of initialRisk, what the trade stood to lose at its initial stop
+// It reports false when the initial risk is unknown or zero, the trade then has no R
+func RMultiple(pnl, initialRisk float64) (float64, bool) {
+	if initialRisk <= 0 || math.IsNaN(initialRisk) {
+		return 0, false
+	}
+	return pnl / initialRisk, true
+}
+
+// PositionRisk returns what the position stood to lose at its initial stop, in its quote asset
+func PositionRisk(position *models.Position) float64 {
+	distance := position.InitialStopDistance
+	if distance == 0 {
+		distance = InitialStopDistance(position.EntryPrice, position.StopLossPrice)
+	}
+	return position.Size * distance
+}
+
+// RecordRMultiple sets the R multiple of a position whose PnL was just set, nil when it has no R
+func RecordRMultiple(position *models.Position) {
+	position.RMultiple = nil
+	if r, ok := RMultiple(position.PnL, PositionRisk(position)); ok {
+		position.RMultiple = &r
+	}
+}
+
+// PositionR returns the R multiple of a closed position, computing it for positions closed before
+// it was recorded; it reports false when the position has no R
+func PositionR(position *models.Position) (float64, bool) {
+	if position.RMultiple != nil {
+		return *position.RMultiple, true
+	}
+	return RMultiple(position.PnL, PositionRisk(position))
+}
+
+// RBucket counts the outcomes in one range of R, see RBucketEdges
+type RBucket struct {
+	Label string `json:"label"` // e.g. "1R to 2R" or "below -2R"
+	Count int    `json:"count"`
+}
+
+// rBucket returns the index of the RBucketEdges range r falls in
+func rBucket(r float64) int {
+	for i, edge := range RBucketEdges {
+		if r < edge {
+			return i
+		}
+	}
+	return len(RBucketEdges)
+}
+
+// rBucketLabel names bucket i of RBucketEdges
+func rBucketLabel(i int) string {
+	switch {
+	case i == 0:
+		return fmt.Sprintf("below %gR", RBucketEdges[0])
+	case i == len(RBucketEdges):
+		return fmt.Sprintf("%gR and up", RBucketEdges[i-1])
+	}
+	return fmt.Sprintf("%gR to %gR", RBucketEdges[i-1], RBucketEdges[i])
+}
+
+// RStats is the distribution of trade outcomes in R
+type RStats struct {
+	Trades    int       `json:"trades"`   // Trades with an R
+	Excluded  int       `json:"excluded"` // Trades without one, their stop unset or at entry
+	Total     float64   `json:"total"`
+	Average   float64   `json:"average"`
+	AtLeast2R float64   `json:"at_least_2r"` // Fraction of Trades at 2R or better
+	Buckets   []RBucket `json:"buckets"`
+}
+
+// RDistribution summarizes the outcomes rs of the trades with an R, excluded counting those without
+func RDistribution(rs []float64, excluded int) RStats {
+	stats := RStats{Trades: len(rs), Excluded: excluded}
+
+	stats.Buckets = make([]RBucket, len(RBucketEdges)+1)
+	for i := range stats.Buckets {
+		stats.Buckets[i].Label = rBucketLabel(i)
+	}
+
+	wins := 0
+	for _, r := range rs {
+		stats.Total += r
+		if r >= 2 {
+			wins++
+		}
+		stats.Buckets[rBucket(r)].Count++
+	}
+	if len(rs) > 0 {
+		stats.Average = stats.Total / float64(len(rs))
+		stats.AtLeast2R = float64(wins) / float64(len(rs))
+	}
+	return stats
+}
@@ -0,0 +1,67 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"testing"
+)
+
+func TestRecordRMultiple(t *testing.T) {
+	// 2 units entered at 100 with the stop at 98 stand to lose 4
+	tests := []struct {
+		name     string
+		position models.Position
+		want     float64
+		ok       bool
+	}{
+		{"win at 2R", models.Position{EntryPrice: 100, StopLossPrice: 98, Size: 2, PnL: 8}, 2, true},
+		{"loss at the stop", models.Position{EntryPrice: 100, StopLossPrice: 98, Size: 2, PnL: -4}, -1, true},
+		{"short win", models.Position{EntryPrice: 100, StopLossPrice: 101, Size: 2, PnL: 3}, 1.5, true},
+		// The stop trailed to 99.5, R stays measured against the initial distance
+		{"trailed stop", models.Position{EntryPrice: 100, StopLossPrice: 99.5, InitialStopDistance: 2, Size: 2, PnL: 6}, 1.5, true},
+		{"stop at entry", models.Position{EntryPrice: 100, StopLossPrice: 100, Size: 2, PnL: 3}, 0, false},
+		{"stop never set", models.Position{EntryPrice: 0, Size: 2, PnL: 3}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position := tt.position
+			RecordRMultiple(&position)
+			if (position.RMultiple != nil) != tt.ok {
+				t.Fatalf("RMultiple = %v, want set %v", position.RMultiple, tt.ok)
+			}
+			if tt.ok && math.Abs(*position.RMultiple-tt.want) > 1e-9 {
+				t.Errorf("RMultiple = %v, want %v", *position.RMultiple, tt.want)
+			}
+
+			// Positions closed before R was recorded get it computed
+			position.RMultiple = nil
+			if r, ok := PositionR(&position); ok != tt.ok || (ok && math.Abs(r-tt.want) > 1e-9) {
+				t.Errorf("PositionR() = %v, %v, want %v, %v", r, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRDistribution(t *testing.T) {
+	stats := RDistribution([]float64{2, -1, 0.5, 3.2, -2.5}, 1)
+	if stats.Trades != 5 || stats.Excluded != 1 {
+		t.Errorf("%d trades and %d excluded, want 5 and 1", stats.Trades, stats.Excluded)
+	}
+	if math.Abs(stats.Total-2.2) > 1e-9 || math.Abs(stats.Average-0.44) > 1e-9 || math.Abs(stats.AtLeast2R-0.4) > 1e-9 {
+		t.Errorf("total %v, average %v, at least 2R %v, want 2.2, 0.44 and 0.4", stats.Total, stats.Average, stats.AtLeast2R)
+	}
+
+	want := map[string]int{"below -2R": 1, "-1R to 0R": 1, "0R to 1R": 1, "2R to 3R": 1, "3R and up": 1}
+	if len(stats.Buckets) != len(RBucketEdges)+1 {
+		t.Fatalf("%d buckets, want %d", len(stats.Buckets), len(RBucketEdges)+1)
+	}
+	for _, bucket := range stats.Buckets {
+		if bucket.Count != want[bucket.Label] {
+			t.Errorf("bucket %s holds %d, want %d", bucket.Label, bucket.Count, want[bucket.Label])
+		}
+	}
+
+	if empty := RDistribution(nil, 3); empty.Average != 0 || empty.AtLeast2R != 0 || empty.Excluded != 3 {
+		t.Errorf("RDistribution() of no trades = %+v", empty)
+	}
+}
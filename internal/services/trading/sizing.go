@@ -0,0 +1,132 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Guards a position's size can be changed by, recorded on the position as SizeGuard
+const (
+	SizeGuardMinNotional = "min_notional" // Raised to the least position value
+	SizeGuardMaxNotional = "max_notional" // Cut to the most position value
+	SizeGuardMarginCap   = "margin_cap"   // Cut to the share of the balance one position may use as margin
+	SizeGuardStepSize    = "step_size"    // Rounded down to the exchange's quantity step
+)
+
+// ErrSizeRejected is returned by SizingConfig.Guard when no size within the bounds can be traded
+var ErrSizeRejected = errors.New("position size rejected")
+
+// SizingConfig bounds the size the sizing formula asks for, zero bounds are off
+type SizingConfig struct {
+	MinNotional       float64 `json:"min_notional"`        // Least position value in the quote asset, smaller sizes are raised to it
+	MaxNotional       float64 `json:"max_notional"`        // Most position value in the quote asset, larger sizes are cut to it
+	MaxMarginFraction float64 `json:"max_margin_fraction"` // Most of the balance one position's margin may use
+}
+
+// DefaultSizingConfig raises positions to Binance's 5 USDT minimum notional and keeps one position's margin
+// to half the balance
+func DefaultSizingConfig() SizingConfig {
+	return SizingConfig{MinNotional: 5, MaxMarginFraction: 0.5}
+}
+
+// Validate checks the bounds are not negative, the minimum is below the maximum and the margin cap is a fraction
+func (c SizingConfig) Validate() error {
+	if c.MinNotional < 0 || c.MaxNotional < 0 || c.MaxMarginFraction < 0 {
+		return fmt.Errorf("position size bounds cannot be negative")
+	}
+	if c.MaxNotional > 0 && c.MinNotional > c.MaxNotional {
+		return fmt.Errorf("minimum notional %.2f is above the maximum %.2f", c.MinNotional, c.MaxNotional)
+	}
+	if c.MaxMarginFraction > 1 {
+		return fmt.Errorf("margin cap %.2f is above the whole balance, it is a fraction (0.5 for half)", c.MaxMarginFraction)
+	}
+	return nil
+}
+
+// SizeDecision is a position size after the guards, next to the size asked for
+type SizeDecision struct {
+	Requested float64
+	Granted   float64
+	Reason    string // Last guard that changed the size, "" when none did
+}
+
+// Guard bounds size, in units of symbol's base asset at price, for an account holding balance at leverage:
+// the notional is cut to MaxNotional and the margin to MaxMarginFraction of the balance, then raised to
+// MinNotional, then rounded down to the symbol's step size when known (up, when down would fall below
+// MinNotional). It returns ErrSizeRejected when the minimum, or the size rounded up to it, needs more margin than
+// the cap or the balance allow, or nothing is left after rounding
+func (c SizingConfig) Guard(symbol string, size, price, balance float64, leverage int) (SizeDecision, error) {
+	decision := SizeDecision{Requested: size, Granted: size}
+	if !(size > 0) || !(price > 0) || math.IsInf(size, 0) || leverage <= 0 {
+		return decision, fmt.Errorf("%w: size %v at price %v", ErrSizeRejected, size, price)
+	}
+	marginOf := func(size float64) float64 { return size * price / float64(leverage) }
+
+	if c.MaxNotional > 0 && decision.Granted*price > c.MaxNotional {
+		decision.Granted, decision.Reason = c.MaxNotional/price, SizeGuardMaxNotional
+	}
+	maxMargin := math.Inf(1)
+	if c.MaxMarginFraction > 0 {
+		maxMargin = math.Max(balance, 0) * c.MaxMarginFraction
+	}
+	if marginOf(decision.Granted) > maxMargin {
+		decision.Granted, decision.Reason = maxMargin*float64(leverage)/price, SizeGuardMarginCap
+	}
+	if c.MinNotional > 0 && decision.Granted*price < c.MinNotional {
+		minimum := c.MinNotional / price
+		if margin := marginOf(minimum); margin > maxMargin || margin > balance {
+			return decision, fmt.Errorf("%w: the %.2f minimum notional needs %.4f margin, balance %.4f allows %.4f",
+				ErrSizeRejected, c.MinNotional, margin, balance, math.Min(maxMargin, balance))
+		}
+		decision.Granted, decision.Reason = minimum, SizeGuardMinNotional
+	}
+
+	bounded := decision.Granted
+	if step := StepSize(symbol); step > 0 {
+		rounded := math.Floor(decision.Granted/step+1e-9) * step
+		if c.MinNotional > 0 && rounded*price < c.MinNotional {
+			rounded = math.Ceil(decision.Granted/step-1e-9) * step
+		}
+		if rounded != decision.Granted {
+			decision.Granted = rounded
+			if decision.Reason == "" {
+				decision.Reason = SizeGuardStepSize
+			}
+		}
+	}
+	if !(decision.Granted > 0) {
+		return decision, fmt.Errorf("%w: size %v rounds to nothing at step %v", ErrSizeRejected, size, StepSize(symbol))
+	}
+	// Rounding up to the minimum can take the margin past what the cap or the balance allow
+	if margin := marginOf(decision.Granted); decision.Granted > bounded && (margin > maxMargin || margin > balance) {
+		return decision, fmt.Errorf("%w: size %v rounded to step %v needs %.4f margin, balance %.4f allows %.4f",
+			ErrSizeRejected, decision.Granted, StepSize(symbol), margin, balance, math.Min(maxMargin, balance))
+	}
+	return decision, nil
+}
+
+var (
+	stepSizesMu sync.RWMutex
+	stepSizes   = map[string]float64{}
+)
+
+// SetStepSizes records each symbol's quantity step size from the exchange info, replacing earlier ones
+func SetStepSizes(sizes map[string]float64) {
+	stepSizesMu.Lock()
+	defer stepSizesMu.Unlock()
+	stepSizes = make(map[string]float64, len(sizes))
+	for symbol, size := range sizes {
+		if size > 0 {
+			stepSizes[symbol] = size
+		}
+	}
+}
+
+// StepSize returns the symbol's quantity step size, 0 when unknown
+func StepSize(symbol string) float64 {
+	stepSizesMu.RLock()
+	defer stepSizesMu.RUnlock()
+	return stepSizes[symbol]
+}
@@ -0,0 +1,72 @@
+package trading
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSizingGuard(t *testing.T) {
+	SetStepSizes(map[string]float64{"STEPUSDT": 0.001, "COARSEUSDT": 0.03})
+	t.Cleanup(func() { SetStepSizes(nil) })
+
+	defaults := DefaultSizingConfig() // 5 USDT minimum, half the balance as margin
+	tests := []struct {
+		name    string
+		config  SizingConfig
+		symbol  string
+		size    float64
+		balance float64
+		want    float64
+		reason  string
+		wantErr bool
+	}{
+		{"within bounds", defaults, "BTCUSDT", 0.5, 10, 0.5, "", false},
+		{"tiny balance raised to the minimum", defaults, "BTCUSDT", 0.001, 1, 0.05, SizeGuardMinNotional, false},
+		{"tiny balance below the minimum's margin", defaults, "BTCUSDT", 0.001, 0.05, 0, "", true},
+		{"huge risk cut to the margin cap", defaults, "BTCUSDT", 500, 10, 2.5, SizeGuardMarginCap, false},
+		{"max notional hit", SizingConfig{MaxNotional: 100}, "BTCUSDT", 5, 10, 1, SizeGuardMaxNotional, false},
+		{"rounded down to the step", defaults, "STEPUSDT", 0.12345, 10, 0.123, SizeGuardStepSize, false},
+		{"rounded up past the margin cap", defaults, "COARSEUSDT", 0.001, 0.2, 0, "", true},
+		{"rounded up past the balance", SizingConfig{MinNotional: 5}, "COARSEUSDT", 0.001, 0.1, 0, "", true},
+		{"no size", defaults, "BTCUSDT", 0, 10, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 100 USDT a unit at 50x: a size of 1 puts up 2 USDT of margin
+			decision, err := tt.config.Guard(tt.symbol, tt.size, 100, tt.balance, 50)
+			if tt.wantErr {
+				if !errors.Is(err, ErrSizeRejected) {
+					t.Fatalf("Guard() = %+v, %v, want ErrSizeRejected", decision, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(decision.Granted-tt.want) > 1e-9 || decision.Reason != tt.reason || decision.Requested != tt.size {
+				t.Errorf("Guard() = %+v, want %v granted of %v for %q", decision, tt.want, tt.size, tt.reason)
+			}
+		})
+	}
+}
+
+func TestSizingConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  SizingConfig
+		wantErr bool
+	}{
+		{"default", DefaultSizingConfig(), false},
+		{"negative", SizingConfig{MinNotional: -1}, true},
+		{"minimum above maximum", SizingConfig{MinNotional: 10, MaxNotional: 5}, true},
+		{"margin cap as a percentage", SizingConfig{MaxMarginFraction: 50}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
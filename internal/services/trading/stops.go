@@ -0,0 +1,113 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"fmt"
+	"math"
+)
+
+// DefaultBreakevenAtR moves the stop to entry once price has gone this many initial stop
+// distances in favor of the trade, see TradeConfig.BreakevenAtR
+const DefaultBreakevenAtR = 1.0
+
+// InitialStopDistance returns the distance between entry and the original stop
+func InitialStopDistance(entryPrice, stopLossPrice float64) float64 {
+	return math.Abs(entryPrice - stopLossPrice)
+}
+
+// BreakevenStop returns the stop price after applying the breakeven rule for the
+// best price reached so far. The stop only ever tightens, so whichever stop is
+// tighter wins when other stop adjustments are in play.
+func BreakevenStop(side string, entryPrice, stopLossPrice, initialStopDistance, bestPrice, atR float64) float64 {
+	if atR <= 0 || initialStopDistance <= 0 {
+		return stopLossPrice
+	}
+
+	trigger := initialStopDistance * atR
+	if side == models.PositionSideLong {
+		if bestPrice-entryPrice >= trigger {
+			return math.Max(stopLossPrice, entryPrice)
+		}
+		return stopLossPrice
+	}
+
+	if entryPrice-bestPrice >= trigger {
+		return math.Min(stopLossPrice, entryPrice)
+	}
+	return stopLossPrice
+}
+
+// StopUpdate moves an open position's stop loss and take profit, a zero level is left as it is
+type StopUpdate struct {
+	StopLoss   float64
+	TakeProfit float64
+	Reason     string // Recorded on the StopChange, e.g. the strategy name or "manual"
+	Override   bool   // Allow the stop to loosen, away from price
+}
+
+// ValidateStops checks the levels a position on side may move to with price at price:
+// the stop only tightens unless override is set, and both levels stay on their side of price
+func ValidateStops(side string, stopLoss, newStopLoss, newTakeProfit, price float64, override bool) error {
+	if newStopLoss <= 0 || newTakeProfit <= 0 {
+		return fmt.Errorf("stop loss and take profit must be positive")
+	}
+	if side == models.PositionSideLong {
+		if !override && newStopLoss < stopLoss {
+			return fmt.Errorf("stop loss %.8f would loosen the stop at %.8f", newStopLoss, stopLoss)
+		}
+		if PriceGTE(newStopLoss, price) {
+			return fmt.Errorf("stop loss %.8f is not below price %.8f", newStopLoss, price)
+		}
+		if PriceLTE(newTakeProfit, price) {
+			return fmt.Errorf("take profit %.8f is not above price %.8f", newTakeProfit, price)
+		}
+		return nil
+	}
+
+	if !override && newStopLoss > stopLoss {
+		return fmt.Errorf("stop loss %.8f would loosen the stop at %.8f", newStopLoss, stopLoss)
+	}
+	if PriceLTE(newStopLoss, price) {
+		return fmt.Errorf("stop loss %.8f is not above price %.8f", newStopLoss, price)
+	}
+	if PriceGTE(newTakeProfit, price) {
+		return fmt.Errorf("take profit %.8f is not below price %.8f", newTakeProfit, price)
+	}
+	return nil
+}
+
+// ApplyStopUpdate validates update against the position at price and moves its levels, returning the
+// change to record; it returns nil when the levels stay where they are
+func ApplyStopUpdate(position *models.Position, update StopUpdate, price float64) (*models.StopChange, error) {
+	stopLoss, takeProfit := position.StopLossPrice, position.TakeProfitPrice
+	if update.StopLoss != 0 {
+		stopLoss = update.StopLoss
+	}
+	if update.TakeProfit != 0 {
+		takeProfit = update.TakeProfit
+	}
+	if stopLoss == position.StopLossPrice && takeProfit == position.TakeProfitPrice {
+		return nil, nil
+	}
+	if err := ValidateStops(position.Side, position.StopLossPrice, stopLoss, takeProfit, price, update.Override); err != nil {
+		return nil, err
+	}
+
+	// R stays measured against the stop the position opened with
+	if position.InitialStopDistance == 0 {
+		position.InitialStopDistance = InitialStopDistance(position.EntryPrice, position.StopLossPrice)
+	}
+
+	change := &models.StopChange{
+		PositionID:    position.ID,
+		OldStopLoss:   position.StopLossPrice,
+		NewStopLoss:   stopLoss,
+		OldTakeProfit: position.TakeProfitPrice,
+		NewTakeProfit: takeProfit,
+		Price:         price,
+		Reason:        update.Reason,
+		Override:      update.Override,
+	}
+	position.StopLossPrice, position.TakeProfitPrice = stopLoss, takeProfit
+	return change, nil
+}
@@ -0,0 +1,106 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/models"
+	"testing"
+)
+
+func TestBreakevenStop(t *testing.T) {
+	tests := []struct {
+		name      string
+		side      string
+		stop      float64
+		bestPrice float64
+		atR       float64
+		want      float64
+	}{
+		{"long below 1R keeps the stop", models.PositionSideLong, 98, 101.9, 1, 98},
+		{"long at 1R moves to entry", models.PositionSideLong, 98, 102, 1, 100},
+		{"long keeps a tighter stop", models.PositionSideLong, 100.5, 103, 1, 100.5},
+		{"short at 1R moves to entry", models.PositionSideShort, 102, 98, 1, 100},
+		{"short below 1R keeps the stop", models.PositionSideShort, 102, 98.1, 1, 102},
+		{"short keeps a tighter stop", models.PositionSideShort, 99.5, 97, 1, 99.5},
+		{"long at 2R with atR 2", models.PositionSideLong, 98, 104, 2, 100},
+		{"long at 1R with atR 2", models.PositionSideLong, 98, 102, 2, 98},
+		{"disabled", models.PositionSideLong, 98, 110, 0, 98},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := InitialStopDistance(100, 98)
+			if got := BreakevenStop(tt.side, 100, tt.stop, distance, tt.bestPrice, tt.atR); got != tt.want {
+				t.Errorf("BreakevenStop() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakevenStopWithoutInitialDistance(t *testing.T) {
+	if got := BreakevenStop(models.PositionSideLong, 100, 98, 0, 120, 1); got != 98 {
+		t.Errorf("BreakevenStop() = %v, want the stop left at 98", got)
+	}
+}
+
+func TestApplyStopUpdate(t *testing.T) {
+	// Positions entered at 100 with price now at 103 for the long and 97 for the short
+	tests := []struct {
+		name       string
+		side       string
+		update     StopUpdate
+		wantStop   float64
+		wantTarget float64
+		wantErr    bool
+	}{
+		{"long tightens", models.PositionSideLong, StopUpdate{StopLoss: 101}, 101, 110, false},
+		{"long moves its target", models.PositionSideLong, StopUpdate{TakeProfit: 106}, 98, 106, false},
+		{"long loosens", models.PositionSideLong, StopUpdate{StopLoss: 97}, 98, 110, true},
+		{"long loosens with override", models.PositionSideLong, StopUpdate{StopLoss: 97, Override: true}, 97, 110, false},
+		{"long stop at price", models.PositionSideLong, StopUpdate{StopLoss: 103}, 98, 110, true},
+		{"long target below price", models.PositionSideLong, StopUpdate{TakeProfit: 102}, 98, 110, true},
+		{"short tightens", models.PositionSideShort, StopUpdate{StopLoss: 99}, 99, 90, false},
+		{"short loosens", models.PositionSideShort, StopUpdate{StopLoss: 103}, 102, 90, true},
+		{"short stop below price", models.PositionSideShort, StopUpdate{StopLoss: 96, Override: true}, 102, 90, true},
+		{"short target above price", models.PositionSideShort, StopUpdate{TakeProfit: 98}, 102, 90, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position := &models.Position{ID: 7, Side: tt.side, EntryPrice: 100, StopLossPrice: 98, TakeProfitPrice: 110}
+			price := 103.0
+			if tt.side == models.PositionSideShort {
+				position.StopLossPrice, position.TakeProfitPrice = 102, 90
+				price = 97
+			}
+			oldStop, oldTarget := position.StopLossPrice, position.TakeProfitPrice
+
+			change, err := ApplyStopUpdate(position, tt.update, price)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyStopUpdate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if position.StopLossPrice != tt.wantStop || position.TakeProfitPrice != tt.wantTarget {
+				t.Errorf("levels = %v, %v, want %v, %v", position.StopLossPrice, position.TakeProfitPrice, tt.wantStop, tt.wantTarget)
+			}
+			if tt.wantErr {
+				if change != nil {
+					t.Errorf("rejected update recorded %+v", change)
+				}
+				return
+			}
+			want := models.StopChange{PositionID: 7, OldStopLoss: oldStop, NewStopLoss: tt.wantStop, OldTakeProfit: oldTarget,
+				NewTakeProfit: tt.wantTarget, Price: price, Override: tt.update.Override}
+			if change == nil || *change != want {
+				t.Errorf("change = %+v, want %+v", change, want)
+			}
+			// R stays measured against the opening stop
+			if position.InitialStopDistance != 2 {
+				t.Errorf("InitialStopDistance = %v, want 2", position.InitialStopDistance)
+			}
+		})
+	}
+}
+
+func TestApplyStopUpdateLeavingTheLevelsRecordsNothing(t *testing.T) {
+	position := &models.Position{Side: models.PositionSideLong, EntryPrice: 100, StopLossPrice: 98, TakeProfitPrice: 110}
+	change, err := ApplyStopUpdate(position, StopUpdate{StopLoss: 98}, 103)
+	if err != nil || change != nil {
+		t.Errorf("ApplyStopUpdate() = %+v, %v, want nothing to record", change, err)
+	}
+}
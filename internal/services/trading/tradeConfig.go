@@ -0,0 +1,150 @@
+package trading
+
+import (
+	"CryptoTradeBot/internal/services/risk"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// TradeConfig is every entry, sizing and risk setting live trading and backtests share
+type TradeConfig struct {
+	Entry    EntryConfig
+	Reversal ReversalConfig
+
+	Performance *risk.PerformanceConfig // Suspends entries on losing symbols, nil to disable
+	Scaling     *risk.ScalingConfig     // Sizes trades by the win or loss streak, nil for fixed size
+	EquityStop  *risk.EquityStopConfig  // Flattens and stops entering below the equity floor, nil to disable
+
+	Frequency risk.FrequencyConfig
+	Sizing    SizingConfig
+
+	// Initial stop distances in favor after which the stop moves to entry, 0 disables
+	BreakevenAtR float64
+}
+
+// TradeFlags are the command line flags a TradeConfig is read from
+type TradeFlags struct {
+	entryMode   string
+	limitOffset float64
+	limitExpiry int
+	hedgeMode   bool
+
+	breakevenAtR float64
+
+	reversals       bool
+	reversalMinHold time.Duration
+	reversalMargin  float64
+	maxReversals    int
+
+	performanceGuard bool
+	minExpectancy    float64
+	minTrades        int
+	probation        time.Duration
+
+	riskScaling   bool
+	winStreak     int
+	winStreakRisk float64
+
+	equityStop         float64
+	maxTradesPerSymbol int
+	maxTradesPerDay    int
+
+	minNotional       float64
+	maxNotional       float64
+	maxMarginFraction float64
+}
+
+// RegisterFlags defines the flags on fs, defaulting to the default settings
+func (f *TradeFlags) RegisterFlags(fs *flag.FlagSet) {
+	entry, reversal := DefaultEntryConfig(), DefaultReversalConfig()
+	performance, sizing := risk.DefaultPerformanceConfig(), DefaultSizingConfig()
+
+	fs.StringVar(&f.entryMode, "entry-mode", EntryModeMarket, "Entry order type: 'market' or 'limit'")
+	fs.Float64Var(&f.limitOffset, "limit-offset", entry.LimitOffset, "Limit entry offset from the signal price, as a fraction")
+	fs.IntVar(&f.limitExpiry, "limit-expiry", entry.ExpiryCandles, "5m candles before an unfilled limit entry expires")
+	fs.BoolVar(&f.hedgeMode, "hedge-mode", false, "Allow a long and a short position on the same symbol at once; disables reversals")
+
+	fs.Float64Var(&f.breakevenAtR, "breakeven-at-r", DefaultBreakevenAtR, "Move the stop to entry once price has gone this many initial stop distances in favor, 0 to disable")
+
+	fs.BoolVar(&f.reversals, "reversals", reversal.Enabled, "Reverse open positions on strong opposite signals")
+	fs.DurationVar(&f.reversalMinHold, "reversal-min-hold", reversal.MinHold, "Minimum time a position is held before it may be reversed")
+	fs.Float64Var(&f.reversalMargin, "reversal-margin", reversal.ConfidenceMargin, "Confidence an opposite signal must beat the open position's by to reverse it")
+	fs.IntVar(&f.maxReversals, "max-reversals", reversal.MaxPerDay, "Maximum reversals per symbol per UTC day")
+
+	fs.BoolVar(&f.performanceGuard, "performance-guard", true, "Suspend entries on symbols whose recent expectancy is below -min-expectancy")
+	fs.Float64Var(&f.minExpectancy, "min-expectancy", performance.MinExpectancy, "Average PnL per trade, in the quote asset, below which a symbol is suspended")
+	fs.IntVar(&f.minTrades, "min-trades", performance.MinTrades, "Closed trades needed before a symbol can be suspended")
+	fs.DurationVar(&f.probation, "probation", performance.Probation, "How long a suspended symbol stays suspended")
+
+	fs.BoolVar(&f.riskScaling, "risk-scaling", true, "Scale position size by the account's streak: half size after two losses in a row, back to full after a win")
+	fs.IntVar(&f.winStreak, "win-streak", 0, "Consecutive wins after which risk rises to -win-streak-risk, 0 to never raise it")
+	fs.Float64Var(&f.winStreakRisk, "win-streak-risk", 0.03, "Risk per trade during a winning streak, against the 2% a full size position stands for")
+
+	fs.Float64Var(&f.equityStop, "equity-stop", risk.DefaultEquityStopConfig().Floor, "Flatten the account and block entries until the next UTC day once equity falls below this fraction of the day's starting equity, 0 to disable")
+	fs.IntVar(&f.maxTradesPerSymbol, "max-trades-per-symbol-per-day", 0, "Positions a symbol may open per UTC day, 0 for no limit")
+	fs.IntVar(&f.maxTradesPerDay, "max-trades-per-day", 0, "Positions the account may open per UTC day over every symbol, 0 for no limit")
+
+	fs.Float64Var(&f.minNotional, "min-notional", sizing.MinNotional, "Least position value in the quote asset, smaller positions are raised to it or refused when the margin cap does not allow it, 0 for no floor")
+	fs.Float64Var(&f.maxNotional, "max-notional", 0, "Most position value in the quote asset, larger positions are cut to it, 0 for no ceiling")
+	fs.Float64Var(&f.maxMarginFraction, "max-margin-fraction", sizing.MaxMarginFraction, "Most of the balance one position's margin may use, 0 for no cap")
+}
+
+// Config validates the parsed flags into a TradeConfig
+func (f *TradeFlags) Config() (TradeConfig, error) {
+	if f.entryMode != EntryModeMarket && f.entryMode != EntryModeLimit {
+		return TradeConfig{}, fmt.Errorf("invalid entry mode %q, use 'market' or 'limit'", f.entryMode)
+	}
+	if f.breakevenAtR < 0 {
+		return TradeConfig{}, fmt.Errorf("breakeven-at-r must not be negative, got %v", f.breakevenAtR)
+	}
+	config := TradeConfig{
+		Entry: EntryConfig{
+			Mode:          f.entryMode,
+			LimitOffset:   f.limitOffset,
+			ExpiryCandles: f.limitExpiry,
+			HedgeMode:     f.hedgeMode,
+		},
+		Reversal:  DefaultReversalConfig(),
+		Frequency: risk.FrequencyConfig{MaxTradesPerSymbolPerDay: f.maxTradesPerSymbol, MaxTradesPerDay: f.maxTradesPerDay},
+		Sizing:    SizingConfig{MinNotional: f.minNotional, MaxNotional: f.maxNotional, MaxMarginFraction: f.maxMarginFraction},
+
+		BreakevenAtR: f.breakevenAtR,
+	}
+	config.Reversal.Enabled = f.reversals
+	config.Reversal.MinHold = f.reversalMinHold
+	config.Reversal.MaxPerDay = f.maxReversals
+	config.Reversal.ConfidenceMargin = f.reversalMargin
+
+	if f.performanceGuard {
+		performance := risk.DefaultPerformanceConfig()
+		performance.MinExpectancy = f.minExpectancy
+		performance.MinTrades = f.minTrades
+		performance.Probation = f.probation
+		config.Performance = &performance
+	}
+	if f.riskScaling {
+		scaling := risk.DefaultScalingConfig()
+		if f.winStreak > 0 {
+			scaling = scaling.WithWinStreak(f.winStreak, f.winStreakRisk)
+		}
+		if err := scaling.Validate(); err != nil {
+			return TradeConfig{}, err
+		}
+		config.Scaling = &scaling
+	}
+	if f.equityStop > 0 {
+		equityStop := risk.EquityStopConfig{Floor: f.equityStop}
+		if err := equityStop.Validate(); err != nil {
+			return TradeConfig{}, err
+		}
+		config.EquityStop = &equityStop
+	}
+	if err := config.Frequency.Validate(); err != nil {
+		return TradeConfig{}, err
+	}
+	if err := config.Sizing.Validate(); err != nil {
+		return TradeConfig{}, err
+	}
+	return config, nil
+}
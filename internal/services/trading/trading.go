@@ -1,6 +1,7 @@
 package trading
 
 import (
+	"CryptoTradeBot/internal/clock"
 	"CryptoTradeBot/internal/models"
 	"CryptoTradeBot/internal/repositories"
 	"CryptoTradeBot/internal/services/analysis"
@@ -13,73 +14,105 @@ import (
 type PaperTrader struct {
 	positionRepo *repositories.PositionRepository
 	priceRepo    *repositories.PriceRepository
-	balanceRepo  *repositories.BalanceRepository
+	account      *AccountService
+	closes       *CloseRetries // Closes that failed, retried by the monitor
+	clock        clock.Clock
+	breakevenAtR float64 // See TradeConfig.BreakevenAtR
+}
+
+// NewPaperTrader creates a new instance of PaperTrader, timed by clk
+func NewPaperTrader(positionRepo *repositories.PositionRepository, priceRepo *repositories.PriceRepository,
+	account *AccountService, clk clock.Clock) *PaperTrader {
+	return &PaperTrader{
+		positionRepo: positionRepo,
+		priceRepo:    priceRepo,
+		account:      account,
+		closes:       NewCloseRetries(),
+		clock:        clk,
+		breakevenAtR: DefaultBreakevenAtR,
+	}
+}
+
+// UseBreakeven moves stops to entry after atR initial stop distances in favor, 0 disables
+func (t *PaperTrader) UseBreakeven(atR float64) {
+	t.breakevenAtR = atR
 }
 
 const (
-	InitialBalance = 10.0 // USDT - Match backtesting initial balance
+	InitialBalance = 10.0 // Quote asset - Match backtesting initial balance
 	Leverage       = 50   // Fixed leverage
 	FixedSize      = 1.0  // $1 per trade - Fixed dollar amount instead of percentage
 )
 
-func (t *PaperTrader) OpenPosition(result *analysis.AnalysisResult) error {
+func (t *PaperTrader) OpenPosition(ctx context.Context, result *analysis.AnalysisResult) error {
 	// Get current balance for validation only
-	balance, err := t.balanceRepo.FindBySymbol("USDT")
+	balance, err := t.account.BalanceFor(result.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get balance: %v", err)
 	}
 
 	// Ensure we have enough balance
 	if balance.Balance < FixedSize {
-		return fmt.Errorf("insufficient balance: %.2f USDT", balance.Balance)
+		return fmt.Errorf("insufficient balance: %.2f %s", balance.Balance, balance.Symbol)
 	}
 
 	// Calculate position size based on fixed $1 per trade
 	positionSize := (FixedSize / result.EntryPrice) * float64(Leverage)
 
 	position := &models.Position{
-		Symbol:          result.Symbol,
-		Side:            result.Direction,
-		Size:            positionSize,
-		Leverage:        Leverage,
-		EntryPrice:      result.EntryPrice,
-		StopLossPrice:   result.StopLoss,
-		TakeProfitPrice: result.TakeProfit,
-		OpenTime:        time.Now(),
-		Status:          models.PositionStatusOpen,
-		PnL:             0,
-		CreatedAt:       time.Now(),
-		UpdatedAt:       time.Now(),
-	}
-
-	return t.positionRepo.Create(position)
+		Symbol:              result.Symbol,
+		Side:                result.Direction,
+		Size:                positionSize,
+		Leverage:            Leverage,
+		EntryPrice:          result.EntryPrice,
+		StopLossPrice:       result.StopLoss,
+		TakeProfitPrice:     result.TakeProfit,
+		InitialStopDistance: InitialStopDistance(result.EntryPrice, result.StopLoss),
+		LiquidationPrice:    PositionLiquidationPrice(result.Symbol, result.Direction, result.EntryPrice, positionSize, Leverage),
+		OpenTime:            t.clock.Now(),
+		Status:              models.PositionStatusOpen,
+		PnL:                 0,
+		CreatedAt:           t.clock.Now(),
+		UpdatedAt:           t.clock.Now(),
+	}
+	if StopBeyondLiquidation(position.Side, position.StopLossPrice, position.LiquidationPrice) {
+		log.Printf("Warning: %s %s stop %.8f is beyond liquidation %.8f",
+			position.Symbol, position.Side, position.StopLossPrice, position.LiquidationPrice)
+	}
+
+	return t.positionRepo.Create(ctx, position)
 }
 
 // MonitorPositions checks open positions for take profit or stop loss
 func (t *PaperTrader) MonitorPositions(ctx context.Context) {
-	ticker := time.NewTicker(time.Second * 15)
+	ticker := t.clock.NewTicker(time.Second * 15)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			if err := t.checkOpenPositions(); err != nil {
+		case <-ticker.C():
+			t.retryCloses()
+			if err := t.checkOpenPositions(ctx); err != nil {
 				log.Printf("Error checking positions: %v", err)
 			}
 		}
 	}
 }
 
-func (t *PaperTrader) checkOpenPositions() error {
+func (t *PaperTrader) checkOpenPositions(ctx context.Context) error {
 	positions, err := t.positionRepo.FindOpenPositions()
 	if err != nil {
 		return fmt.Errorf("failed to get open positions: %v", err)
 	}
 
 	for i := range positions {
-		if err := t.checkPosition(&positions[i]); err != nil {
+		// An exit that already triggered is retried as decided, not re-evaluated
+		if t.closes.Pending(positions[i].ID) {
+			continue
+		}
+		if err := t.checkPosition(ctx, &positions[i]); err != nil {
 			log.Printf("Error checking position %d: %v", positions[i].ID, err)
 		}
 	}
@@ -87,10 +120,33 @@ func (t *PaperTrader) checkOpenPositions() error {
 	return nil
 }
 
+// retryCloses attempts again the closes that failed on an earlier pass and are due
+func (t *PaperTrader) retryCloses() {
+	for id, pending := range t.closes.Due(t.clock.Now()) {
+		position, err := t.positionRepo.FindByID(id)
+		if err != nil {
+			t.closes.Failed(id, pending, t.clock.Now())
+			log.Printf("Error loading position %d to retry its close: %v", id, err)
+			continue
+		}
+		// The earlier attempt may have landed with only its response lost
+		if position == nil || position.Status != models.PositionStatusOpen {
+			t.closes.Done(id)
+			continue
+		}
+
+		position.CloseReason = pending.Reason
+		position.CloseTime = pending.At
+		if err := t.closePosition(position, pending.Price, pending.PnL); err != nil {
+			log.Printf("Error closing position %d: %v", id, err)
+		}
+	}
+}
+
 // checkPosition expects a pointer to Position
-func (t *PaperTrader) checkPosition(position *models.Position) error {
+func (t *PaperTrader) checkPosition(ctx context.Context, position *models.Position) error {
 	// Get current price
-	latest, err := t.priceRepo.GetLatestPrice(position.Symbol)
+	latest, err := t.priceRepo.GetLatestPrice(ctx, position.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to get price: %v", err)
 	}
@@ -99,53 +155,97 @@ func (t *PaperTrader) checkPosition(position *models.Position) error {
 	shouldClose := false
 	pnl := 0.0
 
+	// A liquidation loses the whole isolated margin, unless the stop inside it filled first
+	low, high := currentPrice, currentPrice
+	if !latest.OpenTime.Before(position.OpenTime) {
+		low, high = latest.Low, latest.High
+	}
+	if LiquidatedBeforeStop(position.Side, position.StopLossPrice, position.LiquidationPrice, low, high) {
+		TrackExcursion(position, low, high, position.LiquidationPrice, position.TakeProfitPrice)
+		position.CloseReason = CloseReasonLiquidation
+		return t.closePosition(position, position.LiquidationPrice, -position.EntryPrice*position.Size/float64(position.Leverage))
+	}
+
 	// Check for take profit or stop loss
 	if position.Side == models.PositionSideLong {
-		if currentPrice >= position.TakeProfitPrice || currentPrice <= position.StopLossPrice {
+		if PriceGTE(currentPrice, position.TakeProfitPrice) || PriceLTE(currentPrice, position.StopLossPrice) {
 			pnl = (currentPrice - position.EntryPrice) * position.Size * float64(position.Leverage)
 			shouldClose = true
 		}
 	} else {
-		if currentPrice <= position.TakeProfitPrice || currentPrice >= position.StopLossPrice {
+		if PriceLTE(currentPrice, position.TakeProfitPrice) || PriceGTE(currentPrice, position.StopLossPrice) {
 			pnl = (position.EntryPrice - currentPrice) * position.Size * float64(position.Leverage)
 			shouldClose = true
 		}
 	}
 
 	if shouldClose {
+		TrackExcursion(position, low, high, position.StopLossPrice, position.TakeProfitPrice, currentPrice)
 		return t.closePosition(position, currentPrice, pnl)
 	}
 
-	return nil
+	tracked := TrackExcursion(position, low, high)
+	if moved := t.applyBreakeven(position, currentPrice); !moved && !tracked {
+		return nil
+	}
+	position.UpdatedAt = t.clock.Now()
+	return t.positionRepo.Update(position)
 }
 
+// applyBreakeven moves the stop to entry once the trade has gone breakevenAtR in its favor
+// It reports whether the stop moved, leaving the position for the caller to save
+func (t *PaperTrader) applyBreakeven(position *models.Position, currentPrice float64) bool {
+	if t.breakevenAtR == 0 {
+		return false
+	}
+	if position.InitialStopDistance == 0 {
+		position.InitialStopDistance = InitialStopDistance(position.EntryPrice, position.StopLossPrice)
+	}
+
+	newStop := BreakevenStop(position.Side, position.EntryPrice, position.StopLossPrice,
+		position.InitialStopDistance, currentPrice, t.breakevenAtR)
+	if newStop == position.StopLossPrice {
+		return false
+	}
+
+	log.Printf("Moving %s %s stop to breakeven: %.8f -> %.8f",
+		position.Symbol, position.Side, position.StopLossPrice, newStop)
+
+	position.StopLossPrice = newStop
+	return true
+}
+
+// closePosition marks the position closed and books its PnL in one database transaction
+// A failed close is retried by the monitor with backoff, at the same price and close time
 func (t *PaperTrader) closePosition(position *models.Position, closePrice, pnl float64) error {
 	// Update position
-	position.CloseTime = time.Now()
+	if position.CloseTime.IsZero() {
+		position.CloseTime = t.clock.Now()
+	}
 	position.Status = models.PositionStatusClosed
 	position.PnL = pnl
-	position.UpdatedAt = time.Now()
+	RecordRMultiple(position)
+	position.UpdatedAt = t.clock.Now()
 
-	// Save position
-	if err := t.positionRepo.Update(position); err != nil {
-		return fmt.Errorf("failed to update position: %v", err)
+	// Save position and book PnL together, in the symbol's quote asset
+	quote, err := t.account.QuoteFor(position.Symbol)
+	if err == nil {
+		_, err = t.positionRepo.Close(position, quote)
 	}
-
-	// Update balance
-	balance, err := t.balanceRepo.FindBySymbol("USDT")
 	if err != nil {
-		return fmt.Errorf("failed to get balance: %v", err)
-	}
-
-	balance.Balance += pnl
-	balance.LastUpdated = time.Now()
-
-	if err := t.balanceRepo.Update(balance); err != nil {
-		return fmt.Errorf("failed to update balance: %v", err)
+		position.Status = models.PositionStatusOpen
+		retry := t.closes.Failed(position.ID, PendingClose{
+			Reason: position.CloseReason,
+			Price:  closePrice,
+			PnL:    pnl,
+			At:     position.CloseTime,
+		}, t.clock.Now())
+		return fmt.Errorf("failed to close position, retrying at %s: %v", retry.RetryAt.Format("15:04:05"), err)
 	}
+	t.closes.Done(position.ID)
 
-	log.Printf("Position closed: %s %s | Entry: %.8f Exit: %.8f | PnL: %.2f USDT",
-		position.Symbol, position.Side, position.EntryPrice, closePrice, pnl)
+	log.Printf("Position closed: %s %s | Entry: %.8f Exit: %.8f | PnL: %.2f %s",
+		position.Symbol, position.Side, position.EntryPrice, closePrice, pnl, quote)
 
 	return nil
 }
@@ -0,0 +1,91 @@
+package testdb
+
+import (
+	"CryptoTradeBot/internal/models"
+	"math"
+	"time"
+)
+
+// FixtureDays is how many days of candles the price fixture spans, as Seed stores it
+const FixtureDays = 10
+
+// FixtureStart is when the price fixture begins
+var FixtureStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// FixtureSymbols are the symbols the price fixture covers, with the price each starts around
+var FixtureSymbols = map[string]float64{
+	"BTCUSDT": 40000,
+	"ETHUSDT": 2500,
+}
+
+// FixturePrices generates days of candles of every timeframe for symbol from FixtureStart, the same on
+// every call. The 1m candles follow a few overlapping waves, so trends, pullbacks and volume spikes
+// all occur, and the higher timeframes are built from them. Prices are whole cents and volumes whole
+// units, so they survive the database's decimal columns unchanged
+func FixturePrices(symbol string, days int) []models.Price {
+	base := FixtureSymbols[symbol]
+	phase := float64(len(symbol)) // Sets the symbols' waves apart
+	path := func(minute int) float64 {
+		t := float64(minute)
+		return base * (1 +
+			0.04*math.Sin(2*math.Pi*t/(3*24*60)+phase) +
+			0.012*math.Sin(2*math.Pi*t/(7*60)+2*phase) +
+			0.003*math.Sin(2*math.Pi*t/47))
+	}
+	cents := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	minutes := days * 24 * 60
+	oneMinute := make([]models.Price, minutes)
+	for i := range oneMinute {
+		open, close := cents(path(i)), cents(path(i+1))
+		wick := cents(base * 0.0004 * (1 + math.Sin(float64(i)/13)))
+		volume := math.Round(20 + 10*math.Sin(2*math.Pi*float64(i)/90+phase))
+		if i%97 == 0 {
+			volume *= 3
+		}
+		openTime := FixtureStart.Add(time.Duration(i) * time.Minute)
+		oneMinute[i] = models.Price{
+			Exchange:   models.ExchangeBinance,
+			Symbol:     symbol,
+			TimeFrame:  models.PriceTimeFrame1m,
+			OpenTime:   openTime,
+			CloseTime:  openTime.Add(time.Minute - time.Millisecond),
+			Open:       open,
+			High:       cents(math.Max(open, close) + wick),
+			Low:        cents(math.Min(open, close) - wick),
+			Close:      close,
+			Volume:     volume,
+			TradeCount: int64(volume) * 3,
+		}
+	}
+
+	prices := oneMinute
+	for timeFrame, interval := range models.TimeFrameDurations {
+		if timeFrame != models.PriceTimeFrame1m {
+			prices = append(prices, aggregate(oneMinute, timeFrame, interval)...)
+		}
+	}
+	return prices
+}
+
+// aggregate builds the candles of timeFrame from consecutive 1m candles starting on its boundary
+func aggregate(oneMinute []models.Price, timeFrame string, interval time.Duration) []models.Price {
+	per := int(interval / time.Minute)
+	candles := make([]models.Price, 0, len(oneMinute)/per)
+	for from := 0; from+per <= len(oneMinute); from += per {
+		bucket := oneMinute[from : from+per]
+		candle := bucket[0]
+		candle.TimeFrame = timeFrame
+		candle.CloseTime = candle.OpenTime.Add(interval - time.Millisecond)
+		candle.Close = bucket[per-1].Close
+		candle.Volume, candle.TradeCount = 0, 0
+		for _, minute := range bucket {
+			candle.High = math.Max(candle.High, minute.High)
+			candle.Low = math.Min(candle.Low, minute.Low)
+			candle.Volume += minute.Volume
+			candle.TradeCount += minute.TradeCount
+		}
+		candles = append(candles, candle)
+	}
+	return candles
+}
@@ -2,164 +2,460 @@ package main
 
 import (
 	"CryptoTradeBot/internal/backtesting"
+	"CryptoTradeBot/internal/backtestserver"
+	"CryptoTradeBot/internal/books"
+	"CryptoTradeBot/internal/live"
 	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/notifications"
 	"CryptoTradeBot/internal/operations/handlers"
+	"CryptoTradeBot/internal/operations/ledger"
+	"CryptoTradeBot/internal/operations/priceOperations"
+	"CryptoTradeBot/internal/operations/reports"
 	"CryptoTradeBot/internal/repositories"
-	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/risk"
+	"CryptoTradeBot/internal/services/trading"
+	"CryptoTradeBot/pkg/backtest"
+	"CryptoTradeBot/pkg/strategy"
 	"context"
+	"encoding/csv"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/joho/godotenv"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 func main() {
 	// Add command line flags
-	mode := flag.String("mode", "live", "Trading mode: 'live' or 'backtest'")
-	days := flag.Int("days", 30, "Number of days to backtest")
+	mode := flag.String("mode", "live", "Trading mode: 'live', 'backtest', 'download', 'server', 'verify', 'flatten', 'audit', 'resume', 'report', 'export-live', 'tag', 'prune', 'control', 'dump', 'parity' or 'compare'")
+	days := flag.Int("days", 30, "Days covered by backtest, download, audit and export-live, ending at -to where it applies")
+	fix := flag.Bool("fix", false, "Delete and refetch bad candles found in verify mode")
+	indicatorCache := flag.Bool("indicator-cache", false, "Fold each backtest candle into cached indicator state once instead of recomputing the window every step; faster, values differ only by the window's seeding")
+	compareSides := flag.Bool("compare-sides", false, "Backtest both sides, long only and short only over the same period and compare them")
+	out := flag.String("out", "", "Write backtest or export-live results to this file, as JSON, CSV or HTML by its extension; the CSV file dump mode writes")
+	from := flag.String("from", "", "Start of the backtest, download or export-live period, YYYY-MM-DD in UTC or RFC3339 (export-live takes days only); -days before -to by default")
+	to := flag.String("to", "", "End of the backtest, download or export-live period, exclusive, YYYY-MM-DD in UTC or RFC3339 (export-live takes days only); now by default, tomorrow for export-live")
+	backtestPath := flag.String("backtest", "", "Backtest results JSON to measure the export-live period against")
+	matchTolerance := flag.Duration("match-tolerance", 5*time.Minute, "Entry time difference within which compare treats two trades as the same")
+	csvPath := flag.String("csv", "", "Also write the compare tables as CSV to this file")
+	symbol := flag.String("symbol", "", "Symbol to resume in resume mode; without it resume lifts the account's equity stop")
+	booksFile := flag.String("books", "", "Books file of independent portfolios, each its own account, symbols, strategies, sizing and trade caps sharing the price feed; replaces -accounts and -symbols in live and backtest modes")
+	accountSpec := flag.String("accounts", "", "Live accounts as name=strategy-config pairs, comma separated; empty runs the default account on STRATEGY_CONFIG")
+	primary := flag.String("primary", "", "Account that trades in live mode; the other -accounts run as shadows, their decisions only recorded and compared in the primary's report. Empty trades every account")
+	account := flag.String("account", models.DefaultAccount, "Account used by flatten, resume, report, export-live, tag and control modes; audit covers every account")
+	retention := flag.String("retention", "", "Candle retention overrides as timeframe=age pairs, e.g. 5m=90d,1h=730d,4h=forever; by default 1m is kept 30 days, 5m 90, 15m a year, 1h two years and 4h and 1d forever")
+	dryRun := flag.Bool("dry-run", false, "Show what prune mode would delete without deleting it")
+	reportDate := flag.String("date", "", "Day to summarize in report mode, YYYY-MM-DD in UTC; yesterday by default")
+	reportPeriod := flag.String("period", reports.PeriodDay, "Report mode period: 'day' or the ISO 'week' containing -date")
+	symbolsFile := flag.String("symbols-file", "", "File listing the traded symbols, comma or newline separated; live mode reloads it on SIGHUP")
+	universeSize := flag.Int("universe-size", 0, "Trade the top N QUOTE_ASSET perpetuals by 24h quote volume, reselected daily; 0 trades the fixed symbols")
+	minQuoteVolume := flag.Float64("min-quote-volume", 50_000_000, "24h quote asset volume below which a symbol never enters the universe")
+	universeExclude := flag.String("universe-exclude", "", "Comma separated symbols never selected for the universe")
+	historicalUniverse := flag.Bool("historical-universe", false, "Backtest only enters the symbols the universe held at the time, as stored by live trading")
+	sendReport := flag.Bool("send", false, "Deliver the report through the notification channels as well as printing it")
+	excludeTags := flag.String("exclude-tags", "", "Comma separated journal tags whose trades reports leave out of their counts and PnL")
+	positionID := flag.Uint("position", 0, "Closed position to journal in tag mode")
+	tags := flag.String("tags", "", "Comma separated journal tags to add in tag mode")
+	untag := flag.String("untag", "", "Comma separated journal tags to remove in tag mode")
+	listen := flag.String("listen", ":8090", "Address server mode serves backtests on")
+	serverConcurrency := flag.Int("server-concurrency", backtestserver.DefaultConcurrency, "Backtests server mode runs at once, later requests wait in a queue")
+	artifactsDir := flag.String("artifacts-dir", "backtests", "Directory server mode writes each run's JSON, CSV and HTML results to")
+	notes := flag.String("notes", "", "Journal notes to set in tag mode, replacing the current ones")
+	action := flag.String("action", "", "Control mode action on the running bot: 'pause', 'resume' or 'flatten', which also pauses")
+	interactive := flag.Bool("interactive", false, "After a backtest, browse its trades in a sortable, filterable table with a summary; without a terminal the plain output is printed instead")
+	load := flag.String("load", "", "Backtest results JSON to summarize in backtest mode instead of running a backtest, browsed with -interactive")
+	reason := flag.String("reason", "manual", "Why control mode pauses or flattens, shown in the logs and notifications")
+	var tradeFlags trading.TradeFlags
+	tradeFlags.RegisterFlags(flag.CommandLine)
+	var backtestFlags backtesting.Flags
+	backtestFlags.RegisterFlags(flag.CommandLine)
+	var liveFlags live.Flags
+	liveFlags.RegisterFlags(flag.CommandLine)
+	recorder := priceOperations.DefaultRecorderConfig()
+	recorder.RegisterFlags(flag.CommandLine)
+	exchange := flag.String("exchange", models.ExchangeBinance, "Exchange whose candles download, verify, backtest, dump and server modes fetch and read: 'binance' or 'bybit'; trading stays on Binance")
 	flag.Parse()
 
+	// Comparing saved runs needs neither the database nor the exchange
+	if *mode == "compare" {
+		if flag.NArg() != 2 {
+			log.Fatal("Usage: -mode compare [-match-tolerance 5m] [-csv file] <a.json> <b.json>")
+		}
+		runCompare(flag.Arg(0), flag.Arg(1), *matchTolerance, *csvPath)
+		return
+	}
+	// Summarizing a saved run only reads its file
+	if *mode == "backtest" && *load != "" {
+		runLoadedBacktest(*load, *interactive)
+		return
+	}
+
+	tradeConfig, err := tradeFlags.Config()
+	if err != nil {
+		log.Fatal(err)
+	}
+	backtestConfig, err := backtestFlags.Config(tradeConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sweepMargins, err := backtestFlags.SweepMargins()
+	if err != nil {
+		log.Fatal(err)
+	}
+	liveConfig, err := liveFlags.Config(tradeConfig, recorder, *symbolsFile, splitTags(*excludeTags))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if !slices.Contains(models.Exchanges, *exchange) {
+		log.Fatalf("Invalid exchange. Use one of %s", strings.Join(models.Exchanges, ", "))
+	}
+	// Other exchanges' candles are only recorded to compare and backtest against, trading reads Binance's
+	if *exchange != models.ExchangeBinance && !slices.Contains([]string{"download", "verify", "backtest", "dump", "parity", "server"}, *mode) {
+		log.Fatalf("-exchange %s only applies to download, verify, backtest, dump and server modes", *exchange)
+	}
+
 	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
 
+	// Controlling the running bot only talks to its dashboard
+	if *mode == "control" {
+		runControl(*action, *account, *reason)
+		return
+	}
+
+	// Balances, PnL and the traded symbols are all in one quote asset
+	quoteAsset, err := trading.QuoteAssetFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Database setup
 	db := setupDatabase()
 
-	// Initialize repositories
-	priceRepo := repositories.NewPriceRepository(db)
-	positionRepo := repositories.NewPositionRepository(db)
-	balanceRepo := repositories.NewBalanceRepository(db)
-
-	// Initialize analysis
-	analysis := analysis.NewAnalysis()
+	// Initialize repositories, scoped to the account single-account modes work on
+	priceRepo := repositories.NewPriceRepository(db).ForExchange(*exchange)
+	if *exchange != models.ExchangeBinance {
+		log.Printf("Reading and storing %s candles", *exchange)
+	}
+	positionRepo := repositories.NewPositionRepository(db).ForAccount(*account)
+	balanceRepo := repositories.NewBalanceRepository(db).ForAccount(*account)
+	accountService := trading.NewAccountService(balanceRepo, quoteAsset)
+	accountService.UsePrices(priceRepo)
+	orderRepo := repositories.NewPendingOrderRepository(db).ForAccount(*account)
+	transactionRepo := repositories.NewTransactionRepository(db).ForAccount(*account)
+	suspensionRepo := repositories.NewSymbolSuspensionRepository(db).ForAccount(*account)
+	signalRepo := repositories.NewSignalRepository(db).ForAccount(*account)
 
 	symbols := []string{
 		"BTCUSDT", "ETHUSDT", "XRPUSDT",
 	}
+	if *symbolsFile != "" && *universeSize > 0 {
+		log.Fatal("Use either -symbols-file or -universe-size, not both")
+	}
+	if *symbolsFile != "" {
+		loaded, err := live.LoadSymbols(*symbolsFile)
+		if err != nil {
+			log.Fatal("Failed to load symbols:", err)
+		}
+		symbols = loaded
+	}
+	if *universeSize == 0 {
+		if err := trading.CheckSymbols(symbols, quoteAsset); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// One weight budget is shared by every Binance client
+	limiter := priceOperations.NewWeightLimiter(priceOperations.BinanceWeightLimit, recorder.WeightThreshold)
+
+	// Initialize notifications
+	notifier, err := notifications.NewNotifierFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure notifications:", err)
+	}
+
+	// A volume-ranked universe changes daily, so overrides cannot be checked against it
+	strategySymbols := symbols
+	if *universeSize > 0 || *historicalUniverse {
+		strategySymbols = nil
+	}
+
+	// Initialize strategies, rejecting bad parameter files before anything runs
+	strategies, err := strategy.LoadStrategyManager(os.Getenv("STRATEGY_CONFIG"), strategySymbols)
+	if err != nil {
+		log.Fatal("Failed to load strategy config:", err)
+	}
+
+	retentionPolicy, err := priceOperations.ParseRetention(*retention)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	switch *mode {
 	case "live":
-		runLiveTrading(priceRepo, positionRepo, balanceRepo, analysis, symbols)
+		accounts, err := live.LoadAccounts(*accountSpec, *booksFile, *primary, strategies, strategySymbols)
+		if err != nil {
+			log.Fatal("Failed to load accounts:", err)
+		}
+		// Books record the prices of every book's symbols, each book trading its own
+		if *booksFile != "" {
+			if *universeSize > 0 {
+				log.Fatal("-books and -universe-size cannot be combined, books list their own symbols")
+			}
+			symbols = live.AccountSymbols(accounts)
+		}
+		// Fixed symbols must be trading perpetuals, fetch errors on a typo would never stop
+		validator := priceOperations.NewSymbolValidator(
+			priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), limiter),
+			limiter, recorder.ExchangeInfoCache)
+		if *universeSize == 0 {
+			if symbols, err = validator.Validate(context.Background(), symbols); err != nil {
+				log.Fatal(err)
+			}
+		}
+		// Stops must clear a few price ticks from entry, without tick sizes only a fraction of entry
+		if sizes, err := validator.TickSizes(context.Background()); err != nil {
+			log.Printf("Warning: %v, checking stop distances against %.2f%% of entry only", err, strategy.MinStopFraction*100)
+		} else {
+			strategy.SetTickSizes(sizes)
+		}
+		// Position sizes are rounded down to the quantity step, left unrounded without step sizes
+		if sizes, err := validator.StepSizes(context.Background()); err != nil {
+			log.Printf("Warning: %v, position sizes are not rounded to the exchange's step size", err)
+		} else {
+			trading.SetStepSizes(sizes)
+		}
+		var universe *priceOperations.UniverseService
+		if *universeSize > 0 {
+			client := priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), limiter)
+			universe = priceOperations.NewUniverseService(client, limiter, repositories.NewUniverseRepository(db), priceOperations.UniverseConfig{
+				Size:           *universeSize,
+				MinQuoteVolume: *minQuoteVolume,
+				Exclude:        splitSymbols(*universeExclude),
+				QuoteAsset:     quoteAsset,
+			})
+		}
+		live.Run(live.Deps{
+			DB:              db,
+			PriceRepo:       priceRepo,
+			PositionRepo:    positionRepo,
+			AccountService:  accountService,
+			OrderRepo:       orderRepo,
+			TransactionRepo: transactionRepo,
+			SuspensionRepo:  suspensionRepo,
+			SignalRepo:      signalRepo,
+			Notifier:        notifier,
+			Accounts:        accounts,
+			Symbols:         symbols,
+			Limiter:         limiter,
+			Validator:       validator,
+			Universe:        universe,
+			Retention:       priceOperations.NewRetentionService(priceRepo, retentionPolicy, live.WarmUpAge(accounts)),
+		}, liveConfig)
 	case "backtest":
-		runBacktest(priceRepo, analysis, symbols, *days)
+		var universeRepo *repositories.UniverseRepository
+		if *historicalUniverse {
+			universeRepo = repositories.NewUniverseRepository(db)
+		}
+		start, end, err := backtest.ParsePeriod(*from, *to, *days)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *booksFile != "" {
+			portfolio, err := books.Load(*booksFile)
+			if err != nil {
+				log.Fatal("Failed to load books:", err)
+			}
+			if *indicatorCache {
+				for _, book := range portfolio {
+					book.Strategies.EnableIncremental()
+				}
+			}
+			runBooksBacktest(priceRepo, portfolio, backtestConfig, start, end, *out)
+			return
+		}
+		if *indicatorCache {
+			strategies.EnableIncremental()
+		}
+		runBacktest(priceRepo, universeRepo, strategies, backtestConfig, *compareSides, sweepMargins, symbols, start, end, *out, *interactive)
+	case "dump":
+		start, end, err := backtest.ParsePeriod(*from, *to, *days)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *indicatorCache {
+			strategies.EnableIncremental()
+		}
+		runDump(priceRepo, strategies, symbols, start, end, *out)
+	case "parity":
+		start, end, err := backtest.ParsePeriod(*from, *to, *days)
+		if err != nil {
+			log.Fatal(err)
+		}
+		runParity(priceRepo, positionRepo, accountService, orderRepo, strategies, notifier, symbols, start, end)
+	case "download":
+		start, end, err := backtest.ParsePeriod(*from, *to, *days)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// The warm-up before the period is needed as much as the period itself
+		warmUp := time.Duration(strategies.WindowCandles(backtest.BaseTimeFrame)) * models.TimeFrameDurations[backtest.BaseTimeFrame]
+		runDownload(priceRepo, limiter, symbols, start.Add(-warmUp), end)
+	case "server":
+		token := os.Getenv("BACKTEST_API_TOKEN")
+		if token == "" {
+			log.Fatal("BACKTEST_API_TOKEN must be set to serve backtests")
+		}
+		runServer(priceRepo, strategies, backtestserver.Config{
+			Token:        token,
+			Concurrency:  *serverConcurrency,
+			ArtifactsDir: *artifactsDir,
+			Symbols:      symbols,
+			Days:         *days,
+			Base:         backtestConfig,
+		}, *listen)
+	case "verify":
+		runVerify(priceRepo, limiter, *fix)
+	case "flatten":
+		runFlatten(priceRepo, positionRepo, accountService, orderRepo, strategies, notifier)
+	case "audit":
+		runAudit(balanceRepo, transactionRepo, quoteAsset, *days)
+	case "resume":
+		runResume(positionRepo, suspensionRepo, repositories.NewBotStateRepository(db).ForAccount(*account), notifier, *symbol)
+	case "report":
+		runReport(priceRepo, positionRepo, transactionRepo, signalRepo, repositories.NewConfigSnapshotRepository(db), notifier, quoteAsset, *reportDate, *reportPeriod, splitTags(*excludeTags), *sendReport)
+	case "tag":
+		runTag(positionRepo, *positionID, splitTags(*tags), splitTags(*untag), *notes, isFlagSet("notes"))
+	case "prune":
+		accounts, err := live.LoadAccounts(*accountSpec, *booksFile, *primary, strategies, strategySymbols)
+		if err != nil {
+			log.Fatal("Failed to load accounts:", err)
+		}
+		runPrune(priceOperations.NewRetentionService(priceRepo, retentionPolicy, live.WarmUpAge(accounts)), *dryRun)
+	case "export-live":
+		runExportLive(positionRepo, transactionRepo, *from, *to, *days, *out, *backtestPath, *matchTolerance, backtestFlags.Ratios())
 	default:
-		log.Fatal("Invalid mode. Use 'live' or 'backtest'")
+		log.Fatal("Invalid mode. Use 'live', 'backtest', 'download', 'server', 'verify', 'flatten', 'audit', 'resume', 'report', 'export-live', 'tag', 'prune', 'control', 'dump', 'parity' or 'compare'")
 	}
 }
 
 func setupDatabase() *gorm.DB {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"))
-
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	options, err := repositories.DatabaseOptionsFromEnv()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
 	}
-
-	err = db.AutoMigrate(
-		&models.Price{},
-		&models.Position{},
-		&models.Balance{},
-		&models.Transaction{},
-	)
+	db, err := repositories.OpenDatabaseWithOptions(repositories.DSNFromEnv(), options)
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		log.Fatal(err)
 	}
-
-	db.Logger = db.Logger.LogMode(logger.Error)
 	return db
 }
 
-func runLiveTrading(priceRepo *repositories.PriceRepository,
-	positionRepo *repositories.PositionRepository,
-	balanceRepo *repositories.BalanceRepository,
-	analysis *analysis.Analysis,
-	symbols []string) {
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	// Initialize handlers
-	priceHandler := handlers.NewPriceHandler(priceRepo)
-	analysisHandler := handlers.NewAnalysisHandler(
-		analysis,
-		priceRepo,
-		positionRepo,
-		balanceRepo,
-	)
-
-	// Initialize balance
-	if err := initBalance(balanceRepo); err != nil {
-		log.Fatal("Failed to initialize balance:", err)
+// splitSymbols parses a comma separated symbol list, ignoring blanks
+func splitSymbols(list string) []string {
+	var symbols []string
+	for _, field := range strings.Split(list, ",") {
+		if symbol := strings.ToUpper(strings.TrimSpace(field)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
 	}
+	return symbols
+}
 
-	log.Println("Starting live trading...")
-
-	// Start price handler
-	if err := priceHandler.Start(ctx, symbols); err != nil {
-		log.Fatal("Failed to start price handler:", err)
+// splitTags parses a comma separated tag list, ignoring blanks; tags keep their case for validation
+func splitTags(list string) []string {
+	var tags []string
+	for _, field := range strings.Split(list, ",") {
+		if tag := strings.TrimSpace(field); tag != "" {
+			tags = append(tags, tag)
+		}
 	}
-
-	time.Sleep(time.Second * 10)
-	go analysisHandler.Start(ctx, symbols)
-
-	// Handle shutdown
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
-	<-c
-
-	log.Println("Shutting down...")
-	cancel()
-	time.Sleep(time.Second * 2)
-	log.Println("Shutdown complete")
+	return tags
 }
 
-func initBalance(balanceRepo *repositories.BalanceRepository) error {
-	balance, err := balanceRepo.FindBySymbol("USDT")
-	if err != nil {
-		return fmt.Errorf("error checking balance: %v", err)
+// formatCounts lists counts as "name n" pairs in name order, "none" when empty
+func formatCounts(counts map[string]int) string {
+	if len(counts) == 0 {
+		return "none"
 	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %d", name, counts[name])
+	}
+	return strings.Join(parts, ", ")
+}
 
-	if balance == nil {
-		newBalance := &models.Balance{
-			Symbol:      "USDT",
-			Balance:     1000.0, // Starting with 1000 USDT
-			LastUpdated: time.Now(),
+// isFlagSet reports whether the named flag was given on the command line
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-		if err := balanceRepo.Create(newBalance); err != nil {
-			return fmt.Errorf("error creating initial balance: %v", err)
-		}
-	}
-	return nil
+	})
+	return set
 }
 
 func runBacktest(priceRepo *repositories.PriceRepository,
-	analysis *analysis.Analysis,
+	universeRepo *repositories.UniverseRepository,
+	strategies *strategy.StrategyManager,
+	config backtest.Config,
+	compareSides bool,
+	sweepMargins []float64,
 	symbols []string,
-	days int) {
+	startTime, endTime time.Time,
+	out string,
+	interactive bool) {
 
-	log.Printf("Starting backtest for last %d days...", days)
+	log.Printf("Starting backtest from %s to %s...", startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	if strategies.FiltersOrderFlow() {
+		log.Println("Warning: the order flow filter needs live trades, the backtest runs without it")
+	}
 
-	// Ensure we have enough price data
-	endTime := time.Now()
-	startTime := endTime.AddDate(0, 0, -days)
+	// Replay the universe live trading held over the period instead of the fixed symbols
+	var universe *backtest.Universe
+	if universeRepo != nil {
+		snapshots, err := universeRepo.FindForPeriod(startTime, endTime)
+		if err != nil {
+			log.Fatal("Failed to load universe:", err)
+		}
+		if len(snapshots) == 0 {
+			log.Fatal("No stored universe for the backtest period, run live trading with -universe-size first")
+		}
+		universe = backtesting.NewUniverse(snapshots)
+		symbols = universe.Symbols()
+	} else {
+		// Universe symbols are only held for part of the period, so only fixed symbols must cover all of it
+		warmUp := time.Duration(strategies.WindowCandles(backtest.BaseTimeFrame)) * models.TimeFrameDurations[backtest.BaseTimeFrame]
+		if err := priceOperations.NewPriceVerifier(priceRepo, nil).CheckCoverage(symbols, backtest.BaseTimeFrame, startTime.Add(-warmUp), endTime); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Log the actual data we have
 	for _, symbol := range symbols {
 		prices, err := priceRepo.GetPricesByTimeFrame(
+			context.Background(),
 			symbol,
 			models.PriceTimeFrame5m,
 			startTime,
@@ -186,39 +482,895 @@ func runBacktest(priceRepo *repositories.PriceRepository,
 		)
 	}
 
-	bt := backtesting.NewBacktest(priceRepo, analysis)
+	config.Universe = universe
 
-	endTime = time.Now()
-	startTime = endTime.AddDate(0, 0, -30) // 30 days
+	if compareSides {
+		compareBacktestSides(priceRepo, strategies, config, symbols, startTime, endTime)
+		return
+	}
+	if len(sweepMargins) > 0 {
+		sweepReversalMargins(priceRepo, strategies, config, sweepMargins, symbols, startTime, endTime)
+		return
+	}
 
+	bt := backtest.NewEngine(priceRepo, strategies, config)
 	results, err := bt.RunBacktest(startTime, endTime, symbols)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Println("\nTrade History:")
-	for _, trade := range results.Trades {
-		fmt.Printf("%s: %s %s Entry: %.8f Exit: %.8f PnL: %.2f\n",
-			trade.EntryTime.Format("2006-01-02 15:04"),
-			trade.Symbol,
-			trade.Side,
-			trade.EntryPrice,
-			trade.ExitPrice,
-			trade.PnL)
+	browse := canBrowse(interactive)
+	if !browse {
+		printTradeHistory(results.Trades)
 	}
 
 	// Print results
 	fmt.Println("\nBacktest Results:")
 	fmt.Printf("Period: %s to %s\n", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+	fmt.Printf("Seed: %d\n", results.Seed)
+	if results.Provenance != nil {
+		fmt.Printf("Config: %s (build %s)\n", results.Provenance.Short(), results.Provenance.Version)
+	}
+	for _, skipped := range results.Skipped {
+		fmt.Printf("Skipped %s: %s\n", skipped.Symbol, skipped.Reason)
+	}
 	fmt.Printf("Total Trades: %d\n", results.TotalTrades)
 	fmt.Printf("Winning Trades: %d\n", results.WinningTrades)
 	fmt.Printf("Losing Trades: %d\n", results.LosingTrades)
 	fmt.Printf("Win Rate: %.2f%%\n", results.WinRate*100)
 	fmt.Printf("Average PnL: %.2f USDT\n", results.AveragePnL)
+	fmt.Printf("Liquidations: %d\n", results.Liquidations)
+	if results.StopChanges > 0 || results.RejectedStopChanges > 0 {
+		fmt.Printf("Stop Changes: %d, %d rejected\n", results.StopChanges, results.RejectedStopChanges)
+	}
+	fmt.Printf("MAE (R): p25 %.2f, p50 %.2f, p75 %.2f, p90 %.2f\n",
+		results.Excursions.MAE.P25, results.Excursions.MAE.P50, results.Excursions.MAE.P75, results.Excursions.MAE.P90)
+	fmt.Printf("MFE (R): p25 %.2f, p50 %.2f, p75 %.2f, p90 %.2f\n",
+		results.Excursions.MFE.P25, results.Excursions.MFE.P50, results.Excursions.MFE.P75, results.Excursions.MFE.P90)
+	printRStats(results.R)
+	printConfidenceBuckets(results.Confidence)
+	if config.Reversal.Enabled {
+		r := results.Reversals
+		fmt.Printf("Reversals: %d, closed legs %.2f USDT vs %.2f held (%d still open at the end), reversed legs %.2f USDT\n",
+			r.Reversals, r.ClosedLegPnL, r.HeldPnL, r.Unresolved, r.ReversedLegPnL)
+		fmt.Printf("Reversal Net Benefit: %+.2f USDT\n", r.NetBenefit)
+	}
+	if config.Timing == backtest.TimingNextOpen {
+		fmt.Printf("Entry Timing: %s, %d signals skipped on gaps over %.2f%%\n", config.Timing, results.GapSkips, config.GapTolerance*100)
+	} else {
+		fmt.Printf("Entry Timing: %s\n", config.Timing)
+	}
+	fmt.Printf("Ambiguous Bars: %d (%s)\n", results.AmbiguousBars, config.SameBar)
+	if config.SameBar == backtest.SameBarProbe {
+		fmt.Printf("Resolved by %s candles: %d of %d\n", backtest.ProbeTimeFrame, results.ProbedBars, results.AmbiguousBars)
+	}
 	fmt.Printf("Max Drawdown: %.2f%%\n", results.MaxDrawdown*100)
 	fmt.Printf("Final Balance: %.2f USDT\n", results.FinalBalance)
+	printRatios(results)
+	if config.Performance != nil {
+		fmt.Printf("Suspensions: %d (%d signals skipped)\n", results.Suspensions, results.SuspendedSignals)
+	}
+	if config.EquityStop != nil {
+		fmt.Printf("Equity Stop-outs: %d (%d signals skipped)\n", results.EquityStopOuts, results.EquityStopSignals)
+	}
+	if config.Frequency.Enabled() {
+		fmt.Printf("Throttled Signals: %d\n", results.ThrottledSignals)
+	}
+	if results.InvalidSignals > 0 {
+		fmt.Printf("Invalid Signals: %d, not traded\n", results.InvalidSignals)
+	}
+	if guarded := backtest.SizeGuards(results.Trades); len(guarded) > 0 || results.SizeRejections > 0 {
+		fmt.Printf("Size Guards: %s, %d fills refused\n", formatCounts(guarded), results.SizeRejections)
+	}
+	if config.Entry.Mode == trading.EntryModeLimit {
+		fmt.Printf("Limit Fills: %d of %d signals (%.2f%%)\n", results.Fills, results.Signals, results.FillRate*100)
+	}
+	if liquidity := config.Liquidity; liquidity != nil {
+		l := results.Liquidity
+		fmt.Printf("Liquidity (%s, %.2f%% of candle volume): %d trades capped, %d spread, %.2f of %.2f USDT notional filled (%.2f%% shortfall), %d fills refused on empty candles\n",
+			liquidity.Mode, liquidity.MaxParticipation*100, l.Capped, l.Spread, l.Filled, l.Wanted, l.Shortfall*100, results.LiquiditySkips)
+	}
+
+	if out != "" {
+		if err := results.Export(out); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Results written to %s", out)
+	}
+	if browse {
+		if err := backtest.Browse(results, os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// runBooksBacktest backtests every book on its own balance over the same prices, printing each
+// book's summary and the portfolio's; with out set each book's results go to out with its name appended
+func runBooksBacktest(priceRepo *repositories.PriceRepository, portfolio []books.Book, config backtest.Config, startTime, endTime time.Time, out string) {
+	log.Printf("Starting backtest of %d books from %s to %s...", len(portfolio), startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	for _, book := range portfolio {
+		warmUp := time.Duration(book.Strategies.WindowCandles(backtest.BaseTimeFrame)) * models.TimeFrameDurations[backtest.BaseTimeFrame]
+		if err := priceOperations.NewPriceVerifier(priceRepo, nil).CheckCoverage(book.Symbols, backtest.BaseTimeFrame, startTime.Add(-warmUp), endTime); err != nil {
+			log.Fatalf("Book %s: %v", book.Name, err)
+		}
+	}
+
+	results, err := backtesting.RunBooks(priceRepo, portfolio, config, startTime, endTime)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("\nPortfolio Backtest Results:")
+	fmt.Printf("Period: %s to %s\n", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+	for i, book := range results.Books {
+		r := book.Results
+		fmt.Printf("\n[%s] %s\n", book.Book, strings.Join(portfolio[i].Symbols, ", "))
+		for _, skipped := range r.Skipped {
+			fmt.Printf("Skipped %s: %s\n", skipped.Symbol, skipped.Reason)
+		}
+		fmt.Printf("Total Trades: %d\n", r.TotalTrades)
+		fmt.Printf("Win Rate: %.2f%%\n", r.WinRate*100)
+		fmt.Printf("Average PnL: %.2f USDT\n", r.AveragePnL)
+		fmt.Printf("Max Drawdown: %.2f%%\n", r.MaxDrawdown*100)
+		fmt.Printf("Final Balance: %.2f USDT\n", r.FinalBalance)
+
+		if out != "" {
+			path := strings.TrimSuffix(out, filepath.Ext(out)) + "-" + book.Book + filepath.Ext(out)
+			if err := r.Export(path); err != nil {
+				log.Fatal(err)
+			}
+			log.Printf("Results of %s written to %s", book.Book, path)
+		}
+	}
+	fmt.Printf("\nPortfolio: %d trades, final balance %.2f USDT over %d books\n", results.TotalTrades, results.FinalBalance, len(results.Books))
+}
+
+// runLoadedBacktest summarizes the backtest results saved as JSON at path, browsing them when interactive
+func runLoadedBacktest(path string, interactive bool) {
+	results, err := backtest.LoadResults(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if canBrowse(interactive) {
+		if err := backtest.Browse(results, os.Stdin, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	printTradeHistory(results.Trades)
+	summary := backtest.SummarizeTrades(results.Trades)
+	fmt.Printf("\nBacktest Results (%s):\n", path)
+	if results.Provenance != nil {
+		fmt.Printf("Config: %s (build %s)\n", results.Provenance.Short(), results.Provenance.Version)
+	}
+	fmt.Printf("Total Trades: %d\n", summary.Trades)
+	fmt.Printf("Win Rate: %.2f%%\n", summary.WinRate*100)
+	fmt.Printf("Average PnL: %.2f USDT\n", summary.AveragePnL)
+	fmt.Printf("Profit Factor: %.2f\n", summary.ProfitFactor)
+	fmt.Printf("Max Drawdown: %.2f%%\n", results.MaxDrawdown*100)
+	fmt.Printf("Final Balance: %.2f USDT\n", results.FinalBalance)
+	printRatios(results)
+}
+
+// canBrowse reports whether the interactive summary asked for can run, needing a terminal on both ends
+func canBrowse(interactive bool) bool {
+	if !interactive {
+		return false
+	}
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		log.Printf("No terminal to browse in, printing the plain summary")
+		return false
+	}
+	return true
+}
+
+// isTerminal reports whether f is a character device, as an interactive terminal is
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printRatios prints the Sharpe, Sortino and Calmar ratios of results, or why they are missing
+func printRatios(results *backtest.Results) {
+	if results.RatiosInsufficient {
+		fmt.Printf("Sharpe, Sortino, Calmar: insufficient data (%d daily returns)\n", results.RatioDays)
+		return
+	}
 	fmt.Printf("Sharpe Ratio: %.2f\n", results.SharpeRatio)
+	fmt.Printf("Sortino Ratio: %.2f\n", results.SortinoRatio)
+	fmt.Printf("Calmar Ratio: %.2f\n", results.CalmarRatio)
+}
+
+// printTradeHistory prints every trade on a line of its own, in the order given
+func printTradeHistory(trades []backtest.Trade) {
+	fmt.Println("\nTrade History:")
+	for _, trade := range trades {
+		fmt.Printf("%s: %s %s Entry: %.8f Exit: %.8f PnL: %.2f [%s]\n",
+			trade.EntryTime.Format("2006-01-02 15:04"),
+			trade.Symbol,
+			trade.Side,
+			trade.EntryPrice,
+			trade.ExitPrice,
+			trade.PnL,
+			trade.Confluence)
+	}
+}
+
+// runDump writes the indicator and signal series the strategies see over the period to out as CSV
+func runDump(priceRepo *repositories.PriceRepository,
+	strategies *strategy.StrategyManager,
+	symbols []string,
+	start, end time.Time,
+	out string) {
+
+	if out == "" {
+		log.Fatal("Usage: -mode dump -out file.csv [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-days n]")
+	}
+	file, err := os.Create(out)
+	if err != nil {
+		log.Fatalf("Failed to create %s: %v", out, err)
+	}
+	defer file.Close()
+
+	engine := backtest.NewEngine(priceRepo, strategies, backtest.DefaultConfig())
+	if err := engine.Dump(context.Background(), file, start, end, symbols); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatalf("Failed to write %s: %v", out, err)
+	}
+	log.Printf("Dump written to %s", out)
+}
+
+// parityShown caps the divergences runParity prints
+const parityShown = 20
+
+// runParity replays symbols' stored candles from start to end through the backtest's analysis and the live
+// handler's, exiting with an error status when they decide any candle differently
+func runParity(priceRepo *repositories.PriceRepository,
+	positionRepo *repositories.PositionRepository,
+	accountService *trading.AccountService,
+	orderRepo *repositories.PendingOrderRepository,
+	strategies *strategy.StrategyManager,
+	notifier *notifications.Notifier,
+	symbols []string,
+	start, end time.Time) {
+
+	handler := handlers.NewAnalysisHandler(strategies, priceRepo, positionRepo, accountService, orderRepo,
+		notifier, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	engine := backtest.NewEngine(priceRepo, strategies, backtest.DefaultConfig())
+	report, err := engine.Parity(context.Background(), handlers.NewParityAnalyzer(handler), start, end, symbols)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d candles compared, %d signals, %d divergences\n", report.Candles, report.Signals, len(report.Divergences))
+	for i, divergence := range report.Divergences {
+		if i == parityShown {
+			fmt.Printf("... and %d more\n", len(report.Divergences)-parityShown)
+			break
+		}
+		fmt.Printf("%s %s: backtest %s, live %s\n", divergence.Backtest.Time.UTC().Format("2006-01-02 15:04"),
+			divergence.Backtest.Symbol, divergence.Backtest, divergence.Live)
+	}
+	if len(report.Divergences) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runServer serves backtests over HTTP until interrupted
+func runServer(priceRepo *repositories.PriceRepository,
+	strategies *strategy.StrategyManager,
+	config backtestserver.Config,
+	addr string) {
+
+	server, err := backtestserver.NewServer(priceRepo, strategies, config)
+	if err != nil {
+		log.Fatal("Failed to start backtest server:", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	server.Serve(ctx, addr)
+	log.Println("Backtest server stopped")
+}
+
+// compareBacktestSides backtests both sides, long only and short only over the same period and prints them side by side
+func compareBacktestSides(priceRepo *repositories.PriceRepository,
+	strategies *strategy.StrategyManager,
+	config backtest.Config,
+	symbols []string,
+	startTime, endTime time.Time) {
+
+	sides := []string{strategy.DirectionBoth, models.PositionSideLong, models.PositionSideShort}
+	results := make([]*backtest.Results, len(sides))
+	for i, side := range sides {
+		log.Printf("Backtesting %s side(s)...", side)
+		config.Direction = side
+		result, err := backtest.NewEngine(priceRepo, strategies, config).RunBacktest(startTime, endTime, symbols)
+		if err != nil {
+			log.Fatal(err)
+		}
+		results[i] = result
+	}
+
+	fmt.Printf("\nSide Comparison: %s to %s\n", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Side\tTrades\tWin Rate\tTotal PnL\tAvg PnL\tProfit Factor\tMax Drawdown\tFinal Balance\t")
+	for i, side := range sides {
+		r := results[i]
+		total := 0.0
+		for _, trade := range r.Trades {
+			total += trade.PnL
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%.2f\t%.4f\t%.2f\t%.2f%%\t%.2f\t\n",
+			side, r.TotalTrades, r.WinRate*100, total, r.AveragePnL,
+			backtest.ProfitFactor(r.Trades), r.MaxDrawdown*100, r.FinalBalance)
+	}
+	w.Flush()
+	if config.Reversal.Enabled && !config.Entry.HedgeMode {
+		fmt.Println("Reversals only run in the both-sides backtest")
+	}
+}
+
+// sweepReversalMargins backtests the same period once per reversal confidence margin and prints the runs side by side
+func sweepReversalMargins(priceRepo *repositories.PriceRepository,
+	strategies *strategy.StrategyManager,
+	config backtest.Config,
+	margins []float64,
+	symbols []string,
+	startTime, endTime time.Time) {
+
+	if !config.Reversal.Enabled || config.Entry.HedgeMode || config.Direction != strategy.DirectionBoth {
+		log.Fatal("Sweeping the reversal margin needs reversals enabled on a both-sides backtest without hedge mode")
+	}
+
+	results := make([]*backtest.Results, len(margins))
+	for i, margin := range margins {
+		log.Printf("Backtesting reversal margin %.4f...", margin)
+		config.Reversal.ConfidenceMargin = margin
+		result, err := backtest.NewEngine(priceRepo, strategies, config).RunBacktest(startTime, endTime, symbols)
+		if err != nil {
+			log.Fatal(err)
+		}
+		results[i] = result
+	}
+
+	fmt.Printf("\nReversal Margin Sweep: %s to %s\n", startTime.Format("2006-01-02"), endTime.Format("2006-01-02"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Margin\tTrades\tReversals\tClosed Legs\tHeld\tReversed Legs\tNet Benefit\tWin Rate\tMax Drawdown\tFinal Balance\t")
+	for i, margin := range margins {
+		r := results[i]
+		fmt.Fprintf(w, "%.4f\t%d\t%d\t%.2f\t%.2f\t%.2f\t%+.2f\t%.2f%%\t%.2f%%\t%.2f\t\n",
+			margin, r.TotalTrades, r.Reversals.Reversals, r.Reversals.ClosedLegPnL, r.Reversals.HeldPnL,
+			r.Reversals.ReversedLegPnL, r.Reversals.NetBenefit, r.WinRate*100, r.MaxDrawdown*100, r.FinalBalance)
+	}
+	w.Flush()
+}
+
+// runDownload fetches the base timeframe candles of symbols missing between start and end
+func runDownload(priceRepo *repositories.PriceRepository, limiter *priceOperations.WeightLimiter, symbols []string, start, end time.Time) {
+	provider := dataProvider(priceRepo.Exchange(), limiter)
+	verifier := priceOperations.NewPriceVerifier(priceRepo, priceOperations.NewPriceFetcher(provider, limiter, nil))
+
+	log.Printf("Downloading %s %s candles from %s to %s...", provider.Exchange(), backtest.BaseTimeFrame, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	began := time.Now()
+	var failed []string
+	for i, symbol := range symbols {
+		if info, err := provider.SymbolInfo(context.Background(), symbol); err == nil && info == nil {
+			log.Printf("[%d/%d] %s is not listed on %s, skipped", i+1, len(symbols), symbol, provider.Exchange())
+			failed = append(failed, symbol)
+			continue
+		}
+		stored, err := verifier.Download(context.Background(), symbol, backtest.BaseTimeFrame, start, end)
+		if err != nil {
+			// One flaky symbol should not cost the others their download
+			log.Printf("[%d/%d] Failed to download %s: %v", i+1, len(symbols), symbol, err)
+			failed = append(failed, symbol)
+			continue
+		}
+		eta := time.Since(began) / time.Duration(i+1) * time.Duration(len(symbols)-i-1)
+		log.Printf("[%d/%d] %s: stored %d candles, ETA %s", i+1, len(symbols), symbol, stored, eta.Round(time.Second))
+	}
+	if len(failed) > 0 {
+		log.Fatalf("Failed to download %d of %d symbols: %s, run again to retry them", len(failed), len(symbols), strings.Join(failed, ", "))
+	}
+}
+
+// dataProvider returns the market data of exchange, Binance's through a client weighed against limiter
+func dataProvider(exchange string, limiter *priceOperations.WeightLimiter) priceOperations.ExchangeDataProvider {
+	client := priceOperations.NewFuturesClient(os.Getenv("BINANCE_API_KEY"), os.Getenv("BINANCE_SECRET_KEY"), limiter)
+	provider, err := priceOperations.NewDataProvider(exchange, client, limiter)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return provider
+}
+
+func runVerify(priceRepo *repositories.PriceRepository, limiter *priceOperations.WeightLimiter, fix bool) {
+	log.Println("Verifying stored price data...")
+
+	var fetcher *priceOperations.PriceFetcher
+	if fix {
+		fetcher = priceOperations.NewPriceFetcher(dataProvider(priceRepo.Exchange(), limiter), limiter, nil)
+	}
+
+	verifier := priceOperations.NewPriceVerifier(priceRepo, fetcher)
+	reports, err := verifier.Verify(context.Background(), fix)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("\nPrice Data Integrity:")
+	clean := 0
+	for _, report := range reports {
+		if !report.HasIssues() {
+			clean++
+			continue
+		}
+		fmt.Printf("%s %s: %d candles | Invalid: %d Duplicates: %d Gaps: %d\n",
+			report.Symbol,
+			report.TimeFrame,
+			report.Candles,
+			len(report.InvalidIDs),
+			len(report.DuplicateIDs),
+			len(report.Gaps))
+	}
+	fmt.Printf("%d of %d series clean\n", clean, len(reports))
+}
+
+func runFlatten(priceRepo *repositories.PriceRepository,
+	positionRepo *repositories.PositionRepository,
+	accountService *trading.AccountService,
+	orderRepo *repositories.PendingOrderRepository,
+	strategies *strategy.StrategyManager,
+	notifier *notifications.Notifier) {
+
+	log.Println("Closing all open positions...")
 
-	// Optional: Print detailed trade history to console
+	analysisHandler := handlers.NewAnalysisHandler(strategies, priceRepo, positionRepo, accountService, orderRepo,
+		notifier, trading.DefaultEntryConfig(), trading.DefaultReversalConfig())
+	report, err := analysisHandler.Flatten(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	fmt.Println("\nFlatten Results:")
+	for _, position := range report.Closed {
+		fmt.Printf("Closed %d: %s %s Entry: %.8f PnL: %.2f\n",
+			position.ID,
+			position.Symbol,
+			position.Side,
+			position.EntryPrice,
+			position.PnL)
+	}
+	for id, err := range report.Remaining {
+		fmt.Printf("Still open %d: %v\n", id, err)
+	}
+	fmt.Printf("Realized PnL: %.2f %s\n", report.RealizedPnL, accountService.QuoteAsset())
+
+	if len(report.Remaining) > 0 {
+		os.Exit(1)
+	}
+}
+
+func runAudit(balanceRepo *repositories.BalanceRepository,
+	transactionRepo *repositories.TransactionRepository,
+	quoteAsset string,
+	days int) {
+
+	accounts, err := balanceRepo.Accounts()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reconciled := true
+	for _, account := range accounts {
+		if !auditAccount(balanceRepo.ForAccount(account), transactionRepo.ForAccount(account), quoteAsset, days) {
+			reconciled = false
+		}
+	}
+	if !reconciled {
+		os.Exit(1)
+	}
+}
+
+// auditAccount prints the daily PnL and ledger reconciliation of one account, reporting whether it reconciled
+func auditAccount(balanceRepo *repositories.BalanceRepository,
+	transactionRepo *repositories.TransactionRepository,
+	quoteAsset string,
+	days int) bool {
+
+	log.Printf("Auditing balance ledger of %s...", balanceRepo.Account())
+
+	auditor := ledger.NewAuditor(balanceRepo, transactionRepo, handlers.InitialBalance)
+	report, err := auditor.Audit(quoteAsset)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	endTime := time.Now()
+	daily, err := transactionRepo.GetDailyPnL(quoteAsset, endTime.AddDate(0, 0, -days), endTime)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("\n[%s] Daily PnL (last %d days):\n", balanceRepo.Account(), days)
+	for _, day := range daily {
+		fmt.Printf("%s: %.2f %s over %d trades\n", day.Day.Format("2006-01-02"), day.PnL, quoteAsset, day.Trades)
+	}
+
+	fmt.Printf("\n[%s] Ledger Reconciliation:\n", balanceRepo.Account())
+	fmt.Printf("Initial Balance: %.8f %s\n", report.InitialBalance, quoteAsset)
+	fmt.Printf("Transactions: %.8f %s\n", report.TransactionSum, quoteAsset)
+	fmt.Printf("Expected Balance: %.8f %s\n", report.Expected, quoteAsset)
+	fmt.Printf("Actual Balance: %.8f %s\n", report.Actual, quoteAsset)
+
+	if !report.Reconciled() {
+		fmt.Printf("MISMATCH: balance is off by %.8f %s\n", report.Difference(), quoteAsset)
+		return false
+	}
+	fmt.Println("Balance reconciled")
+	return true
+}
+
+// runControl sends action for account to the running bot's dashboard, at CONTROL_URL or
+// on localhost at DASHBOARD_PORT, authenticated with DASHBOARD_CONTROL_TOKEN
+func runControl(action, account, reason string) {
+	switch action {
+	case "pause", "resume", "flatten":
+	default:
+		log.Fatal("Invalid action. Use 'pause', 'resume' or 'flatten'")
+	}
+
+	base := os.Getenv("CONTROL_URL")
+	if base == "" {
+		port := os.Getenv("DASHBOARD_PORT")
+		if port == "" {
+			log.Fatal("Set CONTROL_URL or DASHBOARD_PORT to reach the running bot")
+		}
+		base = "http://localhost:" + port
+	}
+	query := url.Values{"account": {account}, "reason": {reason}}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(base, "/")+"/control/"+action+"?"+query.Encode(), nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("DASHBOARD_CONTROL_TOKEN"))
+
+	client := &http.Client{Timeout: 2 * time.Minute} // Flattening closes every position before answering
+	resp, err := client.Do(req)
+	if err != nil {
+		log.Fatal("Failed to reach the bot: ", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("%s failed: %s %s", action, resp.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Println(strings.TrimSpace(string(body)))
+}
+
+// runResume lifts symbol's performance suspension, or the account's equity stop when no symbol is given
+func runResume(positionRepo *repositories.PositionRepository,
+	suspensionRepo *repositories.SymbolSuspensionRepository,
+	stateRepo *repositories.BotStateRepository,
+	notifier *notifications.Notifier,
+	symbol string) {
+
+	if symbol == "" {
+		if err := risk.NewEquityStop(risk.DefaultEquityStopConfig(), stateRepo).Reset(); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Equity stop of %s lifted\n", stateRepo.Account())
+		return
+	}
+
+	tracker := risk.NewSymbolPerformanceTracker(positionRepo, suspensionRepo, notifier, risk.DefaultPerformanceConfig())
+	if err := tracker.Reset(symbol, time.Now()); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s resumed\n", symbol)
+}
+
+func runCompare(pathA, pathB string, tolerance time.Duration, csvPath string) {
+	a, err := backtest.LoadResults(pathA)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, err := backtest.LoadResults(pathB)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	diff := backtest.Compare(a, b, tolerance)
+
+	fmt.Printf("\nA: %s (config %s)\nB: %s (config %s)\n", pathA, configLabel(diff.ConfigA), pathB, configLabel(diff.ConfigB))
+	if len(diff.ConfigChanges) > 0 {
+		fmt.Println("\nConfig changes from A to B:")
+		for _, change := range diff.ConfigChanges {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Metric\tA\tB\tDelta\t")
+	for _, m := range diff.Metrics {
+		fmt.Fprintf(w, "%s\t%.4f\t%.4f\t%+.4f\t\n", m.Name, m.A, m.B, m.Delta)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Fprintln(w, "Symbol\tTrades A\tTrades B\tPnL A\tPnL B\tPnL Delta\tWin Rate A\tWin Rate B\tMAE A\tMAE B\tMFE A\tMFE B\tAvg R A\tAvg R B\t")
+	for _, s := range diff.Symbols {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%.2f\t%+.2f\t%.2f%%\t%.2f%%\t%.2fR\t%.2fR\t%.2fR\t%.2fR\t%.2fR\t%.2fR\t\n",
+			s.Symbol, s.TradesA, s.TradesB, s.PnLA, s.PnLB, s.PnLB-s.PnLA, s.WinRateA*100, s.WinRateB*100,
+			s.ExcursionsA.MAE.P50, s.ExcursionsB.MAE.P50, s.ExcursionsA.MFE.P50, s.ExcursionsB.MFE.P50,
+			s.AverageRA, s.AverageRB)
+	}
+	w.Flush()
+
+	fmt.Printf("\nMatched trades: %d (tolerance %s)\n", diff.Matched, tolerance)
+	fmt.Printf("Only in A: %d\n", len(diff.OnlyInA))
+	for _, t := range diff.OnlyInA {
+		fmt.Printf("  %s %s %s PnL: %.2f\n", t.EntryTime.Format("2006-01-02 15:04"), t.Symbol, t.Side, t.PnL)
+	}
+	fmt.Printf("Only in B: %d\n", len(diff.OnlyInB))
+	for _, t := range diff.OnlyInB {
+		fmt.Printf("  %s %s %s PnL: %.2f\n", t.EntryTime.Format("2006-01-02 15:04"), t.Symbol, t.Side, t.PnL)
+	}
+
+	if csvPath != "" {
+		if err := writeCompareCSV(csvPath, diff); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("CSV written to %s", csvPath)
+	}
+}
+
+// configLabel shows a run's config hash, runs saved before provenance was recorded have none
+func configLabel(hash string) string {
+	if hash == "" {
+		return "unknown"
+	}
+	return hash
+}
+
+func writeCompareCSV(path string, diff *backtest.RunDiff) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create csv: %v", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	w.Write([]string{"section", "name", "a", "b", "delta"})
+	for _, m := range diff.Metrics {
+		w.Write([]string{"metric", m.Name, formatFloat(m.A), formatFloat(m.B), formatFloat(m.Delta)})
+	}
+	for _, s := range diff.Symbols {
+		w.Write([]string{"trades", s.Symbol, strconv.Itoa(s.TradesA), strconv.Itoa(s.TradesB), strconv.Itoa(s.TradesB - s.TradesA)})
+		w.Write([]string{"pnl", s.Symbol, formatFloat(s.PnLA), formatFloat(s.PnLB), formatFloat(s.PnLB - s.PnLA)})
+		w.Write([]string{"win_rate", s.Symbol, formatFloat(s.WinRateA), formatFloat(s.WinRateB), formatFloat(s.WinRateB - s.WinRateA)})
+		w.Write([]string{"median_mae_r", s.Symbol, formatFloat(s.ExcursionsA.MAE.P50), formatFloat(s.ExcursionsB.MAE.P50), formatFloat(s.ExcursionsB.MAE.P50 - s.ExcursionsA.MAE.P50)})
+		w.Write([]string{"median_mfe_r", s.Symbol, formatFloat(s.ExcursionsA.MFE.P50), formatFloat(s.ExcursionsB.MFE.P50), formatFloat(s.ExcursionsB.MFE.P50 - s.ExcursionsA.MFE.P50)})
+		w.Write([]string{"average_r", s.Symbol, formatFloat(s.AverageRA), formatFloat(s.AverageRB), formatFloat(s.AverageRB - s.AverageRA)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// printRStats prints the R outcome distribution of a run
+func printRStats(r trading.RStats) {
+	fmt.Printf("R: total %.2fR, average %.2fR, %.2f%% at 2R or better over %d trades (%d without an initial stop)\n",
+		r.Total, r.Average, r.AtLeast2R*100, r.Trades, r.Excluded)
+	for _, b := range r.Buckets {
+		fmt.Printf("  %s: %d\n", b.Label, b.Count)
+	}
+}
+
+// printConfidenceBuckets prints a run's performance by entry confidence
+func printConfidenceBuckets(buckets []trading.ConfidenceBucket) {
+	if len(buckets) == 0 {
+		return
+	}
+	fmt.Println("By confidence:")
+	for _, b := range buckets {
+		fmt.Printf("  %s: %d trades, %.2f%% won, %.2f USDT, average %.2fR, size x%.2f\n",
+			b.Label, b.Trades, b.WinRate*100, b.PnL, b.AverageR, b.SizeMultiplier)
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', 8, 64)
+}
+
+// runExportLive converts the account's live trading over a period into backtest results, writes them
+// like a backtest's and, given a backtest of the same period, reports where the two diverged
+func runExportLive(positionRepo *repositories.PositionRepository,
+	transactionRepo *repositories.TransactionRepository,
+	from, to string,
+	days int,
+	out, backtestPath string,
+	tolerance time.Duration,
+	ratioConfig backtest.RatioConfig) {
+
+	const usage = "Usage: -mode export-live [-from YYYY-MM-DD] [-to YYYY-MM-DD] [-out file] [-backtest results.json]"
+
+	end := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if to != "" {
+		parsed, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			log.Fatal(usage)
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, -days)
+	if from != "" {
+		parsed, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			log.Fatal(usage)
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		log.Fatal("-from must be before -to")
+	}
+
+	positions, err := positionRepo.FindClosedBetween(start, end)
+	if err != nil {
+		log.Fatal("Failed to load positions:", err)
+	}
+	transactions, err := transactionRepo.GetTransactionsByTimeRange(start, end)
+	if err != nil {
+		log.Fatal("Failed to load transactions:", err)
+	}
+
+	live := backtesting.FromLive(positions, transactions, handlers.InitialBalance, start, end, ratioConfig)
+
+	fmt.Printf("\nLive Results (%s): %s to %s\n", positionRepo.Account(), start.Format("2006-01-02"), end.Format("2006-01-02"))
+	fmt.Printf("Total Trades: %d\n", live.TotalTrades)
+	fmt.Printf("Win Rate: %.2f%%\n", live.WinRate*100)
+	fmt.Printf("Average PnL: %.2f USDT\n", live.AveragePnL)
+	fmt.Printf("Max Drawdown: %.2f%%\n", live.MaxDrawdown*100)
+	fmt.Printf("Final Balance: %.2f USDT\n", live.FinalBalance)
+	printRatios(live)
+
+	if out != "" {
+		if err := live.Export(out); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Results written to %s", out)
+	}
+
+	if backtestPath == "" {
+		return
+	}
+	backtested, err := backtest.LoadResults(backtestPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shortfall := backtesting.CompareLive(live, backtested, start, end, tolerance)
+
+	fmt.Printf("\nLive vs %s\n\n", backtestPath)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Symbol\tTrades Backtest\tTrades Live\tDelta\tPnL Backtest\tPnL Live\tPnL Delta\t")
+	for _, s := range shortfall.Symbols {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%+d\t%.2f\t%.2f\t%+.2f\t\n",
+			s.Symbol, s.BacktestTrades, s.LiveTrades, s.TradeDelta(), s.BacktestPnL, s.LivePnL, s.PnLDelta())
+	}
+	w.Flush()
+
+	fmt.Printf("\nMatched trades: %d (tolerance %s)\n", shortfall.Matched, tolerance)
+	fmt.Printf("Backtest only: %d\n", len(shortfall.BacktestOnly))
+	for _, t := range shortfall.BacktestOnly {
+		fmt.Printf("  %s %s %s PnL: %.2f\n", t.EntryTime.Format("2006-01-02 15:04"), t.Symbol, t.Side, t.PnL)
+	}
+	fmt.Printf("Live only: %d\n", len(shortfall.LiveOnly))
+	for _, t := range shortfall.LiveOnly {
+		fmt.Printf("  %s %s %s PnL: %.2f\n", t.EntryTime.Format("2006-01-02 15:04"), t.Symbol, t.Side, t.PnL)
+	}
+}
+
+// runTag journals a closed position from the command line, for when the dashboard is not running
+// runPrune deletes candles past their retention, or lists them with dryRun
+func runPrune(retention *priceOperations.RetentionService, dryRun bool) {
+	results, err := retention.Prune(time.Now(), dryRun)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Symbol\tTimeframe\tBefore\t%s\t\n", verb)
+	var total int64
+	for _, result := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t\n", result.Symbol, result.TimeFrame, result.Cutoff.UTC().Format("2006-01-02 15:04"), result.Rows)
+		total += result.Rows
+	}
+	w.Flush()
+	fmt.Printf("%s %d candles\n", verb, total)
+}
+
+func runTag(positionRepo *repositories.PositionRepository, id uint, add, remove []string, notes string, setNotes bool) {
+	if id == 0 || (len(add) == 0 && len(remove) == 0 && !setNotes) {
+		log.Fatal("Usage: -mode tag -position <ID> [-tags a,b] [-untag c] [-notes text]")
+	}
+
+	for _, tag := range add {
+		if err := positionRepo.AddTag(id, tag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, tag := range remove {
+		if err := positionRepo.RemoveTag(id, tag); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if setNotes {
+		if err := positionRepo.SetNotes(id, notes); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	tags, err := positionRepo.Tags([]uint{id})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Position %d tags: %s\n", id, strings.Join(tags[id], ", "))
+}
+
+func runReport(priceRepo *repositories.PriceRepository,
+	positionRepo *repositories.PositionRepository,
+	transactionRepo *repositories.TransactionRepository,
+	signalRepo *repositories.SignalRepository,
+	configRepo *repositories.ConfigSnapshotRepository,
+	notifier *notifications.Notifier,
+	quoteAsset string,
+	date, kind string,
+	excludeTags []string,
+	send bool) {
+
+	now := time.Now().UTC()
+	day := now.AddDate(0, 0, -1)
+	if date != "" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			log.Fatal("Usage: -mode report [-date YYYY-MM-DD] [-period day|week] [-send]")
+		}
+		day = parsed
+	}
+
+	period, err := reports.NewPeriod(kind, day)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	reporter := reports.NewReportService(priceRepo, positionRepo, transactionRepo, signalRepo, notifier, handlers.InitialBalance, quoteAsset)
+	reporter.UseConfigSnapshots(configRepo)
+	if err := reporter.ExcludeTags(excludeTags); err != nil {
+		log.Fatal(err)
+	}
+	report, err := reporter.Build(context.Background(), period, now)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(report.Title())
+	fmt.Print(reports.RenderText(report))
+
+	if send {
+		if err := reporter.Send(report); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
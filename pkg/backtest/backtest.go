@@ -0,0 +1,209 @@
+// Package backtest is the public face of the backtest engine
+//
+// An Engine replays candles from any PriceSource through a strategy.StrategyManager.
+// Everything is configured through Config; nothing here reads the environment or a database.
+package backtest
+
+import (
+	"CryptoTradeBot/internal/backtesting"
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/strategy"
+	"CryptoTradeBot/internal/services/trading"
+	"io"
+	"time"
+)
+
+type (
+	// Engine runs one backtest, create a new one per run
+	Engine = backtesting.Backtest
+	Config = backtesting.Config
+
+	// Results are what a run produced, saved and loaded as JSON
+	Results        = backtesting.BacktestResults
+	Trade          = backtesting.Trade
+	EquityPoint    = backtesting.EquityPoint
+	ExcursionStats = backtesting.ExcursionStats
+	ReversalStats  = backtesting.ReversalStats
+	RStats         = trading.RStats
+	RunDiff        = backtesting.RunDiff
+	SkippedSymbol  = backtesting.SkippedSymbol
+	Progress       = backtesting.Progress
+	RatioConfig    = backtesting.RatioConfig
+	Ratios         = backtesting.Ratios
+
+	// TradeView filters and sorts trades for the interactive summary
+	TradeView    = backtesting.TradeView
+	TradeSummary = backtesting.TradeSummary
+
+	// PriceSource supplies the candles a run replays
+	PriceSource = backtesting.PriceSource
+	SliceSource = backtesting.SliceSource
+	Price       = models.Price
+
+	// Universe limits entries to the symbols selected at the time
+	Universe = backtesting.Universe
+
+	EntryConfig     = trading.EntryConfig
+	ReversalConfig  = trading.ReversalConfig
+	SameBarPolicy   = backtesting.SameBarPolicy
+	ExecutionTiming = backtesting.ExecutionTiming
+
+	// Liquidity caps fills at a share of candle volume
+	LiquidityConfig = backtesting.LiquidityConfig
+	LiquidityStats  = backtesting.LiquidityStats
+
+	// Parity compares the backtest's analysis with a live decision path on the same candles
+	LiveAnalyzer     = backtesting.LiveAnalyzer
+	ParityReport     = backtesting.ParityReport
+	ParityDecision   = backtesting.ParityDecision
+	ParityDivergence = backtesting.ParityDivergence
+
+	// Phases let callers hook into the per-candle processing order
+	Phase       = backtesting.Phase
+	PhaseHook   = backtesting.PhaseHook
+	CandleState = backtesting.CandleState
+)
+
+// Initial balance, leverage and margin per trade every run starts from
+const (
+	InitialBalance = backtesting.InitialBalance
+	Leverage       = backtesting.Leverage
+	FixedSize      = backtesting.FixedSize
+)
+
+// BaseTimeFrame is the timeframe a run steps through; higher ones are resampled from it
+const BaseTimeFrame = backtesting.BaseTimeFrame
+
+// Exit taken when a candle reaches both take profit and stop loss
+const (
+	SameBarWorstCase     = backtesting.SameBarWorstCase
+	SameBarBestCase      = backtesting.SameBarBestCase
+	SameBarProbe         = backtesting.SameBarProbe
+	SameBarProbabilistic = backtesting.SameBarProbabilistic
+)
+
+// When market entries fill
+const (
+	TimingClose    = backtesting.TimingClose
+	TimingNextOpen = backtesting.TimingNextOpen
+)
+
+// What becomes of the part of an entry beyond its candle's volume cap
+const (
+	LiquidityClip   = backtesting.LiquidityClip
+	LiquiditySpread = backtesting.LiquiditySpread
+)
+
+// Entry order types
+const (
+	EntryModeMarket = trading.EntryModeMarket
+	EntryModeLimit  = trading.EntryModeLimit
+)
+
+// Phases in their default order
+const (
+	PhaseProtectiveExits = backtesting.PhaseProtectiveExits
+	PhaseTimeExits       = backtesting.PhaseTimeExits
+	PhaseReversals       = backtesting.PhaseReversals
+	PhaseEntries         = backtesting.PhaseEntries
+	PhaseEquityMark      = backtesting.PhaseEquityMark
+)
+
+const (
+	DefaultExitSlippage = backtesting.DefaultExitSlippage
+	DefaultGapTolerance = backtesting.DefaultGapTolerance
+	DefaultSeed         = backtesting.DefaultSeed
+
+	DefaultMaxParticipation = backtesting.DefaultMaxParticipation
+	DefaultLiquidityCandles = backtesting.DefaultLiquidityCandles
+	ProbeTimeFrame          = backtesting.ProbeTimeFrame
+)
+
+// DumpColumns is the header row Engine.Dump writes, documented on backtesting.DumpColumns
+var DumpColumns = backtesting.DumpColumns
+
+// DefaultConfig returns the default backtest settings
+func DefaultConfig() Config {
+	return backtesting.DefaultConfig()
+}
+
+// DefaultLiquidityConfig returns a 5% participation cap clipping what is left over
+func DefaultLiquidityConfig() LiquidityConfig {
+	return backtesting.DefaultLiquidityConfig()
+}
+
+// DefaultEntryConfig returns market entries
+func DefaultEntryConfig() EntryConfig {
+	return trading.DefaultEntryConfig()
+}
+
+// DefaultReversalConfig returns the default reversal guards
+func DefaultReversalConfig() ReversalConfig {
+	return trading.DefaultReversalConfig()
+}
+
+// NewEngine creates an Engine replaying source through strategies with config
+func NewEngine(source PriceSource, strategies *strategy.StrategyManager, config Config) *Engine {
+	return backtesting.NewBacktestWithConfig(source, strategies, config)
+}
+
+// NewSliceSource creates an in-memory PriceSource from candles of any symbols and timeframes
+func NewSliceSource(prices []Price) *SliceSource {
+	return backtesting.NewSliceSource(prices)
+}
+
+// ParseSameBarPolicy validates a same-bar policy name
+func ParseSameBarPolicy(value string) (SameBarPolicy, error) {
+	return backtesting.ParseSameBarPolicy(value)
+}
+
+// ParsePeriod returns the period between from and to, each YYYY-MM-DD in UTC or RFC3339
+// A missing to is now and a missing from is days before to
+func ParsePeriod(from, to string, days int) (time.Time, time.Time, error) {
+	return backtesting.ParsePeriod(from, to, days)
+}
+
+// ParseExecutionTiming validates an execution timing name
+func ParseExecutionTiming(value string) (ExecutionTiming, error) {
+	return backtesting.ParseExecutionTiming(value)
+}
+
+// LoadResults reads results written by Save
+func LoadResults(path string) (*Results, error) {
+	return backtesting.LoadResults(path)
+}
+
+// Compare diffs two runs, pairing trades with the same symbol whose entries are within tolerance
+func Compare(a, b *Results, tolerance time.Duration) *RunDiff {
+	return backtesting.Compare(a, b, tolerance)
+}
+
+// ProfitFactor returns gross profit over gross loss, +Inf when nothing was lost
+func ProfitFactor(trades []Trade) float64 {
+	return backtesting.ProfitFactor(trades)
+}
+
+// RStatsOf returns the R distribution of trades, counting those without an R as excluded
+func RStatsOf(trades []Trade) RStats {
+	return backtesting.RStatsOf(trades)
+}
+
+// SizeGuards counts the trades whose size each sizing guard changed
+func SizeGuards(trades []Trade) map[string]int {
+	return backtesting.SizeGuards(trades)
+}
+
+// SummarizeTrades totals trades, counting those that made money as wins
+func SummarizeTrades(trades []Trade) TradeSummary {
+	return backtesting.SummarizeTrades(trades)
+}
+
+// Browse runs the interactive summary of results, reading commands from in and writing to out
+func Browse(results *Results, in io.Reader, out io.Writer) error {
+	return backtesting.Browse(results, in, out)
+}
+
+// DefaultRatioConfig measures against a zero risk-free rate and needs a month of daily returns
+func DefaultRatioConfig() RatioConfig {
+	return backtesting.DefaultRatioConfig()
+}
@@ -0,0 +1,71 @@
+// Package indicators is the public face of the bot's technical indicators
+//
+// Every service is stateless apart from IndicatorState and takes its periods as arguments.
+package indicators
+
+import "CryptoTradeBot/internal/services/indicators"
+
+type (
+	ATRService        = indicators.ATRService
+	EMAService        = indicators.EMAService
+	RSIService        = indicators.RSIService
+	MACDService       = indicators.MACDService
+	MACDResult        = indicators.MACDResult
+	SuperTrendService = indicators.SuperTrendService
+	SuperTrendResult  = indicators.SuperTrendResult
+	IchimokuService   = indicators.IchimokuService
+	IchimokuResult    = indicators.IchimokuResult
+
+	// IndicatorState updates EMA, RSI and MACD one candle at a time
+	IndicatorState = indicators.IndicatorState
+	Periods        = indicators.Periods
+	Snapshot       = indicators.Snapshot
+)
+
+// SuperTrend directions
+const (
+	SuperTrendDown = indicators.SuperTrendDown
+	SuperTrendUp   = indicators.SuperTrendUp
+)
+
+// Standard Ichimoku periods
+const (
+	IchimokuTenkan       = indicators.IchimokuTenkan
+	IchimokuKijun        = indicators.IchimokuKijun
+	IchimokuSenkouB      = indicators.IchimokuSenkouB
+	IchimokuDisplacement = indicators.IchimokuDisplacement
+)
+
+// Ichimoku biases
+const (
+	IchimokuBearish = indicators.IchimokuBearish
+	IchimokuNeutral = indicators.IchimokuNeutral
+	IchimokuBullish = indicators.IchimokuBullish
+)
+
+func NewATRService() *ATRService               { return indicators.NewATRService() }
+func NewEMAService() *EMAService               { return indicators.NewEMAService() }
+func NewRSIService() *RSIService               { return indicators.NewRSIService() }
+func NewMACDService() *MACDService             { return indicators.NewMACDService() }
+func NewSuperTrendService() *SuperTrendService { return indicators.NewSuperTrendService() }
+func NewIchimokuService() *IchimokuService     { return indicators.NewIchimokuService() }
+
+// NewIndicatorState creates an IndicatorState tracking the given periods
+func NewIndicatorState(periods Periods) *IndicatorState {
+	return indicators.NewIndicatorState(periods)
+}
+
+// DefaultPeriods returns EMA 8/21, RSI 14 and MACD 12/26/9
+func DefaultPeriods() Periods {
+	return indicators.DefaultPeriods()
+}
+
+// Valid returns the part of values from firstValid on, nil when there is none
+func Valid(values []float64, firstValid int) []float64 {
+	return indicators.Valid(values, firstValid)
+}
+
+// Align trims tail-aligned series to the length of the shortest, nil when any is empty
+func Align(series ...[]float64) [][]float64 {
+	return indicators.Align(series...)
+}
@@ -0,0 +1,139 @@
+// Package strategy is the public face of the bot's strategies and their parameters
+//
+// Managers are built from parameters or a config file path given by the caller; nothing
+// here reads the environment.
+package strategy
+
+import (
+	"CryptoTradeBot/internal/models"
+	"CryptoTradeBot/internal/services/analysis"
+	"CryptoTradeBot/internal/services/strategy"
+)
+
+type (
+	// StrategyManager picks the strategy and parameters for each symbol
+	StrategyManager = strategy.StrategyManager
+
+	// Params is one strategy parameter set as written in the config file
+	Params     = strategy.Params
+	FileConfig = strategy.FileConfig
+
+	// Settings is every parameter set a manager runs, as recorded with results
+	Settings = strategy.Settings
+
+	// Strategy turns a window of candles into an entry decision
+	Strategy = strategy.Strategy
+	Factory  = strategy.Factory
+
+	// AnalysisConfig holds the tunable settings a Params embeds
+	AnalysisConfig = analysis.Config
+	AnalysisResult = analysis.AnalysisResult
+	Confluence     = analysis.Confluence
+
+	// Volatility is the realized volatility and regime of one timeframe, as reported on results,
+	// measured with the VolatilityParams of each timeframe in a VolatilitySet
+	Volatility       = analysis.Volatility
+	VolatilityParams = analysis.VolatilityParams
+	VolatilitySet    = analysis.VolatilitySet
+
+	// Price is one OHLCV candle
+	Price = models.Price
+
+	// Position is an open position handed to a strategy's ManagePosition, which may return
+	// a StopAdjustment to move its stop loss and take profit
+	Position       = models.Position
+	StopAdjustment = analysis.StopAdjustment
+)
+
+// DirectionBoth analyzes entries on either side
+const DirectionBoth = strategy.DirectionBoth
+
+// DefaultStrategy is used when a parameter set does not name a strategy
+const DefaultStrategy = strategy.DefaultStrategy
+
+// Closest a stop may sit to entry: MinStopTicks price ticks, and never under MinStopFraction of entry
+const (
+	MinStopTicks    = strategy.MinStopTicks
+	MinStopFraction = strategy.MinStopFraction
+)
+
+// Target modes
+const (
+	TargetModePercent    = analysis.TargetModePercent
+	TargetModeVolatility = analysis.TargetModeVolatility
+)
+
+// Volatility regimes
+const (
+	VolatilityLow    = analysis.VolatilityLow
+	VolatilityNormal = analysis.VolatilityNormal
+	VolatilityHigh   = analysis.VolatilityHigh
+)
+
+// Timeframes
+const (
+	TimeFrame1m  = models.PriceTimeFrame1m
+	TimeFrame5m  = models.PriceTimeFrame5m
+	TimeFrame15m = models.PriceTimeFrame15m
+	TimeFrame1h  = models.PriceTimeFrame1h
+	TimeFrame4h  = models.PriceTimeFrame4h
+	TimeFrame1d  = models.PriceTimeFrame1d
+)
+
+// DefaultParams returns the built-in parameter set
+func DefaultParams() Params {
+	return strategy.DefaultParams()
+}
+
+// DefaultAnalysisConfig returns the built-in analysis settings
+func DefaultAnalysisConfig() AnalysisConfig {
+	return analysis.DefaultConfig()
+}
+
+// NewStrategyManager creates a manager that uses the same parameters for every symbol
+func NewStrategyManager(params Params) (*StrategyManager, error) {
+	return strategy.NewStrategyManager(params)
+}
+
+// NewStrategyManagerWithOverrides creates a manager with per-symbol parameter sets
+// A nil symbols accepts overrides for any symbol
+func NewStrategyManagerWithOverrides(defaults Params, overrides map[string]Params, symbols []string) (*StrategyManager, error) {
+	return strategy.NewStrategyManagerWithOverrides(defaults, overrides, symbols)
+}
+
+// LoadStrategyManager reads the strategy config file at path, see FileConfig
+// An empty path uses DefaultParams for every symbol
+func LoadStrategyManager(path string, symbols []string) (*StrategyManager, error) {
+	return strategy.LoadStrategyManager(path, symbols)
+}
+
+// WindowCandles converts per-timeframe warm-up counts into candles of the base timeframe
+func WindowCandles(warmUp map[string]int, base string) int {
+	return strategy.WindowCandles(warmUp, base)
+}
+
+// Register makes a strategy available by name in configuration files
+func Register(name string, factory Factory) {
+	strategy.Register(name, factory)
+}
+
+// New builds the named strategy
+func New(name string, params AnalysisConfig) (Strategy, error) {
+	return strategy.New(name, params)
+}
+
+// Names lists the registered strategies
+func Names() []string {
+	return strategy.Names()
+}
+
+// ValidateResult checks a signal's direction, confidence and the ordering of its entry, stop and target
+// before it is executed, see SetTickSizes for the stop distance
+func ValidateResult(result *AnalysisResult) error {
+	return strategy.ValidateResult(result)
+}
+
+// SetTickSizes records the symbols' price tick sizes, the stop must sit MinStopTicks from entry
+func SetTickSizes(sizes map[string]float64) {
+	strategy.SetTickSizes(sizes)
+}